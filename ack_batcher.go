@@ -0,0 +1,187 @@
+package gorabbit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AckBatchConfig enables the acknowledgement of deliveries in batches, using a single multiple acknowledgement for
+// several deliveries. Deliveries are only acknowledged once all deliveries received before them are settled, so that
+// concurrent processing never acknowledges a delivery that is still being processed.
+// Acknowledgements that are still pending when the channel is lost are dropped, and the broker redelivers the deliveries.
+type AckBatchConfig struct {
+	// Size is the number of processed deliveries that triggers a batch acknowledgement.
+	Size int
+
+	// Interval is the maximum delay before pending acknowledgements are flushed, whatever their number.
+	Interval time.Duration
+}
+
+// ackBatcher is an amqp.Acknowledger that delays and groups positive acknowledgements.
+// Negative acknowledgements and rejections are forwarded immediately.
+type ackBatcher struct {
+	// acknowledger is the native acknowledger, usually the amqp.Channel.
+	acknowledger amqp.Acknowledger
+
+	// size is the number of completed deliveries that triggers a flush.
+	size int
+
+	// unsettled holds the tags of the deliveries that were received but neither acknowledged nor rejected yet.
+	unsettled map[uint64]struct{}
+
+	// completed holds the tags of the deliveries waiting for their acknowledgement.
+	completed map[uint64]struct{}
+
+	// mutex protects the batcher from concurrent processing.
+	mutex sync.Mutex
+
+	// logger logs events.
+	logger Logger
+}
+
+// newAckBatcher instantiates a new ackBatcher and launches its periodical flush until the context is canceled.
+func newAckBatcher(ctx context.Context, acknowledger amqp.Acknowledger, config AckBatchConfig, logger Logger) *ackBatcher {
+	batcher := &ackBatcher{
+		acknowledger: acknowledger,
+		size:         config.Size,
+		unsettled:    make(map[uint64]struct{}),
+		completed:    make(map[uint64]struct{}),
+		logger:       logger,
+	}
+
+	if config.Interval > 0 {
+		go func() {
+			ticker := time.NewTicker(config.Interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					batcher.flush()
+				}
+			}
+		}()
+	}
+
+	return batcher
+}
+
+// track registers a received delivery, it must be called before the delivery is processed.
+func (b *ackBatcher) track(tag uint64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.unsettled[tag] = struct{}{}
+}
+
+// Ack registers the delivery as completed, and flushes the pending acknowledgements if the batch is full.
+func (b *ackBatcher) Ack(tag uint64, multiple bool) error {
+	if multiple {
+		return b.acknowledger.Ack(tag, multiple)
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.unsettled, tag)
+
+	b.completed[tag] = struct{}{}
+
+	if len(b.completed) >= b.size {
+		return b.flushLocked()
+	}
+
+	return nil
+}
+
+// Nack settles the delivery and forwards the negative acknowledgement.
+func (b *ackBatcher) Nack(tag uint64, multiple bool, requeue bool) error {
+	b.settle(tag)
+
+	return b.acknowledger.Nack(tag, multiple, requeue)
+}
+
+// Reject settles the delivery and forwards the rejection.
+func (b *ackBatcher) Reject(tag uint64, requeue bool) error {
+	b.settle(tag)
+
+	return b.acknowledger.Reject(tag, requeue)
+}
+
+// settle removes a delivery from the unsettled ones.
+func (b *ackBatcher) settle(tag uint64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	delete(b.unsettled, tag)
+}
+
+// flush acknowledges all completed deliveries that can safely be acknowledged.
+func (b *ackBatcher) flush() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if err := b.flushLocked(); err != nil {
+		b.logger.Error(err, "Could not acknowledge batch")
+	}
+}
+
+// flushLocked acknowledges, with a single multiple acknowledgement, all completed deliveries that were received before
+// the oldest unsettled delivery. The mutex must be held.
+func (b *ackBatcher) flushLocked() error {
+	if len(b.completed) == 0 {
+		return nil
+	}
+
+	// We find the oldest delivery that is still being processed, which must not be acknowledged.
+	var oldestUnsettled uint64
+
+	for tag := range b.unsettled {
+		if oldestUnsettled == 0 || tag < oldestUnsettled {
+			oldestUnsettled = tag
+		}
+	}
+
+	// We find the newest completed delivery received before it.
+	var lastTag uint64
+
+	for tag := range b.completed {
+		if (oldestUnsettled == 0 || tag < oldestUnsettled) && tag > lastTag {
+			lastTag = tag
+		}
+	}
+
+	if lastTag == 0 {
+		return nil
+	}
+
+	if err := b.acknowledger.Ack(lastTag, true); err != nil {
+		return err
+	}
+
+	for tag := range b.completed {
+		if tag <= lastTag {
+			delete(b.completed, tag)
+		}
+	}
+
+	b.logger.Debug("Batch acknowledged", LogField{Key: "lastTag", Value: lastTag})
+
+	return nil
+}
+
+// flushAckBatch acknowledges the completed deliveries of the current consumption of the channel, if batched.
+func (c *amqpChannel) flushAckBatch() {
+	c.consumptionMutex.Lock()
+	batcher := c.ackBatcher
+	c.consumptionMutex.Unlock()
+
+	if batcher != nil {
+		batcher.flush()
+	}
+}
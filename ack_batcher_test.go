@@ -0,0 +1,64 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_AckBatch_Size(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{
+		PrefetchCount: 10,
+		AckBatch:      &gorabbit.AckBatchConfig{Size: 3, Interval: time.Hour},
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created":  func([]byte) error { return nil },
+			"event.rejected": func([]byte) error { return gorabbit.DeadLetter(errors.New("rejected")) },
+		},
+	})
+
+	server.deliver("events", "event.created", "first", "second")
+	server.deliver("events", "event.rejected", "rejected")
+	server.deliver("events", "event.created", "third", "fourth")
+
+	// The rejection is sent right away, the three first acknowledgements with a single multiple one.
+	require.Eventually(t, func() bool { return len(server.settled()) == 4 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, server.ackCount())
+	assert.Equal(t, []fakeSettlement{
+		{Body: "rejected"},
+		{Body: "first", Acked: true},
+		{Body: "second", Acked: true},
+		{Body: "third", Acked: true},
+	}, server.settled())
+
+	// The pending acknowledgements are flushed when the consumption stops.
+	require.NoError(t, client.Disconnect())
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 5 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 2, server.ackCount())
+	assert.Equal(t, fakeSettlement{Body: "fourth", Acked: true}, server.settled()[4])
+}
+
+func TestClient_AckBatch_Interval(t *testing.T) {
+	server := newFakeServer(t)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		PrefetchCount: 10,
+		AckBatch:      &gorabbit.AckBatchConfig{Size: 10, Interval: 50 * time.Millisecond},
+	})
+
+	server.deliver("events", "event.created", "first", "second")
+
+	// The batch is not full, so it is acknowledged by the periodical flush.
+	require.Eventually(t, func() bool { return len(server.settled()) == 2 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, 1, server.ackCount())
+}
@@ -0,0 +1,118 @@
+package gorabbit_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_AckModeManual(t *testing.T) {
+	server := newFakeServer(t)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		AckMode:       gorabbit.AckModeManual,
+		PrefetchCount: 10,
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"event.created": func(ctx context.Context, payload []byte) error {
+				delivery, _ := gorabbit.DeliveryFromContext(ctx)
+
+				switch string(payload) {
+				case "acked":
+					return delivery.Ack()
+				case "requeued":
+					return delivery.Nack(true)
+				default:
+					// The error of the handler does not settle the delivery either.
+					return errors.New("left unsettled")
+				}
+			},
+		},
+	})
+
+	server.deliver("events", "event.created", "acked", "requeued", "unsettled")
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 2 }, time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, []fakeSettlement{{Body: "acked", Acked: true}, {Body: "requeued", Requeue: true}}, server.settled())
+}
+
+func TestClient_AckModeOnSuccess(t *testing.T) {
+	server := newFakeServer(t)
+
+	var ackErr atomic.Value
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		AckMode: gorabbit.AckModeOnSuccess,
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"event.created": func(ctx context.Context, _ []byte) error {
+				delivery, _ := gorabbit.DeliveryFromContext(ctx)
+
+				// The deliveries are acknowledged by the consumer, not by the handlers.
+				ackErr.Store(delivery.Ack())
+
+				return nil
+			},
+		},
+	})
+
+	server.deliver("events", "event.created", "processed")
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 1 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []fakeSettlement{{Body: "processed", Acked: true}}, server.settled())
+	assert.Error(t, ackErr.Load().(error))
+}
+
+func TestClient_AckModeAuto(t *testing.T) {
+	server := newFakeServer(t)
+
+	handled := make(chan string, 2)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		AckMode: gorabbit.AckModeAuto,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				handled <- string(payload)
+
+				return errors.New("failed")
+			},
+		},
+	})
+
+	server.deliver("events", "event.created", "first", "second")
+
+	assert.Equal(t, "first", <-handled)
+	assert.Equal(t, "second", <-handled)
+
+	// The deliveries acknowledged upon reception are never settled by the consumer, even when failing.
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Empty(t, server.settled())
+}
+
+func TestMessageConsumer_Validate_AckMode(t *testing.T) {
+	consumer := gorabbit.MessageConsumer{
+		Queue:    "events",
+		Name:     "events",
+		AckMode:  gorabbit.AckModeManual,
+		Handlers: gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }},
+	}
+
+	// The manual acknowledgement requires a prefetch count.
+	assert.Error(t, consumer.Validate())
+
+	consumer.PrefetchCount = 10
+	assert.NoError(t, consumer.Validate())
+
+	consumer.AckMode = "later"
+	assert.Error(t, consumer.Validate())
+}
@@ -0,0 +1,97 @@
+package gorabbit
+
+import "errors"
+
+// AckDecision defines what is done with a processed delivery.
+type AckDecision uint8
+
+const (
+	// AckDecisionRetry retries the delivery through the consumer's retry mechanism.
+	AckDecisionRetry AckDecision = iota
+
+	// AckDecisionDiscard acknowledges the delivery and drops it.
+	AckDecisionDiscard
+
+	// AckDecisionDeadLetter dead-letters the delivery, or sends it to the quarantine queue if one is configured.
+	AckDecisionDeadLetter
+
+	// AckDecisionAck acknowledges the delivery as successfully processed.
+	AckDecisionAck
+
+	// AckDecisionRequeue negative acknowledges the delivery with requeue, bypassing the consumer's retry mechanism.
+	AckDecisionRequeue
+)
+
+// String returns the name of the AckDecision.
+func (a AckDecision) String() string {
+	switch a {
+	case AckDecisionRetry:
+		return "retry"
+	case AckDecisionDiscard:
+		return "discard"
+	case AckDecisionDeadLetter:
+		return "dead-letter"
+	case AckDecisionAck:
+		return "ack"
+	case AckDecisionRequeue:
+		return "requeue"
+	default:
+		return "unknown"
+	}
+}
+
+// classifiedError is an error returned by a handler that carries the AckDecision to apply to the delivery.
+type classifiedError struct {
+	err    error
+	action AckDecision
+}
+
+// Error returns the message of the wrapped error.
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// Retryable wraps an error returned by a handler so that the delivery is retried through the consumer's retry
+// mechanism. This is the default behavior for errors that are not classified.
+// Returns nil if err is nil.
+func Retryable(err error) error {
+	return classify(err, AckDecisionRetry)
+}
+
+// Discard wraps an error returned by a handler so that the delivery is acknowledged and dropped without any retry.
+// Returns nil if err is nil.
+func Discard(err error) error {
+	return classify(err, AckDecisionDiscard)
+}
+
+// DeadLetter wraps an error returned by a handler so that the delivery is immediately dead-lettered without any retry.
+// If the consumer has a quarantine queue, the delivery is sent to it, otherwise it is negative acknowledged without
+// requeue and will be routed to the queue's dead-letter exchange if any.
+// Returns nil if err is nil.
+func DeadLetter(err error) error {
+	return classify(err, AckDecisionDeadLetter)
+}
+
+func classify(err error, action AckDecision) error {
+	if err == nil {
+		return nil
+	}
+
+	return &classifiedError{err: err, action: action}
+}
+
+// classifyError returns the AckDecision carried by an error, defaulting to AckDecisionRetry.
+func classifyError(err error) AckDecision {
+	var classified *classifiedError
+
+	if errors.As(err, &classified) {
+		return classified.action
+	}
+
+	return AckDecisionRetry
+}
@@ -0,0 +1,109 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClassifiedErrors(t *testing.T) {
+	failure := errors.New("downstream unavailable")
+
+	for _, classify := range []func(error) error{gorabbit.Retryable, gorabbit.Discard, gorabbit.DeadLetter} {
+		assert.NoError(t, classify(nil))
+
+		err := classify(failure)
+		assert.ErrorIs(t, err, failure)
+		assert.Equal(t, failure.Error(), err.Error())
+	}
+}
+
+func TestClient_AckPolicy(t *testing.T) {
+	server := newFakeServer(t)
+
+	failure := errors.New("downstream unavailable")
+
+	var retried atomic.Bool
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		RetryPolicy: gorabbit.ConstantRetryPolicy{Delay: 10 * time.Millisecond, MaxAttempts: 3},
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				switch string(payload) {
+				case "retryable":
+					// The delivery succeeds once retried.
+					if retried.CompareAndSwap(false, true) {
+						return gorabbit.Retryable(failure)
+					}
+
+					return nil
+				case "discard":
+					return gorabbit.Discard(failure)
+				case "dead-letter":
+					return gorabbit.DeadLetter(failure)
+				case "wrapped":
+					return fmt.Errorf("could not handle event: %w", gorabbit.DeadLetter(failure))
+				default:
+					return nil
+				}
+			},
+		},
+	})
+
+	server.deliver("events", "event.created", "retryable", "discard", "dead-letter", "wrapped")
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 5 }, time.Second, 10*time.Millisecond)
+
+	// The retryable delivery is acknowledged once re-published, then once processed. The discarded delivery is
+	// acknowledged and dropped, while the dead-lettered ones, even wrapped, are rejected without requeue.
+	assert.ElementsMatch(t, []fakeSettlement{
+		{Body: "retryable", Acked: true},
+		{Body: "retryable", Acked: true},
+		{Body: "discard", Acked: true},
+		{Body: "dead-letter"},
+		{Body: "wrapped"},
+	}, server.settled())
+
+	publishings := server.publishings()
+	require.Len(t, publishings, 1)
+
+	assert.Equal(t, "events", publishings[0].RoutingKey)
+	assert.Equal(t, "retryable", publishings[0].Body)
+	assert.EqualValues(t, 1, publishings[0].Headers["x-retry-attempt"])
+}
+
+func TestClient_AckPolicy_DeadLetterQuarantine(t *testing.T) {
+	server := newFakeServer(t)
+
+	failure := errors.New("invalid payload")
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		QuarantineQueue: "events.quarantine",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func([]byte) error {
+				return fmt.Errorf("could not handle event: %w", gorabbit.DeadLetter(failure))
+			},
+		},
+	})
+
+	server.deliver("events", "event.created", "poison")
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 1 }, time.Second, 10*time.Millisecond)
+
+	// The dead-lettered delivery is parked in the quarantine queue, without any retry, then acknowledged.
+	assert.Equal(t, []fakeSettlement{{Body: "poison", Acked: true}}, server.settled())
+
+	publishings := server.publishings()
+	require.Len(t, publishings, 1)
+
+	assert.Equal(t, "events.quarantine", publishings[0].RoutingKey)
+	assert.Equal(t, "poison", publishings[0].Body)
+	assert.Equal(t, "could not handle event: invalid payload", publishings[0].Headers["x-quarantine-reason"])
+}
@@ -0,0 +1,137 @@
+package gorabbit
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AckStrategy decides what is done with a delivery once its handler returned.
+// Implementations must be safe for concurrent use if the consumer processes deliveries concurrently.
+type AckStrategy interface {
+	// Decide returns the AckDecision to apply to the delivery, given the error returned by its handler, nil on success.
+	Decide(delivery Delivery, err error) AckDecision
+}
+
+// AckStrategyFunc is an adapter to use an ordinary function as an AckStrategy.
+type AckStrategyFunc func(delivery Delivery, err error) AckDecision
+
+// Decide calls f(delivery, err).
+func (f AckStrategyFunc) Decide(delivery Delivery, err error) AckDecision {
+	return f(delivery, err)
+}
+
+// defaultAckStrategy is the AckStrategy used by consumers that do not define one.
+type defaultAckStrategy struct {
+	maxDeliveryAttempts uint
+}
+
+// DefaultAckStrategy returns the AckStrategy used by consumers that do not define one:
+//   - successful deliveries are acknowledged.
+//   - errors classified with Discard, DeadLetter or Retryable are applied as is.
+//   - deliveries that reached the maxDeliveryAttempts, if not 0, are dead-lettered.
+//   - other failed deliveries are retried.
+//
+// It can be wrapped by custom strategies to only override specific cases.
+func DefaultAckStrategy(maxDeliveryAttempts uint) AckStrategy {
+	return defaultAckStrategy{maxDeliveryAttempts: maxDeliveryAttempts}
+}
+
+func (s defaultAckStrategy) Decide(delivery Delivery, err error) AckDecision {
+	if err == nil {
+		return AckDecisionAck
+	}
+
+	// If the handler classified its error, we apply the requested decision.
+	if decision := classifyError(err); decision != AckDecisionRetry {
+		return decision
+	}
+
+	// If the delivery failed too many times, it is dead-lettered instead of being retried.
+	if s.maxDeliveryAttempts > 0 && delivery.Attempts+1 >= s.maxDeliveryAttempts {
+		return AckDecisionDeadLetter
+	}
+
+	return AckDecisionRetry
+}
+
+// ackStrategy returns the AckStrategy of the consumer, or the default one.
+func (c MessageConsumer) ackStrategy() AckStrategy {
+	if c.AckStrategy != nil {
+		return c.AckStrategy
+	}
+
+	return DefaultAckStrategy(c.MaxDeliveryAttempts)
+}
+
+// applyDecision applies an AckDecision to a processed delivery.
+func (c *amqpChannel) applyDecision(delivery *amqp.Delivery, alreadyAcknowledged bool, decision AckDecision, reason error) {
+	switch decision {
+	case AckDecisionAck:
+		c.logger.Debug("Delivery successfully processed", LogField{Key: "messageID", Value: delivery.MessageId})
+
+		if !alreadyAcknowledged {
+			_ = delivery.Ack(false)
+		}
+
+		c.markProcessed(delivery)
+	case AckDecisionDiscard:
+		c.logger.Debug("Delivery discarded", LogField{Key: "messageID", Value: delivery.MessageId}, reasonField(reason))
+
+		c.capturePoison(delivery, decision, reason)
+
+		if !alreadyAcknowledged {
+			_ = delivery.Ack(false)
+		}
+	case AckDecisionDeadLetter:
+		c.logger.Debug("Delivery dead-lettered", LogField{Key: "messageID", Value: delivery.MessageId}, reasonField(reason))
+
+		c.deadLetter(delivery, alreadyAcknowledged, reason)
+	case AckDecisionRequeue:
+		c.logger.Debug("Delivery requeued", LogField{Key: "messageID", Value: delivery.MessageId}, reasonField(reason))
+
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, true)
+		}
+	case AckDecisionRetry:
+		c.retryFailed(delivery, alreadyAcknowledged, reason)
+	default:
+		c.logger.Warn("Unknown ack decision, retrying delivery", LogField{Key: "decision", Value: decision.String()})
+
+		c.retryFailed(delivery, alreadyAcknowledged, reason)
+	}
+}
+
+// retryFailed retries a failed delivery through the consumer's retry mechanism.
+func (c *amqpChannel) retryFailed(delivery *amqp.Delivery, alreadyAcknowledged bool, reason error) {
+	// If the consumer has a backoff retry configuration, failed deliveries are sent to the matching wait queue.
+	if c.consumer.Retry != nil {
+		c.retryWithBackoff(delivery, alreadyAcknowledged, reason)
+
+		return
+	}
+
+	// If the consumer has a retry policy, failed deliveries are re-published to its queue after the delay of the policy.
+	if c.consumer.RetryPolicy != nil {
+		go c.retryWithPolicy(delivery, alreadyAcknowledged, reason)
+
+		return
+	}
+
+	// If the consumer has a nack delay, failed deliveries are held before being requeued.
+	if c.consumer.NackDelay > 0 && !alreadyAcknowledged {
+		go c.delayedNack(delivery)
+
+		return
+	}
+
+	// Otherwise we retry the delivery.
+	go c.retryDelivery(delivery, alreadyAcknowledged, reason)
+}
+
+// reasonField returns the log field of a failure reason.
+func reasonField(reason error) LogField {
+	if reason == nil {
+		return LogField{Key: "reason", Value: ""}
+	}
+
+	return LogField{Key: "reason", Value: reason.Error()}
+}
@@ -0,0 +1,61 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestDefaultAckStrategy_Decide(t *testing.T) {
+	strategy := gorabbit.DefaultAckStrategy(3)
+
+	errFailure := errors.New("failure")
+
+	tests := []struct {
+		name             string
+		attempts         uint
+		err              error
+		expectedDecision gorabbit.AckDecision
+	}{
+		{name: "success", err: nil, expectedDecision: gorabbit.AckDecisionAck},
+		{name: "unclassified error", err: errFailure, expectedDecision: gorabbit.AckDecisionRetry},
+		{name: "retryable error", err: gorabbit.Retryable(errFailure), expectedDecision: gorabbit.AckDecisionRetry},
+		{name: "discarded error", err: gorabbit.Discard(errFailure), expectedDecision: gorabbit.AckDecisionDiscard},
+		{name: "dead-lettered error", err: gorabbit.DeadLetter(errFailure), expectedDecision: gorabbit.AckDecisionDeadLetter},
+		{name: "attempts left", attempts: 1, err: errFailure, expectedDecision: gorabbit.AckDecisionRetry},
+		{name: "max attempts reached", attempts: 2, err: errFailure, expectedDecision: gorabbit.AckDecisionDeadLetter},
+		{name: "success after attempts", attempts: 2, err: nil, expectedDecision: gorabbit.AckDecisionAck},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decision := strategy.Decide(gorabbit.Delivery{Attempts: test.attempts}, test.err)
+
+			assert.Equal(t, test.expectedDecision, decision)
+		})
+	}
+}
+
+func TestDefaultAckStrategy_NoMaxAttempts(t *testing.T) {
+	strategy := gorabbit.DefaultAckStrategy(0)
+
+	decision := strategy.Decide(gorabbit.Delivery{Attempts: 100}, errors.New("failure"))
+
+	assert.Equal(t, gorabbit.AckDecisionRetry, decision)
+}
+
+func TestAckStrategyFunc_Decide(t *testing.T) {
+	strategy := gorabbit.AckStrategyFunc(func(_ gorabbit.Delivery, err error) gorabbit.AckDecision {
+		if err != nil {
+			return gorabbit.AckDecisionRequeue
+		}
+
+		return gorabbit.AckDecisionAck
+	})
+
+	assert.Equal(t, gorabbit.AckDecisionRequeue, strategy.Decide(gorabbit.Delivery{}, errors.New("failure")))
+	assert.Equal(t, gorabbit.AckDecisionAck, strategy.Decide(gorabbit.Delivery{}, nil))
+}
@@ -0,0 +1,209 @@
+package gorabbit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AdaptivePrefetchConfig enables the adjustment of the PrefetchCount of a consumer to its observed handler latency, the
+// way TCP adjusts its congestion window. After each Interval, the PrefetchCount is:
+//   - multiplied by the DecreaseFactor if the mean handler latency exceeded the TargetLatency,
+//   - increased by the IncreaseStep if the latency was within the TargetLatency and the deliveries in flight reached the
+//     PrefetchCount, which then limited the throughput,
+//   - left unchanged otherwise, such as when no delivery was processed.
+//
+// The PrefetchCount of the consumer is the initial one, bounded by the MinPrefetchCount and the MaxPrefetchCount.
+// The adjusted PrefetchCount is kept across channel recoveries.
+type AdaptivePrefetchConfig struct {
+	// MinPrefetchCount is the lowest PrefetchCount. Defaults to 1.
+	MinPrefetchCount int `yaml:"min_prefetch_count"`
+
+	// MaxPrefetchCount is the highest PrefetchCount, bounding the unacknowledged deliveries. Required.
+	MaxPrefetchCount int `yaml:"max_prefetch_count"`
+
+	// TargetLatency is the mean handler latency above which the PrefetchCount is decreased. Required.
+	TargetLatency time.Duration `yaml:"target_latency"`
+
+	// Interval is the delay between two adjustments. Defaults to 5 seconds.
+	Interval time.Duration `yaml:"interval"`
+
+	// IncreaseStep is the number added to the PrefetchCount when it limited the throughput. Defaults to 1.
+	IncreaseStep int `yaml:"increase_step"`
+
+	// DecreaseFactor is the factor, between 0 and 1, applied to the PrefetchCount when the handlers are too slow.
+	// Defaults to 0.5.
+	DecreaseFactor float64 `yaml:"decrease_factor"`
+
+	// OnAdjust is called, if set, after each change of the PrefetchCount. It can be used to feed metrics.
+	OnAdjust func(adjustment PrefetchAdjustment) `yaml:"-"`
+}
+
+// PrefetchAdjustment describes a change of the PrefetchCount of a consumer with an AdaptivePrefetchConfig.
+type PrefetchAdjustment struct {
+	// Consumer is the name of the consumer.
+	Consumer string
+
+	// Previous is the PrefetchCount before the adjustment.
+	Previous int
+
+	// PrefetchCount is the PrefetchCount after the adjustment.
+	PrefetchCount int
+
+	// Latency is the mean handler latency observed since the previous adjustment.
+	Latency time.Duration
+
+	// InFlight is the highest number of deliveries in flight observed since the previous adjustment.
+	InFlight int
+}
+
+// validate verifies the configuration of the given consumer.
+func (a *AdaptivePrefetchConfig) validate(consumer MessageConsumer) error {
+	switch {
+	case consumer.autoAck():
+		return fmt.Errorf("the consumer '%s' cannot adapt its prefetch count with automatic acknowledgements", consumer.Name)
+	case a.TargetLatency <= 0:
+		return fmt.Errorf("the consumer '%s' must define a positive target latency to adapt its prefetch count", consumer.Name)
+	case a.MinPrefetchCount < 0 || a.MaxPrefetchCount < a.minPrefetchCount():
+		return fmt.Errorf("the consumer '%s' must define a max prefetch count above its min prefetch count", consumer.Name)
+	case a.DecreaseFactor < 0 || a.DecreaseFactor >= 1 || a.IncreaseStep < 0 || a.Interval < 0:
+		return fmt.Errorf("the consumer '%s' has an invalid adaptive prefetch step, factor or interval", consumer.Name)
+	}
+
+	return nil
+}
+
+// minPrefetchCount returns the MinPrefetchCount, 1 if not set.
+func (a *AdaptivePrefetchConfig) minPrefetchCount() int {
+	return defaultInt(a.MinPrefetchCount, 1)
+}
+
+// next returns the PrefetchCount following the current one, given the observations of the last interval.
+func (a *AdaptivePrefetchConfig) next(current int, observed prefetchObservation) int {
+	next := current
+
+	switch {
+	case observed.processed == 0:
+	case observed.latency() > a.TargetLatency:
+		factor := a.DecreaseFactor
+		if factor == 0 {
+			factor = defaultAdaptivePrefetchFactor
+		}
+
+		next = int(float64(current) * factor)
+	case observed.peakInFlight >= current:
+		next = current + defaultInt(a.IncreaseStep, 1)
+	}
+
+	return min(max(next, a.minPrefetchCount()), a.MaxPrefetchCount)
+}
+
+// prefetchObservation holds the handler latencies and the deliveries in flight observed during an interval.
+type prefetchObservation struct {
+	processed    int
+	totalLatency time.Duration
+	peakInFlight int
+}
+
+// latency returns the mean handler latency.
+func (o prefetchObservation) latency() time.Duration {
+	if o.processed == 0 {
+		return 0
+	}
+
+	return o.totalLatency / time.Duration(o.processed)
+}
+
+// prefetchObserver accumulates the prefetchObservation of the current interval.
+type prefetchObserver struct {
+	current prefetchObservation
+	mutex   sync.Mutex
+}
+
+// observeLatency records the latency of a handler.
+func (o *prefetchObserver) observeLatency(latency time.Duration) {
+	if o == nil {
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.current.processed++
+	o.current.totalLatency += latency
+}
+
+// observeInFlight records the number of deliveries in flight.
+func (o *prefetchObserver) observeInFlight(inFlight int) {
+	if o == nil {
+		return
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	o.current.peakInFlight = max(o.current.peakInFlight, inFlight)
+}
+
+// reset returns the observation of the interval and starts a new one.
+func (o *prefetchObserver) reset() prefetchObservation {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	observed := o.current
+	o.current = prefetchObservation{}
+
+	return observed
+}
+
+// adaptPrefetch adjusts the PrefetchCount of the consumer after each interval until the consumption stops.
+func (c *amqpChannel) adaptPrefetch() {
+	config := c.consumer.AdaptivePrefetch
+
+	interval := config.Interval
+	if interval <= 0 {
+		interval = defaultAdaptivePrefetchPeriod
+	}
+
+	// The PrefetchCount of the consumer is the initial one, within the bounds.
+	prefetchCount, rateLimit := c.tuning.get()
+	c.tune(min(max(prefetchCount, config.minPrefetchCount()), config.MaxPrefetchCount), rateLimit)
+
+	c.prefetchObserver.reset()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := c.consumptionContext()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.adjustPrefetch(config, c.prefetchObserver.reset())
+		}
+	}
+}
+
+// adjustPrefetch applies the PrefetchCount following the observations of the last interval.
+func (c *amqpChannel) adjustPrefetch(config *AdaptivePrefetchConfig, observed prefetchObservation) {
+	previous, rateLimit := c.tuning.get()
+
+	next := config.next(previous, observed)
+	if next == previous {
+		return
+	}
+
+	c.tune(next, rateLimit)
+
+	if config.OnAdjust != nil {
+		config.OnAdjust(PrefetchAdjustment{
+			Consumer:      c.consumer.Name,
+			Previous:      previous,
+			PrefetchCount: next,
+			Latency:       observed.latency(),
+			InFlight:      observed.peakInFlight,
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMessageConsumer_Validate_AdaptivePrefetch(t *testing.T) {
+	consumer := gorabbit.MessageConsumer{
+		Queue:         "invoices_queue",
+		Name:          "invoice_processor",
+		PrefetchCount: 10,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"invoice.created": func(_ []byte) error { return nil },
+		},
+		AdaptivePrefetch: &gorabbit.AdaptivePrefetchConfig{
+			MaxPrefetchCount: 100,
+			TargetLatency:    50 * time.Millisecond,
+		},
+	}
+
+	require.NoError(t, consumer.Validate())
+
+	tests := map[string]func(consumer *gorabbit.MessageConsumer){
+		"auto ack":         func(consumer *gorabbit.MessageConsumer) { consumer.AutoAck = true },
+		"no target":        func(consumer *gorabbit.MessageConsumer) { consumer.AdaptivePrefetch.TargetLatency = 0 },
+		"no max":           func(consumer *gorabbit.MessageConsumer) { consumer.AdaptivePrefetch.MaxPrefetchCount = 0 },
+		"max below min":    func(consumer *gorabbit.MessageConsumer) { consumer.AdaptivePrefetch.MinPrefetchCount = 200 },
+		"increasing decay": func(consumer *gorabbit.MessageConsumer) { consumer.AdaptivePrefetch.DecreaseFactor = 1.5 },
+	}
+
+	for name, mutate := range tests {
+		invalid := consumer
+		adaptive := *consumer.AdaptivePrefetch
+		invalid.AdaptivePrefetch = &adaptive
+
+		mutate(&invalid)
+
+		assert.Error(t, invalid.Validate(), name)
+	}
+}
+
+func TestLoadClientConfig_AdaptivePrefetch(t *testing.T) {
+	path := writeConfigFile(t, `
+consumers:
+  prefetch_count: 20
+  adaptive_prefetch:
+    max_prefetch_count: 200
+    target_latency: 100ms
+    increase_step: 5
+`)
+
+	config, err := gorabbit.LoadClientConfig(path)
+	require.NoError(t, err)
+
+	adaptive := config.ClientOptions().ConsumerDefaults.AdaptivePrefetch
+	require.NotNil(t, adaptive)
+	assert.Equal(t, 200, adaptive.MaxPrefetchCount)
+	assert.Equal(t, 100*time.Millisecond, adaptive.TargetLatency)
+	assert.Equal(t, 5, adaptive.IncreaseStep)
+
+	path = writeConfigFile(t, `
+consumers:
+  adaptive_prefetch:
+    max_prefetch_count: 200
+`)
+
+	_, err = gorabbit.LoadClientConfig(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package gorabbit
+
+import (
+	"fmt"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Kinds of administered resource, used to type the errors.
+const (
+	resourceQueue    = "queue"
+	resourceExchange = "exchange"
+)
+
+// administer runs an administration operation on a new channel of the connection.
+func (a *amqpConnection) administer(operation func(channel *amqp.Channel) error) error {
+	if !a.ready() {
+		return errConnectionClosed
+	}
+
+	return runOnNewChannel(a.connection.Load(), operation)
+}
+
+// purgeQueue removes the ready messages of a queue and returns their number.
+func (a *amqpConnection) purgeQueue(queue string) (int, error) {
+	var purged int
+
+	err := a.administer(func(channel *amqp.Channel) error {
+		var err error
+
+		purged, err = channel.QueuePurge(queue, false)
+
+		return err
+	})
+
+	return purged, administrationError(resourceQueue, err)
+}
+
+// deleteQueue deletes a queue and returns the number of messages it held.
+func (a *amqpConnection) deleteQueue(queue string, ifUnused, ifEmpty bool) (int, error) {
+	var deleted int
+
+	err := a.administer(func(channel *amqp.Channel) error {
+		var err error
+
+		deleted, err = channel.QueueDelete(queue, ifUnused, ifEmpty, false)
+
+		return err
+	})
+
+	return deleted, administrationError(resourceQueue, err)
+}
+
+// deleteExchange deletes an exchange.
+func (a *amqpConnection) deleteExchange(exchange string, ifUnused bool) error {
+	err := a.administer(func(channel *amqp.Channel) error {
+		return channel.ExchangeDelete(exchange, ifUnused, false)
+	})
+
+	return administrationError(resourceExchange, err)
+}
+
+// unbindQueue removes the binding of a queue to an exchange.
+func (a *amqpConnection) unbindQueue(exchange, queue, routingKey string, args map[string]interface{}) error {
+	table, err := tableArguments(args)
+	if err != nil {
+		return err
+	}
+
+	err = a.administer(func(channel *amqp.Channel) error {
+		return channel.QueueUnbind(queue, routingKey, exchange, table)
+	})
+
+	return administrationError(resourceQueue, err)
+}
+
+// administrationError wraps the error returned by the server for an operation on the given kind of resource with the
+// matching exported error, so that it can be checked with errors.Is while keeping the server's reason. An unbinding
+// targets both an exchange and a queue, so the missing one is told by the reason.
+func administrationError(resource string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var typed error
+
+	switch {
+	case isErrorNotFound(err) && (resource == resourceExchange || strings.Contains(err.Error(), "no exchange")):
+		typed = ErrExchangeNotFound
+	case isErrorNotFound(err):
+		typed = ErrQueueNotFound
+	case isErrorPreconditionFailed(err) && resource == resourceExchange:
+		typed = ErrExchangeInUse
+	case isErrorPreconditionFailed(err) && strings.Contains(err.Error(), "not empty"):
+		typed = ErrQueueNotEmpty
+	case isErrorPreconditionFailed(err):
+		typed = ErrQueueInUse
+	case isErrorAccessRefused(err):
+		typed = ErrAccessRefused
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", typed, err)
+}
@@ -0,0 +1,27 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMQTTClient_Administration_Disabled(t *testing.T) {
+	t.Setenv("GORABBIT_DISABLED", "true")
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions())
+
+	purged, err := client.PurgeQueue("events_queue")
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	deleted, err := client.DeleteQueue("events_queue", true, true)
+	require.NoError(t, err)
+	assert.Zero(t, deleted)
+
+	assert.NoError(t, client.DeleteExchange("events_exchange", true))
+	assert.NoError(t, client.Unbind("events_exchange", "events_queue", "event.#", nil))
+}
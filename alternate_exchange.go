@@ -0,0 +1,70 @@
+package gorabbit
+
+// argAlternateExchange is the exchange argument defining the exchange receiving the messages that cannot be routed.
+const argAlternateExchange = "alternate-exchange"
+
+// AlternateExchangeConfig defines the alternate exchange of an exchange, receiving the messages it cannot route.
+type AlternateExchangeConfig struct {
+	// Name is the name of the alternate exchange.
+	Name string `yaml:"name"`
+
+	// Declare declares, if true, the alternate exchange as a fanout exchange with the persistence of its exchange.
+	Declare bool `yaml:"declare"`
+
+	// UnroutableQueue declares, if set, a queue bound to the alternate exchange to collect the unroutable messages.
+	UnroutableQueue string `yaml:"unroutable_queue"`
+}
+
+// alternateExchangeArguments returns the exchange arguments, pointing to its alternate exchange if any.
+func (e ExchangeConfig) alternateExchangeArguments() map[string]interface{} {
+	if e.AlternateExchange == nil {
+		return e.Args
+	}
+
+	if _, defined := e.Args[argAlternateExchange]; defined {
+		return e.Args
+	}
+
+	args := make(map[string]interface{}, len(e.Args)+1)
+
+	for k, v := range e.Args {
+		args[k] = v
+	}
+
+	args[argAlternateExchange] = e.AlternateExchange.Name
+
+	return args
+}
+
+// declareAlternateExchange declares, if requested, the alternate exchange of an exchange and its unroutable queue.
+func (manager *mqttManager) declareAlternateExchange(config ExchangeConfig) error {
+	alternate := config.AlternateExchange
+	if alternate == nil {
+		return nil
+	}
+
+	if alternate.Name == "" {
+		return errEmptyAlternateExchangeName
+	}
+
+	if alternate.Declare {
+		err := manager.CreateExchange(ExchangeConfig{
+			Name:      alternate.Name,
+			Type:      ExchangeTypeFanout,
+			Persisted: config.Persisted,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if alternate.UnroutableQueue == "" {
+		return nil
+	}
+
+	return manager.CreateQueue(QueueConfig{
+		Name:     alternate.UnroutableQueue,
+		Durable:  config.Persisted,
+		Bindings: []BindingConfig{{Exchange: alternate.Name}},
+	})
+}
@@ -0,0 +1,67 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMQTTManager_CreateExchange_AlternateExchange(t *testing.T) {
+	server := newFakeServer(t)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	require.NoError(t, manager.CreateExchange(gorabbit.ExchangeConfig{
+		Name:      "events",
+		Type:      gorabbit.ExchangeTypeTopic,
+		Persisted: true,
+		AlternateExchange: &gorabbit.AlternateExchangeConfig{
+			Name:            "events.unrouted",
+			Declare:         true,
+			UnroutableQueue: "events.unrouted",
+		},
+	}))
+
+	// The alternate exchange and its queue are declared before the exchange pointing to it.
+	assert.Equal(t, []fakeDeclaration{
+		{Kind: "exchange", Name: "events.unrouted", RoutingKey: "fanout"},
+		{Kind: "queue", Name: "events.unrouted"},
+		{Kind: "binding", Name: "events.unrouted", Exchange: "events.unrouted"},
+		{Kind: "exchange", Name: "events", RoutingKey: "topic", Arguments: amqp.Table{"alternate-exchange": "events.unrouted"}},
+	}, server.declarations())
+}
+
+func TestMQTTManager_CreateExchange_AlternateExchangeArgument(t *testing.T) {
+	server := newFakeServer(t)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	// The alternate exchange defined by the arguments is kept, and nothing else is declared unless requested.
+	require.NoError(t, manager.CreateExchange(gorabbit.ExchangeConfig{
+		Name:              "events",
+		Type:              gorabbit.ExchangeTypeTopic,
+		Args:              map[string]interface{}{"alternate-exchange": "unrouted"},
+		AlternateExchange: &gorabbit.AlternateExchangeConfig{Name: "events.unrouted"},
+	}))
+
+	assert.Equal(t, []fakeDeclaration{
+		{Kind: "exchange", Name: "events", RoutingKey: "topic", Arguments: amqp.Table{"alternate-exchange": "unrouted"}},
+	}, server.declarations())
+
+	err = manager.CreateExchange(gorabbit.ExchangeConfig{
+		Name:              "events",
+		Type:              gorabbit.ExchangeTypeTopic,
+		AlternateExchange: &gorabbit.AlternateExchangeConfig{},
+	})
+	assert.Error(t, err)
+}
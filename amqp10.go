@@ -0,0 +1,377 @@
+package gorabbit
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// AMQP 1.0 type codes.
+const (
+	amqp10Described  = 0x00
+	amqp10Null       = 0x40
+	amqp10True       = 0x41
+	amqp10False      = 0x42
+	amqp10UInt0      = 0x43
+	amqp10ULong0     = 0x44
+	amqp10List0      = 0x45
+	amqp10UByte      = 0x50
+	amqp10Byte       = 0x51
+	amqp10SmallUInt  = 0x52
+	amqp10SmallULong = 0x53
+	amqp10SmallInt   = 0x54
+	amqp10SmallLong  = 0x55
+	amqp10Boolean    = 0x56
+	amqp10UShort     = 0x60
+	amqp10Short      = 0x61
+	amqp10UInt       = 0x70
+	amqp10Int        = 0x71
+	amqp10ULong      = 0x80
+	amqp10Long       = 0x81
+	amqp10Double     = 0x82
+	amqp10Timestamp  = 0x83
+	amqp10VBin8      = 0xa0
+	amqp10Str8       = 0xa1
+	amqp10Sym8       = 0xa3
+	amqp10VBin32     = 0xb0
+	amqp10Str32      = 0xb1
+	amqp10Sym32      = 0xb3
+	amqp10List8      = 0xc0
+	amqp10Map8       = 0xc1
+	amqp10List32     = 0xd0
+	amqp10Map32      = 0xd1
+	amqp10Array8     = 0xe0
+	amqp10Array32    = 0xf0
+)
+
+// AMQP 1.0 descriptors of the sections of a message.
+const (
+	amqp10Header                = 0x70
+	amqp10Properties            = 0x73
+	amqp10ApplicationProperties = 0x74
+	amqp10Data                  = 0x75
+	amqp10AMQPValue             = 0x77
+	amqp10ContentIndex          = 6
+)
+
+// amqp10Symbol is an AMQP 1.0 symbol, such as a SASL mechanism or an error condition.
+type amqp10Symbol string
+
+// amqp10Value is an AMQP 1.0 described value, such as a performative or a section of a message. Its descriptor is 0
+// unless numeric.
+type amqp10Value struct {
+	descriptor uint64
+	value      interface{}
+}
+
+// fields returns the fields of a described list, such as the ones of a performative.
+func (v amqp10Value) fields() []interface{} {
+	fields, _ := v.value.([]interface{})
+
+	return fields
+}
+
+// amqp10Field returns the field at the given index of a list, nil if absent.
+func amqp10Field(fields []interface{}, index int) interface{} {
+	if index < len(fields) {
+		return fields[index]
+	}
+
+	return nil
+}
+
+// amqp10Uint returns the field at the given index of a list as an unsigned integer, 0 if absent.
+func amqp10Uint(fields []interface{}, index int) uint64 {
+	value, _ := amqp10Field(fields, index).(uint64)
+
+	return value
+}
+
+// encodeAMQP10 encodes a value with the smallest code of its type, among the types of the performatives and of the
+// application properties. The values of other types are encoded as their string representation.
+func encodeAMQP10(b *frameBuffer, value interface{}) {
+	switch v := value.(type) {
+	case nil:
+		b.uint8(amqp10Null)
+	case bool:
+		if v {
+			b.uint8(amqp10True)
+		} else {
+			b.uint8(amqp10False)
+		}
+	case uint8:
+		b.uint8(amqp10UByte).uint8(v)
+	case uint16:
+		b.uint8(amqp10UShort).uint16(v)
+	case uint32:
+		b.uint8(amqp10UInt).uint32(v)
+	case uint64:
+		b.uint8(amqp10ULong).uint64(v)
+	case int:
+		b.uint8(amqp10Long).uint64(uint64(v))
+	case int32:
+		b.uint8(amqp10Int).uint32(uint32(v))
+	case int64:
+		b.uint8(amqp10Long).uint64(uint64(v))
+	case float64:
+		b.uint8(amqp10Double).uint64(math.Float64bits(v))
+	case time.Time:
+		b.uint8(amqp10Timestamp).uint64(uint64(v.UnixMilli()))
+	case string:
+		b.uint8(amqp10Str32).bytes([]byte(v))
+	case amqp10Symbol:
+		b.uint8(amqp10Sym32).bytes([]byte(v))
+	case []byte:
+		b.uint8(amqp10VBin32).bytes(v)
+	case []interface{}:
+		items := new(frameBuffer)
+
+		for _, item := range v {
+			encodeAMQP10(items, item)
+		}
+
+		b.uint8(amqp10List32).uint32(uint32(4 + items.Len())).uint32(uint32(len(v)))
+		b.Write(items.Bytes())
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		items := new(frameBuffer)
+
+		for _, key := range keys {
+			encodeAMQP10(items, key)
+			encodeAMQP10(items, v[key])
+		}
+
+		b.uint8(amqp10Map32).uint32(uint32(4 + items.Len())).uint32(uint32(2 * len(v)))
+		b.Write(items.Bytes())
+	case amqp10Value:
+		b.uint8(amqp10Described).uint8(amqp10SmallULong).uint8(uint8(v.descriptor))
+		encodeAMQP10(b, v.value)
+	default:
+		encodeAMQP10(b, fmt.Sprint(v))
+	}
+}
+
+// readAMQP10Value decodes an AMQP 1.0 value. The integers are decoded as uint64 or int64, the lists as []interface{}
+// and the maps as map[interface{}]interface{}.
+func readAMQP10Value(r *frameReader) interface{} {
+	return readAMQP10Encoded(r, r.uint8())
+}
+
+// readAMQP10Encoded decodes an AMQP 1.0 value whose type code was read.
+func readAMQP10Encoded(r *frameReader, code uint8) interface{} {
+	switch code {
+	case amqp10Described:
+		descriptor, _ := readAMQP10Value(r).(uint64)
+
+		return amqp10Value{descriptor: descriptor, value: readAMQP10Value(r)}
+	case amqp10Null:
+		return nil
+	case amqp10True, amqp10False:
+		return code == amqp10True
+	case amqp10Boolean:
+		return r.uint8() != 0
+	case amqp10UInt0, amqp10ULong0:
+		return uint64(0)
+	case amqp10UByte, amqp10SmallUInt, amqp10SmallULong:
+		return uint64(r.uint8())
+	case amqp10UShort:
+		return uint64(r.uint16())
+	case amqp10UInt:
+		return uint64(r.uint32())
+	case amqp10ULong:
+		return r.uint64()
+	case amqp10Byte, amqp10SmallInt, amqp10SmallLong:
+		return int64(int8(r.uint8()))
+	case amqp10Short:
+		return int64(int16(r.uint16()))
+	case amqp10Int:
+		return int64(int32(r.uint32()))
+	case amqp10Long, amqp10Timestamp:
+		return int64(r.uint64())
+	case amqp10Double:
+		return math.Float64frombits(r.uint64())
+	case amqp10VBin8, amqp10VBin32:
+		return readAMQP10Sized(r, code == amqp10VBin32)
+	case amqp10Str8, amqp10Str32:
+		return string(readAMQP10Sized(r, code == amqp10Str32))
+	case amqp10Sym8, amqp10Sym32:
+		return amqp10Symbol(readAMQP10Sized(r, code == amqp10Sym32))
+	case amqp10List0:
+		return []interface{}(nil)
+	case amqp10List8, amqp10List32, amqp10Map8, amqp10Map32:
+		items := readAMQP10Items(r, code == amqp10List32 || code == amqp10Map32)
+
+		if code == amqp10List8 || code == amqp10List32 {
+			return items
+		}
+
+		entries := make(map[interface{}]interface{}, len(items)/2)
+		for i := 0; i+1 < len(items); i += 2 {
+			entries[items[i]] = items[i+1]
+		}
+
+		return entries
+	case amqp10Array8, amqp10Array32:
+		return readAMQP10Array(r, code == amqp10Array32)
+	default:
+		skipAMQP10Encoded(r, code)
+
+		return nil
+	}
+}
+
+// readAMQP10Sized reads the bytes of a variable width value, its size being encoded on 1 or 4 bytes.
+func readAMQP10Sized(r *frameReader, wide bool) []byte {
+	if wide {
+		return r.next(int(r.uint32()))
+	}
+
+	return r.next(int(r.uint8()))
+}
+
+// readAMQP10Items reads the items of a list or of a map, their size and count being encoded on 1 or 4 bytes.
+func readAMQP10Items(r *frameReader, wide bool) []interface{} {
+	var count uint32
+
+	if wide {
+		_, count = r.uint32(), r.uint32()
+	} else {
+		_, count = r.uint8(), uint32(r.uint8())
+	}
+
+	var items []interface{}
+
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		items = append(items, readAMQP10Value(r))
+	}
+
+	return items
+}
+
+// readAMQP10Array reads the elements of an array, which share the constructor following the count.
+func readAMQP10Array(r *frameReader, wide bool) []interface{} {
+	var count uint32
+
+	if wide {
+		_, count = r.uint32(), r.uint32()
+	} else {
+		_, count = r.uint8(), uint32(r.uint8())
+	}
+
+	code := r.uint8()
+
+	var descriptor uint64
+	if code == amqp10Described {
+		descriptor, _ = readAMQP10Value(r).(uint64)
+		code = r.uint8()
+	}
+
+	var elements []interface{}
+
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		element := readAMQP10Encoded(r, code)
+
+		if descriptor != 0 {
+			element = amqp10Value{descriptor: descriptor, value: element}
+		}
+
+		elements = append(elements, element)
+	}
+
+	return elements
+}
+
+// readAMQP10ContentType reads the properties section of a message, returning its content type.
+func readAMQP10ContentType(r *frameReader) string {
+	var count uint32
+
+	switch r.uint8() {
+	case amqp10List0:
+		return ""
+	case amqp10List8:
+		_ = r.uint8()
+		count = uint32(r.uint8())
+	case amqp10List32:
+		_ = r.uint32()
+		count = r.uint32()
+	default:
+		r.err = fmt.Errorf("%w: unexpected amqp 1.0 properties", errAMQP10Protocol)
+
+		return ""
+	}
+
+	contentType := ""
+
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		if i != amqp10ContentIndex {
+			skipAMQP10Value(r)
+
+			continue
+		}
+
+		if value, ok := readAMQP10Binary(r); ok {
+			contentType = string(value)
+		}
+	}
+
+	return contentType
+}
+
+// readAMQP10Binary reads a binary, string or symbol value. Returns false, the value being skipped, if it is of another
+// type.
+func readAMQP10Binary(r *frameReader) ([]byte, bool) {
+	code := r.uint8()
+
+	switch code {
+	case amqp10VBin8, amqp10Str8, amqp10Sym8:
+		return r.next(int(r.uint8())), true
+	case amqp10VBin32, amqp10Str32, amqp10Sym32:
+		return r.next(int(r.uint32())), true
+	default:
+		skipAMQP10Encoded(r, code)
+
+		return nil, false
+	}
+}
+
+// skipAMQP10Value skips an AMQP 1.0 value.
+func skipAMQP10Value(r *frameReader) {
+	skipAMQP10Encoded(r, r.uint8())
+}
+
+// skipAMQP10Encoded skips an AMQP 1.0 value whose type code was read, its width being told by the code.
+func skipAMQP10Encoded(r *frameReader, code uint8) {
+	if code == amqp10Described {
+		skipAMQP10Value(r)
+		skipAMQP10Value(r)
+
+		return
+	}
+
+	switch code >> 4 {
+	case 0x4:
+	case 0x5:
+		r.next(1)
+	case 0x6:
+		r.next(2)
+	case 0x7:
+		r.next(4)
+	case 0x8:
+		r.next(8)
+	case 0x9:
+		r.next(16)
+	case 0xa, 0xc, 0xe:
+		r.next(int(r.uint8()))
+	case 0xb, 0xd, 0xf:
+		r.next(int(r.uint32()))
+	default:
+		r.err = fmt.Errorf("%w: unknown amqp 1.0 type %#x", errAMQP10Protocol, code)
+	}
+}
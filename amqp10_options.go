@@ -0,0 +1,164 @@
+package gorabbit
+
+import (
+	"net/url"
+	"time"
+)
+
+// AMQP10Options holds all necessary properties to publish over AMQP 1.0 with an AMQP10Publisher, to RabbitMQ 4 or to
+// any broker speaking AMQP 1.0, such as Azure Service Bus.
+type AMQP10Options struct {
+	// Host is the broker host name.
+	Host string
+
+	// Port is the AMQP 1.0 port number of the broker.
+	Port uint
+
+	// Username is the allowed username, authenticated with SASL PLAIN. SASL ANONYMOUS is used if empty.
+	Username string
+
+	// Password is the allowed password.
+	Password string
+
+	// Vhost is the RabbitMQ vhost the publishings are sent to, passed as the hostname "vhost:<vhost>" of the
+	// connection. The Host is passed instead if empty, as expected by the other brokers.
+	Vhost string
+
+	// UseTLS defines whether the connection is secured with TLS.
+	UseTLS bool
+
+	// ContainerID identifies the connection, a random one being generated if empty.
+	ContainerID string
+
+	// Timeout bounds the connection to the broker, along with the context of the publishing.
+	Timeout time.Duration
+
+	// Address returns the target address of the publishings to an exchange with a routing key. Defaults to
+	// AMQP10RabbitMQAddress. Brokers such as Azure Service Bus address their entities by name instead.
+	Address func(exchange, routingKey string) string
+
+	// Marshaller encodes the payloads, JSONMarshaller if nil. Its content type and headers are carried by the
+	// properties and the application properties of the messages.
+	Marshaller Marshaller
+}
+
+// DefaultAMQP10Options will return an AMQP10Options with default values.
+func DefaultAMQP10Options() *AMQP10Options {
+	return &AMQP10Options{
+		Host:     defaultHost,
+		Port:     defaultPort,
+		Username: defaultUsername,
+		Password: defaultPassword,
+		Vhost:    defaultVhost,
+		UseTLS:   defaultUseTLS,
+		Timeout:  defaultAMQP10Timeout,
+	}
+}
+
+// NewAMQP10Options is the exported builder for an AMQP10Options and will offer setter methods for an easy
+// construction. Any non-assigned field will be set to default through DefaultAMQP10Options.
+func NewAMQP10Options() *AMQP10Options {
+	return DefaultAMQP10Options()
+}
+
+// SetHost will assign the host.
+func (a *AMQP10Options) SetHost(host string) *AMQP10Options {
+	a.Host = host
+
+	return a
+}
+
+// SetPort will assign the AMQP 1.0 port of the broker.
+func (a *AMQP10Options) SetPort(port uint) *AMQP10Options {
+	a.Port = port
+
+	return a
+}
+
+// SetCredentials will assign the username and password.
+func (a *AMQP10Options) SetCredentials(username, password string) *AMQP10Options {
+	a.Username = username
+	a.Password = password
+
+	return a
+}
+
+// SetVhost will assign the Vhost.
+func (a *AMQP10Options) SetVhost(vhost string) *AMQP10Options {
+	a.Vhost = vhost
+
+	return a
+}
+
+// SetUseTLS will assign the UseTLS status.
+func (a *AMQP10Options) SetUseTLS(use bool) *AMQP10Options {
+	a.UseTLS = use
+
+	return a
+}
+
+// SetContainerID will assign the container identifier of the connection.
+func (a *AMQP10Options) SetContainerID(containerID string) *AMQP10Options {
+	a.ContainerID = containerID
+
+	return a
+}
+
+// SetTimeout will assign the timeout of the connection to the broker.
+func (a *AMQP10Options) SetTimeout(timeout time.Duration) *AMQP10Options {
+	a.Timeout = timeout
+
+	return a
+}
+
+// SetAddress will assign the function returning the target address of the publishings.
+func (a *AMQP10Options) SetAddress(address func(exchange, routingKey string) string) *AMQP10Options {
+	a.Address = address
+
+	return a
+}
+
+// SetMarshaller will assign the Marshaller encoding the payloads.
+func (a *AMQP10Options) SetMarshaller(marshaller Marshaller) *AMQP10Options {
+	a.Marshaller = marshaller
+
+	return a
+}
+
+// AMQP10RabbitMQAddress returns the address of RabbitMQ 4 targeting an exchange with a routing key,
+// "/exchanges/<exchange>/<routing key>", or the queue named by the routing key, "/queues/<queue>", if the exchange is
+// empty, as the default exchange does.
+func AMQP10RabbitMQAddress(exchange, routingKey string) string {
+	if exchange == "" {
+		return "/queues/" + url.PathEscape(routingKey)
+	}
+
+	return "/exchanges/" + url.PathEscape(exchange) + "/" + url.PathEscape(routingKey)
+}
+
+// hostname returns the hostname of the connection, the vhost for RabbitMQ.
+func (a *AMQP10Options) hostname() string {
+	if a.Vhost == "" {
+		return a.Host
+	}
+
+	return "vhost:" + a.Vhost
+}
+
+// address returns the target address of the publishings to the exchange with the routing key.
+func (a *AMQP10Options) address(exchange, routingKey string) string {
+	if a.Address == nil {
+		return AMQP10RabbitMQAddress(exchange, routingKey)
+	}
+
+	return a.Address(exchange, routingKey)
+}
+
+// marshaller returns the Marshaller, or the JSONMarshaller if not set.
+func (a *AMQP10Options) marshaller() Marshaller {
+	if a.Marshaller == nil {
+		return JSONMarshaller{}
+	}
+
+	return a.Marshaller
+}
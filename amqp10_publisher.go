@@ -0,0 +1,925 @@
+package gorabbit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AMQP 1.0 descriptors of the performatives, of the SASL frames and of their fields.
+const (
+	amqp10Open           = 0x10
+	amqp10Begin          = 0x11
+	amqp10Attach         = 0x12
+	amqp10Flow           = 0x13
+	amqp10Transfer       = 0x14
+	amqp10Disposition    = 0x15
+	amqp10Detach         = 0x16
+	amqp10End            = 0x17
+	amqp10Close          = 0x18
+	amqp10Error          = 0x1d
+	amqp10Accepted       = 0x24
+	amqp10Rejected       = 0x25
+	amqp10Released       = 0x26
+	amqp10Source         = 0x28
+	amqp10Target         = 0x29
+	amqp10SASLMechanisms = 0x40
+	amqp10SASLInit       = 0x41
+	amqp10SASLOutcome    = 0x44
+)
+
+// AMQP 1.0 connection settings.
+const (
+	amqp10FrameAMQP     = 0
+	amqp10FrameSASL     = 1
+	amqp10FrameHeader   = 8
+	amqp10MaxFrameSize  = 65536
+	amqp10MinFrameSize  = 512
+	amqp10SessionWindow = 2048
+	amqp10SASLOK        = 0
+	amqp10SASLAuth      = 1
+	amqp10NotFound      = "amqp:not-found"
+	amqp10Unauthorized  = "amqp:unauthorized-access"
+	amqp10SASLAnonymous = "ANONYMOUS"
+	amqp10SASLPlain     = "PLAIN"
+	amqp10ProtocolAMQP  = "AMQP\x00\x01\x00\x00"
+	amqp10ProtocolSASL  = "AMQP\x03\x01\x00\x00"
+)
+
+// AMQP10Publisher publishes over AMQP 1.0, to RabbitMQ 4 or to the brokers speaking only AMQP 1.0, such as Azure
+// Service Bus, so that the services publishing to them share the Publisher interface with the ones speaking AMQP 0-9-1.
+// The publishings target the address of their exchange and routing key, see AMQP10Options.Address, and are settled by
+// the broker before Publish returns. No connection is made until a publishing, and a lost connection is opened again
+// by the next one.
+type AMQP10Publisher struct {
+	options    AMQP10Options
+	marshaller Marshaller
+
+	// mutex protects conn and closed from concurrent access.
+	mutex  sync.Mutex
+	conn   *amqp10Conn
+	closed bool
+}
+
+// NewAMQP10Publisher will instantiate a new AMQP10Publisher. No connection is made until a publishing.
+func NewAMQP10Publisher(options *AMQP10Options) *AMQP10Publisher {
+	if options == nil {
+		options = DefaultAMQP10Options()
+	}
+
+	opts := *options
+
+	if opts.ContainerID == "" {
+		opts.ContainerID = strings.ToLower(libraryName) + "-" + uuid.NewString()
+	}
+
+	return &AMQP10Publisher{options: opts, marshaller: opts.marshaller()}
+}
+
+// Publish will send the payload to the address of the exchange and routing key, as a durable message.
+func (p *AMQP10Publisher) Publish(exchange, routingKey string, payload interface{}) error {
+	return p.PublishWithContext(context.Background(), exchange, routingKey, payload, nil)
+}
+
+// PublishWithOptions will send the payload to the address of the exchange and routing key. The DeliveryMode and the
+// MessagePriority of the options set the durability and the priority of the message.
+func (p *AMQP10Publisher) PublishWithOptions(exchange, routingKey string, payload interface{}, options *PublishingOptions) error {
+	return p.PublishWithContext(context.Background(), exchange, routingKey, payload, options)
+}
+
+// PublishWithContext behaves like PublishWithOptions, the context bounding the connection to the broker and the wait
+// for the settlement of the message. options can be nil.
+// Returns ErrUnroutable if the broker released the message, as RabbitMQ does when no queue is bound.
+func (p *AMQP10Publisher) PublishWithContext(
+	ctx context.Context,
+	exchange string,
+	routingKey string,
+	payload interface{},
+	options *PublishingOptions,
+) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	metadata := &MessageMetadata{
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Type:       routingKey,
+		Headers:    make(map[string]interface{}),
+	}
+
+	body, err := p.marshaller.Marshal(metadata, payload)
+	if err != nil {
+		return err
+	}
+
+	conn, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	// A missing target is reported as a missing exchange, or as a missing queue for the default exchange.
+	notFound := ErrExchangeNotFound
+	if exchange == "" {
+		notFound = ErrQueueNotFound
+	}
+
+	link, err := conn.link(ctx, p.options.address(exchange, routingKey), notFound)
+	if err != nil {
+		return err
+	}
+
+	return conn.transfer(ctx, link, encodeAMQP10Message(metadata, body, options))
+}
+
+// Disconnect closes the connection to the broker, if any. The AMQP10Publisher cannot publish anymore.
+func (p *AMQP10Publisher) Disconnect() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+
+	if p.conn == nil {
+		return nil
+	}
+
+	err := p.conn.disconnect()
+
+	p.conn = nil
+
+	return err
+}
+
+// connect returns the current connection, or a new one if there is none or it was lost.
+func (p *AMQP10Publisher) connect(ctx context.Context) (*amqp10Conn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return nil, errConnectionClosed
+	}
+
+	if p.conn != nil && !p.conn.isClosed() {
+		return p.conn, nil
+	}
+
+	conn, err := dialAMQP10(ctx, p.options)
+	if err != nil {
+		return nil, err
+	}
+
+	p.conn = conn
+
+	return conn, nil
+}
+
+// encodeAMQP10Message encodes the payload as an AMQP 1.0 message: the metadata set by the Marshaller are carried by
+// its properties and application properties, and the type by its subject, as RabbitMQ maps it from AMQP 0-9-1.
+func encodeAMQP10Message(metadata *MessageMetadata, body []byte, options *PublishingOptions) []byte {
+	if options == nil {
+		options = &PublishingOptions{}
+	}
+
+	message := new(frameBuffer)
+
+	header := []interface{}{options.mode() == Persistent.Uint8(), nil}
+	if options.MessagePriority != nil {
+		header[1] = options.priority()
+	}
+
+	encodeAMQP10(message, amqp10Value{descriptor: amqp10Header, value: header})
+
+	properties := make([]interface{}, amqp10ContentIndex+1)
+	properties[0] = uuid.NewString()
+	properties[3] = metadata.Type
+
+	if options.ReplyTo != "" {
+		properties[4] = options.ReplyTo
+	}
+
+	if options.CorrelationID != "" {
+		properties[5] = options.CorrelationID
+	}
+
+	if metadata.ContentType != "" {
+		properties[amqp10ContentIndex] = amqp10Symbol(metadata.ContentType)
+	}
+
+	encodeAMQP10(message, amqp10Value{descriptor: amqp10Properties, value: properties})
+
+	if len(metadata.Headers) > 0 {
+		encodeAMQP10(message, amqp10Value{descriptor: amqp10ApplicationProperties, value: metadata.Headers})
+	}
+
+	encodeAMQP10(message, amqp10Value{descriptor: amqp10Data, value: body})
+
+	return message.Bytes()
+}
+
+// amqp10Link is a sender link of an amqp10Conn, attached to an address.
+type amqp10Link struct {
+	name    string
+	handle  uint32
+	address string
+
+	// notFound is the error of a missing address.
+	notFound error
+
+	// attached is closed once the link is attached, or refused with err.
+	attached chan struct{}
+
+	// credited is signaled when the broker grants credits to the link, or detaches it, each waiter signaling the next
+	// one while the link has credits left.
+	credited chan struct{}
+
+	// deliveryCount and credit are the flow control state of the link, err the reason it was detached, protected by
+	// the mutex of the connection.
+	deliveryCount uint32
+	credit        uint32
+	err           error
+}
+
+// signal wakes up a publishing waiting for a credit of the link, if any.
+func (l *amqp10Link) signal() {
+	select {
+	case l.credited <- struct{}{}:
+	default:
+	}
+}
+
+// amqp10Delivery is a transfer waiting for its settlement.
+type amqp10Delivery struct {
+	link    *amqp10Link
+	settled chan error
+}
+
+// amqp10Conn is a connection to an AMQP 1.0 broker, with a single session whose sender links are attached on demand,
+// one per address.
+type amqp10Conn struct {
+	conn         net.Conn
+	maxFrameSize uint32
+
+	// writeMutex serializes the frames written by the transfers, the links and the heartbeats.
+	writeMutex sync.Mutex
+
+	// mutex protects the fields below from concurrent access.
+	mutex          sync.Mutex
+	links          map[string]*amqp10Link
+	remoteHandles  map[uint32]*amqp10Link
+	nextHandle     uint32
+	nextDeliveryID uint32
+	deliveries     map[uint32]amqp10Delivery
+	err            error
+
+	// done is closed once the connection is lost or disconnected, err holding the reason.
+	done chan struct{}
+}
+
+// dialAMQP10 opens a connection to the broker, authenticates and begins the session, within the Timeout of the options
+// and the context.
+func dialAMQP10(ctx context.Context, options AMQP10Options) (*amqp10Conn, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	address := net.JoinHostPort(options.Host, strconv.Itoa(int(options.Port)))
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if options.UseTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: options.Host, MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = new(net.Dialer).DialContext(ctx, "tcp", address)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	// The handshake is bounded by the context as well.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c := &amqp10Conn{
+		conn:          conn,
+		maxFrameSize:  amqp10MaxFrameSize,
+		links:         make(map[string]*amqp10Link),
+		remoteHandles: make(map[uint32]*amqp10Link),
+		deliveries:    make(map[uint32]amqp10Delivery),
+		done:          make(chan struct{}),
+	}
+
+	idleTimeout, err := c.handshake(reader, options)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	go c.read(reader)
+
+	if idleTimeout > 0 {
+		go c.heartbeat(idleTimeout / 2)
+	}
+
+	return c, nil
+}
+
+// handshake authenticates with SASL, opens the connection and begins the session. Returns the idle timeout of the
+// broker, the connection being closed if no frame is sent within it.
+func (c *amqp10Conn) handshake(reader *bufio.Reader, options AMQP10Options) (time.Duration, error) {
+	if err := c.exchangeProtocol(reader, amqp10ProtocolSASL); err != nil {
+		return 0, err
+	}
+
+	if _, err := readAMQP10Performative(reader, amqp10SASLMechanisms); err != nil {
+		return 0, err
+	}
+
+	mechanism, response := amqp10Symbol(amqp10SASLAnonymous), []byte(nil)
+
+	if options.Username != "" {
+		mechanism = amqp10SASLPlain
+		response = []byte("\x00" + options.Username + "\x00" + options.Password)
+	}
+
+	init := amqp10Value{descriptor: amqp10SASLInit, value: []interface{}{mechanism, response, options.hostname()}}
+	if err := c.write(amqp10FrameSASL, init, nil); err != nil {
+		return 0, err
+	}
+
+	outcome, err := readAMQP10Performative(reader, amqp10SASLOutcome)
+	if err != nil {
+		return 0, err
+	}
+
+	switch code := amqp10Uint(outcome.fields(), 0); code {
+	case amqp10SASLOK:
+	case amqp10SASLAuth:
+		return 0, fmt.Errorf("%w: amqp 1.0 authentication failed", ErrAccessRefused)
+	default:
+		return 0, fmt.Errorf("%w: amqp 1.0 authentication failed with code %d", ErrNotConnected, code)
+	}
+
+	if err = c.exchangeProtocol(reader, amqp10ProtocolAMQP); err != nil {
+		return 0, err
+	}
+
+	open := amqp10Value{descriptor: amqp10Open, value: []interface{}{
+		options.ContainerID, options.hostname(), uint32(amqp10MaxFrameSize),
+	}}
+	if err = c.write(amqp10FrameAMQP, open, nil); err != nil {
+		return 0, err
+	}
+
+	opened, err := readAMQP10Performative(reader, amqp10Open)
+	if err != nil {
+		return 0, err
+	}
+
+	if size := uint32(amqp10Uint(opened.fields(), 2)); size >= amqp10MinFrameSize && size < c.maxFrameSize {
+		c.maxFrameSize = size
+	}
+
+	begin := amqp10Value{descriptor: amqp10Begin, value: []interface{}{
+		nil, uint32(0), uint32(amqp10SessionWindow), uint32(amqp10SessionWindow),
+	}}
+	if err = c.write(amqp10FrameAMQP, begin, nil); err != nil {
+		return 0, err
+	}
+
+	if _, err = readAMQP10Performative(reader, amqp10Begin); err != nil {
+		return 0, err
+	}
+
+	return time.Duration(amqp10Uint(opened.fields(), 4)) * time.Millisecond, nil
+}
+
+// exchangeProtocol sends the protocol header, and reads the one of the broker, which must be the same.
+func (c *amqp10Conn) exchangeProtocol(reader *bufio.Reader, protocol string) error {
+	if _, err := c.conn.Write([]byte(protocol)); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	header := make([]byte, len(protocol))
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	if string(header) != protocol {
+		return fmt.Errorf("%w: unsupported protocol %q", ErrNotConnected, header)
+	}
+
+	return nil
+}
+
+// link returns the sender link attached to the address, attaching it first if needed.
+func (c *amqp10Conn) link(ctx context.Context, address string, notFound error) (*amqp10Link, error) {
+	c.mutex.Lock()
+
+	if c.err != nil {
+		c.mutex.Unlock()
+
+		return nil, c.err
+	}
+
+	link, found := c.links[address]
+
+	if !found {
+		link = &amqp10Link{
+			name:     address + "-" + uuid.NewString(),
+			handle:   c.nextHandle,
+			address:  address,
+			notFound: notFound,
+			attached: make(chan struct{}),
+			credited: make(chan struct{}, 1),
+		}
+
+		c.nextHandle++
+		c.links[address] = link
+	}
+
+	c.mutex.Unlock()
+
+	if !found {
+		// The link sends its messages unsettled, the broker settling them once routed.
+		attach := amqp10Value{descriptor: amqp10Attach, value: []interface{}{
+			link.name, link.handle, false, uint8(0), uint8(0),
+			amqp10Value{descriptor: amqp10Source, value: []interface{}{}},
+			amqp10Value{descriptor: amqp10Target, value: []interface{}{address}},
+			nil, false, uint32(0),
+		}}
+
+		if err := c.write(amqp10FrameAMQP, attach, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	select {
+	case <-link.attached:
+	case <-c.done:
+		return nil, c.closedErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return link, link.err
+}
+
+// transfer sends a message on the link once it has credits, and waits for its settlement until the context is done.
+func (c *amqp10Conn) transfer(ctx context.Context, link *amqp10Link, message []byte) error {
+	if err := c.reserve(ctx, link); err != nil {
+		return err
+	}
+
+	// The delivery identifiers are assigned in the order of the transfers.
+	c.writeMutex.Lock()
+
+	c.mutex.Lock()
+	deliveryID := c.nextDeliveryID
+	c.nextDeliveryID++
+
+	settled := make(chan error, 1)
+	c.deliveries[deliveryID] = amqp10Delivery{link: link, settled: settled}
+	c.mutex.Unlock()
+
+	err := c.writeLocked(amqp10Transfers(link.handle, deliveryID, message, c.maxFrameSize)...)
+
+	c.writeMutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.deliveries, deliveryID)
+		c.mutex.Unlock()
+	}()
+
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err = <-settled:
+		return err
+	case <-c.done:
+		return c.closedErr()
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", errPublishingNotConfirmed, ctx.Err())
+	}
+}
+
+// reserve waits for a credit of the link, and takes it.
+func (c *amqp10Conn) reserve(ctx context.Context, link *amqp10Link) error {
+	for {
+		c.mutex.Lock()
+
+		if link.err != nil || c.err != nil {
+			err := link.err
+			if err == nil {
+				err = c.err
+			}
+
+			link.signal()
+			c.mutex.Unlock()
+
+			return err
+		}
+
+		if link.credit > 0 {
+			link.credit--
+			link.deliveryCount++
+
+			if link.credit > 0 {
+				link.signal()
+			}
+
+			c.mutex.Unlock()
+
+			return nil
+		}
+
+		c.mutex.Unlock()
+
+		select {
+		case <-link.credited:
+		case <-c.done:
+		case <-ctx.Done():
+			return fmt.Errorf("%w: no credit granted: %w", ErrConnectionBlocked, ctx.Err())
+		}
+	}
+}
+
+// amqp10Transfers returns the transfer frames of a message, split across several ones if it exceeds the frame size.
+func amqp10Transfers(handle, deliveryID uint32, message []byte, maxFrameSize uint32) [][]byte {
+	tag := binary.BigEndian.AppendUint32(nil, deliveryID)
+
+	fields := func(more bool) amqp10Value {
+		return amqp10Value{descriptor: amqp10Transfer, value: []interface{}{
+			handle, deliveryID, tag, uint32(0), false, more,
+		}}
+	}
+
+	performative := new(frameBuffer)
+	encodeAMQP10(performative, fields(true))
+
+	size := int(maxFrameSize) - amqp10FrameHeader - performative.Len()
+
+	var frames [][]byte
+
+	for len(message) > size {
+		frames = append(frames, amqp10Frame(amqp10FrameAMQP, fields(true), message[:size]))
+		message = message[size:]
+	}
+
+	return append(frames, amqp10Frame(amqp10FrameAMQP, fields(false), message))
+}
+
+// read dispatches the performatives of the broker until the connection is lost.
+func (c *amqp10Conn) read(reader *bufio.Reader) {
+	for {
+		_, performative, err := readAMQP10Frame(reader)
+		if err != nil {
+			c.close(fmt.Errorf("%w: %w", ErrNotConnected, err))
+
+			return
+		}
+
+		if err = c.dispatch(performative); err != nil {
+			c.close(err)
+
+			return
+		}
+	}
+}
+
+// dispatch handles a performative of the broker. Returns an error if the connection is to be closed.
+func (c *amqp10Conn) dispatch(performative amqp10Value) error {
+	fields := performative.fields()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch performative.descriptor {
+	case amqp10Attach:
+		for _, link := range c.links {
+			if link.name != amqp10Field(fields, 0) {
+				continue
+			}
+
+			c.remoteHandles[uint32(amqp10Uint(fields, 1))] = link
+
+			// A refused link is attached without target, then detached with the error.
+			if amqp10Field(fields, 6) != nil {
+				close(link.attached)
+			}
+		}
+	case amqp10Flow:
+		link, found := c.remoteHandles[uint32(amqp10Uint(fields, 4))]
+		if !found || amqp10Field(fields, 4) == nil {
+			return nil
+		}
+
+		link.credit = uint32(amqp10Uint(fields, 5)) + uint32(amqp10Uint(fields, 6)) - link.deliveryCount
+
+		link.signal()
+	case amqp10Disposition:
+		first := uint32(amqp10Uint(fields, 1))
+
+		last := first
+		if amqp10Field(fields, 2) != nil {
+			last = uint32(amqp10Uint(fields, 2))
+		}
+
+		state, _ := amqp10Field(fields, 4).(amqp10Value)
+
+		for deliveryID, delivery := range c.deliveries {
+			if deliveryID >= first && deliveryID <= last {
+				delivery.settled <- amqp10Outcome(state, delivery.link)
+				delete(c.deliveries, deliveryID)
+			}
+		}
+	case amqp10Detach:
+		link, found := c.remoteHandles[uint32(amqp10Uint(fields, 0))]
+		if !found {
+			return nil
+		}
+
+		c.detach(link, amqp10LinkError(amqp10Field(fields, 2), link.notFound))
+
+		go func() {
+			detach := amqp10Value{descriptor: amqp10Detach, value: []interface{}{link.handle, true}}
+			_ = c.write(amqp10FrameAMQP, detach, nil)
+		}()
+	case amqp10End, amqp10Close:
+		return amqp10CloseError(amqp10Field(fields, 0))
+	}
+
+	return nil
+}
+
+// detach forgets the link, failing its attachment and its deliveries with the given error.
+func (c *amqp10Conn) detach(link *amqp10Link, err error) {
+	link.err = err
+
+	delete(c.links, link.address)
+
+	for handle, remote := range c.remoteHandles {
+		if remote == link {
+			delete(c.remoteHandles, handle)
+		}
+	}
+
+	select {
+	case <-link.attached:
+	default:
+		close(link.attached)
+	}
+
+	link.signal()
+
+	for deliveryID, delivery := range c.deliveries {
+		if delivery.link == link {
+			delivery.settled <- err
+			delete(c.deliveries, deliveryID)
+		}
+	}
+}
+
+// amqp10Outcome returns the error of the settlement of a delivery, nil if it was accepted.
+func amqp10Outcome(state amqp10Value, link *amqp10Link) error {
+	switch state.descriptor {
+	case amqp10Accepted:
+		return nil
+	case amqp10Rejected:
+		return amqp10LinkError(amqp10Field(state.fields(), 0), errPublishingNotConfirmed)
+	case amqp10Released:
+		return fmt.Errorf("%w: message released by the broker, to %s", ErrUnroutable, link.address)
+	default:
+		return fmt.Errorf("%w: message settled with outcome %#x", errPublishingNotConfirmed, state.descriptor)
+	}
+}
+
+// amqp10LinkError returns the error of an AMQP 1.0 error field, matching its condition with the errors of the
+// client failure modes, the given one being the fallback.
+func amqp10LinkError(field interface{}, fallback error) error {
+	value, ok := field.(amqp10Value)
+	if !ok || value.descriptor != amqp10Error {
+		return fmt.Errorf("%w: no error condition given by the broker", fallback)
+	}
+
+	condition, _ := amqp10Field(value.fields(), 0).(amqp10Symbol)
+	description, _ := amqp10Field(value.fields(), 1).(string)
+
+	switch condition {
+	case amqp10Unauthorized:
+		return fmt.Errorf("%w: %s: %s", ErrAccessRefused, condition, description)
+	case amqp10NotFound:
+		return fmt.Errorf("%w: %s: %s", fallback, condition, description)
+	default:
+		if fallback == ErrExchangeNotFound || fallback == ErrQueueNotFound {
+			fallback = ErrNotConnected
+		}
+
+		return fmt.Errorf("%w: %s: %s", fallback, condition, description)
+	}
+}
+
+// write sends a frame, closing the connection if it cannot be sent.
+func (c *amqp10Conn) write(frameType uint8, performative amqp10Value, payload []byte) error {
+	return c.writeFrames(amqp10Frame(frameType, performative, payload))
+}
+
+// writeFrames sends the frames one after the other, closing the connection if they cannot be sent.
+func (c *amqp10Conn) writeFrames(frames ...[]byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	return c.writeLocked(frames...)
+}
+
+// writeLocked sends the frames while holding the writeMutex.
+func (c *amqp10Conn) writeLocked(frames ...[]byte) error {
+	if c.isClosed() {
+		return c.closedErr()
+	}
+
+	for _, frame := range frames {
+		if _, err := c.conn.Write(frame); err != nil {
+			c.close(fmt.Errorf("%w: %w", ErrNotConnected, err))
+
+			return c.closedErr()
+		}
+	}
+
+	return nil
+}
+
+// heartbeat sends an empty frame at the given interval until the connection is closed, so that an idle connection is
+// kept open.
+func (c *amqp10Conn) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	empty := binary.BigEndian.AppendUint32(nil, amqp10FrameHeader)
+	empty = append(empty, 2, amqp10FrameAMQP, 0, 0)
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			_ = c.writeFrames(empty)
+		}
+	}
+}
+
+// disconnect closes the connection, letting the broker know first.
+func (c *amqp10Conn) disconnect() error {
+	err := c.write(amqp10FrameAMQP, amqp10Value{descriptor: amqp10Close, value: []interface{}{}}, nil)
+
+	c.close(errConnectionClosed)
+
+	return err
+}
+
+// close closes the connection once, failing the pending deliveries with the given error.
+func (c *amqp10Conn) close(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = err
+
+	for _, link := range c.links {
+		c.detach(link, err)
+	}
+
+	close(c.done)
+
+	_ = c.conn.Close()
+}
+
+// isClosed returns true once the connection is lost or disconnected.
+func (c *amqp10Conn) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// closedErr returns the reason why the connection was closed.
+func (c *amqp10Conn) closedErr() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
+
+// amqp10Frame returns the frame of the performative followed by the payload, prefixed with its header.
+func amqp10Frame(frameType uint8, performative amqp10Value, payload []byte) []byte {
+	body := new(frameBuffer)
+	encodeAMQP10(body, performative)
+	body.Write(payload)
+
+	frame := new(frameBuffer)
+	frame.uint32(uint32(amqp10FrameHeader + body.Len())).uint8(2).uint8(frameType).uint16(0)
+	frame.Write(body.Bytes())
+
+	return frame.Bytes()
+}
+
+// readAMQP10Frame reads the next frame holding a performative, skipping the empty ones, and returns its type along
+// with its performative.
+func readAMQP10Frame(reader io.Reader) (uint8, amqp10Value, error) {
+	for {
+		header := make([]byte, amqp10FrameHeader)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return 0, amqp10Value{}, err
+		}
+
+		size, offset := binary.BigEndian.Uint32(header), uint32(header[4])*4
+		if size < amqp10FrameHeader || offset < amqp10FrameHeader || offset > size {
+			return 0, amqp10Value{}, fmt.Errorf("%w: frame of %d bytes", errAMQP10Protocol, size)
+		}
+
+		frame := make([]byte, size-amqp10FrameHeader)
+		if _, err := io.ReadFull(reader, frame); err != nil {
+			return 0, amqp10Value{}, err
+		}
+
+		frame = frame[offset-amqp10FrameHeader:]
+
+		if len(frame) == 0 {
+			continue
+		}
+
+		r := &frameReader{data: frame}
+
+		performative, ok := readAMQP10Value(r).(amqp10Value)
+		if r.err != nil || !ok {
+			return 0, amqp10Value{}, fmt.Errorf("%w: frame without performative", errAMQP10Protocol)
+		}
+
+		return header[5], performative, nil
+	}
+}
+
+// readAMQP10Performative reads the next performative of the handshake, which must have the given descriptor. A close
+// of the broker is returned as an error.
+func readAMQP10Performative(reader io.Reader, descriptor uint64) (amqp10Value, error) {
+	_, performative, err := readAMQP10Frame(reader)
+	if err != nil {
+		return performative, fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	if performative.descriptor == amqp10Close {
+		return performative, amqp10CloseError(amqp10Field(performative.fields(), 0))
+	}
+
+	if performative.descriptor != descriptor {
+		return performative, fmt.Errorf("%w: expected %#x, got %#x", errAMQP10Protocol, descriptor, performative.descriptor)
+	}
+
+	return performative, nil
+}
+
+// amqp10CloseError returns the error of the error field of an end or a close of the broker, which always wraps
+// ErrNotConnected, or ErrAccessRefused.
+func amqp10CloseError(field interface{}) error {
+	err := amqp10LinkError(field, ErrNotConnected)
+	if errors.Is(err, ErrNotConnected) || errors.Is(err, ErrAccessRefused) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrNotConnected, err)
+}
@@ -0,0 +1,487 @@
+package gorabbit_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// fakeAMQP10Described is a described value decoded or encoded by a fakeAMQP10Broker.
+type fakeAMQP10Described struct {
+	descriptor uint64
+	value      interface{}
+}
+
+// fakeAMQP10Symbol is a symbol decoded or encoded by a fakeAMQP10Broker.
+type fakeAMQP10Symbol string
+
+// fakeAMQP10Message is a message received by a fakeAMQP10Broker.
+type fakeAMQP10Message struct {
+	Address     string
+	Durable     bool
+	Priority    interface{}
+	Subject     interface{}
+	ContentType interface{}
+	Body        string
+}
+
+// fakeAMQP10Broker is a minimal AMQP 1.0 broker accepting the sender links of the publishers, and settling their
+// transfers with the configured outcome. The links to the missing addresses are refused.
+type fakeAMQP10Broker struct {
+	listener net.Listener
+
+	mutex     sync.Mutex
+	saslCode  uint8
+	outcome   uint64
+	missing   map[string]bool
+	hostname  string
+	mechanism string
+	response  string
+	received  []fakeAMQP10Message
+}
+
+// newFakeAMQP10Broker starts a fakeAMQP10Broker, stopped at the end of the test.
+func newFakeAMQP10Broker(t *testing.T) *fakeAMQP10Broker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &fakeAMQP10Broker{listener: listener, outcome: 0x24, missing: make(map[string]bool)}
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go b.serve(conn)
+		}
+	}()
+
+	return b
+}
+
+func (b *fakeAMQP10Broker) options() *gorabbit.AMQP10Options {
+	return gorabbit.NewAMQP10Options().
+		SetPort(uint(b.listener.Addr().(*net.TCPAddr).Port)).
+		SetTimeout(time.Second)
+}
+
+func (b *fakeAMQP10Broker) messages() []fakeAMQP10Message {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]fakeAMQP10Message(nil), b.received...)
+}
+
+func (b *fakeAMQP10Broker) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+
+	send := func(frameType byte, descriptor uint64, fields ...interface{}) {
+		body := encodeFakeAMQP10(nil, fakeAMQP10Described{descriptor: descriptor, value: fields})
+
+		frame := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+		frame = append(frame, 2, frameType, 0, 0)
+
+		_, _ = conn.Write(append(frame, body...))
+	}
+
+	addresses := make(map[uint64]string)
+	deliveries := make(map[uint64][]byte)
+
+	for {
+		performative, payload, err := readFakeAMQP10Frame(reader)
+		if err != nil {
+			return
+		}
+
+		fields, _ := performative.value.([]interface{})
+
+		switch performative.descriptor {
+		case 0: // protocol header
+			_, _ = conn.Write(payload)
+
+			if payload[4] == 3 {
+				send(1, 0x40, fakeAMQP10Symbol("PLAIN"))
+			}
+		case 0x41: // sasl-init
+			b.mutex.Lock()
+			b.mechanism = string(fields[0].(fakeAMQP10Symbol))
+			response, _ := fields[1].([]byte)
+			b.response = string(response)
+			b.hostname, _ = fields[2].(string)
+			code := b.saslCode
+			b.mutex.Unlock()
+
+			send(1, 0x44, code)
+		case 0x10: // open
+			send(0, 0x10, "broker", nil, uint32(512))
+		case 0x11: // begin
+			send(0, 0x11, uint16(0), uint32(0), uint32(2048), uint32(2048))
+		case 0x12: // attach
+			target := fields[6].(fakeAMQP10Described).value.([]interface{})
+			address := target[0].(string)
+			handle := fields[1].(uint64)
+
+			b.mutex.Lock()
+			missing := b.missing[address]
+			b.mutex.Unlock()
+
+			if missing {
+				send(0, 0x12, fields[0], uint32(handle), true, nil, nil, nil, nil)
+				send(0, 0x16, uint32(handle), true, fakeAMQP10Described{descriptor: 0x1d, value: []interface{}{
+					fakeAMQP10Symbol("amqp:not-found"), "no such address",
+				}})
+
+				continue
+			}
+
+			addresses[handle] = address
+
+			send(0, 0x12, fields[0], uint32(handle), true, nil, nil, fields[5], fields[6])
+			send(0, 0x13, uint32(0), uint32(2048), uint32(0), uint32(2048), uint32(handle), uint32(0), uint32(100))
+		case 0x14: // transfer
+			deliveryID := fields[1].(uint64)
+			deliveries[deliveryID] = append(deliveries[deliveryID], payload...)
+
+			if more, _ := fields[5].(bool); more {
+				continue
+			}
+
+			message := decodeFakeAMQP10Message(deliveries[deliveryID])
+			message.Address = addresses[fields[0].(uint64)]
+			delete(deliveries, deliveryID)
+
+			b.mutex.Lock()
+			b.received = append(b.received, message)
+			outcome := b.outcome
+			b.mutex.Unlock()
+
+			var state []interface{}
+			if outcome == 0x25 {
+				state = []interface{}{fakeAMQP10Described{descriptor: 0x1d, value: []interface{}{
+					fakeAMQP10Symbol("amqp:internal-error"), "rejected",
+				}}}
+			}
+
+			send(0, 0x15, true, uint32(deliveryID), nil, true, fakeAMQP10Described{descriptor: outcome, value: state})
+		case 0x18: // close
+			send(0, 0x18)
+
+			return
+		}
+	}
+}
+
+// decodeFakeAMQP10Message decodes the sections of a message.
+func decodeFakeAMQP10Message(data []byte) fakeAMQP10Message {
+	var message fakeAMQP10Message
+
+	for len(data) > 0 {
+		var section interface{}
+
+		section, data = decodeFakeAMQP10(data)
+
+		described := section.(fakeAMQP10Described)
+		fields, _ := described.value.([]interface{})
+
+		switch described.descriptor {
+		case 0x70:
+			message.Durable, _ = fields[0].(bool)
+			message.Priority = fields[1]
+		case 0x73:
+			message.Subject = fields[3]
+			message.ContentType = fields[6]
+		case 0x75:
+			message.Body += string(described.value.([]byte))
+		}
+	}
+
+	return message
+}
+
+// readFakeAMQP10Frame reads a protocol header, returned as a performative without descriptor, or a frame, returned as
+// its performative and its payload.
+func readFakeAMQP10Frame(reader *bufio.Reader) (fakeAMQP10Described, []byte, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return fakeAMQP10Described{}, nil, err
+	}
+
+	if string(header[:4]) == "AMQP" {
+		return fakeAMQP10Described{}, header, nil
+	}
+
+	body := make([]byte, binary.BigEndian.Uint32(header)-8)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return fakeAMQP10Described{}, nil, err
+	}
+
+	if len(body) == 0 {
+		return readFakeAMQP10Frame(reader)
+	}
+
+	performative, payload := decodeFakeAMQP10(body)
+
+	return performative.(fakeAMQP10Described), payload, nil
+}
+
+// decodeFakeAMQP10 decodes the types encoded by the publishers, returning the value and the remaining data.
+func decodeFakeAMQP10(data []byte) (interface{}, []byte) {
+	code, data := data[0], data[1:]
+
+	switch code {
+	case 0x00:
+		descriptor, data := decodeFakeAMQP10(data)
+		value, data := decodeFakeAMQP10(data)
+
+		return fakeAMQP10Described{descriptor: descriptor.(uint64), value: value}, data
+	case 0x40:
+		return nil, data
+	case 0x41, 0x42:
+		return code == 0x41, data
+	case 0x50, 0x53:
+		return uint64(data[0]), data[1:]
+	case 0x60:
+		return uint64(binary.BigEndian.Uint16(data)), data[2:]
+	case 0x70:
+		return uint64(binary.BigEndian.Uint32(data)), data[4:]
+	case 0x80:
+		return binary.BigEndian.Uint64(data), data[8:]
+	case 0xa0, 0xa1, 0xa3, 0xb0, 0xb1, 0xb3:
+		size, width := int(data[0]), 1
+
+		if code >= 0xb0 {
+			size, width = int(binary.BigEndian.Uint32(data)), 4
+		}
+
+		data = data[width:]
+
+		value := data[:size]
+
+		switch code & 0x0f {
+		case 0x01:
+			return string(value), data[size:]
+		case 0x03:
+			return fakeAMQP10Symbol(value), data[size:]
+		default:
+			return append([]byte(nil), value...), data[size:]
+		}
+	case 0xd0, 0xd1:
+		count := int(binary.BigEndian.Uint32(data[4:]))
+		data = data[8:]
+
+		items := make([]interface{}, count)
+		for i := range items {
+			items[i], data = decodeFakeAMQP10(data)
+		}
+
+		return items, data
+	default:
+		panic("unexpected amqp 1.0 type")
+	}
+}
+
+// encodeFakeAMQP10 encodes the values sent by a fakeAMQP10Broker.
+func encodeFakeAMQP10(data []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(data, 0x40)
+	case bool:
+		if v {
+			return append(data, 0x41)
+		}
+
+		return append(data, 0x42)
+	case uint8:
+		return append(data, 0x50, v)
+	case uint16:
+		return binary.BigEndian.AppendUint16(append(data, 0x60), v)
+	case uint32:
+		return binary.BigEndian.AppendUint32(append(data, 0x70), v)
+	case string:
+		return append(binary.BigEndian.AppendUint32(append(data, 0xb1), uint32(len(v))), v...)
+	case fakeAMQP10Symbol:
+		return append(binary.BigEndian.AppendUint32(append(data, 0xb3), uint32(len(v))), v...)
+	case []interface{}:
+		var items []byte
+		for _, item := range v {
+			items = encodeFakeAMQP10(items, item)
+		}
+
+		data = binary.BigEndian.AppendUint32(append(data, 0xd0), uint32(4+len(items)))
+		data = binary.BigEndian.AppendUint32(data, uint32(len(v)))
+
+		return append(data, items...)
+	case fakeAMQP10Described:
+		return encodeFakeAMQP10(append(data, 0x00, 0x53, byte(v.descriptor)), v.value)
+	default:
+		panic("unexpected value")
+	}
+}
+
+func TestAMQP10Publisher_Publish(t *testing.T) {
+	broker := newFakeAMQP10Broker(t)
+
+	publisher := gorabbit.NewAMQP10Publisher(broker.options().SetVhost("events"))
+	defer func() { _ = publisher.Disconnect() }()
+
+	large := strings.Repeat("a", 2000)
+
+	require.NoError(t, publisher.Publish("events_exchange", "event.created", "created"))
+	require.NoError(t, publisher.Publish("", "orders", large))
+	require.NoError(t, publisher.Publish("events_exchange", "event.created", "again"))
+
+	assert.Equal(t, []fakeAMQP10Message{
+		{
+			Address:     "/exchanges/events_exchange/event.created",
+			Durable:     true,
+			Subject:     "event.created",
+			ContentType: fakeAMQP10Symbol("application/json"),
+			Body:        `"created"`,
+		},
+		{
+			Address:     "/queues/orders",
+			Durable:     true,
+			Subject:     "orders",
+			ContentType: fakeAMQP10Symbol("application/json"),
+			Body:        `"` + large + `"`,
+		},
+		{
+			Address:     "/exchanges/events_exchange/event.created",
+			Durable:     true,
+			Subject:     "event.created",
+			ContentType: fakeAMQP10Symbol("application/json"),
+			Body:        `"again"`,
+		},
+	}, broker.messages())
+
+	// The vhost is passed as the hostname, the credentials with SASL PLAIN.
+	assert.Equal(t, "vhost:events", broker.hostname)
+	assert.Equal(t, "PLAIN", broker.mechanism)
+	assert.Equal(t, "\x00guest\x00guest", broker.response)
+}
+
+func TestAMQP10Publisher_PublishWithOptions(t *testing.T) {
+	broker := newFakeAMQP10Broker(t)
+
+	publisher := gorabbit.NewAMQP10Publisher(broker.options().SetAddress(func(_, routingKey string) string {
+		return routingKey
+	}))
+	defer func() { _ = publisher.Disconnect() }()
+
+	options := gorabbit.SendOptions().SetMode(gorabbit.Transient).SetPriority(gorabbit.PriorityHigh)
+
+	require.NoError(t, publisher.PublishWithOptions("events_exchange", "event.created", "created", options))
+
+	assert.Equal(t, []fakeAMQP10Message{{
+		Address:     "event.created",
+		Priority:    uint64(gorabbit.PriorityHigh),
+		Subject:     "event.created",
+		ContentType: fakeAMQP10Symbol("application/json"),
+		Body:        `"created"`,
+	}}, broker.messages())
+}
+
+func TestAMQP10Publisher_Outcomes(t *testing.T) {
+	broker := newFakeAMQP10Broker(t)
+	broker.missing["/exchanges/missing_exchange/event.created"] = true
+	broker.missing["/queues/missing_queue"] = true
+
+	publisher := gorabbit.NewAMQP10Publisher(broker.options())
+	defer func() { _ = publisher.Disconnect() }()
+
+	require.ErrorIs(t, publisher.Publish("missing_exchange", "event.created", "created"), gorabbit.ErrExchangeNotFound)
+	require.ErrorIs(t, publisher.Publish("", "missing_queue", "created"), gorabbit.ErrQueueNotFound)
+
+	broker.mutex.Lock()
+	broker.outcome = 0x26
+	broker.mutex.Unlock()
+
+	require.ErrorIs(t, publisher.Publish("events_exchange", "event.created", "created"), gorabbit.ErrUnroutable)
+
+	broker.mutex.Lock()
+	broker.outcome = 0x25
+	broker.mutex.Unlock()
+
+	err := publisher.Publish("events_exchange", "event.created", "created")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "amqp:internal-error")
+}
+
+func TestAMQP10Publisher_Errors(t *testing.T) {
+	broker := newFakeAMQP10Broker(t)
+	broker.saslCode = 1
+
+	publisher := gorabbit.NewAMQP10Publisher(broker.options())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.ErrorIs(t, publisher.PublishWithContext(ctx, "", "orders", "created", nil), gorabbit.ErrAccessRefused)
+
+	require.NoError(t, publisher.Disconnect())
+
+	require.ErrorIs(t, publisher.Publish("", "orders", "created"), gorabbit.ErrNotConnected)
+
+	unreachable := gorabbit.NewAMQP10Publisher(gorabbit.NewAMQP10Options().SetPort(1))
+
+	require.ErrorIs(t, unreachable.Publish("", "orders", "created"), gorabbit.ErrNotConnected)
+}
+
+func TestClient_AMQP10Publishing(t *testing.T) {
+	server := newFakeServer(t)
+	broker := newFakeAMQP10Broker(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetAMQP10Publishing(broker.options()))
+
+	t.Cleanup(func() { _ = client.Disconnect() })
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, client.Publish("events_exchange", "event.created", "created"))
+
+	// The publishing is sent over AMQP 1.0, not over the publishing connection.
+	assert.Equal(t, []fakeAMQP10Message{{
+		Address:     "/exchanges/events_exchange/event.created",
+		Durable:     true,
+		Subject:     "event.created",
+		ContentType: fakeAMQP10Symbol("application/json"),
+		Body:        `"created"`,
+	}}, broker.messages())
+	assert.Zero(t, server.receivedCount())
+}
+
+func TestClientOptions_Validate_AMQP10Publishing(t *testing.T) {
+	options := gorabbit.NewClientOptions().
+		SetAMQP10Publishing(gorabbit.NewAMQP10Options()).
+		SetBlockingPublishing(true)
+
+	err := options.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "AMQP10Publishing")
+
+	options.SetBlockingPublishing(false)
+
+	assert.NoError(t, options.Validate())
+}
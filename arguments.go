@@ -0,0 +1,124 @@
+package gorabbit
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// tableArguments converts arguments decoded from JSON or YAML into an amqp.Table, coercing each value into a type
+// supported by the AMQP field tables. Returns nil if there are no arguments, and an error wrapping ErrInvalidArgument
+// if a value cannot be coerced without altering it.
+func tableArguments(args map[string]interface{}) (amqp.Table, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	table := make(amqp.Table, len(args))
+
+	for k, v := range args {
+		value, err := tableValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w '%s': %w", ErrInvalidArgument, k, err)
+		}
+
+		table[k] = value
+	}
+
+	return table, nil
+}
+
+// arguments returns the exchange arguments as an amqp.Table.
+func (e ExchangeConfig) arguments() (amqp.Table, error) {
+	return tableArguments(e.alternateExchangeArguments())
+}
+
+// tableValue coerces a value into a type supported by the AMQP field tables:
+//   - integral numbers, such as the float64 decoded from JSON, become int64, as expected by arguments like x-message-ttl.
+//   - nested maps become amqp.Table.
+//   - arrays are coerced element by element.
+//
+// Returns an error for the fractional numbers and those out of the int64 range, rather than truncating or wrapping them.
+func tableValue(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return floatTableValue(v)
+	case float32:
+		return floatTableValue(float64(v))
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+
+		f, err := v.Float64()
+		if err != nil {
+			return nil, fmt.Errorf("the number %s is out of range", v)
+		}
+
+		return floatTableValue(f)
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint:
+		return uintTableValue(uint64(v))
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return uintTableValue(v)
+	case map[string]interface{}:
+		return tableArguments(v)
+	case amqp.Table:
+		return tableArguments(v)
+	case []interface{}:
+		values := make([]interface{}, len(v))
+
+		for i, element := range v {
+			coerced, err := tableValue(element)
+			if err != nil {
+				return nil, err
+			}
+
+			values[i] = coerced
+		}
+
+		return values, nil
+	default:
+		return value, nil
+	}
+}
+
+// floatTableValue coerces a floating-point number into an int64 if it is integral. The integral numbers out of the int64
+// range, whose conversion is implementation-defined, and the fractional numbers, which are not expected by any
+// argument, are rejected.
+func floatTableValue(v float64) (interface{}, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) || v != math.Trunc(v) {
+		return nil, fmt.Errorf("the number %v is not an integer", v)
+	}
+
+	// math.MaxInt64 is not representable as a float64, which rounds it up to 2^63.
+	if v < math.MinInt64 || v >= math.MaxInt64 {
+		return nil, fmt.Errorf("the number %v is out of the int64 range", v)
+	}
+
+	return int64(v), nil
+}
+
+// uintTableValue coerces an unsigned integer into an int64, rejecting those above math.MaxInt64.
+func uintTableValue(v uint64) (interface{}, error) {
+	if v > math.MaxInt64 {
+		return nil, fmt.Errorf("the number %d is out of the int64 range", v)
+	}
+
+	return int64(v), nil
+}
@@ -0,0 +1,113 @@
+package gorabbit_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMQTTManager_CreateQueue_Arguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected interface{}
+	}{
+		{name: "integral float64", value: float64(60000), expected: int64(60000)},
+		{name: "negative float64", value: float64(-1), expected: int64(-1)},
+		{name: "float32", value: float32(10), expected: int64(10)},
+		{name: "json integer", value: json.Number("9223372036854775807"), expected: int64(math.MaxInt64)},
+		{name: "json integral float", value: json.Number("1e3"), expected: int64(1000)},
+		{name: "uint8", value: uint8(10), expected: int64(10)},
+		{name: "uint64", value: uint64(math.MaxInt64), expected: int64(math.MaxInt64)},
+		{name: "string", value: "reject-publish", expected: "reject-publish"},
+		{name: "nested", value: map[string]interface{}{"ttl": float64(5)}, expected: amqp.Table{"ttl": int64(5)}},
+		{name: "array", value: []interface{}{float64(1), "a"}, expected: []interface{}{int64(1), "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t)
+
+			manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+			require.NoError(t, err)
+
+			defer manager.Disconnect()
+
+			require.NoError(t, manager.CreateQueue(gorabbit.QueueConfig{
+				Name: "events",
+				Args: map[string]interface{}{"x-custom": tt.value},
+			}))
+
+			assert.Equal(t, []fakeDeclaration{
+				{Kind: "queue", Name: "events", Arguments: amqp.Table{"x-custom": tt.expected}},
+			}, server.declarations())
+		})
+	}
+}
+
+func TestMQTTManager_CreateQueue_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{name: "uint64 above max int64", value: uint64(math.MaxInt64) + 1},
+		{name: "max uint64", value: uint64(math.MaxUint64)},
+		{name: "uint above max int64", value: uint(math.MaxUint64)},
+		{name: "float64 above max int64", value: float64(math.MaxInt64)},
+		{name: "float64 below min int64", value: -1e19},
+		{name: "fractional float64", value: 1.5},
+		{name: "fractional float32", value: float32(0.5)},
+		{name: "NaN", value: math.NaN()},
+		{name: "infinity", value: math.Inf(1)},
+		{name: "json above max int64", value: json.Number("9223372036854775808")},
+		{name: "json fractional", value: json.Number("2.5")},
+		{name: "nested", value: map[string]interface{}{"ttl": 1.5}},
+		{name: "array", value: []interface{}{float64(1), uint64(math.MaxUint64)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t)
+
+			manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+			require.NoError(t, err)
+
+			defer manager.Disconnect()
+
+			err = manager.CreateQueue(gorabbit.QueueConfig{
+				Name: "events",
+				Args: map[string]interface{}{"x-custom": tt.value},
+			})
+			require.ErrorIs(t, err, gorabbit.ErrInvalidArgument)
+			assert.Contains(t, err.Error(), "x-custom")
+
+			// Nothing is declared with an altered value.
+			assert.Empty(t, server.declarations())
+		})
+	}
+}
+
+func TestMQTTManager_InvalidArguments(t *testing.T) {
+	server := newFakeServer(t)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	args := map[string]interface{}{"x-custom": uint64(math.MaxUint64)}
+
+	err = manager.CreateExchange(gorabbit.ExchangeConfig{Name: "events", Type: gorabbit.ExchangeTypeTopic, Args: args})
+	assert.ErrorIs(t, err, gorabbit.ErrInvalidArgument)
+
+	err = manager.BindExchangeToExchange("events", "audit", "#", args)
+	assert.ErrorIs(t, err, gorabbit.ErrInvalidArgument)
+
+	assert.Empty(t, server.declarations())
+}
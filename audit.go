@@ -0,0 +1,289 @@
+package gorabbit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AuditAction is a step in the lifecycle of a message recorded by an AuditSink.
+type AuditAction string
+
+const (
+	// AuditPublished is a message sent to the server, including a cached message replayed once the channel is back up.
+	AuditPublished AuditAction = "published"
+
+	// AuditConfirmed is a published message the server took responsibility for.
+	AuditConfirmed AuditAction = "confirmed"
+
+	// AuditRejected is a published message the server could not take responsibility for.
+	AuditRejected AuditAction = "rejected"
+
+	// AuditConsumed is a delivery received by a consumer.
+	AuditConsumed AuditAction = "consumed"
+
+	// AuditAcked is a delivery acknowledged after its processing.
+	AuditAcked AuditAction = "acked"
+
+	// AuditNacked is a delivery negatively acknowledged after its processing, to be requeued, retried or discarded.
+	AuditNacked AuditAction = "nacked"
+
+	// AuditDeadLettered is a delivery dead-lettered after its processing.
+	AuditDeadLettered AuditAction = "dead_lettered"
+)
+
+// String returns the string representation of the AuditAction.
+func (a AuditAction) String() string {
+	return string(a)
+}
+
+// AuditRecord is a structured record of a step in the lifecycle of a message.
+type AuditRecord struct {
+	// Action is the recorded step.
+	Action AuditAction
+
+	// MessageID is the unique identifier of the message.
+	MessageID string
+
+	// Exchange is the exchange the message was published to.
+	Exchange string
+
+	// RoutingKey is the routing key of the message.
+	RoutingKey string
+
+	// Consumer is the name of the consumer of a delivery.
+	Consumer string
+
+	// Queue is the queue a delivery was consumed from.
+	Queue string
+
+	// MessageTimestamp is the timestamp set on the message by its publisher.
+	MessageTimestamp time.Time
+
+	// Time is when the step happened.
+	Time time.Time
+}
+
+// AuditSink receives the records of the lifecycle of the messages, by batch, to keep an audit trail.
+// Implementations do not need to be safe for concurrent use, the batches being written one at a time.
+type AuditSink interface {
+	// WriteAudit writes a batch of records, in the order they happened. A batch whose writing failed is written again
+	// until it succeeds or the client is disconnected.
+	WriteAudit(records []AuditRecord) error
+}
+
+// AuditConfig enables the recording of the lifecycle of the messages into an AuditSink.
+type AuditConfig struct {
+	// Sink receives the records.
+	Sink AuditSink
+
+	// BatchSize is the number of records written at once. Defaults to 100.
+	BatchSize int
+
+	// FlushInterval is the maximum time a record waits before being written. Defaults to 1s.
+	FlushInterval time.Duration
+}
+
+// auditor batches the AuditRecord of a client and writes them to its AuditSink from a dedicated goroutine. The records
+// of a batch whose writing failed are kept, to be written again with the next one.
+type auditor struct {
+	sink          AuditSink
+	batchSize     int
+	flushInterval time.Duration
+	records       []AuditRecord
+	mutex         sync.Mutex
+	full          chan struct{}
+	stop          chan struct{}
+	stopOnce      sync.Once
+	done          chan struct{}
+	logger        Logger
+}
+
+// newAuditor returns an auditor writing to the sink of the config, or nil if there is no sink.
+func newAuditor(config *AuditConfig, logger Logger) *auditor {
+	if config == nil || config.Sink == nil {
+		return nil
+	}
+
+	a := &auditor{
+		sink:          config.Sink,
+		batchSize:     config.BatchSize,
+		flushInterval: config.FlushInterval,
+		full:          make(chan struct{}, 1),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		logger: inheritLogger(logger, map[string]interface{}{
+			"context": "audit",
+		}),
+	}
+
+	if a.batchSize <= 0 {
+		a.batchSize = defaultAuditBatchSize
+	}
+
+	if a.flushInterval <= 0 {
+		a.flushInterval = defaultAuditFlushInterval
+	}
+
+	go a.run()
+
+	return a
+}
+
+// record adds a record to the pending ones, which are written once a batch is full or at the next flush.
+func (a *auditor) record(record AuditRecord) {
+	if a == nil {
+		return
+	}
+
+	record.Time = time.Now()
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.records = append(a.records, record)
+
+	if len(a.records) >= a.batchSize {
+		select {
+		case a.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run writes the pending records when a batch is full or periodically, until the auditor is closed.
+func (a *auditor) run() {
+	defer close(a.done)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stop:
+			a.write()
+
+			return
+		case <-a.full:
+			a.write()
+		case <-ticker.C:
+			a.write()
+		}
+	}
+}
+
+// write writes the pending records to the sink by batch. On failure, the unwritten records are kept in front of the
+// pending ones.
+func (a *auditor) write() {
+	a.mutex.Lock()
+	records := a.records
+	a.records = nil
+	a.mutex.Unlock()
+
+	for len(records) > 0 {
+		batch := records[:min(a.batchSize, len(records))]
+
+		if err := a.sink.WriteAudit(batch); err != nil {
+			a.logger.Error(err, "Could not write audit records", LogField{Key: "records", Value: len(records)})
+
+			a.mutex.Lock()
+			a.records = append(records, a.records...)
+			a.mutex.Unlock()
+
+			return
+		}
+
+		records = records[len(batch):]
+	}
+}
+
+// close writes the pending records and stops the auditor, waiting at most until the context is done.
+func (a *auditor) close(ctx context.Context) {
+	if a == nil {
+		return
+	}
+
+	a.stopOnce.Do(func() {
+		close(a.stop)
+	})
+
+	select {
+	case <-a.done:
+	case <-ctx.Done():
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if len(a.records) > 0 {
+		a.logger.Warn("Audit records could not be written before the disconnection", LogField{Key: "records", Value: len(a.records)})
+	}
+}
+
+// auditPublished records a published message and, if the channel is in confirm mode, its confirmation.
+func (c *amqpChannel) auditPublished(msg mqttPublishing, confirmation *amqp.DeferredConfirmation) {
+	if c.auditor == nil {
+		return
+	}
+
+	record := AuditRecord{
+		MessageID:        msg.Msg.MessageId,
+		Exchange:         msg.Exchange,
+		RoutingKey:       msg.RoutingKey,
+		MessageTimestamp: msg.Msg.Timestamp,
+	}
+
+	published := record
+	published.Action = AuditPublished
+
+	c.auditor.record(published)
+
+	if confirmation == nil {
+		return
+	}
+
+	go func() {
+		<-confirmation.Done()
+
+		c.faults.delayConfirm()
+
+		record.Action = AuditRejected
+
+		if confirmation.Acked() {
+			record.Action = AuditConfirmed
+		}
+
+		c.auditor.record(record)
+	}()
+}
+
+// auditDelivery records a step of the lifecycle of a delivery.
+func (c *amqpChannel) auditDelivery(delivery *amqp.Delivery, action AuditAction) {
+	if c.auditor == nil {
+		return
+	}
+
+	c.auditor.record(AuditRecord{
+		Action:           action,
+		MessageID:        delivery.MessageId,
+		Exchange:         delivery.Exchange,
+		RoutingKey:       delivery.RoutingKey,
+		Consumer:         c.consumer.Name,
+		Queue:            c.queueName(),
+		MessageTimestamp: delivery.Timestamp,
+	})
+}
+
+// auditAction returns the AuditAction matching an AckDecision.
+func auditAction(decision AckDecision) AuditAction {
+	switch decision {
+	case AckDecisionAck:
+		return AuditAcked
+	case AckDecisionDeadLetter:
+		return AuditDeadLettered
+	default:
+		return AuditNacked
+	}
+}
@@ -0,0 +1,51 @@
+package gorabbit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type recordingAuditSink struct {
+	mutex   sync.Mutex
+	records []gorabbit.AuditRecord
+}
+
+func (s *recordingAuditSink) WriteAudit(records []gorabbit.AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.records = append(s.records, records...)
+
+	return nil
+}
+
+func TestClientOptions_SetAudit(t *testing.T) {
+	sink := new(recordingAuditSink)
+
+	options := gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetAudit(gorabbit.AuditConfig{Sink: sink, FlushInterval: 10 * time.Millisecond})
+
+	require.NotNil(t, options.Audit)
+
+	client := gorabbit.NewClient(options)
+
+	// Nothing listens on the port, so the message is cached rather than published.
+	require.Error(t, client.Publish("events_exchange", "event.created", "payload"))
+
+	time.Sleep(50 * time.Millisecond)
+
+	require.NoError(t, client.Disconnect())
+
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+
+	assert.Empty(t, sink.records)
+}
@@ -0,0 +1,96 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// backpressure tracks whether the broker blocks the publishing connection, so that the blocking publishings wait for
+// it to be unblocked instead of being cached.
+type backpressure struct {
+	mutex sync.Mutex
+
+	// unblocked is closed while the connection is not blocked, and replaced by an open channel once it is.
+	unblocked chan struct{}
+}
+
+// newBackpressure instantiates a new backpressure, the connection not being blocked.
+func newBackpressure() *backpressure {
+	unblocked := make(chan struct{})
+	close(unblocked)
+
+	return &backpressure{unblocked: unblocked}
+}
+
+// setBlocked records whether the connection is blocked.
+func (b *backpressure) setBlocked(blocked bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	select {
+	case <-b.unblocked:
+		if blocked {
+			b.unblocked = make(chan struct{})
+		}
+	default:
+		if !blocked {
+			close(b.unblocked)
+		}
+	}
+}
+
+// wait returns a channel closed once the connection is not blocked.
+func (b *backpressure) wait() <-chan struct{} {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.unblocked
+}
+
+// awaitPublishable waits, until the context is done, for the channel to be ready and the connection not blocked by
+// the broker. Returns an error wrapping the error of the context, and ErrConnectionBlocked or errChannelClosed
+// depending on what the publishing waited for.
+func (c *amqpChannel) awaitPublishable(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for {
+		if !c.ready() {
+			timer := time.NewTimer(blockingPublishPollInterval)
+
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return fmt.Errorf("%w: %w", errChannelClosed, ctx.Err())
+			case <-c.ctx.Done():
+				timer.Stop()
+
+				return errChannelClosed
+			case <-timer.C:
+			}
+
+			continue
+		}
+
+		unblocked := c.backpressure.wait()
+
+		select {
+		case <-unblocked:
+			return nil
+		default:
+		}
+
+		// The channel may be lost while the connection is blocked, so it is checked again once unblocked.
+		select {
+		case <-unblocked:
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %w", ErrConnectionBlocked, ctx.Err())
+		case <-c.ctx.Done():
+			return errChannelClosed
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func newBlockingClient(t *testing.T, server *fakeServer, recorder *publishRecorder, blockings chan<- bool) gorabbit.MQTTClient {
+	t.Helper()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetRetryDelay(100 * time.Millisecond).
+		SetBlockingPublishing(true).
+		SetMetrics(recorder).
+		SetNotifications(gorabbit.BrokerNotifications{
+			OnBlocked: func(blocking gorabbit.ConnectionBlocking) { blockings <- blocking.Active },
+		}))
+
+	t.Cleanup(func() { _ = client.Disconnect() })
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	return client
+}
+
+func TestClient_BlockingPublishing_WaitsForUnblocking(t *testing.T) {
+	server := newFakeServer(t)
+	recorder := &publishRecorder{}
+	blockings := make(chan bool, 2)
+	client := newBlockingClient(t, server, recorder, blockings)
+
+	server.setBlocked(true)
+	require.True(t, <-blockings)
+
+	published := make(chan error, 1)
+
+	go func() {
+		published <- client.Publish("events_exchange", "event.created", "payload")
+	}()
+
+	select {
+	case err := <-published:
+		t.Fatalf("publishing not blocked: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	server.setBlocked(false)
+	require.False(t, <-blockings)
+
+	require.NoError(t, <-published)
+
+	assert.Eventually(t, func() bool {
+		return len(server.ackedBodies()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{`"payload"`}, server.ackedBodies())
+	assert.Zero(t, recorder.count(gorabbit.PublishOutcomeCached))
+}
+
+func TestClient_BlockingPublishing_ContextDone(t *testing.T) {
+	server := newFakeServer(t)
+	recorder := &publishRecorder{}
+	blockings := make(chan bool, 1)
+	client := newBlockingClient(t, server, recorder, blockings)
+
+	server.setBlocked(true)
+	require.True(t, <-blockings)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", nil)
+	require.ErrorIs(t, err, gorabbit.ErrConnectionBlocked)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The publishing fails instead of being cached.
+	assert.Zero(t, server.receivedCount())
+	assert.Zero(t, recorder.count(gorabbit.PublishOutcomeCached))
+	assert.Equal(t, 1, recorder.count(gorabbit.PublishOutcomeFailure))
+}
+
+func TestClient_BlockingPublishing_WaitsForChannel(t *testing.T) {
+	server := newFakeServer(t)
+	recorder := &publishRecorder{}
+	client := newBlockingClient(t, server, recorder, make(chan bool, 1))
+
+	// The broker loses the channel on the first publishing.
+	server.setOnPublish(func(publishing fakePublishing) fakeAction {
+		if publishing.Body == `"lost"` {
+			return fakeClose
+		}
+
+		return fakeAck
+	})
+
+	require.NoError(t, client.Publish("events_exchange", "event.created", "lost"))
+
+	require.Eventually(t, func() bool { return !client.IsHealthy() }, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", nil))
+
+	assert.Eventually(t, func() bool {
+		return len(server.ackedBodies()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{`"payload"`}, server.ackedBodies())
+	assert.Zero(t, recorder.count(gorabbit.PublishOutcomeCached))
+}
+
+func TestClientOptions_Validate_BlockingPublishing(t *testing.T) {
+	options := gorabbit.NewClientOptions().
+		SetKeepAlive(false).
+		SetBlockingPublishing(true)
+
+	err := options.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "BlockingPublishing")
+	assert.Contains(t, err.Error(), "requires KeepAlive")
+
+	options.SetKeepAlive(true).SetPublishingPipeline(gorabbit.PublishingPipeline{})
+
+	err = options.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be used with a PublishingPipeline")
+}
@@ -2,12 +2,16 @@ package gorabbit
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // amqpChannels is a simple wrapper of an amqpChannel slice.
@@ -27,7 +31,7 @@ func (a amqpChannels) publishingChannel() *amqpChannel {
 // updateParentConnection updates every channel's parent connection.
 func (a amqpChannels) updateParentConnection(conn *amqp.Connection) {
 	for _, channel := range a {
-		channel.connection = conn
+		channel.connection.Store(conn)
 	}
 }
 
@@ -36,21 +40,35 @@ type amqpChannel struct {
 	// ctx is the parent context and acts as a safeguard.
 	ctx context.Context
 
-	// connection is the native amqp.Connection.
-	connection *amqp.Connection
+	// connection is the native amqp.Connection, replaced when the parent connection is opened again.
+	connection atomic.Pointer[amqp.Connection]
 
-	// channel is the native amqp.Channel.
-	channel *amqp.Channel
+	// channel is the native amqp.Channel, replaced when the channel is opened again.
+	channel atomic.Pointer[amqp.Channel]
 
 	// keepAlive is the flag that will define whether active guards and re-connections are enabled or not.
 	keepAlive bool
 
 	// retryDelay defines the delay to wait before re-connecting if the channel was closed and the keepAlive flag is set to true.
-	retryDelay time.Duration
+	// It is shared with the connection, to be updated by the client configuration.
+	retryDelay *reloadableDuration
+
+	// recovery governs the retries of the channel. It is shared with the connection.
+	recovery RetryPolicy
 
 	// consumer is the MessageConsumer that holds all necessary information for the consumption of messages.
 	consumer *MessageConsumer
 
+	// handlers dispatches the deliveries of the consumer to its handlers.
+	handlers *handlerMatcher
+
+	// tuning holds the PrefetchCount and ConsumeRateLimit of the consumer, which can be updated while consuming.
+	tuning *consumerTuning
+
+	// consumptionCtxMutex protects consumptionCtx and consumptionCancel, renewed by each consumption, from concurrent
+	// access.
+	consumptionCtxMutex sync.Mutex
+
 	// consumptionCtx holds the consumption context.
 	consumptionCtx context.Context
 
@@ -58,22 +76,146 @@ type amqpChannel struct {
 	consumptionCancel context.CancelFunc
 
 	// consumptionHealth manages the status of all active consumptions.
-	consumptionHealth consumptionHealth
+	consumptionHealth *consumptionHealth
+
+	// declaredQueues holds the names of the already declared retry and quarantine queues.
+	declaredQueues map[string]bool
+
+	// declaredQueuesMutex protects declaredQueues from concurrent processing.
+	declaredQueuesMutex sync.Mutex
+
+	// streamOffset holds the offset of the last processed delivery when consuming a stream queue.
+	streamOffset int64
+
+	// streamOffsetSet is true once a stream delivery was processed.
+	streamOffsetSet bool
+
+	// streamOffsetMutex protects streamOffset from concurrent processing.
+	streamOffsetMutex sync.Mutex
+
+	// active is true once a SingleActiveConsumer received a delivery.
+	active bool
+
+	// activeMutex protects active from concurrent access.
+	activeMutex sync.Mutex
+
+	// consumerTag is the tag of the current subscription.
+	consumerTag string
+
+	// consumptionDone is closed when the current consumption loop stops.
+	consumptionDone chan struct{}
+
+	// subscriptionErr is the error that ended or prevented the last subscription, if any.
+	subscriptionErr error
+
+	// consumptionMutex protects consumerTag, consumptionDone, subscriptionErr and ackBatcher from concurrent access.
+	consumptionMutex sync.Mutex
+
+	// draining is true once the channel is being drained, in which case it does not process new deliveries.
+	draining atomic.Bool
+
+	// handingOver is true while a SingleActiveConsumer hands its queue over to the next consumer in line.
+	handingOver atomic.Bool
+
+	// inFlight tracks the deliveries being processed.
+	inFlight sync.WaitGroup
+
+	// inFlightCount is the number of deliveries being processed.
+	inFlightCount atomic.Int64
+
+	// requeuedCount is the number of deliveries requeued without being processed while draining.
+	requeuedCount atomic.Int64
+
+	// prefetchObserver accumulates the handler latencies and deliveries in flight, if the consumer defines an
+	// AdaptivePrefetch.
+	prefetchObserver *prefetchObserver
+
+	// queueStats holds the last inspection of the consumed queue, if the consumer defines a QueueMonitor.
+	queueStats *QueueStats
+
+	// queueStatsMutex protects queueStats from concurrent access.
+	queueStatsMutex sync.Mutex
+
+	// subscriptions holds the channels of the additional queues consumed on the same native channel.
+	subscriptions []*amqpChannel
+
+	// inFlightDeliveries holds the deliveries whose handler is running, by tracking identifier.
+	inFlightDeliveries map[uint64]InFlightDelivery
+
+	// inFlightSequence generates the tracking identifiers of the inFlightDeliveries.
+	inFlightSequence uint64
+
+	// inFlightMutex protects inFlightDeliveries and inFlightSequence from concurrent access.
+	inFlightMutex sync.Mutex
+
+	// stats accumulates the statistics of the processed deliveries.
+	stats consumerStats
+
+	// chunks reassembles the chunked messages received by the channel.
+	chunks chunkAssembler
+
+	// ackBatcher groups the acknowledgements of the current consumption, if the consumer defines an AckBatch.
+	ackBatcher *ackBatcher
+
+	// metrics receives the metrics of the processed deliveries, if set.
+	metrics MetricsCollector
+
+	// tracer starts the spans of the publishings and processed deliveries, if set.
+	tracer trace.Tracer
+
+	// correlation propagates the values of the contexts of the publishings and processed deliveries, if set.
+	correlation *correlation
+
+	// payloadLogging logs the payloads of the publishings and processed deliveries, if set.
+	payloadLogging *PayloadLogging
+
+	// notifications receives the notifications of the broker about the publishings, if set.
+	notifications *BrokerNotifications
+
+	// auditor records the lifecycle of the publishings and processed deliveries, if set.
+	auditor *auditor
+
+	// faults injects failures into the channel, if set.
+	faults *FaultInjector
+
+	// serverNamedQueue is true if the consumer consumes a queue named by the server, declared with every new channel.
+	serverNamedQueue bool
+
+	// queueNameMutex protects the consumer's queue name, changed with every server-named queue declaration.
+	queueNameMutex sync.RWMutex
 
 	// publishingCache manages the caching of unpublished messages due to a connection error.
-	publishingCache *ttlMap[string, mqttPublishing]
+	publishingCache *ttlMap[mqttPublishing]
+
+	// pipeline buffers the publishings and sends them in windows of publisher confirms, if set.
+	pipeline *publishingPipeline
+
+	// reliable is true if the publishings are confirmed, mandatory, and cached until confirmed.
+	reliable bool
+
+	// returns records the returned publishings of the current channel, if reliable.
+	returns atomic.Pointer[returnTracker]
+
+	// replays tracks the settlement of the replayed publishings, awaited before the next replay.
+	replays sync.WaitGroup
+
+	// replayMutex serializes the replays of the channels opened concurrently by the recoveries.
+	replayMutex sync.Mutex
+
+	// backpressure makes the publishings wait for the channel and the broker instead of being cached, if set.
+	backpressure *backpressure
 
 	// maxRetry defines the retry header for each message.
 	maxRetry uint
 
 	// closed is an inner property that switches to true if the channel was explicitly closed.
-	closed bool
+	closed atomic.Bool
 
 	// logger logs events.
-	logger logger
+	logger Logger
 
 	// releaseLogger forces logs not matter the mode. It is used to log important things.
-	releaseLogger logger
+	releaseLogger Logger
 
 	// connectionType defines the connectionType.
 	connectionType connectionType
@@ -84,43 +226,71 @@ type amqpChannel struct {
 //   - connection is the parent amqp.Connection.
 //   - keepAlive will keep the channel alive if true.
 //   - retryDelay defines the delay between each retry, if the keepAlive flag is set to true.
+//   - recovery governs the retries of the channel.
 //   - consumer is the MessageConsumer that will hold consumption information.
 //   - maxRetry is the retry header for each message.
+//   - metrics receives the metrics of the processed deliveries, if not nil.
+//   - tracer starts the spans of the processed deliveries, if not nil.
+//   - correlation propagates the values of the contexts of the processed deliveries, if not nil.
+//   - payloadLogging logs the payloads of the processed deliveries, if not nil.
+//   - auditor records the lifecycle of the processed deliveries, if not nil.
+//   - faults injects failures into the channel, if not nil.
 //   - logger is the parent logger.
 func newConsumerChannel(
 	ctx context.Context,
 	connection *amqp.Connection,
 	keepAlive bool,
-	retryDelay time.Duration,
+	retryDelay *reloadableDuration,
+	recovery RetryPolicy,
 	consumer *MessageConsumer,
-	logger logger,
+	metrics MetricsCollector,
+	tracer trace.Tracer,
+	correlation *correlation,
+	payloadLogging *PayloadLogging,
+	auditor *auditor,
+	faults *FaultInjector,
+	logger Logger,
 ) *amqpChannel {
+	// The consumer tag is computed once, so that it remains the same across channel recoveries.
+	if consumer.Tag == "" {
+		consumer.Tag = defaultConsumerTag(consumer.Name)
+	}
+
 	channel := &amqpChannel{
-		ctx:        ctx,
-		connection: connection,
-		keepAlive:  keepAlive,
-		retryDelay: retryDelay,
-		logger: inheritLogger(logger, map[string]interface{}{
-			"context":  "channel",
-			"type":     connectionTypeConsumer,
-			"consumer": consumer.Name,
-			"queue":    consumer.Queue,
-		}),
-		releaseLogger: &stdLogger{
-			logger:     newLogrus(),
-			identifier: libraryName,
-			logFields: map[string]interface{}{
-				"context":  "channel",
-				"type":     connectionTypeConsumer,
-				"consumer": consumer.Name,
-				"queue":    consumer.Queue,
-			},
-		},
+		ctx:               ctx,
+		keepAlive:         keepAlive,
+		retryDelay:        retryDelay,
+		recovery:          recovery,
+		tuning:            newConsumerTuning(consumer),
+		logger:            inheritLogger(logger, consumerLogFields(consumer)),
+		releaseLogger:     newReleaseLogger(logger, consumerLogFields(consumer)),
 		connectionType:    connectionTypeConsumer,
-		consumptionHealth: make(consumptionHealth),
+		consumptionHealth: newConsumptionHealth(),
+		declaredQueues:    make(map[string]bool),
 		consumer:          consumer,
+		handlers:          newHandlerMatcher(consumer),
+		metrics:           metrics,
+		tracer:            tracer,
+		correlation:       correlation,
+		payloadLogging:    payloadLogging,
+		auditor:           auditor,
+		faults:            faults,
+		serverNamedQueue:  consumer.Queue == "",
+	}
+
+	channel.connection.Store(connection)
+
+	if consumer.AdaptivePrefetch != nil && !consumer.autoAck() {
+		channel.prefetchObserver = &prefetchObserver{}
+	}
+
+	// Additional queues are consumed on the same channel.
+	for _, subscription := range consumer.Subscriptions {
+		channel.subscriptions = append(channel.subscriptions, newSubscriptionChannel(channel, subscription, logger))
 	}
 
+	faults.register(channel)
+
 	// We open an initial channel.
 	err := channel.open()
 
@@ -132,45 +302,99 @@ func newConsumerChannel(
 	return channel
 }
 
+// consumerLogFields returns the log fields identifying a consumer channel.
+func consumerLogFields(consumer *MessageConsumer) map[string]interface{} {
+	return map[string]interface{}{
+		"context":     "channel",
+		"type":        connectionTypeConsumer,
+		"consumer":    consumer.Name,
+		"consumerTag": consumer.Tag,
+		"queue":       consumer.Queue,
+	}
+}
+
 // newPublishingChannel instantiates a new publishingChannel and amqpChannel for method inheritance.
 //   - ctx is the parent context.
 //   - connection is the parent amqp.Connection.
 //   - keepAlive will keep the channel alive if true.
 //   - retryDelay defines the delay between each retry, if the keepAlive flag is set to true.
+//   - recovery governs the retries of the channel.
 //   - maxRetry defines the maximum number of times a message can be retried if its consumption failed.
 //   - publishingCacheSize is the maximum cache size of failed publishing.
 //   - publishingCacheTTL defines the time to live for each failed publishing that was put in cache.
+//   - metrics receives the metrics of the publishings, if not nil.
+//   - tracer starts the spans of the publishings, if not nil.
+//   - correlation propagates the values of the contexts of the publishings, if not nil.
+//   - payloadLogging logs the payloads of the publishings, if not nil.
+//   - notifications receives the notifications of the broker about the publishings, if not nil.
+//   - auditor records the lifecycle of the publishings, if not nil.
+//   - faults injects failures into the channel and its publishings, if not nil.
+//   - pipeline configures the asynchronous publishing, if not nil.
+//   - reliable makes the publishings confirmed, mandatory, and cached until confirmed.
+//   - backpressure makes the publishings wait for the channel and the broker instead of being cached, if not nil.
 //   - logger is the parent logger.
 func newPublishingChannel(
 	ctx context.Context,
 	connection *amqp.Connection,
 	keepAlive bool,
-	retryDelay time.Duration,
+	retryDelay *reloadableDuration,
+	recovery RetryPolicy,
 	maxRetry uint,
 	publishingCacheSize uint64,
 	publishingCacheTTL time.Duration,
-	logger logger,
+	metrics MetricsCollector,
+	tracer trace.Tracer,
+	correlation *correlation,
+	payloadLogging *PayloadLogging,
+	notifications *BrokerNotifications,
+	auditor *auditor,
+	faults *FaultInjector,
+	pipeline *PublishingPipeline,
+	reliable bool,
+	backpressure *backpressure,
+	logger Logger,
 ) *amqpChannel {
 	channel := &amqpChannel{
 		ctx:        ctx,
-		connection: connection,
 		keepAlive:  keepAlive,
 		retryDelay: retryDelay,
+		recovery:   recovery,
 		logger: inheritLogger(logger, map[string]interface{}{
 			"context": "channel",
 			"type":    connectionTypePublisher,
 		}),
-		releaseLogger: &stdLogger{
-			logger:     newLogrus(),
-			identifier: libraryName,
-			logFields: map[string]interface{}{
-				"context": "channel",
-				"type":    connectionTypePublisher,
-			},
-		},
-		connectionType:  connectionTypePublisher,
-		publishingCache: newTTLMap[string, mqttPublishing](publishingCacheSize, publishingCacheTTL),
-		maxRetry:        maxRetry,
+		releaseLogger: newReleaseLogger(logger, map[string]interface{}{
+			"context": "channel",
+			"type":    connectionTypePublisher,
+		}),
+		connectionType: connectionTypePublisher,
+		maxRetry:       maxRetry,
+		metrics:        metrics,
+		tracer:         tracer,
+		correlation:    correlation,
+		payloadLogging: payloadLogging,
+		auditor:        auditor,
+		faults:         faults,
+		notifications:  notifications,
+		reliable:       reliable,
+		backpressure:   backpressure,
+	}
+
+	channel.connection.Store(connection)
+
+	channel.publishingCache = newTTLMap(ctx, publishingCacheSize, publishingCacheTTL, func(msg mqttPublishing, expired bool) {
+		if expired {
+			channel.observePublish(msg, PublishOutcomeExpired)
+		} else {
+			channel.observePublish(msg, PublishOutcomeEvicted)
+		}
+	})
+
+	faults.register(channel)
+
+	// The pipeline is set before the channel is opened, so that it is opened in confirm mode.
+	if pipeline != nil {
+		channel.pipeline = newPublishingPipeline(channel, *pipeline)
 	}
 
 	// We open an initial channel.
@@ -187,7 +411,8 @@ func newPublishingChannel(
 // open opens a new amqp.Channel from the parent connection.
 func (c *amqpChannel) open() error {
 	// If the channel is nil or closed we return an error.
-	if c.connection == nil || c.connection.IsClosed() {
+	connection := c.connection.Load()
+	if connection == nil || connection.IsClosed() {
 		err := errConnectionClosed
 
 		c.logger.Error(err, "Could not open channel")
@@ -196,63 +421,93 @@ func (c *amqpChannel) open() error {
 	}
 
 	// We request a channel from the parent connection.
-	channel, err := c.connection.Channel()
+	channel, err := connection.Channel()
 	if err != nil {
 		c.logger.Error(err, "Could not open channel")
 
 		return err
 	}
 
-	c.channel = channel
+	// The notifications are subscribed to before any publishing, so that none is missed.
+	if err = c.listenNotifications(channel); err != nil {
+		c.logger.Error(err, "Could not subscribe to broker notifications")
 
-	c.logger.Info("Channel opened")
+		_ = channel.Close()
 
-	c.onChannelOpened()
+		return err
+	}
+
+	// A previous channel means that the channel was lost.
+	if c.channel.Load() != nil {
+		c.observeChannel(ConnectionEventReopened)
+	} else {
+		c.observeChannel(ConnectionEventOpened)
+	}
+
+	c.channel.Store(channel)
 
-	// If the keepAlive flag is set to true, we activate a new guard.
+	c.logger.Info("Channel opened")
+
+	// If the keepAlive flag is set to true, we activate a new guard. It subscribes to the closing of the channel before
+	// the cached publishings are replayed, so that a channel lost in the middle of the replay is reopened.
 	if c.keepAlive {
-		go c.guard()
+		go c.guard(channel.NotifyClose(make(chan *amqp.Error)))
 	}
 
+	c.onChannelOpened()
+
 	return nil
 }
 
-// reconnect will indefinitely call the open method until a connection is successfully established or the context is canceled.
+// retry will call the open method until a channel is successfully opened, the context is canceled or the recovery
+// policy gives up.
 func (c *amqpChannel) retry() {
 	c.logger.Debug("Retry launched")
 
-	for {
+	var (
+		delay time.Duration
+		err   error
+	)
+
+	for attempt := uint(1); ; attempt++ {
+		if !c.recovery.ShouldRetry(attempt, err) {
+			c.logger.Error(err, "Channel retry given up", LogField{Key: "attempts", Value: attempt - 1})
+
+			return
+		}
+
+		delay = c.recovery.NextDelay(attempt, delay)
+
+		// Wait for the delay of the attempt.
 		select {
 		case <-c.ctx.Done():
 			c.logger.Debug("Retry stopped by the context")
 
 			// If the context was canceled, we break out of the method.
 			return
-		default:
-			// Wait for the retryDelay.
-			time.Sleep(c.retryDelay)
+		case <-time.After(delay):
+		}
 
-			// If there is no channel or the current channel is closed, we open a new channel.
-			if !c.ready() {
-				err := c.open()
-				// If the operation succeeds, we break the loop.
-				if err == nil {
-					c.logger.Debug("Retry successful")
+		// If the channel exists and is active, we break out.
+		if c.ready() {
+			return
+		}
 
-					return
-				}
+		// If there is no channel or the current channel is closed, we open a new channel.
+		err = c.open()
+		// If the operation succeeds, we break the loop.
+		if err == nil {
+			c.logger.Debug("Retry successful")
 
-				c.logger.Error(err, "Could not open new channel during retry")
-			} else {
-				// If the channel exists and is active, we break out.
-				return
-			}
+			return
 		}
+
+		c.logger.Error(err, "Could not open new channel during retry")
 	}
 }
 
 // guard is a channel safeguard that listens to channel close events and re-launches the channel.
-func (c *amqpChannel) guard() {
+func (c *amqpChannel) guard(closes chan *amqp.Error) {
 	c.logger.Debug("Guard launched")
 
 	for {
@@ -262,20 +517,22 @@ func (c *amqpChannel) guard() {
 
 			// If the context was canceled, we break out of the method.
 			return
-		case err, ok := <-c.channel.NotifyClose(make(chan *amqp.Error)):
+		case err, ok := <-closes:
 			if !ok {
 				return
 			}
 
 			if err != nil {
-				c.logger.Warn("Channel lost", logField{Key: "reason", Value: err.Reason}, logField{Key: "code", Value: err.Code})
+				c.logger.Warn("Channel lost", LogField{Key: "reason", Value: err.Reason}, LogField{Key: "code", Value: err.Code})
 			}
 
 			// If the channel was explicitly closed, we do not want to retry.
-			if c.closed {
+			if c.closed.Load() {
 				return
 			}
 
+			c.observeChannel(ConnectionEventLost)
+
 			c.onChannelClosed()
 
 			go c.retry()
@@ -288,7 +545,12 @@ func (c *amqpChannel) guard() {
 // close the channel only if it is ready.
 func (c *amqpChannel) close() error {
 	if c.ready() {
-		err := c.channel.Close()
+		// We acknowledge the pending batch before closing, otherwise the deliveries would be redelivered.
+		for _, channel := range c.consumerChannels() {
+			channel.flushAckBatch()
+		}
+
+		err := c.channel.Load().Close()
 		if err != nil {
 			c.logger.Error(err, "Could not close channel")
 
@@ -296,19 +558,29 @@ func (c *amqpChannel) close() error {
 		}
 	}
 
-	c.closed = true
+	c.closed.Store(true)
+
+	c.observeChannel(ConnectionEventClosed)
 
 	return nil
 }
 
 // ready returns true if the channel exists and is not closed.
 func (c *amqpChannel) ready() bool {
-	return c.channel != nil && !c.channel.IsClosed()
+	channel := c.channel.Load()
+
+	return channel != nil && !channel.IsClosed()
 }
 
 // healthy returns true if the channel exists and is not closed.
 func (c *amqpChannel) healthy() bool {
 	if c.connectionType == connectionTypeConsumer {
+		for _, subscription := range c.subscriptions {
+			if !subscription.healthy() {
+				return false
+			}
+		}
+
 		return c.ready() && c.consumptionHealth.IsHealthy()
 	}
 
@@ -319,49 +591,91 @@ func (c *amqpChannel) healthy() bool {
 func (c *amqpChannel) onChannelOpened() {
 	if c.connectionType == connectionTypeConsumer {
 		// We re-instantiate the consumptionContext and consumptionCancel.
-		c.consumptionCtx, c.consumptionCancel = context.WithCancel(c.ctx)
+		c.renewConsumption()
 
 		// This is just a safeguard.
 		if c.consumer != nil {
-			c.logger.Info("Launching consumer", logField{Key: "event", Value: "onChannelOpened"})
+			c.logger.Info("Launching consumer", LogField{Key: "event", Value: "onChannelOpened"})
 
 			// If the consumer is present we want to start consuming.
 			go c.consume()
+
+			c.startSubscriptions()
 		}
 	} else {
+		if c.publishingCache == nil {
+			return
+		}
+
+		// The reliable replay checks the cache once the previous replay is settled.
+		if c.reliable {
+			c.replayReliably()
+
+			return
+		}
+
 		// If the publishing cache is empty, nothing to do here.
-		if c.publishingCache == nil || c.publishingCache.Len() == 0 {
+		if c.publishingCache.Len() == 0 {
 			return
 		}
 
-		c.logger.Info("Emptying publishing cache", logField{Key: "event", Value: "onChannelOpened"})
+		c.logger.Info("Emptying publishing cache", LogField{Key: "event", Value: "onChannelOpened"})
 
 		// For each cached unsuccessful message, we try publishing it again.
 		c.publishingCache.ForEach(func(key string, msg mqttPublishing) {
-			_ = c.channel.PublishWithContext(c.ctx, msg.Exchange, msg.RoutingKey, msg.Mandatory, msg.Immediate, msg.Msg)
+			confirmation, err := c.channel.Load().PublishWithDeferredConfirmWithContext(c.ctx, msg.Exchange, msg.RoutingKey, msg.Mandatory, msg.Immediate, msg.Msg)
+			if err != nil {
+				c.observePublish(msg, PublishOutcomeFailure)
+			} else {
+				c.observePublish(msg, PublishOutcomeRepublished)
+
+				c.auditPublished(msg, confirmation)
+			}
 
 			c.publishingCache.Delete(key)
 		})
 	}
 }
 
+// consumptionContext returns the context of the current consumption.
+func (c *amqpChannel) consumptionContext() context.Context {
+	c.consumptionCtxMutex.Lock()
+	defer c.consumptionCtxMutex.Unlock()
+
+	return c.consumptionCtx
+}
+
+// renewConsumption replaces the context of the consumption with a new one, for the consumption starting.
+func (c *amqpChannel) renewConsumption() {
+	c.consumptionCtxMutex.Lock()
+	defer c.consumptionCtxMutex.Unlock()
+
+	c.consumptionCtx, c.consumptionCancel = context.WithCancel(c.ctx)
+}
+
+// cancelConsumption cancels the context of the current consumption.
+func (c *amqpChannel) cancelConsumption() {
+	c.consumptionCtxMutex.Lock()
+	defer c.consumptionCtxMutex.Unlock()
+
+	if c.consumptionCancel != nil {
+		c.consumptionCancel()
+	}
+}
+
 // onChannelClosed is called when a channel is closed.
 func (c *amqpChannel) onChannelClosed() {
 	if c.connectionType == connectionTypeConsumer {
-		c.logger.Info("Canceling consumptions", logField{Key: "event", Value: "onChannelClosed"})
+		c.logger.Info("Canceling consumptions", LogField{Key: "event", Value: "onChannelClosed"})
 
 		// We cancel the consumptionCtx.
-		c.consumptionCancel()
-	}
-}
+		c.cancelConsumption()
 
-// getID returns a unique identifier for the channel.
-func (c *amqpChannel) getID() string {
-	if c.consumer == nil {
-		return fmt.Sprintf("publisher_%s", uuid.NewString())
-	}
+		// Without a channel, the consumer cannot be active anymore.
+		c.setActive(false)
 
-	return fmt.Sprintf("%s_%s", c.consumer.Name, uuid.NewString())
+		c.stopSubscriptions()
+	}
 }
 
 // consume handles the consumption mechanism.
@@ -374,39 +688,152 @@ func (c *amqpChannel) consume() {
 		return
 	}
 
-	// TODO(Alex): Double check why setting a prefetch size greater than 0 causes an error
-	// Set the QOS, which defines how many messages can be processed at the same time.
-	err := c.channel.Qos(c.consumer.PrefetchCount, c.consumer.PrefetchSize, false)
-	if err != nil {
-		c.logger.Error(err, "Could not define QOS for consumer")
+	// A drained channel must not consume anymore.
+	if c.isDraining() {
+		return
+	}
+
+	// If the consumer carries its topology, we declare it before consuming.
+	if err := c.declareTopology(); err != nil {
+		c.consumptionHealth.AddSubscription(c.consumer.Queue, err)
+
+		c.logger.Error(err, "Could not declare consumer topology")
 
 		return
 	}
 
-	deliveries, err := c.channel.Consume(c.consumer.Queue, c.getID(), c.consumer.AutoAck, false, false, false, nil)
+	// The QOS is ignored by the broker for automatically acknowledged deliveries, so we only define it otherwise.
+	if !c.consumer.autoAck() {
+		// TODO(Alex): Double check why setting a prefetch size greater than 0 causes an error
+		// Set the QOS, which defines how many messages can be processed at the same time.
+		prefetchCount, _ := c.tuning.get()
+
+		err := c.channel.Load().Qos(prefetchCount, c.consumer.PrefetchSize, false)
+		if err != nil {
+			c.logger.Error(err, "Could not define QOS for consumer")
+
+			return
+		}
+	}
+
+	// The consumer tag is stable, so that the subscription can be identified across channel recoveries.
+	consumerTag := c.consumer.Tag
+
+	deliveries, err := c.channel.Load().Consume(c.consumer.Queue, consumerTag, c.consumer.autoAck(), c.consumer.Exclusive, false, false, c.consumeArguments())
+
+	// If the queue is already consumed exclusively, or if we asked for exclusivity on a queue in use, we flag the error.
+	if isErrorAccessRefused(err) {
+		err = fmt.Errorf("%w: %w", ErrExclusiveConsumerConflict, err)
+	} else if isErrorNotFound(err) {
+		err = fmt.Errorf("%w: %w", ErrQueueNotFound, err)
+	}
 
 	c.consumptionHealth.AddSubscription(c.consumer.Queue, err)
 
+	c.setSubscriptionErr(err)
+
 	if err != nil {
 		c.logger.Error(err, "Could not consume messages")
 
 		// If the queue does not exist yet, we want to force a release log with a warning for better visibility.
 		if isErrorNotFound(err) {
-			c.releaseLogger.Warn("Queue does not exist", logField{Key: "queue", Value: c.consumer.Queue})
+			c.releaseLogger.Warn("Queue does not exist", LogField{Key: "queue", Value: c.consumer.Queue})
+		}
+
+		// If the exclusivity could not be acquired, we want to force a release log with a warning for better visibility.
+		// The broker closes the channel, so the guard will retry the subscription if the keepAlive flag is set to true.
+		if errors.Is(err, ErrExclusiveConsumerConflict) {
+			c.releaseLogger.Warn("Queue is in exclusive use", LogField{Key: "queue", Value: c.consumer.Queue})
 		}
 
 		return
 	}
 
+	c.observeConsumer(ConsumerEventSubscribed)
+	defer c.observeConsumer(ConsumerEventCanceled)
+
+	// The consumption stops with its own context, even once the channel was opened again.
+	consumptionCtx := c.consumptionContext()
+
+	// Delivery tags are scoped to the channel, so a new batcher is used for each consumption and the acknowledgements
+	// still pending when the channel is lost are dropped.
+	var batcher *ackBatcher
+
+	if c.consumer.AckBatch != nil && !c.consumer.autoAck() {
+		batcher = newAckBatcher(consumptionCtx, c.channel.Load(), *c.consumer.AckBatch, c.logger)
+	}
+
+	consumptionDone := make(chan struct{})
+	defer close(consumptionDone)
+
+	c.consumptionMutex.Lock()
+	c.consumerTag, c.consumptionDone, c.ackBatcher = consumerTag, consumptionDone, batcher
+	c.consumptionMutex.Unlock()
+
+	if c.consumer.QueueMonitor != nil {
+		go c.monitorQueue()
+	}
+
+	if c.prefetchObserver != nil {
+		go c.adaptPrefetch()
+	}
+
+	// If the consumer requires ordering, deliveries are processed by workers sharded by key.
+	var dispatcher *orderedDispatcher
+
+	if c.consumer.Ordering != nil {
+		dispatcher = newOrderedDispatcher(*c.consumer.Ordering, c.consumer.PrefetchCount, c.dispatchQueued)
+		defer dispatcher.close()
+	}
+
+	// If the consumer schedules by priority, the prefetched deliveries are processed by workers, highest priority first.
+	var prioritizer *priorityDispatcher
+
+	if c.consumer.PriorityScheduling != nil {
+		prioritizer = newPriorityDispatcher(*c.consumer.PriorityScheduling, c.dispatchQueued)
+		defer prioritizer.close()
+	}
+
+	var limiter *rateLimiter
+
 	for {
 		select {
-		case <-c.consumptionCtx.Done():
+		case <-consumptionCtx.Done():
 			return
-		case delivery := <-deliveries:
+		case delivery, ok := <-deliveries:
+			// When the channel is drained or handed over, the deliveries are closed once the buffered ones were received.
+			if !ok && (c.isDraining() || c.handingOver.Load()) {
+				return
+			}
+
 			// When a queue is deleted midway, a delivery with no tag or ID is received.
 			if delivery.DeliveryTag == 0 && delivery.MessageId == "" {
 				c.logger.Warn("Queue has been deleted, stopping consumer")
 
+				// The deliveries of an open channel are only closed once the server cancels the consumer.
+				if !c.channel.Load().IsClosed() {
+					c.setSubscriptionErr(ErrConsumerCanceled)
+				}
+
+				return
+			}
+
+			// Receiving a delivery means that the consumer is active.
+			c.setActive(true)
+
+			// While draining, the deliveries that were not started yet are requeued for another consumer.
+			// Deliveries already acknowledged by the broker are still processed to avoid losing them.
+			if c.isDraining() && !c.consumer.autoAck() {
+				_ = delivery.Nack(false, true)
+
+				c.requeuedCount.Add(1)
+
+				continue
+			}
+
+			// If the consumer is rate limited, we wait for the dispatch to be allowed. The unacknowledged deliveries are
+			// redelivered by the broker if the consumption stops in the meantime.
+			if limiter = c.rateLimiter(limiter); limiter != nil && !limiter.wait(consumptionCtx) {
 				return
 			}
 
@@ -414,206 +841,530 @@ func (c *amqpChannel) consume() {
 			// if a new one is consumed while the previous is still being processed).
 			loopDelivery := delivery
 
-			if c.consumer.ConcurrentProcess {
+			// With batching, the acknowledgements go through the batcher instead of the channel.
+			if batcher != nil {
+				batcher.track(loopDelivery.DeliveryTag)
+
+				loopDelivery.Acknowledger = batcher
+			}
+
+			c.inFlight.Add(1)
+			c.prefetchObserver.observeInFlight(int(c.inFlightCount.Add(1)))
+
+			if dispatcher != nil {
+				// We hand the delivery over to the worker owning its key.
+				if !dispatcher.dispatch(consumptionCtx, &loopDelivery) {
+					c.inFlight.Done()
+					c.inFlightCount.Add(-1)
+
+					return
+				}
+			} else if prioritizer != nil {
+				// The delivery waits for a worker along with the other prefetched deliveries.
+				prioritizer.dispatch(&loopDelivery)
+			} else if c.consumer.ConcurrentProcess {
 				// We process the message asynchronously if the concurrency is set to true.
-				go c.processDelivery(&loopDelivery)
+				go c.dispatchDelivery(&loopDelivery)
 			} else {
 				// Otherwise, we process the message synchronously.
-				c.processDelivery(&loopDelivery)
+				c.dispatchDelivery(&loopDelivery)
 			}
 		}
 	}
 }
 
-// processDelivery is the logic that defines what to do with a processed delivery and its error.
+// dispatchDelivery processes a delivery while tracking it as in-flight.
+func (c *amqpChannel) dispatchDelivery(delivery *amqp.Delivery) {
+	defer c.inFlight.Done()
+	defer c.inFlightCount.Add(-1)
+
+	c.processDelivery(delivery)
+}
+
+// dispatchQueued processes a delivery queued by a dispatcher. If the channel started draining in the meantime, the
+// delivery was not started yet and is requeued for another consumer instead, like the ones received while draining.
+func (c *amqpChannel) dispatchQueued(delivery *amqp.Delivery) {
+	if !c.isDraining() || c.consumer.autoAck() {
+		c.dispatchDelivery(delivery)
+
+		return
+	}
+
+	defer c.inFlight.Done()
+	defer c.inFlightCount.Add(-1)
+
+	_ = delivery.Nack(false, true)
+
+	c.requeuedCount.Add(1)
+}
+
+// processDelivery is the logic that finds and calls the handler of a delivery.
 func (c *amqpChannel) processDelivery(delivery *amqp.Delivery) {
-	handler := c.consumer.Handlers.FindFunc(delivery.RoutingKey)
+	alreadyAcknowledged := c.consumer.autoAck()
+
+	info := newDelivery(c.consumer, delivery)
+
+	c.auditDelivery(delivery, AuditConsumed)
+
+	// If the broker flagged the delivery as redelivered, we notify the consumer's hook.
+	if delivery.Redelivered {
+		c.logger.Debug("Redelivery received", LogField{Key: "messageID", Value: delivery.MessageId})
+
+		if c.consumer.OnRedelivery != nil {
+			c.consumer.OnRedelivery(info)
+		}
+	}
+
+	// If the delivery was already processed, we acknowledge it without calling the handler again.
+	if c.isDuplicate(delivery) {
+		c.logger.Debug("Duplicate delivery skipped", c.deliveryLogFields(delivery)...)
+
+		if !alreadyAcknowledged {
+			_ = delivery.Ack(false)
+		}
+
+		c.auditDelivery(delivery, AuditAcked)
+
+		return
+	}
+
+	routingKey := originalRoutingKey(delivery)
+
+	handler, handlerKey := c.handlers.handlerFor(routingKey)
 
 	// If the handler doesn't exist for the received delivery, we negative acknowledge it without requeue.
 	if handler == nil {
-		c.logger.Debug("No handler found", logField{Key: "routingKey", Value: delivery.RoutingKey})
+		c.logger.Debug("No handler found", LogField{Key: "routingKey", Value: routingKey})
 
 		// If the consumer is not set to auto acknowledge the delivery, we negative acknowledge it without requeue.
-		if !c.consumer.AutoAck {
+		if !alreadyAcknowledged {
 			_ = delivery.Nack(false, false)
+
+			c.auditDelivery(delivery, AuditNacked)
 		}
 
 		return
 	}
 
-	err := handler(delivery.Body)
+	reassembled, err := c.reassembleChunk(delivery)
 
-	// If the consumer has the autoAck flag activated, we want to retry the delivery in case of an error.
-	if c.consumer.AutoAck {
-		if err != nil {
-			go c.retryDelivery(delivery, true)
-		}
+	// A chunk that cannot be reassembled never will be, so it is dead-lettered without calling the handler.
+	if err != nil {
+		c.logger.Error(err, "Could not reassemble delivery", c.deliveryLogFields(delivery)...)
+
+		c.handleResult(delivery, info, alreadyAcknowledged, DeadLetter(err))
+
+		return
+	}
+
+	// The chunks of a message are held until all of them are received.
+	if reassembled == nil {
+		return
+	}
+
+	delivery = reassembled
+	info = newDelivery(c.consumer, delivery)
+
+	payload, err := c.decompress(delivery)
+
+	// A payload that cannot be decoded never will be, so the handler is not called and the decode error policy applies.
+	if err != nil {
+		c.handleDecodeError(delivery, info, alreadyAcknowledged, DecodeStageDecompress, err)
 
 		return
 	}
 
-	// If there is no error, we can simply acknowledge the delivery.
-	if err == nil {
-		c.logger.Debug("Delivery successfully processed", logField{Key: "messageID", Value: delivery.MessageId})
+	if payload, err = c.decodePayload(delivery, routingKey, payload); err != nil {
+		c.handleDecodeError(delivery, info, alreadyAcknowledged, DecodeStageDecode, err)
 
-		_ = delivery.Ack(false)
+		return
+	}
+
+	if info.Envelope, payload, err = c.unwrapDelivery(delivery, payload); err != nil {
+		c.handleDecodeError(delivery, info, alreadyAcknowledged, DecodeStageUnwrap, err)
 
 		return
 	}
 
-	// Otherwise we retry the delivery.
-	go c.retryDelivery(delivery, false)
-}
+	if err = c.validatePayload(delivery, routingKey, payload); err != nil {
+		c.handleDecodeError(delivery, info, alreadyAcknowledged, DecodeStageValidate, err)
 
-// retryDelivery processes a delivery retry based on its redelivery header.
-//
-//nolint:gocognit // We can allow the current complexity for now but we should revisit it later.
-func (c *amqpChannel) retryDelivery(delivery *amqp.Delivery, alreadyAcknowledged bool) {
-	c.logger.Debug("Delivery retry launched")
+		return
+	}
 
-	for {
-		select {
-		case <-c.consumptionCtx.Done():
-			c.logger.Debug("Delivery retry stopped by the consumption context")
+	info.Body = payload
+	info.channel = c
 
-			return
-		default:
-			// We wait for the retry delay before retrying a message.
-			time.Sleep(c.retryDelay)
+	c.logPayload("Delivery payload", payload, c.deliveryLogFields(delivery)...)
 
-			// We first extract the xDeathCountHeader.
-			maxRetryHeader, exists := delivery.Headers[xDeathCountHeader]
+	metrics := DeliveryMetrics{
+		RoutingKey:  routingKey,
+		Handler:     handlerKey,
+		PayloadSize: len(payload),
+	}
 
-			// If the header doesn't exist.
-			if !exists {
-				c.logger.Debug("Delivery retry invalid")
+	untrack := c.trackInFlight(delivery, routingKey, handlerKey)
 
-				// We negative acknowledge the delivery without requeue if the autoAck flag is set to false.
-				if !alreadyAcknowledged {
-					_ = delivery.Nack(false, false)
-				}
+	ctx := c.correlation.restore(ContextWithDelivery(c.consumptionContext(), info), delivery.Headers)
 
-				return
-			}
+	ctx, cancel := DecorateContext(ctx, info, c.consumer.ContextDecorators...)
 
-			// We then cast the value as an int32.
-			retriesCount, ok := maxRetryHeader.(int32)
+	ctx, span := c.startDeliverySpan(ctx, delivery, handlerKey)
 
-			// If the casting fails,we negative acknowledge the delivery without requeue if the autoAck flag is set to false.
-			if !ok {
-				c.logger.Debug("Delivery retry invalid")
+	startedAt := time.Now()
 
-				if !alreadyAcknowledged {
-					_ = delivery.Nack(false, false)
-				}
+	panicked, err := c.callHandler(ctx, handler, payload)
 
-				return
-			}
+	err = handlerError(ctx, err)
 
-			// If the retries count is still greater than 0, we re-publish the delivery with a decremented xDeathCountHeader.
-			if retriesCount > 0 {
-				c.logger.Debug("Retrying delivery", logField{Key: "retriesLeft", Value: retriesCount - 1})
+	cancel()
 
-				// We first negative acknowledge the existing delivery to remove it from queue if the autoAck flag is set to false.
-				if !alreadyAcknowledged {
-					_ = delivery.Nack(false, false)
-				}
+	metrics.Duration = time.Since(startedAt)
 
-				// We create a new publishing which is a copy of the old one but with a decremented xDeathCountHeader.
-				newPublishing := amqp.Publishing{
-					ContentType:  "application/json",
-					Body:         delivery.Body,
-					Type:         delivery.RoutingKey,
-					Priority:     delivery.Priority,
-					DeliveryMode: delivery.DeliveryMode,
-					MessageId:    delivery.MessageId,
-					Timestamp:    delivery.Timestamp,
-					Headers: map[string]interface{}{
-						xDeathCountHeader: int(retriesCount - 1),
-					},
-				}
+	c.prefetchObserver.observeLatency(metrics.Duration)
 
-				// We work on a best-effort basis. We try to re-publish the delivery, but we do nothing if it fails.
-				_ = c.channel.PublishWithContext(c.ctx, delivery.Exchange, delivery.RoutingKey, false, false, newPublishing)
+	endSpan(span, err)
 
-				return
-			}
+	untrack()
 
-			c.logger.Debug("Cannot retry delivery, max retries reached")
+	c.stats.record(err)
 
-			// Otherwise, we negative acknowledge the delivery without requeue if the autoAck flag is set to false.
-			if !alreadyAcknowledged {
-				_ = delivery.Nack(false, false)
-			}
+	c.trackStreamOffset(delivery)
 
-			return
+	// In manual mode, the handler is responsible for the acknowledgement of the delivery.
+	if c.consumer.ackMode() == AckModeManual {
+		metrics.Outcome = DeliveryOutcomeSuccess
+
+		if err != nil {
+			c.logger.Error(err, "Delivery processing failed", c.deliveryLogFields(delivery)...)
+
+			metrics.Outcome = DeliveryOutcomeFailure
+		} else {
+			c.markProcessed(delivery)
 		}
+
+		c.settleChunks(delivery, alreadyAcknowledged, AckDecisionAck)
+	} else {
+		metrics.Outcome = deliveryOutcome(c.handleResult(delivery, info, alreadyAcknowledged, err))
+	}
+
+	if panicked {
+		metrics.Outcome = DeliveryOutcomePanic
 	}
+
+	c.observeDelivery(metrics)
 }
 
-// publish will publish a message with the given configuration.
-func (c *amqpChannel) publish(exchange string, routingKey string, payload []byte, options *PublishingOptions) error {
-	publishing := &amqp.Publishing{
-		ContentType:  "application/json",
-		Body:         payload,
-		Type:         routingKey,
-		Priority:     PriorityMedium.Uint8(),
-		DeliveryMode: Persistent.Uint8(),
-		MessageId:    uuid.NewString(),
-		Timestamp:    time.Now(),
+// callHandler calls the handler of a delivery, recovering from any panic as an error.
+func (c *amqpChannel) callHandler(
+	ctx context.Context,
+	handler MQTTMessageContextHandlerFunc,
+	payload []byte,
+) (panicked bool, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("handler panicked: %v", recovered)
+			panicked = true
+
+			c.releaseLogger.Error(err, "Delivery handler panicked")
+		}
+	}()
+
+	return false, handler(ctx, payload)
+}
+
+// handleResult is the logic that defines what to do with a processed delivery and its error, based on the consumer's
+// AckStrategy. It returns the applied AckDecision.
+func (c *amqpChannel) handleResult(delivery *amqp.Delivery, info Delivery, alreadyAcknowledged bool, err error) AckDecision {
+	decision := c.consumer.ackStrategy().Decide(info, err)
+
+	c.applyDecision(delivery, alreadyAcknowledged, decision, err)
+
+	c.settleChunks(delivery, alreadyAcknowledged, decision)
+
+	c.auditDelivery(delivery, auditAction(decision))
+
+	return decision
+}
+
+// retryDelivery re-publishes a failed delivery to its original exchange while its x-death-count header, stamped from
+// the MaxRetry of its publisher, allows it. The delivery is held for the delay of the recovery policy of the channel,
+// the RetryDelay by default, which can also give up on it. This is the retry mechanism of the consumers without Retry
+// nor RetryPolicy.
+func (c *amqpChannel) retryDelivery(delivery *amqp.Delivery, alreadyAcknowledged bool, reason error) {
+	c.logger.Debug("Delivery retry launched")
+
+	attempt := retryAttempt(delivery) + 1
+
+	previous := time.Duration(headerCount(delivery.Headers[xRetryDelayHeader])) * time.Millisecond
+	delay := c.recovery.NextDelay(attempt, previous)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-c.consumptionContext().Done():
+		c.logger.Debug("Delivery retry stopped by the consumption context")
+
+		return
+	case <-timer.C:
+	}
+
+	// The retries count is the xDeathCountHeader, without which the delivery cannot be retried.
+	retriesCount, ok := delivery.Headers[xDeathCountHeader].(int32)
+	if !ok {
+		c.logger.Debug("Delivery retry invalid")
+
+		// We negative acknowledge the delivery without requeue if the autoAck flag is set to false.
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, false)
+		}
+
+		return
+	}
+
+	// Once the retries count is exhausted or the policy gives up, we give up on the delivery.
+	if retriesCount <= 0 || !c.recovery.ShouldRetry(attempt, reason) {
+		c.logger.Debug("Cannot retry delivery, max retries reached")
+
+		c.deadLetter(delivery, alreadyAcknowledged, reason)
+
+		return
+	}
+
+	c.logger.Debug("Retrying delivery", LogField{Key: "retriesLeft", Value: retriesCount - 1})
+
+	// We first negative acknowledge the existing delivery to remove it from queue if the autoAck flag is set to false.
+	if !alreadyAcknowledged {
+		_ = delivery.Nack(false, false)
+	}
+
+	// We create a new publishing which is a copy of the old one but with a decremented xDeathCountHeader.
+	newPublishing := amqp.Publishing{
+		ContentType:  delivery.ContentType,
+		Body:         delivery.Body,
+		Type:         delivery.RoutingKey,
+		Priority:     delivery.Priority,
+		DeliveryMode: delivery.DeliveryMode,
+		MessageId:    delivery.MessageId,
+		Timestamp:    delivery.Timestamp,
 		Headers: map[string]interface{}{
-			xDeathCountHeader: int(c.maxRetry),
+			xDeathCountHeader:   int(retriesCount - 1),
+			xRetryAttemptHeader: int32(attempt),
+			xRetryDelayHeader:   delay.Milliseconds(),
 		},
 	}
 
+	copyMarshallerHeaders(delivery.Headers, newPublishing.Headers)
+
+	// We work on a best-effort basis. We try to re-publish the delivery, but we do nothing if it fails.
+	_ = c.channel.Load().PublishWithContext(c.ctx, delivery.Exchange, delivery.RoutingKey, false, false, newPublishing)
+}
+
+// publish will publish a message with the given configuration. The metadata set by the Marshaller of the payload, if
+// any, gives its content type and additional headers.
+func (c *amqpChannel) publish(ctx context.Context, exchange string, routingKey string, payload []byte, metadata *MessageMetadata, options *PublishingOptions) error {
+	msg := acquirePublishing()
+
+	// The publishing is reused once sent, unless it is kept by the publishing cache or the pipeline.
+	retained := false
+
+	defer func() {
+		if !retained {
+			releasePublishing(msg)
+		}
+	}()
+
+	publishing := &msg.Msg
+
+	publishing.ContentType = ContentTypeJSON
+	publishing.Body = payload
+	publishing.Type = routingKey
+	publishing.Priority = PriorityMedium.Uint8()
+	publishing.DeliveryMode = Persistent.Uint8()
+	publishing.MessageId = uuid.NewString()
+	publishing.Timestamp = time.Now()
+	publishing.Headers[xDeathCountHeader] = int(c.maxRetry)
+
+	if metadata != nil {
+		if metadata.ContentType != "" {
+			publishing.ContentType = metadata.ContentType
+		}
+
+		for key, value := range metadata.Headers {
+			publishing.Headers[key] = value
+		}
+	}
+
 	// If options are declared, we add the option.
 	if options != nil {
 		publishing.Priority = options.priority()
 		publishing.DeliveryMode = options.mode()
+		publishing.CorrelationId = options.CorrelationID
+		publishing.ReplyTo = options.ReplyTo
+		routingKey = options.routing(routingKey, publishing.Headers)
 	}
 
+	span := c.startPublishSpan(ctx, exchange, routingKey, publishing)
+
+	correlationFields := c.correlation.inject(ctx, publishing.Headers)
+
+	c.logPayload(
+		"Publishing payload",
+		payload,
+		append([]LogField{{Key: "messageID", Value: publishing.MessageId}, {Key: "routingKey", Value: routingKey}}, correlationFields...)...,
+	)
+
+	msg.Exchange = exchange
+	msg.RoutingKey = routingKey
+	msg.Mandatory = (options != nil && options.Mandatory) || c.reliable
+
+	// The pipeline sends the message in the background, even once the channel is back up.
+	if c.pipeline != nil {
+		err := c.pipeline.enqueue(ctx, &pipelinedPublishing{msg: msg, span: span, fields: correlationFields})
+		if err != nil {
+			endSpan(span, err)
+		}
+
+		retained = err == nil
+
+		return err
+	}
+
+	// A blocking publishing waits, until its context is done, for the channel and the broker instead of being cached.
+	if c.backpressure != nil {
+		if err := c.awaitPublishable(ctx); err != nil {
+			c.logger.Error(err, "Could not publish message", correlationFields...)
+
+			c.observePublish(*msg, PublishOutcomeFailure)
+
+			endSpan(span, err)
+
+			return err
+		}
+	}
+
+	// With a deadline, the publishing waits, within its budget, for the channel to be recovered instead of failing
+	// right away.
+	if !c.ready() && c.keepAlive {
+		c.awaitRecovery(ctx)
+	}
+
+	dropped := c.faults.dropPublish()
+
 	// If the channel is not ready, we cannot publish, but we send the message to cache if the keepAlive flag is set to true.
-	if !c.ready() {
+	if !c.ready() || dropped {
 		err := errChannelClosed
+		if dropped {
+			err = ErrInjectedFault
+		}
+
+		if c.keepAlive && c.backpressure == nil {
+			c.logger.Error(err, "Could not publish message, sending to cache", correlationFields...)
+
+			full := c.publishingCache.Put(msg.HashCode(), *msg)
 
-		if c.keepAlive {
-			c.logger.Error(err, "Could not publish message, sending to cache")
+			retained = true
 
-			msg := mqttPublishing{
-				Exchange:   exchange,
-				RoutingKey: routingKey,
-				Mandatory:  false,
-				Immediate:  false,
-				Msg:        *publishing,
+			c.observePublish(*msg, PublishOutcomeCached)
+
+			if full {
+				err = fmt.Errorf("%w: %w", ErrCacheFull, err)
 			}
 
-			c.publishingCache.Put(msg.HashCode(), msg)
+			if c.reliable {
+				err = fmt.Errorf("%w: %w", ErrPublishingCached, err)
+			}
 		} else {
-			c.logger.Error(err, "Could not publish message")
+			c.logger.Error(err, "Could not publish message", correlationFields...)
+
+			c.observePublish(*msg, PublishOutcomeFailure)
 		}
 
+		endSpan(span, err)
+
 		return err
 	}
 
-	err := c.channel.PublishWithContext(c.ctx, exchange, routingKey, false, false, *publishing)
+	// The returns of the channel are those of the publishing, even if the channel is replaced in the meantime.
+	returns := c.returns.Load()
+
+	confirmation, err := c.channel.Load().PublishWithDeferredConfirmWithContext(c.ctx, exchange, routingKey, msg.Mandatory, false, *publishing)
 
 	// If the message could not be sent we return an error without caching it.
 	if err != nil {
-		c.logger.Error(err, "Could not publish message")
+		err = publishingError(err)
+
+		c.observePublish(*msg, PublishOutcomeFailure)
+
+		c.logger.Error(err, "Could not publish message", correlationFields...)
 
 		// If the exchange does not exist yet, we want to force a release log with a warning for better visibility.
 		if isErrorNotFound(err) {
 			c.releaseLogger.Warn(
 				"The MQTT message was not sent, exchange does not exist",
-				logField{Key: "exchange", Value: exchange},
-				logField{Key: "routingKey", Value: routingKey},
+				LogField{Key: "exchange", Value: exchange},
+				LogField{Key: "routingKey", Value: routingKey},
 			)
 		}
 
+		endSpan(span, err)
+
 		return err
 	}
 
-	c.logger.Debug("Message successfully sent", logField{Key: "messageID", Value: publishing.MessageId})
+	if c.reliable {
+		c.auditPublished(*msg, confirmation)
+
+		// The publishing only succeeds once confirmed by the broker.
+		retained, err = c.awaitConfirmation(ctx, msg, confirmation, returns)
+		if err != nil {
+			c.logger.Error(err, "Reliable publishing not confirmed", correlationFields...)
+
+			endSpan(span, err)
+
+			return err
+		}
+
+		endSpan(span, nil)
+
+		c.logger.Debug(
+			"Message successfully confirmed",
+			append([]LogField{{Key: "messageID", Value: publishing.MessageId}}, correlationFields...)...,
+		)
+
+		return nil
+	}
+
+	endSpan(span, nil)
+
+	c.observePublish(*msg, PublishOutcomeSuccess)
+
+	c.auditPublished(*msg, confirmation)
+
+	c.logger.Debug(
+		"Message successfully sent",
+		append([]LogField{{Key: "messageID", Value: publishing.MessageId}}, correlationFields...)...,
+	)
 
 	return nil
 }
+
+// publishingError wraps the error returned by the server for a publishing with the matching exported error, so that it
+// can be checked with errors.Is while keeping the server's reason.
+func publishingError(err error) error {
+	var typed error
+
+	switch {
+	case errors.Is(err, amqp.ErrClosed):
+		typed = ErrNotConnected
+	case isErrorNotFound(err):
+		typed = ErrExchangeNotFound
+	case isErrorAccessRefused(err):
+		typed = ErrAccessRefused
+	default:
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", typed, err)
+}
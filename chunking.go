@@ -0,0 +1,265 @@
+package gorabbit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// chunkHeaders are the headers describing a chunk, removed from the reassembled delivery.
+var chunkHeaders = []string{chunkIDHeader, chunkIndexHeader, chunkCountHeader, chunkChecksumHeader}
+
+// splitPayload splits a payload into chunks of at most the given size.
+func splitPayload(payload []byte, size int) [][]byte {
+	chunks := make([][]byte, 0, (len(payload)+size-1)/size)
+
+	for len(payload) > size {
+		chunks = append(chunks, payload[:size])
+		payload = payload[size:]
+	}
+
+	return append(chunks, payload)
+}
+
+// payloadChecksum returns the hex encoded SHA-256 checksum of a payload.
+func payloadChecksum(payload []byte) string {
+	sum := sha256.Sum256(payload)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// publishChunks publishes a payload as consecutive chunk messages sharing the same chunk ID.
+func (c *connectionManager) publishChunks(ctx context.Context, exchange, routingKey string, payload []byte, metadata *MessageMetadata, options *PublishingOptions) error {
+	chunks := splitPayload(payload, c.chunkSize)
+	id := uuid.NewString()
+	checksum := payloadChecksum(payload)
+
+	for i, chunk := range chunks {
+		chunkMetadata := *metadata
+		chunkMetadata.Headers = make(map[string]interface{}, len(metadata.Headers)+len(chunkHeaders))
+
+		for key, value := range metadata.Headers {
+			chunkMetadata.Headers[key] = value
+		}
+
+		chunkMetadata.Headers[chunkIDHeader] = id
+		chunkMetadata.Headers[chunkIndexHeader] = i
+		chunkMetadata.Headers[chunkCountHeader] = len(chunks)
+		chunkMetadata.Headers[chunkChecksumHeader] = checksum
+
+		if err := c.publisherConnection.publish(ctx, exchange, routingKey, chunk, &chunkMetadata, options); err != nil {
+			return fmt.Errorf("could not publish chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+	}
+
+	return nil
+}
+
+// chunkedMessage holds the chunks of a message received so far.
+type chunkedMessage struct {
+	deliveries []*amqp.Delivery
+	received   int
+	startedAt  time.Time
+}
+
+// chunkAssembler reassembles the chunked messages of a consumer. The deliveries of the chunks are held unacknowledged
+// until the reassembled delivery is processed, then settled the same way.
+type chunkAssembler struct {
+	mutex    sync.Mutex
+	messages map[string]*chunkedMessage
+	held     map[uint64][]*amqp.Delivery
+}
+
+// add adds a chunk to its message, returning the chunks of the message once all of them were received.
+func (a *chunkAssembler) add(id string, index, count uint, delivery *amqp.Delivery) []*amqp.Delivery {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.messages == nil {
+		a.messages = make(map[string]*chunkedMessage)
+		a.held = make(map[uint64][]*amqp.Delivery)
+	}
+
+	message, found := a.messages[id]
+	if !found {
+		message = &chunkedMessage{
+			deliveries: make([]*amqp.Delivery, count),
+			startedAt:  time.Now(),
+		}
+
+		a.messages[id] = message
+	}
+
+	if message.deliveries[index] == nil {
+		message.received++
+	}
+
+	message.deliveries[index] = delivery
+
+	if message.received < len(message.deliveries) {
+		return nil
+	}
+
+	delete(a.messages, id)
+
+	held := make([]*amqp.Delivery, 0, len(message.deliveries)-1)
+
+	for _, chunk := range message.deliveries {
+		if chunk != delivery {
+			held = append(held, chunk)
+		}
+	}
+
+	a.held[delivery.DeliveryTag] = held
+
+	return message.deliveries
+}
+
+// expire removes the messages whose chunks were not all received within the timeout, returning their chunks.
+func (a *chunkAssembler) expire(timeout time.Duration) []*amqp.Delivery {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	var expired []*amqp.Delivery
+
+	for id, message := range a.messages {
+		if time.Since(message.startedAt) < timeout {
+			continue
+		}
+
+		for _, chunk := range message.deliveries {
+			if chunk != nil {
+				expired = append(expired, chunk)
+			}
+		}
+
+		delete(a.messages, id)
+	}
+
+	return expired
+}
+
+// release returns and forgets the chunks held with the delivery completing their message.
+func (a *chunkAssembler) release(deliveryTag uint64) []*amqp.Delivery {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	held := a.held[deliveryTag]
+
+	delete(a.held, deliveryTag)
+
+	return held
+}
+
+// reassembleChunk adds a chunk delivery to its message and returns the reassembled delivery once all the chunks were
+// received, or nil. Deliveries that are not chunks are returned as is.
+func (c *amqpChannel) reassembleChunk(delivery *amqp.Delivery) (*amqp.Delivery, error) {
+	id, ok := delivery.Headers[chunkIDHeader].(string)
+	if !ok {
+		return delivery, nil
+	}
+
+	c.expireChunks()
+
+	index := headerCount(delivery.Headers[chunkIndexHeader])
+	count := headerCount(delivery.Headers[chunkCountHeader])
+
+	if count == 0 || index >= count {
+		return nil, fmt.Errorf("%w: chunk %d of %d", errInvalidChunk, index, count)
+	}
+
+	chunks := c.chunks.add(id, index, count, delivery)
+	if chunks == nil {
+		return nil, nil
+	}
+
+	var body bytes.Buffer
+
+	for _, chunk := range chunks {
+		body.Write(chunk.Body)
+	}
+
+	if checksum, _ := delivery.Headers[chunkChecksumHeader].(string); checksum != payloadChecksum(body.Bytes()) {
+		return nil, fmt.Errorf("%w: chunked message '%s'", errChunkChecksumMismatch, id)
+	}
+
+	reassembled := *delivery
+	reassembled.Body = body.Bytes()
+	reassembled.Headers = make(amqp.Table, len(delivery.Headers))
+
+	for key, value := range delivery.Headers {
+		reassembled.Headers[key] = value
+	}
+
+	for _, header := range chunkHeaders {
+		delete(reassembled.Headers, header)
+	}
+
+	return &reassembled, nil
+}
+
+// expireChunks settles the chunks of the messages that could not be reassembled within the consumer's timeout. They are
+// dead-lettered if the consumer defines where to, with a quarantine or a DeadLetterExchange, and requeued otherwise so
+// that they are not lost.
+func (c *amqpChannel) expireChunks() {
+	expired := c.chunks.expire(c.consumer.chunkTimeout())
+	if len(expired) == 0 {
+		return
+	}
+
+	deadLetter := c.consumer.hasQuarantine() || c.consumer.hasDeadLetterExchange()
+
+	c.releaseLogger.Warn("Incomplete chunked message expired",
+		LogField{Key: "chunks", Value: len(expired)},
+		LogField{Key: "deadLettered", Value: deadLetter},
+	)
+
+	if c.consumer.autoAck() {
+		return
+	}
+
+	for _, chunk := range expired {
+		if !deadLetter {
+			_ = chunk.Nack(false, true)
+
+			continue
+		}
+
+		id, _ := chunk.Headers[chunkIDHeader].(string)
+
+		c.deadLetter(chunk, false, fmt.Errorf("%w: chunked message '%s'", errChunkExpired, id))
+	}
+}
+
+// settleChunks settles the chunks held with a reassembled delivery according to the decision applied to the delivery:
+// they are requeued when the delivery is requeued as is, negative acknowledged when the delivery is dead-lettered by the
+// broker, and acknowledged when the reassembled payload is processed, dropped or republished.
+func (c *amqpChannel) settleChunks(delivery *amqp.Delivery, alreadyAcknowledged bool, decision AckDecision) {
+	held := c.chunks.release(delivery.DeliveryTag)
+
+	if alreadyAcknowledged || len(held) == 0 {
+		return
+	}
+
+	requeue := decision == AckDecisionRequeue ||
+		(decision == AckDecisionRetry && c.consumer.Retry == nil && c.consumer.RetryPolicy == nil && c.consumer.NackDelay > 0)
+	deadLetter := decision == AckDecisionDeadLetter && !c.consumer.hasQuarantine()
+
+	for _, chunk := range held {
+		switch {
+		case requeue:
+			_ = chunk.Nack(false, true)
+		case deadLetter:
+			_ = chunk.Nack(false, false)
+		default:
+			_ = chunk.Ack(false)
+		}
+	}
+}
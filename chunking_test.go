@@ -0,0 +1,179 @@
+package gorabbit_test
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// chunkRecorder records the payloads handled by a consumer.
+type chunkRecorder struct {
+	mutex    sync.Mutex
+	payloads []string
+}
+
+func (r *chunkRecorder) handle(payload []byte) error {
+	var value string
+
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.payloads = append(r.payloads, value)
+
+	return nil
+}
+
+func (r *chunkRecorder) handled() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.payloads...)
+}
+
+// newChunkingClient returns a client publishing in chunks of 10 bytes and consuming the queue with the consumer, the
+// payloads published to the queue through the default exchange being delivered to it.
+func newChunkingClient(t *testing.T, server *fakeServer, consumer gorabbit.MessageConsumer) (gorabbit.MQTTClient, *chunkRecorder) {
+	t.Helper()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetChunkSize(10))
+
+	t.Cleanup(func() { _ = client.Disconnect() })
+
+	recorder := &chunkRecorder{}
+
+	consumer.Queue, consumer.Name = "events", "events"
+	consumer.Handlers = gorabbit.MQTTMessageHandlers{"events": recorder.handle}
+
+	require.NoError(t, client.RegisterConsumer(consumer))
+	require.Eventually(t, func() bool { return server.consumed("events") }, time.Second, 10*time.Millisecond)
+
+	return client, recorder
+}
+
+func TestClient_Chunking_Reassembled(t *testing.T) {
+	server := newFakeServer(t)
+	client, recorder := newChunkingClient(t, server, gorabbit.MessageConsumer{})
+
+	// The encoded payload, quotes included, is split into chunks of 10, 10 and 5 bytes.
+	payload := strings.Repeat("x", 23)
+
+	require.NoError(t, client.Publish("", "events", payload))
+	require.Eventually(t, func() bool { return server.receivedCount() == 3 }, time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool { return len(recorder.handled()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{payload}, recorder.handled())
+
+	// The chunks held until the message is reassembled are settled along with it.
+	require.Eventually(t, func() bool { return len(server.settled()) == 3 }, time.Second, 10*time.Millisecond)
+
+	for _, settlement := range server.settled() {
+		assert.True(t, settlement.Acked)
+	}
+
+	// A payload that fits in a chunk is published as is.
+	require.NoError(t, client.Publish("", "events", "small"))
+
+	require.Eventually(t, func() bool { return len(recorder.handled()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "small", recorder.handled()[1])
+	assert.Equal(t, 4, server.receivedCount())
+}
+
+// publishIncomplete publishes a chunked message whose last chunk is rejected by the server, hence never delivered,
+// then waits for the timeout of the chunks.
+func publishIncomplete(t *testing.T, server *fakeServer, client gorabbit.MQTTClient, timeout time.Duration) {
+	t.Helper()
+
+	published := 0
+
+	server.setOnPublish(func(publishing fakePublishing) fakeAction {
+		if publishing.RoutingKey != "events" {
+			return fakeAck
+		}
+
+		if published++; published == 3 {
+			return fakeNack
+		}
+
+		return fakeAck
+	})
+
+	require.NoError(t, client.Publish("", "events", strings.Repeat("x", 23)))
+	require.Eventually(t, func() bool { return server.receivedCount() == 3 }, time.Second, 10*time.Millisecond)
+
+	time.Sleep(timeout)
+
+	server.setOnPublish(nil)
+}
+
+func TestClient_Chunking_ExpiredRequeued(t *testing.T) {
+	server := newFakeServer(t)
+	client, recorder := newChunkingClient(t, server, gorabbit.MessageConsumer{ChunkTimeout: 50 * time.Millisecond})
+
+	publishIncomplete(t, server, client, 60*time.Millisecond)
+
+	// The next chunk expires the incomplete message, whose chunks are requeued rather than lost.
+	require.NoError(t, client.Publish("", "events", strings.Repeat("y", 23)))
+
+	require.Eventually(t, func() bool { return len(recorder.handled()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{strings.Repeat("y", 23)}, recorder.handled())
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 5 }, time.Second, 10*time.Millisecond)
+
+	settled := server.settled()
+	assert.Equal(t, []fakeSettlement{{Body: `"xxxxxxxxx`, Requeue: true}, {Body: "xxxxxxxxxx", Requeue: true}}, settled[:2])
+}
+
+func TestClient_Chunking_ExpiredQuarantined(t *testing.T) {
+	server := newFakeServer(t)
+	client, _ := newChunkingClient(t, server, gorabbit.MessageConsumer{
+		ChunkTimeout:    50 * time.Millisecond,
+		QuarantineQueue: "events.quarantine",
+	})
+
+	publishIncomplete(t, server, client, 60*time.Millisecond)
+
+	var (
+		mutex       sync.Mutex
+		quarantined []string
+	)
+
+	server.setOnPublish(func(publishing fakePublishing) fakeAction {
+		if publishing.RoutingKey == "events.quarantine" {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			quarantined = append(quarantined, publishing.Body)
+		}
+
+		return fakeAck
+	})
+
+	require.NoError(t, client.Publish("", "events", strings.Repeat("y", 23)))
+
+	// The chunks of the incomplete message are copied to the quarantine queue, then acknowledged.
+	require.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(quarantined) == 2
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{`"xxxxxxxxx`, "xxxxxxxxxx"}, quarantined)
+
+	require.Eventually(t, func() bool { return len(server.settled()) >= 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []fakeSettlement{{Body: `"xxxxxxxxx`, Acked: true}, {Body: "xxxxxxxxxx", Acked: true}}, server.settled()[:2])
+}
@@ -0,0 +1,202 @@
+package gorabbit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// CircuitState is the state of the circuit breaker of the publishings.
+type CircuitState string
+
+const (
+	// CircuitClosed is the state of a circuit letting the publishings through.
+	CircuitClosed CircuitState = "closed"
+
+	// CircuitOpen is the state of a circuit failing the publishings with ErrCircuitOpen, the broker being unavailable.
+	CircuitOpen CircuitState = "open"
+
+	// CircuitHalfOpen is the state of a circuit letting a few probe publishings through, to detect the recovery of the
+	// broker.
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// String returns the string representation of the CircuitState.
+func (s CircuitState) String() string {
+	return string(s)
+}
+
+// CircuitBreakerConfig enables the circuit breaker of the publishings. Once FailureThreshold publishings in a row fail
+// because the broker is unavailable, the circuit opens: the publishings fail right away with ErrCircuitOpen, without
+// being sent nor cached. After the OpenDuration, the circuit is half-open and lets HalfOpenProbes publishings through:
+// it closes once they all succeed, and opens again as soon as one fails.
+//
+// Only the failures of the broker count, such as a closed channel or a publishing timing out, not the invalid
+// publishings nor the canceled ones.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of publishings failing in a row that opens the circuit. Defaults to 5.
+	FailureThreshold int `yaml:"failure_threshold"`
+
+	// OpenDuration is the time during which the circuit stays open before being half-open. Defaults to 30 seconds.
+	OpenDuration time.Duration `yaml:"open_duration"`
+
+	// HalfOpenProbes is the number of publishings let through by the half-open circuit. Defaults to 1.
+	HalfOpenProbes int `yaml:"half_open_probes"`
+
+	// OnStateChange is called, if set, with each new state of the circuit. It must not block.
+	OnStateChange func(state CircuitState) `yaml:"-"`
+}
+
+// circuitBreaker fails the publishings fast while the broker is unavailable.
+type circuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+	halfOpenProbes   int
+	onStateChange    func(state CircuitState)
+	logger           Logger
+
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+	mutex     sync.Mutex
+}
+
+// newCircuitBreaker instantiates a new circuitBreaker with the given configuration, or returns nil if not set.
+func newCircuitBreaker(config *CircuitBreakerConfig, logger Logger) *circuitBreaker {
+	if config == nil {
+		return nil
+	}
+
+	openDuration := config.OpenDuration
+	if openDuration <= 0 {
+		openDuration = defaultCircuitOpenDuration
+	}
+
+	return &circuitBreaker{
+		failureThreshold: defaultInt(config.FailureThreshold, defaultCircuitFailureThreshold),
+		openDuration:     openDuration,
+		halfOpenProbes:   defaultInt(config.HalfOpenProbes, 1),
+		onStateChange:    config.OnStateChange,
+		logger:           logger,
+		state:            CircuitClosed,
+	}
+}
+
+// allow returns ErrCircuitOpen if the publishing must not be sent. Otherwise, the outcome of the publishing must be
+// recorded.
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.openDuration {
+		b.transition(CircuitHalfOpen)
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		return ErrCircuitOpen
+	case CircuitHalfOpen:
+		if b.probes >= b.halfOpenProbes {
+			return ErrCircuitOpen
+		}
+
+		b.probes++
+	case CircuitClosed:
+	}
+
+	return nil
+}
+
+// record records the outcome of a publishing let through by allow.
+func (b *circuitBreaker) record(err error) {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	failed := isBrokerFailure(err)
+
+	switch b.state {
+	case CircuitClosed:
+		switch {
+		case failed:
+			b.failures++
+		case err == nil:
+			b.failures = 0
+		}
+	case CircuitHalfOpen:
+		// The publishing may have been let through before the circuit was half-open.
+		if b.probes > 0 {
+			b.probes--
+		}
+
+		switch {
+		case failed:
+			b.transition(CircuitOpen)
+		case err == nil:
+			b.successes++
+
+			if b.successes >= b.halfOpenProbes {
+				b.transition(CircuitClosed)
+			}
+		}
+	case CircuitOpen:
+		// The publishing was let through before the circuit opened.
+	}
+
+	if b.state == CircuitClosed && b.failures >= b.failureThreshold {
+		b.transition(CircuitOpen)
+	}
+}
+
+// transition switches the circuit to the given state. The circuitBreaker must be locked.
+func (b *circuitBreaker) transition(state CircuitState) {
+	b.state = state
+	b.failures, b.probes, b.successes = 0, 0, 0
+
+	switch state {
+	case CircuitOpen:
+		b.openedAt = time.Now()
+
+		b.logger.Warn("Publishing circuit opened", LogField{Key: "openDuration", Value: b.openDuration.String()})
+	case CircuitHalfOpen:
+		b.logger.Info("Publishing circuit half-open")
+	case CircuitClosed:
+		b.logger.Info("Publishing circuit closed")
+	}
+
+	if b.onStateChange != nil {
+		b.onStateChange(state)
+	}
+}
+
+// isBrokerFailure returns true if the error of a publishing is due to the unavailability of the broker, rather than to
+// the publishing itself such as a missing exchange.
+func isBrokerFailure(err error) bool {
+	var amqpErr *amqp.Error
+
+	if errors.As(err, &amqpErr) {
+		switch amqpErr.Code {
+		case amqp.NotFound, amqp.AccessRefused, amqp.PreconditionFailed:
+			return false
+		}
+
+		return true
+	}
+
+	return errors.Is(err, ErrNotConnected) ||
+		errors.Is(err, errPublishingNotConfirmed) ||
+		errors.Is(err, ErrInjectedFault) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,76 @@
+package gorabbit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var (
+		states []gorabbit.CircuitState
+		mutex  sync.Mutex
+	)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetCircuitBreaker(gorabbit.CircuitBreakerConfig{
+			FailureThreshold: 2,
+			OpenDuration:     50 * time.Millisecond,
+			OnStateChange: func(state gorabbit.CircuitState) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				states = append(states, state)
+			},
+		}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// Nothing listens on the port, so the publishings fail until the circuit opens.
+	for i := 0; i < 2; i++ {
+		err := client.Publish("events_exchange", "event.created", "payload")
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, gorabbit.ErrCircuitOpen)
+	}
+
+	require.ErrorIs(t, client.Publish("events_exchange", "event.created", "payload"), gorabbit.ErrCircuitOpen)
+
+	// Once half-open, the probe fails and the circuit opens again.
+	time.Sleep(60 * time.Millisecond)
+
+	err := client.Publish("events_exchange", "event.created", "payload")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, gorabbit.ErrCircuitOpen)
+
+	require.ErrorIs(t, client.Publish("events_exchange", "event.created", "payload"), gorabbit.ErrCircuitOpen)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	assert.Equal(t, []gorabbit.CircuitState{gorabbit.CircuitOpen, gorabbit.CircuitHalfOpen, gorabbit.CircuitOpen}, states)
+}
+
+func TestClient_CircuitBreaker_InvalidPublishing(t *testing.T) {
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetCircuitBreaker(gorabbit.CircuitBreakerConfig{FailureThreshold: 1}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// The payload cannot be marshalled, which is no failure of the broker.
+	for i := 0; i < 3; i++ {
+		err := client.Publish("events_exchange", "event.created", make(chan int))
+
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, gorabbit.ErrCircuitOpen)
+	}
+}
@@ -2,8 +2,9 @@ package gorabbit
 
 import (
 	"context"
-	"fmt"
 	"os"
+	"sync"
+	"sync/atomic"
 )
 
 // MQTTClient is a simple MQTT interface that offers basic client operations such as:
@@ -12,6 +13,8 @@ import (
 //   - Disconnecting
 //   - Ready and health checks
 type MQTTClient interface {
+	Client
+
 	// Disconnect launches the disconnection process.
 	// This operation disables to client permanently.
 	Disconnect() error
@@ -31,6 +34,10 @@ type MQTTClient interface {
 	// Returns an error if the connection to the RabbitMQ server is down.
 	PublishWithOptions(exchange, routingKey string, payload interface{}, options *PublishingOptions) error
 
+	// PublishWithContext behaves like PublishWithOptions, with a context whose span, if tracing is enabled, is the
+	// parent of the publishing span. options can be nil.
+	PublishWithContext(ctx context.Context, exchange, routingKey string, payload interface{}, options *PublishingOptions) error
+
 	// RegisterConsumer will register a MessageConsumer for internal queue subscription and message processing.
 	// The MessageConsumer will hold a list of MQTTMessageHandlers to internalize message processing.
 	// Based on the return of error of each handler, the process of acknowledgment, rejection and retry of messages is
@@ -39,12 +46,63 @@ type MQTTClient interface {
 	// alive if and when necessary.
 	RegisterConsumer(consumer MessageConsumer) error
 
+	// Drain gracefully stops all consumers, typically before the termination of the process:
+	//	- subscriptions are canceled so that the broker stops sending deliveries.
+	//	- deliveries received but not processed yet are negative acknowledged with requeue.
+	//	- in-flight deliveries are awaited until their processing ends or the context is canceled.
+	//	- publishings buffered by the PublishingPipeline are awaited until they are confirmed or dropped.
+	// Returns a DrainReport holding the number of awaited and requeued deliveries, and of flushed publishings.
+	// Drained consumers do not consume anymore, but the client can still publish until it is disconnected.
+	Drain(ctx context.Context) (DrainReport, error)
+
+	// Events returns the stream of the lifecycle events of the client: connections and channels opened, lost and
+	// recovered, consumers subscribed and canceled, publishings cached and replayed, deliveries dead-lettered.
+	// The stream is buffered and is meant for a single reader. Events are dropped when it is not read fast enough.
+	Events() <-chan Event
+
 	// IsReady returns true if the client is fully operational and connected to the RabbitMQ.
 	IsReady() bool
 
 	// IsHealthy returns true if the client is ready (IsReady) and all channels are operating successfully.
 	IsHealthy() bool
 
+	// HealthReport returns a detailed view of the client's health, including the state of each consumer and, if
+	// monitored, the depth of their queue.
+	HealthReport() HealthReport
+
+	// ConsumerStats returns live statistics of each consumer, such as its delivery rate and last error.
+	ConsumerStats() []ConsumerStats
+
+	// ExportSchema returns the topology carried by the registered consumers as a RabbitMQ definitions JSON document, in
+	// the SchemaDefinitions format, so that it can be compared with the definitions exported from the broker.
+	ExportSchema() ([]byte, error)
+
+	// VerifyTopology verifies, without creating anything, that the queues of the registered consumers and the topology
+	// they carry exist with the expected properties. The report lists what is missing or mismatched.
+	// Returns an error if the verification itself could not be performed.
+	VerifyTopology(ctx context.Context) (TopologyReport, error)
+
+	// PurgeQueue removes the ready messages of a queue, and returns their number.
+	// Returns ErrQueueNotFound if the queue does not exist.
+	PurgeQueue(queue string) (int, error)
+
+	// DeleteQueue deletes a queue, and returns the number of messages it held. If ifUnused is true, the queue is only
+	// deleted if it has no consumers, otherwise ErrQueueInUse is returned. If ifEmpty is true, the queue is only
+	// deleted if it has no messages, otherwise ErrQueueNotEmpty is returned.
+	DeleteQueue(queue string, ifUnused, ifEmpty bool) (int, error)
+
+	// DeleteExchange deletes an exchange. If ifUnused is true, the exchange is only deleted if it has no bindings,
+	// otherwise ErrExchangeInUse is returned.
+	DeleteExchange(exchange string, ifUnused bool) error
+
+	// Unbind removes the binding of a queue to an exchange via a given routingKey and, for headers exchanges, arguments.
+	// Returns ErrQueueNotFound or ErrExchangeNotFound if the queue or the exchange does not exist.
+	Unbind(exchange, queue, routingKey string, args map[string]interface{}) error
+
+	// IsConsumerActive returns true if the consumer with the given name is actively receiving deliveries.
+	// A consumer declared as SingleActiveConsumer stays passive until the broker elects it.
+	IsConsumerActive(name string) bool
+
 	// GetHost returns the host used to initialize the client.
 	GetHost() string
 
@@ -59,33 +117,58 @@ type MQTTClient interface {
 
 	// IsDisabled returns whether the client is disabled or not.
 	IsDisabled() bool
+
+	// UpdateConfig applies the options over the current ones without recreating the connections, unless the connection
+	// settings change. The RetryDelay, the logging options, the PrefetchCount and the defaults of the consumers and
+	// publishings are applied live, including to the consumers that inherit them. A *ClientOptions is rejected, as it
+	// would replace every setting.
+	// Returns the problems of the resulting options, in which case nothing is applied.
+	UpdateConfig(opts ...Option) error
 }
 
 type mqttClient struct {
-	// Host is the RabbitMQ server host name.
-	Host string
+	// options are the current options of the client, replaced by UpdateConfig.
+	options atomic.Pointer[ClientOptions]
 
-	// Port is the RabbitMQ server port number.
-	Port uint
+	// consumers are the registered consumers, as registered before the defaults of the options are applied.
+	consumers []MessageConsumer
 
-	// Username is the RabbitMQ server allowed username.
-	Username string
-
-	// Password is the RabbitMQ server allowed password.
-	Password string
-
-	// Vhost is used for CloudAMQP connections to set the specific vhost.
-	Vhost string
+	// reloadMutex serializes the updates of the configuration and the registrations of consumers.
+	reloadMutex sync.Mutex
 
 	// logger defines the logger used, depending on the mode set.
-	logger logger
+	logger *reloadableLogger
 
 	// disabled completely disables the client if true.
 	disabled bool
 
+	// events emits the lifecycle events of the client.
+	events *eventBus
+
+	// health keeps the recent transitions of the connections and detects their flapping.
+	health *healthHistory
+
+	// auditor records the lifecycle of the messages into the AuditSink, if set.
+	auditor *auditor
+
+	// breaker fails the publishings fast while the broker is unavailable, if set.
+	breaker *circuitBreaker
+
 	// connectionManager manages the connection and channel logic and high-level logic
-	// such as keep alive mechanism and health check.
-	connectionManager *connectionManager
+	// such as keep alive mechanism and health check. It is replaced when UpdateConfig dials again.
+	connectionManager atomic.Pointer[connectionManager]
+
+	// topology records the topology declared through the manager, kept across the managers dialed again.
+	topology *topologyRegistry
+
+	// managerOptions are the options of the manager setting up the topology.
+	managerOptions *ManagerOptions
+
+	// manager sets up the topology, dialed on the first SetupTopology.
+	manager MQTTManager
+
+	// managerMutex guards the manager.
+	managerMutex sync.Mutex
 
 	// ctx holds the global context used for the client.
 	ctx context.Context
@@ -95,11 +178,15 @@ type mqttClient struct {
 }
 
 // NewClient will instantiate a new MQTTClient.
-// If options is set to nil, the DefaultClientOptions will be used.
-func NewClient(options *ClientOptions) MQTTClient {
-	// If no options is passed, we use the DefaultClientOptions.
-	if options == nil {
-		options = DefaultClientOptions()
+// The options are applied in order over the DefaultClientOptions, either a *ClientOptions or functional options such as
+// WithURI. If no options are passed, or only nil ones, the DefaultClientOptions will be used.
+func NewClient(opts ...Option) MQTTClient {
+	options := DefaultClientOptions()
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt.apply(options)
+		}
 	}
 
 	return newClientFromOptions(options)
@@ -114,14 +201,13 @@ func NewClientFromEnv() MQTTClient {
 
 func newClientFromOptions(options *ClientOptions) MQTTClient {
 	client := &mqttClient{
-		Host:     options.Host,
-		Port:     options.Port,
-		Username: options.Username,
-		Password: options.Password,
-		Vhost:    options.Vhost,
-		logger:   &noLogger{},
+		logger: newReloadableLogger(&noLogger{}),
+		events: newEventBus(defaultEventBufferSize),
+		health: newHealthHistory(options.FlapDetection),
 	}
 
+	client.options.Store(options)
+
 	// We check if the disabled flag is present, which will completely disable the MQTTClient.
 	if disabledOverride := os.Getenv("GORABBIT_DISABLED"); disabledOverride != "" {
 		switch disabledOverride {
@@ -132,39 +218,98 @@ func newClientFromOptions(options *ClientOptions) MQTTClient {
 		}
 	}
 
+	client.logger.set(newClientLogger(options))
+
+	// Invalid options are logged no matter the mode, since the client still dials with them.
+	if err := options.Validate(); err != nil {
+		newReleaseLogger(client.logger, map[string]interface{}{"context": "client"}).Error(err, "Invalid client options")
+	}
+
+	client.auditor = newAuditor(options.Audit, client.logger)
+
+	client.breaker = newCircuitBreaker(options.CircuitBreaker, client.logger)
+
+	client.managerOptions = newTopologyManagerOptions(options)
+
+	client.topology = newTopologyRegistry()
+
+	client.ctx, client.cancel = context.WithCancel(context.Background())
+
+	client.connectionManager.Store(client.newConnectionManager(options))
+
+	return client
+}
+
+// newClientLogger returns the Logger of a client with the given options.
+func newClientLogger(options *ClientOptions) Logger {
+	var logger Logger = &noLogger{}
+
+	mode := options.Mode
+
 	// We check if the mode was overwritten with the environment variable "GORABBIT_MODE".
 	if modeOverride := os.Getenv("GORABBIT_MODE"); isValidMode(modeOverride) {
 		// We override the mode only if it is valid
-		options.Mode = modeOverride
+		mode = modeOverride
 	}
 
-	if options.Mode == Debug {
+	if mode == Debug {
 		// If the mode is Debug, we want to actually log important events.
-		client.logger = newStdLogger()
+		logger = newStdLogger()
 	}
 
-	client.ctx, client.cancel = context.WithCancel(context.Background())
-
-	protocol := defaultProtocol
+	// A logger provided by the user receives every log, filtered by its own level.
+	if options.SlogLogger != nil {
+		logger = newSlogLogger(options.SlogLogger)
+	}
 
-	if options.UseTLS {
-		protocol = securedProtocol
+	if options.Logger != nil {
+		logger = newCustomLogger(options.Logger)
 	}
 
-	dialURL := fmt.Sprintf("%s://%s:%s@%s:%d/%s", protocol, client.Username, client.Password, client.Host, client.Port, client.Vhost)
+	return newLeveledLogger(logger, options.LogLevel, options.LogSampling)
+}
+
+// newTopologyManagerOptions returns the options of the manager setting up the topology of a client.
+func newTopologyManagerOptions(options *ClientOptions) *ManagerOptions {
+	return &ManagerOptions{
+		Host:          options.Host,
+		Port:          options.Port,
+		Username:      options.Username,
+		Password:      options.Password,
+		Vhost:         options.Vhost,
+		UseTLS:        options.UseTLS,
+		URIParameters: options.URIParameters,
+		Mode:          options.Mode,
+	}
+}
 
-	client.connectionManager = newConnectionManager(
+// newConnectionManager returns a new connectionManager dialing the consumer and publisher connections of the client.
+func (client *mqttClient) newConnectionManager(options *ClientOptions) *connectionManager {
+	return newConnectionManager(
 		client.ctx,
-		dialURL,
+		options.ConnectionSettings().URI(),
 		options.KeepAlive,
 		options.RetryDelay,
+		options.RecoveryPolicy,
 		options.MaxRetry,
 		options.PublishingCacheSize,
 		options.PublishingCacheTTL,
+		newMetricsCollectors(options.Metrics, client.events, client.health),
+		newTracer(options.Tracing),
+		newCorrelation(options.CorrelationKeys, options.ContextEnricher),
+		options.PayloadLogging,
+		options.Notifications,
+		client.auditor,
+		client.topology,
+		options.FaultInjector,
+		options.PublishingPipeline,
+		options.ReliablePublishing,
+		options.BlockingPublishing,
+		options.marshaller(),
+		options.ChunkSize,
+		options.AMQP10Publishing,
 		client.logger,
 	)
-
-	return client
 }
 
 func (client *mqttClient) Publish(exchange string, routingKey string, payload interface{}) error {
@@ -172,12 +317,30 @@ func (client *mqttClient) Publish(exchange string, routingKey string, payload in
 }
 
 func (client *mqttClient) PublishWithOptions(exchange string, routingKey string, payload interface{}, options *PublishingOptions) error {
+	return client.PublishWithContext(context.Background(), exchange, routingKey, payload, options)
+}
+
+func (client *mqttClient) PublishWithContext(
+	ctx context.Context,
+	exchange string,
+	routingKey string,
+	payload interface{},
+	options *PublishingOptions,
+) error {
 	// client is disabled, so we do nothing and return no error.
 	if client.disabled {
 		return nil
 	}
 
-	return client.connectionManager.publish(exchange, routingKey, payload, options)
+	if err := client.breaker.allow(); err != nil {
+		return err
+	}
+
+	err := client.connectionManager.Load().publish(ctx, exchange, routingKey, payload, options.withDefaults(client.options.Load().PublishingDefaults))
+
+	client.breaker.record(err)
+
+	return err
 }
 
 func (client *mqttClient) RegisterConsumer(consumer MessageConsumer) error {
@@ -186,7 +349,17 @@ func (client *mqttClient) RegisterConsumer(consumer MessageConsumer) error {
 		return nil
 	}
 
-	return client.connectionManager.registerConsumer(consumer)
+	client.reloadMutex.Lock()
+	defer client.reloadMutex.Unlock()
+
+	err := client.connectionManager.Load().registerConsumer(client.effectiveConsumer(consumer, client.options.Load()))
+	if err != nil {
+		return err
+	}
+
+	client.consumers = append(client.consumers, consumer)
+
+	return nil
 }
 
 func (client *mqttClient) Disconnect() error {
@@ -195,28 +368,54 @@ func (client *mqttClient) Disconnect() error {
 		return nil
 	}
 
-	err := client.connectionManager.close()
+	client.reloadMutex.Lock()
+	defer client.reloadMutex.Unlock()
+
+	err := client.connectionManager.Load().close()
 
 	if err != nil {
 		return err
 	}
 
+	if err = client.closeTopologyManager(); err != nil {
+		client.logger.Error(err, "Could not close the topology connection")
+	}
+
 	// cancel the context to stop all reconnection goroutines.
 	client.cancel()
 
+	// write the pending audit records, without waiting indefinitely for the sink.
+	auditCtx, cancelAudit := context.WithTimeout(context.Background(), defaultAuditCloseTimeout)
+	defer cancelAudit()
+
+	client.auditor.close(auditCtx)
+
 	// disable the client to avoid trying to launch new operations.
 	client.disabled = true
 
 	return nil
 }
 
+func (client *mqttClient) Drain(ctx context.Context) (DrainReport, error) {
+	// client is disabled, so we do nothing and return no error.
+	if client.disabled {
+		return DrainReport{}, nil
+	}
+
+	return client.connectionManager.Load().drain(ctx)
+}
+
+func (client *mqttClient) Events() <-chan Event {
+	return client.events.events
+}
+
 func (client *mqttClient) IsReady() bool {
 	// client is disabled, so we do nothing and return true.
 	if client.disabled {
 		return true
 	}
 
-	return client.connectionManager.isReady()
+	return client.connectionManager.Load().isReady()
 }
 
 func (client *mqttClient) IsHealthy() bool {
@@ -225,23 +424,126 @@ func (client *mqttClient) IsHealthy() bool {
 		return true
 	}
 
-	return client.connectionManager.isHealthy()
+	return client.connectionManager.Load().isHealthy()
+}
+
+func (client *mqttClient) HealthReport() HealthReport {
+	// client is disabled, so we do nothing and return a healthy report.
+	if client.disabled {
+		return HealthReport{Ready: true, Healthy: true}
+	}
+
+	report := client.connectionManager.Load().healthReport()
+
+	client.health.fill(&report)
+
+	return report
+}
+
+func (client *mqttClient) ConsumerStats() []ConsumerStats {
+	// client is disabled, so we do nothing and return no stats.
+	if client.disabled {
+		return nil
+	}
+
+	return client.connectionManager.Load().consumersStats()
+}
+
+func (client *mqttClient) ExportSchema() ([]byte, error) {
+	// client is disabled, so we do nothing and return an empty schema.
+	if client.disabled {
+		return newTopologyRegistry().export(client.options.Load().Vhost)
+	}
+
+	return client.connectionManager.Load().exportSchema(client.options.Load().Vhost)
+}
+
+func (client *mqttClient) VerifyTopology(ctx context.Context) (TopologyReport, error) {
+	// client is disabled, so we do nothing and return a valid report.
+	if client.disabled {
+		return TopologyReport{}, nil
+	}
+
+	return client.connectionManager.Load().verifyTopology(ctx)
+}
+
+func (client *mqttClient) PurgeQueue(queue string) (int, error) {
+	// client is disabled, so we do nothing and return no error.
+	if client.disabled {
+		return 0, nil
+	}
+
+	return client.connectionManager.Load().purgeQueue(queue)
+}
+
+func (client *mqttClient) DeleteQueue(queue string, ifUnused, ifEmpty bool) (int, error) {
+	// client is disabled, so we do nothing and return no error.
+	if client.disabled {
+		return 0, nil
+	}
+
+	count, err := client.connectionManager.Load().deleteQueue(queue, ifUnused, ifEmpty)
+	if err != nil {
+		return 0, err
+	}
+
+	client.topology.removeQueue(queue)
+
+	return count, nil
+}
+
+func (client *mqttClient) DeleteExchange(exchange string, ifUnused bool) error {
+	// client is disabled, so we do nothing and return no error.
+	if client.disabled {
+		return nil
+	}
+
+	return client.connectionManager.Load().deleteExchange(exchange, ifUnused)
+}
+
+func (client *mqttClient) Unbind(exchange, queue, routingKey string, args map[string]interface{}) error {
+	// client is disabled, so we do nothing and return no error.
+	if client.disabled {
+		return nil
+	}
+
+	return client.connectionManager.Load().unbindQueue(exchange, queue, routingKey, args)
+}
+
+func (client *mqttClient) IsConsumerActive(name string) bool {
+	// client is disabled, so we do nothing and return false.
+	if client.disabled {
+		return false
+	}
+
+	return client.connectionManager.Load().isConsumerActive(name)
+}
+
+// handOverConsumer makes the SingleActiveConsumer with the given name hand its queue over to the next consumer in
+// line, see JoinConsumerGroup.
+func (client *mqttClient) handOverConsumer(ctx context.Context, name string) error {
+	// client is disabled, so we do nothing and return no error.
+	if client.disabled {
+		return nil
+	}
+
+	return client.connectionManager.Load().handOverConsumer(ctx, name)
 }
 
 func (client *mqttClient) GetHost() string {
-	return client.Host
+	return client.options.Load().Host
 }
 
 func (client *mqttClient) GetPort() uint {
-	return client.Port
+	return client.options.Load().Port
 }
 
 func (client *mqttClient) GetUsername() string {
-	return client.Username
+	return client.options.Load().Username
 }
 
 func (client *mqttClient) GetVhost() string {
-	return client.Vhost
+	return client.options.Load().Vhost
 }
 
 func (client *mqttClient) IsDisabled() bool {
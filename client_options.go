@@ -1,9 +1,13 @@
 package gorabbit
 
 import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"time"
 
-	"github.com/Netflix/go-env"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ClientOptions holds all necessary properties to launch a successful connection with an MQTTClient.
@@ -26,13 +30,23 @@ type ClientOptions struct {
 	// UseTLS defines whether we use amqp or amqps protocol.
 	UseTLS bool
 
+	// URIParameters are the query parameters of the connection URI, such as the heartbeat or the CA certificate.
+	URIParameters
+
 	// KeepAlive will determine whether the re-connection and retry mechanisms should be triggered.
 	KeepAlive bool
 
 	// RetryDelay will define the delay for the re-connection and retry mechanism.
 	RetryDelay time.Duration
 
-	// MaxRetry will define the number of retries when an amqpMessage could not be processed.
+	// RecoveryPolicy governs, if set, the re-connections and the channel recoveries instead of the RetryDelay, such as
+	// to back off exponentially or to give up after some attempts. It also governs the retries of the failed
+	// deliveries of the consumers without Retry, RetryPolicy nor NackDelay.
+	RecoveryPolicy RetryPolicy
+
+	// MaxRetry will define the number of retries when an amqpMessage could not be processed, carried by the
+	// x-death-count header of the publishings. It only applies to the consumers without Retry, RetryPolicy nor
+	// NackDelay, whose failed deliveries are retried after the delays of the RecoveryPolicy.
 	MaxRetry uint
 
 	// PublishingCacheTTL defines the time to live for each publishing cache item.
@@ -43,6 +57,97 @@ type ClientOptions struct {
 
 	// Mode will specify whether logs are enabled or not.
 	Mode string
+
+	// Metrics receives the metrics of the client, if set.
+	Metrics MetricsCollector
+
+	// Tracing provides the tracer of the publishing and delivery spans, if set.
+	Tracing trace.TracerProvider
+
+	// SlogLogger receives the logs of the client no matter the Mode, if set.
+	SlogLogger *slog.Logger
+
+	// Logger receives the logs of the client no matter the Mode, if set. It takes precedence over the SlogLogger.
+	Logger Logger
+
+	// LogLevel is the minimum level of the logs, including the important ones logged in Release mode.
+	LogLevel LogLevel
+
+	// LogSampling is the interval during which identical logs of a connection or channel, such as the errors of a
+	// reconnection loop, are logged only once, if positive.
+	LogSampling time.Duration
+
+	// CorrelationKeys are the context keys, by header, whose values are propagated from the context of the publishings
+	// to the headers of the messages, then restored into the context of the handlers. They are added to the logs of
+	// the publishings and deliveries.
+	CorrelationKeys map[string]interface{}
+
+	// ContextEnricher returns additional values of the context of the publishings to propagate as headers, if set.
+	ContextEnricher ContextEnricher
+
+	// ContextDecorators decorate, in order, the context of the context handlers of all the consumers, before their own
+	// ContextDecorators.
+	ContextDecorators []ContextDecorator
+
+	// PayloadLogging logs the payloads of the publishings and deliveries at the debug level, if set. The payloads are
+	// truncated, and redacted with its Redact function.
+	PayloadLogging *PayloadLogging
+
+	// Notifications receives the returns, confirmations, flow controls and blockings of the broker about the
+	// publishings, if set.
+	Notifications *BrokerNotifications
+
+	// Audit records the lifecycle of the published and consumed messages into an AuditSink, if set.
+	Audit *AuditConfig
+
+	// FaultInjector injects failures into the client for resilience tests, if set. It must not be set in production.
+	FaultInjector *FaultInjector
+
+	// FlapDetection configures when a reconnecting connection is reported as flapping by the HealthReport. Defaults to
+	// 5 reconnections within 10 minutes.
+	FlapDetection *FlapDetection
+
+	// Marshaller encodes the payloads of the publishings. Defaults to the JSONMarshaller.
+	Marshaller Marshaller
+
+	// ChunkSize is, if greater than 0, the size in bytes above which the payloads are published as consecutive chunk
+	// messages, reassembled by the consumers before calling the handlers.
+	ChunkSize int
+
+	// PrefetchCount is, if positive, the PrefetchCount of the registered consumers that define none.
+	PrefetchCount int
+
+	// ConsumerDefaults are, if set, applied to the registered consumers that do not define them.
+	ConsumerDefaults *ConsumerDefaults
+
+	// PublishingDefaults are, if set, the MessagePriority and DeliveryMode of the publishings that define none.
+	PublishingDefaults *PublishingOptions
+
+	// CircuitBreaker enables, if set, the failing of the publishings with ErrCircuitOpen while the broker is unavailable.
+	CircuitBreaker *CircuitBreakerConfig
+
+	// PublishingPipeline enables, if set, the asynchronous publishing: the publishings are buffered and sent in the
+	// background in windows of publisher confirms, at least once.
+	PublishingPipeline *PublishingPipeline
+
+	// ReliablePublishing enables the at-least-once guarantee of the publishings: they are mandatory, and only succeed
+	// once confirmed by the broker. The publishings that are not confirmed are cached, and replayed with the next
+	// channel until confirmed. It requires KeepAlive and the publishing cache, and cannot be used with the
+	// PublishingPipeline.
+	ReliablePublishing bool
+
+	// AMQP10Publishing routes, if set, the publishings over AMQP 1.0 through an AMQP10Publisher dialed with these
+	// options, instead of the publishing connection. The consumers still consume over AMQP 0-9-1. It cannot be used
+	// with the PublishingPipeline, the ReliablePublishing or the BlockingPublishing.
+	AMQP10Publishing *AMQP10Options
+
+	// BlockingPublishing makes the publishings wait, until their context is done, for the channel to be recovered and
+	// the connection to be unblocked by the broker, instead of being cached. It requires KeepAlive, and cannot be used
+	// with the PublishingPipeline.
+	BlockingPublishing bool
+
+	// uriErr is the error of an invalid URI passed to WithURI.
+	uriErr error
 }
 
 // DefaultClientOptions will return a ClientOptions with default values.
@@ -70,40 +175,138 @@ func NewClientOptions() *ClientOptions {
 }
 
 // NewClientOptionsFromEnv will generate a ClientOptions from environment variables. Empty values will be taken as default
-// through the DefaultClientOptions.
+// through the DefaultClientOptions, as are all the values if one of them is invalid.
 func NewClientOptionsFromEnv() *ClientOptions {
-	defaultOpts := DefaultClientOptions()
+	options, err := LoadEnvConfig()
+	if err != nil {
+		return DefaultClientOptions()
+	}
 
-	fromEnv := new(RabbitMQEnvs)
+	return options
+}
 
-	_, err := env.UnmarshalFromEnviron(fromEnv)
-	if err != nil {
-		return defaultOpts
+// Validate reports all the problems of the options at once, as a joined error naming the offending options, such as an
+// empty host, a publishing cache TTL without cache size, or invalid ConsumerDefaults. It is called by NewClient, which
+// logs the problems, since they would otherwise surface as confusing connection or channel errors.
+//
+//nolint:gocognit,gocyclo // The checks are independent of each other.
+func (c *ClientOptions) Validate() error {
+	var errs []error
+
+	invalid := func(option, reason string) {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", errInvalidOptions, option, reason))
+	}
+
+	if c.uriErr != nil {
+		errs = append(errs, fmt.Errorf("%w: %w", errInvalidOptions, c.uriErr))
 	}
 
-	if fromEnv.Host != "" {
-		defaultOpts.Host = fromEnv.Host
+	if c.Host == "" {
+		invalid("Host", "cannot be empty")
 	}
 
-	if fromEnv.Port > 0 {
-		defaultOpts.Port = fromEnv.Port
+	if c.Port == 0 || c.Port > math.MaxUint16 {
+		invalid("Port", "must be between 1 and 65535")
 	}
 
-	if fromEnv.Username != "" {
-		defaultOpts.Username = fromEnv.Username
+	if c.Heartbeat < 0 {
+		invalid("Heartbeat", "cannot be negative")
 	}
 
-	if fromEnv.Password != "" {
-		defaultOpts.Password = fromEnv.Password
+	if c.ConnectionTimeout < 0 {
+		invalid("ConnectionTimeout", "cannot be negative")
 	}
 
-	if fromEnv.Vhost != "" {
-		defaultOpts.Vhost = fromEnv.Vhost
+	if c.ChannelMax < 0 || c.ChannelMax > math.MaxUint16 {
+		invalid("ChannelMax", "must be between 0 and 65535")
 	}
 
-	defaultOpts.UseTLS = fromEnv.UseTLS
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		invalid("CertFile", "requires a KeyFile, and vice versa")
+	}
+
+	if c.KeepAlive && c.RetryDelay <= 0 {
+		invalid("RetryDelay", "must be positive with KeepAlive")
+	}
+
+	if c.PublishingCacheTTL > 0 && c.PublishingCacheSize == 0 {
+		invalid("PublishingCacheTTL", "requires a PublishingCacheSize")
+	}
+
+	if c.PublishingCacheSize > 0 && c.PublishingCacheTTL <= 0 {
+		invalid("PublishingCacheSize", "requires a positive PublishingCacheTTL")
+	}
+
+	if c.Mode != "" && !isValidMode(c.Mode) {
+		invalid("Mode", fmt.Sprintf("must be '%s' or '%s'", Release, Debug))
+	}
+
+	if c.LogLevel > LogLevelError {
+		invalid("LogLevel", "is unknown")
+	}
 
-	return defaultOpts
+	if c.ChunkSize < 0 {
+		invalid("ChunkSize", "cannot be negative")
+	}
+
+	if c.PrefetchCount < 0 {
+		invalid("PrefetchCount", "cannot be negative")
+	}
+
+	if defaults := c.ConsumerDefaults; defaults != nil && defaults.ConsumeRateLimit < 0 {
+		invalid("ConsumerDefaults.ConsumeRateLimit", "cannot be negative")
+	}
+
+	if defaults := c.ConsumerDefaults; defaults != nil && defaults.Retry != nil {
+		if err := defaults.Retry.Validate(); err != nil {
+			invalid("ConsumerDefaults.Retry", err.Error())
+		}
+	}
+
+	if b := c.CircuitBreaker; b != nil && (b.FailureThreshold < 0 || b.OpenDuration < 0 || b.HalfOpenProbes < 0) {
+		invalid("CircuitBreaker", "cannot have a negative threshold, duration or number of probes")
+	}
+
+	if p := c.PublishingPipeline; p != nil {
+		if p.BufferSize < 0 || p.BatchSize < 0 || p.MaxAttempts < 0 {
+			invalid("PublishingPipeline", "cannot have a negative buffer size, batch size or max attempts")
+		}
+
+		if p.ConfirmTimeout < 0 {
+			invalid("PublishingPipeline.ConfirmTimeout", "cannot be negative")
+		}
+	}
+
+	if c.ReliablePublishing {
+		if !c.KeepAlive || c.PublishingCacheSize == 0 {
+			invalid("ReliablePublishing", "requires KeepAlive and a PublishingCacheSize")
+		}
+
+		if c.PublishingPipeline != nil {
+			invalid("ReliablePublishing", "cannot be used with a PublishingPipeline")
+		}
+	}
+
+	if c.BlockingPublishing {
+		if !c.KeepAlive {
+			invalid("BlockingPublishing", "requires KeepAlive")
+		}
+
+		if c.PublishingPipeline != nil {
+			invalid("BlockingPublishing", "cannot be used with a PublishingPipeline")
+		}
+	}
+
+	if c.AMQP10Publishing != nil && (c.PublishingPipeline != nil || c.ReliablePublishing || c.BlockingPublishing) {
+		invalid("AMQP10Publishing", "cannot be used with a PublishingPipeline, ReliablePublishing or BlockingPublishing")
+	}
+
+	if p := c.PublishingDefaults; p != nil && p.MessagePriority != nil &&
+		(*p.MessagePriority < PriorityLowest || *p.MessagePriority > PriorityHighest) {
+		invalid("PublishingDefaults.MessagePriority", fmt.Sprintf("must be between %d and %d", PriorityLowest, PriorityHighest))
+	}
+
+	return errors.Join(errs...)
 }
 
 // SetHost will assign the Host.
@@ -142,6 +345,13 @@ func (c *ClientOptions) SetUseTLS(use bool) *ClientOptions {
 	return c
 }
 
+// SetURIParameters will assign the query parameters of the connection URI.
+func (c *ClientOptions) SetURIParameters(params URIParameters) *ClientOptions {
+	c.URIParameters = params
+
+	return c
+}
+
 // SetKeepAlive will assign the KeepAlive status.
 func (c *ClientOptions) SetKeepAlive(keepAlive bool) *ClientOptions {
 	c.KeepAlive = keepAlive
@@ -156,6 +366,13 @@ func (c *ClientOptions) SetRetryDelay(delay time.Duration) *ClientOptions {
 	return c
 }
 
+// SetRecoveryPolicy will assign the RetryPolicy of the re-connections and channel recoveries.
+func (c *ClientOptions) SetRecoveryPolicy(policy RetryPolicy) *ClientOptions {
+	c.RecoveryPolicy = policy
+
+	return c
+}
+
 // SetMaxRetry will assign the max retry count.
 func (c *ClientOptions) SetMaxRetry(retry uint) *ClientOptions {
 	c.MaxRetry = retry
@@ -185,3 +402,185 @@ func (c *ClientOptions) SetMode(mode string) *ClientOptions {
 
 	return c
 }
+
+// SetMetrics will assign the MetricsCollector.
+func (c *ClientOptions) SetMetrics(metrics MetricsCollector) *ClientOptions {
+	c.Metrics = metrics
+
+	return c
+}
+
+// SetTracing will assign the TracerProvider of the publishing and delivery spans.
+func (c *ClientOptions) SetTracing(provider trace.TracerProvider) *ClientOptions {
+	c.Tracing = provider
+
+	return c
+}
+
+// SetSlogLogger will assign the slog.Logger receiving the logs of the client.
+func (c *ClientOptions) SetSlogLogger(logger *slog.Logger) *ClientOptions {
+	c.SlogLogger = logger
+
+	return c
+}
+
+// SetLogger will assign the Logger receiving the logs of the client.
+func (c *ClientOptions) SetLogger(logger Logger) *ClientOptions {
+	c.Logger = logger
+
+	return c
+}
+
+// SetLogLevel will assign the minimum LogLevel.
+func (c *ClientOptions) SetLogLevel(level LogLevel) *ClientOptions {
+	c.LogLevel = level
+
+	return c
+}
+
+// SetLogSampling will assign the interval during which identical logs are logged only once.
+func (c *ClientOptions) SetLogSampling(sampling time.Duration) *ClientOptions {
+	c.LogSampling = sampling
+
+	return c
+}
+
+// SetCorrelationKey will add a context key whose value is propagated through the given header.
+func (c *ClientOptions) SetCorrelationKey(header string, key interface{}) *ClientOptions {
+	if c.CorrelationKeys == nil {
+		c.CorrelationKeys = make(map[string]interface{})
+	}
+
+	c.CorrelationKeys[header] = key
+
+	return c
+}
+
+// SetContextEnricher will assign the ContextEnricher.
+func (c *ClientOptions) SetContextEnricher(enricher ContextEnricher) *ClientOptions {
+	c.ContextEnricher = enricher
+
+	return c
+}
+
+// SetContextDecorators will assign the ContextDecorators of the context handlers of all the consumers.
+func (c *ClientOptions) SetContextDecorators(decorators ...ContextDecorator) *ClientOptions {
+	c.ContextDecorators = decorators
+
+	return c
+}
+
+// SetPayloadLogging will enable the debug logging of the payloads, truncated and redacted as configured.
+func (c *ClientOptions) SetPayloadLogging(payloadLogging PayloadLogging) *ClientOptions {
+	c.PayloadLogging = &payloadLogging
+
+	return c
+}
+
+// SetNotifications will assign the callbacks receiving the notifications of the broker about the publishings.
+func (c *ClientOptions) SetNotifications(notifications BrokerNotifications) *ClientOptions {
+	c.Notifications = &notifications
+
+	return c
+}
+
+// SetAudit will enable the recording of the lifecycle of the messages into the AuditSink of the config.
+func (c *ClientOptions) SetAudit(config AuditConfig) *ClientOptions {
+	c.Audit = &config
+
+	return c
+}
+
+// SetFaultInjector will assign the FaultInjector injecting failures into the client, for resilience tests only.
+func (c *ClientOptions) SetFaultInjector(faults *FaultInjector) *ClientOptions {
+	c.FaultInjector = faults
+
+	return c
+}
+
+// SetFlapDetection will assign the threshold, window and callback of the detection of the flapping connections.
+func (c *ClientOptions) SetFlapDetection(config FlapDetection) *ClientOptions {
+	c.FlapDetection = &config
+
+	return c
+}
+
+// SetMarshaller will assign the Marshaller encoding the payloads of the publishings.
+func (c *ClientOptions) SetMarshaller(marshaller Marshaller) *ClientOptions {
+	c.Marshaller = marshaller
+
+	return c
+}
+
+// SetChunkSize will assign the size in bytes above which the payloads are published in chunks.
+func (c *ClientOptions) SetChunkSize(size int) *ClientOptions {
+	c.ChunkSize = size
+
+	return c
+}
+
+// SetPrefetchCount will assign the PrefetchCount of the registered consumers that define none.
+func (c *ClientOptions) SetPrefetchCount(count int) *ClientOptions {
+	c.PrefetchCount = count
+
+	return c
+}
+
+// SetConsumerDefaults will assign the properties applied to the registered consumers that do not define them.
+func (c *ClientOptions) SetConsumerDefaults(defaults ConsumerDefaults) *ClientOptions {
+	c.ConsumerDefaults = &defaults
+
+	return c
+}
+
+// SetPublishingDefaults will assign the MessagePriority and DeliveryMode of the publishings that define none.
+func (c *ClientOptions) SetPublishingDefaults(defaults *PublishingOptions) *ClientOptions {
+	c.PublishingDefaults = defaults
+
+	return c
+}
+
+// SetCircuitBreaker will set the CircuitBreaker of the publishings.
+func (c *ClientOptions) SetCircuitBreaker(config CircuitBreakerConfig) *ClientOptions {
+	c.CircuitBreaker = &config
+
+	return c
+}
+
+// SetReliablePublishing will assign whether the publishings are published at least once.
+func (c *ClientOptions) SetReliablePublishing(reliable bool) *ClientOptions {
+	c.ReliablePublishing = reliable
+
+	return c
+}
+
+// SetBlockingPublishing will assign whether the publishings wait for the channel and the broker instead of being
+// cached.
+func (c *ClientOptions) SetBlockingPublishing(blocking bool) *ClientOptions {
+	c.BlockingPublishing = blocking
+
+	return c
+}
+
+// SetPublishingPipeline will set the PublishingPipeline enabling the asynchronous publishing.
+func (c *ClientOptions) SetPublishingPipeline(pipeline PublishingPipeline) *ClientOptions {
+	c.PublishingPipeline = &pipeline
+
+	return c
+}
+
+// SetAMQP10Publishing will set the AMQP10Options routing the publishings over AMQP 1.0.
+func (c *ClientOptions) SetAMQP10Publishing(options *AMQP10Options) *ClientOptions {
+	c.AMQP10Publishing = options
+
+	return c
+}
+
+// marshaller returns the Marshaller, or the JSONMarshaller if none is set.
+func (c *ClientOptions) marshaller() Marshaller {
+	if c.Marshaller == nil {
+		return JSONMarshaller{}
+	}
+
+	return c.Marshaller
+}
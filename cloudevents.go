@@ -0,0 +1,363 @@
+package gorabbit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentTypeCloudEvents is the content type of the CloudEvents in structured mode.
+const ContentTypeCloudEvents = "application/cloudevents+json"
+
+// CloudEventsSpecVersion is the version of the CloudEvents specification the events are produced with.
+const CloudEventsSpecVersion = "1.0"
+
+// CloudEventsMode is how the CloudEventsMarshaller sets the attributes of the events.
+type CloudEventsMode uint8
+
+const (
+	// CloudEventsBinary sets the attributes in ce-* headers, leaving the payload as is.
+	CloudEventsBinary CloudEventsMode = iota
+
+	// CloudEventsStructured wraps the payload and the attributes in a JSON event.
+	CloudEventsStructured
+)
+
+// CloudEvent is an event following the CloudEvents specification.
+type CloudEvent struct {
+	// ID identifies the event, a new UUID by default.
+	ID string
+
+	// Source identifies the context in which the event happened.
+	Source string
+
+	// SpecVersion is the version of the CloudEvents specification, CloudEventsSpecVersion by default.
+	SpecVersion string
+
+	// Type is the type of the event, the type of the message by default.
+	Type string
+
+	// DataContentType is the content type of the data, JSON if empty.
+	DataContentType string
+
+	// DataSchema identifies the schema the data adheres to.
+	DataSchema string
+
+	// Subject is the subject of the event in the context of its source.
+	Subject string
+
+	// Time is when the event happened, now by default.
+	Time time.Time
+
+	// Extensions are the extension attributes of the event.
+	Extensions map[string]string
+
+	// Data is the encoded payload of the event.
+	Data []byte
+}
+
+// cloudEventAttributes are the context attributes defined by the CloudEvents specification.
+var cloudEventAttributes = map[string]bool{
+	"specversion":     true,
+	"id":              true,
+	"source":          true,
+	"type":            true,
+	"datacontenttype": true,
+	"dataschema":      true,
+	"subject":         true,
+	"time":            true,
+	"data":            true,
+	"data_base64":     true,
+}
+
+// attributes returns the context attributes of the event, including its extensions, by name.
+func (e *CloudEvent) attributes() map[string]string {
+	attributes := make(map[string]string, len(e.Extensions)+8)
+
+	for name, value := range e.Extensions {
+		attributes[name] = value
+	}
+
+	attributes["specversion"] = e.SpecVersion
+	attributes["id"] = e.ID
+	attributes["source"] = e.Source
+	attributes["type"] = e.Type
+
+	if e.DataContentType != "" {
+		attributes["datacontenttype"] = e.DataContentType
+	}
+
+	if e.DataSchema != "" {
+		attributes["dataschema"] = e.DataSchema
+	}
+
+	if e.Subject != "" {
+		attributes["subject"] = e.Subject
+	}
+
+	if !e.Time.IsZero() {
+		attributes["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+
+	return attributes
+}
+
+// setAttribute sets a context attribute of the event, unknown ones being extensions.
+func (e *CloudEvent) setAttribute(name, value string) {
+	switch name {
+	case "specversion":
+		e.SpecVersion = value
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "type":
+		e.Type = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "dataschema":
+		e.DataSchema = value
+	case "subject":
+		e.Subject = value
+	case "time":
+		e.Time, _ = time.Parse(time.RFC3339Nano, value)
+	default:
+		if e.Extensions == nil {
+			e.Extensions = make(map[string]string)
+		}
+
+		e.Extensions[name] = value
+	}
+}
+
+// validate returns an error if a required attribute of the event is missing.
+func (e *CloudEvent) validate() error {
+	if e.SpecVersion == "" || e.ID == "" || e.Source == "" || e.Type == "" {
+		return fmt.Errorf("%w: missing required attribute", errNotCloudEvent)
+	}
+
+	return nil
+}
+
+// dataIsJSON returns true if the data of the event is JSON.
+func (e *CloudEvent) dataIsJSON() bool {
+	mediaType, _, _ := strings.Cut(e.DataContentType, ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	return mediaType == "" || mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json")
+}
+
+// structured returns the event encoded in the JSON structured mode.
+func (e *CloudEvent) structured() ([]byte, error) {
+	event := make(map[string]interface{})
+
+	for name, value := range e.attributes() {
+		event[name] = value
+	}
+
+	if e.Data != nil {
+		if e.dataIsJSON() {
+			event["data"] = json.RawMessage(e.Data)
+		} else {
+			event["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+
+	return json.Marshal(event)
+}
+
+// ParseCloudEvent returns the CloudEvent carried by a delivery, either in binary or in structured mode.
+func ParseCloudEvent(delivery Delivery) (*CloudEvent, error) {
+	return parseCloudEvent(delivery.ContentType, delivery.Headers, delivery.Body)
+}
+
+// parseCloudEvent returns the CloudEvent carried by a message, either in binary or in structured mode.
+func parseCloudEvent(contentType string, headers map[string]interface{}, data []byte) (*CloudEvent, error) {
+	event := &CloudEvent{}
+
+	if strings.HasPrefix(contentType, ContentTypeCloudEvents) {
+		if err := event.parseStructured(data); err != nil {
+			return nil, err
+		}
+	} else if _, binary := headers[cloudEventsHeaderPrefix+"specversion"]; binary {
+		for header, value := range headers {
+			if name, found := strings.CutPrefix(header, cloudEventsHeaderPrefix); found {
+				event.setAttribute(name, fmt.Sprint(value))
+			}
+		}
+
+		event.DataContentType = contentType
+		event.Data = data
+	} else {
+		return nil, errNotCloudEvent
+	}
+
+	if err := event.validate(); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// parseStructured decodes an event encoded in the JSON structured mode.
+func (e *CloudEvent) parseStructured(data []byte) error {
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("could not decode CloudEvent: %w", err)
+	}
+
+	for name, raw := range fields {
+		if name == "data" || name == "data_base64" {
+			continue
+		}
+
+		var value string
+
+		if err := json.Unmarshal(raw, &value); err != nil {
+			value = string(raw)
+		}
+
+		e.setAttribute(name, value)
+	}
+
+	if raw, found := fields["data_base64"]; found {
+		var encoded string
+
+		if err := json.Unmarshal(raw, &encoded); err != nil {
+			return fmt.Errorf("could not decode CloudEvent data: %w", err)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("could not decode CloudEvent data: %w", err)
+		}
+
+		e.Data = decoded
+	} else if raw, found = fields["data"]; found {
+		e.Data = raw
+
+		var text string
+
+		if !e.dataIsJSON() && json.Unmarshal(raw, &text) == nil {
+			e.Data = []byte(text)
+		}
+	}
+
+	return nil
+}
+
+// CloudEventsMarshaller is a Marshaller publishing the payloads encoded by another Marshaller as CloudEvents, either in
+// binary or in structured mode, and decoding the data of the events.
+//
+// A CloudEvent payload is published with its own attributes, the missing required ones being set, and its data as is.
+type CloudEventsMarshaller struct {
+	marshaller Marshaller
+	source     string
+	mode       CloudEventsMode
+}
+
+// NewCloudEventsMarshaller returns a CloudEventsMarshaller encoding the data of the events with the given marshaller,
+// the JSONMarshaller if nil, and sourcing them from the given source.
+func NewCloudEventsMarshaller(marshaller Marshaller, source string, mode CloudEventsMode) *CloudEventsMarshaller {
+	if marshaller == nil {
+		marshaller = JSONMarshaller{}
+	}
+
+	return &CloudEventsMarshaller{
+		marshaller: marshaller,
+		source:     source,
+		mode:       mode,
+	}
+}
+
+// Marshal encodes the payload with the wrapped marshaller, then publishes it as a CloudEvent.
+func (m *CloudEventsMarshaller) Marshal(metadata *MessageMetadata, payload interface{}) ([]byte, error) {
+	event, err := m.event(metadata, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.mode == CloudEventsStructured {
+		metadata.ContentType = ContentTypeCloudEvents
+
+		return event.structured()
+	}
+
+	if metadata.Headers == nil {
+		metadata.Headers = make(map[string]interface{})
+	}
+
+	for name, value := range event.attributes() {
+		if name != "datacontenttype" {
+			metadata.Headers[cloudEventsHeaderPrefix+name] = value
+		}
+	}
+
+	metadata.ContentType = event.DataContentType
+
+	return event.Data, nil
+}
+
+// event returns the CloudEvent of a payload.
+func (m *CloudEventsMarshaller) event(metadata *MessageMetadata, payload interface{}) (*CloudEvent, error) {
+	var event CloudEvent
+
+	switch p := payload.(type) {
+	case CloudEvent:
+		event = p
+	case *CloudEvent:
+		event = *p
+	default:
+		data, err := m.marshaller.Marshal(metadata, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		event.DataContentType = metadata.ContentType
+		event.Data = data
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	}
+
+	if event.Source == "" {
+		event.Source = m.source
+	}
+
+	if event.SpecVersion == "" {
+		event.SpecVersion = CloudEventsSpecVersion
+	}
+
+	if event.Type == "" {
+		event.Type = metadata.Type
+	}
+
+	if event.DataContentType == "" {
+		event.DataContentType = ContentTypeJSON
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	return &event, nil
+}
+
+// Unmarshal decodes the data of the CloudEvent with the wrapped marshaller.
+func (m *CloudEventsMarshaller) Unmarshal(metadata *MessageMetadata, data []byte, v interface{}) error {
+	event, err := parseCloudEvent(metadata.ContentType, metadata.Headers, data)
+	if err != nil {
+		return err
+	}
+
+	dataMetadata := *metadata
+	dataMetadata.ContentType = event.DataContentType
+
+	return m.marshaller.Unmarshal(&dataMetadata, event.Data, v)
+}
@@ -0,0 +1,96 @@
+package gorabbit_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestCloudEventsMarshaller_Binary(t *testing.T) {
+	marshaller := gorabbit.NewCloudEventsMarshaller(nil, "/billing", gorabbit.CloudEventsBinary)
+
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "invoice.created", Type: "invoice.created"}
+
+	data, err := marshaller.Marshal(metadata, map[string]int{"amount": 10})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"amount":10}`, string(data))
+	assert.Equal(t, gorabbit.ContentTypeJSON, metadata.ContentType)
+	assert.Equal(t, "1.0", metadata.Headers["ce-specversion"])
+	assert.Equal(t, "/billing", metadata.Headers["ce-source"])
+	assert.Equal(t, "invoice.created", metadata.Headers["ce-type"])
+	assert.NotEmpty(t, metadata.Headers["ce-id"])
+	assert.NotEmpty(t, metadata.Headers["ce-time"])
+
+	event, err := gorabbit.ParseCloudEvent(gorabbit.Delivery{ContentType: metadata.ContentType, Headers: metadata.Headers, Body: data})
+	require.NoError(t, err)
+
+	assert.Equal(t, "invoice.created", event.Type)
+	assert.Equal(t, "/billing", event.Source)
+	assert.False(t, event.Time.IsZero())
+
+	var decoded map[string]int
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, 10, decoded["amount"])
+}
+
+func TestCloudEventsMarshaller_Structured(t *testing.T) {
+	marshaller := gorabbit.NewCloudEventsMarshaller(nil, "/billing", gorabbit.CloudEventsStructured)
+
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "invoice.created", Type: "invoice.created"}
+
+	data, err := marshaller.Marshal(metadata, gorabbit.CloudEvent{
+		Type:       "com.example.invoice.created",
+		Subject:    "invoice-42",
+		Extensions: map[string]string{"tenant": "acme"},
+		Data:       []byte(`{"amount":10}`),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, gorabbit.ContentTypeCloudEvents, metadata.ContentType)
+
+	var structured map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(data, &structured))
+	assert.Equal(t, "com.example.invoice.created", structured["type"])
+	assert.Equal(t, "acme", structured["tenant"])
+	assert.Equal(t, map[string]interface{}{"amount": float64(10)}, structured["data"])
+
+	event, err := gorabbit.ParseCloudEvent(gorabbit.Delivery{ContentType: metadata.ContentType, Body: data})
+	require.NoError(t, err)
+
+	assert.Equal(t, "invoice-42", event.Subject)
+	assert.Equal(t, "acme", event.Extensions["tenant"])
+	assert.JSONEq(t, `{"amount":10}`, string(event.Data))
+
+	var decoded map[string]int
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, 10, decoded["amount"])
+}
+
+func TestCloudEventsMarshaller_StructuredBinaryData(t *testing.T) {
+	marshaller := gorabbit.NewCloudEventsMarshaller(rawMarshaller{}, "/reports", gorabbit.CloudEventsStructured)
+
+	metadata := &gorabbit.MessageMetadata{Type: "report.generated"}
+
+	data, err := marshaller.Marshal(metadata, "plain text")
+	require.NoError(t, err)
+
+	assert.Contains(t, string(data), "data_base64")
+
+	var decoded string
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, "plain text", decoded)
+}
+
+func TestParseCloudEvent_NotCloudEvent(t *testing.T) {
+	_, err := gorabbit.ParseCloudEvent(gorabbit.Delivery{ContentType: gorabbit.ContentTypeJSON, Body: []byte(`{}`)})
+	require.Error(t, err)
+}
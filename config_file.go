@@ -0,0 +1,363 @@
+package gorabbit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClientConfig is the content of a YAML client configuration file, as loaded by LoadClientConfig.
+type ClientConfig struct {
+	// Connection holds the connection settings.
+	Connection ConnectionConfig `yaml:"connection"`
+
+	// Publishing holds the publishing settings and defaults.
+	Publishing PublishingConfig `yaml:"publishing"`
+
+	// Consumers holds the defaults of the registered consumers.
+	Consumers ConsumerConfig `yaml:"consumers"`
+
+	// Exchanges are the exchanges of the topology, to be set up with the manager's SetupTopology.
+	Exchanges []ExchangeConfig `yaml:"exchanges"`
+
+	// Queues are the queues of the topology, to be set up with the manager's SetupTopology.
+	Queues []QueueConfig `yaml:"queues"`
+}
+
+// ConnectionConfig is the connection section of a ClientConfig.
+type ConnectionConfig struct {
+	ConnectionSettings `yaml:",inline"`
+
+	// KeepAlive overrides, if set, whether the re-connection and retry mechanisms are triggered.
+	KeepAlive *bool `yaml:"keep_alive"`
+
+	// RetryDelay overrides, if positive, the delay of the re-connection and retry mechanisms.
+	RetryDelay time.Duration `yaml:"retry_delay"`
+
+	// MaxRetry overrides, if set, the number of retries of a message that could not be processed.
+	MaxRetry *uint `yaml:"max_retry"`
+}
+
+// PublishingConfig is the publishing section of a ClientConfig.
+type PublishingConfig struct {
+	// CacheSize overrides, if positive, the max length of the publishing cache.
+	CacheSize uint64 `yaml:"cache_size"`
+
+	// CacheTTL overrides, if positive, the time to live of the publishing cache items.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+
+	// ChunkSize is, if positive, the size in bytes above which the payloads are published in chunks.
+	ChunkSize int `yaml:"chunk_size"`
+
+	// Priority is, if set, the priority of the publishings that define none, from 1 to 6.
+	Priority *MessagePriority `yaml:"priority"`
+
+	// Persistent is, if set, whether the publishings that define no delivery mode are persisted.
+	Persistent *bool `yaml:"persistent"`
+
+	// Pipeline enables, if set, the asynchronous publishing.
+	Pipeline *PublishingPipeline `yaml:"pipeline"`
+
+	// CircuitBreaker enables, if set, the circuit breaker of the publishings.
+	CircuitBreaker *CircuitBreakerConfig `yaml:"circuit_breaker"`
+
+	// Reliable enables the at-least-once guarantee of the publishings.
+	Reliable bool `yaml:"reliable"`
+
+	// Blocking makes the publishings wait for the channel and the broker instead of being cached.
+	Blocking bool `yaml:"blocking"`
+}
+
+// ConsumerConfig is the consumers section of a ClientConfig.
+type ConsumerConfig struct {
+	ConsumerDefaults `yaml:",inline"`
+
+	// PrefetchCount is, if positive, the PrefetchCount of the consumers that define none.
+	PrefetchCount int `yaml:"prefetch_count"`
+}
+
+// LoadClientConfig parses a YAML client configuration file and validates it, the errors naming the offending fields:
+//
+//	connection:
+//	  host: rabbitmq.internal
+//	  port: 5671
+//	  username: billing
+//	  password: secret
+//	  vhost: production
+//	  use_tls: true
+//	  retry_delay: 5s
+//	publishing:
+//	  cache_size: 256
+//	  cache_ttl: 1m
+//	  priority: 4
+//	  persistent: true
+//	consumers:
+//	  prefetch_count: 20
+//	  concurrent_process: true
+//	  retry:
+//	    initial_delay: 1s
+//	    max_attempts: 5
+//	exchanges:
+//	  - name: billing_exchange
+//	    type: topic
+//	    persisted: true
+//	queues:
+//	  - name: invoices_queue
+//	    durable: true
+//	    bindings:
+//	      - exchange: billing_exchange
+//	        routing_key: invoice.#
+//
+// Unknown fields are rejected, to catch typos.
+func LoadClientConfig(path string) (*ClientConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var config ClientConfig
+
+	if err = decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidConfig, err)
+	}
+
+	if err = config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// NewClientFromConfigFile will instantiate a new MQTTClient from a YAML client configuration file, along with the
+// loaded ClientConfig holding the topology to set up. Additional options are applied over the ones of the file.
+func NewClientFromConfigFile(path string, opts ...Option) (MQTTClient, *ClientConfig, error) {
+	config, err := LoadClientConfig(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewClient(append([]Option{config.ClientOptions()}, opts...)...), config, nil
+}
+
+// Validate returns the errors of the configuration, each naming its offending field.
+func (c *ClientConfig) Validate() error {
+	var errs []error
+
+	invalid := func(field, reason string) {
+		errs = append(errs, fmt.Errorf("%w: %s: %s", errInvalidConfig, field, reason))
+	}
+
+	if c.Connection.Port > math.MaxUint16 {
+		invalid("connection.port", "must be at most 65535")
+	}
+
+	if c.Connection.RetryDelay < 0 {
+		invalid("connection.retry_delay", "must be positive")
+	}
+
+	if c.Connection.Heartbeat < 0 {
+		invalid("connection.heartbeat", "must be positive")
+	}
+
+	if c.Connection.ConnectionTimeout < 0 {
+		invalid("connection.connection_timeout", "must be positive")
+	}
+
+	if c.Connection.ChannelMax < 0 || c.Connection.ChannelMax > math.MaxUint16 {
+		invalid("connection.channel_max", "must be between 0 and 65535")
+	}
+
+	if (c.Connection.CertFile == "") != (c.Connection.KeyFile == "") {
+		invalid("connection.certfile", "requires a keyfile, and vice versa")
+	}
+
+	if c.Publishing.CacheTTL < 0 {
+		invalid("publishing.cache_ttl", "must be positive")
+	}
+
+	if c.Publishing.ChunkSize < 0 {
+		invalid("publishing.chunk_size", "must be positive")
+	}
+
+	if p := c.Publishing.Pipeline; p != nil && (p.BufferSize < 0 || p.BatchSize < 0 || p.MaxAttempts < 0 || p.ConfirmTimeout < 0) {
+		invalid("publishing.pipeline", "must not hold negative values")
+	}
+
+	if b := c.Publishing.CircuitBreaker; b != nil && (b.FailureThreshold < 0 || b.OpenDuration < 0 || b.HalfOpenProbes < 0) {
+		invalid("publishing.circuit_breaker", "must not hold negative values")
+	}
+
+	if p := c.Publishing.Priority; p != nil && (*p < PriorityLowest || *p > PriorityHighest) {
+		invalid("publishing.priority", fmt.Sprintf("must be between %d and %d", PriorityLowest, PriorityHighest))
+	}
+
+	if c.Consumers.PrefetchCount < 0 {
+		invalid("consumers.prefetch_count", "must be positive")
+	}
+
+	if c.Consumers.ConsumeRateLimit < 0 {
+		invalid("consumers.consume_rate_limit", "must be positive")
+	}
+
+	if retry := c.Consumers.Retry; retry != nil {
+		if retry.InitialDelay <= 0 {
+			invalid("consumers.retry.initial_delay", "must be positive")
+		}
+
+		if retry.Multiplier < 0 {
+			invalid("consumers.retry.multiplier", "must be positive")
+		}
+
+		if retry.MaxAttempts == 0 {
+			invalid("consumers.retry.max_attempts", "must be positive")
+		}
+	}
+
+	if adaptive := c.Consumers.AdaptivePrefetch; adaptive != nil {
+		if adaptive.TargetLatency <= 0 {
+			invalid("consumers.adaptive_prefetch.target_latency", "must be positive")
+		}
+
+		if adaptive.MaxPrefetchCount < adaptive.minPrefetchCount() {
+			invalid("consumers.adaptive_prefetch.max_prefetch_count", "must be above the min prefetch count")
+		}
+
+		if adaptive.DecreaseFactor < 0 || adaptive.DecreaseFactor >= 1 {
+			invalid("consumers.adaptive_prefetch.decrease_factor", "must be between 0 and 1")
+		}
+	}
+
+	for i, exchange := range c.Exchanges {
+		if exchange.Name == "" {
+			invalid(fmt.Sprintf("exchanges[%d].name", i), "cannot be empty")
+		}
+
+		if exchange.Type == "" {
+			invalid(fmt.Sprintf("exchanges[%d].type", i), "cannot be empty")
+		}
+	}
+
+	for i, queue := range c.Queues {
+		if queue.Name == "" {
+			invalid(fmt.Sprintf("queues[%d].name", i), "cannot be empty")
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// WithClientConfig sets the options defined by a configuration file, the ones it does not define being set to their
+// default value, see ClientConfig.ClientOptions. Unlike the ClientOptions, the options a file cannot define, such as the
+// Logger and the Marshaller, are kept, so that the configuration file can be reloaded with UpdateConfig.
+func WithClientConfig(config *ClientConfig) Option {
+	return optionFunc(func(options *ClientOptions) {
+		file := config.ClientOptions()
+
+		options.Host = file.Host
+		options.Port = file.Port
+		options.Username = file.Username
+		options.Password = file.Password
+		options.Vhost = file.Vhost
+		options.UseTLS = file.UseTLS
+		options.URIParameters = file.URIParameters
+		options.KeepAlive = file.KeepAlive
+		options.RetryDelay = file.RetryDelay
+		options.MaxRetry = file.MaxRetry
+		options.PublishingCacheSize = file.PublishingCacheSize
+		options.PublishingCacheTTL = file.PublishingCacheTTL
+		options.ChunkSize = file.ChunkSize
+		options.PublishingPipeline = file.PublishingPipeline
+		options.CircuitBreaker = file.CircuitBreaker
+		options.ReliablePublishing = file.ReliablePublishing
+		options.BlockingPublishing = file.BlockingPublishing
+		options.PublishingDefaults = file.PublishingDefaults
+		options.PrefetchCount = file.PrefetchCount
+		options.ConsumerDefaults = file.ConsumerDefaults
+	})
+}
+
+// ClientOptions returns the ClientOptions of the configuration, over the DefaultClientOptions.
+func (c *ClientConfig) ClientOptions() *ClientOptions {
+	options := DefaultClientOptions()
+
+	settings := c.Connection.ConnectionSettings
+
+	if settings.Host != "" {
+		options.Host = settings.Host
+	}
+
+	if settings.Port > 0 {
+		options.Port = settings.Port
+	}
+
+	if settings.Username != "" {
+		options.Username = settings.Username
+	}
+
+	if settings.Password != "" {
+		options.Password = settings.Password
+	}
+
+	options.Vhost = settings.Vhost
+	options.UseTLS = settings.UseTLS
+	options.URIParameters = settings.URIParameters
+
+	if c.Connection.KeepAlive != nil {
+		options.KeepAlive = *c.Connection.KeepAlive
+	}
+
+	if c.Connection.RetryDelay > 0 {
+		options.RetryDelay = c.Connection.RetryDelay
+	}
+
+	if c.Connection.MaxRetry != nil {
+		options.MaxRetry = *c.Connection.MaxRetry
+	}
+
+	if c.Publishing.CacheSize > 0 {
+		options.PublishingCacheSize = c.Publishing.CacheSize
+	}
+
+	if c.Publishing.CacheTTL > 0 {
+		options.PublishingCacheTTL = c.Publishing.CacheTTL
+	}
+
+	options.ChunkSize = c.Publishing.ChunkSize
+	options.PublishingPipeline = c.Publishing.Pipeline
+	options.CircuitBreaker = c.Publishing.CircuitBreaker
+	options.ReliablePublishing = c.Publishing.Reliable
+	options.BlockingPublishing = c.Publishing.Blocking
+
+	if c.Publishing.Priority != nil || c.Publishing.Persistent != nil {
+		defaults := SendOptions()
+
+		if c.Publishing.Priority != nil {
+			defaults.SetPriority(*c.Publishing.Priority)
+		}
+
+		if c.Publishing.Persistent != nil {
+			defaults.SetMode(Transient)
+
+			if *c.Publishing.Persistent {
+				defaults.SetMode(Persistent)
+			}
+		}
+
+		options.PublishingDefaults = defaults
+	}
+
+	options.PrefetchCount = c.Consumers.PrefetchCount
+
+	consumerDefaults := c.Consumers.ConsumerDefaults
+	options.ConsumerDefaults = &consumerDefaults
+
+	return options
+}
@@ -0,0 +1,116 @@
+package gorabbit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "gorabbit.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadClientConfig(t *testing.T) {
+	path := writeConfigFile(t, `
+connection:
+  host: rabbitmq.internal
+  port: 5671
+  username: billing
+  password: secret
+  vhost: production
+  use_tls: true
+  keep_alive: false
+  retry_delay: 5s
+publishing:
+  cache_size: 256
+  cache_ttl: 1m
+  priority: 5
+  persistent: false
+consumers:
+  prefetch_count: 20
+  concurrent_process: true
+  retry:
+    initial_delay: 1s
+    max_attempts: 5
+exchanges:
+  - name: billing_exchange
+    type: topic
+    persisted: true
+queues:
+  - name: invoices_queue
+    durable: true
+    bindings:
+      - exchange: billing_exchange
+        routing_key: invoice.#
+`)
+
+	config, err := gorabbit.LoadClientConfig(path)
+	require.NoError(t, err)
+
+	require.Len(t, config.Exchanges, 1)
+	require.Len(t, config.Queues, 1)
+	assert.Equal(t, "invoice.#", config.Queues[0].Bindings[0].RoutingKey)
+
+	options := config.ClientOptions()
+
+	assert.Equal(t, "rabbitmq.internal", options.Host)
+	assert.Equal(t, uint(5671), options.Port)
+	assert.Equal(t, "production", options.Vhost)
+	assert.True(t, options.UseTLS)
+	assert.False(t, options.KeepAlive)
+	assert.Equal(t, 5*time.Second, options.RetryDelay)
+	assert.Equal(t, uint64(256), options.PublishingCacheSize)
+	assert.Equal(t, time.Minute, options.PublishingCacheTTL)
+	assert.Equal(t, gorabbit.PriorityHigh, *options.PublishingDefaults.MessagePriority)
+	assert.Equal(t, gorabbit.Transient, *options.PublishingDefaults.DeliveryMode)
+	assert.Equal(t, 20, options.PrefetchCount)
+	assert.True(t, options.ConsumerDefaults.ConcurrentProcess)
+	assert.Equal(t, time.Second, options.ConsumerDefaults.Retry.InitialDelay)
+	assert.Equal(t, uint(5), options.ConsumerDefaults.Retry.MaxAttempts)
+}
+
+func TestLoadClientConfig_Invalid(t *testing.T) {
+	path := writeConfigFile(t, `
+connection:
+  port: 70000
+publishing:
+  priority: 9
+consumers:
+  retry:
+    max_attempts: 3
+exchanges:
+  - type: topic
+`)
+
+	_, err := gorabbit.LoadClientConfig(path)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "connection.port")
+	assert.Contains(t, err.Error(), "publishing.priority")
+	assert.Contains(t, err.Error(), "consumers.retry.initial_delay")
+	assert.Contains(t, err.Error(), "exchanges[0].name")
+}
+
+func TestLoadClientConfig_UnknownField(t *testing.T) {
+	path := writeConfigFile(t, `
+connection:
+  hostname: rabbitmq.internal
+`)
+
+	_, err := gorabbit.LoadClientConfig(path)
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "hostname")
+}
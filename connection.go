@@ -2,10 +2,14 @@ package gorabbit
 
 import (
 	"context"
+	"fmt"
 	"net/url"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // amqpConnection holds information about the management of the native amqp.Connection.
@@ -13,8 +17,8 @@ type amqpConnection struct {
 	// ctx is the parent context and acts as a safeguard.
 	ctx context.Context
 
-	// connection is the native amqp.Connection.
-	connection *amqp.Connection
+	// connection is the native amqp.Connection, replaced when the connection is opened again.
+	connection atomic.Pointer[amqp.Connection]
 
 	// uri represents the connection string to the RabbitMQ server.
 	uri string
@@ -23,14 +27,21 @@ type amqpConnection struct {
 	keepAlive bool
 
 	// retryDelay defines the delay to wait before re-connecting if we lose connection and the keepAlive flag is set to true.
-	retryDelay time.Duration
+	// It is shared with the channels, to be updated by the client configuration.
+	retryDelay *reloadableDuration
+
+	// recovery governs the re-connections, and is shared with the channels to govern their recoveries.
+	recovery RetryPolicy
 
 	// closed is an inner property that switches to true if the connection was explicitly closed.
-	closed bool
+	closed atomic.Bool
 
 	// channels holds a list of active amqpChannel
 	channels amqpChannels
 
+	// channelsMutex protects channels from concurrent access.
+	channelsMutex sync.Mutex
+
 	// maxRetry defines the number of retries when publishing a message.
 	maxRetry uint
 
@@ -40,8 +51,38 @@ type amqpConnection struct {
 	// publishingCacheTTL defines the time to live for a cached failed publishing.
 	publishingCacheTTL time.Duration
 
+	// pipeline configures the asynchronous publishing, if set.
+	pipeline *PublishingPipeline
+
+	// reliable makes the publishings confirmed, mandatory, and cached until confirmed.
+	reliable bool
+
+	// backpressure tracks whether the broker blocks the connection, if the publishings are blocking.
+	backpressure *backpressure
+
+	// metrics receives the metrics of the consumed deliveries, if set.
+	metrics MetricsCollector
+
+	// tracer starts the spans of the publishings and deliveries, if set.
+	tracer trace.Tracer
+
+	// correlation propagates the values of the contexts of the publishings and deliveries, if set.
+	correlation *correlation
+
+	// payloadLogging logs the payloads of the publishings and deliveries, if set.
+	payloadLogging *PayloadLogging
+
+	// notifications receives the notifications of the broker about the publishings, if set.
+	notifications *BrokerNotifications
+
+	// auditor records the lifecycle of the publishings and deliveries, if set.
+	auditor *auditor
+
+	// faults injects failures into the channels, if set.
+	faults *FaultInjector
+
 	// logger logs events.
-	logger logger
+	logger Logger
 
 	// connectionType defines the connectionType.
 	connectionType connectionType
@@ -52,9 +93,29 @@ type amqpConnection struct {
 //   - uri is the connection string.
 //   - keepAlive will keep the connection alive if true.
 //   - retryDelay defines the delay between each re-connection, if the keepAlive flag is set to true.
+//   - recovery governs the re-connections and the channel recoveries instead of the retryDelay, if not nil.
+//   - metrics receives the metrics of the consumed deliveries, if not nil.
+//   - tracer starts the spans of the consumed deliveries, if not nil.
+//   - correlation propagates the values of the contexts of the consumed deliveries, if not nil.
+//   - payloadLogging logs the payloads of the consumed deliveries, if not nil.
+//   - auditor records the lifecycle of the consumed deliveries, if not nil.
+//   - faults injects failures into the consumer channels, if not nil.
 //   - logger is the parent logger.
-func newConsumerConnection(ctx context.Context, uri string, keepAlive bool, retryDelay time.Duration, logger logger) *amqpConnection {
-	return newConnection(ctx, uri, keepAlive, retryDelay, logger, connectionTypeConsumer)
+func newConsumerConnection(
+	ctx context.Context,
+	uri string,
+	keepAlive bool,
+	retryDelay time.Duration,
+	recovery RetryPolicy,
+	metrics MetricsCollector,
+	tracer trace.Tracer,
+	correlation *correlation,
+	payloadLogging *PayloadLogging,
+	auditor *auditor,
+	faults *FaultInjector,
+	logger Logger,
+) *amqpConnection {
+	return newConnection(ctx, uri, keepAlive, retryDelay, recovery, metrics, tracer, correlation, payloadLogging, nil, auditor, faults, logger, connectionTypeConsumer)
 }
 
 // newPublishingConnection initializes a new publisher amqpConnection with given arguments.
@@ -62,25 +123,53 @@ func newConsumerConnection(ctx context.Context, uri string, keepAlive bool, retr
 //   - uri is the connection string.
 //   - keepAlive will keep the connection alive if true.
 //   - retryDelay defines the delay between each re-connection, if the keepAlive flag is set to true.
+//   - recovery governs the re-connections and the channel recoveries instead of the retryDelay, if not nil.
 //   - maxRetry defines the publishing max retry header.
 //   - publishingCacheSize defines the maximum length of failed publishing cache.
 //   - publishingCacheTTL defines the time to live for failed publishing in cache.
+//   - metrics receives the metrics of the publishings, if not nil.
+//   - tracer starts the spans of the publishings, if not nil.
+//   - correlation propagates the values of the contexts of the publishings, if not nil.
+//   - payloadLogging logs the payloads of the publishings, if not nil.
+//   - notifications receives the notifications of the broker about the publishings, if not nil.
+//   - auditor records the lifecycle of the publishings, if not nil.
+//   - faults injects failures into the publishing channel, if not nil.
+//   - pipeline configures the asynchronous publishing, if not nil.
+//   - reliable makes the publishings confirmed, mandatory, and cached until confirmed.
+//   - blocking makes the publishings wait for the channel and the broker instead of being cached.
 //   - logger is the parent logger.
 func newPublishingConnection(
 	ctx context.Context,
 	uri string,
 	keepAlive bool,
 	retryDelay time.Duration,
+	recovery RetryPolicy,
 	maxRetry uint,
 	publishingCacheSize uint64,
 	publishingCacheTTL time.Duration,
-	logger logger,
+	metrics MetricsCollector,
+	tracer trace.Tracer,
+	correlation *correlation,
+	payloadLogging *PayloadLogging,
+	notifications *BrokerNotifications,
+	auditor *auditor,
+	faults *FaultInjector,
+	pipeline *PublishingPipeline,
+	reliable bool,
+	blocking bool,
+	logger Logger,
 ) *amqpConnection {
-	conn := newConnection(ctx, uri, keepAlive, retryDelay, logger, connectionTypePublisher)
+	conn := newConnection(ctx, uri, keepAlive, retryDelay, recovery, metrics, tracer, correlation, payloadLogging, notifications, auditor, faults, logger, connectionTypePublisher)
 
 	conn.maxRetry = maxRetry
 	conn.publishingCacheSize = publishingCacheSize
 	conn.publishingCacheTTL = publishingCacheTTL
+	conn.pipeline = pipeline
+	conn.reliable = reliable
+
+	if blocking {
+		conn.backpressure = newBackpressure()
+	}
 
 	return conn
 }
@@ -90,14 +179,47 @@ func newPublishingConnection(
 //   - uri is the connection string.
 //   - keepAlive will keep the connection alive if true.
 //   - retryDelay defines the delay between each re-connection, if the keepAlive flag is set to true.
+//   - recovery governs the re-connections and the channel recoveries instead of the retryDelay, if not nil.
+//   - metrics receives the metrics of the connection and its channels, if not nil.
+//   - tracer starts the spans of the publishings and deliveries of its channels, if not nil.
+//   - correlation propagates the values of the contexts of the publishings and deliveries of its channels, if not nil.
+//   - payloadLogging logs the payloads of the publishings and deliveries of its channels, if not nil.
+//   - notifications receives the notifications of the broker about the connection and its publishings, if not nil.
+//   - auditor records the lifecycle of the publishings and deliveries of its channels, if not nil.
+//   - faults injects failures into the channels, if not nil.
 //   - logger is the parent logger.
-func newConnection(ctx context.Context, uri string, keepAlive bool, retryDelay time.Duration, logger logger, connectionType connectionType) *amqpConnection {
+func newConnection(
+	ctx context.Context,
+	uri string,
+	keepAlive bool,
+	retryDelay time.Duration,
+	recovery RetryPolicy,
+	metrics MetricsCollector,
+	tracer trace.Tracer,
+	correlation *correlation,
+	payloadLogging *PayloadLogging,
+	notifications *BrokerNotifications,
+	auditor *auditor,
+	faults *FaultInjector,
+	logger Logger,
+	connectionType connectionType,
+) *amqpConnection {
+	reloadableRetryDelay := newReloadableDuration(retryDelay)
+
 	conn := &amqpConnection{
-		ctx:        ctx,
-		uri:        uri,
-		keepAlive:  keepAlive,
-		retryDelay: retryDelay,
-		channels:   make(amqpChannels, 0),
+		ctx:            ctx,
+		uri:            uri,
+		keepAlive:      keepAlive,
+		retryDelay:     reloadableRetryDelay,
+		recovery:       recoveryPolicy(recovery, reloadableRetryDelay),
+		channels:       make(amqpChannels, 0),
+		metrics:        metrics,
+		tracer:         tracer,
+		correlation:    correlation,
+		payloadLogging: payloadLogging,
+		notifications:  notifications,
+		auditor:        auditor,
+		faults:         faults,
 		logger: inheritLogger(logger, map[string]interface{}{
 			"context": "connection",
 			"type":    connectionType,
@@ -105,7 +227,7 @@ func newConnection(ctx context.Context, uri string, keepAlive bool, retryDelay t
 		connectionType: connectionType,
 	}
 
-	conn.logger.Debug("Initializing new amqp connection", logField{Key: "uri", Value: conn.uriForLog()})
+	conn.logger.Debug("Initializing new amqp connection", LogField{Key: "uri", Value: conn.uriForLog()})
 
 	// We open an initial connection.
 	err := conn.open()
@@ -125,21 +247,30 @@ func (a *amqpConnection) open() error {
 		return errEmptyURI
 	}
 
-	a.logger.Debug("Connecting to RabbitMQ server", logField{Key: "uri", Value: a.uriForLog()})
+	a.logger.Debug("Connecting to RabbitMQ server", LogField{Key: "uri", Value: a.uriForLog()})
 
 	// We request a connection from the RabbitMQ server.
-	conn, err := amqp.Dial(a.uri)
+	conn, err := dial(a.uri)
 	if err != nil {
 		a.logger.Error(err, "Connection failed")
 
 		return err
 	}
 
-	a.logger.Info("Connection successful", logField{Key: "uri", Value: a.uriForLog()})
+	a.logger.Info("Connection successful", LogField{Key: "uri", Value: a.uriForLog()})
+
+	// A previous connection means that the connection was lost.
+	if a.connection.Load() != nil {
+		a.observeConnection(ConnectionEventReopened)
+	} else {
+		a.observeConnection(ConnectionEventOpened)
+	}
+
+	a.connection.Store(conn)
 
-	a.connection = conn
+	a.listenBlockings(conn)
 
-	a.channels.updateParentConnection(a.connection)
+	a.channelList().updateParentConnection(conn)
 
 	// If the keepAlive flag is set to true, we activate a new guard.
 	if a.keepAlive {
@@ -149,37 +280,50 @@ func (a *amqpConnection) open() error {
 	return nil
 }
 
-// reconnect will indefinitely call the open method until a connection is successfully established or the context is canceled.
+// reconnect will call the open method until a connection is successfully established, the context is canceled or the
+// recovery policy gives up.
 func (a *amqpConnection) reconnect() {
 	a.logger.Debug("Re-connection launched")
 
-	for {
+	var (
+		delay time.Duration
+		err   error
+	)
+
+	for attempt := uint(1); ; attempt++ {
+		if !a.recovery.ShouldRetry(attempt, err) {
+			a.logger.Error(err, "Re-connection given up", LogField{Key: "attempts", Value: attempt - 1})
+
+			return
+		}
+
+		delay = a.recovery.NextDelay(attempt, delay)
+
+		// Wait for the delay of the attempt.
 		select {
 		case <-a.ctx.Done():
 			a.logger.Debug("Re-connection stopped by the context")
 
 			// If the context was canceled, we break out of the method.
 			return
-		default:
-			// Wait for the retryDelay.
-			time.Sleep(a.retryDelay)
-
-			// If there is no connection or the current connection is closed, we open a new connection.
-			if !a.ready() {
-				err := a.open()
-				// If the operation succeeds, we break the loop.
-				if err == nil {
-					a.logger.Debug("Re-connection successful")
-
-					return
-				}
-
-				a.logger.Error(err, "Could not open new connection during re-connection")
-			} else {
-				// If the connection exists and is active, we break out.
-				return
-			}
+		case <-time.After(delay):
 		}
+
+		// If the connection exists and is active, we break out.
+		if a.ready() {
+			return
+		}
+
+		// If there is no connection or the current connection is closed, we open a new connection.
+		err = a.open()
+		// If the operation succeeds, we break the loop.
+		if err == nil {
+			a.logger.Debug("Re-connection successful")
+
+			return
+		}
+
+		a.logger.Error(err, "Could not open new connection during re-connection")
 	}
 }
 
@@ -194,20 +338,22 @@ func (a *amqpConnection) guard() {
 
 			// If the context was canceled, we break out of the method.
 			return
-		case err, ok := <-a.connection.NotifyClose(make(chan *amqp.Error)):
+		case err, ok := <-a.connection.Load().NotifyClose(make(chan *amqp.Error)):
 			if !ok {
 				return
 			}
 
 			if err != nil {
-				a.logger.Warn("Connection lost", logField{Key: "reason", Value: err.Reason}, logField{Key: "code", Value: err.Code})
+				a.logger.Warn("Connection lost", LogField{Key: "reason", Value: err.Reason}, LogField{Key: "code", Value: err.Code})
 			}
 
 			// If the connection was explicitly closed, we do not want to re-connect.
-			if a.closed {
+			if a.closed.Load() {
 				return
 			}
 
+			a.observeConnection(ConnectionEventLost)
+
 			go a.reconnect()
 
 			return
@@ -218,14 +364,14 @@ func (a *amqpConnection) guard() {
 // close the connection only if it is ready.
 func (a *amqpConnection) close() error {
 	if a.ready() {
-		for _, channel := range a.channels {
+		for _, channel := range a.channelList() {
 			err := channel.close()
 			if err != nil {
 				return err
 			}
 		}
 
-		err := a.connection.Close()
+		err := a.connection.Load().Close()
 		if err != nil {
 			a.logger.Error(err, "Could not close connection")
 
@@ -233,7 +379,9 @@ func (a *amqpConnection) close() error {
 		}
 	}
 
-	a.closed = true
+	a.closed.Store(true)
+
+	a.observeConnection(ConnectionEventClosed)
 
 	a.logger.Info("Connection closed")
 
@@ -242,7 +390,9 @@ func (a *amqpConnection) close() error {
 
 // ready returns true if the connection exists and is not closed.
 func (a *amqpConnection) ready() bool {
-	return a.connection != nil && !a.connection.IsClosed()
+	connection := a.connection.Load()
+
+	return connection != nil && !connection.IsClosed()
 }
 
 // healthy returns true if the connection exists, is not closed and all child channels are healthy.
@@ -253,7 +403,7 @@ func (a *amqpConnection) healthy() bool {
 	}
 
 	// Verify that all connection channels are ready too.
-	for _, channel := range a.channels {
+	for _, channel := range a.channelList() {
 		if !channel.healthy() {
 			return false
 		}
@@ -264,38 +414,95 @@ func (a *amqpConnection) healthy() bool {
 
 // registerConsumer opens a new consumerChannel and registers the MessageConsumer.
 func (a *amqpConnection) registerConsumer(consumer MessageConsumer) error {
-	for _, channel := range a.channels {
-		if channel.consumer != nil && channel.consumer.Queue == consumer.Queue {
+	for _, channel := range a.channelList() {
+		if channel.consumer != nil && channel.consumesAny(consumer.queues()) {
 			err := errConsumerAlreadyExists
 
-			a.logger.Error(err, "Could not register consumer", logField{Key: "consumer", Value: consumer.Name})
+			a.logger.Error(err, "Could not register consumer", LogField{Key: "consumer", Value: consumer.Name})
 
 			return err
 		}
 	}
 
-	if err := consumer.Handlers.Validate(); err != nil {
+	if err := consumer.Validate(); err != nil {
+		a.logger.Error(err, "Could not register consumer", LogField{Key: "consumer", Value: consumer.Name})
+
 		return err
 	}
 
-	channel := newConsumerChannel(a.ctx, a.connection, a.keepAlive, a.retryDelay, &consumer, a.logger)
+	// We work on a copy of the deduplication config to fill in the defaults.
+	if consumer.Deduplication != nil {
+		deduplication := *consumer.Deduplication
+
+		if deduplication.Store == nil {
+			deduplication.Store = NewMemoryDeduplicationStore(defaultDeduplicationSize)
+		}
+
+		if deduplication.Window <= 0 {
+			deduplication.Window = defaultDeduplicationWindow
+		}
+
+		consumer.Deduplication = &deduplication
+	}
+
+	channel := newConsumerChannel(a.ctx, a.connection.Load(), a.keepAlive, a.retryDelay, a.recovery, &consumer, a.metrics, a.tracer, a.correlation, a.payloadLogging, a.auditor, a.faults, a.logger)
 
+	a.channelsMutex.Lock()
 	a.channels = append(a.channels, channel)
+	a.channelsMutex.Unlock()
 
-	a.logger.Info("Consumer registered", logField{Key: "consumer", Value: consumer.Name})
+	a.logger.Info("Consumer registered", LogField{Key: "consumer", Value: consumer.Name})
 
 	return nil
 }
 
-func (a *amqpConnection) publish(exchange, routingKey string, payload []byte, options *PublishingOptions) error {
+// consumerActive returns true if the consumer with the given name exists and is actively receiving deliveries.
+func (a *amqpConnection) consumerActive(name string) bool {
+	for _, channel := range a.channelList() {
+		if channel.consumer != nil && channel.consumer.Name == name {
+			return channel.isActive()
+		}
+	}
+
+	return false
+}
+
+// handOverConsumer makes the SingleActiveConsumer with the given name hand its queue over to the next consumer in line.
+func (a *amqpConnection) handOverConsumer(ctx context.Context, name string) error {
+	for _, channel := range a.channelList() {
+		if channel.consumer != nil && channel.consumer.Name == name {
+			return channel.handOver(ctx)
+		}
+	}
+
+	return fmt.Errorf("%w: %s", errConsumerNotFound, name)
+}
+
+func (a *amqpConnection) publish(ctx context.Context, exchange, routingKey string, payload []byte, metadata *MessageMetadata, options *PublishingOptions) error {
+	return a.publisherChannel().publish(ctx, exchange, routingKey, payload, metadata, options)
+}
+
+// publisherChannel returns the publishing channel, opened by the first publishing.
+func (a *amqpConnection) publisherChannel() *amqpChannel {
+	a.channelsMutex.Lock()
+	defer a.channelsMutex.Unlock()
+
 	publishingChannel := a.channels.publishingChannel()
 	if publishingChannel == nil {
-		publishingChannel = newPublishingChannel(a.ctx, a.connection, a.keepAlive, a.retryDelay, a.maxRetry, a.publishingCacheSize, a.publishingCacheTTL, a.logger)
+		publishingChannel = newPublishingChannel(a.ctx, a.connection.Load(), a.keepAlive, a.retryDelay, a.recovery, a.maxRetry, a.publishingCacheSize, a.publishingCacheTTL, a.metrics, a.tracer, a.correlation, a.payloadLogging, a.notifications, a.auditor, a.faults, a.pipeline, a.reliable, a.backpressure, a.logger)
 
 		a.channels = append(a.channels, publishingChannel)
 	}
 
-	return publishingChannel.publish(exchange, routingKey, payload, options)
+	return publishingChannel
+}
+
+// channelList returns a copy of the channels, which can be iterated while channels are added.
+func (a *amqpConnection) channelList() amqpChannels {
+	a.channelsMutex.Lock()
+	defer a.channelsMutex.Unlock()
+
+	return append(amqpChannels(nil), a.channels...)
 }
 
 // uriForLog returns the uri with the password hidden for security measures.
@@ -2,8 +2,9 @@ package gorabbit
 
 import (
 	"context"
-	"encoding/json"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type connectionManager struct {
@@ -12,6 +13,19 @@ type connectionManager struct {
 
 	// publisherConnection holds the independent publishing connection.
 	publisherConnection *amqpConnection
+
+	// marshaller encodes the payloads of the publishings.
+	marshaller Marshaller
+
+	// chunkSize is the size above which the payloads are published in chunks, if greater than 0.
+	chunkSize int
+
+	// amqp10 publishes over AMQP 1.0 instead of the publisherConnection, if set.
+	amqp10 *AMQP10Publisher
+
+	// topology records the topology declared through the manager of the client, to validate the priorities of the
+	// publishings.
+	topology *topologyRegistry
 }
 
 // newConnectionManager instantiates a new connectionManager with given arguments.
@@ -20,14 +34,42 @@ func newConnectionManager(
 	uri string,
 	keepAlive bool,
 	retryDelay time.Duration,
+	recovery RetryPolicy,
 	maxRetry uint,
 	publishingCacheSize uint64,
 	publishingCacheTTL time.Duration,
-	logger logger,
+	metrics MetricsCollector,
+	tracer trace.Tracer,
+	correlation *correlation,
+	payloadLogging *PayloadLogging,
+	notifications *BrokerNotifications,
+	auditor *auditor,
+	topology *topologyRegistry,
+	faults *FaultInjector,
+	pipeline *PublishingPipeline,
+	reliable bool,
+	blocking bool,
+	marshaller Marshaller,
+	chunkSize int,
+	amqp10 *AMQP10Options,
+	logger Logger,
 ) *connectionManager {
 	c := &connectionManager{
-		consumerConnection:  newConsumerConnection(ctx, uri, keepAlive, retryDelay, logger),
-		publisherConnection: newPublishingConnection(ctx, uri, keepAlive, retryDelay, maxRetry, publishingCacheSize, publishingCacheTTL, logger),
+		consumerConnection:  newConsumerConnection(ctx, uri, keepAlive, retryDelay, recovery, metrics, tracer, correlation, payloadLogging, auditor, faults, logger),
+		publisherConnection: newPublishingConnection(ctx, uri, keepAlive, retryDelay, recovery, maxRetry, publishingCacheSize, publishingCacheTTL, metrics, tracer, correlation, payloadLogging, notifications, auditor, faults, pipeline, reliable, blocking, logger),
+		marshaller:          marshaller,
+		chunkSize:           chunkSize,
+		topology:            topology,
+	}
+
+	if amqp10 != nil {
+		// The payloads are encoded by the Marshaller of the client, unless the AMQP10Options define their own.
+		opts := *amqp10
+		if opts.Marshaller == nil {
+			opts.Marshaller = marshaller
+		}
+
+		c.amqp10 = NewAMQP10Publisher(&opts)
 	}
 
 	return c
@@ -35,6 +77,12 @@ func newConnectionManager(
 
 // close offers the basic connection and channel close() mechanism but with extra higher level checks.
 func (c *connectionManager) close() error {
+	if c.amqp10 != nil {
+		if err := c.amqp10.Disconnect(); err != nil {
+			return err
+		}
+	}
+
 	if err := c.publisherConnection.close(); err != nil {
 		return err
 	}
@@ -60,6 +108,47 @@ func (c *connectionManager) isHealthy() bool {
 	return c.publisherConnection.healthy() && c.consumerConnection.healthy()
 }
 
+// healthReport returns a detailed view of the health of both connections and of the consumers.
+func (c *connectionManager) healthReport() HealthReport {
+	report := HealthReport{
+		Ready:   c.isReady(),
+		Healthy: c.isHealthy(),
+	}
+
+	if c.consumerConnection != nil {
+		report.Consumers = c.consumerConnection.consumersHealth()
+	}
+
+	return report
+}
+
+// consumersStats returns the statistics of the consumers.
+func (c *connectionManager) consumersStats() []ConsumerStats {
+	if c.consumerConnection == nil {
+		return nil
+	}
+
+	return c.consumerConnection.consumersStats()
+}
+
+// exportSchema returns the topology carried by the registered consumers as a RabbitMQ definitions JSON document.
+func (c *connectionManager) exportSchema(vhost string) ([]byte, error) {
+	if c.consumerConnection == nil {
+		return nil, errConsumerConnectionNotInitialized
+	}
+
+	return c.consumerConnection.consumersTopology().export(vhost)
+}
+
+// verifyTopology verifies the topology used by the registered consumers.
+func (c *connectionManager) verifyTopology(ctx context.Context) (TopologyReport, error) {
+	if c.consumerConnection == nil {
+		return TopologyReport{}, errConsumerConnectionNotInitialized
+	}
+
+	return verifyTopology(ctx, c.consumerConnection.connection.Load(), c.consumerConnection.consumersTopologyChecks())
+}
+
 // registerConsumer registers a new MessageConsumer.
 func (c *connectionManager) registerConsumer(consumer MessageConsumer) error {
 	if c.consumerConnection == nil {
@@ -69,15 +158,104 @@ func (c *connectionManager) registerConsumer(consumer MessageConsumer) error {
 	return c.consumerConnection.registerConsumer(consumer)
 }
 
-func (c *connectionManager) publish(exchange, routingKey string, payload interface{}, options *PublishingOptions) error {
+// isConsumerActive returns true if the consumer with the given name is actively receiving deliveries.
+func (c *connectionManager) isConsumerActive(name string) bool {
+	if c.consumerConnection == nil {
+		return false
+	}
+
+	return c.consumerConnection.consumerActive(name)
+}
+
+// handOverConsumer makes the SingleActiveConsumer with the given name hand its queue over to the next consumer in line.
+func (c *connectionManager) handOverConsumer(ctx context.Context, name string) error {
+	if c.consumerConnection == nil {
+		return errConsumerConnectionNotInitialized
+	}
+
+	return c.consumerConnection.handOverConsumer(ctx, name)
+}
+
+// drain drains all consumers, then waits for the buffered publishings.
+func (c *connectionManager) drain(ctx context.Context) (DrainReport, error) {
+	if c.consumerConnection == nil {
+		return DrainReport{}, errConsumerConnectionNotInitialized
+	}
+
+	report, err := c.consumerConnection.drain(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.Published, err = c.publisherConnection.flush(ctx)
+
+	return report, err
+}
+
+// purgeQueue purges a queue through the publisher connection.
+func (c *connectionManager) purgeQueue(queue string) (int, error) {
+	if c.publisherConnection == nil {
+		return 0, errPublisherConnectionNotInitialized
+	}
+
+	return c.publisherConnection.purgeQueue(queue)
+}
+
+// deleteQueue deletes a queue through the publisher connection.
+func (c *connectionManager) deleteQueue(queue string, ifUnused, ifEmpty bool) (int, error) {
+	if c.publisherConnection == nil {
+		return 0, errPublisherConnectionNotInitialized
+	}
+
+	return c.publisherConnection.deleteQueue(queue, ifUnused, ifEmpty)
+}
+
+// deleteExchange deletes an exchange through the publisher connection.
+func (c *connectionManager) deleteExchange(exchange string, ifUnused bool) error {
 	if c.publisherConnection == nil {
 		return errPublisherConnectionNotInitialized
 	}
 
-	payloadBytes, err := json.Marshal(payload)
+	return c.publisherConnection.deleteExchange(exchange, ifUnused)
+}
+
+// unbindQueue removes the binding of a queue through the publisher connection.
+func (c *connectionManager) unbindQueue(exchange, queue, routingKey string, args map[string]interface{}) error {
+	if c.publisherConnection == nil {
+		return errPublisherConnectionNotInitialized
+	}
+
+	return c.publisherConnection.unbindQueue(exchange, queue, routingKey, args)
+}
+
+func (c *connectionManager) publish(ctx context.Context, exchange, routingKey string, payload interface{}, options *PublishingOptions) error {
+	if c.publisherConnection == nil {
+		return errPublisherConnectionNotInitialized
+	}
+
+	if err := c.validatePublishingPriority(exchange, routingKey, options); err != nil {
+		return err
+	}
+
+	if c.amqp10 != nil {
+		return c.amqp10.PublishWithContext(ctx, exchange, routingKey, payload, options)
+	}
+
+	metadata := &MessageMetadata{
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Type:       routingKey,
+		Headers:    make(map[string]interface{}),
+	}
+
+	payloadBytes, err := c.marshaller.Marshal(metadata, payload)
 	if err != nil {
 		return err
 	}
 
-	return c.publisherConnection.publish(exchange, routingKey, payloadBytes, options)
+	if c.chunkSize > 0 && len(payloadBytes) > c.chunkSize {
+		return c.publishChunks(ctx, exchange, routingKey, payloadBytes, metadata, options)
+	}
+
+	return c.publisherConnection.publish(ctx, exchange, routingKey, payloadBytes, metadata, options)
 }
@@ -0,0 +1,58 @@
+package gorabbit
+
+import "strconv"
+
+// argHashHeader is the exchange argument making a consistent-hash exchange hash the value of the given header instead
+// of the routing key.
+const argHashHeader = "hash-header"
+
+// ConsistentHashExchange returns the configuration of an exchange distributing the messages among its bound queues by
+// hashing their routing key or, if hashHeader is set, the value of that header. Messages with the same hashing key are
+// always routed to the same queue. It requires the rabbitmq_consistent_hash_exchange plugin.
+func ConsistentHashExchange(name string, persisted bool, hashHeader string) ExchangeConfig {
+	config := ExchangeConfig{
+		Name:      name,
+		Type:      ExchangeTypeConsistentHash,
+		Persisted: persisted,
+	}
+
+	if hashHeader != "" {
+		config.Args = map[string]interface{}{argHashHeader: hashHeader}
+	}
+
+	return config
+}
+
+// ConsistentHashBinding returns the binding of a queue to a consistent-hash exchange with the given weight, which is
+// the share of the hash space, and so of the messages, the queue receives relatively to the other bound queues.
+// A weight of 0 is taken as 1.
+func ConsistentHashBinding(exchange string, weight uint) BindingConfig {
+	if weight == 0 {
+		weight = 1
+	}
+
+	return BindingConfig{
+		Exchange:   exchange,
+		RoutingKey: strconv.FormatUint(uint64(weight), 10),
+	}
+}
+
+// routing returns the routing key to publish with and sets the hashing header, depending on the partition key or the
+// hashing key of the PublishingOptions.
+func (m *PublishingOptions) routing(routingKey string, headers map[string]interface{}) string {
+	if m.PartitionKey != nil && m.Partitions > 0 {
+		return strconv.Itoa(superStreamPartitionIndex(*m.PartitionKey, m.Partitions))
+	}
+
+	if m.HashKey == nil {
+		return routingKey
+	}
+
+	if m.HashHeader == "" {
+		return *m.HashKey
+	}
+
+	headers[m.HashHeader] = *m.HashKey
+
+	return routingKey
+}
@@ -0,0 +1,37 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestConsistentHashExchange(t *testing.T) {
+	config := gorabbit.ConsistentHashExchange("jobs_exchange", true, "")
+
+	assert.Equal(t, gorabbit.ExchangeTypeConsistentHash, config.Type)
+	assert.Empty(t, config.Args)
+
+	config = gorabbit.ConsistentHashExchange("jobs_exchange", true, "tenant")
+
+	assert.Equal(t, map[string]interface{}{"hash-header": "tenant"}, config.Args)
+}
+
+func TestConsistentHashBinding(t *testing.T) {
+	assert.Equal(t, gorabbit.BindingConfig{Exchange: "jobs_exchange", RoutingKey: "3"}, gorabbit.ConsistentHashBinding("jobs_exchange", 3))
+	assert.Equal(t, "1", gorabbit.ConsistentHashBinding("jobs_exchange", 0).RoutingKey)
+}
+
+func TestPublishingOptions_HashKey(t *testing.T) {
+	options := gorabbit.SendOptions().SetHeaderHashKey("tenant", "acme")
+
+	assert.Equal(t, "acme", *options.HashKey)
+	assert.Equal(t, "tenant", options.HashHeader)
+
+	options.SetHashKey("order-42")
+
+	assert.Equal(t, "order-42", *options.HashKey)
+	assert.Empty(t, options.HashHeader)
+}
@@ -2,6 +2,8 @@ package gorabbit
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"time"
 )
 
@@ -12,26 +14,94 @@ const libraryName = "Gorabbit"
 const (
 	defaultProtocol = "amqp"
 	securedProtocol = "amqps"
+
+	managementProtocol        = "http"
+	securedManagementProtocol = "https"
 )
 
-// Default values for the ClientOptions and ManagerOptions.
+// Default values for the ClientOptions, ManagerOptions, ManagementOptions and MQTTOptions.
 const (
-	defaultHost                = "127.0.0.1"
-	defaultPort                = 5672
-	defaultUsername            = "guest"
-	defaultPassword            = "guest"
-	defaultVhost               = ""
-	defaultUseTLS              = false
-	defaultKeepAlive           = true
-	defaultRetryDelay          = 3 * time.Second
-	defaultMaxRetry            = 5
-	defaultPublishingCacheTTL  = 60 * time.Second
-	defaultPublishingCacheSize = 128
-	defaultMode                = Release
+	defaultHost                    = "127.0.0.1"
+	defaultPort                    = 5672
+	defaultUsername                = "guest"
+	defaultPassword                = "guest"
+	defaultVhost                   = ""
+	defaultUseTLS                  = false
+	defaultKeepAlive               = true
+	defaultRetryDelay              = 3 * time.Second
+	defaultMaxRetry                = 5
+	defaultPublishingCacheTTL      = 60 * time.Second
+	defaultPublishingCacheSize     = 128
+	defaultMode                    = Release
+	defaultRetryMultiplier         = 2
+	defaultRetryMaxDelay           = math.MaxUint32 * time.Millisecond
+	defaultDeduplicationSize       = 10000
+	defaultDeduplicationWindow     = 10 * time.Minute
+	defaultQueueMonitorPeriod      = 30 * time.Second
+	defaultManagementPort          = 15672
+	defaultManagementTimeout       = 10 * time.Second
+	defaultManagementPollInterval  = time.Second
+	defaultEventBufferSize         = 256
+	defaultPayloadLoggingMaxSize   = 1024
+	defaultAuditBatchSize          = 100
+	defaultAuditFlushInterval      = time.Second
+	defaultAuditCloseTimeout       = 10 * time.Second
+	defaultFlapThreshold           = 5
+	defaultFlapWindow              = 10 * time.Minute
+	defaultHealthHistorySize       = 100
+	defaultChunkTimeout            = time.Minute
+	defaultHeartbeat               = 10 * time.Second
+	defaultConnectionTimeout       = 30 * time.Second
+	defaultLocale                  = "en_US"
+	defaultPipelineBufferSize      = 1024
+	defaultPipelineBatchSize       = 128
+	defaultPipelineConfirmTimeout  = 30 * time.Second
+	defaultPipelineMaxAttempts     = 5
+	pipelineFlushInterval          = 10 * time.Millisecond
+	blockingPublishPollInterval    = 10 * time.Millisecond
+	maxPooledPublishingHeaders     = 32
+	ttlMapShards                   = 16
+	defaultAdaptivePrefetchPeriod  = 5 * time.Second
+	defaultAdaptivePrefetchFactor  = 0.5
+	defaultCircuitFailureThreshold = 5
+	defaultCircuitOpenDuration     = 30 * time.Second
+	defaultMQTTPort                = 1883
+	defaultMQTTKeepAlive           = 60 * time.Second
+	defaultMQTTTimeout             = 30 * time.Second
+	defaultMQTTExchange            = "amq.topic"
+	defaultStreamPort              = 5552
+	defaultStreamHeartbeat         = 60 * time.Second
+	defaultStreamTimeout           = 30 * time.Second
+	defaultStreamCredit            = 10
+	defaultAMQP10Timeout           = 30 * time.Second
+	defaultRebalanceInterval       = 30 * time.Second
+	defaultHandOverTimeout         = time.Minute
+	defaultSagaTimeout             = 30 * time.Second
+	defaultSchedulerPollInterval   = time.Second
+	defaultSchedulerBatchSize      = 100
 )
 
 const (
-	xDeathCountHeader = "x-death-count"
+	xDeathCountHeader         = "x-death-count"
+	xDeathHeader              = "x-death"
+	xDeliveryCountHeader      = "x-delivery-count"
+	xRetryAttemptHeader       = "x-retry-attempt"
+	xRetryDelayHeader         = "x-retry-delay"
+	xOriginalQueueHeader      = "x-original-queue"
+	xOriginalExchangeHeader   = "x-original-exchange"
+	xOriginalRoutingKeyHeader = "x-original-routing-key"
+	xQuarantineReasonHeader   = "x-quarantine-reason"
+	envelopeTypeHeader        = "x-message-type"
+	envelopeVersionHeader     = "x-schema-version"
+	envelopeProducerHeader    = "x-producer"
+	envelopeEmittedAtHeader   = "x-emitted-at"
+	encryptionKeyIDHeader     = "x-encryption-key-id"
+	cloudEventsHeaderPrefix   = "ce-"
+	chunkIDHeader             = "x-chunk-id"
+	chunkIndexHeader          = "x-chunk-index"
+	chunkCountHeader          = "x-chunk-count"
+	chunkChecksumHeader       = "x-chunk-checksum"
+	xDecodeErrorHeader        = "x-decode-error"
 )
 
 // Connection Types.
@@ -52,12 +122,97 @@ const (
 	ExchangeTypeDirect  ExchangeType = "direct"
 	ExchangeTypeFanout  ExchangeType = "fanout"
 	ExchangeTypeHeaders ExchangeType = "headers"
+
+	// ExchangeTypeConsistentHash requires the rabbitmq_consistent_hash_exchange plugin, see ConsistentHashExchange.
+	ExchangeTypeConsistentHash ExchangeType = "x-consistent-hash"
 )
 
 func (e ExchangeType) String() string {
 	return string(e)
 }
 
+// Queue Types
+
+type QueueType string
+
+const (
+	QueueTypeClassic QueueType = "classic"
+	QueueTypeQuorum  QueueType = "quorum"
+	QueueTypeStream  QueueType = "stream"
+)
+
+func (q QueueType) String() string {
+	return string(q)
+}
+
+// Dead Letter Strategies
+
+type DeadLetterStrategy string
+
+const (
+	DeadLetterStrategyAtMostOnce  DeadLetterStrategy = "at-most-once"
+	DeadLetterStrategyAtLeastOnce DeadLetterStrategy = "at-least-once"
+)
+
+func (d DeadLetterStrategy) String() string {
+	return string(d)
+}
+
+// Overflow Behaviors
+
+type OverflowBehavior string
+
+const (
+	OverflowDropHead         OverflowBehavior = "drop-head"
+	OverflowRejectPublish    OverflowBehavior = "reject-publish"
+	OverflowRejectPublishDLX OverflowBehavior = "reject-publish-dlx"
+)
+
+func (o OverflowBehavior) String() string {
+	return string(o)
+}
+
+// Headers Matches
+
+type HeadersMatch string
+
+const (
+	// HeadersMatchAll routes the messages matching all the headers of the binding.
+	HeadersMatchAll HeadersMatch = "all"
+
+	// HeadersMatchAny routes the messages matching at least one of the headers of the binding.
+	HeadersMatchAny HeadersMatch = "any"
+
+	// HeadersMatchAllWithX is HeadersMatchAll, also matching the headers starting with "x-".
+	HeadersMatchAllWithX HeadersMatch = "all-with-x"
+
+	// HeadersMatchAnyWithX is HeadersMatchAny, also matching the headers starting with "x-".
+	HeadersMatchAnyWithX HeadersMatch = "any-with-x"
+)
+
+func (h HeadersMatch) String() string {
+	return string(h)
+}
+
+// Acknowledgement Modes
+
+type AckMode string
+
+const (
+	// AckModeAuto acknowledges deliveries upon reception, before they are processed.
+	AckModeAuto AckMode = "auto"
+
+	// AckModeOnSuccess acknowledges deliveries once successfully processed and retries them otherwise.
+	AckModeOnSuccess AckMode = "on_success"
+
+	// AckModeManual leaves the acknowledgement of deliveries to the handlers, see Delivery.Ack and Delivery.Nack.
+	AckModeManual AckMode = "manual"
+)
+
+func (a AckMode) String() string {
+	return string(a)
+}
+
 // Priority Levels.
 
 type MessagePriority uint8
@@ -101,10 +256,124 @@ func isValidMode(mode string) bool {
 // Errors.
 var (
 	errEmptyURI                          = errors.New("amqp uri is empty")
-	errChannelClosed                     = errors.New("channel is closed")
-	errConnectionClosed                  = errors.New("connection is closed")
+	errChannelClosed                     = fmt.Errorf("%w: channel is closed", ErrNotConnected)
+	errConnectionClosed                  = fmt.Errorf("%w: connection is closed", ErrNotConnected)
 	errConsumerAlreadyExists             = errors.New("consumer already exists")
-	errConsumerConnectionNotInitialized  = errors.New("consumerConnection is not initialized")
-	errPublisherConnectionNotInitialized = errors.New("publisherConnection is not initialized")
+	errConsumerNotFound                  = errors.New("consumer not found")
+	errNoReplyTo                         = errors.New("delivery expects no reply")
+	errConsumerConnectionNotInitialized  = fmt.Errorf("%w: consumerConnection is not initialized", ErrNotConnected)
+	errPublisherConnectionNotInitialized = fmt.Errorf("%w: publisherConnection is not initialized", ErrNotConnected)
 	errEmptyQueue                        = errors.New("queue is empty")
+	errManualAckDisabled                 = errors.New("delivery can only be acknowledged by handlers in manual ack mode")
+	errInvalidReplayRate                 = errors.New("replay rate cannot be negative")
+	errReplayNotConfirmed                = errors.New("replayed message was not confirmed by the server")
+	errEmptyExchangeName                 = errors.New("exchange name cannot be empty")
+	errEmptyQueueName                    = errors.New("queue name cannot be empty")
+	errEmptyAlternateExchangeName        = errors.New("alternate exchange name cannot be empty")
+	errMigrationNotRecorded              = errors.New("migration record was not confirmed by the server")
+	errEmptyPolicyName                   = errors.New("policy name cannot be empty")
+	errEmptyShovelName                   = errors.New("shovel name cannot be empty")
+	errEmptyUpstreamName                 = errors.New("federation upstream name cannot be empty")
+	errEnvelopeNotJSON                   = errors.New("only JSON payloads can be wrapped in an envelope body")
+	errUnknownEnvelopeVersion            = errors.New("no handler for the envelope version")
+	errUnknownEncryptionKey              = errors.New("unknown encryption key")
+	errInvalidEncryptionKey              = errors.New("encryption keys must be 16, 24 or 32 bytes long")
+	errEncryptedPayloadTooShort          = errors.New("encrypted payload is too short")
+	errNotCloudEvent                     = errors.New("message is not a CloudEvent")
+	errInvalidChunk                      = errors.New("invalid chunk")
+	errChunkChecksumMismatch             = errors.New("checksum mismatch")
+	errChunkExpired                      = errors.New("incomplete chunked message expired")
+	errInvalidEnvValue                   = errors.New("invalid value of environment variable")
+	errInvalidConfig                     = errors.New("invalid configuration")
+	errInvalidOptions                    = errors.New("invalid client options")
+	errUpdateWithClientOptions           = fmt.Errorf("%w: a *ClientOptions replaces every setting, use the functional options to update the configuration", errInvalidOptions)
+	errInvalidSubscription               = errors.New("invalid subscription")
+	errPublishingNotConfirmed            = errors.New("publishing not confirmed by the server")
+	errMQTTExchange                      = errors.New("mqtt publishings can only target the exchange of the mqtt plugin")
+	errMQTTProtocol                      = errors.New("unexpected mqtt packet")
+	errMQTTPacketTooLarge                = errors.New("mqtt packet exceeds the maximum remaining length")
+	errMQTTPacketIDsExhausted            = errors.New("no mqtt packet identifier left")
+	errStreamProtocol                    = errors.New("unexpected stream frame")
+	errTruncatedFrame                    = errors.New("truncated frame")
+	errAMQP10Protocol                    = errors.New("unexpected amqp 1.0 frame")
+	errEmptyStreamName                   = errors.New("stream name cannot be empty")
+	errStreamConsumerHandler             = errors.New("stream consumer must define a handler")
+)
+
+// Exported Errors.
+var (
+	// ErrNotConnected is returned when an operation needs a connection or a channel to the server that is not open,
+	// such as while it is being recovered.
+	ErrNotConnected = errors.New("not connected")
+
+	// ErrCacheFull is returned by a publishing cached while the publishing cache is full, an older publishing being
+	// evicted to make room for it.
+	ErrCacheFull = errors.New("publishing cache is full")
+
+	// ErrUnroutable is returned by a publishing that the server could not route to any queue, when the publishing
+	// awaits its confirmation: a reliable or pipelined mandatory publishing, see ErrPublishingReturned, or an AMQP 1.0
+	// publishing. The other mandatory publishings return before the server answers, and are reported through OnReturn.
+	ErrUnroutable = errors.New("publishing is unroutable")
+
+	// ErrConsumerCanceled is the error of a consumer whose subscription was canceled by the server, such as when its
+	// queue is deleted.
+	ErrConsumerCanceled = errors.New("consumer canceled by the server")
+
+	// ErrHandlerTimeout is the error of a handler that failed once the timeout of its context, set by ContextTimeout,
+	// elapsed. It is also the cause of the context.
+	ErrHandlerTimeout = errors.New("handler timed out")
+
+	// ErrDecode is matched by the DecodeError of a delivery that could not be decoded.
+	ErrDecode = errors.New("delivery could not be decoded")
+
+	// ErrExclusiveConsumerConflict is returned when an exclusive consumer cannot subscribe to a queue because another
+	// consumer already uses it, or when a consumer cannot subscribe to a queue consumed by an exclusive consumer.
+	ErrExclusiveConsumerConflict = errors.New("queue is in exclusive use by another consumer")
+
+	// ErrQueueNotFound is returned when an operation targets a queue that does not exist.
+	ErrQueueNotFound = errors.New("queue not found")
+
+	// ErrExchangeNotFound is returned when an operation targets an exchange that does not exist.
+	ErrExchangeNotFound = errors.New("exchange not found")
+
+	// ErrQueueInUse is returned when a queue deleted only if unused has consumers.
+	ErrQueueInUse = errors.New("queue is in use")
+
+	// ErrQueueNotEmpty is returned when a queue deleted only if empty has messages.
+	ErrQueueNotEmpty = errors.New("queue is not empty")
+
+	// ErrExchangeInUse is returned when an exchange deleted only if unused has bindings.
+	ErrExchangeInUse = errors.New("exchange is in use")
+
+	// ErrAccessRefused is returned when the user is not allowed to perform an operation.
+	ErrAccessRefused = errors.New("access refused")
+
+	// ErrPriorityOutOfRange is returned when a message is published with a priority greater than the maximum priority
+	// of its queue.
+	ErrPriorityOutOfRange = errors.New("priority exceeds the max priority of the queue")
+
+	// ErrInvalidArgument is returned when an argument of a queue, an exchange or a binding cannot be represented in an
+	// AMQP field table without altering its value.
+	ErrInvalidArgument = errors.New("invalid argument")
+
+	// ErrInjectedFault is returned by a publishing dropped by a FaultInjector.
+	ErrInjectedFault = errors.New("publishing dropped by fault injection")
+
+	// ErrCircuitOpen is returned by the publishings failed right away by an open circuit breaker, the broker being
+	// unavailable.
+	ErrCircuitOpen = errors.New("publishing circuit is open")
+
+	// ErrPublishingReturned is returned by a reliable publishing that the broker could not route to any queue.
+	ErrPublishingReturned = fmt.Errorf("%w: publishing returned by the server", ErrUnroutable)
+
+	// ErrPublishingCached is returned by a reliable publishing that was not confirmed, because the channel was down or
+	// the broker did not confirm it. It is cached, and will be replayed with the next channel until confirmed.
+	ErrPublishingCached = errors.New("publishing cached to be replayed")
+
+	// ErrConnectionBlocked is returned by a blocking publishing whose context is done while the broker blocks the
+	// publishing connection, usually on a resource alarm.
+	ErrConnectionBlocked = errors.New("connection blocked by the server")
+
+	// ErrReplyTimeout is returned when the reply to a command, such as the one of a saga step, is not received in time.
+	ErrReplyTimeout = errors.New("reply timed out")
 )
@@ -1,9 +1,14 @@
 package gorabbit
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // MQTTMessageHandlers is a wrapper that holds a map[string]MQTTMessageHandlerFunc.
@@ -12,217 +17,467 @@ type MQTTMessageHandlers map[string]MQTTMessageHandlerFunc
 // MQTTMessageHandlerFunc is the function that will be called when a delivery is received.
 type MQTTMessageHandlerFunc func(payload []byte) error
 
-// Validate verifies that all routing keys in the handlers are properly formatted and allowed.
-//
-//nolint:gocognit // We can allow the current complexity for now but we should revisit it later.
+// MQTTMessageContextHandlers is a wrapper that holds a map[string]MQTTMessageContextHandlerFunc.
+type MQTTMessageContextHandlers map[string]MQTTMessageContextHandlerFunc
+
+// MQTTMessageContextHandlerFunc is the function that will be called when a delivery is received.
+// The context is canceled when the consumption stops and holds the Delivery, see DeliveryFromContext.
+type MQTTMessageContextHandlerFunc func(ctx context.Context, payload []byte) error
+
+// Validate verifies that all routing keys in the handlers are properly formatted and allowed. See MessageConsumer.DryRun
+// to also detect the ambiguous and unreachable handlers.
 func (mh MQTTMessageHandlers) Validate() error {
 	for k := range mh {
-		// A routing key cannot be empty.
-		if len(k) == 0 {
-			return errors.New("a routing key cannot be empty")
+		if err := validateRoutingKey(k); err != nil {
+			return err
 		}
+	}
 
-		// A routing key cannot be equal to the wildcard '#'.
-		if len(k) == 1 && k == "#" {
-			return errors.New("a routing key cannot be the wildcard '#'")
-		}
+	return nil
+}
 
-		// A routing key cannot contain spaces.
-		if strings.Contains(k, " ") {
-			return errors.New("a routing key cannot contain spaces")
-		}
+// FindFunc returns the handler matching the given routing key, or nil if none matches. The keys are compiled at each
+// call, see RoutingKeyMatcher to match many routing keys.
+func (mh MQTTMessageHandlers) FindFunc(routingKey string) MQTTMessageHandlerFunc {
+	fn, _, _ := findHandler(mh, routingKey)
 
-		// If a routing key is not just made up of one word.
-		if strings.Contains(k, ".") {
-			// We need to make sure that we do not find an empty word or a '%' in the middle of the key.
-			split := strings.Split(k, ".")
-
-			for i, v := range split {
-				// We cannot have empty strings.
-				if v == "" {
-					return fmt.Errorf("the routing key '%s' is not properly formatted", k)
-				}
-
-				// The wildcard '#' is not allowed in the middle.
-				if v == "#" && i > 0 && i < len(split)-1 {
-					return fmt.Errorf("the wildcard '#' in the routing key '%s' is not allowed", k)
-				}
-			}
+	return fn
+}
+
+// Validate verifies that all routing keys in the handlers are properly formatted and allowed.
+func (mh MQTTMessageContextHandlers) Validate() error {
+	for k := range mh {
+		if err := validateRoutingKey(k); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
-// matchesPrefixWildcard verifies that everything that comes after the '#' wildcard matches.
-func (mh MQTTMessageHandlers) matchesPrefixWildcard(storedWords, words []string) bool {
-	// compareIndex starts after the wildcard in the storedWords array.
-	compareIndex := 1
-
-	// we initialize the wordIdx at -1.
-	wordIdx := -1
+// FindFunc returns the handler matching the given routing key, or nil if none matches. The keys are compiled at each
+// call, see RoutingKeyMatcher to match many routing keys.
+func (mh MQTTMessageContextHandlers) FindFunc(routingKey string) MQTTMessageContextHandlerFunc {
+	fn, _, _ := findHandler(mh, routingKey)
 
-	// Here we are searching for the first occurrence of the first word after the '#' wildcard
-	// of the storedWords in the words.
-	for i, w := range words {
-		if w == storedWords[compareIndex] {
-			// We can now start comparing at 'i'.
-			wordIdx = i
+	return fn
+}
 
-			break
-		}
+// validateRoutingKey verifies that a handler routing key is properly formatted and allowed.
+func validateRoutingKey(k string) error {
+	// A routing key cannot be empty.
+	if len(k) == 0 {
+		return errors.New("a routing key cannot be empty")
 	}
 
-	// If we did not find the first word, then surely the key does not match.
-	if wordIdx == -1 {
-		return false
+	// A routing key cannot be equal to the wildcard '#'.
+	if len(k) == 1 && k == "#" {
+		return errors.New("a routing key cannot be the wildcard '#'")
 	}
 
-	// If the length of storedWords is not the same as the length of words after the wildcard,
-	// then surely the key does not match.
-	if len(storedWords)-compareIndex != len(words)-wordIdx {
-		return false
+	// A routing key cannot contain spaces.
+	if strings.Contains(k, " ") {
+		return errors.New("a routing key cannot contain spaces")
 	}
 
-	// Now we can compare, word by word if the routing keys matches.
-	for i := wordIdx; i < len(words); i++ {
-		// Be careful, if we find '*' then it should match no matter what.
-		if storedWords[compareIndex] != words[i] && storedWords[compareIndex] != "*" {
-			return false
+	// If a routing key is not just made up of one word.
+	if strings.Contains(k, ".") {
+		// We need to make sure that we do not find an empty word or a '%' in the middle of the key.
+		split := strings.Split(k, ".")
+
+		for i, v := range split {
+			// We cannot have empty strings.
+			if v == "" {
+				return fmt.Errorf("the routing key '%s' is not properly formatted", k)
+			}
+
+			// The wildcard '#' is not allowed in the middle.
+			if v == "#" && i > 0 && i < len(split)-1 {
+				return fmt.Errorf("the wildcard '#' in the routing key '%s' is not allowed", k)
+			}
 		}
+	}
+
+	return nil
+}
 
-		// We move right in the storedWords.
-		compareIndex++
+// findHandler returns the handler whose routing key matches the given routing key, along with its registered key. The
+// wildcard keys are compiled at each call, which only suits one-off lookups: the consumers dispatch their deliveries
+// through the handlerMatcher compiled when they are registered.
+func findHandler[F any](handlers map[string]F, routingKey string) (F, string, bool) {
+	// We first check for a direct match
+	if fn, found := handlers[routingKey]; found {
+		return fn, routingKey, true
 	}
 
-	return true
+	return newRoutingTrie(handlers).match(routingKey)
+}
+
+// MatchRoutingKey returns true if the routing key matches the pattern, which can contain the '*' and '#' wildcards the
+// same way as the keys of the handlers. The pattern is compiled at each call, so a RoutingKeyMatcher should be kept to
+// match many routing keys.
+func MatchRoutingKey(pattern, routingKey string) bool {
+	_, found := NewRoutingKeyMatcher(pattern).Match(routingKey)
+
+	return found
+}
+
+// MessageConsumer holds all the information needed to consume messages.
+type MessageConsumer struct {
+	// Queue defines the queue from which we want to consume messages.
+	// If empty, a server-named exclusive queue is declared with every new channel and bound as defined by the QueueConfig
+	// bindings. This is the usual pattern of broadcast subscribers.
+	Queue string
+
+	// Name is a unique identifier of the consumer. Should be as explicit as possible.
+	Name string
+
+	// PrefetchSize defines the max size of messages that are allowed to be processed at the same time.
+	// This property is dropped if deliveries are automatically acknowledged.
+	PrefetchSize int
+
+	// PrefetchCount defines the max number of messages that are allowed to be processed at the same time.
+	// This property is dropped if deliveries are automatically acknowledged.
+	PrefetchCount int
+
+	// AutoAck defines whether a message is directly acknowledged or not when being consumed.
+	// This property is dropped if AckMode is set.
+	AutoAck bool
+
+	// AckMode defines how deliveries are acknowledged. Defaults to AckModeAuto if AutoAck is set to true, and to
+	// AckModeOnSuccess otherwise.
+	AckMode AckMode
+
+	// ConcurrentProcess will make MQTTMessageHandlers run concurrently for faster consumption, if set to true.
+	ConcurrentProcess bool
+
+	// Handlers is the list of defined handlers.
+	Handlers MQTTMessageHandlers
+
+	// ContextHandlers is the list of defined handlers that receive a context holding the Delivery.
+	// ContextHandlers are looked up before Handlers.
+	ContextHandlers MQTTMessageContextHandlers
+
+	// Exclusive defines whether the consumer requests exclusive access to the queue. If another consumer already uses
+	// the queue, the subscription fails with ErrExclusiveConsumerConflict and, if KeepAlive is set, is attempted again
+	// after each RetryDelay until the exclusivity is acquired.
+	Exclusive bool
+
+	// SingleActiveConsumer must be set to true if the queue was declared with the single active consumer flag.
+	// The consumer then starts as passive and is considered active once it receives its first delivery.
+	SingleActiveConsumer bool
+
+	// OnActiveStateChange is called, if set, when a SingleActiveConsumer switches between active and passive.
+	OnActiveStateChange func(active bool)
+
+	// StreamOffset defines, if set, the offset from which a stream queue is consumed. Stream consumers require a
+	// PrefetchCount and cannot use AckModeAuto. After a channel recovery, the consumption resumes right after the last
+	// processed offset.
+	StreamOffset *StreamOffset
+
+	// Retry enables the exponential backoff retry mechanism through dead-letter and TTL queues, if set.
+	// Otherwise, failed deliveries are retried by re-publishing them to their original exchange.
+	Retry *RetryConfig
+
+	// RetryPolicy governs, if set, the retries of the failed deliveries: a failed delivery is held for the delay of the
+	// policy, then re-published to the queue of the consumer and acknowledged, until the policy gives up and it is
+	// dead-lettered. Held deliveries count towards the PrefetchCount. It cannot be used along with Retry.
+	RetryPolicy RetryPolicy
+
+	// NackDelay defines, if set, the delay during which a failed delivery is held before being negative acknowledged
+	// with requeue. This avoids redelivery storms when a downstream dependency is briefly unavailable.
+	// Held deliveries count towards the PrefetchCount. This property is dropped with AckModeAuto or if Retry or
+	// RetryPolicy is set.
+	NackDelay time.Duration
+
+	// MaxDeliveryAttempts defines the number of attempts after which a failing delivery is quarantined instead of being
+	// retried. Attempts are tracked via the x-death, x-delivery-count and x-retry-attempt headers.
+	// No limit other than the retry mechanism's own is applied if set to 0.
+	MaxDeliveryAttempts uint
+
+	// Deduplication enables, if set, the skipping of deliveries whose MessageId was already successfully processed.
+	Deduplication *DeduplicationConfig
+
+	// QuarantineQueue is the parking-lot queue that receives deliveries that could not be processed.
+	// Defaults to "<queue>.parking-lot" if MaxDeliveryAttempts is set.
+	QuarantineQueue string
+
+	// AckBatch enables, if set, the acknowledgement of successful deliveries in batches with a single multiple
+	// acknowledgement. The PrefetchCount should be greater than the batch size. This property is dropped with AckModeAuto.
+	AckBatch *AckBatchConfig
+
+	// ConsumeRateLimit defines, if set, the maximum number of deliveries dispatched to the handlers per second.
+	// Deliveries waiting for their dispatch are held by the consumer, within the limit of the PrefetchCount.
+	ConsumeRateLimit float64
+
+	// QueueConfig defines, if set, the queue and bindings declared before consuming. The queue is always named after
+	// the consumer's Queue, or by the server if empty. The declaration is repeated with every new channel.
+	QueueConfig *QueueConfig
+
+	// DeadLetterExchange defines, if set, the exchange declared before the queue to receive its dead-lettered messages.
+	// The QueueConfig arguments point to it unless they already define an x-dead-letter-exchange.
+	DeadLetterExchange *ExchangeConfig
+
+	// QueueMonitor enables, if set, the periodical inspection of the queue depth and consumer count, exposed through the
+	// client's HealthReport and the OnStats callback.
+	QueueMonitor *QueueMonitorConfig
+
+	// Ordering enables, if set, the sequential processing of deliveries sharing the same key while deliveries with
+	// different keys are processed concurrently. ConcurrentProcess is ignored when set.
+	Ordering *OrderingConfig
+
+	// Tag is the consumer tag of the subscription, identifying the consumer in the management UI. It is kept across
+	// channel recoveries and must be unique per queue. Defaults to "<name>@<hostname>".
+	Tag string
+
+	// AckStrategy decides, if set, what is done with each processed delivery. Defaults to DefaultAckStrategy with the
+	// MaxDeliveryAttempts. It is not used with AckModeManual.
+	AckStrategy AckStrategy
+
+	// Subscriptions defines additional queues consumed on the same channel, each with its own handlers, to reduce the
+	// number of channels. All other properties are shared with the main Queue.
+	Subscriptions []QueueSubscription
+
+	// OnRedelivery is called, if set, before the processing of each delivery flagged as redelivered by the broker. It can
+	// be used to log or count redeliveries. Handlers can also check the Redelivered flag through DeliveryFromContext.
+	OnRedelivery func(delivery Delivery)
+
+	// Decompress enables the decompression of payloads according to their ContentEncoding before calling the handlers.
+	// Payloads with an empty or unknown encoding are passed as is, and payloads that cannot be decompressed are
+	// dead-lettered.
+	Decompress bool
+
+	// Decompressors defines additional Decompressor functions by content encoding, overriding the default ones.
+	Decompressors map[string]Decompressor
+
+	// PoisonStore persists, if set, a capture of each delivery that is dead-lettered or discarded by the AckStrategy,
+	// with its headers, payload, error and attempt history.
+	PoisonStore PoisonStore
+
+	// PriorityScheduling enables, if set, the processing of the prefetched deliveries by order of priority across a fixed
+	// number of workers. ConcurrentProcess is ignored when set, and it cannot be combined with Ordering.
+	PriorityScheduling *PrioritySchedulingConfig
+
+	// Decoder decodes, if set, the payloads before calling the handlers, such as decrypting them. Deliveries that cannot
+	// be decoded are dead-lettered without calling the handler.
+	Decoder PayloadDecoder
+
+	// Validator validates, if set, the payloads before calling the handlers. Deliveries with an invalid payload are
+	// dead-lettered without calling the handler.
+	Validator PayloadValidator
+
+	// UnwrapEnvelopes unwraps the payloads wrapped in an Envelope by an EnvelopeMarshaller before calling the handlers.
+	// The Envelope is available through the Delivery of the handler context.
+	UnwrapEnvelopes bool
+
+	// ChunkTimeout is the maximum time to receive all the chunks of a message published in chunks, after which the
+	// received ones are dead-lettered if the consumer has a quarantine or a DeadLetterExchange, and requeued otherwise.
+	// Defaults to 1 minute.
+	ChunkTimeout time.Duration
+
+	// DecodeErrorPolicy defines what is done with the deliveries that cannot be decompressed, decoded, unwrapped or
+	// validated. Their handler is not called, and the error passed to the AckStrategy, the PoisonStore and the
+	// DecodeFallback is a *DecodeError. Defaults to DecodeErrorDeadLetter.
+	DecodeErrorPolicy DecodeErrorPolicy
+
+	// Marshaller decodes the payloads unmarshalled by the handlers through Delivery.Unmarshal. Defaults to the
+	// JSONMarshaller. If it is a ContentNegotiator, the content type it picks is exposed by the Delivery.
+	Marshaller Marshaller
+
+	// DecodeFallback handles, with the DecodeErrorFallback policy, the raw payload of the deliveries that cannot be
+	// decoded.
+	DecodeFallback DecodeFallbackHandler
+
+	// ContextDecorators decorate, in order, the context of the ContextHandlers of each delivery, after the
+	// ContextDecorators of the ClientOptions.
+	ContextDecorators []ContextDecorator
+
+	// AdaptivePrefetch enables, if set, the adjustment of the PrefetchCount to the observed handler latency and
+	// deliveries in flight. It cannot be combined with automatic acknowledgements.
+	AdaptivePrefetch *AdaptivePrefetchConfig
 }
 
-// matchesSuffixWildcard verifies that everything that comes before the '#' wildcard matches.
-func (mh MQTTMessageHandlers) matchesSuffixWildcard(storedWords, words []string) bool {
-	backCount := 2
+// ConsumerDefaults are the properties applied to the registered consumers that do not define them.
+type ConsumerDefaults struct {
+	// ConcurrentProcess enables, if true, the ConcurrentProcess of all the consumers.
+	ConcurrentProcess bool `yaml:"concurrent_process"`
 
-	// compareIndex starts before the wildcard in the storedWords array.
-	compareIndex := len(storedWords) - backCount
+	// MaxDeliveryAttempts is, if positive, the MaxDeliveryAttempts of the consumers that define none.
+	MaxDeliveryAttempts uint `yaml:"max_delivery_attempts"`
 
-	// we initialize the wordIdx at -1.
-	wordIdx := -1
+	// Retry is, if set, the Retry of the consumers that define none.
+	Retry *RetryConfig `yaml:"retry"`
 
-	// Here we are searching for the first occurrence of the first word before the '#' wildcard
-	// of the storedWords in the words.
-	for i, w := range words {
-		if w == storedWords[compareIndex] {
-			wordIdx = i
+	// ConsumeRateLimit is, if positive, the ConsumeRateLimit of the consumers that define none.
+	ConsumeRateLimit float64 `yaml:"consume_rate_limit"`
 
-			break
-		}
+	// AdaptivePrefetch is, if set, the AdaptivePrefetch of the consumers that define none and acknowledge their
+	// deliveries.
+	AdaptivePrefetch *AdaptivePrefetchConfig `yaml:"adaptive_prefetch"`
+}
+
+// apply applies the defaults to a consumer.
+func (d *ConsumerDefaults) apply(consumer *MessageConsumer) {
+	if d == nil {
+		return
 	}
 
-	// If we did not find the first word, then surely the key does not match.
-	if wordIdx == -1 {
-		return false
+	if d.ConcurrentProcess {
+		consumer.ConcurrentProcess = true
 	}
 
-	// If the indexes are not the same then surely the key does not match.
-	if compareIndex != wordIdx {
-		return false
+	if consumer.MaxDeliveryAttempts == 0 {
+		consumer.MaxDeliveryAttempts = d.MaxDeliveryAttempts
 	}
 
-	// Now we can compare, word by word, going backwards if the routing keys matches.
-	for i := wordIdx; i > -1; i-- {
-		// Be careful, if we find '*' then it should match no matter what.
-		if storedWords[compareIndex] != words[i] && storedWords[compareIndex] != "*" {
-			return false
-		}
+	if consumer.Retry == nil && d.Retry != nil {
+		retry := *d.Retry
+		consumer.Retry = &retry
+	}
 
-		// We move left in the storedWords.
-		compareIndex--
+	if consumer.ConsumeRateLimit == 0 {
+		consumer.ConsumeRateLimit = d.ConsumeRateLimit
 	}
 
-	return true
+	if consumer.AdaptivePrefetch == nil && d.AdaptivePrefetch != nil && !consumer.autoAck() {
+		adaptive := *d.AdaptivePrefetch
+		consumer.AdaptivePrefetch = &adaptive
+	}
 }
 
-// matchesSuffixWildcard verifies that 2 keys match word by word.
-func (mh MQTTMessageHandlers) matchesKey(storedWords, words []string) bool {
-	// If the lengths are not the same then surely the key does not match.
-	if len(storedWords) != len(words) {
-		return false
+// defaultConsumerTag returns a consumer tag made of the consumer name and the hostname, which usually identifies the
+// instance, or a random suffix if the hostname is not available.
+func defaultConsumerTag(name string) string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return fmt.Sprintf("%s_%s", name, uuid.NewString())
 	}
 
-	// Now we can compare, word by word if the routing keys matches.
-	for i, word := range words {
-		// Be careful, if we find '*' then it should match no matter what.
-		if storedWords[i] != word && storedWords[i] != "*" {
-			return false
-		}
+	return fmt.Sprintf("%s@%s", name, hostname)
+}
+
+// Validate reports all the problems of the consumer at once, as a joined error: invalid handlers, subscriptions, queue
+// properties and arguments, or incompatible properties such as a manual acknowledgement without PrefetchCount, which
+// would let the broker send the whole queue to the consumer.
+//
+//nolint:gocognit,gocyclo // The checks are independent of each other.
+func (c MessageConsumer) Validate() error {
+	var errs []error
+
+	if err := validateSubscriptions(c); err != nil {
+		errs = append(errs, err)
 	}
 
-	return true
-}
+	if err := c.Handlers.Validate(); err != nil {
+		errs = append(errs, err)
+	}
 
-func (mh MQTTMessageHandlers) FindFunc(routingKey string) MQTTMessageHandlerFunc {
-	// We first check for a direct match
-	if fn, found := mh[routingKey]; found {
-		return fn
+	if err := c.ContextHandlers.Validate(); err != nil {
+		errs = append(errs, err)
 	}
 
-	// Split the routing key into individual words.
-	words := strings.Split(routingKey, ".")
+	switch c.ackMode() {
+	case AckModeAuto, AckModeOnSuccess:
+	case AckModeManual:
+		if c.PrefetchCount == 0 {
+			errs = append(errs, fmt.Errorf("the consumer '%s' must define a prefetch count to acknowledge manually", c.Name))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("the consumer '%s' has an unknown ack mode '%s'", c.Name, c.AckMode))
+	}
 
-	// Check if any of the registered keys match the routing key.
-	for key, fn := range mh {
-		// Split the registered key into individual words.
-		storedWords := strings.Split(key, ".")
+	if c.PrefetchCount < 0 || c.PrefetchSize < 0 {
+		errs = append(errs, fmt.Errorf("the consumer '%s' cannot have a negative prefetch", c.Name))
+	}
 
-		//nolint: gocritic,nestif // We need this if-else block
-		if storedWords[0] == "#" {
-			if !mh.matchesPrefixWildcard(storedWords, words) {
-				continue
-			}
-		} else if storedWords[len(storedWords)-1] == "#" {
-			if !mh.matchesSuffixWildcard(storedWords, words) {
-				continue
-			}
-		} else {
-			if !mh.matchesKey(storedWords, words) {
-				continue
-			}
+	if c.StreamOffset != nil {
+		if err := validateStreamConsumer(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			errs = append(errs, err)
 		}
 
-		return fn
+		if c.RetryPolicy != nil {
+			errs = append(errs, fmt.Errorf("the consumer '%s' cannot define both a retry and a retry policy", c.Name))
+		}
 	}
 
-	// No matching keys were found.
-	return nil
-}
+	if err := validateConsumerTopology(c); err != nil {
+		errs = append(errs, err)
+	}
 
-// MessageConsumer holds all the information needed to consume messages.
-type MessageConsumer struct {
-	// Queue defines the queue from which we want to consume messages.
-	Queue string
+	if c.Ordering != nil && c.Ordering.Workers <= 0 {
+		errs = append(errs, fmt.Errorf("the consumer '%s' must define a positive number of ordering workers", c.Name))
+	}
 
-	// Name is a unique identifier of the consumer. Should be as explicit as possible.
-	Name string
+	if c.PriorityScheduling != nil {
+		if c.PriorityScheduling.Workers <= 0 {
+			errs = append(errs, fmt.Errorf("the consumer '%s' must define a positive number of priority workers", c.Name))
+		}
 
-	// PrefetchSize defines the max size of messages that are allowed to be processed at the same time.
-	// This property is dropped if AutoAck is set to true.
-	PrefetchSize int
+		if c.Ordering != nil {
+			errs = append(errs, fmt.Errorf("the consumer '%s' cannot combine ordering and priority scheduling", c.Name))
+		}
+	}
 
-	// PrefetchCount defines the max number of messages that are allowed to be processed at the same time.
-	// This property is dropped if AutoAck is set to true.
-	PrefetchCount int
+	if c.AckBatch != nil && c.AckBatch.Size <= 0 {
+		errs = append(errs, fmt.Errorf("the consumer '%s' must define a positive ack batch size", c.Name))
+	}
 
-	// AutoAck defines whether a message is directly acknowledged or not when being consumed.
-	AutoAck bool
+	if c.ConsumeRateLimit < 0 {
+		errs = append(errs, fmt.Errorf("the consumer '%s' cannot have a negative consume rate limit", c.Name))
+	}
 
-	// ConcurrentProcess will make MQTTMessageHandlers run concurrently for faster consumption, if set to true.
-	ConcurrentProcess bool
+	if c.AdaptivePrefetch != nil {
+		if err := c.AdaptivePrefetch.validate(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
 
-	// Handlers is the list of defined handlers.
-	Handlers MQTTMessageHandlers
+	return errors.Join(errs...)
 }
 
 // HashCode returns a unique identifier for the defined consumer.
 func (c MessageConsumer) HashCode() string {
 	return fmt.Sprintf("%s-%s", c.Queue, c.Name)
 }
+
+// ackMode returns the acknowledgement mode of the consumer, derived from AutoAck if AckMode is not set.
+func (c MessageConsumer) ackMode() AckMode {
+	if c.AckMode != "" {
+		return c.AckMode
+	}
+
+	if c.AutoAck {
+		return AckModeAuto
+	}
+
+	return AckModeOnSuccess
+}
+
+// chunkTimeout returns the ChunkTimeout, or the default one if not set.
+func (c MessageConsumer) chunkTimeout() time.Duration {
+	if c.ChunkTimeout <= 0 {
+		return defaultChunkTimeout
+	}
+
+	return c.ChunkTimeout
+}
+
+// marshaller returns the Marshaller, or the JSONMarshaller if none is set.
+func (c MessageConsumer) marshaller() Marshaller {
+	if c.Marshaller == nil {
+		return JSONMarshaller{}
+	}
+
+	return c.Marshaller
+}
+
+// autoAck returns true if deliveries are acknowledged upon reception.
+func (c MessageConsumer) autoAck() bool {
+	return c.ackMode() == AckModeAuto
+}
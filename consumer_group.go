@@ -0,0 +1,275 @@
+package gorabbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ConsumerGroupConfig defines a consumer group: a consistent-hash exchange partitioning the messages among shard
+// queues, each shard being consumed by a single member of the group at a time, as the partitions of a Kafka consumer
+// group. Messages with the same routing key, or HashHeader value, always reach the same shard and so the same member.
+type ConsumerGroupConfig struct {
+	// Name is the name of the group and of its exchange. Shards are named "<Name>-<index>".
+	Name string
+
+	// Shards is the number of shards, which bounds the number of members consuming at the same time.
+	Shards int
+
+	// HashHeader makes, if set, the exchange hash the value of this header instead of the routing key.
+	HashHeader string
+
+	// Type is the type of the shards, classic or quorum. Defaults to the broker's default type.
+	Type QueueType
+
+	// Args defines the additional arguments of each shard, such as "x-max-length".
+	Args map[string]interface{}
+
+	// RebalanceInterval is the delay between two checks of the balance of the shards among the members.
+	// Defaults to 30 seconds.
+	RebalanceInterval time.Duration
+}
+
+// ConsumerGroup returns the exchange and the shards of a consumer group, to be declared with the manager's
+// SetupTopology. The shards are single active consumer queues bound with the same weight to the exchange, which
+// requires the rabbitmq_consistent_hash_exchange plugin. The messages are published to the exchange named after the
+// group.
+func ConsumerGroup(config ConsumerGroupConfig) (ExchangeConfig, []QueueConfig, error) {
+	if err := config.validate(); err != nil {
+		return ExchangeConfig{}, nil, err
+	}
+
+	shards := make([]QueueConfig, 0, config.Shards)
+
+	for i := 0; i < config.Shards; i++ {
+		shards = append(shards, config.shard(i))
+	}
+
+	return ConsistentHashExchange(config.Name, true, config.HashHeader), shards, nil
+}
+
+// ConsumerGroupShard returns the name of the shard of a consumer group with the given index.
+func ConsumerGroupShard(group string, index int) string {
+	return fmt.Sprintf("%s-%d", group, index)
+}
+
+// validate returns an error if the group has no name or no shard, or shards that cannot have a single active consumer.
+func (g ConsumerGroupConfig) validate() error {
+	if g.Name == "" {
+		return errors.New("the consumer group name cannot be empty")
+	}
+
+	if g.Shards <= 0 {
+		return fmt.Errorf("the consumer group '%s' must have at least one shard", g.Name)
+	}
+
+	if g.Type == QueueTypeStream {
+		return fmt.Errorf("the shards of the consumer group '%s' cannot be streams", g.Name)
+	}
+
+	return nil
+}
+
+// shard returns the configuration of the shard with the given index.
+func (g ConsumerGroupConfig) shard(index int) QueueConfig {
+	return QueueConfig{
+		Name:                 ConsumerGroupShard(g.Name, index),
+		Durable:              true,
+		Type:                 g.Type,
+		Args:                 g.Args,
+		SingleActiveConsumer: true,
+		Bindings:             []BindingConfig{ConsistentHashBinding(g.Name, 1)},
+	}
+}
+
+// consumerHandOver is implemented by the clients whose single active consumers can hand their queue over to the next
+// consumer in line.
+type consumerHandOver interface {
+	handOverConsumer(ctx context.Context, name string) error
+}
+
+// ConsumerGroupMember is the membership of an instance in a consumer group, see JoinConsumerGroup.
+type ConsumerGroupMember struct {
+	config ConsumerGroupConfig
+
+	// names are the names of the consumers of the shards, by index.
+	names []string
+
+	// handOver hands a shard over to the next member in line, nil if the client cannot.
+	handOver func(ctx context.Context, name string) error
+
+	// mutex protects the fields below from concurrent access.
+	mutex       sync.Mutex
+	active      map[int]bool
+	members     int
+	handingOver bool
+}
+
+// JoinConsumerGroup registers a consumer of each shard of the group, named "<consumer name>-<index>" and built from the
+// given consumer, whose Queue and QueueConfig are replaced by the ones of the shard. The broker activates a single
+// consumer per shard, the consumers of the other members standing by to take over when it leaves.
+//
+// The members are counted as the consumers of the shards, inspected every RebalanceInterval. A member active on more
+// than its share of the shards hands the extra ones over, one per inspection, by subscribing again, last in line, once
+// the deliveries it received are processed. A shard is known as active once it delivered a message, so only the
+// shards receiving messages are rebalanced.
+//
+// The exchange of the group, see ConsumerGroup, must already exist. The shards are declared by their consumers.
+// Returns the problems of the group or of the consumers, see RegisterConsumer.
+func JoinConsumerGroup(client ConsumerRegistry, config ConsumerGroupConfig, consumer MessageConsumer) (*ConsumerGroupMember, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	if consumer.Name == "" {
+		return nil, fmt.Errorf("%w: the consumer of the group '%s' must have a name", errInvalidSubscription, config.Name)
+	}
+
+	member := &ConsumerGroupMember{
+		config: config,
+		names:  make([]string, config.Shards),
+		active: make(map[int]bool),
+	}
+
+	if handOver, ok := client.(consumerHandOver); ok {
+		member.handOver = handOver.handOverConsumer
+	}
+
+	for i := 0; i < config.Shards; i++ {
+		member.names[i] = fmt.Sprintf("%s-%d", consumer.Name, i)
+
+		if err := client.RegisterConsumer(member.consumer(consumer, i)); err != nil {
+			return member, err
+		}
+	}
+
+	return member, nil
+}
+
+// consumer returns the consumer of the shard with the given index, built from the given one.
+func (m *ConsumerGroupMember) consumer(consumer MessageConsumer, index int) MessageConsumer {
+	shard := m.config.shard(index)
+
+	consumer.Name = m.names[index]
+	consumer.Queue = shard.Name
+	consumer.QueueConfig = &shard
+	consumer.SingleActiveConsumer = true
+
+	if consumer.Tag != "" {
+		consumer.Tag = fmt.Sprintf("%s-%d", consumer.Tag, index)
+	}
+
+	onActiveStateChange := consumer.OnActiveStateChange
+
+	consumer.OnActiveStateChange = func(active bool) {
+		m.setActive(index, active)
+
+		if onActiveStateChange != nil {
+			onActiveStateChange(active)
+		}
+	}
+
+	monitor := QueueMonitorConfig{}
+	if consumer.QueueMonitor != nil {
+		monitor = *consumer.QueueMonitor
+	}
+
+	monitor.Interval = m.config.RebalanceInterval
+	if monitor.Interval <= 0 {
+		monitor.Interval = defaultRebalanceInterval
+	}
+
+	onStats := monitor.OnStats
+
+	monitor.OnStats = func(stats QueueStats) {
+		m.rebalance(index, stats.Consumers)
+
+		if onStats != nil {
+			onStats(stats)
+		}
+	}
+
+	consumer.QueueMonitor = &monitor
+
+	return consumer
+}
+
+// Shards returns the indexes of the shards this member is active on, in order.
+func (m *ConsumerGroupMember) Shards() []int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	shards := make([]int, 0, len(m.active))
+
+	for index := range m.active {
+		shards = append(shards, index)
+	}
+
+	sort.Ints(shards)
+
+	return shards
+}
+
+// Members returns the number of members of the group, as counted by the last inspection of a shard, 0 before the
+// first one.
+func (m *ConsumerGroupMember) Members() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.members
+}
+
+// setActive records whether the member is active on the shard with the given index.
+func (m *ConsumerGroupMember) setActive(index int, active bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if active {
+		m.active[index] = true
+	} else {
+		delete(m.active, index)
+	}
+}
+
+// rebalance records the member count inspected on the shard with the given index, and hands the shard over if the
+// member is active on it and on more than its share of the shards.
+func (m *ConsumerGroupMember) rebalance(index, consumers int) {
+	m.mutex.Lock()
+
+	if consumers > 0 {
+		m.members = consumers
+	}
+
+	members := m.members
+	if members == 0 {
+		members = 1
+	}
+
+	share := (m.config.Shards + members - 1) / members
+
+	handOver := m.handOver != nil && !m.handingOver && m.active[index] && len(m.active) > share
+	if handOver {
+		m.handingOver = true
+	}
+
+	m.mutex.Unlock()
+
+	if !handOver {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultHandOverTimeout)
+		defer cancel()
+
+		// A shard that could not be handed over is handed over by a later inspection.
+		_ = m.handOver(ctx, m.names[index])
+
+		m.mutex.Lock()
+		m.handingOver = false
+		m.mutex.Unlock()
+	}()
+}
@@ -0,0 +1,100 @@
+package gorabbit_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestConsumerGroup(t *testing.T) {
+	exchange, shards, err := gorabbit.ConsumerGroup(gorabbit.ConsumerGroupConfig{
+		Name:       "orders",
+		Shards:     3,
+		HashHeader: "customer",
+		Type:       gorabbit.QueueTypeQuorum,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, gorabbit.ConsistentHashExchange("orders", true, "customer"), exchange)
+
+	require.Len(t, shards, 3)
+
+	for i, shard := range shards {
+		assert.Equal(t, gorabbit.ConsumerGroupShard("orders", i), shard.Name)
+		assert.True(t, shard.Durable)
+		assert.True(t, shard.SingleActiveConsumer)
+		assert.Equal(t, gorabbit.QueueTypeQuorum, shard.Type)
+		assert.Equal(t, []gorabbit.BindingConfig{gorabbit.ConsistentHashBinding("orders", 1)}, shard.Bindings)
+		assert.NoError(t, shard.Validate())
+	}
+
+	assert.Equal(t, "orders-2", shards[2].Name)
+}
+
+func TestConsumerGroup_Invalid(t *testing.T) {
+	_, _, err := gorabbit.ConsumerGroup(gorabbit.ConsumerGroupConfig{Shards: 3})
+	assert.Error(t, err)
+
+	_, _, err = gorabbit.ConsumerGroup(gorabbit.ConsumerGroupConfig{Name: "orders"})
+	assert.Error(t, err)
+
+	_, _, err = gorabbit.ConsumerGroup(gorabbit.ConsumerGroupConfig{Name: "orders", Shards: 3, Type: gorabbit.QueueTypeStream})
+	assert.Error(t, err)
+
+	_, err = gorabbit.JoinConsumerGroup(&recordingRegistry{}, gorabbit.ConsumerGroupConfig{Name: "orders", Shards: 3}, gorabbit.MessageConsumer{
+		Handlers: gorabbit.MQTTMessageHandlers{"order.created": func([]byte) error { return nil }},
+	})
+	assert.Error(t, err)
+}
+
+func TestJoinConsumerGroup(t *testing.T) {
+	registry := &recordingRegistry{}
+
+	var inspected []gorabbit.QueueStats
+
+	member, err := gorabbit.JoinConsumerGroup(registry, gorabbit.ConsumerGroupConfig{
+		Name:              "orders",
+		Shards:            3,
+		RebalanceInterval: time.Second,
+	}, gorabbit.MessageConsumer{
+		Name:          "order_processor",
+		PrefetchCount: 10,
+		Handlers:      gorabbit.MQTTMessageHandlers{"order.created": func([]byte) error { return nil }},
+		QueueMonitor: &gorabbit.QueueMonitorConfig{
+			OnStats: func(stats gorabbit.QueueStats) { inspected = append(inspected, stats) },
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, registry.consumers, 3)
+
+	for i, consumer := range registry.consumers {
+		shard := gorabbit.ConsumerGroupShard("orders", i)
+
+		assert.Equal(t, "order_processor-"+strconv.Itoa(i), consumer.Name)
+		assert.Equal(t, shard, consumer.Queue)
+		assert.Equal(t, shard, consumer.QueueConfig.Name)
+		assert.True(t, consumer.SingleActiveConsumer)
+		assert.Equal(t, time.Second, consumer.QueueMonitor.Interval)
+	}
+
+	// The shards are tracked as the broker activates them.
+	registry.consumers[2].OnActiveStateChange(true)
+	registry.consumers[0].OnActiveStateChange(true)
+	registry.consumers[1].OnActiveStateChange(true)
+	registry.consumers[1].OnActiveStateChange(false)
+
+	assert.Equal(t, []int{0, 2}, member.Shards())
+
+	// The members are counted by the inspections of the shards, which are still passed along.
+	assert.Equal(t, 0, member.Members())
+
+	registry.consumers[0].QueueMonitor.OnStats(gorabbit.QueueStats{Queue: "orders-0", Consumers: 2})
+
+	assert.Equal(t, 2, member.Members())
+	assert.Len(t, inspected, 1)
+}
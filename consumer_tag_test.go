@@ -0,0 +1,43 @@
+package gorabbit_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_ConsumerTag(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{Tag: "events-worker-1"})
+
+	// The tag is kept by the subscription of the new channel.
+	server.closeChannel("events")
+
+	require.Eventually(t, func() bool { return len(server.consumerTags("events")) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"events-worker-1", "events-worker-1"}, server.consumerTags("events"))
+
+	report := client.HealthReport()
+	require.Len(t, report.Consumers, 1)
+	assert.Equal(t, "events-worker-1", report.Consumers[0].Tag)
+}
+
+func TestClient_ConsumerTag_Default(t *testing.T) {
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	server := newFakeServer(t)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{})
+
+	server.closeChannel("events")
+
+	// The default tag is made of the consumer name and the hostname, and is kept as well.
+	require.Eventually(t, func() bool { return len(server.consumerTags("events")) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"events@" + hostname, "events@" + hostname}, server.consumerTags("events"))
+}
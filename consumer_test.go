@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/KardinalAI/gorabbit"
 )
@@ -158,3 +159,64 @@ func TestMQTTMessageHandlers_FindFunc(t *testing.T) {
 		}
 	}
 }
+
+func TestMQTTMessageHandlers_FindFunc_Precedence(t *testing.T) {
+	var matched string
+
+	handler := func(key string) gorabbit.MQTTMessageHandlerFunc {
+		return func(_ []byte) error {
+			matched = key
+
+			return nil
+		}
+	}
+
+	handlers := gorabbit.MQTTMessageHandlers{
+		"event.user.created": handler("event.user.created"),
+		"event.*.created":    handler("event.*.created"),
+		"event.#":            handler("event.#"),
+		"#.toto":             handler("#.toto"),
+	}
+
+	tests := map[string]string{
+		"event.user.created":  "event.user.created",
+		"event.order.created": "event.*.created",
+		"event.order.deleted": "event.#",
+		"event":               "event.#",
+		"toto.space.toto":     "#.toto",
+	}
+
+	for routingKey, expected := range tests {
+		fn := handlers.FindFunc(routingKey)
+		require.NotNil(t, fn, routingKey)
+
+		require.NoError(t, fn(nil))
+		assert.Equal(t, expected, matched, routingKey)
+	}
+}
+
+func TestMessageConsumer_Validate(t *testing.T) {
+	consumer := gorabbit.MessageConsumer{
+		Queue:   "invoices_queue",
+		Name:    "invoices",
+		AckMode: gorabbit.AckModeManual,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"invoice.created": func([]byte) error { return nil },
+		},
+	}
+
+	err := consumer.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must define a prefetch count to acknowledge manually")
+
+	consumer.PrefetchCount = 10
+	require.NoError(t, consumer.Validate())
+
+	consumer.ConsumeRateLimit = -1
+	consumer.QueueConfig = &gorabbit.QueueConfig{Name: "invoices_queue", Type: gorabbit.QueueTypeStream}
+
+	err = consumer.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot have a negative consume rate limit")
+	assert.Contains(t, err.Error(), "the stream queue 'invoices_queue' must be durable")
+}
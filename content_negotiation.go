@@ -0,0 +1,82 @@
+package gorabbit
+
+import (
+	"strings"
+)
+
+// ContentNegotiator is implemented by the Marshaller picking how to decode a payload according to its content type. Set
+// as the Marshaller of a MessageConsumer, the content type it picks is exposed by the Delivery of the handler context.
+type ContentNegotiator interface {
+	// Negotiate returns the content type a payload of the given content type is decoded as.
+	Negotiate(contentType string) string
+}
+
+// ContentTypeMarshaller is a Marshaller picking the Marshaller of each payload by content type, falling back to the one
+// of the default content type for the unknown content types. Payloads are always encoded with the default one. It is
+// also a PayloadValidator and a PayloadDecoder, delegating to the picked Marshaller if it implements them.
+//
+// The marshallers must be registered before the ContentTypeMarshaller is used.
+type ContentTypeMarshaller struct {
+	defaultType string
+	marshallers map[string]Marshaller
+}
+
+// NewContentTypeMarshaller returns a ContentTypeMarshaller with the Marshaller of the default content type.
+func NewContentTypeMarshaller(defaultType string, marshaller Marshaller) *ContentTypeMarshaller {
+	return &ContentTypeMarshaller{
+		defaultType: mediaType(defaultType),
+		marshallers: map[string]Marshaller{mediaType(defaultType): marshaller},
+	}
+}
+
+// Register will assign the Marshaller of the payloads of the given content type, whose parameters are ignored.
+func (m *ContentTypeMarshaller) Register(contentType string, marshaller Marshaller) *ContentTypeMarshaller {
+	m.marshallers[mediaType(contentType)] = marshaller
+
+	return m
+}
+
+// Negotiate returns the media type of the content type if a Marshaller is registered for it, the default content type
+// otherwise.
+func (m *ContentTypeMarshaller) Negotiate(contentType string) string {
+	if _, found := m.marshallers[mediaType(contentType)]; found {
+		return mediaType(contentType)
+	}
+
+	return m.defaultType
+}
+
+// Marshal encodes the payload with the Marshaller of the default content type.
+func (m *ContentTypeMarshaller) Marshal(metadata *MessageMetadata, payload interface{}) ([]byte, error) {
+	return m.marshallers[m.defaultType].Marshal(metadata, payload)
+}
+
+// Unmarshal decodes the payload with the Marshaller of its content type.
+func (m *ContentTypeMarshaller) Unmarshal(metadata *MessageMetadata, data []byte, v interface{}) error {
+	return m.marshallers[m.Negotiate(metadata.ContentType)].Unmarshal(metadata, data, v)
+}
+
+// Validate validates the payload with the Marshaller of its content type, if it is a PayloadValidator.
+func (m *ContentTypeMarshaller) Validate(metadata *MessageMetadata, data []byte) error {
+	if validator, ok := m.marshallers[m.Negotiate(metadata.ContentType)].(PayloadValidator); ok {
+		return validator.Validate(metadata, data)
+	}
+
+	return nil
+}
+
+// Decode decodes the payload with the Marshaller of its content type, if it is a PayloadDecoder.
+func (m *ContentTypeMarshaller) Decode(metadata *MessageMetadata, data []byte) ([]byte, error) {
+	if decoder, ok := m.marshallers[m.Negotiate(metadata.ContentType)].(PayloadDecoder); ok {
+		return decoder.Decode(metadata, data)
+	}
+
+	return data, nil
+}
+
+// mediaType returns the lower-cased media type of a content type, without its parameters.
+func mediaType(contentType string) string {
+	media, _, _ := strings.Cut(contentType, ";")
+
+	return strings.ToLower(strings.TrimSpace(media))
+}
@@ -0,0 +1,42 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestContentTypeMarshaller(t *testing.T) {
+	marshaller := gorabbit.NewContentTypeMarshaller(gorabbit.ContentTypeJSON, gorabbit.JSONMarshaller{}).
+		Register("text/plain", rawMarshaller{})
+
+	assert.Equal(t, "text/plain", marshaller.Negotiate("Text/Plain; charset=utf-8"))
+	assert.Equal(t, gorabbit.ContentTypeJSON, marshaller.Negotiate("application/x-protobuf"))
+	assert.Equal(t, gorabbit.ContentTypeJSON, marshaller.Negotiate(""))
+
+	var decoded string
+
+	require.NoError(t, marshaller.Unmarshal(&gorabbit.MessageMetadata{ContentType: "text/plain"}, []byte("raw"), &decoded))
+	assert.Equal(t, "raw", decoded)
+
+	require.NoError(t, marshaller.Unmarshal(&gorabbit.MessageMetadata{}, []byte(`"json"`), &decoded))
+	assert.Equal(t, "json", decoded)
+
+	metadata := &gorabbit.MessageMetadata{}
+
+	data, err := marshaller.Marshal(metadata, "json")
+	require.NoError(t, err)
+
+	assert.Equal(t, `"json"`, string(data))
+	assert.Equal(t, gorabbit.ContentTypeJSON, metadata.ContentType)
+}
+
+func TestDelivery_Unmarshal(t *testing.T) {
+	var decoded map[string]int
+
+	require.NoError(t, gorabbit.Delivery{Body: []byte(`{"amount":10}`)}.Unmarshal(&decoded))
+	assert.Equal(t, 10, decoded["amount"])
+}
@@ -0,0 +1,73 @@
+package gorabbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ContextDecorator decorates the context passed to the context handlers of a delivery, such as to set a deadline, a
+// logger or a tenant read from the headers of the Delivery. The returned CancelFunc, if not nil, is called once the
+// handler returns.
+type ContextDecorator func(ctx context.Context, delivery Delivery) (context.Context, context.CancelFunc)
+
+// ContextValue returns a ContextDecorator setting the value under the key, such as a logger shared by the handlers.
+func ContextValue(key, value interface{}) ContextDecorator {
+	return func(ctx context.Context, _ Delivery) (context.Context, context.CancelFunc) {
+		return context.WithValue(ctx, key, value), nil
+	}
+}
+
+// ContextHeader returns a ContextDecorator setting the value of the header of the delivery under the key, if present.
+func ContextHeader(header string, key interface{}) ContextDecorator {
+	return func(ctx context.Context, delivery Delivery) (context.Context, context.CancelFunc) {
+		if value, found := delivery.Headers[header]; found {
+			return context.WithValue(ctx, key, value), nil
+		}
+
+		return ctx, nil
+	}
+}
+
+// ContextTimeout returns a ContextDecorator cancelling the context of the handlers after the timeout, with
+// ErrHandlerTimeout as its cause. The error of a handler failing once the timeout elapsed wraps ErrHandlerTimeout.
+func ContextTimeout(timeout time.Duration) ContextDecorator {
+	return func(ctx context.Context, _ Delivery) (context.Context, context.CancelFunc) {
+		return context.WithTimeoutCause(ctx, timeout, ErrHandlerTimeout)
+	}
+}
+
+// handlerError returns the error of a handler, wrapped with ErrHandlerTimeout if its context timed out because of
+// ContextTimeout.
+func handlerError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrHandlerTimeout) || !errors.Is(context.Cause(ctx), ErrHandlerTimeout) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrHandlerTimeout, err)
+}
+
+// DecorateContext applies the decorators to the context of the handlers of the delivery, in order, and returns the
+// decorated context along with the CancelFunc calling the ones of the decorators in reverse order.
+func DecorateContext(ctx context.Context, delivery Delivery, decorators ...ContextDecorator) (context.Context, context.CancelFunc) {
+	var cancels []context.CancelFunc
+
+	for _, decorator := range decorators {
+		if decorator == nil {
+			continue
+		}
+
+		var cancel context.CancelFunc
+
+		if ctx, cancel = decorator(ctx, delivery); cancel != nil {
+			cancels = append(cancels, cancel)
+		}
+	}
+
+	return ctx, func() {
+		for i := len(cancels) - 1; i >= 0; i-- {
+			cancels[i]()
+		}
+	}
+}
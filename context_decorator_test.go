@@ -0,0 +1,66 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type tenantKey struct{}
+
+type loggerKey struct{}
+
+func TestDecorateContext(t *testing.T) {
+	var cancelled []string
+
+	tracking := func(name string) gorabbit.ContextDecorator {
+		return func(ctx context.Context, _ gorabbit.Delivery) (context.Context, context.CancelFunc) {
+			return ctx, func() { cancelled = append(cancelled, name) }
+		}
+	}
+
+	delivery := gorabbit.Delivery{Headers: map[string]interface{}{"x-tenant": "acme"}}
+
+	ctx, cancel := gorabbit.DecorateContext(context.Background(), delivery,
+		gorabbit.ContextValue(loggerKey{}, "logger"),
+		gorabbit.ContextHeader("x-tenant", tenantKey{}),
+		gorabbit.ContextTimeout(time.Minute),
+		nil,
+		tracking("first"),
+		tracking("second"),
+	)
+
+	assert.Equal(t, "logger", ctx.Value(loggerKey{}))
+	assert.Equal(t, "acme", ctx.Value(tenantKey{}))
+
+	_, hasDeadline := ctx.Deadline()
+	assert.True(t, hasDeadline)
+
+	cancel()
+
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.Equal(t, []string{"second", "first"}, cancelled)
+}
+
+func TestContextTimeout_Cause(t *testing.T) {
+	ctx, cancel := gorabbit.DecorateContext(context.Background(), gorabbit.Delivery{},
+		gorabbit.ContextTimeout(time.Millisecond))
+	defer cancel()
+
+	<-ctx.Done()
+
+	assert.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	assert.ErrorIs(t, context.Cause(ctx), gorabbit.ErrHandlerTimeout)
+}
+
+func TestContextHeader_Missing(t *testing.T) {
+	ctx, cancel := gorabbit.DecorateContext(context.Background(), gorabbit.Delivery{},
+		gorabbit.ContextHeader("x-tenant", tenantKey{}))
+	defer cancel()
+
+	assert.Nil(t, ctx.Value(tenantKey{}))
+}
@@ -0,0 +1,116 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ContextEnricher returns values of the context of a publishing, such as a request ID, to propagate as headers of the
+// message. They are also added to the logs of the publishing.
+type ContextEnricher func(ctx context.Context) map[string]string
+
+// correlation propagates values of the context of the publishings through the headers of the messages, and restores
+// them into the context of the handlers.
+type correlation struct {
+	// keys are the context keys propagated, by header.
+	keys map[string]interface{}
+
+	// enricher returns additional values to propagate, by header.
+	enricher ContextEnricher
+}
+
+// newCorrelation returns a correlation propagating the given context keys, by header, and the values of the enricher.
+// Returns nil if nothing is propagated.
+func newCorrelation(keys map[string]interface{}, enricher ContextEnricher) *correlation {
+	if len(keys) == 0 && enricher == nil {
+		return nil
+	}
+
+	return &correlation{
+		keys:     keys,
+		enricher: enricher,
+	}
+}
+
+// inject sets the values of the context to propagate into the headers of a publishing, and returns them as log fields.
+func (c *correlation) inject(ctx context.Context, headers amqp.Table) []LogField {
+	if c == nil || ctx == nil {
+		return nil
+	}
+
+	values := make(map[string]string)
+
+	for header, key := range c.keys {
+		if value := ctx.Value(key); value != nil {
+			values[header] = fmt.Sprint(value)
+		}
+	}
+
+	if c.enricher != nil {
+		for header, value := range c.enricher(ctx) {
+			values[header] = value
+		}
+	}
+
+	for header, value := range values {
+		headers[header] = value
+	}
+
+	return sortedLogFields(values)
+}
+
+// restore returns the context of a handler holding the propagated values of the headers of a delivery, under their
+// context key.
+func (c *correlation) restore(ctx context.Context, headers amqp.Table) context.Context {
+	if c == nil {
+		return ctx
+	}
+
+	for header, key := range c.keys {
+		if value, ok := headers[header].(string); ok {
+			ctx = context.WithValue(ctx, key, value)
+		}
+	}
+
+	return ctx
+}
+
+// fields returns the propagated values of the headers of a delivery as log fields.
+func (c *correlation) fields(headers amqp.Table) []LogField {
+	if c == nil {
+		return nil
+	}
+
+	values := make(map[string]string)
+
+	for header := range c.keys {
+		if value, ok := headers[header].(string); ok {
+			values[header] = value
+		}
+	}
+
+	return sortedLogFields(values)
+}
+
+// deliveryLogFields returns the log fields identifying a delivery: its message ID and propagated values.
+func (c *amqpChannel) deliveryLogFields(delivery *amqp.Delivery) []LogField {
+	return append([]LogField{{Key: "messageID", Value: delivery.MessageId}}, c.correlation.fields(delivery.Headers)...)
+}
+
+// sortedLogFields returns values as log fields sorted by key.
+func sortedLogFields(values map[string]string) []LogField {
+	fields := make([]LogField, 0, len(values))
+
+	for key, value := range values {
+		fields = append(fields, LogField{Key: key, Value: value})
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+
+	return fields
+}
@@ -0,0 +1,45 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type requestIDKey struct{}
+
+func TestClientOptions_Correlation(t *testing.T) {
+	logger := new(recordingLogger)
+
+	// Nothing listens on the port, so the publishing fails and is logged.
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetLogger(logger).
+		SetLogLevel(gorabbit.LogLevelError).
+		SetCorrelationKey("x-request-id", requestIDKey{}).
+		SetContextEnricher(func(ctx context.Context) map[string]string {
+			return map[string]string{"x-tenant": "acme"}
+		}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "4f2a")
+
+	require.Error(t, client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", nil))
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	require.Contains(t, logger.messages, "Could not publish message, sending to cache")
+
+	fields := logger.fields[len(logger.fields)-1]
+
+	assert.Contains(t, fields, gorabbit.LogField{Key: "x-request-id", Value: "4f2a"})
+	assert.Contains(t, fields, gorabbit.LogField{Key: "x-tenant", Value: "acme"})
+}
@@ -0,0 +1,102 @@
+package gorabbit
+
+import (
+	"context"
+	"time"
+)
+
+// retryBudget splits the time left before the deadline of a context across the remaining attempts of a retry
+// mechanism, so that the attempts end before the deadline instead of overshooting it.
+type retryBudget struct {
+	deadline time.Time
+
+	// maxAttempts is the number of attempts of the RetryPolicy, 0 if unknown or unlimited.
+	maxAttempts uint
+}
+
+// newRetryBudget returns the retryBudget of the context for the attempts of the given RetryPolicy, nil if the context
+// has no deadline.
+func newRetryBudget(ctx context.Context, policy RetryPolicy) *retryBudget {
+	if ctx == nil {
+		return nil
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	return &retryBudget{deadline: deadline, maxAttempts: policyMaxAttempts(policy)}
+}
+
+// delay returns the delay to wait before the given attempt, starting at 1: the delay of the RetryPolicy, shortened to
+// the share of the remaining budget of the attempt. A share is kept for the last attempt itself, and without a known
+// number of attempts, each one is granted half of the remaining budget. Returns false if the budget is exhausted.
+func (b *retryBudget) delay(attempt uint, delay time.Duration) (time.Duration, bool) {
+	if b == nil {
+		return delay, true
+	}
+
+	remaining := time.Until(b.deadline)
+	if remaining <= 0 {
+		return 0, false
+	}
+
+	attemptsLeft := uint(1)
+	if b.maxAttempts >= attempt {
+		attemptsLeft = b.maxAttempts - attempt + 1
+	}
+
+	return min(delay, remaining/time.Duration(attemptsLeft+1)), true
+}
+
+// policyMaxAttempts returns the number of attempts of the RetryPolicy if it is a BoundedRetryPolicy, 0 if unknown or
+// unlimited.
+func policyMaxAttempts(policy RetryPolicy) uint {
+	if bounded, ok := policy.(BoundedRetryPolicy); ok {
+		return bounded.AttemptLimit()
+	}
+
+	return 0
+}
+
+// awaitRecovery waits, within the budget of the context, for the channel to be recovered, following the delays of its
+// recovery policy. Returns false if the channel is still not ready once the budget or the policy is exhausted, or
+// without a deadline.
+func (c *amqpChannel) awaitRecovery(ctx context.Context) bool {
+	budget := newRetryBudget(ctx, c.recovery)
+	if budget == nil {
+		return false
+	}
+
+	var delay time.Duration
+
+	for attempt := uint(1); c.recovery.ShouldRetry(attempt, errChannelClosed); attempt++ {
+		delay = c.recovery.NextDelay(attempt, delay)
+
+		wait, ok := budget.delay(attempt, delay)
+		if !ok {
+			return false
+		}
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return false
+		case <-c.ctx.Done():
+			timer.Stop()
+
+			return false
+		case <-timer.C:
+		}
+
+		if c.ready() {
+			return true
+		}
+	}
+
+	return false
+}
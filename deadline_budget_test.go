@@ -0,0 +1,64 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_PublishWithContext_DeadlineBudget(t *testing.T) {
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetKeepAlive(true).
+		SetRetryDelay(time.Hour))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// Nothing listens on the port, so the publishing waits for the recovery of the channel within its budget, instead
+	// of the RetryDelay.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	require.Error(t, client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", nil))
+
+	elapsed := time.Since(start)
+
+	assert.Greater(t, elapsed, 100*time.Millisecond)
+	assert.Less(t, elapsed, 400*time.Millisecond)
+
+	// Without a deadline, the publishing fails right away.
+	start = time.Now()
+
+	require.Error(t, client.PublishWithContext(context.Background(), "events_exchange", "event.created", "payload", nil))
+
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestClient_PublishWithContext_DeadlineBudget_MaxAttempts(t *testing.T) {
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetKeepAlive(true).
+		SetRecoveryPolicy(gorabbit.ConstantRetryPolicy{Delay: time.Hour, MaxAttempts: 3}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// The budget is split across the attempts of the policy, the last one keeping its own share.
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	require.Error(t, client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", nil))
+
+	elapsed := time.Since(start)
+
+	assert.Greater(t, elapsed, 200*time.Millisecond)
+	assert.Less(t, elapsed, 400*time.Millisecond)
+}
@@ -0,0 +1,96 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DecodeStage is the step of the decoding of a delivery, before its handler is called.
+type DecodeStage string
+
+const (
+	// DecodeStageDecompress is the decompression of the payload according to its content encoding.
+	DecodeStageDecompress DecodeStage = "decompress"
+
+	// DecodeStageDecode is the decoding of the payload by the Decoder of the consumer.
+	DecodeStageDecode DecodeStage = "decode"
+
+	// DecodeStageUnwrap is the unwrapping of the payload from its Envelope.
+	DecodeStageUnwrap DecodeStage = "unwrap"
+
+	// DecodeStageValidate is the validation of the payload by the Validator of the consumer.
+	DecodeStageValidate DecodeStage = "validate"
+)
+
+// DecodeError is the failure of the decoding of a delivery, telling it apart from the failures of its handler.
+type DecodeError struct {
+	// Stage is the step of the decoding that failed.
+	Stage DecodeStage
+
+	// Err is the error of the step.
+	Err error
+}
+
+// Error returns the message of the DecodeError.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("could not %s delivery: %s", e.Stage, e.Err)
+}
+
+// Unwrap returns the error of the step.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is ErrDecode, so that any DecodeError matches it with errors.Is.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// DecodeErrorPolicy defines what is done with the deliveries that cannot be decoded.
+type DecodeErrorPolicy uint8
+
+const (
+	// DecodeErrorDeadLetter dead-letters the delivery. If the consumer has a quarantine queue, the copy of the delivery
+	// carries the DecodeError in its x-decode-error header.
+	DecodeErrorDeadLetter DecodeErrorPolicy = iota
+
+	// DecodeErrorDiscard acknowledges the delivery and drops it.
+	DecodeErrorDiscard
+
+	// DecodeErrorFallback calls the DecodeFallback of the consumer with the raw payload, its result being handled like
+	// the one of a handler. Deliveries are dead-lettered if the consumer has no DecodeFallback.
+	DecodeErrorFallback
+)
+
+// DecodeFallbackHandler handles the raw payload of a delivery that could not be decoded.
+type DecodeFallbackHandler func(ctx context.Context, payload []byte, err *DecodeError) error
+
+// handleDecodeError applies the DecodeErrorPolicy of the consumer to a delivery that could not be decoded.
+func (c *amqpChannel) handleDecodeError(delivery *amqp.Delivery, info Delivery, alreadyAcknowledged bool, stage DecodeStage, err error) {
+	decodeErr := &DecodeError{Stage: stage, Err: err}
+
+	c.logger.Error(decodeErr, "Could not decode delivery", c.deliveryLogFields(delivery)...)
+
+	switch c.consumer.DecodeErrorPolicy {
+	case DecodeErrorDiscard:
+		c.handleResult(delivery, info, alreadyAcknowledged, Discard(decodeErr))
+	case DecodeErrorFallback:
+		if c.consumer.DecodeFallback == nil {
+			c.handleResult(delivery, info, alreadyAcknowledged, DeadLetter(decodeErr))
+
+			return
+		}
+
+		ctx := c.correlation.restore(ContextWithDelivery(c.consumptionContext(), info), delivery.Headers)
+
+		_, err = c.callHandler(ctx, func(ctx context.Context, payload []byte) error {
+			return c.consumer.DecodeFallback(ctx, payload, decodeErr)
+		}, delivery.Body)
+
+		c.handleResult(delivery, info, alreadyAcknowledged, err)
+	default:
+		c.handleResult(delivery, info, alreadyAcknowledged, DeadLetter(decodeErr))
+	}
+}
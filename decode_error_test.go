@@ -0,0 +1,25 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestDecodeError(t *testing.T) {
+	cause := errors.New("unexpected end of JSON input")
+
+	err := fmt.Errorf("wrapped: %w", &gorabbit.DecodeError{Stage: gorabbit.DecodeStageValidate, Err: cause})
+
+	var decodeErr *gorabbit.DecodeError
+
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, gorabbit.DecodeStageValidate, decodeErr.Stage)
+	assert.ErrorIs(t, err, cause)
+	assert.ErrorIs(t, err, gorabbit.ErrDecode)
+	assert.Equal(t, "could not validate delivery: unexpected end of JSON input", decodeErr.Error())
+}
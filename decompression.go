@@ -0,0 +1,98 @@
+package gorabbit
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Content encodings supported by default.
+const (
+	ContentEncodingGzip    = "gzip"
+	ContentEncodingDeflate = "deflate"
+	ContentEncodingZstd    = "zstd"
+)
+
+// Decompressor decompresses a payload encoded with a given content encoding.
+type Decompressor func(payload []byte) ([]byte, error)
+
+// defaultDecompressors holds the Decompressor of each content encoding supported by default.
+var defaultDecompressors = map[string]Decompressor{
+	ContentEncodingGzip:    decompressGzip,
+	ContentEncodingDeflate: decompressDeflate,
+	ContentEncodingZstd:    decompressZstd,
+}
+
+// decompressGzip decompresses a gzip payload.
+func decompressGzip(payload []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// decompressDeflate decompresses a deflate payload, either wrapped in the zlib format, as in HTTP, or raw.
+func decompressDeflate(payload []byte) ([]byte, error) {
+	if reader, err := zlib.NewReader(bytes.NewReader(payload)); err == nil {
+		defer reader.Close()
+
+		return io.ReadAll(reader)
+	}
+
+	reader := flate.NewReader(bytes.NewReader(payload))
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// zstdDecoder is the shared zstd decoder, safe for concurrent use with DecodeAll.
+var zstdDecoder, _ = zstd.NewReader(nil)
+
+// decompressZstd decompresses a zstd payload.
+func decompressZstd(payload []byte) ([]byte, error) {
+	return zstdDecoder.DecodeAll(payload, nil)
+}
+
+// decompressor returns the Decompressor of the given content encoding, looking into the consumer's Decompressors first.
+func (c MessageConsumer) decompressor(encoding string) Decompressor {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+
+	if decompressor, found := c.Decompressors[encoding]; found {
+		return decompressor
+	}
+
+	return defaultDecompressors[encoding]
+}
+
+// decompress returns the payload of the delivery, decompressed according to its ContentEncoding if the consumer enables
+// the decompression. Payloads with an unknown or empty encoding are returned as is.
+func (c *amqpChannel) decompress(delivery *amqp.Delivery) ([]byte, error) {
+	if !c.consumer.Decompress || delivery.ContentEncoding == "" {
+		return delivery.Body, nil
+	}
+
+	decompressor := c.consumer.decompressor(delivery.ContentEncoding)
+	if decompressor == nil {
+		c.logger.Debug("Unknown content encoding, payload left as is", LogField{Key: "contentEncoding", Value: delivery.ContentEncoding})
+
+		return delivery.Body, nil
+	}
+
+	payload, err := decompressor(delivery.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not decompress '%s' payload: %w", delivery.ContentEncoding, err)
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,116 @@
+package gorabbit_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// compress returns the payload compressed by the writer.
+func compress(t *testing.T, payload string, newWriter func(io.Writer) io.WriteCloser) string {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	writer := newWriter(&buffer)
+
+	_, err := writer.Write([]byte(payload))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buffer.String()
+}
+
+func TestClient_Decompress(t *testing.T) {
+	server := newFakeServer(t)
+
+	received := make(chan string, 10)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		Decompress: true,
+		Decompressors: map[string]gorabbit.Decompressor{
+			"reversed": func(payload []byte) ([]byte, error) {
+				reversed := make([]byte, len(payload))
+
+				for i, b := range payload {
+					reversed[len(payload)-1-i] = b
+				}
+
+				return reversed, nil
+			},
+		},
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				received <- string(payload)
+
+				return nil
+			},
+		},
+	})
+
+	gzipped := compress(t, "gzip", func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+	zlibbed := compress(t, "zlib", func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })
+	deflated := compress(t, "deflate", func(w io.Writer) io.WriteCloser {
+		writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+
+		return writer
+	})
+	zstded := compress(t, "zstd", func(w io.Writer) io.WriteCloser {
+		writer, _ := zstd.NewWriter(w)
+
+		return writer
+	})
+
+	server.deliverMessages("events",
+		fakeDelivery{RoutingKey: "event.created", Body: gzipped, ContentEncoding: "gzip"},
+		fakeDelivery{RoutingKey: "event.created", Body: zlibbed, ContentEncoding: "deflate"},
+		fakeDelivery{RoutingKey: "event.created", Body: deflated, ContentEncoding: " Deflate "},
+		fakeDelivery{RoutingKey: "event.created", Body: zstded, ContentEncoding: "zstd"},
+		fakeDelivery{RoutingKey: "event.created", Body: "desrever", ContentEncoding: "reversed"},
+		fakeDelivery{RoutingKey: "event.created", Body: "plain"},
+		fakeDelivery{RoutingKey: "event.created", Body: "unknown", ContentEncoding: "br"},
+		fakeDelivery{RoutingKey: "event.created", Body: "corrupted", ContentEncoding: "gzip"},
+	)
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 8 }, time.Second, 10*time.Millisecond)
+
+	// The payloads are decompressed, unless their encoding is empty or unknown.
+	assert.ElementsMatch(t, []string{"gzip", "zlib", "deflate", "zstd", "reversed", "plain", "unknown"}, receiveAll(t, received, 7))
+
+	// The payload that cannot be decompressed is dead-lettered without calling the handler.
+	assert.Contains(t, server.settled(), fakeSettlement{Body: "corrupted"})
+	assert.Empty(t, received)
+}
+
+func TestClient_Decompress_Disabled(t *testing.T) {
+	server := newFakeServer(t)
+
+	received := make(chan string, 10)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				received <- string(payload)
+
+				return nil
+			},
+		},
+	})
+
+	gzipped := compress(t, "gzip", func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })
+
+	server.deliverMessages("events", fakeDelivery{RoutingKey: "event.created", Body: gzipped, ContentEncoding: "gzip"})
+
+	// The payload is passed as is.
+	assert.Equal(t, []string{gzipped}, receiveAll(t, received, 1))
+}
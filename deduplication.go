@@ -0,0 +1,134 @@
+package gorabbit
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DeduplicationStore keeps track of the MessageId of already processed deliveries.
+// Implementations must be safe for concurrent use.
+type DeduplicationStore interface {
+	// Contains returns true if the given id was already processed and has not expired yet.
+	Contains(ctx context.Context, id string) (bool, error)
+
+	// Add marks the given id as processed for the given ttl.
+	Add(ctx context.Context, id string, ttl time.Duration) error
+}
+
+// DeduplicationConfig enables the deduplication of deliveries based on their MessageId.
+type DeduplicationConfig struct {
+	// Store keeps track of the processed ids. Defaults to an in-memory LRU store if not set.
+	Store DeduplicationStore
+
+	// Window defines how long a processed id is remembered.
+	Window time.Duration
+}
+
+// memoryDeduplicationEntry is an entry of the memoryDeduplicationStore.
+type memoryDeduplicationEntry struct {
+	id        string
+	expiresAt time.Time
+}
+
+// memoryDeduplicationStore is an in-memory LRU DeduplicationStore.
+type memoryDeduplicationStore struct {
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	mutex   sync.Mutex
+}
+
+// NewMemoryDeduplicationStore returns an in-memory DeduplicationStore that remembers at most size ids, evicting the
+// least recently used ones first.
+func NewMemoryDeduplicationStore(size int) DeduplicationStore {
+	return &memoryDeduplicationStore{
+		size:    size,
+		entries: make(map[string]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+func (s *memoryDeduplicationStore) Contains(_ context.Context, id string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	element, found := s.entries[id]
+	if !found {
+		return false, nil
+	}
+
+	entry, _ := element.Value.(*memoryDeduplicationEntry)
+
+	// If the entry expired, we remove it.
+	if time.Now().After(entry.expiresAt) {
+		s.order.Remove(element)
+		delete(s.entries, id)
+
+		return false, nil
+	}
+
+	s.order.MoveToFront(element)
+
+	return true, nil
+}
+
+func (s *memoryDeduplicationStore) Add(_ context.Context, id string, ttl time.Duration) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if element, found := s.entries[id]; found {
+		entry, _ := element.Value.(*memoryDeduplicationEntry)
+		entry.expiresAt = time.Now().Add(ttl)
+
+		s.order.MoveToFront(element)
+
+		return nil
+	}
+
+	s.entries[id] = s.order.PushFront(&memoryDeduplicationEntry{id: id, expiresAt: time.Now().Add(ttl)})
+
+	// If the store is full, we evict the least recently used entry.
+	if s.size > 0 && s.order.Len() > s.size {
+		oldest := s.order.Back()
+
+		entry, _ := oldest.Value.(*memoryDeduplicationEntry)
+
+		s.order.Remove(oldest)
+		delete(s.entries, entry.id)
+	}
+
+	return nil
+}
+
+// isDuplicate returns true if the delivery was already processed according to the consumer's DeduplicationConfig.
+func (c *amqpChannel) isDuplicate(delivery *amqp.Delivery) bool {
+	if c.consumer.Deduplication == nil || delivery.MessageId == "" {
+		return false
+	}
+
+	found, err := c.consumer.Deduplication.Store.Contains(c.consumptionContext(), delivery.MessageId)
+	if err != nil {
+		// If the store is unavailable, we prefer processing the delivery twice rather than losing it.
+		c.logger.Error(err, "Could not check delivery deduplication", LogField{Key: "messageID", Value: delivery.MessageId})
+
+		return false
+	}
+
+	return found
+}
+
+// markProcessed registers the delivery as processed in the consumer's DeduplicationConfig store.
+func (c *amqpChannel) markProcessed(delivery *amqp.Delivery) {
+	if c.consumer.Deduplication == nil || delivery.MessageId == "" {
+		return
+	}
+
+	err := c.consumer.Deduplication.Store.Add(c.consumptionContext(), delivery.MessageId, c.consumer.Deduplication.Window)
+	if err != nil {
+		c.logger.Error(err, "Could not mark delivery as processed", LogField{Key: "messageID", Value: delivery.MessageId})
+	}
+}
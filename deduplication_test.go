@@ -0,0 +1,43 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMemoryDeduplicationStore(t *testing.T) {
+	ctx := context.Background()
+
+	store := gorabbit.NewMemoryDeduplicationStore(2)
+
+	require.NoError(t, store.Add(ctx, "first", time.Minute))
+	require.NoError(t, store.Add(ctx, "second", time.Minute))
+
+	found, err := store.Contains(ctx, "first")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// "second" is now the least recently used id and must be evicted.
+	require.NoError(t, store.Add(ctx, "third", time.Minute))
+
+	found, err = store.Contains(ctx, "second")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	found, err = store.Contains(ctx, "third")
+	require.NoError(t, err)
+	assert.True(t, found)
+
+	// Expired ids are not found anymore.
+	require.NoError(t, store.Add(ctx, "expired", -time.Second))
+
+	found, err = store.Contains(ctx, "expired")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
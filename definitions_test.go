@@ -0,0 +1,55 @@
+package gorabbit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+const definitions = `{
+	"queues": [{
+		"name": "events",
+		"durable": true,
+		"arguments": {"x-message-ttl": 60000, "x-dead-letter-exchange": "events.dlx"}
+	}],
+	"exchanges": [{
+		"name": "events",
+		"type": "headers",
+		"durable": true,
+		"arguments": {"alternate-exchange": "unrouted"}
+	}],
+	"bindings": [{
+		"source": "events",
+		"destination": "events",
+		"destination_type": "queue",
+		"routing_key": "",
+		"arguments": {"x-match": "all", "version": 2}
+	}]
+}`
+
+func TestMQTTManager_SetupFromDefinitions_Arguments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "definitions.json")
+	require.NoError(t, os.WriteFile(path, []byte(definitions), 0o600))
+
+	server := newFakeServer(t)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	require.NoError(t, manager.SetupFromDefinitions(path))
+
+	// The arguments are declared, the JSON numbers being coerced into integers as expected by the broker.
+	assert.Equal(t, []fakeDeclaration{
+		{Kind: "queue", Name: "events", Arguments: amqp.Table{"x-message-ttl": int64(60000), "x-dead-letter-exchange": "events.dlx"}},
+		{Kind: "exchange", Name: "events", RoutingKey: "headers", Arguments: amqp.Table{"alternate-exchange": "unrouted"}},
+		{Kind: "binding", Name: "events", Exchange: "events", Arguments: amqp.Table{"x-match": "all", "version": int64(2)}},
+	}, server.declarations())
+}
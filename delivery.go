@@ -0,0 +1,175 @@
+package gorabbit
+
+import (
+	"context"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// deliveryContextKey is the context key under which the Delivery is stored.
+type deliveryContextKey struct{}
+
+// Delivery holds the information of a consumed message. It is passed to MQTTMessageContextHandlers through their
+// context and can be retrieved with DeliveryFromContext.
+type Delivery struct {
+	// Queue is the queue the delivery was consumed from.
+	Queue string
+
+	// ConsumerTag is the tag of the consumer that received the delivery.
+	ConsumerTag string
+
+	// Exchange is the exchange the message was published to.
+	Exchange string
+
+	// RoutingKey is the routing key the message was published with.
+	RoutingKey string
+
+	// MessageID is the unique identifier of the message.
+	MessageID string
+
+	// CorrelationID is the correlation identifier of the message.
+	CorrelationID string
+
+	// ReplyTo is the queue the replies to the message are expected on, if any.
+	ReplyTo string
+
+	// ContentType is the MIME content type of the payload.
+	ContentType string
+
+	// NegotiatedContentType is the content type the payload is unmarshalled as, if the Marshaller of the consumer is a
+	// ContentNegotiator.
+	NegotiatedContentType string
+
+	// ContentEncoding is the MIME content encoding of the payload.
+	ContentEncoding string
+
+	// Priority is the priority of the message.
+	Priority uint8
+
+	// Redelivered is true if the message was already delivered at least once.
+	Redelivered bool
+
+	// Timestamp is the time the message was published at.
+	Timestamp time.Time
+
+	// Headers holds the message headers.
+	Headers map[string]interface{}
+
+	// Body is the message payload.
+	Body []byte
+
+	// Envelope is the Envelope of the message, if the consumer unwraps envelopes and the message has one.
+	Envelope *Envelope
+
+	// Attempts is the number of times the delivery was already attempted, based on its x-death, x-delivery-count and
+	// x-retry-attempt headers.
+	Attempts uint
+
+	// acknowledger is the native delivery, used for manual acknowledgement.
+	acknowledger *amqp.Delivery
+
+	// manualAck is true if the consumer uses the AckModeManual.
+	manualAck bool
+
+	// marshaller is the Marshaller of the consumer.
+	marshaller Marshaller
+
+	// channel is the channel the delivery was consumed from, used to forward it.
+	channel *amqpChannel
+}
+
+// newDelivery builds a Delivery from a native amqp.Delivery consumed by the given consumer.
+func newDelivery(consumer *MessageConsumer, delivery *amqp.Delivery) Delivery {
+	info := Delivery{
+		Queue:           consumer.Queue,
+		ConsumerTag:     delivery.ConsumerTag,
+		Exchange:        delivery.Exchange,
+		RoutingKey:      originalRoutingKey(delivery),
+		MessageID:       delivery.MessageId,
+		CorrelationID:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		Priority:        delivery.Priority,
+		Redelivered:     delivery.Redelivered,
+		Timestamp:       delivery.Timestamp,
+		Headers:         delivery.Headers,
+		Body:            delivery.Body,
+		Attempts:        deliveryAttempts(delivery, consumer.Queue),
+		acknowledger:    delivery,
+		manualAck:       consumer.ackMode() == AckModeManual,
+		marshaller:      consumer.marshaller(),
+	}
+
+	if negotiator, ok := info.marshaller.(ContentNegotiator); ok {
+		info.NegotiatedContentType = negotiator.Negotiate(delivery.ContentType)
+	}
+
+	return info
+}
+
+// Ack acknowledges the delivery. Only available to consumers using the AckModeManual.
+func (d Delivery) Ack() error {
+	if !d.manualAck {
+		return errManualAckDisabled
+	}
+
+	return d.acknowledger.Ack(false)
+}
+
+// Nack negative acknowledges the delivery, with or without requeue. Only available to consumers using the AckModeManual.
+func (d Delivery) Nack(requeue bool) error {
+	if !d.manualAck {
+		return errManualAckDisabled
+	}
+
+	return d.acknowledger.Nack(false, requeue)
+}
+
+// StreamOffset returns the offset of the delivery in its stream, if it was consumed from a stream queue.
+func (d Delivery) StreamOffset() (int64, bool) {
+	offset, ok := d.Headers[argStreamOffset].(int64)
+
+	return offset, ok
+}
+
+// Metadata returns the MessageMetadata of the delivery, to unmarshal its payload.
+func (d Delivery) Metadata() *MessageMetadata {
+	metadata := &MessageMetadata{
+		Exchange:    d.Exchange,
+		RoutingKey:  d.RoutingKey,
+		Queue:       d.Queue,
+		ContentType: d.ContentType,
+		Headers:     d.Headers,
+	}
+
+	if d.acknowledger != nil {
+		metadata.Type = d.acknowledger.Type
+	}
+
+	return metadata
+}
+
+// Unmarshal decodes the payload of the delivery into v with the Marshaller of the consumer.
+func (d Delivery) Unmarshal(v interface{}) error {
+	marshaller := d.marshaller
+	if marshaller == nil {
+		marshaller = JSONMarshaller{}
+	}
+
+	return marshaller.Unmarshal(d.Metadata(), d.Body, v)
+}
+
+// DeliveryFromContext returns the Delivery held by a handler context.
+func DeliveryFromContext(ctx context.Context) (Delivery, bool) {
+	delivery, ok := ctx.Value(deliveryContextKey{}).(Delivery)
+
+	return delivery, ok
+}
+
+// ContextWithDelivery returns a copy of the parent context holding the given Delivery, as passed to the
+// MQTTMessageContextHandlers. It allows calling the handlers in tests, see gorabbittest.MockClient.
+func ContextWithDelivery(parent context.Context, delivery Delivery) context.Context {
+	return context.WithValue(parent, deliveryContextKey{}, delivery)
+}
@@ -0,0 +1,145 @@
+package gorabbit
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// DrainReport holds the outcome of a Drain operation.
+type DrainReport struct {
+	// Consumers is the number of drained consumers.
+	Consumers int
+
+	// InFlight is the number of deliveries whose processing was awaited.
+	InFlight int
+
+	// Requeued is the number of received deliveries that were negative acknowledged with requeue before being processed.
+	Requeued int
+
+	// Published is the number of publishings buffered by the PublishingPipeline whose confirmation was awaited.
+	Published int
+}
+
+// add merges another report into the current one.
+func (r *DrainReport) add(other DrainReport) {
+	r.Consumers += other.Consumers
+	r.InFlight += other.InFlight
+	r.Requeued += other.Requeued
+	r.Published += other.Published
+}
+
+// isDraining returns true if the channel is being drained.
+func (c *amqpChannel) isDraining() bool {
+	return c.draining.Load()
+}
+
+// drain stops the consumption of the channel, waits for the processing of the in-flight deliveries and requeues the
+// deliveries that were received but not processed yet.
+// Once drained, the channel does not consume anymore, even after a recovery.
+func (c *amqpChannel) drain(ctx context.Context) (DrainReport, error) {
+	report := DrainReport{Consumers: 1}
+
+	c.draining.Store(true)
+
+	c.consumptionMutex.Lock()
+	consumerTag, consumptionDone := c.consumerTag, c.consumptionDone
+	c.consumptionMutex.Unlock()
+
+	// If the consumption never started, there is nothing to drain.
+	if consumptionDone == nil {
+		return report, nil
+	}
+
+	// We cancel the subscription, the broker stops sending deliveries and the ones already received are requeued by the
+	// consumption loop.
+	if c.ready() {
+		if err := c.channel.Load().Cancel(consumerTag, false); err != nil {
+			c.logger.Error(err, "Could not cancel consumption")
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return report, ctx.Err()
+	case <-consumptionDone:
+	}
+
+	inFlight, requeued := c.inFlightCount.Load(), c.requeuedCount.Load()
+
+	report.InFlight = int(inFlight)
+	report.Requeued = int(requeued)
+
+	// We wait for the in-flight deliveries to be processed.
+	processed := make(chan struct{})
+
+	go func() {
+		c.inFlight.Wait()
+		close(processed)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return report, ctx.Err()
+	case <-processed:
+	}
+
+	// The in-flight deliveries still queued by a dispatcher were requeued rather than processed.
+	pending := c.requeuedCount.Load() - requeued
+
+	report.InFlight = int(inFlight - pending)
+	report.Requeued = int(requeued + pending)
+
+	c.flushAckBatch()
+
+	// The consumption context is finally canceled to stop the remaining background operations.
+	c.cancelConsumption()
+
+	c.logger.Info(
+		"Consumer drained",
+		LogField{Key: "inFlight", Value: report.InFlight},
+		LogField{Key: "requeued", Value: report.Requeued},
+	)
+
+	return report, nil
+}
+
+// drain drains all consumer channels of the connection concurrently.
+func (a *amqpConnection) drain(ctx context.Context) (DrainReport, error) {
+	var report DrainReport
+
+	var errs []error
+
+	var mutex sync.Mutex
+
+	var wg sync.WaitGroup
+
+	for _, parent := range a.channelList() {
+		if parent.consumer == nil {
+			continue
+		}
+
+		for _, channel := range parent.consumerChannels() {
+			wg.Add(1)
+
+			go func(channel *amqpChannel) {
+				defer wg.Done()
+
+				channelReport, err := channel.drain(ctx)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				report.add(channelReport)
+
+				if err != nil {
+					errs = append(errs, err)
+				}
+			}(channel)
+		}
+	}
+
+	wg.Wait()
+
+	return report, errors.Join(errs...)
+}
@@ -0,0 +1,119 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// blockingHandler is a handler holding each delivery until released.
+type blockingHandler struct {
+	started chan string
+	release chan struct{}
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{started: make(chan string, 10), release: make(chan struct{})}
+}
+
+func (h *blockingHandler) handle(payload []byte) error {
+	h.started <- string(payload)
+
+	<-h.release
+
+	return nil
+}
+
+// drainWithDispatcher consumes three deliveries with the consumer, the first one being processed and the others
+// queued by its dispatcher when the client is drained.
+func drainWithDispatcher(t *testing.T, consumer gorabbit.MessageConsumer) (gorabbit.DrainReport, []fakeSettlement) {
+	t.Helper()
+
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()))
+
+	defer func() { _ = client.Disconnect() }()
+
+	handler := newBlockingHandler()
+
+	consumer.Queue, consumer.Name, consumer.PrefetchCount = "events", "events", 10
+	consumer.Handlers = gorabbit.MQTTMessageHandlers{"event.created": handler.handle}
+
+	require.NoError(t, client.RegisterConsumer(consumer))
+	require.Eventually(t, func() bool { return server.consumed("events") }, time.Second, 10*time.Millisecond)
+
+	server.deliver("events", "event.created", "first", "second", "third")
+
+	assert.Equal(t, "first", <-handler.started)
+
+	require.Eventually(t, func() bool {
+		stats := client.ConsumerStats()
+
+		return len(stats) == 1 && stats[0].InFlight == 3
+	}, time.Second, 10*time.Millisecond)
+
+	type drained struct {
+		report gorabbit.DrainReport
+		err    error
+	}
+
+	done := make(chan drained, 1)
+
+	go func() {
+		report, err := client.Drain(context.Background())
+		done <- drained{report: report, err: err}
+	}()
+
+	// The first delivery completes once the consumer is canceled.
+	require.Eventually(t, func() bool { return server.canceledCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	close(handler.release)
+
+	result := <-done
+	require.NoError(t, result.err)
+
+	// Only the delivery started before the drain is processed.
+	select {
+	case body := <-handler.started:
+		t.Fatalf("delivery %s processed while draining", body)
+	default:
+	}
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 3 }, time.Second, 10*time.Millisecond)
+
+	return result.report, server.settled()
+}
+
+func TestClient_Drain_OrderedDispatcher(t *testing.T) {
+	report, settled := drainWithDispatcher(t, gorabbit.MessageConsumer{
+		Ordering: &gorabbit.OrderingConfig{Workers: 1},
+	})
+
+	assert.Equal(t, gorabbit.DrainReport{Consumers: 1, InFlight: 1, Requeued: 2}, report)
+	assert.ElementsMatch(t, []fakeSettlement{
+		{Body: "first", Acked: true},
+		{Body: "second", Requeue: true},
+		{Body: "third", Requeue: true},
+	}, settled)
+}
+
+func TestClient_Drain_PriorityDispatcher(t *testing.T) {
+	report, settled := drainWithDispatcher(t, gorabbit.MessageConsumer{
+		PriorityScheduling: &gorabbit.PrioritySchedulingConfig{Workers: 1},
+	})
+
+	assert.Equal(t, gorabbit.DrainReport{Consumers: 1, InFlight: 1, Requeued: 2}, report)
+	assert.ElementsMatch(t, []fakeSettlement{
+		{Body: "first", Acked: true},
+		{Body: "second", Requeue: true},
+		{Body: "third", Requeue: true},
+	}, settled)
+}
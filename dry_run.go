@@ -0,0 +1,419 @@
+package gorabbit
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxRoutingKeyLength is the maximum length in bytes of a routing key, an AMQP short string.
+const maxRoutingKeyLength = 255
+
+// FindingSeverity is the severity of a ConsumerFinding.
+type FindingSeverity string
+
+const (
+	// FindingError flags a definition that cannot be registered.
+	FindingError FindingSeverity = "error"
+
+	// FindingWarning flags a definition that can be registered but is likely misrouted.
+	FindingWarning FindingSeverity = "warning"
+)
+
+func (s FindingSeverity) String() string {
+	return string(s)
+}
+
+// ConsumerFinding is a problem found by the dry-run of a consumer.
+type ConsumerFinding struct {
+	// Severity is the severity of the problem.
+	Severity FindingSeverity
+
+	// Queue is the queue of the handler or binding concerned, if any.
+	Queue string
+
+	// Key is the routing key of the handler or binding concerned, if any.
+	Key string
+
+	// Message describes the problem.
+	Message string
+}
+
+func (f ConsumerFinding) String() string {
+	var subject []string
+
+	if f.Queue != "" {
+		subject = append(subject, fmt.Sprintf("queue '%s'", f.Queue))
+	}
+
+	if f.Key != "" {
+		subject = append(subject, fmt.Sprintf("key '%s'", f.Key))
+	}
+
+	if len(subject) == 0 {
+		return fmt.Sprintf("%s: %s", f.Severity, f.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", f.Severity, strings.Join(subject, ", "), f.Message)
+}
+
+// ConsumerReport is the result of the dry-run of a consumer.
+type ConsumerReport struct {
+	// Consumer is the name of the consumer.
+	Consumer string
+
+	// Findings holds the problems found, errors first.
+	Findings []ConsumerFinding
+}
+
+// Valid returns true if the report holds no error, the warnings aside.
+func (r ConsumerReport) Valid() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors returns the findings preventing the registration of the consumer.
+func (r ConsumerReport) Errors() []ConsumerFinding {
+	return r.filter(FindingError)
+}
+
+// Warnings returns the findings of a consumer that can be registered but is likely misrouted.
+func (r ConsumerReport) Warnings() []ConsumerFinding {
+	return r.filter(FindingWarning)
+}
+
+// Err returns an error describing the errors, or nil if the consumer can be registered.
+func (r ConsumerReport) Err() error {
+	errs := make([]error, 0, len(r.Findings))
+
+	for _, finding := range r.Errors() {
+		errs = append(errs, errors.New(finding.String()))
+	}
+
+	return errors.Join(errs...)
+}
+
+// filter returns the findings of the given severity.
+func (r ConsumerReport) filter(severity FindingSeverity) []ConsumerFinding {
+	var findings []ConsumerFinding
+
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			findings = append(findings, finding)
+		}
+	}
+
+	return findings
+}
+
+// DryRun verifies the consumer without registering it, and reports all the problems found:
+//   - the errors of Validate,
+//   - the routing keys of the handlers that are not valid topic patterns,
+//   - the routing keys registered both as Handlers and ContextHandlers, the ContextHandler shadowing the Handler,
+//   - the wildcard routing keys of a queue matching the same routing keys, only one handler being called for them,
+//   - the handlers that no message can reach through the bindings of the QueueConfig, and the bindings routing
+//     messages that no handler matches.
+//
+// The bindings with arguments or an empty routing key, such as those of headers and fanout exchanges, can route any
+// routing key, so that the reachability is not verified.
+func (c MessageConsumer) DryRun() ConsumerReport {
+	report := ConsumerReport{Consumer: c.Name}
+
+	report.Findings = append(report.Findings, c.dryRunProperties()...)
+
+	var bindings []BindingConfig
+
+	if c.QueueConfig != nil {
+		bindings = c.QueueConfig.Bindings
+	}
+
+	report.Findings = append(report.Findings, dryRunHandlers(c.Queue, c.Handlers, c.ContextHandlers, bindings)...)
+
+	for _, subscription := range c.Subscriptions {
+		report.Findings = append(report.Findings,
+			dryRunHandlers(subscription.Queue, subscription.Handlers, subscription.ContextHandlers, nil)...)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Severity == FindingError && report.Findings[j].Severity != FindingError
+	})
+
+	return report
+}
+
+// dryRunProperties returns the errors of Validate other than those of the handlers, which are reported with their
+// queue and key.
+func (c MessageConsumer) dryRunProperties() []ConsumerFinding {
+	c.Handlers, c.ContextHandlers = nil, nil
+
+	subscriptions := make([]QueueSubscription, len(c.Subscriptions))
+
+	for i, subscription := range c.Subscriptions {
+		subscriptions[i] = QueueSubscription{Queue: subscription.Queue}
+	}
+
+	c.Subscriptions = subscriptions
+
+	err := c.Validate()
+	if err == nil {
+		return nil
+	}
+
+	errs := []error{err}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	}
+
+	findings := make([]ConsumerFinding, 0, len(errs))
+
+	for _, err := range errs {
+		findings = append(findings, ConsumerFinding{Severity: FindingError, Message: err.Error()})
+	}
+
+	return findings
+}
+
+// dryRunHandlers returns the problems of the handlers of a queue, given its bindings if known.
+//
+//nolint:gocognit // The checks share the valid keys of the queue.
+func dryRunHandlers(
+	queue string,
+	handlers MQTTMessageHandlers,
+	contextHandlers MQTTMessageContextHandlers,
+	bindings []BindingConfig,
+) []ConsumerFinding {
+	var findings []ConsumerFinding
+
+	finding := func(severity FindingSeverity, key, format string, args ...interface{}) {
+		findings = append(findings, ConsumerFinding{
+			Severity: severity,
+			Queue:    queue,
+			Key:      key,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	var handlerKeys []string
+
+	for _, key := range sortedKeys(handlers) {
+		if _, found := contextHandlers[key]; found {
+			finding(FindingWarning, key, "the Handler is shadowed by the ContextHandler registered with the same key")
+
+			continue
+		}
+
+		handlerKeys = append(handlerKeys, key)
+	}
+
+	var valid []string
+
+	// The ContextHandlers are looked up before the Handlers, so their keys are only ambiguous among themselves.
+	for _, keys := range [][]string{sortedKeys(contextHandlers), handlerKeys} {
+		var validKeys []string
+
+		for _, key := range keys {
+			if err := validateRoutingKey(key); err != nil {
+				finding(FindingError, key, "%s", err.Error())
+
+				continue
+			}
+
+			if len(key) > maxRoutingKeyLength {
+				finding(FindingError, key, "the routing key exceeds %d bytes", maxRoutingKeyLength)
+
+				continue
+			}
+
+			validKeys = append(validKeys, key)
+		}
+
+		// The keys are compiled once, to verify the witnesses of all their pairs.
+		matchers := make(map[string]*RoutingKeyMatcher, len(validKeys))
+
+		for _, key := range validKeys {
+			matchers[key] = NewRoutingKeyMatcher(key)
+		}
+
+		for i, key := range validKeys {
+			for _, other := range validKeys[i+1:] {
+				if witness, ambiguous := ambiguousKeys(key, other, matchers); ambiguous {
+					finding(FindingWarning, key, "the routing key '%s' matches the key '%s' as well, so that "+
+						"only one handler is called", witness, other)
+				}
+			}
+		}
+
+		valid = append(valid, validKeys...)
+	}
+
+	if !bindingsRestrictKeys(bindings) {
+		return findings
+	}
+
+	for _, key := range valid {
+		if !overlapsAny(key, bindingKeys(bindings)) {
+			finding(FindingWarning, key, "the handler is unreachable through the bindings of the queue")
+		}
+	}
+
+	for _, binding := range bindings {
+		if !overlapsAny(binding.RoutingKey, valid) {
+			finding(FindingWarning, binding.RoutingKey, "the messages routed by the binding to the exchange '%s' "+
+				"match no handler", binding.Exchange)
+		}
+	}
+
+	return findings
+}
+
+// sortedKeys returns the keys of the handlers in order, so that the findings are stable.
+func sortedKeys[F any](handlers map[string]F) []string {
+	keys := make([]string, 0, len(handlers))
+
+	for key := range handlers {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// ambiguousKeys returns a routing key matched by both wildcard keys, if any, in which case only the handler preferred
+// by the routingTrie is called. Keys without wildcard are matched first, so they are never ambiguous. The matchers hold
+// the compiled keys.
+func ambiguousKeys(key, other string, matchers map[string]*RoutingKeyMatcher) (string, bool) {
+	if !hasWildcard(key) || !hasWildcard(other) {
+		return "", false
+	}
+
+	witness, found := topicOverlap(strings.Split(key, "."), strings.Split(other, "."))
+	if !found {
+		return "", false
+	}
+
+	routingKey := strings.Join(witness, ".")
+
+	// The handlers match routing keys with their own semantics, so the witness is verified.
+	_, keyMatches := matchers[key].Match(routingKey)
+	_, otherMatches := matchers[other].Match(routingKey)
+
+	return routingKey, keyMatches && otherMatches
+}
+
+// hasWildcard returns true if the key contains the '*' or '#' wildcard.
+func hasWildcard(key string) bool {
+	for _, word := range strings.Split(key, ".") {
+		if word == "*" || word == "#" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bindingsRestrictKeys returns true if the bindings are known and only route the routing keys matching their own.
+func bindingsRestrictKeys(bindings []BindingConfig) bool {
+	if len(bindings) == 0 {
+		return false
+	}
+
+	for _, binding := range bindings {
+		if binding.RoutingKey == "" || len(binding.Args) > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// bindingKeys returns the routing keys of the bindings.
+func bindingKeys(bindings []BindingConfig) []string {
+	keys := make([]string, 0, len(bindings))
+
+	for _, binding := range bindings {
+		keys = append(keys, binding.RoutingKey)
+	}
+
+	return keys
+}
+
+// overlapsAny returns true if a routing key matches both the pattern and one of the others, as topic patterns.
+func overlapsAny(pattern string, others []string) bool {
+	for _, other := range others {
+		if _, found := topicOverlap(strings.Split(pattern, "."), strings.Split(other, ".")); found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// topicOverlap returns the words of a routing key matching both topic patterns, where '*' matches exactly one word and
+// '#' matches zero or more words, if any.
+func topicOverlap(a, b []string) ([]string, bool) {
+	if len(a) == 0 && len(b) == 0 {
+		return nil, true
+	}
+
+	if len(a) > 0 && a[0] == "#" {
+		return hashOverlap(a, b)
+	}
+
+	if len(b) > 0 && b[0] == "#" {
+		return hashOverlap(b, a)
+	}
+
+	if len(a) == 0 || len(b) == 0 {
+		return nil, false
+	}
+
+	word := a[0]
+
+	switch {
+	case a[0] == "*" && b[0] == "*":
+		word = "x"
+	case a[0] == "*":
+		word = b[0]
+	case b[0] != "*" && a[0] != b[0]:
+		return nil, false
+	}
+
+	rest, found := topicOverlap(a[1:], b[1:])
+	if !found {
+		return nil, false
+	}
+
+	return append([]string{word}, rest...), true
+}
+
+// hashOverlap returns the words of a routing key matching both topic patterns, the first one starting with '#'.
+func hashOverlap(hash, other []string) ([]string, bool) {
+	// The '#' matches no word.
+	if words, found := topicOverlap(hash[1:], other); found {
+		return words, true
+	}
+
+	if len(other) == 0 {
+		return nil, false
+	}
+
+	// The '#' matches the first word of the other pattern, and maybe more.
+	words, found := topicOverlap(hash, other[1:])
+	if !found {
+		return nil, false
+	}
+
+	word := other[0]
+
+	if word == "*" || word == "#" {
+		if word == "#" {
+			return words, true
+		}
+
+		word = "x"
+	}
+
+	return append([]string{word}, words...), true
+}
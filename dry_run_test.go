@@ -0,0 +1,113 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMessageConsumer_DryRun(t *testing.T) {
+	noop := func(_ []byte) error { return nil }
+
+	consumer := gorabbit.MessageConsumer{
+		Queue: "invoices_queue",
+		Name:  "invoices",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"invoice.*.paid":  noop,
+			"invoice.eu.*":    noop,
+			"invoice.created": noop,
+			"refund.created":  noop,
+			"bad..key":        noop,
+		},
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"invoice.created": nil,
+		},
+		QueueConfig: &gorabbit.QueueConfig{
+			Bindings: []gorabbit.BindingConfig{
+				{Exchange: "billing_exchange", RoutingKey: "invoice.#"},
+				{Exchange: "billing_exchange", RoutingKey: "payment.*"},
+			},
+		},
+		Subscriptions: []gorabbit.QueueSubscription{
+			{Queue: "credits_queue", Handlers: gorabbit.MQTTMessageHandlers{"#": noop}},
+		},
+	}
+
+	report := consumer.DryRun()
+
+	assert.Equal(t, "invoices", report.Consumer)
+	assert.False(t, report.Valid())
+	require.Error(t, report.Err())
+
+	assert.Equal(t, []gorabbit.ConsumerFinding{
+		{
+			Severity: gorabbit.FindingError,
+			Queue:    "invoices_queue",
+			Key:      "bad..key",
+			Message:  "the routing key 'bad..key' is not properly formatted",
+		},
+		{
+			Severity: gorabbit.FindingError,
+			Queue:    "credits_queue",
+			Key:      "#",
+			Message:  "a routing key cannot be the wildcard '#'",
+		},
+	}, report.Errors())
+
+	assert.Equal(t, []gorabbit.ConsumerFinding{
+		{
+			Severity: gorabbit.FindingWarning,
+			Queue:    "invoices_queue",
+			Key:      "invoice.created",
+			Message:  "the Handler is shadowed by the ContextHandler registered with the same key",
+		},
+		{
+			Severity: gorabbit.FindingWarning,
+			Queue:    "invoices_queue",
+			Key:      "invoice.*.paid",
+			Message:  "the routing key 'invoice.eu.paid' matches the key 'invoice.eu.*' as well, so that only one handler is called",
+		},
+		{
+			Severity: gorabbit.FindingWarning,
+			Queue:    "invoices_queue",
+			Key:      "refund.created",
+			Message:  "the handler is unreachable through the bindings of the queue",
+		},
+		{
+			Severity: gorabbit.FindingWarning,
+			Queue:    "invoices_queue",
+			Key:      "payment.*",
+			Message:  "the messages routed by the binding to the exchange 'billing_exchange' match no handler",
+		},
+	}, report.Warnings())
+}
+
+func TestMessageConsumer_DryRun_Valid(t *testing.T) {
+	consumer := gorabbit.MessageConsumer{
+		Queue: "events_queue",
+		Name:  "events",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(_ []byte) error { return nil },
+		},
+		AckMode: gorabbit.AckModeManual,
+		QueueConfig: &gorabbit.QueueConfig{
+			Bindings: []gorabbit.BindingConfig{{Exchange: "events_exchange", RoutingKey: "event.*"}},
+		},
+	}
+
+	report := consumer.DryRun()
+
+	require.Len(t, report.Errors(), 1)
+	assert.Contains(t, report.Errors()[0].Message, "must define a prefetch count")
+
+	consumer.PrefetchCount = 10
+
+	report = consumer.DryRun()
+
+	assert.True(t, report.Valid())
+	assert.NoError(t, report.Err())
+	assert.Empty(t, report.Findings)
+}
@@ -0,0 +1,140 @@
+package gorabbit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io"
+)
+
+// Keyring holds the AES keys encrypting the payloads, by key ID. The payloads are encrypted with the current key and
+// decrypted with the key they were encrypted with, so that the keys can be rotated while messages are in the queues.
+type Keyring struct {
+	current string
+	ciphers map[string]cipher.AEAD
+}
+
+// NewKeyring returns a Keyring encrypting with the key of the given current ID. The keys must be 16, 24 or 32 bytes
+// long, to use AES-128, AES-192 or AES-256.
+func NewKeyring(current string, keys map[string][]byte) (*Keyring, error) {
+	if _, found := keys[current]; !found {
+		return nil, fmt.Errorf("%w: '%s'", errUnknownEncryptionKey, current)
+	}
+
+	keyring := &Keyring{
+		current: current,
+		ciphers: make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for id, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: key '%s'", errInvalidEncryptionKey, id)
+		}
+
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		keyring.ciphers[id] = gcm
+	}
+
+	return keyring, nil
+}
+
+// encrypt encrypts data with the current key, returning the ID of the key along with the nonce and the ciphertext.
+func (k *Keyring) encrypt(data []byte) (string, []byte, error) {
+	gcm := k.ciphers[k.current]
+
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(data)+gcm.Overhead())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", nil, err
+	}
+
+	return k.current, gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// decrypt decrypts data, made of the nonce and the ciphertext, with the key of the given ID.
+func (k *Keyring) decrypt(id string, data []byte) ([]byte, error) {
+	gcm, found := k.ciphers[id]
+	if !found {
+		return nil, fmt.Errorf("%w: '%s'", errUnknownEncryptionKey, id)
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errEncryptedPayloadTooShort
+	}
+
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+}
+
+// EncryptingMarshaller is a Marshaller encrypting the payloads encoded by another Marshaller with AES-GCM, the ID of the
+// key being set in a header. It is also a PayloadDecoder, to decrypt the deliveries of a consumer. The content type
+// of the messages is the one of their decrypted payload.
+type EncryptingMarshaller struct {
+	marshaller Marshaller
+	keyring    *Keyring
+}
+
+// NewEncryptingMarshaller returns an EncryptingMarshaller encrypting the payloads encoded by the given marshaller, the
+// JSONMarshaller if nil, with the keys of the keyring.
+func NewEncryptingMarshaller(marshaller Marshaller, keyring *Keyring) *EncryptingMarshaller {
+	if marshaller == nil {
+		marshaller = JSONMarshaller{}
+	}
+
+	return &EncryptingMarshaller{
+		marshaller: marshaller,
+		keyring:    keyring,
+	}
+}
+
+// Marshal encodes the payload with the wrapped marshaller, then encrypts it with the current key.
+func (m *EncryptingMarshaller) Marshal(metadata *MessageMetadata, payload interface{}) ([]byte, error) {
+	data, err := m.marshaller.Marshal(metadata, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	id, encrypted, err := m.keyring.encrypt(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if metadata.Headers == nil {
+		metadata.Headers = make(map[string]interface{})
+	}
+
+	metadata.Headers[encryptionKeyIDHeader] = id
+
+	return encrypted, nil
+}
+
+// Unmarshal decrypts the payload, then decodes it with the wrapped marshaller.
+func (m *EncryptingMarshaller) Unmarshal(metadata *MessageMetadata, data []byte, v interface{}) error {
+	decrypted, err := m.Decode(metadata, data)
+	if err != nil {
+		return err
+	}
+
+	return m.marshaller.Unmarshal(metadata, decrypted, v)
+}
+
+// Decode decrypts the payload with the key of its header. Payloads without key header are returned as is, so that
+// consumers can decrypt messages while their producers start encrypting them.
+func (m *EncryptingMarshaller) Decode(metadata *MessageMetadata, data []byte) ([]byte, error) {
+	id, ok := metadata.Headers[encryptionKeyIDHeader].(string)
+	if !ok {
+		return data, nil
+	}
+
+	decrypted, err := m.keyring.decrypt(id, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt payload: %w", err)
+	}
+
+	return decrypted, nil
+}
@@ -0,0 +1,87 @@
+package gorabbit_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestNewKeyring(t *testing.T) {
+	_, err := gorabbit.NewKeyring("v1", map[string][]byte{"v1": []byte("too short")})
+	require.Error(t, err)
+
+	_, err = gorabbit.NewKeyring("v2", map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)})
+	require.Error(t, err)
+}
+
+func TestEncryptingMarshaller(t *testing.T) {
+	keyring, err := gorabbit.NewKeyring("v1", map[string][]byte{"v1": bytes.Repeat([]byte{1}, 32)})
+	require.NoError(t, err)
+
+	marshaller := gorabbit.NewEncryptingMarshaller(nil, keyring)
+
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "card.added"}
+
+	data, err := marshaller.Marshal(metadata, map[string]string{"number": "4242"})
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(data), "4242")
+	assert.Equal(t, gorabbit.ContentTypeJSON, metadata.ContentType)
+	assert.Equal(t, "v1", metadata.Headers["x-encryption-key-id"])
+
+	decrypted, err := marshaller.Decode(metadata, data)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"number":"4242"}`, string(decrypted))
+
+	var decoded map[string]string
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, "4242", decoded["number"])
+
+	data[len(data)-1] ^= 1
+
+	_, err = marshaller.Decode(metadata, data)
+	require.Error(t, err)
+}
+
+func TestEncryptingMarshaller_KeyRotation(t *testing.T) {
+	keys := map[string][]byte{
+		"v1": bytes.Repeat([]byte{1}, 16),
+		"v2": bytes.Repeat([]byte{2}, 16),
+	}
+
+	previous, err := gorabbit.NewKeyring("v1", keys)
+	require.NoError(t, err)
+
+	current, err := gorabbit.NewKeyring("v2", keys)
+	require.NoError(t, err)
+
+	metadata := &gorabbit.MessageMetadata{}
+
+	data, err := gorabbit.NewEncryptingMarshaller(nil, previous).Marshal(metadata, "hello")
+	require.NoError(t, err)
+
+	var decoded string
+
+	require.NoError(t, gorabbit.NewEncryptingMarshaller(nil, current).Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, "hello", decoded)
+
+	metadata.Headers["x-encryption-key-id"] = "v3"
+
+	_, err = gorabbit.NewEncryptingMarshaller(nil, current).Decode(metadata, data)
+	require.Error(t, err)
+}
+
+func TestEncryptingMarshaller_Unencrypted(t *testing.T) {
+	keyring, err := gorabbit.NewKeyring("v1", map[string][]byte{"v1": bytes.Repeat([]byte{1}, 24)})
+	require.NoError(t, err)
+
+	data, err := gorabbit.NewEncryptingMarshaller(nil, keyring).Decode(&gorabbit.MessageMetadata{}, []byte(`{"a":1}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"a":1}`, string(data))
+}
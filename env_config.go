@@ -0,0 +1,142 @@
+package gorabbit
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// LoadEnvConfig returns the ClientOptions read from the RABBITMQ_* environment variables declared by the env tags of
+// the RabbitMQEnvs, over the DefaultClientOptions. Unset or empty variables keep their default value, and an invalid
+// value is reported with the name of its variable.
+func LoadEnvConfig() (*ClientOptions, error) {
+	var envs RabbitMQEnvs
+
+	if err := loadEnv(&envs); err != nil {
+		return nil, err
+	}
+
+	return envs.clientOptions(), nil
+}
+
+// clientOptions returns the ClientOptions of the environment variables, over the DefaultClientOptions.
+func (e RabbitMQEnvs) clientOptions() *ClientOptions {
+	options := DefaultClientOptions()
+
+	if e.Host != "" {
+		options.Host = e.Host
+	}
+
+	if e.Port > 0 {
+		options.Port = e.Port
+	}
+
+	if e.Username != "" {
+		options.Username = e.Username
+	}
+
+	if e.Password != "" {
+		options.Password = e.Password
+	}
+
+	if e.Vhost != "" {
+		options.Vhost = e.Vhost
+	}
+
+	options.UseTLS = e.UseTLS
+
+	if e.KeepAlive != nil {
+		options.KeepAlive = *e.KeepAlive
+	}
+
+	if e.RetryDelay > 0 {
+		options.RetryDelay = e.RetryDelay
+	}
+
+	if e.PrefetchCount > 0 {
+		options.PrefetchCount = e.PrefetchCount
+	}
+
+	return options
+}
+
+// loadEnv sets the fields of the struct pointed to by v from the environment variables named by their env tags.
+func loadEnv(v interface{}) error {
+	value := reflect.ValueOf(v).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		name, tagged := value.Type().Field(i).Tag.Lookup("env")
+		if !tagged {
+			continue
+		}
+
+		raw, found := os.LookupEnv(name)
+		if !found || raw == "" {
+			continue
+		}
+
+		if err := setEnvValue(value.Field(i), raw); err != nil {
+			return fmt.Errorf("%w %s: %w", errInvalidEnvValue, name, err)
+		}
+	}
+
+	return nil
+}
+
+// setEnvValue sets a field from the raw value of its environment variable.
+func setEnvValue(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.Ptr {
+		pointer := reflect.New(field.Type().Elem())
+
+		if err := setEnvValue(pointer.Elem(), raw); err != nil {
+			return err
+		}
+
+		field.Set(pointer)
+
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == reflect.TypeOf(time.Duration(0)) {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil {
+				return err
+			}
+
+			field.SetInt(int64(parsed))
+
+			return nil
+		}
+
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		field.SetUint(parsed)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
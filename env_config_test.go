@@ -0,0 +1,104 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestLoadEnvConfig(t *testing.T) {
+	t.Setenv("RABBITMQ_HOST", "rabbitmq.internal")
+	t.Setenv("RABBITMQ_PORT", "5671")
+	t.Setenv("RABBITMQ_USERNAME", "billing")
+	t.Setenv("RABBITMQ_PASSWORD", "secret")
+	t.Setenv("RABBITMQ_VHOST", "production")
+	t.Setenv("RABBITMQ_USE_TLS", "true")
+	t.Setenv("RABBITMQ_KEEP_ALIVE", "false")
+	t.Setenv("RABBITMQ_RETRY_DELAY", "5s")
+	t.Setenv("RABBITMQ_PREFETCH_COUNT", "20")
+
+	options, err := gorabbit.LoadEnvConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, "rabbitmq.internal", options.Host)
+	assert.Equal(t, uint(5671), options.Port)
+	assert.Equal(t, "billing", options.Username)
+	assert.Equal(t, "secret", options.Password)
+	assert.Equal(t, "production", options.Vhost)
+	assert.True(t, options.UseTLS)
+	assert.False(t, options.KeepAlive)
+	assert.Equal(t, 5*time.Second, options.RetryDelay)
+	assert.Equal(t, 20, options.PrefetchCount)
+}
+
+func TestLoadEnvConfig_Defaults(t *testing.T) {
+	t.Setenv("RABBITMQ_HOST", "")
+
+	options, err := gorabbit.LoadEnvConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, gorabbit.DefaultClientOptions(), options)
+}
+
+func TestLoadEnvConfig_Invalid(t *testing.T) {
+	t.Setenv("RABBITMQ_RETRY_DELAY", "5 seconds")
+
+	_, err := gorabbit.LoadEnvConfig()
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "RABBITMQ_RETRY_DELAY")
+}
+
+func TestNewManagerOptionsFromEnv(t *testing.T) {
+	t.Setenv("RABBITMQ_HOST", "rabbitmq.internal")
+	t.Setenv("RABBITMQ_PORT", "5671")
+	t.Setenv("RABBITMQ_USERNAME", "billing")
+	t.Setenv("RABBITMQ_PASSWORD", "secret")
+	t.Setenv("RABBITMQ_VHOST", "production")
+	t.Setenv("RABBITMQ_USE_TLS", "true")
+
+	options := gorabbit.NewManagerOptionsFromEnv()
+
+	assert.Equal(t, "rabbitmq.internal", options.Host)
+	assert.Equal(t, uint(5671), options.Port)
+	assert.Equal(t, "billing", options.Username)
+	assert.Equal(t, "secret", options.Password)
+	assert.Equal(t, "production", options.Vhost)
+	assert.True(t, options.UseTLS)
+}
+
+func TestNewManagerOptionsFromEnv_Invalid(t *testing.T) {
+	t.Setenv("RABBITMQ_HOST", "rabbitmq.internal")
+	t.Setenv("RABBITMQ_PORT", "amqp")
+
+	assert.Equal(t, gorabbit.DefaultManagerOptions(), gorabbit.NewManagerOptionsFromEnv())
+}
+
+func TestNewManagementOptionsFromEnv(t *testing.T) {
+	t.Setenv("RABBITMQ_HOST", "rabbitmq.internal")
+	t.Setenv("RABBITMQ_PORT", "5671")
+	t.Setenv("RABBITMQ_MANAGEMENT_PORT", "15671")
+	t.Setenv("RABBITMQ_USERNAME", "billing")
+	t.Setenv("RABBITMQ_PASSWORD", "secret")
+	t.Setenv("RABBITMQ_VHOST", "production")
+	t.Setenv("RABBITMQ_USE_TLS", "true")
+
+	options := gorabbit.NewManagementOptionsFromEnv()
+
+	assert.Equal(t, "rabbitmq.internal", options.Host)
+	assert.Equal(t, uint(15671), options.Port)
+	assert.Equal(t, "billing", options.Username)
+	assert.Equal(t, "secret", options.Password)
+	assert.Equal(t, "production", options.Vhost)
+	assert.True(t, options.UseTLS)
+}
+
+func TestNewManagementOptionsFromEnv_Defaults(t *testing.T) {
+	t.Setenv("RABBITMQ_HOST", "")
+
+	assert.Equal(t, gorabbit.DefaultManagementOptions(), gorabbit.NewManagementOptionsFromEnv())
+}
@@ -0,0 +1,193 @@
+package gorabbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ContentTypeEnvelope is the content type of the payloads wrapped in an Envelope in their body.
+const ContentTypeEnvelope = "application/vnd.gorabbit.envelope+json"
+
+// EnvelopeMode is where the EnvelopeMarshaller puts the Envelope of a message.
+type EnvelopeMode uint8
+
+const (
+	// EnvelopeInHeaders puts the Envelope in the headers of the message, leaving the payload as is.
+	EnvelopeInHeaders EnvelopeMode = iota
+
+	// EnvelopeInBody wraps the payload in a JSON Envelope. The wrapped payload must be JSON.
+	EnvelopeInBody
+)
+
+// Envelope holds the metadata describing a message, for its consumers to tell its versions apart.
+type Envelope struct {
+	// Type is the name of the type of the message.
+	Type string `json:"type"`
+
+	// Version is the version of the schema of the message.
+	Version string `json:"version,omitempty"`
+
+	// Producer is the name of the service that produced the message.
+	Producer string `json:"producer,omitempty"`
+
+	// EmittedAt is the time the message was produced at.
+	EmittedAt time.Time `json:"emitted_at"`
+
+	// Payload is the wrapped payload, in EnvelopeInBody mode only.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// headers sets the envelope into the headers of a message.
+func (e Envelope) headers(headers map[string]interface{}) {
+	headers[envelopeTypeHeader] = e.Type
+	headers[envelopeVersionHeader] = e.Version
+	headers[envelopeProducerHeader] = e.Producer
+	headers[envelopeEmittedAtHeader] = e.EmittedAt.Format(time.RFC3339Nano)
+}
+
+// EnvelopeMarshaller is a Marshaller wrapping the payloads encoded by another Marshaller in an Envelope, either in the
+// headers or in the body of the messages, and unwrapping them.
+type EnvelopeMarshaller struct {
+	marshaller Marshaller
+	producer   string
+	mode       EnvelopeMode
+	versions   map[string]string
+}
+
+// NewEnvelopeMarshaller returns an EnvelopeMarshaller wrapping the payloads encoded by the given marshaller, the
+// JSONMarshaller if nil, in envelopes produced by the given producer.
+func NewEnvelopeMarshaller(marshaller Marshaller, producer string, mode EnvelopeMode) *EnvelopeMarshaller {
+	if marshaller == nil {
+		marshaller = JSONMarshaller{}
+	}
+
+	return &EnvelopeMarshaller{
+		marshaller: marshaller,
+		producer:   producer,
+		mode:       mode,
+		versions:   make(map[string]string),
+	}
+}
+
+// SetVersion will assign the schema version of the messages of the given type, their routing key by default. The
+// versions must be set before the EnvelopeMarshaller is used.
+func (m *EnvelopeMarshaller) SetVersion(messageType, version string) *EnvelopeMarshaller {
+	m.versions[messageType] = version
+
+	return m
+}
+
+// Marshal encodes the payload with the wrapped marshaller, then wraps it in an Envelope.
+func (m *EnvelopeMarshaller) Marshal(metadata *MessageMetadata, payload interface{}) ([]byte, error) {
+	data, err := m.marshaller.Marshal(metadata, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope := Envelope{
+		Type:      metadata.Type,
+		Version:   m.versions[metadata.Type],
+		Producer:  m.producer,
+		EmittedAt: time.Now().UTC(),
+	}
+
+	if m.mode == EnvelopeInHeaders {
+		if metadata.Headers == nil {
+			metadata.Headers = make(map[string]interface{})
+		}
+
+		envelope.headers(metadata.Headers)
+
+		return data, nil
+	}
+
+	if metadata.ContentType != ContentTypeJSON {
+		return nil, fmt.Errorf("%w: '%s'", errEnvelopeNotJSON, metadata.ContentType)
+	}
+
+	envelope.Payload = data
+
+	metadata.ContentType = ContentTypeEnvelope
+
+	return json.Marshal(envelope)
+}
+
+// Unmarshal unwraps the payload from its Envelope, if any, then decodes it with the wrapped marshaller.
+func (m *EnvelopeMarshaller) Unmarshal(metadata *MessageMetadata, data []byte, v interface{}) error {
+	_, payload, err := unwrapEnvelope(metadata.ContentType, metadata.Headers, data)
+	if err != nil {
+		return err
+	}
+
+	return m.marshaller.Unmarshal(metadata, payload, v)
+}
+
+// unwrapEnvelope returns the Envelope of a message and its unwrapped payload. Messages without envelope are returned
+// with a nil Envelope and their payload as is.
+func unwrapEnvelope(contentType string, headers map[string]interface{}, data []byte) (*Envelope, []byte, error) {
+	if contentType == ContentTypeEnvelope {
+		var envelope Envelope
+
+		if err := json.Unmarshal(data, &envelope); err != nil {
+			return nil, nil, fmt.Errorf("could not decode envelope: %w", err)
+		}
+
+		payload := []byte(envelope.Payload)
+		envelope.Payload = nil
+
+		return &envelope, payload, nil
+	}
+
+	messageType, ok := headers[envelopeTypeHeader].(string)
+	if !ok {
+		return nil, data, nil
+	}
+
+	envelope := &Envelope{Type: messageType}
+
+	envelope.Version, _ = headers[envelopeVersionHeader].(string)
+	envelope.Producer, _ = headers[envelopeProducerHeader].(string)
+
+	if emittedAt, isString := headers[envelopeEmittedAtHeader].(string); isString {
+		envelope.EmittedAt, _ = time.Parse(time.RFC3339Nano, emittedAt)
+	}
+
+	return envelope, data, nil
+}
+
+// unwrapDelivery unwraps the payload of a delivery from its Envelope, if the consumer unwraps envelopes.
+func (c *amqpChannel) unwrapDelivery(delivery *amqp.Delivery, payload []byte) (*Envelope, []byte, error) {
+	if !c.consumer.UnwrapEnvelopes {
+		return nil, payload, nil
+	}
+
+	return unwrapEnvelope(delivery.ContentType, delivery.Headers, payload)
+}
+
+// VersionedHandlers returns a handler dispatching the deliveries to the handler of the version of their Envelope, or
+// to the handler registered under the empty version for the deliveries without envelope or of an unknown version.
+// The consumer must unwrap the envelopes.
+func VersionedHandlers(handlers map[string]MQTTMessageContextHandlerFunc) MQTTMessageContextHandlerFunc {
+	return func(ctx context.Context, payload []byte) error {
+		version := ""
+
+		if delivery, ok := DeliveryFromContext(ctx); ok && delivery.Envelope != nil {
+			version = delivery.Envelope.Version
+		}
+
+		handler, found := handlers[version]
+		if !found {
+			handler, found = handlers[""]
+		}
+
+		if !found {
+			return DeadLetter(fmt.Errorf("%w: '%s'", errUnknownEnvelopeVersion, version))
+		}
+
+		return handler(ctx, payload)
+	}
+}
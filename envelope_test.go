@@ -0,0 +1,100 @@
+package gorabbit_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type rawMarshaller struct{}
+
+func (rawMarshaller) Marshal(metadata *gorabbit.MessageMetadata, payload interface{}) ([]byte, error) {
+	metadata.ContentType = "text/plain"
+
+	return []byte(payload.(string)), nil
+}
+
+func (rawMarshaller) Unmarshal(_ *gorabbit.MessageMetadata, data []byte, v interface{}) error {
+	*v.(*string) = string(data)
+
+	return nil
+}
+
+func TestEnvelopeMarshaller_InHeaders(t *testing.T) {
+	marshaller := gorabbit.NewEnvelopeMarshaller(nil, "billing", gorabbit.EnvelopeInHeaders).
+		SetVersion("invoice.created", "2")
+
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "invoice.created", Type: "invoice.created", Headers: map[string]interface{}{}}
+
+	data, err := marshaller.Marshal(metadata, map[string]int{"amount": 10})
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"amount":10}`, string(data))
+	assert.Equal(t, gorabbit.ContentTypeJSON, metadata.ContentType)
+	assert.Equal(t, "invoice.created", metadata.Headers["x-message-type"])
+	assert.Equal(t, "2", metadata.Headers["x-schema-version"])
+	assert.Equal(t, "billing", metadata.Headers["x-producer"])
+	assert.NotEmpty(t, metadata.Headers["x-emitted-at"])
+
+	var decoded map[string]int
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, 10, decoded["amount"])
+}
+
+func TestEnvelopeMarshaller_InBody(t *testing.T) {
+	marshaller := gorabbit.NewEnvelopeMarshaller(nil, "billing", gorabbit.EnvelopeInBody).
+		SetVersion("invoice.created", "2")
+
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "invoice.created", Type: "invoice.created"}
+
+	data, err := marshaller.Marshal(metadata, map[string]int{"amount": 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, gorabbit.ContentTypeEnvelope, metadata.ContentType)
+
+	var envelope gorabbit.Envelope
+
+	require.NoError(t, json.Unmarshal(data, &envelope))
+	assert.Equal(t, "invoice.created", envelope.Type)
+	assert.Equal(t, "2", envelope.Version)
+	assert.Equal(t, "billing", envelope.Producer)
+	assert.JSONEq(t, `{"amount":10}`, string(envelope.Payload))
+
+	var decoded map[string]int
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, 10, decoded["amount"])
+
+	// Only JSON payloads can be wrapped in the body.
+	_, err = gorabbit.NewEnvelopeMarshaller(rawMarshaller{}, "billing", gorabbit.EnvelopeInBody).
+		Marshal(&gorabbit.MessageMetadata{Type: "invoice.created"}, "raw")
+	assert.Error(t, err)
+}
+
+func TestVersionedHandlers(t *testing.T) {
+	var called string
+
+	handler := gorabbit.VersionedHandlers(map[string]gorabbit.MQTTMessageContextHandlerFunc{
+		"": func(ctx context.Context, payload []byte) error {
+			called = "default"
+
+			return nil
+		},
+	})
+
+	// Deliveries without envelope go to the handler of the empty version.
+	require.NoError(t, handler(context.Background(), nil))
+	assert.Equal(t, "default", called)
+
+	handler = gorabbit.VersionedHandlers(map[string]gorabbit.MQTTMessageContextHandlerFunc{
+		"2": func(ctx context.Context, payload []byte) error { return nil },
+	})
+
+	assert.Error(t, handler(context.Background(), nil))
+}
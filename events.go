@@ -0,0 +1,220 @@
+package gorabbit
+
+import (
+	"time"
+)
+
+// EventType is the type of a lifecycle Event of a client.
+type EventType string
+
+const (
+	// EventConnectionOpened is the first opening of a connection.
+	EventConnectionOpened EventType = "connection_opened"
+
+	// EventConnectionRecovered is the opening of a connection after it was lost.
+	EventConnectionRecovered EventType = "connection_recovered"
+
+	// EventConnectionLost is the unexpected closing of a connection.
+	EventConnectionLost EventType = "connection_lost"
+
+	// EventConnectionClosed is the explicit closing of a connection.
+	EventConnectionClosed EventType = "connection_closed"
+
+	// EventChannelOpened is the first opening of a channel.
+	EventChannelOpened EventType = "channel_opened"
+
+	// EventChannelRecovered is the opening of a channel after it was lost.
+	EventChannelRecovered EventType = "channel_recovered"
+
+	// EventChannelLost is the unexpected closing of a channel.
+	EventChannelLost EventType = "channel_lost"
+
+	// EventChannelClosed is the explicit closing of a channel.
+	EventChannelClosed EventType = "channel_closed"
+
+	// EventConsumerSubscribed is the subscription of a consumer to its queue.
+	EventConsumerSubscribed EventType = "consumer_subscribed"
+
+	// EventConsumerCanceled is the end of the subscription of a consumer.
+	EventConsumerCanceled EventType = "consumer_canceled"
+
+	// EventPublishCached is a message cached while the publishing channel is down.
+	EventPublishCached EventType = "publish_cached"
+
+	// EventPublishReplayed is a cached message published once the publishing channel is back up.
+	EventPublishReplayed EventType = "publish_replayed"
+
+	// EventMessageDeadLettered is a delivery dead-lettered after its processing.
+	EventMessageDeadLettered EventType = "message_dead_lettered"
+)
+
+// String returns the string representation of the EventType.
+func (t EventType) String() string {
+	return string(t)
+}
+
+// Event is a lifecycle event of a client. Only the fields relevant to its Type are set.
+type Event struct {
+	// Type is the type of the event.
+	Type EventType
+
+	// Time is when the event happened.
+	Time time.Time
+
+	// Connection is the type of connection, either "consumer" or "publisher", of a connection or channel event.
+	Connection string
+
+	// Consumer is the name of the consumer of a consumer, channel or message event.
+	Consumer string
+
+	// Queue is the queue of a consumer or message event.
+	Queue string
+
+	// Exchange is the exchange of a publish event.
+	Exchange string
+
+	// RoutingKey is the routing key of a publish or message event.
+	RoutingKey string
+}
+
+// connectionEventTypes are the EventType of the lifecycle events of a connection.
+var connectionEventTypes = map[ConnectionEvent]EventType{
+	ConnectionEventOpened:   EventConnectionOpened,
+	ConnectionEventReopened: EventConnectionRecovered,
+	ConnectionEventLost:     EventConnectionLost,
+	ConnectionEventClosed:   EventConnectionClosed,
+}
+
+// channelEventTypes are the EventType of the lifecycle events of a channel.
+var channelEventTypes = map[ConnectionEvent]EventType{
+	ConnectionEventOpened:   EventChannelOpened,
+	ConnectionEventReopened: EventChannelRecovered,
+	ConnectionEventLost:     EventChannelLost,
+	ConnectionEventClosed:   EventChannelClosed,
+}
+
+// eventBus is a MetricsCollector turning the lifecycle events of a client into Events. The events are buffered and
+// dropped when the buffer is full, so that a slow or missing reader never blocks the client.
+type eventBus struct {
+	events chan Event
+}
+
+func newEventBus(size int) *eventBus {
+	return &eventBus{
+		events: make(chan Event, size),
+	}
+}
+
+// emit sends an event without blocking.
+func (b *eventBus) emit(event Event) {
+	event.Time = time.Now()
+
+	select {
+	case b.events <- event:
+	default:
+	}
+}
+
+// ObserveConnection emits the event of a connection.
+func (b *eventBus) ObserveConnection(metrics ConnectionMetrics) {
+	b.emit(Event{Type: connectionEventTypes[metrics.Event], Connection: metrics.Type})
+}
+
+// ObserveChannel emits the event of a channel.
+func (b *eventBus) ObserveChannel(metrics ChannelMetrics) {
+	b.emit(Event{Type: channelEventTypes[metrics.Event], Connection: metrics.Type, Consumer: metrics.Consumer})
+}
+
+// ObserveConsumer emits the event of a consumer.
+func (b *eventBus) ObserveConsumer(metrics ConsumerMetrics) {
+	eventType := EventConsumerSubscribed
+	if metrics.Event == ConsumerEventCanceled {
+		eventType = EventConsumerCanceled
+	}
+
+	b.emit(Event{Type: eventType, Consumer: metrics.Consumer, Queue: metrics.Queue})
+}
+
+// ObservePublish emits the event of a cached or replayed publishing.
+func (b *eventBus) ObservePublish(metrics PublishMetrics) {
+	var eventType EventType
+
+	switch metrics.Outcome {
+	case PublishOutcomeCached:
+		eventType = EventPublishCached
+	case PublishOutcomeRepublished:
+		eventType = EventPublishReplayed
+	default:
+		return
+	}
+
+	b.emit(Event{Type: eventType, Exchange: metrics.Exchange, RoutingKey: metrics.RoutingKey})
+}
+
+// ObserveDelivery emits the event of a dead-lettered delivery.
+func (b *eventBus) ObserveDelivery(metrics DeliveryMetrics) {
+	if metrics.Outcome != DeliveryOutcomeDeadLettered {
+		return
+	}
+
+	b.emit(Event{
+		Type:       EventMessageDeadLettered,
+		Consumer:   metrics.Consumer,
+		Queue:      metrics.Queue,
+		RoutingKey: metrics.RoutingKey,
+	})
+}
+
+// metricsCollectors passes the metrics to several MetricsCollector, each receiving the ones it collects.
+type metricsCollectors []MetricsCollector
+
+// newMetricsCollectors returns a MetricsCollector passing the metrics to the given ones, ignoring nil ones.
+func newMetricsCollectors(collectors ...MetricsCollector) metricsCollectors {
+	var all metricsCollectors
+
+	for _, collector := range collectors {
+		if collector != nil {
+			all = append(all, collector)
+		}
+	}
+
+	return all
+}
+
+func (m metricsCollectors) ObserveDelivery(metrics DeliveryMetrics) {
+	for _, collector := range m {
+		collector.ObserveDelivery(metrics)
+	}
+}
+
+func (m metricsCollectors) ObserveConnection(metrics ConnectionMetrics) {
+	for _, collector := range m {
+		if connectionCollector, ok := collector.(ConnectionMetricsCollector); ok {
+			connectionCollector.ObserveConnection(metrics)
+		}
+	}
+}
+
+func (m metricsCollectors) ObserveChannel(metrics ChannelMetrics) {
+	for _, collector := range m {
+		if connectionCollector, ok := collector.(ConnectionMetricsCollector); ok {
+			connectionCollector.ObserveChannel(metrics)
+		}
+	}
+}
+
+func (m metricsCollectors) ObserveConsumer(metrics ConsumerMetrics) {
+	for _, collector := range m {
+		if consumerCollector, ok := collector.(ConsumerMetricsCollector); ok {
+			consumerCollector.ObserveConsumer(metrics)
+		}
+	}
+}
+
+func (m metricsCollectors) ObservePublish(metrics PublishMetrics) {
+	for _, collector := range m {
+		if publishCollector, ok := collector.(PublishMetricsCollector); ok {
+			publishCollector.ObservePublish(metrics)
+		}
+	}
+}
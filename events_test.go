@@ -0,0 +1,39 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMQTTClient_Events(t *testing.T) {
+	// Nothing listens on the port, so the publishing channel is down and the message is cached.
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour))
+
+	defer func() { _ = client.Disconnect() }()
+
+	assert.Error(t, client.Publish("events_exchange", "event.created", "payload"))
+
+	select {
+	case event := <-client.Events():
+		assert.Equal(t, gorabbit.EventPublishCached, event.Type)
+		assert.Equal(t, "events_exchange", event.Exchange)
+		assert.Equal(t, "event.created", event.RoutingKey)
+		assert.False(t, event.Time.IsZero())
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+}
+
+func TestMQTTClient_Events_Disabled(t *testing.T) {
+	t.Setenv("GORABBIT_DISABLED", "true")
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions())
+
+	assert.NotNil(t, client.Events())
+}
@@ -0,0 +1,46 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_ExclusiveConsumerConflict(t *testing.T) {
+	server := newFakeServer(t)
+	server.setExclusive("events", true)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetRetryDelay(10 * time.Millisecond))
+
+	t.Cleanup(func() { _ = client.Disconnect() })
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue:     "events",
+		Name:      "events",
+		Exclusive: true,
+		Handlers:  gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }},
+	}))
+
+	// The subscription refused by the broker is reported as a conflict.
+	require.Eventually(t, func() bool {
+		report := client.HealthReport()
+
+		return len(report.Consumers) == 1 && errors.Is(report.Consumers[0].Err, gorabbit.ErrExclusiveConsumerConflict)
+	}, time.Second, 10*time.Millisecond)
+
+	assert.False(t, client.HealthReport().Consumers[0].Healthy)
+
+	// The subscription is attempted again until the exclusivity is acquired.
+	server.setExclusive("events", false)
+
+	require.Eventually(t, func() bool { return server.consumed("events") }, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return client.HealthReport().Consumers[0].Err == nil }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,77 @@
+package gorabbit
+
+import (
+	"context"
+)
+
+// Client is the single entry point over the consumer and publisher connections of a client, and over the connection
+// setting up the topology, all dialed from the same ClientOptions. It is implemented by the MQTTClient returned by
+// NewClient.
+type Client interface {
+	Publisher
+	ConsumerRegistry
+	TopologyManager
+
+	// Health returns a detailed view of the client's health, as HealthReport does.
+	Health() HealthReport
+
+	// Close closes the consumer, publisher and topology connections, as Disconnect does.
+	Close() error
+}
+
+func (client *mqttClient) SetupTopology(ctx context.Context, exchanges []ExchangeConfig, queues []QueueConfig) (Teardown, error) {
+	// client is disabled, so we do nothing and return no error.
+	if client.disabled {
+		return noTeardown, nil
+	}
+
+	manager, err := client.topologyManager()
+	if err != nil {
+		return noTeardown, err
+	}
+
+	return manager.SetupTopology(ctx, exchanges, queues)
+}
+
+func (client *mqttClient) Health() HealthReport {
+	return client.HealthReport()
+}
+
+func (client *mqttClient) Close() error {
+	return client.Disconnect()
+}
+
+// topologyManager returns the MQTTManager setting up the topology, dialing it on the first call.
+func (client *mqttClient) topologyManager() (MQTTManager, error) {
+	client.managerMutex.Lock()
+	defer client.managerMutex.Unlock()
+
+	if client.manager != nil {
+		return client.manager, nil
+	}
+
+	manager, err := newManagerFromOptions(client.managerOptions, client.topology)
+	if err != nil {
+		return nil, err
+	}
+
+	client.manager = manager
+
+	return manager, nil
+}
+
+// closeTopologyManager closes the connection of the MQTTManager setting up the topology, if dialed.
+func (client *mqttClient) closeTopologyManager() error {
+	client.managerMutex.Lock()
+	defer client.managerMutex.Unlock()
+
+	if client.manager == nil {
+		return nil
+	}
+
+	err := client.manager.Disconnect()
+
+	client.manager = nil
+
+	return err
+}
@@ -0,0 +1,1228 @@
+package gorabbit_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQP 0-9-1 frame types and methods spoken by the fakeServer.
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xCE
+
+	classConnection = 10
+	classChannel    = 20
+	classExchange   = 40
+	classQueue      = 50
+	classBasic      = 60
+	classConfirm    = 85
+)
+
+// fakeAction is the reply of a fakeServer to a publishing.
+type fakeAction int
+
+const (
+	// fakeAck confirms the publishing.
+	fakeAck fakeAction = iota
+
+	// fakeNack rejects the publishing.
+	fakeNack
+
+	// fakeReturn returns the publishing as unroutable, then confirms it.
+	fakeReturn
+
+	// fakeClose closes the channel without confirming the publishing.
+	fakeClose
+
+	// fakeHold neither confirms the publishing nor closes the channel.
+	fakeHold
+
+	// fakeDrop drops the connection without confirming the publishing.
+	fakeDrop
+)
+
+// fakePublishing is a publishing received by a fakeServer.
+type fakePublishing struct {
+	Exchange   string
+	RoutingKey string
+	Body       string
+	Headers    amqp.Table
+}
+
+// fakeDelivery is a message delivered by a fakeServer to the consumer of a queue.
+type fakeDelivery struct {
+	RoutingKey      string
+	Body            string
+	Redelivered     bool
+	ContentEncoding string
+	Priority        uint8
+	MessageID       string
+	Headers         amqp.Table
+}
+
+// fakeSettlement is the acknowledgement of a delivery of a fakeServer by its consumer.
+type fakeSettlement struct {
+	Body    string
+	Acked   bool
+	Requeue bool
+}
+
+// fakeDeclaration is an exchange, a queue or a binding declared by a client to a fakeServer.
+type fakeDeclaration struct {
+	Kind       string
+	Name       string
+	Exchange   string
+	RoutingKey string
+	Arguments  amqp.Table
+}
+
+// fakeMessage is a message stored in a queue of a fakeServer, waiting to be fetched.
+type fakeMessage struct {
+	queue       string
+	routingKey  string
+	redelivered bool
+	header      []byte
+	body        []byte
+}
+
+// fakeConsumer is a consumer of a queue of a fakeServer.
+type fakeConsumer struct {
+	conn      *fakeConn
+	channel   uint16
+	tag       string
+	queue     string
+	arguments amqp.Table
+}
+
+// fakeServer is a minimal AMQP 0-9-1 server accepting the connections and the channels of the clients, replying to
+// each publishing with the fakeAction decided by its onPublish function, and delivering to the consumers of a queue the
+// confirmed publishings routed to it by the default exchange.
+type fakeServer struct {
+	listener net.Listener
+
+	mutex       sync.Mutex
+	onPublish   func(publishing fakePublishing) fakeAction
+	received    []fakePublishing
+	acked       []fakePublishing
+	conns       []*fakeConn
+	consumers   []*fakeConsumer
+	settlements []fakeSettlement
+	cancels     int
+	consumes    []fakeConsumer
+	exclusive   map[string]bool
+	acks        int
+	declared    []fakeDeclaration
+	generated   int
+	depths      map[string]int
+	queued      map[string][]fakeMessage
+}
+
+// newFakeServer starts a fakeServer confirming every publishing, stopped at the end of the test.
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeServer{listener: listener}
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go s.accept()
+
+	return s
+}
+
+// port returns the port the fakeServer listens on.
+func (s *fakeServer) port() uint {
+	return uint(s.listener.Addr().(*net.TCPAddr).Port)
+}
+
+// setOnPublish replaces the function deciding the reply to each publishing, fakeAck if nil.
+func (s *fakeServer) setOnPublish(onPublish func(publishing fakePublishing) fakeAction) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.onPublish = onPublish
+}
+
+// setBlocked blocks or unblocks the connections of the clients, like a broker on a resource alarm.
+func (s *fakeServer) setBlocked(blocked bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, c := range s.conns {
+		if blocked {
+			c.writeMethod(0, classConnection, 60, newFakeArgs().shortstr("low on memory"))
+		} else {
+			c.writeMethod(0, classConnection, 61, newFakeArgs())
+		}
+	}
+}
+
+// ackedBodies returns the bodies of the confirmed publishings.
+func (s *fakeServer) ackedBodies() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	bodies := make([]string, 0, len(s.acked))
+
+	for _, publishing := range s.acked {
+		bodies = append(bodies, publishing.Body)
+	}
+
+	return bodies
+}
+
+// consumed returns true if the queue has a consumer.
+func (s *fakeServer) consumed(queue string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.consumer(queue) != nil
+}
+
+// consumeArguments returns the arguments of each consumption of the queue, in order.
+func (s *fakeServer) consumeArguments(queue string) []amqp.Table {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var arguments []amqp.Table
+
+	for _, consume := range s.consumes {
+		if consume.queue == queue {
+			arguments = append(arguments, consume.arguments)
+		}
+	}
+
+	return arguments
+}
+
+// consumerTags returns the consumer tag of each consumption of the queue, in order.
+func (s *fakeServer) consumerTags(queue string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var tags []string
+
+	for _, consume := range s.consumes {
+		if consume.queue == queue {
+			tags = append(tags, consume.tag)
+		}
+	}
+
+	return tags
+}
+
+// declarations returns the exchanges, queues and bindings declared by the clients, in order.
+func (s *fakeServer) declarations() []fakeDeclaration {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]fakeDeclaration(nil), s.declared...)
+}
+
+// declare records a declaration, without its arguments if empty.
+func (s *fakeServer) declare(declaration fakeDeclaration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(declaration.Arguments) == 0 {
+		declaration.Arguments = nil
+	}
+
+	s.declared = append(s.declared, declaration)
+}
+
+// declaredExchange returns true if the exchange was declared.
+func (s *fakeServer) declaredExchange(exchange string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, declaration := range s.declared {
+		if declaration.Kind == "exchange" && declaration.Name == exchange {
+			return true
+		}
+	}
+
+	return false
+}
+
+// declareQueue records the declaration of a queue, unless passive, and returns its name, generated by the server if
+// empty, its depth and its consumer count.
+func (s *fakeServer) declareQueue(args []byte) (string, int, int) {
+	reader := bytes.NewReader(args[2:])
+	queue := readShortstr(reader)
+	bits, _ := reader.ReadByte()
+	arguments := readTable(reader)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if queue == "" {
+		s.generated++
+		queue = "amq.gen-" + strconv.Itoa(s.generated)
+	}
+
+	if bits&1 == 0 {
+		if len(arguments) == 0 {
+			arguments = nil
+		}
+
+		s.declared = append(s.declared, fakeDeclaration{Kind: "queue", Name: queue, Arguments: arguments})
+	}
+
+	consumers := 0
+
+	for _, consumer := range s.consumers {
+		if consumer.queue == queue {
+			consumers++
+		}
+	}
+
+	return queue, s.depths[queue] + len(s.queued[queue]), consumers
+}
+
+// setDepth sets the number of messages ready in the queue, as reported to its declarations.
+func (s *fakeServer) setDepth(queue string, messages int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.depths == nil {
+		s.depths = make(map[string]int)
+	}
+
+	s.depths[queue] = messages
+}
+
+// setExclusive makes the queue consumed, or not, by the exclusive consumer of another client, so that the
+// subscriptions to it are refused.
+func (s *fakeServer) setExclusive(queue string, exclusive bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.exclusive == nil {
+		s.exclusive = make(map[string]bool)
+	}
+
+	s.exclusive[queue] = exclusive
+}
+
+// closeChannel closes the channel of the consumer of the queue, along with its other consumers, as a broker does on a
+// channel error.
+func (s *fakeServer) closeChannel(queue string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	consumer := s.consumer(queue)
+	if consumer == nil {
+		return
+	}
+
+	c := consumer.conn
+
+	remaining := s.consumers[:0]
+
+	for _, existing := range s.consumers {
+		if existing.conn != c || existing.channel != consumer.channel {
+			remaining = append(remaining, existing)
+		}
+	}
+
+	s.consumers = remaining
+
+	delete(c.delivered, consumer.channel)
+
+	c.writeMethod(consumer.channel, classChannel, 40, newFakeArgs().short(541).shortstr("INTERNAL_ERROR").short(0).short(0))
+}
+
+// sameChannel returns true if the queues are consumed on the same channel.
+func (s *fakeServer) sameChannel(queue, other string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	consumer, otherConsumer := s.consumer(queue), s.consumer(other)
+
+	return consumer != nil && otherConsumer != nil &&
+		consumer.conn == otherConsumer.conn && consumer.channel == otherConsumer.channel
+}
+
+// canceledCount returns the number of consumers canceled by the clients.
+func (s *fakeServer) canceledCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.cancels
+}
+
+// ackCount returns the number of acknowledgements received, each settling one delivery or several ones.
+func (s *fakeServer) ackCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.acks
+}
+
+// settled returns the acknowledgements of the deliveries, in order.
+func (s *fakeServer) settled() []fakeSettlement {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]fakeSettlement(nil), s.settlements...)
+}
+
+// deliver delivers the bodies to the consumer of the queue, with the given routing key and no properties.
+func (s *fakeServer) deliver(queue, routingKey string, bodies ...string) {
+	for _, body := range bodies {
+		s.deliverMessages(queue, fakeDelivery{RoutingKey: routingKey, Body: body})
+	}
+}
+
+// deliverMessages delivers the messages to the consumer of the queue, with their properties.
+func (s *fakeServer) deliverMessages(queue string, deliveries ...fakeDelivery) {
+	for _, delivery := range deliveries {
+		s.route(queue, delivery.RoutingKey, delivery.Redelivered, delivery.header(), []byte(delivery.Body))
+	}
+}
+
+// enqueue stores the messages in the queue, to be fetched with basic.get rather than delivered to its consumer.
+func (s *fakeServer) enqueue(queue string, deliveries ...fakeDelivery) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, delivery := range deliveries {
+		s.store(fakeMessage{
+			queue:       queue,
+			routingKey:  delivery.RoutingKey,
+			redelivered: delivery.Redelivered,
+			header:      delivery.header(),
+			body:        []byte(delivery.Body),
+		})
+	}
+}
+
+// store appends the message to its queue. The mutex must be held.
+func (s *fakeServer) store(message fakeMessage) {
+	if s.queued == nil {
+		s.queued = make(map[string][]fakeMessage)
+	}
+
+	s.queued[message.queue] = append(s.queued[message.queue], message)
+}
+
+// get sends the next message stored in the queue, or get-empty if there is none. The message is held by the channel
+// until it is settled, and put back at the head of the queue if requeued or if the channel is closed first.
+func (s *fakeServer) get(c *fakeConn, channel uint16, queue string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.queued[queue]) == 0 {
+		c.writeMethod(channel, classBasic, 72, newFakeArgs().shortstr(""))
+
+		return
+	}
+
+	message := s.queued[queue][0]
+	s.queued[queue] = s.queued[queue][1:]
+
+	c.deliveryTags[channel]++
+	tag := c.deliveryTags[channel]
+
+	if c.delivered[channel] == nil {
+		c.delivered[channel] = make(map[uint64]string)
+	}
+
+	c.delivered[channel][tag] = string(message.body)
+
+	if c.fetched[channel] == nil {
+		c.fetched[channel] = make(map[uint64]fakeMessage)
+	}
+
+	c.fetched[channel][tag] = message
+
+	var flags byte
+	if message.redelivered {
+		flags = 1
+	}
+
+	getOk := newFakeArgs().short(classBasic).short(71).
+		longlong(tag).octet(flags).shortstr("").shortstr(message.routingKey).long(uint32(len(s.queued[queue])))
+
+	c.writeContent(channel, getOk.Bytes(), message.header, message.body)
+}
+
+// requeue puts the fetched messages with the given tags back at the head of their queue, flagged as redelivered.
+// The mutex must be held.
+func (s *fakeServer) requeue(c *fakeConn, channel uint16, tags []uint64) {
+	sort.Slice(tags, func(i, j int) bool { return tags[i] > tags[j] })
+
+	for _, tag := range tags {
+		message, found := c.fetched[channel][tag]
+		if !found {
+			continue
+		}
+
+		delete(c.fetched[channel], tag)
+
+		message.redelivered = true
+
+		s.queued[message.queue] = append([]fakeMessage{message}, s.queued[message.queue]...)
+	}
+}
+
+// closeFetched puts the messages fetched by the channel and not settled yet back in their queue, as a broker does when
+// the channel is closed.
+func (s *fakeServer) closeFetched(c *fakeConn, channel uint16) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var tags []uint64
+
+	for tag := range c.fetched[channel] {
+		tags = append(tags, tag)
+	}
+
+	s.requeue(c, channel, tags)
+
+	delete(c.delivered, channel)
+}
+
+// header returns the content header of the delivery, holding its properties.
+func (d fakeDelivery) header() []byte {
+	var (
+		flags      uint16
+		properties = newFakeArgs()
+	)
+
+	if d.ContentEncoding != "" {
+		flags |= 1 << 14
+		properties.shortstr(d.ContentEncoding)
+	}
+
+	if d.Headers != nil {
+		flags |= 1 << 13
+		properties.fields(d.Headers)
+	}
+
+	if d.Priority != 0 {
+		flags |= 1 << 11
+		properties.octet(d.Priority)
+	}
+
+	if d.MessageID != "" {
+		flags |= 1 << 7
+		properties.shortstr(d.MessageID)
+	}
+
+	header := newFakeArgs().short(classBasic).short(0).longlong(uint64(len(d.Body))).short(flags)
+	header.Write(properties.Bytes())
+
+	return header.Bytes()
+}
+
+// route delivers a message to the consumer of the queue, or stores it in the queue if it has none.
+func (s *fakeServer) route(queue, routingKey string, redelivered bool, header, body []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	consumer := s.consumer(queue)
+	if consumer == nil {
+		s.store(fakeMessage{queue: queue, routingKey: routingKey, redelivered: redelivered, header: header, body: body})
+
+		return
+	}
+
+	c := consumer.conn
+
+	c.deliveryTags[consumer.channel]++
+	tag := c.deliveryTags[consumer.channel]
+
+	if c.delivered[consumer.channel] == nil {
+		c.delivered[consumer.channel] = make(map[uint64]string)
+	}
+
+	c.delivered[consumer.channel][tag] = string(body)
+
+	var flags byte
+	if redelivered {
+		flags = 1
+	}
+
+	deliver := newFakeArgs().short(classBasic).short(60).
+		shortstr(consumer.tag).longlong(tag).octet(flags).shortstr("").shortstr(routingKey)
+
+	c.writeContent(consumer.channel, deliver.Bytes(), header, body)
+}
+
+// consumer returns the consumer of the queue, if any. The mutex must be held.
+func (s *fakeServer) consumer(queue string) *fakeConsumer {
+	for _, consumer := range s.consumers {
+		if consumer.queue == queue {
+			return consumer
+		}
+	}
+
+	return nil
+}
+
+// settle records the acknowledgement of the deliveries of the channel up to the tag, or of the tag only.
+func (s *fakeServer) settle(c *fakeConn, channel uint16, tag uint64, multiple, acked, requeue bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delivered := c.delivered[channel]
+
+	if acked {
+		s.acks++
+	}
+
+	tags := []uint64{tag}
+
+	if multiple {
+		tags = nil
+
+		for delivery := range delivered {
+			if delivery <= tag {
+				tags = append(tags, delivery)
+			}
+		}
+
+		sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+	}
+
+	for _, delivery := range tags {
+		body, found := delivered[delivery]
+		if !found {
+			continue
+		}
+
+		delete(delivered, delivery)
+
+		s.settlements = append(s.settlements, fakeSettlement{Body: body, Acked: acked, Requeue: requeue})
+	}
+
+	if requeue {
+		s.requeue(c, channel, tags)
+	}
+
+	for _, delivery := range tags {
+		delete(c.fetched[channel], delivery)
+	}
+}
+
+// publishings returns the publishings received, in order.
+func (s *fakeServer) publishings() []fakePublishing {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]fakePublishing(nil), s.received...)
+}
+
+// receivedCount returns the number of publishings received.
+func (s *fakeServer) receivedCount() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.received)
+}
+
+// decide records the publishing and returns the reply to it.
+func (s *fakeServer) decide(publishing fakePublishing) fakeAction {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.received = append(s.received, publishing)
+
+	action := fakeAck
+	if s.onPublish != nil {
+		action = s.onPublish(publishing)
+	}
+
+	if action == fakeAck || action == fakeReturn {
+		s.acked = append(s.acked, publishing)
+	}
+
+	return action
+}
+
+func (s *fakeServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.serve(conn)
+	}
+}
+
+// fakeConn is a connection of a client to a fakeServer.
+type fakeConn struct {
+	reader *bufio.Reader
+	writer io.Writer
+
+	// writeMutex serializes the frames written by the connection and by the fakeServer.
+	writeMutex sync.Mutex
+
+	// tags holds the last delivery tag of each channel in confirm mode.
+	tags map[uint16]uint64
+
+	// closing holds the channels closed by the server, whose publishings are discarded until the client acknowledges
+	// the closing.
+	closing map[uint16]bool
+
+	// deliveryTags holds the last delivery tag of each channel, and delivered the bodies of its unsettled deliveries
+	// by tag. They are protected by the mutex of the fakeServer.
+	deliveryTags map[uint16]uint64
+	delivered    map[uint16]map[uint64]string
+
+	// fetched holds the messages fetched by each channel with basic.get and not settled yet, by tag. They are protected
+	// by the mutex of the fakeServer.
+	fetched map[uint16]map[uint64]fakeMessage
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	c := &fakeConn{
+		reader:       bufio.NewReader(conn),
+		writer:       conn,
+		tags:         make(map[uint16]uint64),
+		closing:      make(map[uint16]bool),
+		deliveryTags: make(map[uint16]uint64),
+		delivered:    make(map[uint16]map[uint64]string),
+		fetched:      make(map[uint16]map[uint64]fakeMessage),
+	}
+
+	defer s.forget(c)
+
+	// The protocol header.
+	if _, err := io.ReadFull(c.reader, make([]byte, 8)); err != nil {
+		return
+	}
+
+	s.mutex.Lock()
+	s.conns = append(s.conns, c)
+	s.mutex.Unlock()
+
+	// connection.start, with no server properties, the PLAIN mechanism and the en_US locale.
+	start := newFakeArgs().octet(0).octet(9).table().longstr("PLAIN").longstr("en_US")
+	c.writeMethod(0, classConnection, 10, start)
+
+	for {
+		frameType, channel, payload, err := c.readFrame()
+		if err != nil {
+			return
+		}
+
+		if frameType != frameMethod {
+			continue
+		}
+
+		class, method := binary.BigEndian.Uint16(payload), binary.BigEndian.Uint16(payload[2:])
+		args := payload[4:]
+
+		switch {
+		case class == classConnection && method == 11: // start-ok
+			c.writeMethod(0, classConnection, 30, newFakeArgs().short(2047).long(131072).short(0))
+		case class == classConnection && method == 40: // open
+			c.writeMethod(0, classConnection, 41, newFakeArgs().shortstr(""))
+		case class == classConnection && method == 50: // close
+			c.writeMethod(0, classConnection, 51, newFakeArgs())
+
+			return
+		case class == classChannel && method == 10: // open
+			delete(c.tags, channel)
+			delete(c.closing, channel)
+			c.writeMethod(channel, classChannel, 11, newFakeArgs().longstr(""))
+		case class == classChannel && method == 40: // close
+			s.closeFetched(c, channel)
+
+			c.writeMethod(channel, classChannel, 41, newFakeArgs())
+		case class == classChannel && method == 41: // close-ok
+			delete(c.closing, channel)
+		case class == classConfirm && method == 10: // select
+			c.tags[channel] = 0
+			c.writeMethod(channel, classConfirm, 11, newFakeArgs())
+		case class == classBasic && method == 40: // publish
+			if !s.publish(c, channel, args) {
+				return
+			}
+		case class == classQueue && method == 10: // declare
+			queue, messages, consumers := s.declareQueue(args)
+
+			c.writeMethod(channel, classQueue, 11, newFakeArgs().shortstr(queue).long(uint32(messages)).long(uint32(consumers)))
+		case class == classQueue && method == 20: // bind
+			reader := bytes.NewReader(args[2:])
+			queue := readShortstr(reader)
+			exchange := readShortstr(reader)
+			routingKey := readShortstr(reader)
+			_, _ = reader.ReadByte()
+
+			s.declare(fakeDeclaration{Kind: "binding", Name: queue, Exchange: exchange, RoutingKey: routingKey, Arguments: readTable(reader)})
+
+			c.writeMethod(channel, classQueue, 21, newFakeArgs())
+		case class == classExchange && method == 10: // declare
+			reader := bytes.NewReader(args[2:])
+			exchange := readShortstr(reader)
+			kind := readShortstr(reader)
+			bits, _ := reader.ReadByte()
+
+			// Like a broker, the server closes the channel passively declaring an exchange that was never declared.
+			if bits&1 != 0 {
+				if !s.declaredExchange(exchange) {
+					c.closing[channel] = true
+					c.writeMethod(channel, classChannel, 40, newFakeArgs().short(404).shortstr("NOT_FOUND").short(classExchange).short(10))
+
+					continue
+				}
+			} else {
+				s.declare(fakeDeclaration{Kind: "exchange", Name: exchange, RoutingKey: kind, Arguments: readTable(reader)})
+			}
+
+			c.writeMethod(channel, classExchange, 11, newFakeArgs())
+		case class == classQueue && method == 40: // delete
+			c.writeMethod(channel, classQueue, 41, newFakeArgs().long(0))
+		case class == classBasic && method == 70: // get
+			s.get(c, channel, readShortstr(bytes.NewReader(args[2:])))
+		case class == classBasic && method == 10: // qos
+			c.writeMethod(channel, classBasic, 11, newFakeArgs())
+		case class == classBasic && method == 20: // consume
+			reader := bytes.NewReader(args[2:])
+			queue := readShortstr(reader)
+			tag := readShortstr(reader)
+			flags, _ := reader.ReadByte()
+
+			consumer := &fakeConsumer{conn: c, channel: channel, tag: tag, queue: queue, arguments: readTable(reader)}
+
+			// A queue consumed exclusively refuses the other consumers, as does a queue in use an exclusive one.
+			s.mutex.Lock()
+			refused := s.exclusive[queue] || (flags&4 != 0 && s.consumer(queue) != nil)
+
+			if !refused {
+				s.consumers = append(s.consumers, consumer)
+				s.consumes = append(s.consumes, *consumer)
+			}
+			s.mutex.Unlock()
+
+			if refused {
+				c.writeMethod(channel, classChannel, 40, newFakeArgs().short(403).shortstr("ACCESS_REFUSED").short(classBasic).short(20))
+			} else {
+				c.writeMethod(channel, classBasic, 21, newFakeArgs().shortstr(tag))
+			}
+		case class == classBasic && method == 30: // cancel
+			tag := readShortstr(bytes.NewReader(args))
+
+			s.cancel(c, tag)
+
+			c.writeMethod(channel, classBasic, 31, newFakeArgs().shortstr(tag))
+		case class == classBasic && method == 80: // ack
+			s.settle(c, channel, binary.BigEndian.Uint64(args), args[8]&1 != 0, true, false)
+		case class == classBasic && method == 90: // reject
+			s.settle(c, channel, binary.BigEndian.Uint64(args), false, false, args[8]&1 != 0)
+		case class == classBasic && method == 120: // nack
+			s.settle(c, channel, binary.BigEndian.Uint64(args), args[8]&1 != 0, false, args[8]&2 != 0)
+		}
+	}
+}
+
+// cancel removes the consumer of the connection with the given tag.
+func (s *fakeServer) cancel(c *fakeConn, tag string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, consumer := range s.consumers {
+		if consumer.conn == c && consumer.tag == tag {
+			s.consumers = append(s.consumers[:i], s.consumers[i+1:]...)
+			s.cancels++
+
+			return
+		}
+	}
+}
+
+// forget removes the consumers of a closed connection, and puts its unsettled fetched messages back in their queue.
+func (s *fakeServer) forget(c *fakeConn) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	consumers := s.consumers[:0]
+
+	for _, consumer := range s.consumers {
+		if consumer.conn != c {
+			consumers = append(consumers, consumer)
+		}
+	}
+
+	s.consumers = consumers
+
+	// The messages fetched by the connection and not settled are put back in their queue.
+	for channel, fetched := range c.fetched {
+		var tags []uint64
+
+		for tag := range fetched {
+			tags = append(tags, tag)
+		}
+
+		s.requeue(c, channel, tags)
+	}
+}
+
+// publish reads the content of a publishing and replies to it. Returns false if the connection failed.
+func (s *fakeServer) publish(c *fakeConn, channel uint16, args []byte) bool {
+	reader := bytes.NewReader(args[2:])
+	exchange := readShortstr(reader)
+	routingKey := readShortstr(reader)
+
+	// The content header, then the body frames.
+	_, _, header, err := c.readFrame()
+	if err != nil {
+		return false
+	}
+
+	size := binary.BigEndian.Uint64(header[4:])
+
+	var body []byte
+
+	for uint64(len(body)) < size {
+		_, _, frame, err := c.readFrame()
+		if err != nil {
+			return false
+		}
+
+		body = append(body, frame...)
+	}
+
+	// Like a broker, the server ignores the publishings of a channel it is closing.
+	if c.closing[channel] {
+		return true
+	}
+
+	action := s.decide(fakePublishing{Exchange: exchange, RoutingKey: routingKey, Body: string(body), Headers: readHeaders(header)})
+
+	c.tags[channel]++
+	tag := c.tags[channel]
+
+	switch action {
+	case fakeAck:
+		c.writeMethod(channel, classBasic, 80, newFakeArgs().longlong(tag).octet(0))
+
+		// The default exchange routes the publishing to the queue named by its routing key.
+		if exchange == "" {
+			s.route(routingKey, routingKey, false, header, body)
+		}
+	case fakeNack:
+		c.writeMethod(channel, classBasic, 120, newFakeArgs().longlong(tag).octet(0))
+	case fakeReturn:
+		c.writeMethod(channel, classBasic, 50, newFakeArgs().short(312).shortstr("NO_ROUTE").shortstr(exchange).shortstr(routingKey))
+		c.writeFrame(frameHeader, channel, header)
+		c.writeFrame(frameBody, channel, body)
+		c.writeMethod(channel, classBasic, 80, newFakeArgs().longlong(tag).octet(0))
+	case fakeClose:
+		c.closing[channel] = true
+		c.writeMethod(channel, classChannel, 40, newFakeArgs().short(541).shortstr("INTERNAL_ERROR").short(classBasic).short(40))
+	case fakeDrop:
+		return false
+	}
+
+	return true
+}
+
+// readFrame reads the next frame, skipping the heartbeats.
+func (c *fakeConn) readFrame() (byte, uint16, []byte, error) {
+	for {
+		head := make([]byte, 7)
+		if _, err := io.ReadFull(c.reader, head); err != nil {
+			return 0, 0, nil, err
+		}
+
+		payload := make([]byte, binary.BigEndian.Uint32(head[3:])+1)
+		if _, err := io.ReadFull(c.reader, payload); err != nil {
+			return 0, 0, nil, err
+		}
+
+		if head[0] == frameHeartbeat {
+			continue
+		}
+
+		return head[0], binary.BigEndian.Uint16(head[1:]), payload[:len(payload)-1], nil
+	}
+}
+
+func (c *fakeConn) writeMethod(channel uint16, class, method uint16, args *fakeArgs) {
+	payload := newFakeArgs().short(class).short(method)
+	payload.Write(args.Bytes())
+
+	c.writeFrame(frameMethod, channel, payload.Bytes())
+}
+
+// writeContent writes a content method along with its header and body frames, which must not be interleaved with other
+// frames of the channel.
+func (c *fakeConn) writeContent(channel uint16, method, header, body []byte) {
+	var frames bytes.Buffer
+
+	for _, frame := range []struct {
+		frameType byte
+		payload   []byte
+	}{{frameMethod, method}, {frameHeader, header}, {frameBody, body}} {
+		// An empty body has no body frame.
+		if frame.frameType == frameBody && len(frame.payload) == 0 {
+			continue
+		}
+
+		frames.Write(newFakeArgs().octet(frame.frameType).short(channel).long(uint32(len(frame.payload))).Bytes())
+		frames.Write(frame.payload)
+		frames.WriteByte(frameEnd)
+	}
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	_, _ = c.writer.Write(frames.Bytes())
+}
+
+func (c *fakeConn) writeFrame(frameType byte, channel uint16, payload []byte) {
+	frame := newFakeArgs().octet(frameType).short(channel).long(uint32(len(payload)))
+	frame.Write(payload)
+	frame.WriteByte(frameEnd)
+
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	_, _ = c.writer.Write(frame.Bytes())
+}
+
+// fakeArgs encodes the arguments of a method.
+type fakeArgs struct {
+	bytes.Buffer
+}
+
+func newFakeArgs() *fakeArgs {
+	return &fakeArgs{}
+}
+
+func (a *fakeArgs) octet(v byte) *fakeArgs {
+	a.WriteByte(v)
+
+	return a
+}
+
+func (a *fakeArgs) short(v uint16) *fakeArgs {
+	_ = binary.Write(a, binary.BigEndian, v)
+
+	return a
+}
+
+func (a *fakeArgs) long(v uint32) *fakeArgs {
+	_ = binary.Write(a, binary.BigEndian, v)
+
+	return a
+}
+
+func (a *fakeArgs) longlong(v uint64) *fakeArgs {
+	_ = binary.Write(a, binary.BigEndian, v)
+
+	return a
+}
+
+func (a *fakeArgs) shortstr(v string) *fakeArgs {
+	a.WriteByte(byte(len(v)))
+	a.WriteString(v)
+
+	return a
+}
+
+func (a *fakeArgs) longstr(v string) *fakeArgs {
+	a.long(uint32(len(v)))
+	a.WriteString(v)
+
+	return a
+}
+
+// table encodes an empty table.
+func (a *fakeArgs) table() *fakeArgs {
+	return a.long(0)
+}
+
+// fields encodes a table.
+func (a *fakeArgs) fields(table amqp.Table) *fakeArgs {
+	encoded := newFakeArgs()
+
+	for key, value := range table {
+		encoded.shortstr(key)
+		encoded.field(value)
+	}
+
+	a.long(uint32(encoded.Len()))
+	a.Write(encoded.Bytes())
+
+	return a
+}
+
+// field encodes the value of a field of a table or of an array.
+func (a *fakeArgs) field(value interface{}) {
+	switch v := value.(type) {
+	case string:
+		a.octet('S').longstr(v)
+	case bool:
+		if v {
+			a.octet('t').octet(1)
+		} else {
+			a.octet('t').octet(0)
+		}
+	case int32:
+		a.octet('I').long(uint32(v))
+	case int64:
+		a.octet('l').longlong(uint64(v))
+	case int:
+		a.octet('l').longlong(uint64(v))
+	case time.Time:
+		a.octet('T').longlong(uint64(v.Unix()))
+	case amqp.Table:
+		a.octet('F').fields(v)
+	case []interface{}:
+		encoded := newFakeArgs()
+
+		for _, item := range v {
+			encoded.field(item)
+		}
+
+		a.octet('A').long(uint32(encoded.Len()))
+		a.Write(encoded.Bytes())
+	default:
+		a.octet('V')
+	}
+}
+
+// readHeaders decodes the headers of a content header, nil if it has none.
+func readHeaders(header []byte) amqp.Table {
+	flags := binary.BigEndian.Uint16(header[12:])
+	reader := bytes.NewReader(header[14:])
+
+	// The content type and encoding precede the headers.
+	for _, flag := range []uint16{1 << 15, 1 << 14} {
+		if flags&flag != 0 {
+			readShortstr(reader)
+		}
+	}
+
+	if flags&(1<<13) == 0 {
+		return nil
+	}
+
+	return readTable(reader)
+}
+
+func readTable(reader *bytes.Reader) amqp.Table {
+	var size uint32
+	_ = binary.Read(reader, binary.BigEndian, &size)
+
+	data := make([]byte, size)
+	_, _ = io.ReadFull(reader, data)
+
+	fields := bytes.NewReader(data)
+	table := amqp.Table{}
+
+	for fields.Len() > 0 {
+		key := readShortstr(fields)
+		table[key] = readField(fields)
+	}
+
+	return table
+}
+
+func readField(reader *bytes.Reader) interface{} {
+	kind, _ := reader.ReadByte()
+
+	switch kind {
+	case 't':
+		value, _ := reader.ReadByte()
+
+		return value != 0
+	case 'b':
+		value, _ := reader.ReadByte()
+
+		return int8(value)
+	case 'B':
+		value, _ := reader.ReadByte()
+
+		return value
+	case 's':
+		var value int16
+		_ = binary.Read(reader, binary.BigEndian, &value)
+
+		return value
+	case 'I':
+		var value int32
+		_ = binary.Read(reader, binary.BigEndian, &value)
+
+		return value
+	case 'l':
+		var value int64
+		_ = binary.Read(reader, binary.BigEndian, &value)
+
+		return value
+	case 'd':
+		var value float64
+		_ = binary.Read(reader, binary.BigEndian, &value)
+
+		return value
+	case 'T':
+		var value uint64
+		_ = binary.Read(reader, binary.BigEndian, &value)
+
+		return time.Unix(int64(value), 0)
+	case 'S', 'x':
+		var size uint32
+		_ = binary.Read(reader, binary.BigEndian, &size)
+
+		value := make([]byte, size)
+		_, _ = io.ReadFull(reader, value)
+
+		return string(value)
+	case 'F':
+		return readTable(reader)
+	case 'A':
+		var size uint32
+		_ = binary.Read(reader, binary.BigEndian, &size)
+
+		data := make([]byte, size)
+		_, _ = io.ReadFull(reader, data)
+
+		items := bytes.NewReader(data)
+
+		var array []interface{}
+
+		for items.Len() > 0 {
+			array = append(array, readField(items))
+		}
+
+		return array
+	default:
+		return nil
+	}
+}
+
+func readShortstr(reader *bytes.Reader) string {
+	size, _ := reader.ReadByte()
+
+	value := make([]byte, size)
+	_, _ = io.ReadFull(reader, value)
+
+	return string(value)
+}
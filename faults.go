@@ -0,0 +1,141 @@
+package gorabbit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// faultExchangePrefix prefixes the exchanges, which never exist, passively declared to make the server close a channel.
+const faultExchangePrefix = "gorabbit.fault."
+
+// FaultInjector injects failures into a client, so that resilience tests can exercise its recovery paths, such as the
+// reconnection of the channels and the caching of the publishings, deterministically and without stopping the broker.
+// It is only enabled by setting it on the ClientOptions, and must not be used in production.
+type FaultInjector struct {
+	// droppedPublishes is the number of next publishings to drop.
+	droppedPublishes atomic.Int64
+
+	// confirmsDelay is the delay added to the confirmations of the publishings.
+	confirmsDelay atomic.Int64
+
+	// channels holds the channels of the client.
+	channels []*amqpChannel
+
+	// mutex protects channels from concurrent access.
+	mutex sync.Mutex
+}
+
+// NewFaultInjector returns a FaultInjector injecting no failure until told to.
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{}
+}
+
+// DropPublishes drops the next n publishings as if the publishing channel were down: they are cached if the client is
+// kept alive, and fail with ErrInjectedFault.
+func (f *FaultInjector) DropPublishes(n int) {
+	f.droppedPublishes.Store(int64(n))
+}
+
+// DelayConfirms delays the confirmations of the publishings, as awaited by the reliable and the pipelined publishings,
+// and as received by the OnConfirm callback of the BrokerNotifications and by the AuditSink. A confirmation delayed
+// beyond the deadline of the publishing makes it time out. A zero delay stops delaying them.
+func (f *FaultInjector) DelayConfirms(delay time.Duration) {
+	f.confirmsDelay.Store(int64(delay))
+}
+
+// CloseChannels makes the server close all opened channels of the client with an error, as if they were lost, and
+// returns the number of channels closed. The channels of a client kept alive are then reopened.
+func (f *FaultInjector) CloseChannels() int {
+	f.mutex.Lock()
+	channels := make([]*amqpChannel, len(f.channels))
+	copy(channels, f.channels)
+	f.mutex.Unlock()
+
+	closed := 0
+
+	for _, channel := range channels {
+		if !channel.ready() {
+			continue
+		}
+
+		channel.logger.Warn("Closing channel by fault injection")
+
+		// Passively declaring an exchange that does not exist is a channel error, closing the channel server-side.
+		_ = channel.channel.Load().ExchangeDeclarePassive(faultExchangePrefix+uuid.NewString(), "direct", false, false, false, false, nil)
+
+		closed++
+	}
+
+	return closed
+}
+
+// register adds a channel of the client, to be closed by CloseChannels.
+func (f *FaultInjector) register(channel *amqpChannel) {
+	if f == nil {
+		return
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.channels = append(f.channels, channel)
+}
+
+// dropPublish returns true if the publishing must be dropped.
+func (f *FaultInjector) dropPublish() bool {
+	if f == nil {
+		return false
+	}
+
+	for {
+		remaining := f.droppedPublishes.Load()
+		if remaining <= 0 {
+			return false
+		}
+
+		if f.droppedPublishes.CompareAndSwap(remaining, remaining-1) {
+			return true
+		}
+	}
+}
+
+// delayConfirm waits for the delay added to the confirmations, if any.
+func (f *FaultInjector) delayConfirm() {
+	if f == nil {
+		return
+	}
+
+	if delay := time.Duration(f.confirmsDelay.Load()); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// awaitConfirm waits, until the context is done, for the confirmation of a publishing sent at the given time, and for
+// the delay added to the confirmations since then, if any. Returns true if the publishing was acked, and the error of
+// the context if done first.
+func (f *FaultInjector) awaitConfirm(ctx context.Context, confirmation *amqp.DeferredConfirmation, sent time.Time) (bool, error) {
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil || f == nil {
+		return acked, err
+	}
+
+	delay := time.Duration(f.confirmsDelay.Load()) - time.Since(sent)
+	if delay <= 0 {
+		return acked, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return acked, nil
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
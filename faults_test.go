@@ -0,0 +1,103 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestFaultInjector_DropPublishes(t *testing.T) {
+	faults := gorabbit.NewFaultInjector()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetKeepAlive(false).
+		SetFaultInjector(faults))
+
+	defer func() { _ = client.Disconnect() }()
+
+	faults.DropPublishes(1)
+
+	err := client.Publish("events_exchange", "event.created", "payload")
+	require.ErrorIs(t, err, gorabbit.ErrInjectedFault)
+
+	// Only the next publishing is dropped, the following one fails because nothing listens on the port.
+	err = client.Publish("events_exchange", "event.created", "payload")
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, gorabbit.ErrInjectedFault)
+}
+
+func TestFaultInjector_CloseChannels(t *testing.T) {
+	faults := gorabbit.NewFaultInjector()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetFaultInjector(faults))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// Nothing listens on the port, so no channel is opened.
+	assert.Zero(t, faults.CloseChannels())
+}
+
+func TestFaultInjector_DelayConfirms(t *testing.T) {
+	server := newFakeServer(t)
+	faults := gorabbit.NewFaultInjector()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetReliablePublishing(true).
+		SetFaultInjector(faults))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	faults.DelayConfirms(time.Second)
+
+	// The confirmation is delayed beyond the deadline of the publishing.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	faults.DelayConfirms(0)
+
+	require.NoError(t, client.Publish("events_exchange", "event.created", "payload"))
+}
+
+func TestFaultInjector_DelayConfirms_Pipeline(t *testing.T) {
+	server := newFakeServer(t)
+	recorder := &publishRecorder{}
+	faults := gorabbit.NewFaultInjector()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetMetrics(recorder).
+		SetPublishingPipeline(gorabbit.PublishingPipeline{ConfirmTimeout: 50 * time.Millisecond, MaxAttempts: 1}).
+		SetFaultInjector(faults))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	faults.DelayConfirms(time.Second)
+
+	// The confirmation is delayed beyond the ConfirmTimeout of the pipeline, so the publishing is dropped.
+	require.NoError(t, client.Publish("events_exchange", "event.created", "payload"))
+
+	assert.Eventually(t, func() bool {
+		return recorder.count(gorabbit.PublishOutcomeFailure) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Zero(t, recorder.count(gorabbit.PublishOutcomeSuccess))
+}
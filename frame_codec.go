@@ -0,0 +1,114 @@
+package gorabbit
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// frameBuffer encodes the fields of the frames of the binary protocols, in network order.
+type frameBuffer struct {
+	bytes.Buffer
+}
+
+func (b *frameBuffer) uint8(v uint8) *frameBuffer {
+	b.WriteByte(v)
+
+	return b
+}
+
+func (b *frameBuffer) uint16(v uint16) *frameBuffer {
+	_ = binary.Write(b, binary.BigEndian, v)
+
+	return b
+}
+
+func (b *frameBuffer) uint32(v uint32) *frameBuffer {
+	_ = binary.Write(b, binary.BigEndian, v)
+
+	return b
+}
+
+func (b *frameBuffer) uint64(v uint64) *frameBuffer {
+	_ = binary.Write(b, binary.BigEndian, v)
+
+	return b
+}
+
+func (b *frameBuffer) string(v string) *frameBuffer {
+	b.uint16(uint16(len(v)))
+	b.WriteString(v)
+
+	return b
+}
+
+func (b *frameBuffer) bytes(v []byte) *frameBuffer {
+	b.uint32(uint32(len(v)))
+	b.Write(v)
+
+	return b
+}
+
+// frameReader decodes the fields of the frames of the binary protocols, its first error making the following reads
+// return zero values.
+type frameReader struct {
+	data []byte
+	err  error
+}
+
+func (r *frameReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if n < 0 || len(r.data) < n {
+		r.err = errTruncatedFrame
+
+		return nil
+	}
+
+	v := r.data[:n]
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *frameReader) uint8() uint8 {
+	if v := r.next(1); v != nil {
+		return v[0]
+	}
+
+	return 0
+}
+
+func (r *frameReader) uint16() uint16 {
+	if v := r.next(2); v != nil {
+		return binary.BigEndian.Uint16(v)
+	}
+
+	return 0
+}
+
+func (r *frameReader) uint32() uint32 {
+	if v := r.next(4); v != nil {
+		return binary.BigEndian.Uint32(v)
+	}
+
+	return 0
+}
+
+func (r *frameReader) uint64() uint64 {
+	if v := r.next(8); v != nil {
+		return binary.BigEndian.Uint64(v)
+	}
+
+	return 0
+}
+
+func (r *frameReader) string() string {
+	size := int16(r.uint16())
+	if size <= 0 {
+		return ""
+	}
+
+	return string(r.next(int(size)))
+}
@@ -0,0 +1,184 @@
+// Package gorabbitjsonschema validates the payloads of a gorabbit client against JSON Schemas.
+package gorabbitjsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// ErrInvalidPayload is wrapped by the ValidationError of a payload that does not match its schema.
+var ErrInvalidPayload = errors.New("payload does not match its JSON schema")
+
+// Violation is a part of a payload that does not match its schema.
+type Violation struct {
+	// Location is the JSON pointer to the invalid value of the payload.
+	Location string
+
+	// Message describes the violation.
+	Message string
+}
+
+// ValidationError is the error of a payload that does not match its schema, detailing every violation.
+type ValidationError struct {
+	// Schema is the key the schema is registered with.
+	Schema string
+
+	// Violations are the violations of the schema.
+	Violations []Violation
+}
+
+// Error returns the violations of the schema.
+func (e *ValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+
+	for _, violation := range e.Violations {
+		violations = append(violations, fmt.Sprintf("'%s': %s", violation.Location, violation.Message))
+	}
+
+	return fmt.Sprintf("%s '%s': %s", ErrInvalidPayload, e.Schema, strings.Join(violations, "; "))
+}
+
+// Unwrap returns ErrInvalidPayload.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidPayload
+}
+
+// Marshaller is a gorabbit.Marshaller validating the payloads against the JSON Schema of their message type or routing
+// key, after marshalling them and before unmarshalling them. It is also a gorabbit.PayloadValidator, to validate the
+// deliveries of a consumer. Payloads without schema are not validated.
+type Marshaller struct {
+	marshaller gorabbit.Marshaller
+	schemas    map[string]*jsonschema.Schema
+	patterns   *gorabbit.RoutingKeyMatcher
+}
+
+// Compile-time assertion of the implemented interfaces.
+var (
+	_ gorabbit.Marshaller       = (*Marshaller)(nil)
+	_ gorabbit.PayloadValidator = (*Marshaller)(nil)
+)
+
+// New returns a Marshaller validating the payloads encoded by the given marshaller, the gorabbit.JSONMarshaller if nil.
+func New(marshaller gorabbit.Marshaller) *Marshaller {
+	if marshaller == nil {
+		marshaller = gorabbit.JSONMarshaller{}
+	}
+
+	return &Marshaller{
+		marshaller: marshaller,
+		schemas:    make(map[string]*jsonschema.Schema),
+	}
+}
+
+// AddSchema registers the JSON Schema of the messages of the given type, or of the routing keys matching the given
+// pattern. Among several matching patterns, the literal words are preferred over the wildcards, as for the handlers of
+// a consumer. The schemas must be registered before the Marshaller is used.
+func (m *Marshaller) AddSchema(key string, schema string) error {
+	// The key names the schema resource, whose URL would otherwise be cut at the '#' wildcard.
+	compiled, err := jsonschema.CompileString(url.PathEscape(key)+".json", schema)
+	if err != nil {
+		return fmt.Errorf("could not compile the JSON schema of '%s': %w", key, err)
+	}
+
+	m.schemas[key] = compiled
+
+	// The keys are compiled again as routing key patterns, so that looking up a schema does not compile them.
+	keys := make([]string, 0, len(m.schemas))
+
+	for schemaKey := range m.schemas {
+		keys = append(keys, schemaKey)
+	}
+
+	m.patterns = gorabbit.NewRoutingKeyMatcher(keys...)
+
+	return nil
+}
+
+// Marshal encodes the payload with the wrapped marshaller, then validates it.
+func (m *Marshaller) Marshal(metadata *gorabbit.MessageMetadata, payload interface{}) ([]byte, error) {
+	data, err := m.marshaller.Marshal(metadata, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.Validate(metadata, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Unmarshal validates the payload, then decodes it with the wrapped marshaller.
+func (m *Marshaller) Unmarshal(metadata *gorabbit.MessageMetadata, data []byte, v interface{}) error {
+	if err := m.Validate(metadata, data); err != nil {
+		return err
+	}
+
+	return m.marshaller.Unmarshal(metadata, data, v)
+}
+
+// Validate validates a payload against the schema of its message type or, failing that, of the first pattern matching
+// its routing key. It returns a *ValidationError if the payload does not match the schema.
+func (m *Marshaller) Validate(metadata *gorabbit.MessageMetadata, data []byte) error {
+	key, schema := m.schemaFor(metadata)
+	if schema == nil {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{Schema: key, Violations: []Violation{{Message: err.Error()}}}
+	}
+
+	err := schema.Validate(value)
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	return &ValidationError{Schema: key, Violations: violations(validationErr, nil)}
+}
+
+// schemaFor returns the schema of a message along with its key, or nil if the message has none.
+func (m *Marshaller) schemaFor(metadata *gorabbit.MessageMetadata) (string, *jsonschema.Schema) {
+	for _, key := range []string{metadata.Type, metadata.RoutingKey} {
+		if schema, found := m.schemas[key]; found {
+			return key, schema
+		}
+	}
+
+	if pattern, found := m.patterns.Match(metadata.RoutingKey); found {
+		return pattern, m.schemas[pattern]
+	}
+
+	return "", nil
+}
+
+// violations returns the leaves of a validation error, which are the actual violations.
+func violations(err *jsonschema.ValidationError, result []Violation) []Violation {
+	if len(err.Causes) == 0 {
+		return append(result, Violation{Location: err.InstanceLocation, Message: err.Message})
+	}
+
+	for _, cause := range err.Causes {
+		result = violations(cause, result)
+	}
+
+	return result
+}
@@ -0,0 +1,108 @@
+package gorabbitjsonschema_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbitjsonschema"
+)
+
+const paymentSchema = `{
+	"type": "object",
+	"required": ["amount", "currency"],
+	"properties": {
+		"amount": {"type": "number", "minimum": 0},
+		"currency": {"type": "string", "enum": ["EUR", "USD"]}
+	}
+}`
+
+func TestMarshaller(t *testing.T) {
+	marshaller := gorabbitjsonschema.New(nil)
+
+	require.NoError(t, marshaller.AddSchema("payment.*", paymentSchema))
+
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "payment.created", Type: "payment.created"}
+
+	data, err := marshaller.Marshal(metadata, map[string]interface{}{"amount": 12.5, "currency": "EUR"})
+	require.NoError(t, err)
+	assert.Equal(t, gorabbit.ContentTypeJSON, metadata.ContentType)
+
+	var payment struct {
+		Amount   float64 `json:"amount"`
+		Currency string  `json:"currency"`
+	}
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &payment))
+	assert.Equal(t, "EUR", payment.Currency)
+
+	_, err = marshaller.Marshal(metadata, map[string]interface{}{"amount": -1, "currency": "GBP"})
+	require.ErrorIs(t, err, gorabbitjsonschema.ErrInvalidPayload)
+
+	var validationErr *gorabbitjsonschema.ValidationError
+
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "payment.*", validationErr.Schema)
+	assert.Len(t, validationErr.Violations, 2)
+
+	// Messages without schema are not validated.
+	assert.NoError(t, marshaller.Validate(&gorabbit.MessageMetadata{RoutingKey: "order.created"}, []byte(`"anything"`)))
+}
+
+func TestMarshaller_AddSchema_Invalid(t *testing.T) {
+	assert.Error(t, gorabbitjsonschema.New(nil).AddSchema("payment.created", `{"type": 12}`))
+}
+
+func TestMarshaller_PatternPrecedence(t *testing.T) {
+	marshaller := gorabbitjsonschema.New(nil)
+
+	require.NoError(t, marshaller.AddSchema("*.created", `{"type": "object"}`))
+	require.NoError(t, marshaller.AddSchema("payment.*", paymentSchema))
+
+	// The most specific pattern is used, regardless of the order of registration.
+	err := marshaller.Validate(&gorabbit.MessageMetadata{RoutingKey: "payment.created"}, []byte(`{}`))
+
+	var validationErr *gorabbitjsonschema.ValidationError
+
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "payment.*", validationErr.Schema)
+
+	err = marshaller.Validate(&gorabbit.MessageMetadata{RoutingKey: "order.created"}, []byte(`"order"`))
+
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "*.created", validationErr.Schema)
+}
+
+func BenchmarkMarshaller_Validate(b *testing.B) {
+	marshaller := gorabbitjsonschema.New(nil)
+
+	for _, key := range []string{"payment.*", "payment.refund.*", "order.created", "*.failed"} {
+		require.NoError(b, marshaller.AddSchema(key, `{"type": "object"}`))
+	}
+
+	// The message matches no schema, so that only the lookup of the schema is measured.
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "shipment.label.printed"}
+	data := []byte(`{}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		_ = marshaller.Validate(metadata, data)
+	}
+}
+
+func TestMarshaller_AddSchema_MultiWordWildcard(t *testing.T) {
+	marshaller := gorabbitjsonschema.New(nil)
+
+	require.NoError(t, marshaller.AddSchema("payment.#", paymentSchema))
+
+	err := marshaller.Validate(&gorabbit.MessageMetadata{RoutingKey: "payment.refund.created"}, []byte(`{}`))
+
+	var validationErr *gorabbitjsonschema.ValidationError
+
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "payment.#", validationErr.Schema)
+}
@@ -0,0 +1,221 @@
+// Package gorabbitotel exposes the metrics of a gorabbit client as OpenTelemetry metrics.
+package gorabbitotel
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// meterName is the name of the instrumentation scope of the metrics.
+const meterName = "github.com/KardinalAI/gorabbit"
+
+// Attributes of the metrics that have no semantic convention.
+const (
+	connectionTypeAttribute = attribute.Key("messaging.gorabbit.connection.type")
+	eventAttribute          = attribute.Key("messaging.gorabbit.event")
+	consumerAttribute       = attribute.Key("messaging.gorabbit.consumer")
+	handlerAttribute        = attribute.Key("messaging.gorabbit.handler")
+	outcomeAttribute        = attribute.Key("messaging.gorabbit.outcome")
+)
+
+// Collector is a gorabbit.MetricsCollector recording the metrics of a client through an OpenTelemetry
+// metric.MeterProvider, with the messaging attributes:
+//   - connections and channels: lifecycle events, reconnections and current state.
+//   - publishings: count by exchange and outcome, payload sizes.
+//   - deliveries: count by consumer, queue, handler and outcome, handler durations.
+type Collector struct {
+	connectionEvents metric.Int64Counter
+	reconnections    metric.Int64Counter
+	channelEvents    metric.Int64Counter
+	publishes        metric.Int64Counter
+	publishedBytes   metric.Int64Histogram
+	deliveries       metric.Int64Counter
+	handlerDuration  metric.Float64Histogram
+
+	// connectionStates holds whether each type of connection is open, observed by the connection up gauge.
+	connectionStates map[string]int64
+
+	// connectionStatesMutex protects connectionStates from concurrent access.
+	connectionStatesMutex sync.Mutex
+}
+
+// Compile-time assertions of the implemented interfaces.
+var (
+	_ gorabbit.MetricsCollector           = (*Collector)(nil)
+	_ gorabbit.ConnectionMetricsCollector = (*Collector)(nil)
+	_ gorabbit.PublishMetricsCollector    = (*Collector)(nil)
+)
+
+// NewCollector returns a Collector creating its instruments with the given MeterProvider, or the global one if nil.
+// Returns an error if an instrument could not be created.
+func NewCollector(provider metric.MeterProvider) (*Collector, error) {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+
+	meter := provider.Meter(meterName)
+
+	c := &Collector{
+		connectionStates: make(map[string]int64),
+	}
+
+	var err error
+
+	if c.connectionEvents, err = meter.Int64Counter("messaging.gorabbit.connection.events",
+		metric.WithDescription("Lifecycle events of the connections, by connection type and event."),
+		metric.WithUnit("{event}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if c.reconnections, err = meter.Int64Counter("messaging.gorabbit.connection.reconnections",
+		metric.WithDescription("Successful reconnections after a lost connection, by connection type."),
+		metric.WithUnit("{reconnection}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if _, err = meter.Int64ObservableGauge("messaging.gorabbit.connection.up",
+		metric.WithDescription("Whether the connection is open, by connection type."),
+		metric.WithInt64Callback(c.observeConnectionStates),
+	); err != nil {
+		return nil, err
+	}
+
+	if c.channelEvents, err = meter.Int64Counter("messaging.gorabbit.channel.events",
+		metric.WithDescription("Lifecycle events of the channels, by connection type, consumer and event."),
+		metric.WithUnit("{event}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if c.publishes, err = meter.Int64Counter("messaging.publish.messages",
+		metric.WithDescription("Published messages, by exchange and outcome."),
+		metric.WithUnit("{message}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if c.publishedBytes, err = meter.Int64Histogram("messaging.publish.body.size",
+		metric.WithDescription("Size of the published payloads, by exchange."),
+		metric.WithUnit("By"),
+	); err != nil {
+		return nil, err
+	}
+
+	if c.deliveries, err = meter.Int64Counter("messaging.process.messages",
+		metric.WithDescription("Processed deliveries, by consumer, queue, handler and outcome."),
+		metric.WithUnit("{message}"),
+	); err != nil {
+		return nil, err
+	}
+
+	if c.handlerDuration, err = meter.Float64Histogram("messaging.process.duration",
+		metric.WithDescription("Processing time of the handlers, by consumer, queue and handler."),
+		metric.WithUnit("s"),
+	); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// ObserveConnection records a connection event.
+func (c *Collector) ObserveConnection(metrics gorabbit.ConnectionMetrics) {
+	ctx := context.Background()
+
+	connectionType := connectionTypeAttribute.String(metrics.Type)
+
+	c.connectionEvents.Add(ctx, 1, metric.WithAttributes(
+		semconv.MessagingSystemRabbitmq,
+		connectionType,
+		eventAttribute.String(metrics.Event.String()),
+	))
+
+	if metrics.Event == gorabbit.ConnectionEventReopened {
+		c.reconnections.Add(ctx, 1, metric.WithAttributes(semconv.MessagingSystemRabbitmq, connectionType))
+	}
+
+	c.connectionStatesMutex.Lock()
+	c.connectionStates[metrics.Type] = up(metrics.Event)
+	c.connectionStatesMutex.Unlock()
+}
+
+// ObserveChannel records a channel event.
+func (c *Collector) ObserveChannel(metrics gorabbit.ChannelMetrics) {
+	c.channelEvents.Add(context.Background(), 1, metric.WithAttributes(
+		semconv.MessagingSystemRabbitmq,
+		connectionTypeAttribute.String(metrics.Type),
+		consumerAttribute.String(metrics.Consumer),
+		eventAttribute.String(metrics.Event.String()),
+	))
+}
+
+// ObservePublish records a publishing.
+func (c *Collector) ObservePublish(metrics gorabbit.PublishMetrics) {
+	ctx := context.Background()
+
+	destination := semconv.MessagingDestinationName(metrics.Exchange)
+
+	c.publishes.Add(ctx, 1, metric.WithAttributes(
+		semconv.MessagingSystemRabbitmq,
+		destination,
+		outcomeAttribute.String(metrics.Outcome.String()),
+	))
+
+	if metrics.Outcome == gorabbit.PublishOutcomeSuccess {
+		c.publishedBytes.Record(ctx, int64(metrics.PayloadSize), metric.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			destination,
+		))
+	}
+}
+
+// ObserveDelivery records a processed delivery.
+func (c *Collector) ObserveDelivery(metrics gorabbit.DeliveryMetrics) {
+	ctx := context.Background()
+
+	attributes := []attribute.KeyValue{
+		semconv.MessagingSystemRabbitmq,
+		semconv.MessagingDestinationName(metrics.Queue),
+		consumerAttribute.String(metrics.Consumer),
+		handlerAttribute.String(metrics.Handler),
+	}
+
+	c.handlerDuration.Record(ctx, metrics.Duration.Seconds(), metric.WithAttributes(attributes...))
+
+	attributes = append(attributes, outcomeAttribute.String(metrics.Outcome.String()))
+
+	c.deliveries.Add(ctx, 1, metric.WithAttributes(attributes...))
+}
+
+// observeConnectionStates reports the state of each type of connection.
+func (c *Collector) observeConnectionStates(_ context.Context, observer metric.Int64Observer) error {
+	c.connectionStatesMutex.Lock()
+	defer c.connectionStatesMutex.Unlock()
+
+	for connectionType, state := range c.connectionStates {
+		observer.Observe(state, metric.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			connectionTypeAttribute.String(connectionType),
+		))
+	}
+
+	return nil
+}
+
+// up returns the state of a connection after an event.
+func up(event gorabbit.ConnectionEvent) int64 {
+	if event == gorabbit.ConnectionEventOpened || event == gorabbit.ConnectionEventReopened {
+		return 1
+	}
+
+	return 0
+}
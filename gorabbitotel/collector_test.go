@@ -0,0 +1,64 @@
+package gorabbitotel_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbitotel"
+)
+
+func TestCollector(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+
+	collector, err := gorabbitotel.NewCollector(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	collector.ObserveConnection(gorabbit.ConnectionMetrics{Type: "publisher", Event: gorabbit.ConnectionEventOpened})
+	collector.ObserveConnection(gorabbit.ConnectionMetrics{Type: "publisher", Event: gorabbit.ConnectionEventLost})
+
+	collector.ObservePublish(gorabbit.PublishMetrics{Exchange: "events_exchange", Outcome: gorabbit.PublishOutcomeSuccess, PayloadSize: 128})
+	collector.ObservePublish(gorabbit.PublishMetrics{Exchange: "events_exchange", Outcome: gorabbit.PublishOutcomeSuccess, PayloadSize: 64})
+
+	collector.ObserveDelivery(gorabbit.DeliveryMetrics{
+		Consumer: "events_consumer",
+		Queue:    "events_queue",
+		Handler:  "event.created",
+		Outcome:  gorabbit.DeliveryOutcomeRetry,
+		Duration: 20 * time.Millisecond,
+	})
+
+	var data metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(context.Background(), &data))
+	require.Len(t, data.ScopeMetrics, 1)
+
+	metrics := make(map[string]metricdata.Aggregation)
+
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	publishes, ok := metrics["messaging.publish.messages"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, publishes.DataPoints, 1)
+	assert.Equal(t, int64(2), publishes.DataPoints[0].Value)
+
+	up, ok := metrics["messaging.gorabbit.connection.up"].(metricdata.Gauge[int64])
+	require.True(t, ok)
+	require.Len(t, up.DataPoints, 1)
+	assert.Equal(t, int64(0), up.DataPoints[0].Value)
+
+	deliveries, ok := metrics["messaging.process.messages"].(metricdata.Sum[int64])
+	require.True(t, ok)
+	require.Len(t, deliveries.DataPoints, 1)
+
+	outcome, _ := deliveries.DataPoints[0].Attributes.Value("messaging.gorabbit.outcome")
+	assert.Equal(t, "retry", outcome.AsString())
+}
@@ -0,0 +1,163 @@
+// Package gorabbitprom exposes the metrics of a gorabbit client to Prometheus.
+package gorabbitprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// defaultNamespace is the namespace of the metrics if none is given.
+const defaultNamespace = "gorabbit"
+
+// Collector is a gorabbit.MetricsCollector recording the metrics of a client as Prometheus metrics:
+//   - connections and channels: lifecycle events, reconnections and current state.
+//   - publishings: count by exchange and outcome, payload sizes.
+//   - deliveries: count by consumer, queue, handler and outcome, handler durations.
+//
+// A Collector is a prometheus.Collector, to be registered once.
+type Collector struct {
+	connectionEvents *prometheus.CounterVec
+	connectionUp     *prometheus.GaugeVec
+	reconnections    *prometheus.CounterVec
+	channelEvents    *prometheus.CounterVec
+	channelUp        *prometheus.GaugeVec
+	publishes        *prometheus.CounterVec
+	publishedBytes   *prometheus.HistogramVec
+	deliveries       *prometheus.CounterVec
+	handlerDuration  *prometheus.HistogramVec
+}
+
+// Compile-time assertions of the implemented interfaces.
+var (
+	_ gorabbit.MetricsCollector           = (*Collector)(nil)
+	_ gorabbit.ConnectionMetricsCollector = (*Collector)(nil)
+	_ gorabbit.PublishMetricsCollector    = (*Collector)(nil)
+	_ prometheus.Collector                = (*Collector)(nil)
+)
+
+// NewCollector returns a Collector whose metrics are prefixed with the given namespace, "gorabbit" if empty.
+func NewCollector(namespace string) *Collector {
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	return &Collector{
+		connectionEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "connection_events_total",
+			Help:      "Lifecycle events of the connections, by connection type and event.",
+		}, []string{"type", "event"}),
+		connectionUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "connection_up",
+			Help:      "Whether the connection is open, by connection type.",
+		}, []string{"type"}),
+		reconnections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "connection_reconnections_total",
+			Help:      "Successful reconnections after a lost connection, by connection type.",
+		}, []string{"type"}),
+		channelEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "channel_events_total",
+			Help:      "Lifecycle events of the channels, by connection type, consumer and event.",
+		}, []string{"type", "consumer", "event"}),
+		channelUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "channel_up",
+			Help:      "Whether the channel is open, by connection type and consumer.",
+		}, []string{"type", "consumer"}),
+		publishes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "publishes_total",
+			Help:      "Published messages, by exchange and outcome.",
+		}, []string{"exchange", "outcome"}),
+		publishedBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "published_payload_bytes",
+			Help:      "Size of the published payloads, by exchange.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+		}, []string{"exchange"}),
+		deliveries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "deliveries_total",
+			Help:      "Processed deliveries, by consumer, queue, handler and outcome.",
+		}, []string{"consumer", "queue", "handler", "outcome"}),
+		handlerDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "handler_duration_seconds",
+			Help:      "Processing time of the handlers, by consumer, queue and handler.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"consumer", "queue", "handler"}),
+	}
+}
+
+// ObserveConnection records a connection event.
+func (c *Collector) ObserveConnection(metrics gorabbit.ConnectionMetrics) {
+	c.connectionEvents.WithLabelValues(metrics.Type, metrics.Event.String()).Inc()
+	c.connectionUp.WithLabelValues(metrics.Type).Set(up(metrics.Event))
+
+	if metrics.Event == gorabbit.ConnectionEventReopened {
+		c.reconnections.WithLabelValues(metrics.Type).Inc()
+	}
+}
+
+// ObserveChannel records a channel event.
+func (c *Collector) ObserveChannel(metrics gorabbit.ChannelMetrics) {
+	c.channelEvents.WithLabelValues(metrics.Type, metrics.Consumer, metrics.Event.String()).Inc()
+	c.channelUp.WithLabelValues(metrics.Type, metrics.Consumer).Set(up(metrics.Event))
+}
+
+// ObservePublish records a publishing.
+func (c *Collector) ObservePublish(metrics gorabbit.PublishMetrics) {
+	c.publishes.WithLabelValues(metrics.Exchange, metrics.Outcome.String()).Inc()
+
+	if metrics.Outcome == gorabbit.PublishOutcomeSuccess {
+		c.publishedBytes.WithLabelValues(metrics.Exchange).Observe(float64(metrics.PayloadSize))
+	}
+}
+
+// ObserveDelivery records a processed delivery.
+func (c *Collector) ObserveDelivery(metrics gorabbit.DeliveryMetrics) {
+	c.deliveries.WithLabelValues(metrics.Consumer, metrics.Queue, metrics.Handler, metrics.Outcome.String()).Inc()
+	c.handlerDuration.WithLabelValues(metrics.Consumer, metrics.Queue, metrics.Handler).Observe(metrics.Duration.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(descs chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(descs)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(metrics)
+	}
+}
+
+// collectors returns the underlying metrics.
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.connectionEvents,
+		c.connectionUp,
+		c.reconnections,
+		c.channelEvents,
+		c.channelUp,
+		c.publishes,
+		c.publishedBytes,
+		c.deliveries,
+		c.handlerDuration,
+	}
+}
+
+// up returns the value of a state gauge after an event.
+func up(event gorabbit.ConnectionEvent) float64 {
+	if event == gorabbit.ConnectionEventOpened || event == gorabbit.ConnectionEventReopened {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,61 @@
+package gorabbitprom_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbitprom"
+)
+
+func TestCollector(t *testing.T) {
+	collector := gorabbitprom.NewCollector("")
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	collector.ObserveConnection(gorabbit.ConnectionMetrics{Type: "consumer", Event: gorabbit.ConnectionEventOpened})
+	collector.ObserveConnection(gorabbit.ConnectionMetrics{Type: "consumer", Event: gorabbit.ConnectionEventLost})
+	collector.ObserveConnection(gorabbit.ConnectionMetrics{Type: "consumer", Event: gorabbit.ConnectionEventReopened})
+
+	collector.ObservePublish(gorabbit.PublishMetrics{Exchange: "events_exchange", Outcome: gorabbit.PublishOutcomeSuccess, PayloadSize: 128})
+	collector.ObservePublish(gorabbit.PublishMetrics{Exchange: "events_exchange", Outcome: gorabbit.PublishOutcomeCached})
+
+	collector.ObserveDelivery(gorabbit.DeliveryMetrics{
+		Consumer: "events_consumer",
+		Queue:    "events_queue",
+		Handler:  "event.created",
+		Outcome:  gorabbit.DeliveryOutcomeSuccess,
+		Duration: 20 * time.Millisecond,
+	})
+
+	expected := `
+# HELP gorabbit_connection_reconnections_total Successful reconnections after a lost connection, by connection type.
+# TYPE gorabbit_connection_reconnections_total counter
+gorabbit_connection_reconnections_total{type="consumer"} 1
+# HELP gorabbit_connection_up Whether the connection is open, by connection type.
+# TYPE gorabbit_connection_up gauge
+gorabbit_connection_up{type="consumer"} 1
+# HELP gorabbit_publishes_total Published messages, by exchange and outcome.
+# TYPE gorabbit_publishes_total counter
+gorabbit_publishes_total{exchange="events_exchange",outcome="cached"} 1
+gorabbit_publishes_total{exchange="events_exchange",outcome="success"} 1
+# HELP gorabbit_deliveries_total Processed deliveries, by consumer, queue, handler and outcome.
+# TYPE gorabbit_deliveries_total counter
+gorabbit_deliveries_total{consumer="events_consumer",handler="event.created",outcome="success",queue="events_queue"} 1
+`
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"gorabbit_connection_reconnections_total",
+		"gorabbit_connection_up",
+		"gorabbit_publishes_total",
+		"gorabbit_deliveries_total",
+	)
+	assert.NoError(t, err)
+}
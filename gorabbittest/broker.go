@@ -0,0 +1,398 @@
+package gorabbittest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// BrokerDelivery is a delivery dispatched by the Broker to a consumer.
+type BrokerDelivery struct {
+	// Delivery is the dispatched delivery.
+	Delivery gorabbit.Delivery
+
+	// Consumer is the name of the consumer the delivery was dispatched to.
+	Consumer string
+
+	// Err is the error returned by the handler, or ErrNoHandler if no handler of the consumer matches the delivery.
+	Err error
+}
+
+// brokerExchange is an exchange declared in the Broker.
+type brokerExchange struct {
+	kind     gorabbit.ExchangeType
+	bindings []brokerBinding
+}
+
+// brokerBinding binds an exchange to a queue or to another exchange.
+type brokerBinding struct {
+	destination string
+	toExchange  bool
+	routingKey  string
+	args        map[string]interface{}
+}
+
+// brokerQueue is a queue declared in the Broker, holding its messages until a consumer is registered.
+type brokerQueue struct {
+	messages  []gorabbit.Delivery
+	consumers []mockConsumer
+	next      int
+}
+
+// Broker is an in-memory RabbitMQ broker routing the publishings of its clients to the consumers registered on them,
+// following the semantics of the direct, topic, fanout and headers exchanges, as well as the default exchange routing
+// to the queue named by the routing key. Exchanges of other types route nothing.
+//
+// Deliveries are dispatched synchronously by the publishing, unless a delay is set. They are dispatched round-robin to
+// the consumers of a queue and are neither retried nor requeued: the errors of the handlers are recorded along with
+// the Deliveries. A queue without consumers holds its messages until one is registered.
+type Broker struct {
+	mutex      sync.Mutex
+	exchanges  map[string]*brokerExchange
+	queues     map[string]*brokerQueue
+	deliveries []BrokerDelivery
+	delay      time.Duration
+	queueSeq   int
+	inFlight   sync.WaitGroup
+}
+
+// NewBroker returns an empty Broker, with the default exchange and the amq.direct, amq.topic, amq.fanout and amq.headers
+// exchanges.
+func NewBroker() *Broker {
+	broker := &Broker{
+		exchanges: map[string]*brokerExchange{
+			"":            {kind: gorabbit.ExchangeTypeDirect},
+			"amq.direct":  {kind: gorabbit.ExchangeTypeDirect},
+			"amq.topic":   {kind: gorabbit.ExchangeTypeTopic},
+			"amq.fanout":  {kind: gorabbit.ExchangeTypeFanout},
+			"amq.headers": {kind: gorabbit.ExchangeTypeHeaders},
+		},
+		queues: make(map[string]*brokerQueue),
+	}
+
+	return broker
+}
+
+// Client returns a MockClient connected to the Broker: its publishings are routed by the Broker and its consumers
+// receive the deliveries of their queue. The publishings that cannot be routed have the OutcomeReturn, unless another
+// Outcome is simulated.
+func (b *Broker) Client() *MockClient {
+	client := NewMockClient()
+	client.broker = b
+
+	return client
+}
+
+// SetDelay delays the dispatch of the next deliveries, which are then dispatched concurrently. See Wait.
+func (b *Broker) SetDelay(delay time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.delay = delay
+}
+
+// Wait waits for the delayed deliveries to be dispatched and processed.
+func (b *Broker) Wait() {
+	b.inFlight.Wait()
+}
+
+// DeclareExchange declares the exchange, along with its bindings to the source exchanges.
+func (b *Broker) DeclareExchange(config gorabbit.ExchangeConfig) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.declareExchange(config)
+}
+
+// DeclareQueue declares the queue, along with its bindings, and returns its name, generated if empty.
+func (b *Broker) DeclareQueue(config gorabbit.QueueConfig) string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return b.declareQueue(config)
+}
+
+// Deliveries returns the deliveries dispatched to the consumers, in order.
+func (b *Broker) Deliveries() []BrokerDelivery {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]BrokerDelivery(nil), b.deliveries...)
+}
+
+// Pending returns the number of messages held by the queue until a consumer is registered.
+func (b *Broker) Pending(queue string) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if q, found := b.queues[queue]; found {
+		return len(q.messages)
+	}
+
+	return 0
+}
+
+// declareExchange declares the exchange, or sets its type if it was declared by a binding, and binds it to its source
+// exchanges.
+func (b *Broker) declareExchange(config gorabbit.ExchangeConfig) {
+	if exchange, found := b.exchanges[config.Name]; found {
+		exchange.kind = config.Type
+	} else {
+		b.exchanges[config.Name] = &brokerExchange{kind: config.Type}
+	}
+
+	for _, binding := range config.Bindings {
+		b.bind(binding, config.Name, true)
+	}
+}
+
+// declareQueue declares the queue if needed, binds it and returns its name, generated if empty.
+func (b *Broker) declareQueue(config gorabbit.QueueConfig) string {
+	name := config.Name
+
+	if name == "" {
+		b.queueSeq++
+		name = fmt.Sprintf("amq.gen-%d", b.queueSeq)
+	}
+
+	if _, found := b.queues[name]; !found {
+		b.queues[name] = &brokerQueue{}
+	}
+
+	for _, binding := range config.Bindings {
+		b.bind(binding, name, false)
+	}
+
+	return name
+}
+
+// bind binds the destination to the exchange of the binding, declaring the exchange as direct if unknown.
+func (b *Broker) bind(binding gorabbit.BindingConfig, destination string, toExchange bool) {
+	source, found := b.exchanges[binding.Exchange]
+	if !found {
+		source = &brokerExchange{kind: gorabbit.ExchangeTypeDirect}
+		b.exchanges[binding.Exchange] = source
+	}
+
+	source.bindings = append(source.bindings, brokerBinding{
+		destination: destination,
+		toExchange:  toExchange,
+		routingKey:  binding.RoutingKey,
+		args:        binding.Args,
+	})
+}
+
+// subscribe registers the consumer on its queue, declared from its QueueConfig if set, and returns the messages the
+// queue was holding.
+func (b *Broker) subscribe(consumer mockConsumer) (mockConsumer, []gorabbit.Delivery) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if consumer.QueueConfig != nil || consumer.Queue == "" {
+		config := gorabbit.QueueConfig{}
+
+		if consumer.QueueConfig != nil {
+			config = *consumer.QueueConfig
+		}
+
+		config.Name = consumer.Queue
+		consumer.Queue = b.declareQueue(config)
+	}
+
+	queue, found := b.queues[consumer.Queue]
+	if !found {
+		queue = &brokerQueue{}
+		b.queues[consumer.Queue] = queue
+	}
+
+	queue.consumers = append(queue.consumers, consumer)
+
+	held := queue.messages
+	queue.messages = nil
+
+	return consumer, held
+}
+
+// route returns the queues the message is routed to from the exchange.
+func (b *Broker) route(exchange, routingKey string, headers map[string]interface{}) []string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	var queues []string
+
+	seen := make(map[string]bool)
+
+	b.routeFrom(exchange, routingKey, headers, seen, &queues)
+
+	return queues
+}
+
+// routeFrom adds the queues the message is routed to from the exchange, following the exchange to exchange bindings.
+func (b *Broker) routeFrom(exchange, routingKey string, headers map[string]interface{}, seen map[string]bool, queues *[]string) {
+	if seen["exchange:"+exchange] {
+		return
+	}
+
+	seen["exchange:"+exchange] = true
+
+	if exchange == "" {
+		if _, found := b.queues[routingKey]; found && !seen["queue:"+routingKey] {
+			seen["queue:"+routingKey] = true
+			*queues = append(*queues, routingKey)
+		}
+
+		return
+	}
+
+	source, found := b.exchanges[exchange]
+	if !found {
+		return
+	}
+
+	for _, binding := range source.bindings {
+		if !bindingMatches(source.kind, binding, routingKey, headers) {
+			continue
+		}
+
+		if binding.toExchange {
+			b.routeFrom(binding.destination, routingKey, headers, seen, queues)
+
+			continue
+		}
+
+		if !seen["queue:"+binding.destination] {
+			seen["queue:"+binding.destination] = true
+			*queues = append(*queues, binding.destination)
+		}
+	}
+}
+
+// enqueue dispatches the delivery to the consumers of each queue, or holds it in the queues without consumers.
+func (b *Broker) enqueue(ctx context.Context, queues []string, delivery gorabbit.Delivery) {
+	for _, name := range queues {
+		b.mutex.Lock()
+
+		queue, found := b.queues[name]
+		if !found {
+			b.mutex.Unlock()
+
+			continue
+		}
+
+		delivery.Queue = name
+
+		if len(queue.consumers) == 0 {
+			queue.messages = append(queue.messages, delivery)
+			b.mutex.Unlock()
+
+			continue
+		}
+
+		consumer := queue.consumers[queue.next%len(queue.consumers)]
+		queue.next++
+
+		delay := b.delay
+
+		b.mutex.Unlock()
+
+		b.dispatch(ctx, consumer, delivery, delay)
+	}
+}
+
+// dispatch calls the handler of the consumer with the delivery, after the delay if positive, and records it.
+func (b *Broker) dispatch(ctx context.Context, consumer mockConsumer, delivery gorabbit.Delivery, delay time.Duration) {
+	process := func() {
+		found, err := deliverTo(ctx, consumer, delivery)
+		if !found {
+			err = ErrNoHandler
+		}
+
+		b.mutex.Lock()
+		b.deliveries = append(b.deliveries, BrokerDelivery{Delivery: delivery, Consumer: consumer.Name, Err: err})
+		b.mutex.Unlock()
+	}
+
+	if delay <= 0 {
+		process()
+
+		return
+	}
+
+	b.inFlight.Add(1)
+
+	time.AfterFunc(delay, func() {
+		defer b.inFlight.Done()
+
+		process()
+	})
+}
+
+// bindingMatches returns true if the message is routed through the binding of an exchange of the given type.
+func bindingMatches(kind gorabbit.ExchangeType, binding brokerBinding, routingKey string, headers map[string]interface{}) bool {
+	switch kind {
+	case gorabbit.ExchangeTypeDirect:
+		return binding.routingKey == routingKey
+	case gorabbit.ExchangeTypeTopic:
+		return topicMatches(strings.Split(binding.routingKey, "."), strings.Split(routingKey, "."))
+	case gorabbit.ExchangeTypeFanout:
+		return true
+	case gorabbit.ExchangeTypeHeaders:
+		return headersMatch(binding.args, headers)
+	default:
+		return false
+	}
+}
+
+// topicMatches returns true if the words of the routing key match the words of the binding pattern, where '*'
+// matches exactly one word and '#' matches zero or more words.
+func topicMatches(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+
+	if pattern[0] == "#" {
+		for i := 0; i <= len(words); i++ {
+			if topicMatches(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(words) == 0 || (pattern[0] != "*" && pattern[0] != words[0]) {
+		return false
+	}
+
+	return topicMatches(pattern[1:], words[1:])
+}
+
+// headersMatch returns true if the headers match the arguments of a headers exchange binding, all of them by default
+// or any of them if "x-match" is "any". The other arguments starting with "x-" are ignored, and an argument without
+// value matches the presence of the header.
+func headersMatch(args, headers map[string]interface{}) bool {
+	matchAny := args["x-match"] == "any" || args["x-match"] == "any-with-x"
+
+	for key, expected := range args {
+		if strings.HasPrefix(key, "x-") {
+			continue
+		}
+
+		actual, found := headers[key]
+		matches := found && (expected == nil || fmt.Sprint(actual) == fmt.Sprint(expected))
+
+		if matches && matchAny {
+			return true
+		}
+
+		if !matches && !matchAny {
+			return false
+		}
+	}
+
+	return !matchAny
+}
@@ -0,0 +1,138 @@
+package gorabbittest_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbittest"
+)
+
+// recorder records the routing keys received by the handlers of each queue.
+type recorder struct {
+	mutex    sync.Mutex
+	received map[string][]string
+}
+
+func (r *recorder) consumer(queue string) gorabbit.MessageConsumer {
+	record := func(ctx context.Context, _ []byte) error {
+		delivery, _ := gorabbit.DeliveryFromContext(ctx)
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.received[queue] = append(r.received[queue], delivery.RoutingKey)
+
+		return nil
+	}
+
+	return gorabbit.MessageConsumer{
+		Queue: queue,
+		Name:  queue,
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"invoice.#": record,
+			"*":         record,
+		},
+	}
+}
+
+func TestBroker_Routing(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	client := broker.Client()
+
+	_, err := client.SetupTopology(context.Background(), []gorabbit.ExchangeConfig{
+		{Name: "direct_exchange", Type: gorabbit.ExchangeTypeDirect},
+		{Name: "topic_exchange", Type: gorabbit.ExchangeTypeTopic},
+		{Name: "fanout_exchange", Type: gorabbit.ExchangeTypeFanout},
+		{Name: "headers_exchange", Type: gorabbit.ExchangeTypeHeaders},
+	}, []gorabbit.QueueConfig{
+		{Name: "direct_queue", Bindings: []gorabbit.BindingConfig{{Exchange: "direct_exchange", RoutingKey: "invoice.created"}}},
+		{Name: "topic_queue", Bindings: []gorabbit.BindingConfig{{Exchange: "topic_exchange", RoutingKey: "invoice.#"}}},
+		{Name: "fanout_queue", Bindings: []gorabbit.BindingConfig{{Exchange: "fanout_exchange"}}},
+		{Name: "headers_queue", Bindings: []gorabbit.BindingConfig{{
+			Exchange: "headers_exchange",
+			Args:     map[string]interface{}{"x-match": "any", "x-encryption-key-id": nil},
+		}}},
+	})
+	require.NoError(t, err)
+
+	r := &recorder{received: make(map[string][]string)}
+
+	for _, queue := range []string{"direct_queue", "topic_queue", "fanout_queue", "headers_queue"} {
+		require.NoError(t, client.RegisterConsumer(r.consumer(queue)))
+	}
+
+	require.NoError(t, client.Publish("direct_exchange", "invoice.created", "payload"))
+	require.NoError(t, client.Publish("direct_exchange", "invoice.paid", "payload"))
+	require.NoError(t, client.Publish("topic_exchange", "invoice.paid.late", "payload"))
+	require.NoError(t, client.Publish("fanout_exchange", "anything", "payload"))
+	require.NoError(t, client.Publish("", "direct_queue", "payload"))
+
+	assert.Equal(t, map[string][]string{
+		"direct_queue": {"invoice.created", "direct_queue"},
+		"topic_queue":  {"invoice.paid.late"},
+		"fanout_queue": {"anything"},
+	}, r.received)
+
+	outcomes := make([]gorabbittest.Outcome, 0, len(client.Publishings()))
+
+	for _, publishing := range client.Publishings() {
+		outcomes = append(outcomes, publishing.Outcome)
+	}
+
+	assert.Equal(t, []gorabbittest.Outcome{
+		gorabbittest.OutcomeConfirm,
+		gorabbittest.OutcomeReturn,
+		gorabbittest.OutcomeConfirm,
+		gorabbittest.OutcomeConfirm,
+		gorabbittest.OutcomeConfirm,
+	}, outcomes)
+
+	assert.Len(t, broker.Deliveries(), 4)
+}
+
+func TestBroker_HeldMessages(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	publisher := broker.Client()
+	consumer := broker.Client()
+
+	broker.DeclareQueue(gorabbit.QueueConfig{
+		Name:     "invoices_queue",
+		Bindings: []gorabbit.BindingConfig{{Exchange: "amq.topic", RoutingKey: "invoice.*"}},
+	})
+
+	require.NoError(t, publisher.Publish("amq.topic", "invoice.created", "payload"))
+	assert.Equal(t, 1, broker.Pending("invoices_queue"))
+
+	r := &recorder{received: make(map[string][]string)}
+
+	require.NoError(t, consumer.RegisterConsumer(r.consumer("invoices_queue")))
+
+	assert.Zero(t, broker.Pending("invoices_queue"))
+	assert.Equal(t, []string{"invoice.created"}, r.received["invoices_queue"])
+}
+
+func TestBroker_Delay(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	client := broker.Client()
+
+	r := &recorder{received: make(map[string][]string)}
+
+	require.NoError(t, client.RegisterConsumer(r.consumer("invoices_queue")))
+
+	broker.SetDelay(10 * time.Millisecond)
+
+	require.NoError(t, client.Publish("", "invoices_queue", "payload"))
+	assert.Empty(t, broker.Deliveries())
+
+	broker.Wait()
+
+	require.Len(t, broker.Deliveries(), 1)
+	assert.Equal(t, "invoices_queue", broker.Deliveries()[0].Consumer)
+	assert.NoError(t, broker.Deliveries()[0].Err)
+}
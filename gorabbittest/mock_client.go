@@ -0,0 +1,579 @@
+// Package gorabbittest provides a MockClient to unit test the code publishing and consuming with gorabbit, without a
+// RabbitMQ server.
+package gorabbittest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// ErrNoHandler is returned when a delivery matches no handler of the registered consumers.
+var ErrNoHandler = errors.New("no handler matches the delivery")
+
+// Outcome is the simulated outcome of a publishing.
+type Outcome int
+
+const (
+	// OutcomeConfirm simulates a publishing confirmed by the broker, this is the default Outcome.
+	OutcomeConfirm Outcome = iota
+
+	// OutcomeNack simulates a publishing negatively acknowledged by the broker.
+	OutcomeNack
+
+	// OutcomeReturn simulates a publishing that could not be routed to any queue. Mandatory publishings are returned
+	// through the OnReturn notification before being confirmed.
+	OutcomeReturn
+)
+
+// Publishing is a publishing recorded by the MockClient.
+type Publishing struct {
+	// Exchange is the exchange the payload was published to.
+	Exchange string
+
+	// RoutingKey is the routing key the payload was published with.
+	RoutingKey string
+
+	// Payload is the published payload.
+	Payload interface{}
+
+	// Body is the payload encoded by the Marshaller of the MockClient.
+	Body []byte
+
+	// ContentType is the content type set by the Marshaller of the MockClient.
+	ContentType string
+
+	// Headers are the headers set by the Marshaller of the MockClient.
+	Headers map[string]interface{}
+
+	// Options are the options of the publishing, nil if none were passed.
+	Options *gorabbit.PublishingOptions
+
+	// Outcome is the simulated outcome of the publishing.
+	Outcome Outcome
+
+	// DeliveryTag is the sequence number of the publishing, starting at 1.
+	DeliveryTag uint64
+}
+
+// outcomeRule is an Outcome simulated for the publishings whose routing key matches a pattern.
+type outcomeRule struct {
+	pattern *gorabbit.RoutingKeyMatcher
+	outcome Outcome
+}
+
+// mockConsumer is a registered consumer along with the keys of its handlers, compiled once.
+type mockConsumer struct {
+	gorabbit.MessageConsumer
+
+	contextHandlers *gorabbit.RoutingKeyMatcher
+	handlers        *gorabbit.RoutingKeyMatcher
+}
+
+// newMockConsumer compiles the keys of the handlers of the consumer.
+func newMockConsumer(consumer gorabbit.MessageConsumer) mockConsumer {
+	contextKeys := make([]string, 0, len(consumer.ContextHandlers))
+
+	for key, fn := range consumer.ContextHandlers {
+		if fn != nil {
+			contextKeys = append(contextKeys, key)
+		}
+	}
+
+	keys := make([]string, 0, len(consumer.Handlers))
+
+	for key, fn := range consumer.Handlers {
+		if fn != nil {
+			keys = append(keys, key)
+		}
+	}
+
+	return mockConsumer{
+		MessageConsumer: consumer,
+		contextHandlers: gorabbit.NewRoutingKeyMatcher(contextKeys...),
+		handlers:        gorabbit.NewRoutingKeyMatcher(keys...),
+	}
+}
+
+// MockClient is a gorabbit.MQTTClient recording the publishings and the registered consumers, whose handlers are called
+// with the deliveries injected through Deliver and DeliverPayload, or routed by the Broker of a client returned by
+// Broker.Client. It is safe for concurrent use.
+type MockClient struct {
+	// Marshaller encodes the published payloads and the payloads of DeliverPayload. Defaults to the JSONMarshaller.
+	Marshaller gorabbit.Marshaller
+
+	// Notifications receives, if set, the confirmations and the returns of the simulated outcomes.
+	Notifications *gorabbit.BrokerNotifications
+
+	mutex       sync.Mutex
+	publishings []Publishing
+	consumers   []mockConsumer
+	exchanges   []gorabbit.ExchangeConfig
+	queues      []gorabbit.QueueConfig
+	outcomes    []outcomeRule
+	publishErr  error
+	deliveryTag uint64
+	closed      bool
+	events      chan gorabbit.Event
+	broker      *Broker
+}
+
+// Compile-time assertion of the implemented interface.
+var _ gorabbit.MQTTClient = (*MockClient)(nil)
+
+// NewMockClient returns a MockClient confirming every publishing.
+func NewMockClient() *MockClient {
+	return &MockClient{
+		events: make(chan gorabbit.Event),
+	}
+}
+
+// SimulateOutcome sets the Outcome of the next publishings whose routing key matches the pattern, which can contain the
+// '*' and '#' wildcards. The last matching pattern wins.
+func (m *MockClient) SimulateOutcome(routingKeyPattern string, outcome Outcome) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.outcomes = append(m.outcomes, outcomeRule{pattern: gorabbit.NewRoutingKeyMatcher(routingKeyPattern), outcome: outcome})
+}
+
+// FailPublishing makes the next publishings fail with err without being recorded, until it is called with nil.
+func (m *MockClient) FailPublishing(err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.publishErr = err
+}
+
+// Publishings returns the recorded publishings, in order.
+func (m *MockClient) Publishings() []Publishing {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]Publishing(nil), m.publishings...)
+}
+
+// Consumers returns the registered consumers, in order.
+func (m *MockClient) Consumers() []gorabbit.MessageConsumer {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	consumers := make([]gorabbit.MessageConsumer, 0, len(m.consumers))
+
+	for _, consumer := range m.consumers {
+		consumers = append(consumers, consumer.MessageConsumer)
+	}
+
+	return consumers
+}
+
+// Topology returns the exchanges and the queues set up through SetupTopology.
+func (m *MockClient) Topology() ([]gorabbit.ExchangeConfig, []gorabbit.QueueConfig) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]gorabbit.ExchangeConfig(nil), m.exchanges...), append([]gorabbit.QueueConfig(nil), m.queues...)
+}
+
+// Reset forgets the recorded publishings and the simulated outcomes and failures, keeping the registered consumers.
+func (m *MockClient) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.publishings = nil
+	m.outcomes = nil
+	m.publishErr = nil
+}
+
+// Deliver calls, synchronously, the handler matching the routing key of the delivery among the registered consumers of
+// its Queue, or of any queue if empty. The context handlers receive the delivery through gorabbit.DeliveryFromContext.
+// Returns the error of the handler, or ErrNoHandler if none matches.
+func (m *MockClient) Deliver(ctx context.Context, delivery gorabbit.Delivery) error {
+	m.mutex.Lock()
+	consumers := append([]mockConsumer(nil), m.consumers...)
+	m.mutex.Unlock()
+
+	for _, consumer := range consumers {
+		if delivery.Queue != "" && delivery.Queue != consumer.Queue {
+			continue
+		}
+
+		if found, err := deliverTo(ctx, consumer, delivery); found {
+			return err
+		}
+	}
+
+	return ErrNoHandler
+}
+
+// DeliverPayload encodes the payload with the Marshaller of the MockClient and delivers it with the routing key to the
+// registered consumers of the queue, or of any queue if empty. See Deliver.
+func (m *MockClient) DeliverPayload(ctx context.Context, queue, routingKey string, payload interface{}) error {
+	metadata := &gorabbit.MessageMetadata{RoutingKey: routingKey, Queue: queue, Type: routingKey}
+
+	body, err := m.marshaller().Marshal(metadata, payload)
+	if err != nil {
+		return err
+	}
+
+	return m.Deliver(ctx, gorabbit.Delivery{
+		Queue:       queue,
+		RoutingKey:  routingKey,
+		ContentType: metadata.ContentType,
+		Headers:     metadata.Headers,
+		Body:        body,
+	})
+}
+
+func (m *MockClient) Publish(exchange, routingKey string, payload interface{}) error {
+	return m.PublishWithContext(context.Background(), exchange, routingKey, payload, nil)
+}
+
+func (m *MockClient) PublishWithOptions(exchange, routingKey string, payload interface{}, options *gorabbit.PublishingOptions) error {
+	return m.PublishWithContext(context.Background(), exchange, routingKey, payload, options)
+}
+
+func (m *MockClient) PublishWithContext(
+	ctx context.Context,
+	exchange string,
+	routingKey string,
+	payload interface{},
+	options *gorabbit.PublishingOptions,
+) error {
+	metadata := &gorabbit.MessageMetadata{Exchange: exchange, RoutingKey: routingKey, Type: routingKey}
+
+	body, err := m.marshaller().Marshal(metadata, payload)
+	if err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+
+	// client is closed, so we do nothing and return no error, as a disconnected client does.
+	if m.closed {
+		m.mutex.Unlock()
+
+		return nil
+	}
+
+	if m.publishErr != nil {
+		err = m.publishErr
+		m.mutex.Unlock()
+
+		return err
+	}
+
+	m.deliveryTag++
+
+	outcome, simulated := m.outcome(routingKey)
+
+	var queues []string
+
+	if m.broker != nil {
+		queues = m.broker.route(exchange, routingKey, metadata.Headers)
+
+		if len(queues) == 0 && !simulated {
+			outcome = OutcomeReturn
+		}
+	}
+
+	publishing := Publishing{
+		Exchange:    exchange,
+		RoutingKey:  routingKey,
+		Payload:     payload,
+		Body:        body,
+		ContentType: metadata.ContentType,
+		Headers:     metadata.Headers,
+		Options:     options,
+		Outcome:     outcome,
+		DeliveryTag: m.deliveryTag,
+	}
+
+	m.publishings = append(m.publishings, publishing)
+
+	notifications := m.Notifications
+
+	m.mutex.Unlock()
+
+	m.notify(notifications, publishing)
+
+	if len(queues) > 0 {
+		delivery := gorabbit.Delivery{
+			Exchange:    exchange,
+			RoutingKey:  routingKey,
+			ContentType: metadata.ContentType,
+			Priority:    priority(options),
+			Timestamp:   time.Now(),
+			Headers:     metadata.Headers,
+			Body:        body,
+		}
+
+		if options != nil {
+			delivery.CorrelationID = options.CorrelationID
+			delivery.ReplyTo = options.ReplyTo
+		}
+
+		m.broker.enqueue(ctx, queues, delivery)
+	}
+
+	return nil
+}
+
+func (m *MockClient) RegisterConsumer(consumer gorabbit.MessageConsumer) error {
+	if err := consumer.Handlers.Validate(); err != nil {
+		return err
+	}
+
+	if err := consumer.ContextHandlers.Validate(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+
+	if m.closed {
+		m.mutex.Unlock()
+
+		return nil
+	}
+
+	m.mutex.Unlock()
+
+	var held []gorabbit.Delivery
+
+	registered := newMockConsumer(consumer)
+
+	if m.broker != nil {
+		registered, held = m.broker.subscribe(registered)
+	}
+
+	m.mutex.Lock()
+	m.consumers = append(m.consumers, registered)
+	m.mutex.Unlock()
+
+	for _, delivery := range held {
+		m.broker.enqueue(context.Background(), []string{registered.Queue}, delivery)
+	}
+
+	return nil
+}
+
+func (m *MockClient) SetupTopology(_ context.Context, exchanges []gorabbit.ExchangeConfig, queues []gorabbit.QueueConfig) (gorabbit.Teardown, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.exchanges = append(m.exchanges, exchanges...)
+	m.queues = append(m.queues, queues...)
+
+	if m.broker != nil {
+		for _, exchange := range exchanges {
+			m.broker.DeclareExchange(exchange)
+		}
+
+		for _, queue := range queues {
+			m.broker.DeclareQueue(queue)
+		}
+	}
+
+	return func(context.Context) error {
+		return nil
+	}, nil
+}
+
+func (m *MockClient) Health() gorabbit.HealthReport {
+	return m.HealthReport()
+}
+
+func (m *MockClient) Close() error {
+	return m.Disconnect()
+}
+
+func (m *MockClient) Disconnect() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.closed = true
+
+	return nil
+}
+
+func (m *MockClient) Drain(context.Context) (gorabbit.DrainReport, error) {
+	return gorabbit.DrainReport{}, nil
+}
+
+func (m *MockClient) Events() <-chan gorabbit.Event {
+	return m.events
+}
+
+func (m *MockClient) IsReady() bool {
+	return true
+}
+
+func (m *MockClient) IsHealthy() bool {
+	return true
+}
+
+func (m *MockClient) HealthReport() gorabbit.HealthReport {
+	return gorabbit.HealthReport{Ready: true, Healthy: true}
+}
+
+func (m *MockClient) ConsumerStats() []gorabbit.ConsumerStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := make([]gorabbit.ConsumerStats, 0, len(m.consumers))
+
+	for _, consumer := range m.consumers {
+		stats = append(stats, gorabbit.ConsumerStats{Name: consumer.Name, Queue: consumer.Queue})
+	}
+
+	return stats
+}
+
+func (m *MockClient) ExportSchema() ([]byte, error) {
+	return json.Marshal(gorabbit.SchemaDefinitions{})
+}
+
+func (m *MockClient) VerifyTopology(context.Context) (gorabbit.TopologyReport, error) {
+	return gorabbit.TopologyReport{}, nil
+}
+
+func (m *MockClient) PurgeQueue(string) (int, error) {
+	return 0, nil
+}
+
+func (m *MockClient) DeleteQueue(string, bool, bool) (int, error) {
+	return 0, nil
+}
+
+func (m *MockClient) DeleteExchange(string, bool) error {
+	return nil
+}
+
+func (m *MockClient) Unbind(string, string, string, map[string]interface{}) error {
+	return nil
+}
+
+func (m *MockClient) IsConsumerActive(name string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, consumer := range m.consumers {
+		if consumer.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *MockClient) GetHost() string {
+	return ""
+}
+
+func (m *MockClient) GetPort() uint {
+	return 0
+}
+
+func (m *MockClient) GetUsername() string {
+	return ""
+}
+
+func (m *MockClient) GetVhost() string {
+	return ""
+}
+
+func (m *MockClient) UpdateConfig(...gorabbit.Option) error {
+	return nil
+}
+
+func (m *MockClient) IsDisabled() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.closed
+}
+
+// deliverTo calls the handler of the consumer matching the routing key of the delivery, if any, and returns its error.
+func deliverTo(ctx context.Context, consumer mockConsumer, delivery gorabbit.Delivery) (bool, error) {
+	delivery.Queue = consumer.Queue
+
+	if key, found := consumer.contextHandlers.Match(delivery.RoutingKey); found {
+		fn := consumer.ContextHandlers[key]
+
+		ctx, cancel := gorabbit.DecorateContext(gorabbit.ContextWithDelivery(ctx, delivery), delivery, consumer.ContextDecorators...)
+		defer cancel()
+
+		return true, fn(ctx, delivery.Body)
+	}
+
+	if key, found := consumer.handlers.Match(delivery.RoutingKey); found {
+		return true, consumer.Handlers[key](delivery.Body)
+	}
+
+	return false, nil
+}
+
+// marshaller returns the Marshaller, or the JSONMarshaller if none is set.
+func (m *MockClient) marshaller() gorabbit.Marshaller {
+	if m.Marshaller == nil {
+		return gorabbit.JSONMarshaller{}
+	}
+
+	return m.Marshaller
+}
+
+// outcome returns the Outcome of the last rule matching the routing key, OutcomeConfirm if none does, along with whether
+// a rule matched.
+func (m *MockClient) outcome(routingKey string) (Outcome, bool) {
+	outcome, simulated := OutcomeConfirm, false
+
+	for _, rule := range m.outcomes {
+		if _, found := rule.pattern.Match(routingKey); found {
+			outcome, simulated = rule.outcome, true
+		}
+	}
+
+	return outcome, simulated
+}
+
+// priority returns the priority of the publishing options, 0 if none.
+func priority(options *gorabbit.PublishingOptions) uint8 {
+	if options == nil || options.MessagePriority == nil {
+		return 0
+	}
+
+	return options.MessagePriority.Uint8()
+}
+
+// notify passes the simulated outcome of the publishing to the notifications callbacks, if set.
+func (m *MockClient) notify(notifications *gorabbit.BrokerNotifications, publishing Publishing) {
+	if notifications == nil {
+		return
+	}
+
+	mandatory := publishing.Options != nil && publishing.Options.Mandatory
+
+	if publishing.Outcome == OutcomeReturn && mandatory && notifications.OnReturn != nil {
+		notifications.OnReturn(gorabbit.ReturnedMessage{
+			ReplyCode:  amqp.NoRoute,
+			ReplyText:  "NO_ROUTE",
+			Exchange:   publishing.Exchange,
+			RoutingKey: publishing.RoutingKey,
+			Headers:    publishing.Headers,
+			Body:       publishing.Body,
+		})
+	}
+
+	if notifications.OnConfirm != nil {
+		notifications.OnConfirm(gorabbit.PublishConfirmation{
+			DeliveryTag: publishing.DeliveryTag,
+			Ack:         publishing.Outcome != OutcomeNack,
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package gorabbittest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbittest"
+)
+
+type invoice struct {
+	ID string `json:"id"`
+}
+
+func TestMockClient_Publish(t *testing.T) {
+	client := gorabbittest.NewMockClient()
+
+	require.NoError(t, client.Publish("billing_exchange", "invoice.created", invoice{ID: "42"}))
+
+	publishings := client.Publishings()
+	require.Len(t, publishings, 1)
+
+	assert.Equal(t, "billing_exchange", publishings[0].Exchange)
+	assert.Equal(t, "invoice.created", publishings[0].RoutingKey)
+	assert.Equal(t, invoice{ID: "42"}, publishings[0].Payload)
+	assert.JSONEq(t, `{"id":"42"}`, string(publishings[0].Body))
+	assert.Equal(t, gorabbit.ContentTypeJSON, publishings[0].ContentType)
+	assert.Equal(t, gorabbittest.OutcomeConfirm, publishings[0].Outcome)
+
+	client.Reset()
+	assert.Empty(t, client.Publishings())
+}
+
+func TestMockClient_SimulateOutcome(t *testing.T) {
+	client := gorabbittest.NewMockClient()
+
+	var (
+		confirmations []gorabbit.PublishConfirmation
+		returned      []gorabbit.ReturnedMessage
+	)
+
+	client.Notifications = &gorabbit.BrokerNotifications{
+		OnConfirm: func(confirmation gorabbit.PublishConfirmation) {
+			confirmations = append(confirmations, confirmation)
+		},
+		OnReturn: func(message gorabbit.ReturnedMessage) {
+			returned = append(returned, message)
+		},
+	}
+
+	client.SimulateOutcome("invoice.*", gorabbittest.OutcomeNack)
+	client.SimulateOutcome("invoice.unrouted", gorabbittest.OutcomeReturn)
+
+	require.NoError(t, client.Publish("billing_exchange", "invoice.created", "payload"))
+	require.NoError(t, client.PublishWithOptions("billing_exchange", "invoice.unrouted", "payload", &gorabbit.PublishingOptions{Mandatory: true}))
+
+	assert.Equal(t, []gorabbit.PublishConfirmation{
+		{DeliveryTag: 1, Ack: false},
+		{DeliveryTag: 2, Ack: true},
+	}, confirmations)
+
+	require.Len(t, returned, 1)
+	assert.Equal(t, "invoice.unrouted", returned[0].RoutingKey)
+
+	failure := errors.New("connection lost")
+
+	client.FailPublishing(failure)
+	assert.ErrorIs(t, client.Publish("billing_exchange", "invoice.created", "payload"), failure)
+	assert.Len(t, client.Publishings(), 2)
+}
+
+func TestMockClient_Deliver(t *testing.T) {
+	client := gorabbittest.NewMockClient()
+
+	var received invoice
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue: "invoices_queue",
+		Name:  "invoices",
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"invoice.*": func(ctx context.Context, _ []byte) error {
+				delivery, ok := gorabbit.DeliveryFromContext(ctx)
+				require.True(t, ok)
+				assert.Equal(t, "invoices_queue", delivery.Queue)
+
+				return delivery.Unmarshal(&received)
+			},
+		},
+	}))
+
+	require.NoError(t, client.DeliverPayload(context.Background(), "", "invoice.created", invoice{ID: "42"}))
+	assert.Equal(t, invoice{ID: "42"}, received)
+
+	err := client.Deliver(context.Background(), gorabbit.Delivery{Queue: "other_queue", RoutingKey: "invoice.created"})
+	assert.ErrorIs(t, err, gorabbittest.ErrNoHandler)
+}
+
+func TestMockClient_Deliver_ContextDecorators(t *testing.T) {
+	type tenantKey struct{}
+
+	client := gorabbittest.NewMockClient()
+
+	var tenant interface{}
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue: "invoices_queue",
+		Name:  "invoices",
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"invoice.*": func(ctx context.Context, _ []byte) error {
+				tenant = ctx.Value(tenantKey{})
+
+				return nil
+			},
+		},
+		ContextDecorators: []gorabbit.ContextDecorator{gorabbit.ContextHeader("x-tenant", tenantKey{})},
+	}))
+
+	require.NoError(t, client.Deliver(context.Background(), gorabbit.Delivery{
+		Queue:      "invoices_queue",
+		RoutingKey: "invoice.created",
+		Headers:    map[string]interface{}{"x-tenant": "acme"},
+	}))
+
+	assert.Equal(t, "acme", tenant)
+}
@@ -0,0 +1,52 @@
+// Package gorabbitzap sends the logs of a gorabbit client to a zap.Logger.
+package gorabbitzap
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// Logger is a gorabbit.Logger logging through a zap.Logger, each gorabbit.LogField becoming a zap field.
+type Logger struct {
+	logger *zap.Logger
+}
+
+// Compile-time assertion of the implemented interface.
+var _ gorabbit.Logger = (*Logger)(nil)
+
+// New returns a Logger logging through the given zap.Logger.
+func New(logger *zap.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Error logs a message with its error at error level.
+func (l *Logger) Error(err error, s string, fields ...gorabbit.LogField) {
+	l.logger.Error(s, append(zapFields(fields), zap.Error(err))...)
+}
+
+// Warn logs a message at warn level.
+func (l *Logger) Warn(s string, fields ...gorabbit.LogField) {
+	l.logger.Warn(s, zapFields(fields)...)
+}
+
+// Info logs a message at info level.
+func (l *Logger) Info(s string, fields ...gorabbit.LogField) {
+	l.logger.Info(s, zapFields(fields)...)
+}
+
+// Debug logs a message at debug level.
+func (l *Logger) Debug(s string, fields ...gorabbit.LogField) {
+	l.logger.Debug(s, zapFields(fields)...)
+}
+
+// zapFields converts the fields of a log.
+func zapFields(fields []gorabbit.LogField) []zap.Field {
+	converted := make([]zap.Field, 0, len(fields)+1)
+
+	for _, field := range fields {
+		converted = append(converted, zap.Any(field.Key, field.Value))
+	}
+
+	return converted
+}
@@ -0,0 +1,34 @@
+package gorabbitzap_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbitzap"
+)
+
+func TestLogger(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	logger := gorabbitzap.New(zap.New(core))
+
+	logger.Debug("Message successfully sent")
+	logger.Error(errors.New("connection refused"), "Could not open connection", gorabbit.LogField{Key: "type", Value: "publisher"})
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+
+	assert.Equal(t, zapcore.ErrorLevel, entries[0].Level)
+	assert.Equal(t, "Could not open connection", entries[0].Message)
+	assert.Equal(t, map[string]interface{}{
+		"type":  "publisher",
+		"error": "connection refused",
+	}, entries[0].ContextMap())
+}
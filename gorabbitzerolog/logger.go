@@ -0,0 +1,54 @@
+// Package gorabbitzerolog sends the logs of a gorabbit client to a zerolog.Logger.
+package gorabbitzerolog
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// Logger is a gorabbit.Logger logging through a zerolog.Logger, each gorabbit.LogField becoming a zerolog field.
+type Logger struct {
+	logger zerolog.Logger
+}
+
+// Compile-time assertion of the implemented interface.
+var _ gorabbit.Logger = (*Logger)(nil)
+
+// New returns a Logger logging through the given zerolog.Logger.
+func New(logger zerolog.Logger) *Logger {
+	return &Logger{logger: logger}
+}
+
+// Error logs a message with its error at error level.
+func (l *Logger) Error(err error, s string, fields ...gorabbit.LogField) {
+	log(l.logger.Error().Err(err), s, fields)
+}
+
+// Warn logs a message at warn level.
+func (l *Logger) Warn(s string, fields ...gorabbit.LogField) {
+	log(l.logger.Warn(), s, fields)
+}
+
+// Info logs a message at info level.
+func (l *Logger) Info(s string, fields ...gorabbit.LogField) {
+	log(l.logger.Info(), s, fields)
+}
+
+// Debug logs a message at debug level.
+func (l *Logger) Debug(s string, fields ...gorabbit.LogField) {
+	log(l.logger.Debug(), s, fields)
+}
+
+// log adds the fields to an event and sends it. The event is nil if its level is disabled.
+func log(event *zerolog.Event, s string, fields []gorabbit.LogField) {
+	if event == nil {
+		return
+	}
+
+	for _, field := range fields {
+		event = event.Interface(field.Key, field.Value)
+	}
+
+	event.Msg(s)
+}
@@ -0,0 +1,35 @@
+package gorabbitzerolog_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbitzerolog"
+)
+
+func TestLogger(t *testing.T) {
+	var output bytes.Buffer
+
+	logger := gorabbitzerolog.New(zerolog.New(&output).Level(zerolog.InfoLevel))
+
+	logger.Debug("Message successfully sent")
+	logger.Error(errors.New("connection refused"), "Could not open connection", gorabbit.LogField{Key: "type", Value: "publisher"})
+
+	var record map[string]interface{}
+
+	require.NoError(t, json.Unmarshal(output.Bytes(), &record))
+
+	assert.Equal(t, map[string]interface{}{
+		"level":   "error",
+		"message": "Could not open connection",
+		"type":    "publisher",
+		"error":   "connection refused",
+	}, record)
+}
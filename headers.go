@@ -0,0 +1,50 @@
+package gorabbit
+
+import "fmt"
+
+// argHeadersMatch is the binding argument defining whether any or all the headers of a headers exchange binding must
+// match.
+const argHeadersMatch = "x-match"
+
+// HeadersBinding returns the binding of a queue or exchange to a headers exchange, routing the messages whose headers
+// match any or all the given headers, depending on match.
+func HeadersBinding(exchange string, match HeadersMatch, headers map[string]interface{}) BindingConfig {
+	args := make(map[string]interface{}, len(headers)+1)
+
+	for k, v := range headers {
+		args[k] = v
+	}
+
+	args[argHeadersMatch] = match.String()
+
+	return BindingConfig{
+		Exchange: exchange,
+		Args:     args,
+	}
+}
+
+// Validate verifies that the binding arguments are valid, the "x-match" argument of headers exchange bindings in
+// particular.
+func (b BindingConfig) Validate() error {
+	return validateBindingArguments(b.Exchange, b.Args)
+}
+
+// validateBindingArguments verifies that the "x-match" argument, if any, is a known HeadersMatch.
+func validateBindingArguments(exchange string, args map[string]interface{}) error {
+	match, defined := args[argHeadersMatch]
+	if !defined {
+		return nil
+	}
+
+	value, ok := match.(string)
+	if !ok {
+		return fmt.Errorf("the binding to the exchange '%s' must have a string 'x-match' argument", exchange)
+	}
+
+	switch HeadersMatch(value) {
+	case HeadersMatchAll, HeadersMatchAny, HeadersMatchAllWithX, HeadersMatchAnyWithX:
+		return nil
+	default:
+		return fmt.Errorf("the binding to the exchange '%s' has an unknown 'x-match' argument '%s'", exchange, value)
+	}
+}
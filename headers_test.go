@@ -0,0 +1,37 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestHeadersBinding(t *testing.T) {
+	binding := gorabbit.HeadersBinding("events_headers", gorabbit.HeadersMatchAny, map[string]interface{}{
+		"format": "pdf",
+		"type":   "report",
+	})
+
+	assert.Equal(t, "events_headers", binding.Exchange)
+	assert.Equal(t, map[string]interface{}{"x-match": "any", "format": "pdf", "type": "report"}, binding.Args)
+	assert.NoError(t, binding.Validate())
+}
+
+func TestBindingConfig_Validate(t *testing.T) {
+	binding := gorabbit.BindingConfig{Exchange: "events_headers", Args: map[string]interface{}{"x-match": "some"}}
+
+	require.Error(t, binding.Validate())
+
+	queue := gorabbit.QueueConfig{Name: "reports_queue", Bindings: []gorabbit.BindingConfig{binding}}
+
+	assert.ErrorContains(t, queue.Validate(), "the queue 'reports_queue' has an invalid binding")
+
+	binding.Args["x-match"] = 1
+
+	assert.Error(t, binding.Validate())
+
+	assert.NoError(t, gorabbit.BindingConfig{Exchange: "events_exchange", RoutingKey: "event.#"}.Validate())
+}
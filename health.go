@@ -0,0 +1,110 @@
+package gorabbit
+
+// HealthReport holds a detailed view of the client's health.
+type HealthReport struct {
+	// Ready is true if the client is fully operational and connected to the RabbitMQ.
+	Ready bool
+
+	// Healthy is true if the client is ready and all channels are operating successfully.
+	Healthy bool
+
+	// Consumers holds the health of each registered consumer.
+	Consumers []ConsumerHealth
+
+	// Transitions holds the recent transitions of the connections, the oldest first.
+	Transitions []HealthTransition
+
+	// RecentReconnections is the number of reconnections within the window of the FlapDetection.
+	RecentReconnections int
+
+	// Flapping is true if a connection reconnected at least as many times as the threshold of the FlapDetection
+	// within its window.
+	Flapping bool
+}
+
+// ConsumerHealth holds the health of a registered consumer.
+type ConsumerHealth struct {
+	// Name is the name of the consumer.
+	Name string
+
+	// Tag is the consumer tag of the subscription.
+	Tag string
+
+	// Queue is the consumed queue.
+	Queue string
+
+	// Healthy is true if the consumer's channel is ready and its subscription succeeded.
+	Healthy bool
+
+	// Active is true if the consumer is actively receiving deliveries.
+	Active bool
+
+	// InFlight holds the deliveries being processed by the consumer.
+	InFlight InFlightStats
+
+	// QueueStats holds the last inspection of the queue if the consumer defines a QueueMonitor, nil otherwise.
+	QueueStats *QueueStats
+
+	// Err is the error that ended or prevented the last subscription of the consumer, nil while it is subscribed. It
+	// wraps ErrConsumerCanceled, ErrQueueNotFound or ErrExclusiveConsumerConflict for instance.
+	Err error
+}
+
+// Backlogged returns true if any consumed queue exceeds its backlog threshold.
+func (r HealthReport) Backlogged() bool {
+	for _, consumer := range r.Consumers {
+		if consumer.QueueStats != nil && consumer.QueueStats.Backlogged {
+			return true
+		}
+	}
+
+	return false
+}
+
+// consumerHealth returns the health of the channel's consumer.
+func (c *amqpChannel) consumerHealth() ConsumerHealth {
+	return ConsumerHealth{
+		Name:       c.consumer.Name,
+		Tag:        c.consumer.Tag,
+		Queue:      c.queueName(),
+		Healthy:    c.healthy(),
+		Active:     c.isActive(),
+		InFlight:   c.inFlightStats(),
+		QueueStats: c.lastQueueStats(),
+		Err:        c.lastSubscriptionErr(),
+	}
+}
+
+// setSubscriptionErr records the error that ended or prevented the subscription, nil once subscribed.
+func (c *amqpChannel) setSubscriptionErr(err error) {
+	c.consumptionMutex.Lock()
+	defer c.consumptionMutex.Unlock()
+
+	c.subscriptionErr = err
+}
+
+// lastSubscriptionErr returns the error that ended or prevented the last subscription, if any.
+func (c *amqpChannel) lastSubscriptionErr() error {
+	c.consumptionMutex.Lock()
+	defer c.consumptionMutex.Unlock()
+
+	return c.subscriptionErr
+}
+
+// consumersHealth returns the health of all consumers of the connection.
+func (a *amqpConnection) consumersHealth() []ConsumerHealth {
+	channels := a.channelList()
+	consumers := make([]ConsumerHealth, 0, len(channels))
+
+	for _, parent := range channels {
+		if parent.consumer == nil {
+			continue
+		}
+
+		for _, channel := range parent.consumerChannels() {
+			consumers = append(consumers, channel.consumerHealth())
+		}
+	}
+
+	return consumers
+}
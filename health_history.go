@@ -0,0 +1,149 @@
+package gorabbit
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthTransition is a change of state of a connection of the client.
+type HealthTransition struct {
+	// Connection is the type of the connection, either "consumer" or "publisher".
+	Connection string
+
+	// Event is the lifecycle event of the connection.
+	Event ConnectionEvent
+
+	// Time is when the transition happened.
+	Time time.Time
+}
+
+// FlapReport describes a connection that reconnects repeatedly.
+type FlapReport struct {
+	// Connection is the type of the connection, either "consumer" or "publisher".
+	Connection string
+
+	// Reconnections is the number of reconnections within the Window.
+	Reconnections int
+
+	// Window is the window over which the reconnections are counted.
+	Window time.Duration
+}
+
+// FlapDetection configures when a connection is considered as flapping rather than suffering a single blip.
+type FlapDetection struct {
+	// Threshold is the number of reconnections within the Window from which a connection is flapping. Defaults to 5.
+	Threshold int
+
+	// Window is the window over which the reconnections are counted. Defaults to 10 minutes.
+	Window time.Duration
+
+	// OnFlapping is called when a connection starts flapping, if set. It is called again only once the connection
+	// stopped flapping in between.
+	OnFlapping func(report FlapReport)
+}
+
+// healthHistory is a MetricsCollector keeping the recent transitions of the connections and detecting their flapping.
+type healthHistory struct {
+	threshold     int
+	window        time.Duration
+	onFlapping    func(report FlapReport)
+	transitions   []HealthTransition
+	reconnections map[string][]time.Time
+	flapping      map[string]bool
+	mutex         sync.Mutex
+}
+
+func newHealthHistory(config *FlapDetection) *healthHistory {
+	history := &healthHistory{
+		threshold:     defaultFlapThreshold,
+		window:        defaultFlapWindow,
+		reconnections: make(map[string][]time.Time),
+		flapping:      make(map[string]bool),
+	}
+
+	if config != nil {
+		if config.Threshold > 0 {
+			history.threshold = config.Threshold
+		}
+
+		if config.Window > 0 {
+			history.window = config.Window
+		}
+
+		history.onFlapping = config.OnFlapping
+	}
+
+	return history
+}
+
+// ObserveDelivery ignores the deliveries.
+func (h *healthHistory) ObserveDelivery(DeliveryMetrics) {}
+
+// ObserveChannel ignores the channels, whose health follows their connection.
+func (h *healthHistory) ObserveChannel(ChannelMetrics) {}
+
+// ObserveConnection records the transition of a connection and, on reconnection, checks whether it is flapping.
+func (h *healthHistory) ObserveConnection(metrics ConnectionMetrics) {
+	now := time.Now()
+
+	h.mutex.Lock()
+
+	h.transitions = append(h.transitions, HealthTransition{Connection: metrics.Type, Event: metrics.Event, Time: now})
+
+	if len(h.transitions) > defaultHealthHistorySize {
+		h.transitions = h.transitions[len(h.transitions)-defaultHealthHistorySize:]
+	}
+
+	if metrics.Event == ConnectionEventReopened {
+		h.reconnections[metrics.Type] = append(h.reconnections[metrics.Type], now)
+	}
+
+	count := h.recentReconnections(metrics.Type, now)
+
+	startsFlapping := count >= h.threshold && !h.flapping[metrics.Type]
+
+	h.flapping[metrics.Type] = count >= h.threshold
+
+	h.mutex.Unlock()
+
+	if startsFlapping && h.onFlapping != nil {
+		h.onFlapping(FlapReport{Connection: metrics.Type, Reconnections: count, Window: h.window})
+	}
+}
+
+// recentReconnections drops the reconnections of a connection older than the window, and returns the remaining ones.
+// The mutex must be held.
+func (h *healthHistory) recentReconnections(connection string, now time.Time) int {
+	reconnections := h.reconnections[connection]
+
+	for len(reconnections) > 0 && now.Sub(reconnections[0]) > h.window {
+		reconnections = reconnections[1:]
+	}
+
+	h.reconnections[connection] = reconnections
+
+	return len(reconnections)
+}
+
+// fill sets the transitions and the flapping state into a HealthReport.
+func (h *healthHistory) fill(report *HealthReport) {
+	now := time.Now()
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	report.Transitions = make([]HealthTransition, len(h.transitions))
+	copy(report.Transitions, h.transitions)
+
+	for connection := range h.reconnections {
+		count := h.recentReconnections(connection, now)
+
+		report.RecentReconnections += count
+
+		if count < h.threshold {
+			h.flapping[connection] = false
+		} else {
+			report.Flapping = true
+		}
+	}
+}
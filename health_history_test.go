@@ -0,0 +1,36 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_HealthReport_History(t *testing.T) {
+	flapping := false
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetFlapDetection(gorabbit.FlapDetection{
+			Threshold: 1,
+			Window:    time.Minute,
+			OnFlapping: func(report gorabbit.FlapReport) {
+				flapping = true
+			},
+		}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// Nothing listens on the port, so the connections never open nor reconnect.
+	report := client.HealthReport()
+
+	assert.False(t, report.Ready)
+	assert.Empty(t, report.Transitions)
+	assert.Zero(t, report.RecentReconnections)
+	assert.False(t, report.Flapping)
+	assert.False(t, flapping)
+}
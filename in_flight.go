@@ -0,0 +1,96 @@
+package gorabbit
+
+import (
+	"sort"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// InFlightDelivery describes a delivery whose handler is running.
+type InFlightDelivery struct {
+	// MessageID is the unique identifier of the message.
+	MessageID string
+
+	// RoutingKey is the routing key of the delivery.
+	RoutingKey string
+
+	// Handler is the key under which the running handler is registered.
+	Handler string
+
+	// StartedAt is the time the handler was called at.
+	StartedAt time.Time
+}
+
+// Age returns how long the handler has been running.
+func (d InFlightDelivery) Age() time.Duration {
+	return time.Since(d.StartedAt)
+}
+
+// InFlightStats holds the deliveries being processed by a consumer.
+type InFlightStats struct {
+	// Count is the number of deliveries being processed.
+	Count int
+
+	// OldestAge is the age of the oldest delivery being processed, 0 if there is none.
+	OldestAge time.Duration
+
+	// Deliveries holds the deliveries being processed, the oldest first.
+	Deliveries []InFlightDelivery
+}
+
+// trackInFlight registers a delivery whose handler is about to be called, and returns the function that unregisters it.
+func (c *amqpChannel) trackInFlight(delivery *amqp.Delivery, routingKey, handler string) func() {
+	c.inFlightMutex.Lock()
+	defer c.inFlightMutex.Unlock()
+
+	if c.inFlightDeliveries == nil {
+		c.inFlightDeliveries = make(map[uint64]InFlightDelivery)
+	}
+
+	c.inFlightSequence++
+
+	id := c.inFlightSequence
+
+	c.inFlightDeliveries[id] = InFlightDelivery{
+		MessageID:  delivery.MessageId,
+		RoutingKey: routingKey,
+		Handler:    handler,
+		StartedAt:  time.Now(),
+	}
+
+	return func() {
+		c.inFlightMutex.Lock()
+		defer c.inFlightMutex.Unlock()
+
+		delete(c.inFlightDeliveries, id)
+	}
+}
+
+// inFlightStats returns the deliveries whose handler is running.
+func (c *amqpChannel) inFlightStats() InFlightStats {
+	c.inFlightMutex.Lock()
+
+	deliveries := make([]InFlightDelivery, 0, len(c.inFlightDeliveries))
+
+	for _, delivery := range c.inFlightDeliveries {
+		deliveries = append(deliveries, delivery)
+	}
+
+	c.inFlightMutex.Unlock()
+
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].StartedAt.Before(deliveries[j].StartedAt)
+	})
+
+	stats := InFlightStats{
+		Count:      len(deliveries),
+		Deliveries: deliveries,
+	}
+
+	if len(deliveries) > 0 {
+		stats.OldestAge = deliveries[0].Age()
+	}
+
+	return stats
+}
@@ -0,0 +1,60 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_InFlight(t *testing.T) {
+	server := newFakeServer(t)
+
+	release := make(chan struct{})
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{
+		PrefetchCount:     10,
+		ConcurrentProcess: true,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.*": func([]byte) error {
+				<-release
+
+				return nil
+			},
+		},
+	})
+
+	inFlight := func() gorabbit.InFlightStats {
+		return client.HealthReport().Consumers[0].InFlight
+	}
+
+	assert.Zero(t, inFlight().Count)
+
+	server.deliverMessages("events", fakeDelivery{RoutingKey: "event.created", Body: "first", MessageID: "1"})
+	require.Eventually(t, func() bool { return inFlight().Count == 1 }, time.Second, 10*time.Millisecond)
+
+	server.deliverMessages("events", fakeDelivery{RoutingKey: "event.deleted", Body: "second", MessageID: "2"})
+	require.Eventually(t, func() bool { return inFlight().Count == 2 }, time.Second, 10*time.Millisecond)
+
+	// The deliveries are listed the oldest first, along with the key of their handler.
+	stats := inFlight()
+
+	require.Len(t, stats.Deliveries, 2)
+	assert.Equal(t, "1", stats.Deliveries[0].MessageID)
+	assert.Equal(t, "event.created", stats.Deliveries[0].RoutingKey)
+	assert.Equal(t, "event.*", stats.Deliveries[0].Handler)
+	assert.Equal(t, "2", stats.Deliveries[1].MessageID)
+	assert.Equal(t, "event.deleted", stats.Deliveries[1].RoutingKey)
+	assert.True(t, stats.Deliveries[0].StartedAt.Before(stats.Deliveries[1].StartedAt))
+	assert.Positive(t, stats.OldestAge)
+
+	// The deliveries are no longer in flight once handled.
+	close(release)
+
+	require.Eventually(t, func() bool { return inFlight().Count == 0 }, time.Second, 10*time.Millisecond)
+	assert.Zero(t, inFlight().OldestAge)
+	assert.Empty(t, inFlight().Deliveries)
+}
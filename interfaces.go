@@ -0,0 +1,49 @@
+package gorabbit
+
+import (
+	"context"
+)
+
+// Publisher is the publishing side of a client, for the applications that only publish to depend on, and mock in their
+// tests.
+type Publisher interface {
+	// Publish will send the desired payload through the selected channel.
+	//	- exchange is the name of the exchange targeted for event publishing.
+	//	- routingKey is the route that the exchange will use to forward the message.
+	//	- payload is the object you want to send as a byte array.
+	// Returns an error if the connection to the RabbitMQ server is down.
+	Publish(exchange, routingKey string, payload interface{}) error
+
+	// PublishWithOptions will send the desired payload through the selected channel.
+	//	- exchange is the name of the exchange targeted for event publishing.
+	//	- routingKey is the route that the exchange will use to forward the message.
+	//	- payload is the object you want to send as a byte array.
+	// Optionally you can add publishingOptions for extra customization.
+	// Returns an error if the connection to the RabbitMQ server is down.
+	PublishWithOptions(exchange, routingKey string, payload interface{}, options *PublishingOptions) error
+
+	// PublishWithContext behaves like PublishWithOptions, with a context whose span, if tracing is enabled, is the
+	// parent of the publishing span. options can be nil.
+	PublishWithContext(ctx context.Context, exchange, routingKey string, payload interface{}, options *PublishingOptions) error
+}
+
+// ConsumerRegistry is the consuming side of a client, for the applications that only consume to depend on, and mock in
+// their tests.
+type ConsumerRegistry interface {
+	// RegisterConsumer will register a MessageConsumer for internal queue subscription and message processing.
+	// The MessageConsumer will hold a list of MQTTMessageHandlers to internalize message processing.
+	// Based on the return of error of each handler, the process of acknowledgment, rejection and retry of messages is
+	// fully handled internally.
+	RegisterConsumer(consumer MessageConsumer) error
+}
+
+// TopologyManager sets up the exchanges and the queues with their bindings. It is implemented by both the client and
+// the MQTTManager.
+type TopologyManager interface {
+	// SetupTopology declares the given exchanges, then the given queues along with their bindings, as loaded by
+	// LoadTopology for instance. Declarations are idempotent, so it can be called on every start.
+	// Returns an error if a queue is invalid, before declaring anything, or if a declaration fails.
+	// The returned Teardown deletes the exchanges and queues that did not exist before, even if the setup failed
+	// midway, along with their bindings. Bindings between existing exchanges and queues are left in place.
+	SetupTopology(ctx context.Context, exchanges []ExchangeConfig, queues []QueueConfig) (Teardown, error)
+}
@@ -0,0 +1,55 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+var (
+	_ gorabbit.Publisher        = gorabbit.MQTTClient(nil)
+	_ gorabbit.ConsumerRegistry = gorabbit.MQTTClient(nil)
+	_ gorabbit.TopologyManager  = gorabbit.MQTTClient(nil)
+	_ gorabbit.TopologyManager  = gorabbit.MQTTManager(nil)
+	_ gorabbit.Publisher        = (*gorabbit.MQTTPublisher)(nil)
+	_ gorabbit.Publisher        = (*gorabbit.AMQP10Publisher)(nil)
+)
+
+type recordingPublisher struct {
+	routingKeys []string
+}
+
+func (p *recordingPublisher) Publish(exchange, routingKey string, payload interface{}) error {
+	return p.PublishWithContext(context.Background(), exchange, routingKey, payload, nil)
+}
+
+func (p *recordingPublisher) PublishWithOptions(exchange, routingKey string, payload interface{}, options *gorabbit.PublishingOptions) error {
+	return p.PublishWithContext(context.Background(), exchange, routingKey, payload, options)
+}
+
+func (p *recordingPublisher) PublishWithContext(_ context.Context, _, routingKey string, _ interface{}, _ *gorabbit.PublishingOptions) error {
+	p.routingKeys = append(p.routingKeys, routingKey)
+
+	return nil
+}
+
+type invoiceService struct {
+	publisher gorabbit.Publisher
+}
+
+func (s *invoiceService) create() error {
+	return s.publisher.Publish("billing_exchange", "invoice.created", "invoice")
+}
+
+func TestPublisher_Mock(t *testing.T) {
+	publisher := &recordingPublisher{}
+
+	service := &invoiceService{publisher: publisher}
+
+	require.NoError(t, service.create())
+	assert.Equal(t, []string{"invoice.created"}, publisher.routingKeys)
+}
@@ -0,0 +1,149 @@
+package gorabbit
+
+import (
+	"sync"
+	"time"
+)
+
+// LogLevel is the minimum level of the logs of a client.
+type LogLevel uint8
+
+const (
+	// LogLevelDebug logs everything, this is the default.
+	LogLevelDebug LogLevel = iota
+
+	// LogLevelInfo logs the info, warn and error logs.
+	LogLevelInfo
+
+	// LogLevelWarn logs the warn and error logs.
+	LogLevelWarn
+
+	// LogLevelError logs the error logs only.
+	LogLevelError
+)
+
+// suppressedLogField is the field holding the number of identical logs suppressed by the sampling since the last one.
+const suppressedLogField = "suppressed"
+
+// leveledLogger filters the logs of its logger below a minimum level, and samples identical logs.
+type leveledLogger struct {
+	logger  Logger
+	level   LogLevel
+	sampler *logSampler
+}
+
+// newLeveledLogger wraps a logger with a minimum level and, if sampling is positive, logs identical messages at most
+// once per sampling. The logger is returned as is if it would not filter anything.
+func newLeveledLogger(logger Logger, level LogLevel, sampling time.Duration) Logger {
+	if level == LogLevelDebug && sampling <= 0 {
+		return logger
+	}
+
+	return &leveledLogger{
+		logger:  logger,
+		level:   level,
+		sampler: newLogSampler(sampling),
+	}
+}
+
+func (l leveledLogger) Error(err error, s string, fields ...LogField) {
+	if fields, ok := l.sample(LogLevelError, s, fields); ok {
+		l.logger.Error(err, s, fields...)
+	}
+}
+
+func (l leveledLogger) Warn(s string, fields ...LogField) {
+	if fields, ok := l.sample(LogLevelWarn, s, fields); ok {
+		l.logger.Warn(s, fields...)
+	}
+}
+
+func (l leveledLogger) Info(s string, fields ...LogField) {
+	if fields, ok := l.sample(LogLevelInfo, s, fields); ok {
+		l.logger.Info(s, fields...)
+	}
+}
+
+func (l leveledLogger) Debug(s string, fields ...LogField) {
+	if fields, ok := l.sample(LogLevelDebug, s, fields); ok {
+		l.logger.Debug(s, fields...)
+	}
+}
+
+// sample returns whether a log is kept, along with its fields completed with the number of suppressed identical logs.
+func (l leveledLogger) sample(level LogLevel, s string, fields []LogField) ([]LogField, bool) {
+	if level < l.level {
+		return nil, false
+	}
+
+	suppressed, ok := l.sampler.allow(level, s)
+	if !ok {
+		return nil, false
+	}
+
+	if suppressed > 0 {
+		fields = append(fields, LogField{Key: suppressedLogField, Value: suppressed})
+	}
+
+	return fields, true
+}
+
+// logSampler keeps track of identical logs, to let through at most one per interval.
+type logSampler struct {
+	interval time.Duration
+	samples  map[logSample]*logSampleState
+	mutex    sync.Mutex
+}
+
+// logSample identifies identical logs.
+type logSample struct {
+	level   LogLevel
+	message string
+}
+
+// logSampleState holds when a log was last let through, and how many identical logs were suppressed since.
+type logSampleState struct {
+	loggedAt   time.Time
+	suppressed int
+}
+
+func newLogSampler(interval time.Duration) *logSampler {
+	return &logSampler{
+		interval: interval,
+		samples:  make(map[logSample]*logSampleState),
+	}
+}
+
+// allow returns whether a log is let through and, if so, the number of identical logs suppressed since the last one.
+// Without interval, every log is let through.
+func (s *logSampler) allow(level LogLevel, message string) (int, bool) {
+	if s.interval <= 0 {
+		return 0, true
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	key := logSample{level: level, message: message}
+	now := time.Now()
+
+	state, exists := s.samples[key]
+	if !exists {
+		s.samples[key] = &logSampleState{loggedAt: now}
+
+		return 0, true
+	}
+
+	if now.Sub(state.loggedAt) < s.interval {
+		state.suppressed++
+
+		return 0, false
+	}
+
+	suppressed := state.suppressed
+
+	state.loggedAt = now
+	state.suppressed = 0
+
+	return suppressed, true
+}
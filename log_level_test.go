@@ -0,0 +1,50 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClientOptions_SetLogLevel(t *testing.T) {
+	logger := new(recordingLogger)
+
+	// Nothing listens on the port, so the connection fails and logs it.
+	_ = gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetKeepAlive(false).
+		SetLogger(logger).
+		SetLogLevel(gorabbit.LogLevelError))
+
+	assert.Equal(t, []string{"Connection failed", "Connection failed"}, logger.messages)
+}
+
+func TestClientOptions_SetLogSampling(t *testing.T) {
+	logger := new(recordingLogger)
+
+	// Nothing listens on the port, so both connections keep failing every retry delay.
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(5 * time.Millisecond).
+		SetLogger(logger).
+		SetLogLevel(gorabbit.LogLevelError).
+		SetLogSampling(time.Hour))
+
+	time.Sleep(100 * time.Millisecond)
+
+	_ = client.Disconnect()
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	// Each connection logs its failures once, whatever the number of attempts.
+	assert.ElementsMatch(t, []string{
+		"Connection failed",
+		"Connection failed",
+		"Could not open new connection during re-connection",
+		"Could not open new connection during re-connection",
+	}, logger.messages)
+}
@@ -2,24 +2,27 @@ package gorabbit
 
 import (
 	"os"
+	"sort"
 
 	"github.com/sirupsen/logrus"
 )
 
-type logField struct {
+// LogField is a structured field of a log.
+type LogField struct {
 	Key   string
 	Value interface{}
 }
 
-// logger is the interface that defines log methods.
-type logger interface {
-	Error(error, string, ...logField)
+// Logger is the interface that defines log methods. It can be implemented to send the logs of the client to any
+// logging library, through ClientOptions.SetLogger.
+type Logger interface {
+	Error(error, string, ...LogField)
 
-	Warn(string, ...logField)
+	Warn(string, ...LogField)
 
-	Info(string, ...logField)
+	Info(string, ...LogField)
 
-	Debug(string, ...logField)
+	Debug(string, ...LogField)
 }
 
 // stdLogger logs to stdout using logrus (https://github.com/sirupsen/logrus).
@@ -29,7 +32,7 @@ type stdLogger struct {
 	logFields  map[string]interface{}
 }
 
-func newStdLogger() logger {
+func newStdLogger() Logger {
 	return &stdLogger{
 		logger:     newLogrus(),
 		identifier: libraryName,
@@ -37,7 +40,7 @@ func newStdLogger() logger {
 	}
 }
 
-func (l stdLogger) getExtraFields(fields []logField) map[string]interface{} {
+func (l stdLogger) getExtraFields(fields []LogField) map[string]interface{} {
 	extraFields := make(map[string]interface{})
 
 	for k, field := range l.logFields {
@@ -51,7 +54,7 @@ func (l stdLogger) getExtraFields(fields []logField) map[string]interface{} {
 	return extraFields
 }
 
-func (l stdLogger) Error(err error, s string, fields ...logField) {
+func (l stdLogger) Error(err error, s string, fields ...LogField) {
 	log := l.logger.WithField("library", l.identifier)
 
 	extraFields := l.getExtraFields(fields)
@@ -59,7 +62,7 @@ func (l stdLogger) Error(err error, s string, fields ...logField) {
 	log.WithFields(extraFields).WithError(err).Error(s)
 }
 
-func (l stdLogger) Warn(s string, fields ...logField) {
+func (l stdLogger) Warn(s string, fields ...LogField) {
 	log := l.logger.WithField("library", l.identifier)
 
 	extraFields := l.getExtraFields(fields)
@@ -67,7 +70,7 @@ func (l stdLogger) Warn(s string, fields ...logField) {
 	log.WithFields(extraFields).Warn(s)
 }
 
-func (l stdLogger) Info(s string, fields ...logField) {
+func (l stdLogger) Info(s string, fields ...LogField) {
 	log := l.logger.WithField("library", l.identifier)
 
 	extraFields := l.getExtraFields(fields)
@@ -75,7 +78,7 @@ func (l stdLogger) Info(s string, fields ...logField) {
 	log.WithFields(extraFields).Info(s)
 }
 
-func (l stdLogger) Debug(s string, fields ...logField) {
+func (l stdLogger) Debug(s string, fields ...LogField) {
 	log := l.logger.WithField("library", l.identifier)
 
 	extraFields := l.getExtraFields(fields)
@@ -83,16 +86,66 @@ func (l stdLogger) Debug(s string, fields ...logField) {
 	log.WithFields(extraFields).Debug(s)
 }
 
+// customLogger passes the logs to a Logger provided by the user, along with the inherited fields.
+type customLogger struct {
+	logger    Logger
+	logFields map[string]interface{}
+}
+
+func newCustomLogger(logger Logger) Logger {
+	return &customLogger{
+		logger:    logger,
+		logFields: nil,
+	}
+}
+
+// fields returns the library and the inherited fields sorted by key, followed by the given fields.
+func (l customLogger) fields(fields []LogField) []LogField {
+	allFields := make([]LogField, 0, 1+len(l.logFields)+len(fields))
+
+	allFields = append(allFields, LogField{Key: "library", Value: libraryName})
+
+	keys := make([]string, 0, len(l.logFields))
+
+	for key := range l.logFields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		allFields = append(allFields, LogField{Key: key, Value: l.logFields[key]})
+	}
+
+	return append(allFields, fields...)
+}
+
+func (l customLogger) Error(err error, s string, fields ...LogField) {
+	l.logger.Error(err, s, l.fields(fields)...)
+}
+
+func (l customLogger) Warn(s string, fields ...LogField) {
+	l.logger.Warn(s, l.fields(fields)...)
+}
+
+func (l customLogger) Info(s string, fields ...LogField) {
+	l.logger.Info(s, l.fields(fields)...)
+}
+
+func (l customLogger) Debug(s string, fields ...LogField) {
+	l.logger.Debug(s, l.fields(fields)...)
+}
+
 // noLogger does not log at all, this is the default.
 type noLogger struct{}
 
-func (l noLogger) Error(_ error, _ string, _ ...logField) {}
+func (l noLogger) Error(_ error, _ string, _ ...LogField) {}
 
-func (l noLogger) Warn(_ string, _ ...logField) {}
+func (l noLogger) Warn(_ string, _ ...LogField) {}
 
-func (l noLogger) Info(_ string, _ ...logField) {}
+func (l noLogger) Info(_ string, _ ...LogField) {}
 
-func (l noLogger) Debug(_ string, _ ...logField) {}
+func (l noLogger) Debug(_ string, _ ...LogField) {}
 
 func newLogrus() *logrus.Logger {
 	log := &logrus.Logger{
@@ -114,7 +167,7 @@ func newLogrus() *logrus.Logger {
 	return log
 }
 
-func inheritLogger(parent logger, logFields map[string]interface{}) logger {
+func inheritLogger(parent Logger, logFields map[string]interface{}) Logger {
 	switch v := parent.(type) {
 	case *stdLogger:
 		return &stdLogger{
@@ -122,7 +175,60 @@ func inheritLogger(parent logger, logFields map[string]interface{}) logger {
 			identifier: libraryName,
 			logFields:  logFields,
 		}
+	case *slogLogger:
+		return &slogLogger{
+			logger:    v.logger,
+			logFields: logFields,
+		}
+	case *customLogger:
+		return &customLogger{
+			logger:    v.logger,
+			logFields: logFields,
+		}
+	case *leveledLogger:
+		return &leveledLogger{
+			logger:  inheritLogger(v.logger, logFields),
+			level:   v.level,
+			sampler: newLogSampler(v.sampler.interval),
+		}
+	case *reloadableLogger:
+		return &derivedLogger{parent: v, derive: func(logger Logger) Logger {
+			return inheritLogger(logger, logFields)
+		}}
+	case *derivedLogger:
+		return &derivedLogger{parent: v.parent, derive: func(logger Logger) Logger {
+			return inheritLogger(logger, logFields)
+		}}
 	default:
 		return parent
 	}
 }
+
+// newReleaseLogger returns the logger of the important events, logged no matter the mode. They are logged by the
+// parent logger if it was provided by the user, and to stdout otherwise.
+func newReleaseLogger(parent Logger, logFields map[string]interface{}) Logger {
+	switch v := parent.(type) {
+	case *slogLogger, *customLogger:
+		return inheritLogger(parent, logFields)
+	case *leveledLogger:
+		return &leveledLogger{
+			logger:  newReleaseLogger(v.logger, logFields),
+			level:   v.level,
+			sampler: newLogSampler(v.sampler.interval),
+		}
+	case *reloadableLogger:
+		return &derivedLogger{parent: v, derive: func(logger Logger) Logger {
+			return newReleaseLogger(logger, logFields)
+		}}
+	case *derivedLogger:
+		return &derivedLogger{parent: v.parent, derive: func(logger Logger) Logger {
+			return newReleaseLogger(logger, logFields)
+		}}
+	}
+
+	return &stdLogger{
+		logger:     newLogrus(),
+		identifier: libraryName,
+		logFields:  logFields,
+	}
+}
@@ -0,0 +1,48 @@
+package gorabbit_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type recordingLogger struct {
+	messages []string
+	fields   [][]gorabbit.LogField
+	mutex    sync.Mutex
+}
+
+func (l *recordingLogger) record(s string, fields []gorabbit.LogField) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.messages = append(l.messages, s)
+	l.fields = append(l.fields, fields)
+}
+
+func (l *recordingLogger) Error(_ error, s string, fields ...gorabbit.LogField) { l.record(s, fields) }
+
+func (l *recordingLogger) Warn(s string, fields ...gorabbit.LogField) { l.record(s, fields) }
+
+func (l *recordingLogger) Info(s string, fields ...gorabbit.LogField) { l.record(s, fields) }
+
+func (l *recordingLogger) Debug(s string, fields ...gorabbit.LogField) { l.record(s, fields) }
+
+func TestClientOptions_SetLogger(t *testing.T) {
+	logger := new(recordingLogger)
+
+	// Nothing listens on the port, so the connection fails and logs it.
+	_ = gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetKeepAlive(false).
+		SetLogger(logger))
+
+	require.NotEmpty(t, logger.messages)
+
+	assert.Contains(t, logger.fields[0], gorabbit.LogField{Key: "library", Value: "Gorabbit"})
+	assert.Contains(t, logger.fields[0], gorabbit.LogField{Key: "context", Value: "connection"})
+}
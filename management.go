@@ -0,0 +1,366 @@
+package gorabbit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// managementDefaultVhost is the vhost queried when the ManagementOptions have none.
+const managementDefaultVhost = "/"
+
+// ManagementClient queries the RabbitMQ management HTTP API of a vhost: its queues and their depth, exchanges,
+// bindings, consumers and policies, along with the health of the nodes.
+type ManagementClient struct {
+	baseURL    string
+	username   string
+	password   string
+	vhost      string
+	httpClient *http.Client
+}
+
+// NewManagementClient will instantiate a new ManagementClient. No request is made until a method is called.
+func NewManagementClient(options *ManagementOptions) *ManagementClient {
+	if options == nil {
+		options = DefaultManagementOptions()
+	}
+
+	protocol := managementProtocol
+	if options.UseTLS {
+		protocol = securedManagementProtocol
+	}
+
+	vhost := options.Vhost
+	if vhost == "" {
+		vhost = managementDefaultVhost
+	}
+
+	return &ManagementClient{
+		baseURL:    fmt.Sprintf("%s://%s/api", protocol, net.JoinHostPort(options.Host, strconv.FormatUint(uint64(options.Port), 10))),
+		username:   options.Username,
+		password:   options.Password,
+		vhost:      vhost,
+		httpClient: &http.Client{Timeout: options.Timeout},
+	}
+}
+
+// NewManagementClientFromEnv will instantiate a new ManagementClient from environment variables.
+func NewManagementClientFromEnv() *ManagementClient {
+	return NewManagementClient(NewManagementOptionsFromEnv())
+}
+
+// ManagementError is returned when the management API answers with an error status.
+type ManagementError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Path is the requested API path.
+	Path string
+
+	// Reason is the reason given by the server, if any.
+	Reason string
+}
+
+func (e *ManagementError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("management api %s returned status %d", e.Path, e.StatusCode)
+	}
+
+	return fmt.Sprintf("management api %s returned status %d: %s", e.Path, e.StatusCode, e.Reason)
+}
+
+// ManagementQueue is a queue as described by the management API.
+type ManagementQueue struct {
+	Name                   string                 `json:"name"`
+	Vhost                  string                 `json:"vhost"`
+	Type                   string                 `json:"type"`
+	Durable                bool                   `json:"durable"`
+	AutoDelete             bool                   `json:"auto_delete"`
+	Exclusive              bool                   `json:"exclusive"`
+	Arguments              map[string]interface{} `json:"arguments"`
+	Node                   string                 `json:"node"`
+	State                  string                 `json:"state"`
+	Policy                 string                 `json:"policy"`
+	Messages               int                    `json:"messages"`
+	MessagesReady          int                    `json:"messages_ready"`
+	MessagesUnacknowledged int                    `json:"messages_unacknowledged"`
+	Consumers              int                    `json:"consumers"`
+}
+
+// ManagementExchange is an exchange as described by the management API.
+type ManagementExchange struct {
+	Name       string                 `json:"name"`
+	Vhost      string                 `json:"vhost"`
+	Type       string                 `json:"type"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Internal   bool                   `json:"internal"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+// ManagementBinding is a binding as described by the management API.
+type ManagementBinding struct {
+	Source          string                 `json:"source"`
+	Vhost           string                 `json:"vhost"`
+	Destination     string                 `json:"destination"`
+	DestinationType string                 `json:"destination_type"`
+	RoutingKey      string                 `json:"routing_key"`
+	Arguments       map[string]interface{} `json:"arguments"`
+	PropertiesKey   string                 `json:"properties_key"`
+}
+
+// ManagementQueueReference identifies the queue of a ManagementConsumer.
+type ManagementQueueReference struct {
+	Name  string `json:"name"`
+	Vhost string `json:"vhost"`
+}
+
+// ManagementConsumer is a consumer as described by the management API.
+type ManagementConsumer struct {
+	ConsumerTag   string                   `json:"consumer_tag"`
+	Queue         ManagementQueueReference `json:"queue"`
+	Exclusive     bool                     `json:"exclusive"`
+	AckRequired   bool                     `json:"ack_required"`
+	PrefetchCount int                      `json:"prefetch_count"`
+	Active        bool                     `json:"active"`
+}
+
+// ManagementNode is a cluster node as described by the management API.
+type ManagementNode struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Running       bool   `json:"running"`
+	MemoryAlarm   bool   `json:"mem_alarm"`
+	DiskFreeAlarm bool   `json:"disk_free_alarm"`
+	Uptime        int64  `json:"uptime"`
+}
+
+// ManagementPolicy is a policy applying a definition, such as a max length or a federation upstream, to the queues
+// or exchanges whose name matches its pattern.
+type ManagementPolicy struct {
+	Name       string                 `json:"name"`
+	Vhost      string                 `json:"vhost,omitempty"`
+	Pattern    string                 `json:"pattern"`
+	ApplyTo    string                 `json:"apply-to,omitempty"`
+	Definition map[string]interface{} `json:"definition"`
+	Priority   int                    `json:"priority"`
+}
+
+// ListQueues returns the queues of the vhost, along with their depth and consumer count.
+func (m *ManagementClient) ListQueues(ctx context.Context) ([]ManagementQueue, error) {
+	var queues []ManagementQueue
+
+	err := m.do(ctx, http.MethodGet, m.vhostPath("queues"), nil, &queues)
+
+	return queues, err
+}
+
+// GetQueue returns a queue of the vhost, along with its depth and consumer count.
+func (m *ManagementClient) GetQueue(ctx context.Context, name string) (*ManagementQueue, error) {
+	queue := new(ManagementQueue)
+
+	if err := m.do(ctx, http.MethodGet, m.vhostPath("queues", name), nil, queue); err != nil {
+		return nil, err
+	}
+
+	return queue, nil
+}
+
+// ListExchanges returns the exchanges of the vhost.
+func (m *ManagementClient) ListExchanges(ctx context.Context) ([]ManagementExchange, error) {
+	var exchanges []ManagementExchange
+
+	err := m.do(ctx, http.MethodGet, m.vhostPath("exchanges"), nil, &exchanges)
+
+	return exchanges, err
+}
+
+// ListBindings returns the bindings of the vhost.
+func (m *ManagementClient) ListBindings(ctx context.Context) ([]ManagementBinding, error) {
+	var bindings []ManagementBinding
+
+	err := m.do(ctx, http.MethodGet, m.vhostPath("bindings"), nil, &bindings)
+
+	return bindings, err
+}
+
+// ListQueueBindings returns the bindings of a queue of the vhost.
+func (m *ManagementClient) ListQueueBindings(ctx context.Context, queue string) ([]ManagementBinding, error) {
+	var bindings []ManagementBinding
+
+	err := m.do(ctx, http.MethodGet, m.vhostPath("queues", queue, "bindings"), nil, &bindings)
+
+	return bindings, err
+}
+
+// ListConsumers returns the consumers of the vhost.
+func (m *ManagementClient) ListConsumers(ctx context.Context) ([]ManagementConsumer, error) {
+	var consumers []ManagementConsumer
+
+	err := m.do(ctx, http.MethodGet, m.vhostPath("consumers"), nil, &consumers)
+
+	return consumers, err
+}
+
+// ListNodes returns the nodes of the cluster.
+func (m *ManagementClient) ListNodes(ctx context.Context) ([]ManagementNode, error) {
+	var nodes []ManagementNode
+
+	err := m.do(ctx, http.MethodGet, "/nodes", nil, &nodes)
+
+	return nodes, err
+}
+
+// CheckHealth returns an error if the node serving the API is unreachable or has a resource alarm in effect in the
+// cluster, such as a memory or disk alarm blocking the publishers.
+func (m *ManagementClient) CheckHealth(ctx context.Context) error {
+	return m.do(ctx, http.MethodGet, "/health/checks/alarms", nil, nil)
+}
+
+// ListPolicies returns the policies of the vhost.
+func (m *ManagementClient) ListPolicies(ctx context.Context) ([]ManagementPolicy, error) {
+	var policies []ManagementPolicy
+
+	err := m.do(ctx, http.MethodGet, m.vhostPath("policies"), nil, &policies)
+
+	return policies, err
+}
+
+// PutPolicy creates or replaces a policy of the vhost.
+func (m *ManagementClient) PutPolicy(ctx context.Context, policy ManagementPolicy) error {
+	if policy.Name == "" {
+		return errEmptyPolicyName
+	}
+
+	policy.Vhost = ""
+
+	return m.do(ctx, http.MethodPut, m.vhostPath("policies", policy.Name), policy, nil)
+}
+
+// DeletePolicy deletes a policy of the vhost.
+func (m *ManagementClient) DeletePolicy(ctx context.Context, name string) error {
+	if name == "" {
+		return errEmptyPolicyName
+	}
+
+	return m.do(ctx, http.MethodDelete, m.vhostPath("policies", name), nil, nil)
+}
+
+// putParameter creates or replaces a runtime parameter of the vhost, such as a dynamic shovel or a federation upstream.
+func (m *ManagementClient) putParameter(ctx context.Context, component, name string, value interface{}) error {
+	body := map[string]interface{}{"value": value}
+
+	return m.do(ctx, http.MethodPut, m.parameterPath(component, name), body, nil)
+}
+
+// deleteParameter deletes a runtime parameter of the vhost.
+func (m *ManagementClient) deleteParameter(ctx context.Context, component, name string) error {
+	return m.do(ctx, http.MethodDelete, m.parameterPath(component, name), nil, nil)
+}
+
+// parameterPath returns the escaped path of a runtime parameter of the vhost.
+func (m *ManagementClient) parameterPath(component, name string) string {
+	return "/parameters/" + url.PathEscape(component) + "/" + url.PathEscape(m.vhost) + "/" + url.PathEscape(name)
+}
+
+// poll calls check every interval until it reports done or fails, or the context is canceled.
+func poll(ctx context.Context, interval time.Duration, check func() (bool, error)) error {
+	if interval <= 0 {
+		interval = defaultManagementPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// vhostPath returns the escaped path of a resource of the vhost.
+func (m *ManagementClient) vhostPath(resource string, segments ...string) string {
+	path := "/" + resource + "/" + url.PathEscape(m.vhost)
+
+	for _, segment := range segments {
+		path += "/" + url.PathEscape(segment)
+	}
+
+	return path
+}
+
+// do sends a request to the management API, encoding the body and decoding the response into result, if set.
+func (m *ManagementClient) do(ctx context.Context, method, path string, body, result interface{}) error {
+	var reader io.Reader
+
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+
+		reader = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, m.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	request.SetBasicAuth(m.username, m.password)
+	request.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := m.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode >= http.StatusBadRequest {
+		return managementError(response, path)
+	}
+
+	if result == nil || response.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	return json.NewDecoder(response.Body).Decode(result)
+}
+
+// managementError returns the ManagementError of an error response, with the reason given by the server if any.
+func managementError(response *http.Response, path string) error {
+	var payload struct {
+		Error  string `json:"error"`
+		Reason string `json:"reason"`
+	}
+
+	// The reason is only informative, so a body that cannot be decoded is ignored.
+	_ = json.NewDecoder(response.Body).Decode(&payload)
+
+	reason := payload.Reason
+	if reason == "" {
+		reason = payload.Error
+	}
+
+	return &ManagementError{StatusCode: response.StatusCode, Path: path, Reason: reason}
+}
@@ -0,0 +1,126 @@
+package gorabbit
+
+import "time"
+
+// ManagementOptions holds all necessary properties to query the RabbitMQ management HTTP API with a ManagementClient.
+type ManagementOptions struct {
+	// Host is the RabbitMQ server host name.
+	Host string
+
+	// Port is the RabbitMQ management API port number.
+	Port uint
+
+	// Username is the RabbitMQ server allowed username, which needs a management tag.
+	Username string
+
+	// Password is the RabbitMQ server allowed password.
+	Password string
+
+	// Vhost is the vhost of the queried queues, exchanges, bindings, consumers and policies.
+	Vhost string
+
+	// UseTLS defines whether we use http or https protocol.
+	UseTLS bool
+
+	// Timeout is the timeout of each request.
+	Timeout time.Duration
+}
+
+// DefaultManagementOptions will return a ManagementOptions with default values.
+func DefaultManagementOptions() *ManagementOptions {
+	return &ManagementOptions{
+		Host:     defaultHost,
+		Port:     defaultManagementPort,
+		Username: defaultUsername,
+		Password: defaultPassword,
+		Vhost:    defaultVhost,
+		UseTLS:   defaultUseTLS,
+		Timeout:  defaultManagementTimeout,
+	}
+}
+
+// NewManagementOptions is the exported builder for a ManagementOptions and will offer setter methods for an easy
+// construction. Any non-assigned field will be set to default through DefaultManagementOptions.
+func NewManagementOptions() *ManagementOptions {
+	return DefaultManagementOptions()
+}
+
+// NewManagementOptionsFromEnv will generate a ManagementOptions from the same environment variables as the
+// ClientOptions, along with RABBITMQ_MANAGEMENT_PORT. Empty values will be taken as default through the
+// DefaultManagementOptions.
+func NewManagementOptionsFromEnv() *ManagementOptions {
+	defaultOpts := DefaultManagementOptions()
+
+	fromEnv := new(RabbitMQEnvs)
+
+	if err := loadEnv(fromEnv); err != nil {
+		return defaultOpts
+	}
+
+	if fromEnv.Host != "" {
+		defaultOpts.Host = fromEnv.Host
+	}
+
+	if fromEnv.ManagementPort > 0 {
+		defaultOpts.Port = fromEnv.ManagementPort
+	}
+
+	if fromEnv.Username != "" {
+		defaultOpts.Username = fromEnv.Username
+	}
+
+	if fromEnv.Password != "" {
+		defaultOpts.Password = fromEnv.Password
+	}
+
+	if fromEnv.Vhost != "" {
+		defaultOpts.Vhost = fromEnv.Vhost
+	}
+
+	defaultOpts.UseTLS = fromEnv.UseTLS
+
+	return defaultOpts
+}
+
+// SetHost will assign the host.
+func (m *ManagementOptions) SetHost(host string) *ManagementOptions {
+	m.Host = host
+
+	return m
+}
+
+// SetPort will assign the management API port.
+func (m *ManagementOptions) SetPort(port uint) *ManagementOptions {
+	m.Port = port
+
+	return m
+}
+
+// SetCredentials will assign the username and password.
+func (m *ManagementOptions) SetCredentials(username, password string) *ManagementOptions {
+	m.Username = username
+	m.Password = password
+
+	return m
+}
+
+// SetVhost will assign the Vhost.
+func (m *ManagementOptions) SetVhost(vhost string) *ManagementOptions {
+	m.Vhost = vhost
+
+	return m
+}
+
+// SetUseTLS will assign the UseTLS status.
+func (m *ManagementOptions) SetUseTLS(use bool) *ManagementOptions {
+	m.UseTLS = use
+
+	return m
+}
+
+// SetTimeout will assign the timeout of each request.
+func (m *ManagementOptions) SetTimeout(timeout time.Duration) *ManagementOptions {
+	m.Timeout = timeout
+
+	return m
+}
@@ -0,0 +1,168 @@
+package gorabbit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func newTestManagementClient(t *testing.T, handler http.HandlerFunc) *gorabbit.ManagementClient {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	portNumber, err := strconv.ParseUint(port, 10, 16)
+	require.NoError(t, err)
+
+	return gorabbit.NewManagementClient(gorabbit.NewManagementOptions().
+		SetHost(host).
+		SetPort(uint(portNumber)).
+		SetCredentials("admin", "secret"))
+}
+
+func TestManagementClient_GetQueue(t *testing.T) {
+	client := newTestManagementClient(t, func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "admin", username)
+		assert.Equal(t, "secret", password)
+
+		// The default vhost is escaped in the path.
+		assert.Equal(t, "/api/queues/%2F/events_queue", r.URL.EscapedPath())
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"name":           "events_queue",
+			"vhost":          "/",
+			"messages_ready": 42,
+			"consumers":      2,
+		})
+	})
+
+	queue, err := client.GetQueue(context.Background(), "events_queue")
+	require.NoError(t, err)
+
+	assert.Equal(t, "events_queue", queue.Name)
+	assert.Equal(t, 42, queue.MessagesReady)
+	assert.Equal(t, 2, queue.Consumers)
+}
+
+func TestManagementClient_PutPolicy(t *testing.T) {
+	client := newTestManagementClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/api/policies/%2F/max-length", r.URL.EscapedPath())
+
+		var policy gorabbit.ManagementPolicy
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&policy))
+		assert.Equal(t, "^events\\.", policy.Pattern)
+		assert.Equal(t, "queues", policy.ApplyTo)
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	err := client.PutPolicy(context.Background(), gorabbit.ManagementPolicy{
+		Name:       "max-length",
+		Pattern:    "^events\\.",
+		ApplyTo:    "queues",
+		Definition: map[string]interface{}{"max-length": 1000},
+	})
+	require.NoError(t, err)
+
+	assert.Error(t, client.PutPolicy(context.Background(), gorabbit.ManagementPolicy{}))
+}
+
+func TestManagementClient_Error(t *testing.T) {
+	client := newTestManagementClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "failed", "reason": "resource alarm in effect"})
+	})
+
+	err := client.CheckHealth(context.Background())
+	require.Error(t, err)
+
+	var managementErr *gorabbit.ManagementError
+
+	require.True(t, errors.As(err, &managementErr))
+	assert.Equal(t, http.StatusServiceUnavailable, managementErr.StatusCode)
+	assert.Equal(t, "resource alarm in effect", managementErr.Reason)
+}
+
+func TestManagementClient_PutShovel(t *testing.T) {
+	client := newTestManagementClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/api/parameters/shovel/%2F/replicate-events", r.URL.EscapedPath())
+
+		var body struct {
+			Value map[string]interface{} `json:"value"`
+		}
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{
+			"src-protocol":  "amqp091",
+			"src-uri":       "amqp://",
+			"src-queue":     "events_queue",
+			"dest-protocol": "amqp091",
+			"dest-uri":      "amqps://replica",
+			"dest-queue":    "events_queue",
+		}, body.Value)
+
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	err := client.PutShovel(context.Background(), gorabbit.ShovelConfig{
+		Name:             "replicate-events",
+		SourceURI:        "amqp://",
+		SourceQueue:      "events_queue",
+		DestinationURI:   "amqps://replica",
+		DestinationQueue: "events_queue",
+	})
+	require.NoError(t, err)
+
+	err = client.PutShovel(context.Background(), gorabbit.ShovelConfig{Name: "replicate-events", DestinationQueue: "events_queue"})
+	assert.Error(t, err)
+}
+
+func TestManagementClient_WaitForShovel(t *testing.T) {
+	var calls atomic.Int32
+
+	client := newTestManagementClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/shovels/%2F", r.URL.EscapedPath())
+
+		state := gorabbit.ShovelStateStarting
+		if calls.Add(1) > 1 {
+			state = gorabbit.ShovelStateRunning
+		}
+
+		_ = json.NewEncoder(w).Encode([]gorabbit.ShovelStatus{{Name: "replicate-events", State: state}})
+	})
+
+	require.NoError(t, client.WaitForShovel(context.Background(), "replicate-events", time.Millisecond))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestManagementClient_WaitForFederationLinks(t *testing.T) {
+	client := newTestManagementClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode([]gorabbit.FederationLink{
+			{Upstream: "eu-west", Exchange: "events_exchange", Status: gorabbit.FederationLinkError, Error: "connection refused"},
+		})
+	})
+
+	err := client.WaitForFederationLinks(context.Background(), "eu-west", time.Millisecond)
+	assert.ErrorContains(t, err, "connection refused")
+}
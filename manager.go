@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,6 +32,11 @@ type MQTTManager interface {
 	// Returns an error if the connection to the RabbitMQ server is down or if the exchange or queue does not exist.
 	BindExchangeToQueueViaRoutingKey(exchange, queue, routingKey string) error
 
+	// BindExchangeToExchange will bind a destination exchange to a source exchange via a given routingKey and, for
+	// headers exchanges, arguments.
+	// Returns an error if the connection to the RabbitMQ server is down or if one of the exchanges does not exist.
+	BindExchangeToExchange(source, destination, routingKey string, args map[string]interface{}) error
+
 	// GetNumberOfMessages retrieves the number of messages currently sitting in a given queue.
 	// Returns an error if the connection to the RabbitMQ server is down or the queue does not exist.
 	GetNumberOfMessages(queue string) (int, error)
@@ -55,9 +61,31 @@ type MQTTManager interface {
 	// Returns an error if the connection to the RabbitMQ server is down or the exchange does not exist.
 	DeleteExchange(exchange string) error
 
+	// ReplayDeadLetters republishes the messages of a dead-letter queue matching the filter, if not nil, to the given
+	// queue or, if empty, to their original exchange and routing key. At most rate messages are replayed per second,
+	// or without limit if 0. Only the messages present when the replay starts are considered, and a message is only
+	// removed from the dead-letter queue once its copy is confirmed. Other messages are left in place.
+	ReplayDeadLetters(ctx context.Context, from, to string, filter func(delivery Delivery) bool, rate int) (ReplayReport, error)
+
 	// SetupFromDefinitions loads a definitions.json file and automatically sets up exchanges, queues and bindings.
 	SetupFromDefinitions(path string) error
 
+	// ExportSchema returns the topology declared through the manager as a RabbitMQ definitions JSON document, in the
+	// SchemaDefinitions format, so that it can be compared with the definitions exported from the broker.
+	ExportSchema() ([]byte, error)
+
+	// VerifyTopology verifies, without creating anything, that the given exchanges and queues exist with the expected
+	// properties and arguments, as loaded by LoadTopology for instance. Bindings are not verified.
+	// The report lists what is missing or mismatched. Returns an error if the verification itself could not be performed.
+	VerifyTopology(ctx context.Context, exchanges []ExchangeConfig, queues []QueueConfig) (TopologyReport, error)
+
+	// Migrate applies, in order, the migrations whose version is greater than the last applied one, which is recorded in
+	// the "gorabbit.migrations" queue. Concurrent managers wait for each other through an exclusive lock queue.
+	// Returns the last applied version, along with the error of the failing migration if any.
+	Migrate(ctx context.Context, migrations []Migration) (uint, error)
+
+	TopologyManager
+
 	// GetHost returns the host used to initialize the manager.
 	GetHost() string
 
@@ -91,7 +119,7 @@ type mqttManager struct {
 	Vhost string
 
 	// logger defines the logger used, depending on the mode set.
-	logger logger
+	logger Logger
 
 	// disabled completely disables the manager if true.
 	disabled bool
@@ -101,6 +129,12 @@ type mqttManager struct {
 
 	// channel holds the single channel from the connection.
 	channel *amqp.Channel
+
+	// topology records the topology declared through the manager, to export it.
+	topology *topologyRegistry
+
+	// migrationMutex serializes the migrations of the manager, since the lock queue only excludes other connections.
+	migrationMutex sync.Mutex
 }
 
 // NewManager will instantiate a new MQTTManager.
@@ -111,17 +145,18 @@ func NewManager(options *ManagerOptions) (MQTTManager, error) {
 		options = DefaultManagerOptions()
 	}
 
-	return newManagerFromOptions(options)
+	return newManagerFromOptions(options, newTopologyRegistry())
 }
 
 // NewManagerFromEnv will instantiate a new MQTTManager from environment variables.
 func NewManagerFromEnv() (MQTTManager, error) {
 	options := NewManagerOptionsFromEnv()
 
-	return newManagerFromOptions(options)
+	return newManagerFromOptions(options, newTopologyRegistry())
 }
 
-func newManagerFromOptions(options *ManagerOptions) (MQTTManager, error) {
+// newManagerFromOptions instantiates a new MQTTManager recording the topology it declares into the given registry.
+func newManagerFromOptions(options *ManagerOptions, topology *topologyRegistry) (MQTTManager, error) {
 	manager := &mqttManager{
 		Host:     options.Host,
 		Port:     options.Port,
@@ -129,6 +164,7 @@ func newManagerFromOptions(options *ManagerOptions) (MQTTManager, error) {
 		Password: options.Password,
 		Vhost:    options.Vhost,
 		logger:   &noLogger{},
+		topology: topology,
 	}
 
 	// We check if the disabled flag is present, which will completely disable the MQTTManager.
@@ -152,17 +188,19 @@ func newManagerFromOptions(options *ManagerOptions) (MQTTManager, error) {
 		manager.logger = newStdLogger()
 	}
 
-	protocol := defaultProtocol
-
-	if options.UseTLS {
-		protocol = securedProtocol
-	}
-
-	dialURL := fmt.Sprintf("%s://%s:%s@%s:%d/%s", protocol, manager.Username, manager.Password, manager.Host, manager.Port, manager.Vhost)
+	dialURL := ConnectionSettings{
+		Host:          manager.Host,
+		Port:          manager.Port,
+		Username:      manager.Username,
+		Password:      manager.Password,
+		Vhost:         manager.Vhost,
+		UseTLS:        options.UseTLS,
+		URIParameters: options.URIParameters,
+	}.URI()
 
 	var err error
 
-	manager.connection, err = amqp.Dial(dialURL)
+	manager.connection, err = dial(dialURL)
 	if err != nil {
 		return manager, err
 	}
@@ -208,28 +246,38 @@ func (manager *mqttManager) CreateQueue(config QueueConfig) error {
 		return err
 	}
 
+	// We verify that the queue properties are compatible before declaring anything.
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	args, err := config.arguments()
+	if err != nil {
+		return err
+	}
+
 	// We declare the queue via the channel.
-	_, err := manager.channel.QueueDeclare(
+	_, err = manager.channel.QueueDeclare(
 		config.Name,      // name
 		config.Durable,   // durable
 		false,            // delete when unused
 		config.Exclusive, // exclusive
 		false,            // no-wait
-		config.Args,
+		args,             // arguments
 	)
 
 	if err != nil {
 		return err
 	}
 
+	manager.topology.addQueue(config, args)
+
 	// If bindings are also declared, we create the bindings too.
-	if config.Bindings != nil {
-		for _, binding := range config.Bindings {
-			err = manager.BindExchangeToQueueViaRoutingKey(binding.Exchange, config.Name, binding.RoutingKey)
+	for _, binding := range config.Bindings {
+		err = manager.bind(binding.Exchange, config.Name, bindingDestinationQueue, binding.RoutingKey, binding.Args)
 
-			if err != nil {
-				return err
-			}
+		if err != nil {
+			return err
 		}
 	}
 
@@ -247,16 +295,42 @@ func (manager *mqttManager) CreateExchange(config ExchangeConfig) error {
 		return err
 	}
 
+	// The alternate exchange, if declared, must exist before being used.
+	if err := manager.declareAlternateExchange(config); err != nil {
+		return err
+	}
+
+	args, err := config.arguments()
+	if err != nil {
+		return err
+	}
+
 	// We declare the exchange via the channel.
-	return manager.channel.ExchangeDeclare(
+	err = manager.channel.ExchangeDeclare(
 		config.Name,          // name
 		config.Type.String(), // type
 		config.Persisted,     // durable
 		!config.Persisted,    // auto-deleted
 		false,                // internal
 		false,                // no-wait
-		config.Args,          // arguments
+		args,                 // arguments
 	)
+	if err != nil {
+		return err
+	}
+
+	manager.topology.addExchange(config, args)
+
+	// If bindings are also declared, we bind the exchange to its sources too.
+	for _, binding := range config.Bindings {
+		err = manager.bind(binding.Exchange, config.Name, bindingDestinationExchange, binding.RoutingKey, binding.Args)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (manager *mqttManager) BindExchangeToQueueViaRoutingKey(exchange, queue, routingKey string) error {
@@ -270,14 +344,71 @@ func (manager *mqttManager) BindExchangeToQueueViaRoutingKey(exchange, queue, ro
 		return err
 	}
 
-	// We bind the queue to a given exchange and routing key via the channel.
-	return manager.channel.QueueBind(
-		queue,
-		routingKey,
-		exchange,
-		false,
-		nil,
-	)
+	return manager.bind(exchange, queue, bindingDestinationQueue, routingKey, nil)
+}
+
+func (manager *mqttManager) BindExchangeToExchange(source, destination, routingKey string, args map[string]interface{}) error {
+	// Manager is disabled, so we do nothing and return no error.
+	if manager.disabled {
+		return nil
+	}
+
+	// If the manager is not ready, we return its error.
+	if ready, err := manager.ready(); !ready {
+		return err
+	}
+
+	return manager.bind(source, destination, bindingDestinationExchange, routingKey, args)
+}
+
+// unbind removes the binding of a destination queue or exchange, depending on the destinationType, to a source exchange.
+func (manager *mqttManager) unbind(source, destination, destinationType, routingKey string, args map[string]interface{}) error {
+	table, err := tableArguments(args)
+	if err != nil {
+		return err
+	}
+
+	// We unbind the destination from the given exchange, routing key and arguments via the channel.
+	if destinationType == bindingDestinationExchange {
+		err = manager.channel.ExchangeUnbind(destination, routingKey, source, false, table)
+	} else {
+		err = manager.channel.QueueUnbind(destination, routingKey, source, table)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	manager.topology.removeBinding(source, destination, destinationType, routingKey)
+
+	return nil
+}
+
+// bind binds a destination queue or exchange, depending on the destinationType, to a source exchange.
+func (manager *mqttManager) bind(source, destination, destinationType, routingKey string, args map[string]interface{}) error {
+	if err := validateBindingArguments(source, args); err != nil {
+		return err
+	}
+
+	table, err := tableArguments(args)
+	if err != nil {
+		return err
+	}
+
+	// We bind the destination to the given exchange, routing key and arguments via the channel.
+	if destinationType == bindingDestinationExchange {
+		err = manager.channel.ExchangeBind(destination, routingKey, source, false, table)
+	} else {
+		err = manager.channel.QueueBind(destination, routingKey, source, false, table)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	manager.topology.addBinding(source, destination, destinationType, routingKey, table)
+
+	return nil
 }
 
 func (manager *mqttManager) GetNumberOfMessages(queue string) (int, error) {
@@ -406,6 +537,8 @@ func (manager *mqttManager) DeleteQueue(queue string) error {
 		return err
 	}
 
+	manager.topology.removeQueue(queue)
+
 	return nil
 }
 
@@ -421,7 +554,13 @@ func (manager *mqttManager) DeleteExchange(exchange string) error {
 	}
 
 	// We delete the exchange via the channel.
-	return manager.channel.ExchangeDelete(exchange, false, false)
+	if err := manager.channel.ExchangeDelete(exchange, false, false); err != nil {
+		return err
+	}
+
+	manager.topology.removeExchange(exchange)
+
+	return nil
 }
 
 func (manager *mqttManager) SetupFromDefinitions(path string) error {
@@ -455,6 +594,7 @@ func (manager *mqttManager) SetupFromDefinitions(path string) error {
 			Name:      queue.Name,
 			Durable:   queue.Durable,
 			Exclusive: false,
+			Args:      queue.Arguments,
 		})
 
 		if err != nil {
@@ -468,6 +608,7 @@ func (manager *mqttManager) SetupFromDefinitions(path string) error {
 			Name:      exchange.Name,
 			Type:      ExchangeType(exchange.Type),
 			Persisted: exchange.Durable,
+			Args:      exchange.Arguments,
 		})
 
 		if err != nil {
@@ -476,8 +617,14 @@ func (manager *mqttManager) SetupFromDefinitions(path string) error {
 	}
 
 	for _, binding := range def.Bindings {
-		// We bind the given exchange to the given queue via the given routing key.
-		err = manager.BindExchangeToQueueViaRoutingKey(binding.Source, binding.Destination, binding.RoutingKey)
+		destinationType := bindingDestinationQueue
+
+		if binding.DestinationType == bindingDestinationExchange {
+			destinationType = bindingDestinationExchange
+		}
+
+		// We bind the given exchange to the given queue or exchange via the given routing key and arguments.
+		err = manager.bind(binding.Source, binding.Destination, destinationType, binding.RoutingKey, binding.Arguments)
 
 		if err != nil {
 			return err
@@ -487,6 +634,103 @@ func (manager *mqttManager) SetupFromDefinitions(path string) error {
 	return nil
 }
 
+func (manager *mqttManager) SetupTopology(ctx context.Context, exchanges []ExchangeConfig, queues []QueueConfig) (Teardown, error) {
+	// Manager is disabled, so we do nothing and return no error.
+	if manager.disabled {
+		return noTeardown, nil
+	}
+
+	// We verify the whole topology before declaring anything.
+	for _, exchange := range exchanges {
+		if exchange.Name == "" {
+			return noTeardown, errEmptyExchangeName
+		}
+
+		if exchange.AlternateExchange != nil && exchange.AlternateExchange.Name == "" {
+			return noTeardown, errEmptyAlternateExchangeName
+		}
+	}
+
+	for _, queue := range queues {
+		if queue.Name == "" {
+			return noTeardown, errEmptyQueueName
+		}
+
+		if err := queue.Validate(); err != nil {
+			return noTeardown, err
+		}
+	}
+
+	// If the manager is not ready, we return its error.
+	if ready, err := manager.ready(); !ready {
+		return noTeardown, err
+	}
+
+	// The created exchanges and queues are recorded, so that they can be torn down even if the setup fails.
+	created := &topologyTeardown{manager: manager}
+
+	// The exchanges are declared first, so that the exchanges and queues can be bound to them.
+	for _, exchange := range exchanges {
+		if err := ctx.Err(); err != nil {
+			return created.teardown, err
+		}
+
+		unbound := exchange
+		unbound.Bindings = nil
+
+		if err := created.createExchange(unbound); err != nil {
+			return created.teardown, fmt.Errorf("could not declare exchange '%s': %w", exchange.Name, err)
+		}
+	}
+
+	for _, exchange := range exchanges {
+		for _, binding := range exchange.Bindings {
+			if err := ctx.Err(); err != nil {
+				return created.teardown, err
+			}
+
+			if err := manager.bind(binding.Exchange, exchange.Name, bindingDestinationExchange, binding.RoutingKey, binding.Args); err != nil {
+				return created.teardown, fmt.Errorf("could not bind exchange '%s' to '%s': %w", exchange.Name, binding.Exchange, err)
+			}
+		}
+	}
+
+	for _, queue := range queues {
+		if err := ctx.Err(); err != nil {
+			return created.teardown, err
+		}
+
+		if err := created.createQueue(queue); err != nil {
+			return created.teardown, fmt.Errorf("could not declare queue '%s': %w", queue.Name, err)
+		}
+	}
+
+	return created.teardown, nil
+}
+
+func (manager *mqttManager) ExportSchema() ([]byte, error) {
+	return manager.topology.export(manager.Vhost)
+}
+
+func (manager *mqttManager) VerifyTopology(ctx context.Context, exchanges []ExchangeConfig, queues []QueueConfig) (TopologyReport, error) {
+	// Manager is disabled, so we do nothing and return a valid report.
+	if manager.disabled {
+		return TopologyReport{}, nil
+	}
+
+	checks := make([]topologyCheck, 0, len(exchanges)+len(queues))
+
+	for _, exchange := range exchanges {
+		checks = append(checks, exchangeCheck(exchange))
+	}
+
+	for i := range queues {
+		checks = append(checks, queueCheck(queues[i].Name, &queues[i]))
+	}
+
+	return verifyTopology(ctx, manager.connection, checks)
+}
+
 func (manager *mqttManager) checkChannel() error {
 	var err error
 
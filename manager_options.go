@@ -1,7 +1,5 @@
 package gorabbit
 
-import "github.com/Netflix/go-env"
-
 // ManagerOptions holds all necessary properties to launch a successful connection with an MQTTManager.
 type ManagerOptions struct {
 	// Host is the RabbitMQ server host name.
@@ -22,6 +20,9 @@ type ManagerOptions struct {
 	// UseTLS defines whether we use amqp or amqps protocol.
 	UseTLS bool
 
+	// URIParameters are the query parameters of the connection URI, such as the heartbeat or the CA certificate.
+	URIParameters
+
 	// Mode will specify whether logs are enabled or not.
 	Mode string
 }
@@ -52,8 +53,7 @@ func NewManagerOptionsFromEnv() *ManagerOptions {
 
 	fromEnv := new(RabbitMQEnvs)
 
-	_, err := env.UnmarshalFromEnviron(fromEnv)
-	if err != nil {
+	if err := loadEnv(fromEnv); err != nil {
 		return defaultOpts
 	}
 
@@ -0,0 +1,125 @@
+package gorabbit
+
+import (
+	"encoding/json"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ContentTypeJSON is the content type of the JSON payloads.
+const ContentTypeJSON = "application/json"
+
+// MessageMetadata describes the message whose payload is marshalled or unmarshalled.
+type MessageMetadata struct {
+	// Exchange is the exchange the message is published to.
+	Exchange string
+
+	// RoutingKey is the routing key of the message.
+	RoutingKey string
+
+	// Queue is the queue a delivery was consumed from, empty when publishing.
+	Queue string
+
+	// Type is the type of the message, which is the routing key it was first published with.
+	Type string
+
+	// ContentType is the content type of the payload. A Marshaller sets it when marshalling.
+	ContentType string
+
+	// Headers are the headers of the message. A Marshaller can add headers when marshalling.
+	Headers map[string]interface{}
+}
+
+// Marshaller encodes the payloads of the publishings and decodes the payloads of the deliveries.
+type Marshaller interface {
+	// Marshal encodes the payload of a message, setting the content type of the metadata.
+	Marshal(metadata *MessageMetadata, payload interface{}) ([]byte, error)
+
+	// Unmarshal decodes the payload of a message into v.
+	Unmarshal(metadata *MessageMetadata, data []byte, v interface{}) error
+}
+
+// PayloadValidator is implemented by the Marshaller that can validate a payload without decoding it. Set on a
+// MessageConsumer, it validates the deliveries before they are passed to the handlers.
+type PayloadValidator interface {
+	// Validate returns an error if the payload of a message is invalid.
+	Validate(metadata *MessageMetadata, data []byte) error
+}
+
+// PayloadDecoder is implemented by the Marshaller whose encoding of the payloads must be reversed before they are passed
+// to the handlers, such as an encryption. Set on a MessageConsumer, it decodes the deliveries before the handlers.
+type PayloadDecoder interface {
+	// Decode returns the decoded payload of a message.
+	Decode(metadata *MessageMetadata, data []byte) ([]byte, error)
+}
+
+// JSONMarshaller encodes the payloads as JSON, this is the default Marshaller.
+type JSONMarshaller struct{}
+
+// Marshal encodes the payload as JSON.
+func (JSONMarshaller) Marshal(metadata *MessageMetadata, payload interface{}) ([]byte, error) {
+	metadata.ContentType = ContentTypeJSON
+
+	return json.Marshal(payload)
+}
+
+// Unmarshal decodes a JSON payload into v.
+func (JSONMarshaller) Unmarshal(_ *MessageMetadata, data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// deliveryMetadata returns the metadata of a delivery received by the channel with the given routing key.
+func (c *amqpChannel) deliveryMetadata(delivery *amqp.Delivery, routingKey string) *MessageMetadata {
+	return &MessageMetadata{
+		Exchange:    delivery.Exchange,
+		RoutingKey:  routingKey,
+		Queue:       c.queueName(),
+		Type:        delivery.Type,
+		ContentType: delivery.ContentType,
+		Headers:     delivery.Headers,
+	}
+}
+
+// validatePayload validates the payload of a delivery with the Validator of the consumer, if any.
+func (c *amqpChannel) validatePayload(delivery *amqp.Delivery, routingKey string, payload []byte) error {
+	if c.consumer.Validator == nil {
+		return nil
+	}
+
+	return c.consumer.Validator.Validate(c.deliveryMetadata(delivery, routingKey), payload)
+}
+
+// decodePayload decodes the payload of a delivery with the Decoder of the consumer, if any.
+func (c *amqpChannel) decodePayload(delivery *amqp.Delivery, routingKey string, payload []byte) ([]byte, error) {
+	if c.consumer.Decoder == nil {
+		return payload, nil
+	}
+
+	return c.consumer.Decoder.Decode(c.deliveryMetadata(delivery, routingKey), payload)
+}
+
+// marshallerHeaders are the headers set by the marshallers, which are kept when a delivery is republished.
+var marshallerHeaders = []string{
+	envelopeTypeHeader,
+	envelopeVersionHeader,
+	envelopeProducerHeader,
+	envelopeEmittedAtHeader,
+	encryptionKeyIDHeader,
+}
+
+// copyMarshallerHeaders copies the headers set by the marshallers of a delivery, including the CloudEvents attributes,
+// into the headers of its republishing.
+func copyMarshallerHeaders(from, to map[string]interface{}) {
+	for _, header := range marshallerHeaders {
+		if value, ok := from[header]; ok {
+			to[header] = value
+		}
+	}
+
+	for header, value := range from {
+		if strings.HasPrefix(header, cloudEventsHeaderPrefix) {
+			to[header] = value
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package gorabbit
+
+// marshallerRoute is a Marshaller registered for the messages of an exchange and a routing key pattern.
+type marshallerRoute struct {
+	exchange   string
+	routingKey *routingTrie[struct{}]
+	marshaller Marshaller
+}
+
+// matches returns true if the route matches the metadata of a message.
+func (r marshallerRoute) matches(metadata *MessageMetadata) bool {
+	if r.exchange != "" && r.exchange != metadata.Exchange {
+		return false
+	}
+
+	if r.routingKey == nil {
+		return true
+	}
+
+	_, _, found := r.routingKey.match(metadata.RoutingKey)
+
+	return found
+}
+
+// MarshallerRegistry is a Marshaller picking the Marshaller of each message by exchange and routing key, and by queue
+// for the deliveries. It is also a PayloadValidator and a PayloadDecoder, delegating to the picked Marshaller if it
+// implements them.
+//
+// The marshallers must be registered before the MarshallerRegistry is used.
+type MarshallerRegistry struct {
+	fallback Marshaller
+	routes   []marshallerRoute
+	queues   map[string]Marshaller
+}
+
+// NewMarshallerRegistry returns a MarshallerRegistry falling back to the given marshaller, the JSONMarshaller if nil,
+// for the messages matching no registered route.
+func NewMarshallerRegistry(fallback Marshaller) *MarshallerRegistry {
+	if fallback == nil {
+		fallback = JSONMarshaller{}
+	}
+
+	return &MarshallerRegistry{
+		fallback: fallback,
+		queues:   make(map[string]Marshaller),
+	}
+}
+
+// Register will assign the Marshaller of the messages of the given exchange whose routing key matches the pattern,
+// which can contain the '*' and '#' wildcards. An empty exchange or pattern matches any. The routes are matched in the
+// order they were registered.
+func (r *MarshallerRegistry) Register(exchange, routingKey string, marshaller Marshaller) *MarshallerRegistry {
+	route := marshallerRoute{
+		exchange:   exchange,
+		marshaller: marshaller,
+	}
+
+	if routingKey != "" {
+		route.routingKey = newRoutingTrie(map[string]struct{}{routingKey: {}})
+	}
+
+	r.routes = append(r.routes, route)
+
+	return r
+}
+
+// RegisterQueue will assign the Marshaller of the deliveries consumed from the given queue, which takes precedence over
+// the routes.
+func (r *MarshallerRegistry) RegisterQueue(queue string, marshaller Marshaller) *MarshallerRegistry {
+	r.queues[queue] = marshaller
+
+	return r
+}
+
+// Lookup returns the Marshaller of a message: the one of its queue, else the one of the first matching route, else the
+// fallback.
+func (r *MarshallerRegistry) Lookup(metadata *MessageMetadata) Marshaller {
+	if metadata.Queue != "" {
+		if marshaller, found := r.queues[metadata.Queue]; found {
+			return marshaller
+		}
+	}
+
+	for _, route := range r.routes {
+		if route.matches(metadata) {
+			return route.marshaller
+		}
+	}
+
+	return r.fallback
+}
+
+// Marshal encodes the payload with the Marshaller of the message.
+func (r *MarshallerRegistry) Marshal(metadata *MessageMetadata, payload interface{}) ([]byte, error) {
+	return r.Lookup(metadata).Marshal(metadata, payload)
+}
+
+// Unmarshal decodes the payload with the Marshaller of the message.
+func (r *MarshallerRegistry) Unmarshal(metadata *MessageMetadata, data []byte, v interface{}) error {
+	return r.Lookup(metadata).Unmarshal(metadata, data, v)
+}
+
+// Validate validates the payload with the Marshaller of the message, if it is a PayloadValidator.
+func (r *MarshallerRegistry) Validate(metadata *MessageMetadata, data []byte) error {
+	if validator, ok := r.Lookup(metadata).(PayloadValidator); ok {
+		return validator.Validate(metadata, data)
+	}
+
+	return nil
+}
+
+// Decode decodes the payload with the Marshaller of the message, if it is a PayloadDecoder.
+func (r *MarshallerRegistry) Decode(metadata *MessageMetadata, data []byte) ([]byte, error) {
+	if decoder, ok := r.Lookup(metadata).(PayloadDecoder); ok {
+		return decoder.Decode(metadata, data)
+	}
+
+	return data, nil
+}
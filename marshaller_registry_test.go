@@ -0,0 +1,40 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMarshallerRegistry(t *testing.T) {
+	registry := gorabbit.NewMarshallerRegistry(nil).
+		Register("partner", "orders.#", rawMarshaller{}).
+		RegisterQueue("partner_queue", rawMarshaller{})
+
+	metadata := &gorabbit.MessageMetadata{Exchange: "partner", RoutingKey: "orders.created"}
+
+	data, err := registry.Marshal(metadata, "raw")
+	require.NoError(t, err)
+
+	assert.Equal(t, "raw", string(data))
+	assert.Equal(t, "text/plain", metadata.ContentType)
+
+	metadata = &gorabbit.MessageMetadata{Exchange: "internal", RoutingKey: "orders.created"}
+
+	data, err = registry.Marshal(metadata, "json")
+	require.NoError(t, err)
+
+	assert.Equal(t, `"json"`, string(data))
+	assert.Equal(t, gorabbit.ContentTypeJSON, metadata.ContentType)
+
+	var decoded string
+
+	require.NoError(t, registry.Unmarshal(&gorabbit.MessageMetadata{Queue: "partner_queue"}, []byte("raw"), &decoded))
+	assert.Equal(t, "raw", decoded)
+
+	require.NoError(t, registry.Unmarshal(&gorabbit.MessageMetadata{Queue: "internal_queue"}, []byte(`"json"`), &decoded))
+	assert.Equal(t, "json", decoded)
+}
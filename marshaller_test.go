@@ -0,0 +1,34 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestJSONMarshaller(t *testing.T) {
+	marshaller := gorabbit.JSONMarshaller{}
+
+	metadata := &gorabbit.MessageMetadata{RoutingKey: "event.created"}
+
+	data, err := marshaller.Marshal(metadata, map[string]string{"name": "foo"})
+	require.NoError(t, err)
+
+	assert.Equal(t, gorabbit.ContentTypeJSON, metadata.ContentType)
+	assert.JSONEq(t, `{"name":"foo"}`, string(data))
+
+	var decoded map[string]string
+
+	require.NoError(t, marshaller.Unmarshal(metadata, data, &decoded))
+	assert.Equal(t, "foo", decoded["name"])
+}
+
+func TestMatchRoutingKey(t *testing.T) {
+	assert.True(t, gorabbit.MatchRoutingKey("event.created", "event.created"))
+	assert.True(t, gorabbit.MatchRoutingKey("event.*", "event.created"))
+	assert.True(t, gorabbit.MatchRoutingKey("event.#", "event.foo.created"))
+	assert.False(t, gorabbit.MatchRoutingKey("event.*", "order.created"))
+}
@@ -0,0 +1,280 @@
+package gorabbit
+
+import (
+	"time"
+)
+
+// DeliveryOutcome is the outcome of the processing of a delivery.
+type DeliveryOutcome string
+
+const (
+	// DeliveryOutcomeSuccess is the outcome of a successfully processed delivery.
+	DeliveryOutcomeSuccess DeliveryOutcome = "success"
+
+	// DeliveryOutcomeRetry is the outcome of a failed delivery that is retried.
+	DeliveryOutcomeRetry DeliveryOutcome = "retry"
+
+	// DeliveryOutcomeRequeued is the outcome of a failed delivery that is requeued.
+	DeliveryOutcomeRequeued DeliveryOutcome = "requeued"
+
+	// DeliveryOutcomeDiscarded is the outcome of a delivery that is discarded.
+	DeliveryOutcomeDiscarded DeliveryOutcome = "discarded"
+
+	// DeliveryOutcomeDeadLettered is the outcome of a delivery that is dead-lettered.
+	DeliveryOutcomeDeadLettered DeliveryOutcome = "dead_lettered"
+
+	// DeliveryOutcomeFailure is the outcome of a failed delivery that the handler acknowledges itself, with
+	// AckModeManual.
+	DeliveryOutcomeFailure DeliveryOutcome = "failure"
+
+	// DeliveryOutcomePanic is the outcome of a delivery whose handler panicked.
+	DeliveryOutcomePanic DeliveryOutcome = "panic"
+)
+
+// String returns the string representation of the DeliveryOutcome.
+func (o DeliveryOutcome) String() string {
+	return string(o)
+}
+
+// deliveryOutcome returns the DeliveryOutcome matching an AckDecision.
+func deliveryOutcome(decision AckDecision) DeliveryOutcome {
+	switch decision {
+	case AckDecisionAck:
+		return DeliveryOutcomeSuccess
+	case AckDecisionRequeue:
+		return DeliveryOutcomeRequeued
+	case AckDecisionDiscard:
+		return DeliveryOutcomeDiscarded
+	case AckDecisionDeadLetter:
+		return DeliveryOutcomeDeadLettered
+	default:
+		return DeliveryOutcomeRetry
+	}
+}
+
+// DeliveryMetrics holds the metrics of a processed delivery.
+type DeliveryMetrics struct {
+	// Consumer is the name of the consumer.
+	Consumer string
+
+	// Queue is the queue the delivery was consumed from.
+	Queue string
+
+	// RoutingKey is the routing key of the delivery.
+	RoutingKey string
+
+	// Handler is the key under which the handler is registered.
+	Handler string
+
+	// Outcome is the outcome of the processing.
+	Outcome DeliveryOutcome
+
+	// Duration is the processing time of the handler.
+	Duration time.Duration
+
+	// PayloadSize is the size of the payload passed to the handler, in bytes.
+	PayloadSize int
+}
+
+// MetricsCollector receives the metrics of a client, to expose them through any metrics system.
+// Implementations must be safe for concurrent use and should not block. They can also implement
+// ConnectionMetricsCollector, ConsumerMetricsCollector and PublishMetricsCollector to receive the other metrics.
+type MetricsCollector interface {
+	// ObserveDelivery is called once a delivery was processed by its handler.
+	ObserveDelivery(metrics DeliveryMetrics)
+}
+
+// ConnectionEvent is an event in the lifecycle of a connection or channel.
+type ConnectionEvent string
+
+const (
+	// ConnectionEventOpened is the first opening of a connection or channel.
+	ConnectionEventOpened ConnectionEvent = "opened"
+
+	// ConnectionEventReopened is the opening of a connection or channel after it was lost.
+	ConnectionEventReopened ConnectionEvent = "reopened"
+
+	// ConnectionEventLost is the unexpected closing of a connection or channel.
+	ConnectionEventLost ConnectionEvent = "lost"
+
+	// ConnectionEventClosed is the explicit closing of a connection or channel.
+	ConnectionEventClosed ConnectionEvent = "closed"
+)
+
+// String returns the string representation of the ConnectionEvent.
+func (e ConnectionEvent) String() string {
+	return string(e)
+}
+
+// ConnectionMetrics holds an event of a connection.
+type ConnectionMetrics struct {
+	// Type is either "consumer" or "publisher".
+	Type string
+
+	// Event is the lifecycle event.
+	Event ConnectionEvent
+}
+
+// ChannelMetrics holds an event of a channel.
+type ChannelMetrics struct {
+	// Type is either "consumer" or "publisher".
+	Type string
+
+	// Consumer is the name of the consumer of a consumer channel.
+	Consumer string
+
+	// Event is the lifecycle event.
+	Event ConnectionEvent
+}
+
+// ConnectionMetricsCollector is implemented by the MetricsCollector also receiving the lifecycle events of the
+// connections and channels.
+type ConnectionMetricsCollector interface {
+	// ObserveConnection is called when a connection is opened, lost or closed.
+	ObserveConnection(metrics ConnectionMetrics)
+
+	// ObserveChannel is called when a channel is opened, lost or closed.
+	ObserveChannel(metrics ChannelMetrics)
+}
+
+// ConsumerEvent is an event in the lifecycle of a consumer's subscription.
+type ConsumerEvent string
+
+const (
+	// ConsumerEventSubscribed is the subscription of a consumer to its queue, with every new channel.
+	ConsumerEventSubscribed ConsumerEvent = "subscribed"
+
+	// ConsumerEventCanceled is the end of a subscription, because the consumer was drained, its channel was lost or its
+	// queue was deleted.
+	ConsumerEventCanceled ConsumerEvent = "canceled"
+)
+
+// String returns the string representation of the ConsumerEvent.
+func (e ConsumerEvent) String() string {
+	return string(e)
+}
+
+// ConsumerMetrics holds an event of a consumer.
+type ConsumerMetrics struct {
+	// Consumer is the name of the consumer.
+	Consumer string
+
+	// Queue is the consumed queue.
+	Queue string
+
+	// Event is the lifecycle event.
+	Event ConsumerEvent
+}
+
+// ConsumerMetricsCollector is implemented by the MetricsCollector also receiving the lifecycle events of the consumers.
+type ConsumerMetricsCollector interface {
+	// ObserveConsumer is called when a consumer subscribes to its queue, or its subscription ends.
+	ObserveConsumer(metrics ConsumerMetrics)
+}
+
+// PublishOutcome is the outcome of a publishing.
+type PublishOutcome string
+
+const (
+	// PublishOutcomeSuccess is the outcome of a message sent to the server.
+	PublishOutcomeSuccess PublishOutcome = "success"
+
+	// PublishOutcomeFailure is the outcome of a message that could not be sent.
+	PublishOutcomeFailure PublishOutcome = "failure"
+
+	// PublishOutcomeCached is the outcome of a message cached while the channel is down, to be republished.
+	PublishOutcomeCached PublishOutcome = "cached"
+
+	// PublishOutcomeRepublished is the outcome of a cached message sent once the channel is back up.
+	PublishOutcomeRepublished PublishOutcome = "republished"
+
+	// PublishOutcomeEvicted is the outcome of a cached message dropped to make room for a newer one, the cache being full.
+	PublishOutcomeEvicted PublishOutcome = "evicted"
+
+	// PublishOutcomeExpired is the outcome of a cached message dropped once its time to live elapsed.
+	PublishOutcomeExpired PublishOutcome = "expired"
+
+	// PublishOutcomeReturned is the outcome of a reliable publishing returned by the broker, because it could not be
+	// routed to any queue.
+	PublishOutcomeReturned PublishOutcome = "returned"
+)
+
+// String returns the string representation of the PublishOutcome.
+func (o PublishOutcome) String() string {
+	return string(o)
+}
+
+// PublishMetrics holds the metrics of a publishing.
+type PublishMetrics struct {
+	// Exchange is the exchange the message is published to.
+	Exchange string
+
+	// RoutingKey is the routing key of the message.
+	RoutingKey string
+
+	// Outcome is the outcome of the publishing.
+	Outcome PublishOutcome
+
+	// PayloadSize is the size of the payload, in bytes.
+	PayloadSize int
+}
+
+// PublishMetricsCollector is implemented by the MetricsCollector also receiving the metrics of the publishings.
+type PublishMetricsCollector interface {
+	// ObservePublish is called once a message is published, cached or could not be published.
+	ObservePublish(metrics PublishMetrics)
+}
+
+// observeConnection reports a connection event to the MetricsCollector, if it collects them.
+func (a *amqpConnection) observeConnection(event ConnectionEvent) {
+	if collector, ok := a.metrics.(ConnectionMetricsCollector); ok {
+		collector.ObserveConnection(ConnectionMetrics{Type: string(a.connectionType), Event: event})
+	}
+}
+
+// observeChannel reports a channel event to the MetricsCollector, if it collects them.
+func (c *amqpChannel) observeChannel(event ConnectionEvent) {
+	collector, ok := c.metrics.(ConnectionMetricsCollector)
+	if !ok {
+		return
+	}
+
+	metrics := ChannelMetrics{Type: string(c.connectionType), Event: event}
+
+	if c.consumer != nil {
+		metrics.Consumer = c.consumer.Name
+	}
+
+	collector.ObserveChannel(metrics)
+}
+
+// observeConsumer reports a consumer event to the MetricsCollector, if it collects them.
+func (c *amqpChannel) observeConsumer(event ConsumerEvent) {
+	if collector, ok := c.metrics.(ConsumerMetricsCollector); ok {
+		collector.ObserveConsumer(ConsumerMetrics{Consumer: c.consumer.Name, Queue: c.queueName(), Event: event})
+	}
+}
+
+// observePublish reports the metrics of a publishing to the MetricsCollector, if it collects them.
+func (c *amqpChannel) observePublish(publishing mqttPublishing, outcome PublishOutcome) {
+	if collector, ok := c.metrics.(PublishMetricsCollector); ok {
+		collector.ObservePublish(PublishMetrics{
+			Exchange:    publishing.Exchange,
+			RoutingKey:  publishing.RoutingKey,
+			Outcome:     outcome,
+			PayloadSize: len(publishing.Msg.Body),
+		})
+	}
+}
+
+// observeDelivery reports the metrics of a processed delivery to the MetricsCollector, if any.
+func (c *amqpChannel) observeDelivery(metrics DeliveryMetrics) {
+	if c.metrics == nil {
+		return
+	}
+
+	metrics.Consumer = c.consumer.Name
+	metrics.Queue = c.consumer.Queue
+
+	c.metrics.ObserveDelivery(metrics)
+}
@@ -0,0 +1,93 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type deliveryRecorder struct {
+	metrics []gorabbit.DeliveryMetrics
+	mutex   sync.Mutex
+}
+
+func (r *deliveryRecorder) ObserveDelivery(metrics gorabbit.DeliveryMetrics) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.metrics = append(r.metrics, metrics)
+}
+
+func (r *deliveryRecorder) ObservePublish(gorabbit.PublishMetrics) {}
+
+func (r *deliveryRecorder) observed() map[string]gorabbit.DeliveryMetrics {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	observed := make(map[string]gorabbit.DeliveryMetrics, len(r.metrics))
+
+	for _, metrics := range r.metrics {
+		observed[metrics.RoutingKey] = metrics
+	}
+
+	return observed
+}
+
+func TestClient_Metrics_Deliveries(t *testing.T) {
+	server := newFakeServer(t)
+	recorder := &deliveryRecorder{}
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetMetrics(recorder))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue: "events",
+		Name:  "events-consumer",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func([]byte) error {
+				time.Sleep(10 * time.Millisecond)
+
+				return nil
+			},
+			"event.rejected": func([]byte) error { return gorabbit.DeadLetter(errors.New("invalid event")) },
+			"event.dropped":  func([]byte) error { return gorabbit.Discard(errors.New("obsolete event")) },
+			"order.*":        func([]byte) error { panic("nil order") },
+		},
+	}))
+	require.Eventually(t, func() bool { return server.consumed("events") }, time.Second, 10*time.Millisecond)
+
+	server.deliver("events", "event.created", "created")
+	server.deliver("events", "event.rejected", "rejected")
+	server.deliver("events", "event.dropped", "dropped")
+	server.deliver("events", "order.created", "order")
+
+	require.Eventually(t, func() bool { return len(recorder.observed()) == 4 }, time.Second, 10*time.Millisecond)
+
+	observed := recorder.observed()
+
+	created := observed["event.created"]
+
+	assert.Equal(t, "events-consumer", created.Consumer)
+	assert.Equal(t, "events", created.Queue)
+	assert.Equal(t, "event.created", created.Handler)
+	assert.Equal(t, gorabbit.DeliveryOutcomeSuccess, created.Outcome)
+	assert.GreaterOrEqual(t, created.Duration, 10*time.Millisecond)
+	assert.Equal(t, len("created"), created.PayloadSize)
+
+	assert.Equal(t, gorabbit.DeliveryOutcomeDeadLettered, observed["event.rejected"].Outcome)
+	assert.Equal(t, gorabbit.DeliveryOutcomeDiscarded, observed["event.dropped"].Outcome)
+
+	// The panic of the handler is recovered, and reported along with the key of the handler.
+	assert.Equal(t, gorabbit.DeliveryOutcomePanic, observed["order.created"].Outcome)
+	assert.Equal(t, "order.*", observed["order.created"].Handler)
+}
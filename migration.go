@@ -0,0 +1,317 @@
+package gorabbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Queues used by the migrations.
+const (
+	// migrationsQueue holds the record of the last applied migration.
+	migrationsQueue = "gorabbit.migrations"
+
+	// migrationsLockQueue is declared exclusively while migrating, so that a single manager migrates at a time.
+	migrationsLockQueue = "gorabbit.migrations.lock"
+)
+
+// MigrationStep is a step of a Migration. Steps are built with the *Step functions, or with MigrationFunc for custom
+// operations such as policies.
+type MigrationStep interface {
+	apply(ctx context.Context, manager *mqttManager) error
+}
+
+// Migration is a versioned set of topology changes, applied once and in order by the manager's Migrate.
+// Steps should tolerate being applied again, in case the migration was interrupted before being recorded.
+type Migration struct {
+	// Version is the version of the migration, strictly greater than the version of the previous migration.
+	Version uint
+
+	// Description describes the changes.
+	Description string
+
+	// Steps holds the changes, applied in order.
+	Steps []MigrationStep
+}
+
+// migrationRecord is the record of the last applied migration.
+type migrationRecord struct {
+	Version     uint      `json:"version"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied_at"`
+}
+
+// migrationStepFunc is a MigrationStep calling a function.
+type migrationStepFunc func(ctx context.Context, manager *mqttManager) error
+
+func (f migrationStepFunc) apply(ctx context.Context, manager *mqttManager) error {
+	return f(ctx, manager)
+}
+
+// MigrationFunc returns a MigrationStep calling the given function, for custom operations such as policies.
+func MigrationFunc(f func(ctx context.Context, manager MQTTManager) error) MigrationStep {
+	return migrationStepFunc(func(ctx context.Context, manager *mqttManager) error {
+		return f(ctx, manager)
+	})
+}
+
+// DeclareExchangeStep returns a MigrationStep declaring an exchange.
+func DeclareExchangeStep(config ExchangeConfig) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.CreateExchange(config)
+	})
+}
+
+// DeclareQueueStep returns a MigrationStep declaring a queue along with its bindings.
+func DeclareQueueStep(config QueueConfig) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.CreateQueue(config)
+	})
+}
+
+// BindQueueStep returns a MigrationStep binding a queue to an exchange.
+func BindQueueStep(exchange, queue, routingKey string, args map[string]interface{}) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.bind(exchange, queue, bindingDestinationQueue, routingKey, args)
+	})
+}
+
+// BindExchangeStep returns a MigrationStep binding a destination exchange to a source exchange.
+func BindExchangeStep(source, destination, routingKey string, args map[string]interface{}) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.bind(source, destination, bindingDestinationExchange, routingKey, args)
+	})
+}
+
+// UnbindQueueStep returns a MigrationStep removing the binding of a queue to an exchange.
+func UnbindQueueStep(exchange, queue, routingKey string, args map[string]interface{}) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.unbind(exchange, queue, bindingDestinationQueue, routingKey, args)
+	})
+}
+
+// UnbindExchangeStep returns a MigrationStep removing the binding of a destination exchange to a source exchange.
+func UnbindExchangeStep(source, destination, routingKey string, args map[string]interface{}) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.unbind(source, destination, bindingDestinationExchange, routingKey, args)
+	})
+}
+
+// DeleteQueueStep returns a MigrationStep deleting a queue.
+func DeleteQueueStep(queue string) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.DeleteQueue(queue)
+	})
+}
+
+// DeleteExchangeStep returns a MigrationStep deleting an exchange.
+func DeleteExchangeStep(exchange string) MigrationStep {
+	return migrationStepFunc(func(_ context.Context, manager *mqttManager) error {
+		return manager.DeleteExchange(exchange)
+	})
+}
+
+// validateMigrations verifies that the migrations have strictly increasing positive versions.
+func validateMigrations(migrations []Migration) error {
+	var previous uint
+
+	for _, migration := range migrations {
+		if migration.Version == 0 {
+			return fmt.Errorf("the migration '%s' must have a positive version", migration.Description)
+		}
+
+		if migration.Version <= previous {
+			return fmt.Errorf("the migration %d must come after the migration %d", migration.Version, previous)
+		}
+
+		previous = migration.Version
+	}
+
+	return nil
+}
+
+func (manager *mqttManager) Migrate(ctx context.Context, migrations []Migration) (uint, error) {
+	// Manager is disabled, so we do nothing and return no error.
+	if manager.disabled {
+		return 0, nil
+	}
+
+	// If the manager is not ready, we return its error.
+	if ready, err := manager.ready(); !ready {
+		return 0, err
+	}
+
+	if err := validateMigrations(migrations); err != nil {
+		return 0, err
+	}
+
+	// The exclusive lock queue is granted again to the connection holding it, so the manager's own migrations are
+	// serialized first.
+	manager.migrationMutex.Lock()
+	defer manager.migrationMutex.Unlock()
+
+	unlock, err := manager.lockMigrations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	defer unlock()
+
+	// The records are read on their own channel in confirm mode, and held unacknowledged until they are replaced.
+	channel, err := manager.connection.Channel()
+	if err != nil {
+		return 0, err
+	}
+
+	defer channel.Close()
+
+	if err = channel.Confirm(false); err != nil {
+		return 0, err
+	}
+
+	if _, err = channel.QueueDeclare(migrationsQueue, true, false, false, false, nil); err != nil {
+		return 0, err
+	}
+
+	current, held, err := readMigrationRecords(channel)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current.Version {
+			continue
+		}
+
+		manager.logger.Info("Applying migration",
+			LogField{Key: "version", Value: migration.Version},
+			LogField{Key: "description", Value: migration.Description},
+		)
+
+		for i, step := range migration.Steps {
+			if err = ctx.Err(); err != nil {
+				return current.Version, err
+			}
+
+			if err = step.apply(ctx, manager); err != nil {
+				return current.Version, fmt.Errorf("migration %d failed at step %d: %w", migration.Version, i+1, err)
+			}
+		}
+
+		record := migrationRecord{Version: migration.Version, Description: migration.Description, AppliedAt: time.Now()}
+
+		if err = writeMigrationRecord(ctx, channel, record, held); err != nil {
+			return current.Version, fmt.Errorf("migration %d was applied but could not be recorded: %w", migration.Version, err)
+		}
+
+		// The new record is held in turn, until it is replaced or the channel is closed.
+		if current, held, err = readMigrationRecords(channel); err != nil {
+			return record.Version, err
+		}
+	}
+
+	return current.Version, nil
+}
+
+// lockMigrations waits until the migrations lock is acquired, by declaring the lock queue exclusively, and returns the
+// function releasing it.
+func (manager *mqttManager) lockMigrations(ctx context.Context) (func(), error) {
+	for {
+		channel, err := manager.connection.Channel()
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = channel.QueueDeclare(migrationsLockQueue, false, true, true, false, nil)
+		if err == nil {
+			return func() {
+				_, _ = channel.QueueDelete(migrationsLockQueue, false, false, false)
+				_ = channel.Close()
+			}, nil
+		}
+
+		// The broker closes the channel on failure.
+		_ = channel.Close()
+
+		if !isErrorResourceLocked(err) {
+			return nil, err
+		}
+
+		manager.logger.Debug("Migrations locked by another manager, waiting")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// readMigrationRecords returns the record of the last applied migration, the one with the highest version, along with
+// the delivery tag of the last record read. All records are held unacknowledged until they are replaced.
+func readMigrationRecords(channel *amqp.Channel) (migrationRecord, uint64, error) {
+	var (
+		current migrationRecord
+		lastTag uint64
+	)
+
+	for {
+		delivery, ok, err := channel.Get(migrationsQueue, false)
+		if err != nil {
+			return current, lastTag, err
+		}
+
+		if !ok {
+			return current, lastTag, nil
+		}
+
+		lastTag = delivery.DeliveryTag
+
+		var record migrationRecord
+
+		if err = json.Unmarshal(delivery.Body, &record); err != nil {
+			return current, lastTag, fmt.Errorf("invalid migration record: %w", err)
+		}
+
+		if record.Version > current.Version {
+			current = record
+		}
+	}
+}
+
+// writeMigrationRecord records the last applied migration. The held records are only removed once the new one is
+// confirmed, so that an interrupted write never loses the applied version.
+func writeMigrationRecord(ctx context.Context, channel *amqp.Channel, record migrationRecord, heldTag uint64) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, "", migrationsQueue, true, false, amqp.Publishing{
+		ContentType:  "application/json",
+		Body:         body,
+		DeliveryMode: Persistent.Uint8(),
+		Timestamp:    record.AppliedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !acked {
+		return errMigrationNotRecorded
+	}
+
+	if heldTag > 0 {
+		return channel.Ack(heldTag, true)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package gorabbit_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// exchangesDeclared returns the names of the exchanges declared to the server, in order.
+func exchangesDeclared(server *fakeServer) []string {
+	var exchanges []string
+
+	for _, declaration := range server.declarations() {
+		if declaration.Kind == "exchange" {
+			exchanges = append(exchanges, declaration.Name)
+		}
+	}
+
+	return exchanges
+}
+
+func TestMQTTManager_Migrate(t *testing.T) {
+	server := newFakeServer(t)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	migrations := []gorabbit.Migration{
+		{Version: 1, Description: "events", Steps: []gorabbit.MigrationStep{
+			gorabbit.DeclareExchangeStep(gorabbit.ExchangeConfig{Name: "events", Type: gorabbit.ExchangeTypeTopic}),
+		}},
+		{Version: 2, Description: "orders", Steps: []gorabbit.MigrationStep{
+			gorabbit.DeclareExchangeStep(gorabbit.ExchangeConfig{Name: "orders", Type: gorabbit.ExchangeTypeTopic}),
+		}},
+	}
+
+	version, err := manager.Migrate(context.Background(), migrations)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(2), version)
+	assert.Equal(t, []string{"events", "orders"}, exchangesDeclared(server))
+
+	// Only the migrations after the recorded version are applied.
+	migrations = append(migrations, gorabbit.Migration{Version: 3, Description: "payments", Steps: []gorabbit.MigrationStep{
+		gorabbit.DeclareExchangeStep(gorabbit.ExchangeConfig{Name: "payments", Type: gorabbit.ExchangeTypeTopic}),
+	}})
+
+	version, err = manager.Migrate(context.Background(), migrations)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(3), version)
+	assert.Equal(t, []string{"events", "orders", "payments"}, exchangesDeclared(server))
+
+	version, err = manager.Migrate(context.Background(), migrations)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint(3), version)
+	assert.Len(t, exchangesDeclared(server), 3)
+}
+
+func TestMQTTManager_Migrate_Concurrent(t *testing.T) {
+	server := newFakeServer(t)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	var applied atomic.Int32
+
+	migrations := []gorabbit.Migration{
+		{Version: 1, Description: "slow", Steps: []gorabbit.MigrationStep{
+			gorabbit.MigrationFunc(func(context.Context, gorabbit.MQTTManager) error {
+				applied.Add(1)
+				time.Sleep(50 * time.Millisecond)
+
+				return nil
+			}),
+		}},
+	}
+
+	var wg sync.WaitGroup
+
+	versions := make([]uint, 2)
+	errs := make([]error, 2)
+
+	for i := range versions {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			versions[i], errs[i] = manager.Migrate(context.Background(), migrations)
+		}(i)
+	}
+
+	wg.Wait()
+
+	// The migrations of the same manager wait for each other, so the second one finds the first one recorded.
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	assert.Equal(t, []uint{1, 1}, versions)
+	assert.Equal(t, int32(1), applied.Load())
+}
+
+func TestMQTTManager_Migrate_Invalid(t *testing.T) {
+	server := newFakeServer(t)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	for _, migrations := range [][]gorabbit.Migration{
+		{{Description: "no version"}},
+		{{Version: 2}, {Version: 1}},
+		{{Version: 1}, {Version: 1}},
+	} {
+		_, err = manager.Migrate(context.Background(), migrations)
+		assert.Error(t, err)
+	}
+}
@@ -1,37 +1,46 @@
 package gorabbit
 
 import (
+	"sync"
+	"time"
+
 	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 type SchemaDefinitions struct {
-	Exchanges []struct {
-		Name       string `json:"name"`
-		Vhost      string `json:"vhost"`
-		Type       string `json:"type"`
-		Durable    bool   `json:"durable"`
-		AutoDelete bool   `json:"auto_delete"`
-		Internal   bool   `json:"internal"`
-		Arguments  struct {
-		} `json:"arguments"`
-	} `json:"exchanges"`
-	Queues []struct {
-		Name       string `json:"name"`
-		Vhost      string `json:"vhost"`
-		Durable    bool   `json:"durable"`
-		AutoDelete bool   `json:"auto_delete"`
-		Arguments  struct {
-		} `json:"arguments"`
-	} `json:"queues"`
-	Bindings []struct {
-		Source          string `json:"source"`
-		Vhost           string `json:"vhost"`
-		Destination     string `json:"destination"`
-		DestinationType string `json:"destination_type"`
-		RoutingKey      string `json:"routing_key"`
-		Arguments       struct {
-		} `json:"arguments"`
-	} `json:"bindings"`
+	Exchanges []SchemaExchange `json:"exchanges"`
+	Queues    []SchemaQueue    `json:"queues"`
+	Bindings  []SchemaBinding  `json:"bindings"`
+}
+
+// SchemaExchange is an exchange of the SchemaDefinitions.
+type SchemaExchange struct {
+	Name       string                 `json:"name"`
+	Vhost      string                 `json:"vhost"`
+	Type       string                 `json:"type"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Internal   bool                   `json:"internal"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+// SchemaQueue is a queue of the SchemaDefinitions.
+type SchemaQueue struct {
+	Name       string                 `json:"name"`
+	Vhost      string                 `json:"vhost"`
+	Durable    bool                   `json:"durable"`
+	AutoDelete bool                   `json:"auto_delete"`
+	Arguments  map[string]interface{} `json:"arguments"`
+}
+
+// SchemaBinding is a binding of the SchemaDefinitions.
+type SchemaBinding struct {
+	Source          string                 `json:"source"`
+	Vhost           string                 `json:"vhost"`
+	Destination     string                 `json:"destination"`
+	DestinationType string                 `json:"destination_type"`
+	RoutingKey      string                 `json:"routing_key"`
+	Arguments       map[string]interface{} `json:"arguments"`
 }
 
 type ExchangeConfig struct {
@@ -39,6 +48,13 @@ type ExchangeConfig struct {
 	Type      ExchangeType           `yaml:"type"`
 	Persisted bool                   `yaml:"persisted"`
 	Args      map[string]interface{} `yaml:"args"`
+
+	// Bindings binds the exchange, as destination, to the source exchanges of each binding.
+	Bindings []BindingConfig `yaml:"bindings"`
+
+	// AlternateExchange defines, if set, the exchange receiving the messages that cannot be routed, through the
+	// "alternate-exchange" argument. The manager can also declare it along with a queue collecting those messages.
+	AlternateExchange *AlternateExchangeConfig `yaml:"alternate_exchange"`
 }
 
 type QueueConfig struct {
@@ -47,22 +63,107 @@ type QueueConfig struct {
 	Exclusive bool                   `yaml:"exclusive"`
 	Args      map[string]interface{} `yaml:"args"`
 	Bindings  []BindingConfig        `yaml:"bindings"`
+
+	// Type defines the queue type. Defaults to the broker's default type, usually classic.
+	Type QueueType `yaml:"type"`
+
+	// DeliveryLimit defines, for quorum queues, the number of deliveries after which a message is dead-lettered.
+	DeliveryLimit int `yaml:"delivery_limit"`
+
+	// DeadLetterStrategy defines, for quorum queues, how messages are dead-lettered.
+	DeadLetterStrategy DeadLetterStrategy `yaml:"dead_letter_strategy"`
+
+	// InitialGroupSize defines, for quorum queues, the initial number of replicas.
+	InitialGroupSize int `yaml:"initial_group_size"`
+
+	// SingleActiveConsumer defines whether only one consumer at a time receives messages from the queue, the others
+	// being on standby.
+	SingleActiveConsumer bool `yaml:"single_active_consumer"`
+
+	// MaxPriority makes, if set, a classic priority queue delivering the messages with the highest priority first, from
+	// 0 up to MaxPriority. The broker recommends a maximum of 10.
+	MaxPriority uint8 `yaml:"max_priority"`
+
+	// MaxLength defines, if set, the maximum number of ready messages in the queue.
+	MaxLength int64 `yaml:"max_length"`
+
+	// MaxLengthBytes defines, if set, the maximum total size of the ready messages' bodies in the queue.
+	MaxLengthBytes int64 `yaml:"max_length_bytes"`
+
+	// Overflow defines the behavior of the queue once its max length is reached. Defaults to the broker's default
+	// behavior, dropping the oldest messages.
+	Overflow OverflowBehavior `yaml:"overflow"`
 }
 
 type BindingConfig struct {
 	RoutingKey string `yaml:"routing_key"`
 	Exchange   string `yaml:"exchange"`
+
+	// Args defines the binding arguments, such as the headers to match and "x-match" for headers exchanges.
+	Args map[string]interface{} `yaml:"args"`
 }
 
 type PublishingOptions struct {
 	MessagePriority *MessagePriority
 	DeliveryMode    *DeliveryMode
+
+	// HashKey is, if set, the key hashed by a consistent-hash exchange to pick the queue. It replaces the routing key
+	// unless HashHeader is set.
+	HashKey *string
+
+	// HashHeader is the header carrying the HashKey, for consistent-hash exchanges declared with the same hash header.
+	HashHeader string
+
+	// PartitionKey is, if set, the key picking the partition of a super stream among Partitions. It replaces the
+	// routing key with the index of the partition.
+	PartitionKey *string
+
+	// Partitions is the number of partitions of the super stream.
+	Partitions int
+
+	// Mandatory asks the broker to return the message if it cannot be routed to any queue, through the OnReturn
+	// callback of the BrokerNotifications.
+	Mandatory bool
+
+	// Retained asks the MQTT plugin to keep the message as the last one of its topic, for the future subscribers. It is
+	// only used by the MQTTPublisher.
+	Retained bool
+
+	// CorrelationID is, if set, the correlation identifier of the message, such as the one of the request a reply
+	// answers.
+	CorrelationID string
+
+	// ReplyTo is, if set, the queue the replies to the message are expected on.
+	ReplyTo string
 }
 
 func SendOptions() *PublishingOptions {
 	return &PublishingOptions{}
 }
 
+// withDefaults returns the options completed with the priority and delivery mode of the defaults, if any.
+func (m *PublishingOptions) withDefaults(defaults *PublishingOptions) *PublishingOptions {
+	if defaults == nil {
+		return m
+	}
+
+	if m == nil {
+		return defaults
+	}
+
+	options := *m
+
+	if options.MessagePriority == nil {
+		options.MessagePriority = defaults.MessagePriority
+	}
+
+	if options.DeliveryMode == nil {
+		options.DeliveryMode = defaults.DeliveryMode
+	}
+
+	return &options
+}
+
 func (m *PublishingOptions) priority() uint8 {
 	if m.MessagePriority == nil {
 		return PriorityMedium.Uint8()
@@ -91,10 +192,75 @@ func (m *PublishingOptions) SetMode(mode DeliveryMode) *PublishingOptions {
 	return m
 }
 
-type consumptionHealth map[string]bool
+// SetHashKey will assign the key hashed by a consistent-hash exchange hashing the routing key, in place of the
+// routing key.
+func (m *PublishingOptions) SetHashKey(key string) *PublishingOptions {
+	m.HashKey = &key
+	m.HashHeader = ""
 
-func (s consumptionHealth) IsHealthy() bool {
-	for _, v := range s {
+	return m
+}
+
+// SetPartitionKey will assign the key picking the partition of a super stream with the given number of partitions, the
+// same way as the stream clients do. Messages with the same key always reach the same partition, in order.
+func (m *PublishingOptions) SetPartitionKey(key string, partitions int) *PublishingOptions {
+	m.PartitionKey = &key
+	m.Partitions = partitions
+
+	return m
+}
+
+// SetHeaderHashKey will assign the key hashed by a consistent-hash exchange hashing the given header.
+func (m *PublishingOptions) SetHeaderHashKey(header, key string) *PublishingOptions {
+	m.HashKey = &key
+	m.HashHeader = header
+
+	return m
+}
+
+// SetMandatory will ask the broker to return the message if it cannot be routed to any queue.
+func (m *PublishingOptions) SetMandatory() *PublishingOptions {
+	m.Mandatory = true
+
+	return m
+}
+
+// SetRetained will ask the MQTT plugin to keep the message as the last one of its topic.
+func (m *PublishingOptions) SetRetained() *PublishingOptions {
+	m.Retained = true
+
+	return m
+}
+
+// SetCorrelationID will assign the correlation identifier of the message.
+func (m *PublishingOptions) SetCorrelationID(correlationID string) *PublishingOptions {
+	m.CorrelationID = correlationID
+
+	return m
+}
+
+// SetReplyTo will assign the queue the replies to the message are expected on.
+func (m *PublishingOptions) SetReplyTo(queue string) *PublishingOptions {
+	m.ReplyTo = queue
+
+	return m
+}
+
+type consumptionHealth struct {
+	// mutex protects subscriptions, updated by the consumptions while the health is checked, from concurrent access.
+	mutex         sync.Mutex
+	subscriptions map[string]bool
+}
+
+func newConsumptionHealth() *consumptionHealth {
+	return &consumptionHealth{subscriptions: make(map[string]bool)}
+}
+
+func (s *consumptionHealth) IsHealthy() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, v := range s.subscriptions {
 		if !v {
 			return false
 		}
@@ -103,8 +269,11 @@ func (s consumptionHealth) IsHealthy() bool {
 	return true
 }
 
-func (s consumptionHealth) AddSubscription(queue string, err error) {
-	s[queue] = err == nil
+func (s *consumptionHealth) AddSubscription(queue string, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subscriptions[queue] = err == nil
 }
 
 type mqttPublishing struct {
@@ -126,4 +295,16 @@ type RabbitMQEnvs struct {
 	Password string `env:"RABBITMQ_PASSWORD"`
 	Vhost    string `env:"RABBITMQ_VHOST"`
 	UseTLS   bool   `env:"RABBITMQ_USE_TLS"`
+
+	// ManagementPort is the port of the management HTTP API, only used by the ManagementOptions.
+	ManagementPort uint `env:"RABBITMQ_MANAGEMENT_PORT"`
+
+	// KeepAlive overrides, if set, whether the re-connection and retry mechanisms are triggered.
+	KeepAlive *bool `env:"RABBITMQ_KEEP_ALIVE"`
+
+	// RetryDelay overrides, if positive, the delay of the re-connection and retry mechanisms, such as "5s".
+	RetryDelay time.Duration `env:"RABBITMQ_RETRY_DELAY"`
+
+	// PrefetchCount is, if positive, the PrefetchCount of the consumers that define none.
+	PrefetchCount int `env:"RABBITMQ_PREFETCH_COUNT"`
 }
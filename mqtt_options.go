@@ -0,0 +1,173 @@
+package gorabbit
+
+import (
+	"time"
+)
+
+// MQTTQoS is the quality of service of an MQTT publishing.
+type MQTTQoS uint8
+
+const (
+	// MQTTQoSAtMostOnce sends the publishing without waiting for the broker to acknowledge it (QoS 0).
+	MQTTQoSAtMostOnce MQTTQoS = 0
+
+	// MQTTQoSAtLeastOnce waits for the broker to acknowledge the publishing (QoS 1).
+	MQTTQoSAtLeastOnce MQTTQoS = 1
+)
+
+// MQTTOptions holds all necessary properties to publish to the MQTT plugin of RabbitMQ with an MQTTPublisher.
+type MQTTOptions struct {
+	// Host is the RabbitMQ server host name.
+	Host string
+
+	// Port is the port number of the MQTT plugin.
+	Port uint
+
+	// Username is the RabbitMQ server allowed username.
+	Username string
+
+	// Password is the RabbitMQ server allowed password.
+	Password string
+
+	// Vhost is the vhost the publishings are sent to. The MQTT plugin reads it from the username, as "vhost:username".
+	Vhost string
+
+	// UseTLS defines whether the connection is secured with TLS.
+	UseTLS bool
+
+	// ClientID identifies the MQTT session, a random one being generated if empty.
+	ClientID string
+
+	// KeepAlive is the keep alive interval of the MQTT session, the publisher pinging the broker when idle.
+	KeepAlive time.Duration
+
+	// Timeout bounds the connection to the broker, along with the context of the publishing.
+	Timeout time.Duration
+
+	// Exchange is the topic exchange of the MQTT plugin, amq.topic by default. The publishings can only target it.
+	Exchange string
+
+	// QoS is the quality of service of the publishings whose options do not set a DeliveryMode.
+	QoS MQTTQoS
+
+	// Marshaller encodes the payloads, JSONMarshaller if nil. The MQTT publishings carry no headers nor content type.
+	Marshaller Marshaller
+}
+
+// DefaultMQTTOptions will return an MQTTOptions with default values.
+func DefaultMQTTOptions() *MQTTOptions {
+	return &MQTTOptions{
+		Host:      defaultHost,
+		Port:      defaultMQTTPort,
+		Username:  defaultUsername,
+		Password:  defaultPassword,
+		Vhost:     defaultVhost,
+		UseTLS:    defaultUseTLS,
+		KeepAlive: defaultMQTTKeepAlive,
+		Timeout:   defaultMQTTTimeout,
+		Exchange:  defaultMQTTExchange,
+		QoS:       MQTTQoSAtLeastOnce,
+	}
+}
+
+// NewMQTTOptions is the exported builder for an MQTTOptions and will offer setter methods for an easy construction.
+// Any non-assigned field will be set to default through DefaultMQTTOptions.
+func NewMQTTOptions() *MQTTOptions {
+	return DefaultMQTTOptions()
+}
+
+// SetHost will assign the host.
+func (m *MQTTOptions) SetHost(host string) *MQTTOptions {
+	m.Host = host
+
+	return m
+}
+
+// SetPort will assign the port of the MQTT plugin.
+func (m *MQTTOptions) SetPort(port uint) *MQTTOptions {
+	m.Port = port
+
+	return m
+}
+
+// SetCredentials will assign the username and password.
+func (m *MQTTOptions) SetCredentials(username, password string) *MQTTOptions {
+	m.Username = username
+	m.Password = password
+
+	return m
+}
+
+// SetVhost will assign the Vhost.
+func (m *MQTTOptions) SetVhost(vhost string) *MQTTOptions {
+	m.Vhost = vhost
+
+	return m
+}
+
+// SetUseTLS will assign the UseTLS status.
+func (m *MQTTOptions) SetUseTLS(use bool) *MQTTOptions {
+	m.UseTLS = use
+
+	return m
+}
+
+// SetClientID will assign the client identifier of the MQTT session.
+func (m *MQTTOptions) SetClientID(clientID string) *MQTTOptions {
+	m.ClientID = clientID
+
+	return m
+}
+
+// SetKeepAlive will assign the keep alive interval of the MQTT session.
+func (m *MQTTOptions) SetKeepAlive(keepAlive time.Duration) *MQTTOptions {
+	m.KeepAlive = keepAlive
+
+	return m
+}
+
+// SetTimeout will assign the timeout of the connection to the broker.
+func (m *MQTTOptions) SetTimeout(timeout time.Duration) *MQTTOptions {
+	m.Timeout = timeout
+
+	return m
+}
+
+// SetExchange will assign the topic exchange of the MQTT plugin.
+func (m *MQTTOptions) SetExchange(exchange string) *MQTTOptions {
+	m.Exchange = exchange
+
+	return m
+}
+
+// SetQoS will assign the default quality of service of the publishings.
+func (m *MQTTOptions) SetQoS(qos MQTTQoS) *MQTTOptions {
+	m.QoS = qos
+
+	return m
+}
+
+// SetMarshaller will assign the Marshaller encoding the payloads.
+func (m *MQTTOptions) SetMarshaller(marshaller Marshaller) *MQTTOptions {
+	m.Marshaller = marshaller
+
+	return m
+}
+
+// username returns the username of the MQTT session, prefixed with the vhost unless it is the default one.
+func (m *MQTTOptions) username() string {
+	if m.Vhost == "" || m.Vhost == defaultVhost {
+		return m.Username
+	}
+
+	return m.Vhost + ":" + m.Username
+}
+
+// marshaller returns the Marshaller, or the JSONMarshaller if not set.
+func (m *MQTTOptions) marshaller() Marshaller {
+	if m.Marshaller == nil {
+		return JSONMarshaller{}
+	}
+
+	return m.Marshaller
+}
@@ -0,0 +1,562 @@
+package gorabbit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MQTT 3.1.1 control packet types, in the high nibble of the first byte of a packet.
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttPubAck     = 0x40
+	mqttPingReq    = 0xC0
+	mqttDisconnect = 0xE0
+)
+
+// MQTT 3.1.1 connection settings.
+const (
+	mqttProtocolName     = "MQTT"
+	mqttProtocolLevel    = 4
+	mqttFlagCleanSession = 0x02
+	mqttFlagPassword     = 0x40
+	mqttFlagUsername     = 0x80
+	mqttFlagRetain       = 0x01
+	mqttMaxLengthBytes   = 4
+
+	// mqttMaxRemainingLength is the largest remaining length that fits in mqttMaxLengthBytes, about 256 MB.
+	mqttMaxRemainingLength = 268435455
+
+	// mqttPacketIDs is the number of packet identifiers, which are non-zero 16-bit integers.
+	mqttPacketIDs = 65535
+)
+
+// MQTT 3.1.1 return codes of a refused connection because of the credentials.
+const (
+	mqttBadCredentials = 4
+	mqttNotAuthorized  = 5
+)
+
+// MQTTPublisher publishes to the MQTT plugin of RabbitMQ, so that the services speaking MQTT share the Publisher
+// interface with the ones speaking AMQP. The plugin forwards the publishings to its topic exchange, the topic being the
+// routing key with its dots and slashes swapped: the routing key "device.42.status" is published to the topic
+// "device/42/status", and reaches the queues bound with it. No connection is made until a publishing, and a lost
+// connection is opened again by the next one.
+type MQTTPublisher struct {
+	options    MQTTOptions
+	marshaller Marshaller
+
+	// mutex protects session and closed from concurrent access.
+	mutex   sync.Mutex
+	session *mqttSession
+	closed  bool
+}
+
+// NewMQTTPublisher will instantiate a new MQTTPublisher. No connection is made until a publishing.
+func NewMQTTPublisher(options *MQTTOptions) *MQTTPublisher {
+	if options == nil {
+		options = DefaultMQTTOptions()
+	}
+
+	opts := *options
+
+	if opts.ClientID == "" {
+		opts.ClientID = strings.ToLower(libraryName) + "-" + uuid.NewString()
+	}
+
+	return &MQTTPublisher{options: opts, marshaller: opts.marshaller()}
+}
+
+// Publish will send the payload to the topic of the routing key, with the default QoS of the MQTTOptions.
+func (p *MQTTPublisher) Publish(exchange, routingKey string, payload interface{}) error {
+	return p.PublishWithContext(context.Background(), exchange, routingKey, payload, nil)
+}
+
+// PublishWithOptions will send the payload to the topic of the routing key. The DeliveryMode of the options, if set,
+// picks the QoS like the MQTT plugin does the other way around: Transient publishes at most once (QoS 0), and
+// Persistent at least once (QoS 1). Retained keeps the message as the last one of its topic.
+func (p *MQTTPublisher) PublishWithOptions(exchange, routingKey string, payload interface{}, options *PublishingOptions) error {
+	return p.PublishWithContext(context.Background(), exchange, routingKey, payload, options)
+}
+
+// PublishWithContext behaves like PublishWithOptions, the context bounding the connection to the broker and, with QoS
+// 1, the wait for its acknowledgement. options can be nil.
+// Returns an error if the exchange is neither empty nor the exchange of the MQTT plugin.
+func (p *MQTTPublisher) PublishWithContext(
+	ctx context.Context,
+	exchange string,
+	routingKey string,
+	payload interface{},
+	options *PublishingOptions,
+) error {
+	if exchange != "" && exchange != p.options.Exchange {
+		return fmt.Errorf("%w: '%s'", errMQTTExchange, exchange)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	metadata := &MessageMetadata{
+		Exchange:   p.options.Exchange,
+		RoutingKey: routingKey,
+		Type:       routingKey,
+		Headers:    make(map[string]interface{}),
+	}
+
+	body, err := p.marshaller.Marshal(metadata, payload)
+	if err != nil {
+		return err
+	}
+
+	qos, retained := p.options.QoS, false
+
+	if options != nil {
+		if options.DeliveryMode != nil && *options.DeliveryMode == Transient {
+			qos = MQTTQoSAtMostOnce
+		} else if options.DeliveryMode != nil {
+			qos = MQTTQoSAtLeastOnce
+		}
+
+		retained = options.Retained
+	}
+
+	session, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	return session.publish(ctx, mqttTopic(routingKey), body, qos, retained)
+}
+
+// Disconnect closes the connection to the broker, if any. The MQTTPublisher cannot publish anymore.
+func (p *MQTTPublisher) Disconnect() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.closed = true
+
+	if p.session == nil {
+		return nil
+	}
+
+	err := p.session.disconnect()
+
+	p.session = nil
+
+	return err
+}
+
+// connect returns the current session, or a new one if there is none or it was lost.
+func (p *MQTTPublisher) connect(ctx context.Context) (*mqttSession, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.closed {
+		return nil, errConnectionClosed
+	}
+
+	if p.session != nil && !p.session.isClosed() {
+		return p.session, nil
+	}
+
+	session, err := dialMQTT(ctx, p.options)
+	if err != nil {
+		return nil, err
+	}
+
+	p.session = session
+
+	return session, nil
+}
+
+// mqttTopic returns the MQTT topic of the routing key, swapping its dots and slashes like the MQTT plugin.
+func mqttTopic(routingKey string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.':
+			return '/'
+		case '/':
+			return '.'
+		default:
+			return r
+		}
+	}, routingKey)
+}
+
+// mqttSession is a connection to the MQTT plugin, publishing until it is lost or disconnected.
+type mqttSession struct {
+	conn net.Conn
+
+	// writeMutex serializes the packets written by the publishings and the keep alive.
+	writeMutex sync.Mutex
+
+	// mutex protects nextID, acks and err from concurrent access.
+	mutex  sync.Mutex
+	nextID uint16
+	acks   map[uint16]chan struct{}
+	err    error
+
+	// done is closed once the session is lost or disconnected, err holding the reason.
+	done chan struct{}
+}
+
+// dialMQTT opens a connection to the MQTT plugin and starts a session, within the Timeout of the options and the
+// context.
+func dialMQTT(ctx context.Context, options MQTTOptions) (*mqttSession, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	address := net.JoinHostPort(options.Host, strconv.Itoa(int(options.Port)))
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if options.UseTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: options.Host, MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = new(net.Dialer).DialContext(ctx, "tcp", address)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	// The handshake is bounded by the context as well.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if err = handshakeMQTT(conn, reader, options); err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	session := &mqttSession{
+		conn: conn,
+		acks: make(map[uint16]chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go session.read(reader)
+
+	if options.KeepAlive > 0 {
+		go session.keepAlive(options.KeepAlive / 2)
+	}
+
+	return session, nil
+}
+
+// handshakeMQTT sends the CONNECT packet of a new session, and reads the CONNACK packet of the broker.
+func handshakeMQTT(conn net.Conn, reader *bufio.Reader, options MQTTOptions) error {
+	flags := byte(mqttFlagCleanSession)
+	payload := mqttString(options.ClientID)
+
+	if username := options.username(); username != "" {
+		flags |= mqttFlagUsername
+		payload = append(payload, mqttString(username)...)
+	}
+
+	if options.Password != "" {
+		flags |= mqttFlagPassword
+		payload = append(payload, mqttString(options.Password)...)
+	}
+
+	header := append(mqttString(mqttProtocolName), mqttProtocolLevel, flags)
+	header = binary.BigEndian.AppendUint16(header, uint16(options.KeepAlive/time.Second))
+
+	if _, err := conn.Write(mqttPacket(mqttConnect, header, payload)); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	packetType, body, err := readMQTTPacket(reader)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	if packetType != mqttConnAck || len(body) != 2 {
+		return fmt.Errorf("%w: expected CONNACK, got %#x", errMQTTProtocol, packetType)
+	}
+
+	switch code := body[1]; code {
+	case 0:
+		return nil
+	case mqttBadCredentials, mqttNotAuthorized:
+		return fmt.Errorf("%w: mqtt connection refused with code %d", ErrAccessRefused, code)
+	default:
+		return fmt.Errorf("%w: mqtt connection refused with code %d", ErrNotConnected, code)
+	}
+}
+
+// publish sends a PUBLISH packet and, with QoS 1, waits for its PUBACK until the context is done.
+func (s *mqttSession) publish(ctx context.Context, topic string, body []byte, qos MQTTQoS, retained bool) error {
+	header := byte(mqttPublish) | byte(qos)<<1
+	if retained {
+		header |= mqttFlagRetain
+	}
+
+	variable := mqttString(topic)
+
+	// The remaining length of a packet cannot be encoded beyond mqttMaxRemainingLength, counting the packet identifier
+	// of the QoS 1 publishings.
+	if length := len(variable) + 2*int(qos) + len(body); length > mqttMaxRemainingLength {
+		return fmt.Errorf("%w: %d bytes", errMQTTPacketTooLarge, length)
+	}
+
+	if qos == MQTTQoSAtMostOnce {
+		return s.write(mqttPacket(header, variable, body))
+	}
+
+	id, acked, err := s.track()
+	if err != nil {
+		return err
+	}
+
+	if err = s.write(mqttPacket(header, binary.BigEndian.AppendUint16(variable, id), body)); err != nil {
+		s.untrack(id)
+
+		return err
+	}
+
+	select {
+	case <-acked:
+		return nil
+	case <-s.done:
+		return s.closedErr()
+	case <-ctx.Done():
+		s.untrack(id)
+
+		return ctx.Err()
+	}
+}
+
+// track returns the next free packet identifier, along with the channel closed once its PUBACK is received.
+// Returns an error if every packet identifier is waiting for its PUBACK.
+func (s *mqttSession) track() (uint16, chan struct{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return 0, nil, s.err
+	}
+
+	// The packet identifiers are non-zero, and cannot be reused before their acknowledgement, so they are searched for
+	// one cycle at most.
+	for i := 0; i < mqttPacketIDs; i++ {
+		if s.nextID++; s.nextID == 0 {
+			s.nextID = 1
+		}
+
+		if _, used := s.acks[s.nextID]; !used {
+			acked := make(chan struct{})
+			s.acks[s.nextID] = acked
+
+			return s.nextID, acked, nil
+		}
+	}
+
+	return 0, nil, fmt.Errorf("%w: %d publishings waiting for their acknowledgement", errMQTTPacketIDsExhausted, len(s.acks))
+}
+
+// untrack forgets the packet identifier of a publishing no longer waiting for its PUBACK.
+func (s *mqttSession) untrack(id uint16) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.acks, id)
+}
+
+// acknowledge releases the publishing waiting for the PUBACK of the packet identifier, if any.
+func (s *mqttSession) acknowledge(id uint16) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if acked, found := s.acks[id]; found {
+		close(acked)
+		delete(s.acks, id)
+	}
+}
+
+// write sends a packet, closing the session if it cannot be sent.
+func (s *mqttSession) write(packet []byte) error {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+
+	if _, err := s.conn.Write(packet); err != nil {
+		s.close(fmt.Errorf("%w: %w", ErrNotConnected, err))
+
+		return s.closedErr()
+	}
+
+	return nil
+}
+
+// read dispatches the acknowledgements of the broker until the connection is lost.
+func (s *mqttSession) read(reader *bufio.Reader) {
+	for {
+		packetType, body, err := readMQTTPacket(reader)
+		if err != nil {
+			s.close(fmt.Errorf("%w: %w", ErrNotConnected, err))
+
+			return
+		}
+
+		if packetType == mqttPubAck && len(body) == 2 {
+			s.acknowledge(binary.BigEndian.Uint16(body))
+		}
+	}
+}
+
+// keepAlive pings the broker at the given interval until the session is closed, so that an idle session is kept open.
+func (s *mqttSession) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			_ = s.write(mqttPacket(mqttPingReq))
+		}
+	}
+}
+
+// disconnect sends a DISCONNECT packet and closes the session.
+func (s *mqttSession) disconnect() error {
+	err := s.write(mqttPacket(mqttDisconnect))
+
+	s.close(errConnectionClosed)
+
+	return err
+}
+
+// close closes the session once, failing the publishings waiting for their PUBACK with the given error.
+func (s *mqttSession) close(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.err != nil {
+		return
+	}
+
+	s.err = err
+	s.acks = make(map[uint16]chan struct{})
+
+	close(s.done)
+
+	_ = s.conn.Close()
+}
+
+// isClosed returns true once the session is lost or disconnected.
+func (s *mqttSession) isClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// closedErr returns the reason why the session was closed.
+func (s *mqttSession) closedErr() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.err
+}
+
+// mqttPacket returns the packet with the given first byte, made of the given parts.
+func mqttPacket(header byte, parts ...[]byte) []byte {
+	length := 0
+	for _, part := range parts {
+		length += len(part)
+	}
+
+	packet := append(make([]byte, 0, 1+mqttMaxLengthBytes+length), header)
+
+	// The remaining length is encoded with 7 bits per byte, the high bit telling whether a byte follows.
+	for remaining := length; ; {
+		digit := byte(remaining % 128)
+
+		if remaining /= 128; remaining > 0 {
+			digit |= 0x80
+		}
+
+		packet = append(packet, digit)
+
+		if remaining == 0 {
+			break
+		}
+	}
+
+	for _, part := range parts {
+		packet = append(packet, part...)
+	}
+
+	return packet
+}
+
+// mqttString returns the string prefixed with its length.
+func mqttString(s string) []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(len(s))), s...)
+}
+
+// readMQTTPacket reads the next packet, returning its type and its body.
+func readMQTTPacket(reader *bufio.Reader) (byte, []byte, error) {
+	header, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+
+	for i := 0; ; i++ {
+		if i == mqttMaxLengthBytes {
+			return 0, nil, fmt.Errorf("%w: remaining length too long", errMQTTProtocol)
+		}
+
+		digit, err := reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		length += int(digit&0x7F) * multiplier
+		multiplier *= 128
+
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err = io.ReadFull(reader, body); err != nil {
+		return 0, nil, err
+	}
+
+	return header & 0xF0, body, nil
+}
@@ -0,0 +1,266 @@
+package gorabbit_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// fakeMQTTPublishing is a publishing received by a fakeMQTTBroker.
+type fakeMQTTPublishing struct {
+	Topic    string
+	QoS      byte
+	Retained bool
+	Body     string
+}
+
+// fakeMQTTBroker is a minimal MQTT 3.1.1 broker accepting the sessions of the publishers, acknowledging their QoS 1
+// publishings unless withholding the acknowledgements.
+type fakeMQTTBroker struct {
+	listener net.Listener
+
+	mutex       sync.Mutex
+	username    string
+	returnCode  byte
+	withholding bool
+	received    []fakeMQTTPublishing
+}
+
+// newFakeMQTTBroker starts a fakeMQTTBroker, stopped at the end of the test.
+func newFakeMQTTBroker(t *testing.T) *fakeMQTTBroker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	b := &fakeMQTTBroker{listener: listener}
+
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go b.serve(conn)
+		}
+	}()
+
+	return b
+}
+
+func (b *fakeMQTTBroker) options() *gorabbit.MQTTOptions {
+	return gorabbit.NewMQTTOptions().
+		SetPort(uint(b.listener.Addr().(*net.TCPAddr).Port)).
+		SetTimeout(time.Second)
+}
+
+func (b *fakeMQTTBroker) publishings() []fakeMQTTPublishing {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return append([]fakeMQTTPublishing(nil), b.received...)
+}
+
+func (b *fakeMQTTBroker) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		header, body, err := readFakeMQTTPacket(reader)
+		if err != nil {
+			return
+		}
+
+		switch header & 0xF0 {
+		case 0x10: // CONNECT
+			b.mutex.Lock()
+			code := b.returnCode
+			b.username = readFakeMQTTUsername(body)
+			b.mutex.Unlock()
+
+			_, _ = conn.Write([]byte{0x20, 2, 0, code})
+		case 0x30: // PUBLISH
+			qos := (header >> 1) & 0x03
+			size := int(binary.BigEndian.Uint16(body))
+			publishing := fakeMQTTPublishing{Topic: string(body[2 : 2+size]), QoS: qos, Retained: header&0x01 == 1}
+
+			payload := body[2+size:]
+			if qos > 0 {
+				payload = payload[2:]
+			}
+
+			publishing.Body = string(payload)
+
+			b.mutex.Lock()
+			b.received = append(b.received, publishing)
+			withholding := b.withholding
+			b.mutex.Unlock()
+
+			if qos > 0 && !withholding {
+				_, _ = conn.Write([]byte{0x40, 2, body[2+size], body[3+size]})
+			}
+		case 0xE0: // DISCONNECT
+			return
+		}
+	}
+}
+
+// readFakeMQTTUsername reads the username of the payload of a CONNECT packet, empty if there is none.
+func readFakeMQTTUsername(body []byte) string {
+	flags := body[7]
+	offset := 10
+
+	clientID := int(binary.BigEndian.Uint16(body[offset:]))
+	offset += 2 + clientID
+
+	if flags&0x80 == 0 {
+		return ""
+	}
+
+	size := int(binary.BigEndian.Uint16(body[offset:]))
+
+	return string(body[offset+2 : offset+2+size])
+}
+
+func readFakeMQTTPacket(reader *bufio.Reader) (byte, []byte, error) {
+	header, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+
+	for {
+		digit, err := reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		length += int(digit&0x7F) * multiplier
+		multiplier *= 128
+
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(reader, body)
+
+	return header, body, err
+}
+
+func TestMQTTPublisher_Publish(t *testing.T) {
+	broker := newFakeMQTTBroker(t)
+
+	publisher := gorabbit.NewMQTTPublisher(broker.options().SetVhost("devices"))
+	defer func() { _ = publisher.Disconnect() }()
+
+	require.NoError(t, publisher.Publish("", "device.42.status", "online"))
+	require.NoError(t, publisher.Publish("amq.topic", "device.42/firmware", "1.2"))
+
+	assert.Equal(t, []fakeMQTTPublishing{
+		{Topic: "device/42/status", QoS: 1, Body: `"online"`},
+		{Topic: "device/42.firmware", QoS: 1, Body: `"1.2"`},
+	}, broker.publishings())
+
+	// The vhost is passed along with the username.
+	assert.Equal(t, "devices:guest", broker.username)
+}
+
+func TestMQTTPublisher_PublishWithOptions(t *testing.T) {
+	broker := newFakeMQTTBroker(t)
+
+	publisher := gorabbit.NewMQTTPublisher(broker.options())
+	defer func() { _ = publisher.Disconnect() }()
+
+	options := gorabbit.SendOptions().SetMode(gorabbit.Transient).SetRetained()
+
+	require.NoError(t, publisher.PublishWithOptions("", "device.42.status", "online", options))
+
+	assert.Eventually(t, func() bool {
+		return len(broker.publishings()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, fakeMQTTPublishing{Topic: "device/42/status", QoS: 0, Retained: true, Body: `"online"`}, broker.publishings()[0])
+}
+
+func TestMQTTPublisher_NotAcknowledged(t *testing.T) {
+	broker := newFakeMQTTBroker(t)
+	broker.withholding = true
+
+	publisher := gorabbit.NewMQTTPublisher(broker.options())
+	defer func() { _ = publisher.Disconnect() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := publisher.PublishWithContext(ctx, "", "device.42.status", "online", nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMQTTPublisher_Errors(t *testing.T) {
+	broker := newFakeMQTTBroker(t)
+	broker.returnCode = 5
+
+	publisher := gorabbit.NewMQTTPublisher(broker.options())
+
+	// Only the exchange of the MQTT plugin can be targeted.
+	require.Error(t, publisher.Publish("events_exchange", "device.42.status", "online"))
+
+	require.ErrorIs(t, publisher.Publish("", "device.42.status", "online"), gorabbit.ErrAccessRefused)
+
+	require.NoError(t, publisher.Disconnect())
+
+	require.ErrorIs(t, publisher.Publish("", "device.42.status", "online"), gorabbit.ErrNotConnected)
+
+	unreachable := gorabbit.NewMQTTPublisher(gorabbit.NewMQTTOptions().SetPort(1))
+
+	require.ErrorIs(t, unreachable.Publish("", "device.42.status", "online"), gorabbit.ErrNotConnected)
+}
+
+// sizedMarshaller encodes every payload, an int, into as many zero bytes.
+type sizedMarshaller struct{}
+
+func (sizedMarshaller) Marshal(_ *gorabbit.MessageMetadata, payload interface{}) ([]byte, error) {
+	return make([]byte, payload.(int)), nil
+}
+
+func (sizedMarshaller) Unmarshal(_ *gorabbit.MessageMetadata, _ []byte, _ interface{}) error {
+	return nil
+}
+
+func TestMQTTPublisher_PacketTooLarge(t *testing.T) {
+	broker := newFakeMQTTBroker(t)
+
+	publisher := gorabbit.NewMQTTPublisher(broker.options().SetMarshaller(sizedMarshaller{}))
+	defer func() { _ = publisher.Disconnect() }()
+
+	// The topic "device/42/status" and its length take 18 bytes of the remaining length of 268435455 bytes at most,
+	// and the packet identifier of the QoS 1 publishings 2 more.
+	const limit = 268435455 - 18
+
+	require.Error(t, publisher.Publish("", "device.42.status", limit-1))
+	require.Error(t, publisher.PublishWithOptions("", "device.42.status", limit+1, gorabbit.SendOptions().SetMode(gorabbit.Transient)))
+
+	// Nothing is sent, and the session is kept open for the next publishings.
+	require.NoError(t, publisher.Publish("", "device.42.status", 8))
+
+	assert.Equal(t, []fakeMQTTPublishing{
+		{Topic: "device/42/status", QoS: 1, Body: string(make([]byte, 8))},
+	}, broker.publishings())
+}
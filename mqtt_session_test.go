@@ -0,0 +1,42 @@
+package gorabbit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The packet identifiers are tracked on a bare session, as holding all of them through the MQTTPublisher takes as many
+// goroutines waiting for their acknowledgement, beyond the limit of the race detector.
+func TestMQTTSession_Track_Exhausted(t *testing.T) {
+	session := &mqttSession{acks: make(map[uint16]chan struct{}), done: make(chan struct{})}
+
+	for i := 1; i <= mqttPacketIDs; i++ {
+		id, _, err := session.track()
+		require.NoError(t, err)
+		require.Equal(t, uint16(i), id)
+	}
+
+	// Every packet identifier waits for its acknowledgement, so none can be tracked anymore.
+	_, _, err := session.track()
+	require.ErrorIs(t, err, errMQTTPacketIDsExhausted)
+
+	// The acknowledged identifier is reused, skipping zero.
+	session.acknowledge(42)
+
+	id, _, err := session.track()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(42), id)
+
+	session.untrack(mqttPacketIDs)
+	session.untrack(1)
+
+	id, _, err = session.track()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(mqttPacketIDs), id)
+
+	id, _, err = session.track()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(1), id)
+}
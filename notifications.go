@@ -0,0 +1,163 @@
+package gorabbit
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// notificationBufferSize is the size of the buffers of the notifications received from the broker.
+const notificationBufferSize = 64
+
+// BrokerNotifications holds callbacks receiving the low-level notifications of the broker about the publishings. The
+// callbacks are called sequentially from a dedicated goroutine and must not block, as the broker waits for the
+// notifications to be consumed.
+type BrokerNotifications struct {
+	// OnReturn is called with the mandatory messages that could not be routed to any queue.
+	OnReturn func(message ReturnedMessage)
+
+	// OnConfirm is called with the confirmations of the published messages. Setting it, or an AuditSink, puts the
+	// publishing channel in confirm mode.
+	OnConfirm func(confirmation PublishConfirmation)
+
+	// OnFlow is called when the broker pauses (false) or resumes (true) the publishings on the channel.
+	OnFlow func(active bool)
+
+	// OnBlocked is called when the broker blocks or unblocks the publishing connection, usually on resource alarms.
+	OnBlocked func(blocking ConnectionBlocking)
+}
+
+// ReturnedMessage is a mandatory message returned by the broker because it could not be routed.
+type ReturnedMessage struct {
+	// ReplyCode is the reason code of the return.
+	ReplyCode uint16
+
+	// ReplyText is the reason of the return.
+	ReplyText string
+
+	// Exchange is the exchange the message was published to.
+	Exchange string
+
+	// RoutingKey is the routing key the message was published with.
+	RoutingKey string
+
+	// MessageID is the unique identifier of the message.
+	MessageID string
+
+	// Headers are the headers of the message.
+	Headers map[string]interface{}
+
+	// Body is the payload of the message.
+	Body []byte
+}
+
+// PublishConfirmation is the confirmation of a published message by the broker.
+type PublishConfirmation struct {
+	// DeliveryTag is the sequence number of the publishing on its channel, starting at 1 with every new channel.
+	DeliveryTag uint64
+
+	// Ack is true if the broker took responsibility for the message, false if it was nacked.
+	Ack bool
+}
+
+// ConnectionBlocking is the blocking or unblocking of a connection by the broker.
+type ConnectionBlocking struct {
+	// Active is true if the connection is blocked, false once it is unblocked.
+	Active bool
+
+	// Reason is the reason of the blocking, such as a memory or disk alarm.
+	Reason string
+}
+
+// listenNotifications subscribes to the notifications of a newly opened publishing channel and passes them to the
+// callbacks. The listeners stop when the channel is closed.
+func (c *amqpChannel) listenNotifications(channel *amqp.Channel) error {
+	// The confirmations are needed by the callback, the audit, the pipeline and the reliability of the publishings.
+	if c.consumer == nil && (c.auditor != nil || c.pipeline != nil || c.reliable || (c.notifications != nil && c.notifications.OnConfirm != nil)) {
+		if err := channel.Confirm(false); err != nil {
+			return err
+		}
+	}
+
+	// The reliable and the pipelined publishings fail once returned.
+	if c.reliable || c.pipeline != nil {
+		c.returns.Store(newReturnTracker(channel))
+	}
+
+	if c.notifications == nil {
+		return nil
+	}
+
+	if onReturn := c.notifications.OnReturn; onReturn != nil {
+		returns := channel.NotifyReturn(make(chan amqp.Return, notificationBufferSize))
+
+		go func() {
+			for r := range returns {
+				onReturn(ReturnedMessage{
+					ReplyCode:  r.ReplyCode,
+					ReplyText:  r.ReplyText,
+					Exchange:   r.Exchange,
+					RoutingKey: r.RoutingKey,
+					MessageID:  r.MessageId,
+					Headers:    r.Headers,
+					Body:       r.Body,
+				})
+			}
+		}()
+	}
+
+	if onConfirm := c.notifications.OnConfirm; onConfirm != nil {
+		confirmations := channel.NotifyPublish(make(chan amqp.Confirmation, notificationBufferSize))
+
+		go func() {
+			for confirmation := range confirmations {
+				c.faults.delayConfirm()
+
+				onConfirm(PublishConfirmation{DeliveryTag: confirmation.DeliveryTag, Ack: confirmation.Ack})
+			}
+		}()
+	}
+
+	if onFlow := c.notifications.OnFlow; onFlow != nil {
+		flows := channel.NotifyFlow(make(chan bool, notificationBufferSize))
+
+		go func() {
+			for active := range flows {
+				onFlow(active)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// listenBlockings subscribes to the blockings of a newly opened connection and passes them to the callback and the
+// backpressure of the blocking publishings. The listener stops when the connection is closed.
+func (a *amqpConnection) listenBlockings(connection *amqp.Connection) {
+	var onBlocked func(blocking ConnectionBlocking)
+	if a.notifications != nil {
+		onBlocked = a.notifications.OnBlocked
+	}
+
+	if onBlocked == nil && a.backpressure == nil {
+		return
+	}
+
+	// A new connection is not blocked, until the broker says otherwise.
+	if a.backpressure != nil {
+		a.backpressure.setBlocked(false)
+	}
+
+	blockings := connection.NotifyBlocked(make(chan amqp.Blocking, notificationBufferSize))
+
+	go func() {
+		for blocking := range blockings {
+			// The blockings of a lost connection must not block the publishings of the next one.
+			if a.backpressure != nil && !connection.IsClosed() {
+				a.backpressure.setBlocked(blocking.Active)
+			}
+
+			if onBlocked != nil {
+				onBlocked(ConnectionBlocking{Active: blocking.Active, Reason: blocking.Reason})
+			}
+		}
+	}()
+}
@@ -0,0 +1,80 @@
+package gorabbit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClientOptions_SetNotifications(t *testing.T) {
+	var returned []gorabbit.ReturnedMessage
+
+	options := gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetNotifications(gorabbit.BrokerNotifications{
+			OnReturn: func(message gorabbit.ReturnedMessage) {
+				returned = append(returned, message)
+			},
+		})
+
+	require.NotNil(t, options.Notifications)
+	require.NotNil(t, options.Notifications.OnReturn)
+
+	client := gorabbit.NewClient(options)
+
+	defer func() { _ = client.Disconnect() }()
+
+	// Nothing listens on the port, so the mandatory publishing is cached rather than returned.
+	err := client.PublishWithOptions("events_exchange", "event.created", "payload", gorabbit.SendOptions().SetMandatory())
+
+	require.Error(t, err)
+	assert.Empty(t, returned)
+}
+
+func TestClient_MandatoryPublishing_Returned(t *testing.T) {
+	server := newFakeServer(t)
+	server.setOnPublish(func(fakePublishing) fakeAction { return fakeReturn })
+
+	var (
+		mutex    sync.Mutex
+		returned []gorabbit.ReturnedMessage
+	)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetNotifications(gorabbit.BrokerNotifications{
+			OnReturn: func(message gorabbit.ReturnedMessage) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				returned = append(returned, message)
+			},
+		}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	// Without reliable publishing, the publishing returns before the server answers, so the return is only notified.
+	err := client.PublishWithOptions("events_exchange", "event.unroutable", "payload", gorabbit.SendOptions().SetMandatory())
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return len(returned) == 1 && returned[0].RoutingKey == "event.unroutable"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestPublishingOptions_SetMandatory(t *testing.T) {
+	assert.False(t, gorabbit.SendOptions().Mandatory)
+	assert.True(t, gorabbit.SendOptions().SetMandatory().Mandatory)
+}
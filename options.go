@@ -0,0 +1,160 @@
+package gorabbit
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Option configures the ClientOptions of a client built with NewClient. A *ClientOptions is itself an Option, replacing
+// the options set before it, so that the ClientOptions builder and the functional options can be combined. It cannot be
+// passed to UpdateConfig, since it would also replace the settings it does not set.
+type Option interface {
+	apply(options *ClientOptions)
+}
+
+// optionFunc is an Option applied by a function.
+type optionFunc func(options *ClientOptions)
+
+// apply applies the Option to the ClientOptions.
+func (f optionFunc) apply(options *ClientOptions) {
+	f(options)
+}
+
+// apply replaces the ClientOptions with a copy of c, if not nil.
+func (c *ClientOptions) apply(options *ClientOptions) {
+	if c != nil {
+		*options = *c
+	}
+}
+
+// WithURI sets the host, port, credentials, vhost and TLS usage from an amqp:// or amqps:// URI, along with the
+// URIParameters of its query, such as amqps://host/vhost?heartbeat=30&cacertfile=/etc/ssl/ca.pem. The unknown
+// parameters are ignored. An invalid URI is reported by the client when connecting and leaves the options unchanged.
+func WithURI(uri string) Option {
+	return optionFunc(func(options *ClientOptions) {
+		parsed, err := amqp.ParseURI(uri)
+		if err != nil {
+			options.uriErr = fmt.Errorf("invalid URI: %w", err)
+
+			return
+		}
+
+		// The URI was parsed by amqp.ParseURI, so it is a valid URL.
+		u, _ := url.Parse(uri)
+
+		params, err := parseURIParameters(u.Query())
+		if err != nil {
+			options.uriErr = fmt.Errorf("invalid URI: %w", err)
+
+			return
+		}
+
+		options.Host = parsed.Host
+		options.Port = uint(parsed.Port)
+		options.Username = parsed.Username
+		options.Password = parsed.Password
+		options.Vhost = parsed.Vhost
+		options.UseTLS = parsed.Scheme == securedProtocol
+		options.URIParameters = params
+		options.uriErr = nil
+	})
+}
+
+// WithTLS sets whether the amqps protocol is used.
+func WithTLS(use bool) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.UseTLS = use
+	})
+}
+
+// WithKeepAlive sets whether the re-connection and retry mechanisms are triggered.
+func WithKeepAlive(keepAlive bool) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.KeepAlive = keepAlive
+	})
+}
+
+// WithRetryDelay sets the delay of the re-connection and retry mechanisms.
+func WithRetryDelay(delay time.Duration) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.RetryDelay = delay
+	})
+}
+
+// WithMaxRetry sets the number of retries of a message that could not be processed.
+func WithMaxRetry(retry uint) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.MaxRetry = retry
+	})
+}
+
+// WithPrefetchCount sets the PrefetchCount inherited by the consumers defining none.
+func WithPrefetchCount(count int) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.PrefetchCount = count
+	})
+}
+
+// WithPublishingCache sets the max length of the publishing cache and the time to live of its items.
+func WithPublishingCache(size uint64, ttl time.Duration) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.PublishingCacheSize = size
+		options.PublishingCacheTTL = ttl
+	})
+}
+
+// WithReliablePublishing enables the at-least-once guarantee of the publishings, along with KeepAlive and, if not
+// set, the default publishing cache it requires.
+func WithReliablePublishing() Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.ReliablePublishing = true
+		options.KeepAlive = true
+
+		if options.PublishingCacheSize == 0 {
+			options.PublishingCacheSize = defaultPublishingCacheSize
+			options.PublishingCacheTTL = defaultPublishingCacheTTL
+		}
+	})
+}
+
+// WithBlockingPublishing makes the publishings wait, until their context is done, for the channel to be recovered and
+// the connection to be unblocked instead of being cached, along with the KeepAlive it requires.
+func WithBlockingPublishing() Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.BlockingPublishing = true
+		options.KeepAlive = true
+	})
+}
+
+// WithLogger sets the Logger receiving the logs of the client no matter the Mode.
+func WithLogger(logger Logger) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.Logger = logger
+	})
+}
+
+// WithSlogLogger sets the slog.Logger receiving the logs of the client, as SetSlogLogger does.
+func WithSlogLogger(logger *slog.Logger) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.SetSlogLogger(logger)
+	})
+}
+
+// WithTracing sets the TracerProvider of the publishing and delivery spans, as SetTracing does.
+func WithTracing(provider trace.TracerProvider) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.SetTracing(provider)
+	})
+}
+
+// WithMarshaller sets the Marshaller encoding the payloads of the publishings.
+func WithMarshaller(marshaller Marshaller) Option {
+	return optionFunc(func(options *ClientOptions) {
+		options.Marshaller = marshaller
+	})
+}
@@ -0,0 +1,87 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// OrderingConfig enables the ordered processing of deliveries sharing the same key. Deliveries are sharded by key
+// across a fixed number of workers, so that deliveries with the same key are processed sequentially while deliveries
+// with different keys are processed concurrently.
+type OrderingConfig struct {
+	// Header is the header holding the ordering key. Defaults to the routing key if empty, or if the header is missing.
+	Header string
+
+	// Workers is the number of concurrent workers.
+	Workers int
+}
+
+// orderedDispatcher dispatches deliveries to workers based on their ordering key.
+type orderedDispatcher struct {
+	// config is the OrderingConfig of the consumer.
+	config OrderingConfig
+
+	// workers holds the queue of each worker.
+	workers []chan *amqp.Delivery
+}
+
+// newOrderedDispatcher instantiates a new orderedDispatcher and launches its workers, processing deliveries with the
+// given process function until the dispatcher is closed. Each worker queues up to bufferSize deliveries, so that a
+// slow key does not hold back the others.
+func newOrderedDispatcher(config OrderingConfig, bufferSize int, process func(delivery *amqp.Delivery)) *orderedDispatcher {
+	dispatcher := &orderedDispatcher{
+		config:  config,
+		workers: make([]chan *amqp.Delivery, config.Workers),
+	}
+
+	for i := range dispatcher.workers {
+		worker := make(chan *amqp.Delivery, bufferSize)
+
+		dispatcher.workers[i] = worker
+
+		go func() {
+			for delivery := range worker {
+				process(delivery)
+			}
+		}()
+	}
+
+	return dispatcher
+}
+
+// key returns the ordering key of the delivery.
+func (d *orderedDispatcher) key(delivery *amqp.Delivery) string {
+	if d.config.Header != "" {
+		if value, found := delivery.Headers[d.config.Header]; found {
+			return fmt.Sprint(value)
+		}
+	}
+
+	return originalRoutingKey(delivery)
+}
+
+// dispatch sends the delivery to the worker owning its key, waiting for the worker to be available.
+// Returns false if the context was canceled first.
+func (d *orderedDispatcher) dispatch(ctx context.Context, delivery *amqp.Delivery) bool {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(d.key(delivery)))
+
+	worker := d.workers[hash.Sum32()%uint32(len(d.workers))]
+
+	select {
+	case <-ctx.Done():
+		return false
+	case worker <- delivery:
+		return true
+	}
+}
+
+// close stops the workers once their current delivery is processed.
+func (d *orderedDispatcher) close() {
+	for _, worker := range d.workers {
+		close(worker)
+	}
+}
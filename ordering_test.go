@@ -0,0 +1,99 @@
+package gorabbit_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// orderRecorder records the bodies processed for each ordering key, the bodies being formatted as "<key>:<sequence>".
+type orderRecorder struct {
+	mutex     sync.Mutex
+	processed map[string][]string
+}
+
+func (r *orderRecorder) record(body string) {
+	key, sequence, _ := strings.Cut(body, ":")
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.processed[key] = append(r.processed[key], sequence)
+}
+
+func (r *orderRecorder) sequences(key string) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.processed[key]...)
+}
+
+func TestClient_Ordering(t *testing.T) {
+	server := newFakeServer(t)
+
+	recorder := &orderRecorder{processed: make(map[string][]string)}
+	release := make(chan struct{})
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		PrefetchCount: 10,
+		Ordering:      &gorabbit.OrderingConfig{Header: "x-order", Workers: 4},
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				// The first delivery of the first order holds its worker until released.
+				if string(payload) == "order-1:1" {
+					<-release
+				}
+
+				recorder.record(string(payload))
+
+				return nil
+			},
+		},
+	})
+
+	delivery := func(order, sequence string) fakeDelivery {
+		return fakeDelivery{RoutingKey: "event.created", Body: order + ":" + sequence, Headers: amqp.Table{"x-order": order}}
+	}
+
+	server.deliverMessages("events",
+		delivery("order-1", "1"),
+		delivery("order-1", "2"),
+		delivery("order-2", "1"),
+		delivery("order-2", "2"),
+		delivery("order-2", "3"),
+	)
+
+	// The deliveries of the second order are processed while the first order is held.
+	require.Eventually(t, func() bool { return len(recorder.sequences("order-2")) == 3 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1", "2", "3"}, recorder.sequences("order-2"))
+	assert.Empty(t, recorder.sequences("order-1"))
+
+	// The deliveries of the first order are processed in order once released.
+	close(release)
+
+	require.Eventually(t, func() bool { return len(recorder.sequences("order-1")) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"1", "2"}, recorder.sequences("order-1"))
+}
+
+func TestClient_Ordering_Invalid(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().SetHost("127.0.0.1").SetPort(server.port()))
+
+	defer client.Disconnect()
+
+	err := client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue:    "events",
+		Name:     "events",
+		Handlers: gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }},
+		Ordering: &gorabbit.OrderingConfig{},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package gorabbit
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// redactedValue replaces the redacted values of a payload.
+const redactedValue = "[REDACTED]"
+
+// PayloadRedactor returns the payload to log, with its sensitive fields redacted.
+type PayloadRedactor func(payload []byte) []byte
+
+// PayloadLogging enables the debug logging of the payloads of the published and consumed messages.
+type PayloadLogging struct {
+	// MaxSize is the maximum number of bytes of a payload that are logged, the rest being truncated. Defaults to 1024.
+	MaxSize int
+
+	// Redact redacts the sensitive fields of the payloads before they are logged, if set.
+	Redact PayloadRedactor
+}
+
+// RedactJSONFields returns a PayloadRedactor replacing the values of the given fields of a JSON payload, at any depth
+// and whatever their case. A payload that is not valid JSON is redacted entirely.
+func RedactJSONFields(fields ...string) PayloadRedactor {
+	redacted := make(map[string]bool, len(fields))
+
+	for _, field := range fields {
+		redacted[strings.ToLower(field)] = true
+	}
+
+	return func(payload []byte) []byte {
+		var value interface{}
+
+		if err := json.Unmarshal(payload, &value); err != nil {
+			return []byte(redactedValue)
+		}
+
+		result, err := json.Marshal(redactJSONValue(value, redacted))
+		if err != nil {
+			return []byte(redactedValue)
+		}
+
+		return result
+	}
+}
+
+// redactJSONValue replaces the values of the redacted fields of a decoded JSON value.
+func redactJSONValue(value interface{}, redacted map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if redacted[strings.ToLower(key)] {
+				v[key] = redactedValue
+			} else {
+				v[key] = redactJSONValue(field, redacted)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactJSONValue(item, redacted)
+		}
+	}
+
+	return value
+}
+
+// payload returns a payload as logged: redacted, then truncated.
+func (p *PayloadLogging) payload(payload []byte) string {
+	if p.Redact != nil {
+		payload = p.Redact(payload)
+	}
+
+	maxSize := p.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultPayloadLoggingMaxSize
+	}
+
+	if len(payload) > maxSize {
+		return string(payload[:maxSize]) + "..."
+	}
+
+	return string(payload)
+}
+
+// logPayload logs the payload of a message, if the payload logging is enabled.
+func (c *amqpChannel) logPayload(message string, payload []byte, fields ...LogField) {
+	if c.payloadLogging == nil {
+		return
+	}
+
+	c.logger.Debug(message, append(fields, LogField{Key: "payload", Value: c.payloadLogging.payload(payload)})...)
+}
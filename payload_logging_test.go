@@ -0,0 +1,58 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestRedactJSONFields(t *testing.T) {
+	redact := gorabbit.RedactJSONFields("password", "Email")
+
+	assert.JSONEq(t,
+		`{"user":{"name":"john","email":"[REDACTED]"},"tokens":[{"Password":"[REDACTED]"}]}`,
+		string(redact([]byte(`{"user":{"name":"john","email":"john@example.com"},"tokens":[{"Password":"secret"}]}`))),
+	)
+
+	assert.Equal(t, "[REDACTED]", string(redact([]byte("not json"))))
+}
+
+func TestClientOptions_PayloadLogging(t *testing.T) {
+	logger := new(recordingLogger)
+
+	// Nothing listens on the port, so the publishing is cached after its payload is logged.
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetLogger(logger).
+		SetPayloadLogging(gorabbit.PayloadLogging{
+			MaxSize: 41,
+			Redact:  gorabbit.RedactJSONFields("password"),
+		}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	payload := map[string]string{"password": "secret", "user": "john", "zone": "a very long value to truncate"}
+
+	require.Error(t, client.Publish("events_exchange", "event.created", payload))
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	for i, message := range logger.messages {
+		if message == "Publishing payload" {
+			assert.Contains(t, logger.fields[i], gorabbit.LogField{
+				Key:   "payload",
+				Value: `{"password":"[REDACTED]","user":"john","z...`,
+			})
+
+			return
+		}
+	}
+
+	t.Fatal("payload not logged")
+}
@@ -0,0 +1,331 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PublishingPipeline configures the asynchronous publishing of a client. The publishings are buffered and returned from
+// as soon as they are, then sent by a background writer in windows of publisher confirms: the writer sends a batch of
+// publishings at once and waits for all their confirmations, instead of sending them one by one. The publishings that
+// are not confirmed, because the channel was lost or the server rejected them, are sent again, so that they are
+// published at least once. The mandatory publishings returned by the server as unroutable are dropped.
+type PublishingPipeline struct {
+	// BufferSize is the number of publishings buffered, after which publishing blocks until there is room in the buffer
+	// or its context is done. Defaults to 1024.
+	BufferSize int `yaml:"buffer_size"`
+
+	// BatchSize is the maximum number of publishings sent in a single confirm window. Defaults to 128.
+	BatchSize int `yaml:"batch_size"`
+
+	// ConfirmTimeout is the time to wait for the confirmations of a window, after which the unconfirmed publishings are
+	// sent again. Defaults to 30 seconds.
+	ConfirmTimeout time.Duration `yaml:"confirm_timeout"`
+
+	// MaxAttempts is the number of times a publishing is sent before being dropped, such as when its exchange does not
+	// exist. Once a window is not fully confirmed, its publishings are sent again one at a time, so that only the ones
+	// that are not confirmed on their own are charged an attempt. Defaults to 5.
+	MaxAttempts int `yaml:"max_attempts"`
+}
+
+// pipelinedPublishing is a publishing buffered by a publishingPipeline.
+type pipelinedPublishing struct {
+	msg      *mqttPublishing
+	span     trace.Span
+	fields   []LogField
+	attempts int
+}
+
+// publishingPipeline buffers the publishings of a channel and sends them in windows of publisher confirms.
+type publishingPipeline struct {
+	channel        *amqpChannel
+	queue          chan *pipelinedPublishing
+	batchSize      int
+	confirmTimeout time.Duration
+	maxAttempts    int
+
+	// pending is the number of publishings buffered or waiting for their confirmation.
+	pending atomic.Int64
+}
+
+// newPublishingPipeline instantiates a new publishingPipeline of the channel with the given configuration, and starts
+// its writer until the context of the channel is done.
+func newPublishingPipeline(channel *amqpChannel, config PublishingPipeline) *publishingPipeline {
+	p := &publishingPipeline{
+		channel:        channel,
+		queue:          make(chan *pipelinedPublishing, defaultInt(config.BufferSize, defaultPipelineBufferSize)),
+		batchSize:      defaultInt(config.BatchSize, defaultPipelineBatchSize),
+		confirmTimeout: config.ConfirmTimeout,
+		maxAttempts:    defaultInt(config.MaxAttempts, defaultPipelineMaxAttempts),
+	}
+
+	if p.confirmTimeout <= 0 {
+		p.confirmTimeout = defaultPipelineConfirmTimeout
+	}
+
+	go p.run()
+
+	return p
+}
+
+// enqueue buffers the publishing, blocking while the buffer is full until the context is done.
+func (p *publishingPipeline) enqueue(ctx context.Context, publishing *pipelinedPublishing) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	p.pending.Add(1)
+
+	select {
+	case p.queue <- publishing:
+		return nil
+	case <-ctx.Done():
+		p.pending.Add(-1)
+
+		return ctx.Err()
+	case <-p.channel.ctx.Done():
+		p.pending.Add(-1)
+
+		return errChannelClosed
+	}
+}
+
+// flush waits for the buffered publishings to be confirmed or dropped, until the context is done, and returns their
+// number.
+func (p *publishingPipeline) flush(ctx context.Context) (int, error) {
+	count := int(p.pending.Load())
+
+	ticker := time.NewTicker(pipelineFlushInterval)
+	defer ticker.Stop()
+
+	for p.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return count, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return count, nil
+}
+
+// run sends the buffered publishings, taking up to batchSize of them at once, until the context of the channel is done.
+func (p *publishingPipeline) run() {
+	for {
+		var batch []*pipelinedPublishing
+
+		select {
+		case <-p.channel.ctx.Done():
+			return
+		case publishing := <-p.queue:
+			batch = append(batch, publishing)
+		}
+
+	fill:
+		for len(batch) < p.batchSize {
+			select {
+			case publishing := <-p.queue:
+				batch = append(batch, publishing)
+			default:
+				break fill
+			}
+		}
+
+		p.publish(batch)
+	}
+}
+
+// publish sends the batch until all its publishings are confirmed or dropped.
+func (p *publishingPipeline) publish(batch []*pipelinedPublishing) {
+	// Once a window is not fully confirmed, such as when a publishing to a missing exchange closed the channel, the
+	// publishings are sent one at a time, so that only the offending ones are charged an attempt.
+	isolated := false
+
+	for len(batch) > 0 {
+		if !p.channel.ready() {
+			// Without keepAlive, the channel will not be opened again.
+			if !p.channel.keepAlive {
+				p.drop(batch, errChannelClosed)
+
+				return
+			}
+
+			select {
+			case <-p.channel.ctx.Done():
+				p.drop(batch, errChannelClosed)
+
+				return
+			case <-time.After(p.channel.retryDelay.get()):
+			}
+
+			continue
+		}
+
+		window := batch
+		if isolated {
+			window = batch[:1]
+		}
+
+		unconfirmed := p.sendWindow(window)
+
+		if len(window) > 1 && len(unconfirmed) > 0 {
+			isolated = true
+		}
+
+		if len(window) == 1 {
+			for _, publishing := range unconfirmed {
+				publishing.attempts++
+			}
+		}
+
+		remaining := append(unconfirmed, batch[len(window):]...)
+
+		batch = nil
+
+		for _, publishing := range remaining {
+			if publishing.attempts >= p.maxAttempts {
+				p.drop([]*pipelinedPublishing{publishing}, errPublishingNotConfirmed)
+
+				continue
+			}
+
+			batch = append(batch, publishing)
+		}
+
+		if len(unconfirmed) > 0 && len(batch) > 0 {
+			p.channel.logger.Warn("Publishings not confirmed, sending them again", LogField{Key: "count", Value: len(batch)})
+		}
+	}
+}
+
+// sendWindow sends the publishings of the batch at once, waits for their confirmations and returns the unconfirmed
+// ones, in order. The confirmed publishings that were returned by the server are dropped.
+func (p *publishingPipeline) sendWindow(batch []*pipelinedPublishing) []*pipelinedPublishing {
+	channel := p.channel.channel.Load()
+	returns := p.channel.returns.Load()
+
+	confirmations := make([]*amqp.DeferredConfirmation, 0, len(batch))
+	sent := time.Now()
+
+	for _, publishing := range batch {
+		if p.channel.faults.dropPublish() {
+			p.channel.logger.Error(ErrInjectedFault, "Could not publish message", publishing.fields...)
+
+			break
+		}
+
+		msg := publishing.msg
+
+		confirmation, err := channel.PublishWithDeferredConfirmWithContext(p.channel.ctx, msg.Exchange, msg.RoutingKey, msg.Mandatory, false, msg.Msg)
+		if err != nil {
+			p.channel.logger.Error(err, "Could not publish message", publishing.fields...)
+
+			break
+		}
+
+		confirmations = append(confirmations, confirmation)
+	}
+
+	ctx, cancel := context.WithTimeout(p.channel.ctx, p.confirmTimeout)
+	defer cancel()
+
+	var unconfirmed []*pipelinedPublishing
+
+	for i, confirmation := range confirmations {
+		// The channel is in confirm mode, unless it was lost in the meantime.
+		if confirmation == nil {
+			unconfirmed = append(unconfirmed, batch[i])
+
+			continue
+		}
+
+		if acked, err := p.channel.faults.awaitConfirm(ctx, confirmation, sent); err != nil || !acked {
+			unconfirmed = append(unconfirmed, batch[i])
+
+			continue
+		}
+
+		// The server returns an unroutable mandatory publishing before confirming it.
+		if msg := batch[i].msg; msg.Mandatory && returns.returned(msg.Msg.MessageId) {
+			p.fail(batch[i], fmt.Errorf("%w: exchange '%s', routing key '%s'", ErrPublishingReturned, msg.Exchange, msg.RoutingKey), PublishOutcomeReturned)
+
+			continue
+		}
+
+		p.confirm(batch[i], confirmation)
+	}
+
+	return append(unconfirmed, batch[len(confirmations):]...)
+}
+
+// confirm completes a publishing confirmed by the server.
+func (p *publishingPipeline) confirm(publishing *pipelinedPublishing, confirmation *amqp.DeferredConfirmation) {
+	endSpan(publishing.span, nil)
+
+	p.channel.observePublish(*publishing.msg, PublishOutcomeSuccess)
+
+	p.channel.auditPublished(*publishing.msg, confirmation)
+
+	p.channel.logger.Debug(
+		"Message successfully sent",
+		append([]LogField{{Key: "messageID", Value: publishing.msg.Msg.MessageId}}, publishing.fields...)...,
+	)
+
+	releasePublishing(publishing.msg)
+
+	p.pending.Add(-1)
+}
+
+// drop completes the publishings that could not be confirmed.
+func (p *publishingPipeline) drop(batch []*pipelinedPublishing, err error) {
+	for _, publishing := range batch {
+		p.fail(publishing, err, PublishOutcomeFailure)
+	}
+}
+
+// fail completes a publishing that could not be published, with the given outcome.
+func (p *publishingPipeline) fail(publishing *pipelinedPublishing, err error, outcome PublishOutcome) {
+	endSpan(publishing.span, err)
+
+	p.channel.observePublish(*publishing.msg, outcome)
+
+	p.channel.releaseLogger.Error(
+		err,
+		"Publishing dropped",
+		append([]LogField{
+			{Key: "messageID", Value: publishing.msg.Msg.MessageId},
+			{Key: "exchange", Value: publishing.msg.Exchange},
+			{Key: "routingKey", Value: publishing.msg.RoutingKey},
+			{Key: "attempts", Value: publishing.attempts},
+		}, publishing.fields...)...,
+	)
+
+	releasePublishing(publishing.msg)
+
+	p.pending.Add(-1)
+}
+
+// flush waits for the buffered publishings of the publishing channel to be confirmed or dropped, and returns their
+// number.
+func (a *amqpConnection) flush(ctx context.Context) (int, error) {
+	channel := a.channelList().publishingChannel()
+	if channel == nil || channel.pipeline == nil {
+		return 0, nil
+	}
+
+	return channel.pipeline.flush(ctx)
+}
+
+// defaultInt returns the value if positive, the default value otherwise.
+func defaultInt(value, defaultValue int) int {
+	if value > 0 {
+		return value
+	}
+
+	return defaultValue
+}
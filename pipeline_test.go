@@ -0,0 +1,143 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClientOptions_SetPublishingPipeline(t *testing.T) {
+	options := gorabbit.NewClientOptions().SetPublishingPipeline(gorabbit.PublishingPipeline{BatchSize: 64})
+
+	require.NotNil(t, options.PublishingPipeline)
+	assert.Equal(t, 64, options.PublishingPipeline.BatchSize)
+	require.NoError(t, options.Validate())
+
+	options.SetPublishingPipeline(gorabbit.PublishingPipeline{BufferSize: -1})
+	require.Error(t, options.Validate())
+
+	options.SetPublishingPipeline(gorabbit.PublishingPipeline{ConfirmTimeout: -time.Second})
+	require.Error(t, options.Validate())
+}
+
+func TestClient_PublishingPipeline(t *testing.T) {
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetPublishingPipeline(gorabbit.PublishingPipeline{BufferSize: 1, BatchSize: 1}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	publish := func(timeout time.Duration) error {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		return client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", nil)
+	}
+
+	// Nothing listens on the port, yet the publishings are buffered: one held by the writer, one in the buffer.
+	require.NoError(t, publish(time.Second))
+	require.NoError(t, publish(time.Second))
+
+	// The buffer is full.
+	require.ErrorIs(t, publish(50*time.Millisecond), context.DeadlineExceeded)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	report, err := client.Drain(ctx)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 2, report.Published)
+}
+
+func newPipelinedClient(t *testing.T, server *fakeServer, recorder *publishRecorder, pipeline gorabbit.PublishingPipeline) gorabbit.MQTTClient {
+	t.Helper()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetRetryDelay(10 * time.Millisecond).
+		SetPublishingPipeline(pipeline).
+		SetMetrics(recorder))
+
+	t.Cleanup(func() { _ = client.Disconnect() })
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	return client
+}
+
+func TestClient_PublishingPipeline_MissingExchange(t *testing.T) {
+	server := newFakeServer(t)
+	recorder := &publishRecorder{}
+
+	// A single attempt is allowed, so a valid publishing charged for the closing of the channel would be dropped.
+	client := newPipelinedClient(t, server, recorder, gorabbit.PublishingPipeline{MaxAttempts: 1})
+
+	// Like a broker, the server closes the channel of a publishing to a missing exchange.
+	server.setOnPublish(func(publishing fakePublishing) fakeAction {
+		if publishing.Exchange == "missing_exchange" {
+			return fakeClose
+		}
+
+		return fakeAck
+	})
+
+	ctx := context.Background()
+
+	for i, payload := range []string{"first", "second", "missing", "third", "fourth"} {
+		exchange := "events_exchange"
+		if i == 2 {
+			exchange = "missing_exchange"
+		}
+
+		require.NoError(t, client.PublishWithContext(ctx, exchange, "event.created", payload, nil))
+	}
+
+	flushCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := client.Drain(flushCtx)
+	require.NoError(t, err)
+
+	// Only the publishing to the missing exchange is dropped.
+	assert.ElementsMatch(t, []string{`"first"`, `"second"`, `"third"`, `"fourth"`}, server.ackedBodies())
+	assert.Equal(t, 4, recorder.count(gorabbit.PublishOutcomeSuccess))
+	assert.Equal(t, 1, recorder.count(gorabbit.PublishOutcomeFailure))
+}
+
+func TestClient_PublishingPipeline_Returned(t *testing.T) {
+	server := newFakeServer(t)
+	recorder := &publishRecorder{}
+	client := newPipelinedClient(t, server, recorder, gorabbit.PublishingPipeline{})
+
+	server.setOnPublish(func(publishing fakePublishing) fakeAction {
+		if publishing.RoutingKey == "event.unroutable" {
+			return fakeReturn
+		}
+
+		return fakeAck
+	})
+
+	ctx := context.Background()
+	mandatory := gorabbit.SendOptions().SetMandatory()
+
+	require.NoError(t, client.PublishWithContext(ctx, "events_exchange", "event.unroutable", "payload", mandatory))
+	require.NoError(t, client.PublishWithContext(ctx, "events_exchange", "event.created", "payload", mandatory))
+
+	flushCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	_, err := client.Drain(flushCtx)
+	require.NoError(t, err)
+
+	// The returned publishing is confirmed by the server, yet it is not counted as published.
+	assert.Equal(t, 1, recorder.count(gorabbit.PublishOutcomeReturned))
+	assert.Equal(t, 1, recorder.count(gorabbit.PublishOutcomeSuccess))
+}
@@ -0,0 +1,128 @@
+package gorabbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// PoisonCapture is a snapshot of a delivery that was dead-lettered or discarded, for post-incident analysis.
+type PoisonCapture struct {
+	// Queue is the queue the delivery was consumed from.
+	Queue string `json:"queue"`
+
+	// Exchange is the exchange the message was published to.
+	Exchange string `json:"exchange"`
+
+	// RoutingKey is the original routing key of the message.
+	RoutingKey string `json:"routing_key"`
+
+	// MessageID is the unique identifier of the message.
+	MessageID string `json:"message_id"`
+
+	// Headers holds the message headers.
+	Headers map[string]interface{} `json:"headers"`
+
+	// Body is the raw message payload.
+	Body []byte `json:"body"`
+
+	// Decision is the AckDecision applied to the delivery.
+	Decision string `json:"decision"`
+
+	// Error is the error returned by the handler, if any.
+	Error string `json:"error,omitempty"`
+
+	// Attempts is the number of times the delivery was already attempted before this one.
+	Attempts uint `json:"attempts"`
+
+	// History holds the dead-lettering history of the message, from its x-death header.
+	History []map[string]interface{} `json:"history,omitempty"`
+
+	// CapturedAt is the time of the capture.
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// PoisonStore persists the PoisonCapture of dead-lettered and discarded deliveries (file, object storage, database...).
+// Implementations must be safe for concurrent use.
+type PoisonStore interface {
+	// Save persists the capture.
+	Save(ctx context.Context, capture PoisonCapture) error
+}
+
+// filePoisonStore is a PoisonStore writing each capture as a JSON file.
+type filePoisonStore struct {
+	dir string
+}
+
+// NewFilePoisonStore returns a PoisonStore writing each capture as a JSON file in the given directory, created if needed.
+func NewFilePoisonStore(dir string) PoisonStore {
+	return &filePoisonStore{dir: dir}
+}
+
+func (s *filePoisonStore) Save(_ context.Context, capture PoisonCapture) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(capture, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	id := capture.MessageID
+	if id == "" {
+		id = "unknown"
+	}
+
+	name := fmt.Sprintf("%s_%s_%d.json", filepath.Base(capture.Queue), filepath.Base(id), capture.CapturedAt.UnixNano())
+
+	return os.WriteFile(filepath.Join(s.dir, name), data, 0o600)
+}
+
+// newPoisonCapture builds the PoisonCapture of a delivery.
+func newPoisonCapture(queue string, delivery *amqp.Delivery, decision AckDecision, reason error) PoisonCapture {
+	capture := PoisonCapture{
+		Queue:      queue,
+		Exchange:   delivery.Exchange,
+		RoutingKey: originalRoutingKey(delivery),
+		MessageID:  delivery.MessageId,
+		Headers:    delivery.Headers,
+		Body:       delivery.Body,
+		Decision:   decision.String(),
+		Attempts:   deliveryAttempts(delivery, queue),
+		CapturedAt: time.Now(),
+	}
+
+	if reason != nil {
+		capture.Error = reason.Error()
+	}
+
+	if deaths, ok := delivery.Headers[xDeathHeader].([]interface{}); ok {
+		for _, death := range deaths {
+			if table, isTable := death.(amqp.Table); isTable {
+				capture.History = append(capture.History, table)
+			}
+		}
+	}
+
+	return capture
+}
+
+// capturePoison persists the capture of a dead-lettered or discarded delivery if the consumer defines a PoisonStore.
+// The capture is best-effort and never prevents the delivery from being settled.
+func (c *amqpChannel) capturePoison(delivery *amqp.Delivery, decision AckDecision, reason error) {
+	if c.consumer.PoisonStore == nil {
+		return
+	}
+
+	capture := newPoisonCapture(c.consumer.Queue, delivery, decision, reason)
+
+	if err := c.consumer.PoisonStore.Save(c.consumptionContext(), capture); err != nil {
+		c.logger.Error(err, "Could not capture poison message", LogField{Key: "messageID", Value: delivery.MessageId})
+	}
+}
@@ -0,0 +1,52 @@
+package gorabbit_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestFilePoisonStore_Save(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "poison")
+
+	store := gorabbit.NewFilePoisonStore(dir)
+
+	capture := gorabbit.PoisonCapture{
+		Queue:      "events_queue",
+		RoutingKey: "event.foo.bar.created",
+		MessageID:  "message-id",
+		Headers:    map[string]interface{}{"x-retry-attempt": float64(2)},
+		Body:       []byte(`{"foo":"bar"}`),
+		Decision:   gorabbit.AckDecisionDeadLetter.String(),
+		Error:      "failure",
+		Attempts:   2,
+		CapturedAt: time.Now().UTC(),
+	}
+
+	require.NoError(t, store.Save(context.Background(), capture))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+
+	var saved gorabbit.PoisonCapture
+
+	require.NoError(t, json.Unmarshal(data, &saved))
+
+	assert.Equal(t, capture.MessageID, saved.MessageID)
+	assert.Equal(t, capture.Body, saved.Body)
+	assert.Equal(t, capture.Error, saved.Error)
+	assert.Equal(t, capture.Headers, saved.Headers)
+	assert.True(t, capture.CapturedAt.Equal(saved.CapturedAt))
+}
@@ -0,0 +1,136 @@
+package gorabbit
+
+import (
+	"container/heap"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// PrioritySchedulingConfig enables the processing of the prefetched deliveries by order of Priority, highest first,
+// across a fixed number of workers. Deliveries with the same priority are processed in their order of arrival.
+// The PrefetchCount should be greater than the number of workers, so that a backlog can be reordered. With AckModeAuto,
+// the backlog is not bounded by the PrefetchCount.
+type PrioritySchedulingConfig struct {
+	// Workers is the number of concurrent workers.
+	Workers int
+}
+
+// prioritizedDelivery is a delivery waiting in the priorityDispatcher.
+type prioritizedDelivery struct {
+	delivery *amqp.Delivery
+
+	// sequence is the order of arrival of the delivery.
+	sequence uint64
+}
+
+// deliveryHeap is a heap of deliveries, highest priority first then by order of arrival.
+type deliveryHeap []prioritizedDelivery
+
+func (h deliveryHeap) Len() int {
+	return len(h)
+}
+
+func (h deliveryHeap) Less(i, j int) bool {
+	if h[i].delivery.Priority != h[j].delivery.Priority {
+		return h[i].delivery.Priority > h[j].delivery.Priority
+	}
+
+	return h[i].sequence < h[j].sequence
+}
+
+func (h deliveryHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *deliveryHeap) Push(x interface{}) {
+	*h = append(*h, x.(prioritizedDelivery))
+}
+
+func (h *deliveryHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+
+	return item
+}
+
+// priorityDispatcher dispatches deliveries to workers by order of priority.
+type priorityDispatcher struct {
+	// pending holds the deliveries waiting for a worker.
+	pending deliveryHeap
+
+	// sequence is the order of arrival of the last delivery.
+	sequence uint64
+
+	// closed is true once the dispatcher is closed.
+	closed bool
+
+	// mutex protects the properties above.
+	mutex sync.Mutex
+
+	// available signals the workers that a delivery is pending or that the dispatcher is closed.
+	available *sync.Cond
+}
+
+// newPriorityDispatcher instantiates a new priorityDispatcher and launches its workers, processing deliveries with the
+// given process function until the dispatcher is closed.
+func newPriorityDispatcher(config PrioritySchedulingConfig, process func(delivery *amqp.Delivery)) *priorityDispatcher {
+	dispatcher := &priorityDispatcher{}
+	dispatcher.available = sync.NewCond(&dispatcher.mutex)
+
+	for i := 0; i < config.Workers; i++ {
+		go func() {
+			for {
+				delivery, ok := dispatcher.next()
+				if !ok {
+					return
+				}
+
+				process(delivery)
+			}
+		}()
+	}
+
+	return dispatcher
+}
+
+// dispatch queues the delivery for the next available worker. The number of pending deliveries is bounded by the
+// consumer's prefetch count, unless the deliveries are automatically acknowledged.
+func (d *priorityDispatcher) dispatch(delivery *amqp.Delivery) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.sequence++
+
+	heap.Push(&d.pending, prioritizedDelivery{delivery: delivery, sequence: d.sequence})
+
+	d.available.Signal()
+}
+
+// next waits for the pending delivery with the highest priority. Returns false once the dispatcher is closed and all
+// pending deliveries were handed over.
+func (d *priorityDispatcher) next() (*amqp.Delivery, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for len(d.pending) == 0 {
+		if d.closed {
+			return nil, false
+		}
+
+		d.available.Wait()
+	}
+
+	return heap.Pop(&d.pending).(prioritizedDelivery).delivery, true
+}
+
+// close stops the workers once the pending deliveries are processed.
+func (d *priorityDispatcher) close() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.closed = true
+
+	d.available.Broadcast()
+}
@@ -0,0 +1,128 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_PriorityScheduling(t *testing.T) {
+	server := newFakeServer(t)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	processed := make(chan string, 10)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		PrefetchCount:      10,
+		PriorityScheduling: &gorabbit.PrioritySchedulingConfig{Workers: 1},
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				// The first delivery holds the only worker, so that the next ones pile up.
+				if string(payload) == "blocking" {
+					close(started)
+					<-release
+				}
+
+				processed <- string(payload)
+
+				return nil
+			},
+		},
+	})
+
+	server.deliver("events", "event.created", "blocking")
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		require.FailNow(t, "delivery not handled")
+	}
+
+	server.deliverMessages("events",
+		fakeDelivery{RoutingKey: "event.created", Body: "low", Priority: 1},
+		fakeDelivery{RoutingKey: "event.created", Body: "high", Priority: 9},
+		fakeDelivery{RoutingKey: "event.created", Body: "none"},
+		fakeDelivery{RoutingKey: "event.created", Body: "medium", Priority: 5},
+		fakeDelivery{RoutingKey: "event.created", Body: "high again", Priority: 9},
+	)
+
+	// The deliveries are prefetched while the worker is held.
+	time.Sleep(50 * time.Millisecond)
+
+	close(release)
+
+	// The pending deliveries are processed highest priority first, then in their order of arrival.
+	assert.Equal(t, []string{"blocking", "high", "high again", "medium", "low", "none"}, receiveAll(t, processed, 6))
+}
+
+func TestClient_PriorityScheduling_Invalid(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().SetHost("127.0.0.1").SetPort(server.port()))
+
+	defer client.Disconnect()
+
+	handlers := gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }}
+
+	for _, consumer := range []gorabbit.MessageConsumer{
+		{PriorityScheduling: &gorabbit.PrioritySchedulingConfig{}},
+		{
+			PriorityScheduling: &gorabbit.PrioritySchedulingConfig{Workers: 2},
+			Ordering:           &gorabbit.OrderingConfig{Workers: 2},
+		},
+	} {
+		consumer.Queue = "events"
+		consumer.Name = "events"
+		consumer.Handlers = handlers
+
+		assert.Error(t, client.RegisterConsumer(consumer))
+	}
+}
+
+func TestClient_Publish_DeclaredQueuePriority(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	ctx := context.Background()
+
+	_, err := client.SetupTopology(ctx, nil, []gorabbit.QueueConfig{
+		{Name: "jobs", Durable: true, MaxPriority: 3},
+	})
+	require.NoError(t, err)
+
+	t.Run("in range", func(t *testing.T) {
+		assert.NoError(t, client.PublishWithContext(ctx, "", "jobs", "low", gorabbit.SendOptions().SetPriority(gorabbit.PriorityLowest)))
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		err := client.PublishWithContext(ctx, "", "jobs", "high", gorabbit.SendOptions().SetPriority(gorabbit.PriorityHigh))
+		assert.ErrorIs(t, err, gorabbit.ErrPriorityOutOfRange)
+	})
+
+	t.Run("unknown queue", func(t *testing.T) {
+		assert.NoError(t, client.PublishWithContext(ctx, "", "reports", "high", gorabbit.SendOptions().SetPriority(gorabbit.PriorityHighest)))
+	})
+
+	// Only the publishings within the range of known queues reach the broker.
+	require.Eventually(t, func() bool { return server.receivedCount() == 2 }, time.Second, 10*time.Millisecond)
+
+	var bodies []string
+	for _, publishing := range server.publishings() {
+		bodies = append(bodies, publishing.Body)
+	}
+
+	assert.ElementsMatch(t, []string{`"low"`, `"high"`}, bodies)
+}
@@ -0,0 +1,37 @@
+package gorabbit
+
+import (
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// publishingPool holds the publishings no longer referenced, along with their header tables, so that the publish path
+// reuses them instead of allocating new ones for every message.
+var publishingPool = sync.Pool{
+	New: func() interface{} {
+		return &mqttPublishing{Msg: amqp.Publishing{Headers: amqp.Table{}}}
+	},
+}
+
+// acquirePublishing returns an empty publishing with an empty header table, to be released once sent.
+func acquirePublishing() *mqttPublishing {
+	return publishingPool.Get().(*mqttPublishing)
+}
+
+// releasePublishing returns the publishing to the pool. It must not be referenced anymore, by the publishing cache for
+// instance, which keeps a copy sharing its header table.
+func releasePublishing(publishing *mqttPublishing) {
+	headers := publishing.Msg.Headers
+
+	// The tables grown by many headers are left to the garbage collector, since clearing a map does not shrink it.
+	if len(headers) > maxPooledPublishingHeaders {
+		return
+	}
+
+	clear(headers)
+
+	*publishing = mqttPublishing{Msg: amqp.Publishing{Headers: headers}}
+
+	publishingPool.Put(publishing)
+}
@@ -0,0 +1,99 @@
+package gorabbit_test
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// tenantOptions returns the options publishing with the tenant as header.
+func tenantOptions(tenant string) *gorabbit.PublishingOptions {
+	return gorabbit.SendOptions().SetHeaderHashKey("x-tenant", tenant)
+}
+
+func TestClient_Publish_ReusedHeaders(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(tenant string) {
+			defer wg.Done()
+
+			assert.NoError(t, client.PublishWithContext(ctx, "events_exchange", "event.created", tenant, tenantOptions(tenant)))
+		}(strconv.Itoa(i))
+	}
+
+	wg.Wait()
+
+	require.NoError(t, client.Publish("events_exchange", "event.created", "none"))
+
+	require.Eventually(t, func() bool { return server.receivedCount() == 51 }, time.Second, 10*time.Millisecond)
+
+	// Each publishing carries its own headers, none leaking into the next publishings.
+	publishings := server.publishings()
+	require.Len(t, publishings, 51)
+
+	for _, publishing := range publishings[:50] {
+		assert.Equal(t, `"`+publishing.Headers["x-tenant"].(string)+`"`, publishing.Body)
+	}
+
+	assert.NotContains(t, publishings[50].Headers, "x-tenant")
+}
+
+func TestClient_Publish_CachedHeaders(t *testing.T) {
+	server := newFakeServer(t)
+	faults := gorabbit.NewFaultInjector()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetRetryDelay(10 * time.Millisecond).
+		SetFaultInjector(faults))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.Eventually(t, client.IsReady, time.Second, 10*time.Millisecond)
+
+	ctx := context.Background()
+
+	// The dropped publishing is cached, and keeps its headers while the next publishings reuse theirs.
+	faults.DropPublishes(1)
+
+	require.Error(t, client.PublishWithContext(ctx, "events_exchange", "event.created", "cached", tenantOptions("acme")))
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, client.PublishWithContext(ctx, "events_exchange", "event.created", "sent", tenantOptions(strconv.Itoa(i))))
+	}
+
+	require.Eventually(t, func() bool { return server.receivedCount() == 10 }, time.Second, 10*time.Millisecond)
+
+	// The cached publishing is sent once the channel is recovered.
+	require.Positive(t, faults.CloseChannels())
+
+	require.Eventually(t, func() bool { return server.receivedCount() == 11 }, time.Second, 10*time.Millisecond)
+
+	cached := server.publishings()[10]
+
+	assert.Equal(t, `"cached"`, cached.Body)
+	assert.Equal(t, "acme", cached.Headers["x-tenant"])
+}
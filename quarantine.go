@@ -0,0 +1,178 @@
+package gorabbit
+
+import (
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// quarantineQueueName returns the name of the quarantine queue of a consumer, defaulting to "<queue>.parking-lot".
+func (c MessageConsumer) quarantineQueueName() string {
+	if c.QuarantineQueue != "" {
+		return c.QuarantineQueue
+	}
+
+	return fmt.Sprintf("%s.parking-lot", c.Queue)
+}
+
+// hasQuarantine returns true if failed deliveries should be parked in a quarantine queue.
+func (c MessageConsumer) hasQuarantine() bool {
+	return c.MaxDeliveryAttempts > 0 || c.QuarantineQueue != ""
+}
+
+// deliveryAttempts returns the number of times a delivery was already attempted, based on the x-death header, the
+// quorum queue x-delivery-count header and the retry header, whichever is highest.
+func deliveryAttempts(delivery *amqp.Delivery, queue string) uint {
+	attempts := retryAttempt(delivery)
+
+	if count := headerCount(delivery.Headers[xDeliveryCountHeader]); count > attempts {
+		attempts = count
+	}
+
+	deaths, ok := delivery.Headers[xDeathHeader].([]interface{})
+	if !ok {
+		return attempts
+	}
+
+	var deathCount uint
+
+	for _, death := range deaths {
+		table, isTable := death.(amqp.Table)
+		if !isTable {
+			continue
+		}
+
+		if deathQueue, _ := table["queue"].(string); deathQueue != queue {
+			continue
+		}
+
+		deathCount += headerCount(table["count"])
+	}
+
+	if deathCount > attempts {
+		attempts = deathCount
+	}
+
+	return attempts
+}
+
+// headerCount converts a numeric header value into an uint.
+func headerCount(value interface{}) uint {
+	switch v := value.(type) {
+	case int64:
+		return uint(v)
+	case int32:
+		return uint(v)
+	case int:
+		return uint(v)
+	default:
+		return 0
+	}
+}
+
+// declareQuarantineQueue declares, if not already done, the quarantine queue of the consumer.
+func (c *amqpChannel) declareQuarantineQueue() (string, error) {
+	name := c.consumer.quarantineQueueName()
+
+	c.declaredQueuesMutex.Lock()
+	defer c.declaredQueuesMutex.Unlock()
+
+	if c.declaredQueues[name] {
+		return name, nil
+	}
+
+	_, err := c.channel.Load().QueueDeclare(
+		name,  // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		nil,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	c.declaredQueues[name] = true
+
+	return name, nil
+}
+
+// deadLetter gives up on a delivery. If a quarantine is configured, the delivery is copied to the quarantine queue
+// along with the reason of its failure, otherwise it is negative acknowledged without requeue.
+func (c *amqpChannel) deadLetter(delivery *amqp.Delivery, alreadyAcknowledged bool, reason error) {
+	c.capturePoison(delivery, AckDecisionDeadLetter, reason)
+
+	if !c.consumer.hasQuarantine() {
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, false)
+		}
+
+		return
+	}
+
+	queue, err := c.declareQuarantineQueue()
+	if err != nil {
+		c.logger.Error(err, "Could not declare quarantine queue")
+
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, true)
+		}
+
+		return
+	}
+
+	headers := amqp.Table{}
+
+	for k, v := range delivery.Headers {
+		headers[k] = v
+	}
+
+	headers[xOriginalQueueHeader] = c.consumer.Queue
+	headers[xOriginalExchangeHeader] = delivery.Exchange
+	headers[xOriginalRoutingKeyHeader] = originalRoutingKey(delivery)
+
+	if reason != nil {
+		headers[xQuarantineReasonHeader] = reason.Error()
+	}
+
+	var decodeErr *DecodeError
+
+	if errors.As(reason, &decodeErr) {
+		headers[xDecodeErrorHeader] = decodeErr.Error()
+	}
+
+	publishing := amqp.Publishing{
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		Body:            delivery.Body,
+		Type:            delivery.Type,
+		Priority:        delivery.Priority,
+		DeliveryMode:    Persistent.Uint8(),
+		MessageId:       delivery.MessageId,
+		CorrelationId:   delivery.CorrelationId,
+		Timestamp:       delivery.Timestamp,
+		Headers:         headers,
+	}
+
+	err = c.channel.Load().PublishWithContext(c.ctx, "", queue, false, false, publishing)
+	if err != nil {
+		c.logger.Error(err, "Could not publish delivery to quarantine queue", LogField{Key: "queue", Value: queue})
+
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, true)
+		}
+
+		return
+	}
+
+	c.releaseLogger.Warn("Delivery quarantined",
+		LogField{Key: "messageID", Value: delivery.MessageId},
+		LogField{Key: "quarantineQueue", Value: queue},
+	)
+
+	if !alreadyAcknowledged {
+		_ = delivery.Ack(false)
+	}
+}
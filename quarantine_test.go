@@ -0,0 +1,111 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// newConsumingClient returns a client consuming the queue "events" of the server with the consumer, its handlers
+// receiving the routing key "event.created" unless it defines them.
+func newConsumingClient(t *testing.T, server *fakeServer, consumer gorabbit.MessageConsumer) gorabbit.MQTTClient {
+	t.Helper()
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetRetryDelay(10 * time.Millisecond))
+
+	t.Cleanup(func() { _ = client.Disconnect() })
+
+	if consumer.Queue == "" {
+		consumer.Queue = "events"
+	}
+
+	if consumer.Name == "" {
+		consumer.Name = consumer.Queue
+	}
+
+	if consumer.Handlers == nil && consumer.ContextHandlers == nil {
+		consumer.Handlers = gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }}
+	}
+
+	require.NoError(t, client.RegisterConsumer(consumer))
+	require.Eventually(t, func() bool { return server.consumed(consumer.Queue) }, time.Second, 10*time.Millisecond)
+
+	return client
+}
+
+func TestClient_Quarantine_MaxDeliveryAttempts(t *testing.T) {
+	server := newFakeServer(t)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		MaxDeliveryAttempts: 3,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func([]byte) error { return errors.New("downstream unavailable") },
+		},
+	})
+
+	// The first delivery has attempts left, the second one was delivered twice already by a quorum queue.
+	server.deliverMessages("events",
+		fakeDelivery{RoutingKey: "event.created", Body: "first"},
+		fakeDelivery{RoutingKey: "event.created", Body: "second", Headers: amqp.Table{"x-delivery-count": int64(2)}},
+	)
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 2 }, time.Second, 10*time.Millisecond)
+
+	// The first delivery is retried, rejected without a retry header, while the second one is parked then acknowledged.
+	assert.ElementsMatch(t, []fakeSettlement{{Body: "first"}, {Body: "second", Acked: true}}, server.settled())
+
+	publishings := server.publishings()
+	require.Len(t, publishings, 1)
+
+	assert.Equal(t, "events.parking-lot", publishings[0].RoutingKey)
+	assert.Equal(t, "second", publishings[0].Body)
+	assert.Equal(t, "events", publishings[0].Headers["x-original-queue"])
+	assert.Equal(t, "event.created", publishings[0].Headers["x-original-routing-key"])
+	assert.Equal(t, "downstream unavailable", publishings[0].Headers["x-quarantine-reason"])
+}
+
+func TestClient_Quarantine_DeathCount(t *testing.T) {
+	server := newFakeServer(t)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		MaxDeliveryAttempts: 3,
+		QuarantineQueue:     "events.quarantine",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func([]byte) error { return errors.New("downstream unavailable") },
+		},
+	})
+
+	// Only the deaths of the consumed queue count towards the attempts.
+	server.deliverMessages("events",
+		fakeDelivery{
+			RoutingKey: "event.created",
+			Body:       "retried",
+			Headers: amqp.Table{"x-death": []interface{}{
+				amqp.Table{"queue": "events", "count": int64(1)},
+				amqp.Table{"queue": "events.retry", "count": int64(5)},
+			}},
+		},
+		fakeDelivery{
+			RoutingKey: "event.created",
+			Body:       "dead-lettered",
+			Headers:    amqp.Table{"x-death": []interface{}{amqp.Table{"queue": "events", "count": int64(2)}}},
+		},
+	)
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 2 }, time.Second, 10*time.Millisecond)
+
+	publishings := server.publishings()
+	require.Len(t, publishings, 1)
+
+	assert.Equal(t, "events.quarantine", publishings[0].RoutingKey)
+	assert.Equal(t, "dead-lettered", publishings[0].Body)
+}
@@ -0,0 +1,195 @@
+package gorabbit
+
+import (
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Queue arguments.
+const (
+	argQueueType              = "x-queue-type"
+	argDeliveryLimit          = "x-delivery-limit"
+	argDeadLetterStrategy     = "x-dead-letter-strategy"
+	argQuorumInitialGroupSize = "x-quorum-initial-group-size"
+	argOverflow               = "x-overflow"
+	argSingleActiveConsumer   = "x-single-active-consumer"
+	argMessageTTL             = "x-message-ttl"
+	argDeadLetterExchange     = "x-dead-letter-exchange"
+	argDeadLetterRoutingKey   = "x-dead-letter-routing-key"
+	argMaxLength              = "x-max-length"
+	argMaxLengthBytes         = "x-max-length-bytes"
+	argMaxPriority            = "x-max-priority"
+	argExpires                = "x-expires"
+	argQueueMode              = "x-queue-mode"
+)
+
+// isQuorum returns true if the queue is declared as a quorum queue, either via its Type or its arguments.
+func (q QueueConfig) isQuorum() bool {
+	return q.queueType() == QueueTypeQuorum
+}
+
+// queueType returns the queue type, either from its Type or its arguments.
+func (q QueueConfig) queueType() QueueType {
+	if q.Type != "" {
+		return q.Type
+	}
+
+	if queueType, ok := q.Args[argQueueType].(string); ok {
+		return QueueType(queueType)
+	}
+
+	return ""
+}
+
+// Validate verifies that the queue properties and arguments are compatible with each other.
+//
+//nolint:gocognit // We can allow the current complexity for now but we should revisit it later.
+func (q QueueConfig) Validate() error {
+	switch q.queueType() {
+	case "", QueueTypeClassic, QueueTypeQuorum, QueueTypeStream:
+	default:
+		return fmt.Errorf("the queue '%s' has an unknown type '%s'", q.Name, q.queueType())
+	}
+
+	if q.queueType() == QueueTypeStream {
+		if q.MaxPriority > 0 {
+			return fmt.Errorf("the stream queue '%s' cannot have a max priority", q.Name)
+		}
+
+		if q.MaxLength > 0 || q.Overflow != "" {
+			return fmt.Errorf("the stream queue '%s' cannot have a max length or an overflow behavior, only a max length in bytes", q.Name)
+		}
+
+		if !q.Durable {
+			return fmt.Errorf("the stream queue '%s' must be durable", q.Name)
+		}
+
+		if q.Exclusive {
+			return fmt.Errorf("the stream queue '%s' cannot be exclusive", q.Name)
+		}
+	}
+
+	if q.MaxLength < 0 || q.MaxLengthBytes < 0 {
+		return fmt.Errorf("the queue '%s' cannot have a negative max length", q.Name)
+	}
+
+	switch q.Overflow {
+	case "", OverflowDropHead, OverflowRejectPublish, OverflowRejectPublishDLX:
+	default:
+		return fmt.Errorf("the queue '%s' has an unknown overflow behavior '%s'", q.Name, q.Overflow)
+	}
+
+	for _, binding := range q.Bindings {
+		if err := binding.Validate(); err != nil {
+			return fmt.Errorf("the queue '%s' has an invalid binding: %w", q.Name, err)
+		}
+	}
+
+	if !q.isQuorum() {
+		if q.DeliveryLimit != 0 || q.DeadLetterStrategy != "" || q.InitialGroupSize != 0 {
+			return fmt.Errorf("the queue '%s' uses quorum queue properties but is not a quorum queue", q.Name)
+		}
+
+		return nil
+	}
+
+	if !q.Durable {
+		return fmt.Errorf("the quorum queue '%s' must be durable", q.Name)
+	}
+
+	if q.Exclusive {
+		return fmt.Errorf("the quorum queue '%s' cannot be exclusive", q.Name)
+	}
+
+	if q.DeliveryLimit < 0 {
+		return fmt.Errorf("the quorum queue '%s' cannot have a negative delivery limit", q.Name)
+	}
+
+	if q.InitialGroupSize < 0 {
+		return fmt.Errorf("the quorum queue '%s' cannot have a negative initial group size", q.Name)
+	}
+
+	if q.overflow() == OverflowRejectPublishDLX {
+		return fmt.Errorf("the quorum queue '%s' does not support the 'reject-publish-dlx' overflow", q.Name)
+	}
+
+	switch q.DeadLetterStrategy {
+	case "", DeadLetterStrategyAtMostOnce:
+	case DeadLetterStrategyAtLeastOnce:
+		// The at-least-once dead-lettering strategy is only supported by the broker with the reject-publish overflow.
+		if q.overflow() != OverflowRejectPublish {
+			return fmt.Errorf("the quorum queue '%s' must use the 'reject-publish' overflow with the at-least-once dead letter strategy", q.Name)
+		}
+	default:
+		return fmt.Errorf("the quorum queue '%s' has an unknown dead letter strategy '%s'", q.Name, q.DeadLetterStrategy)
+	}
+
+	return nil
+}
+
+// overflow returns the overflow behavior, either from its Overflow or its arguments.
+func (q QueueConfig) overflow() OverflowBehavior {
+	if q.Overflow != "" {
+		return q.Overflow
+	}
+
+	overflow, _ := q.Args[argOverflow].(string)
+
+	return OverflowBehavior(overflow)
+}
+
+// arguments returns the queue arguments, merging the raw Args with the typed properties. Returns an error wrapping
+// ErrInvalidArgument if a raw argument cannot be represented in an AMQP field table.
+func (q QueueConfig) arguments() (amqp.Table, error) {
+	args, err := tableArguments(q.Args)
+	if err != nil {
+		return nil, err
+	}
+
+	if args == nil {
+		args = amqp.Table{}
+	}
+
+	if q.Type != "" {
+		args[argQueueType] = q.Type.String()
+	}
+
+	if q.DeliveryLimit > 0 {
+		args[argDeliveryLimit] = int64(q.DeliveryLimit)
+	}
+
+	if q.DeadLetterStrategy != "" {
+		args[argDeadLetterStrategy] = q.DeadLetterStrategy.String()
+	}
+
+	if q.InitialGroupSize > 0 {
+		args[argQuorumInitialGroupSize] = int64(q.InitialGroupSize)
+	}
+
+	if q.SingleActiveConsumer {
+		args[argSingleActiveConsumer] = true
+	}
+
+	if q.MaxPriority > 0 {
+		args[argMaxPriority] = int64(q.MaxPriority)
+	}
+
+	if q.MaxLength > 0 {
+		args[argMaxLength] = q.MaxLength
+	}
+
+	if q.MaxLengthBytes > 0 {
+		args[argMaxLengthBytes] = q.MaxLengthBytes
+	}
+
+	if q.Overflow != "" {
+		args[argOverflow] = q.Overflow.String()
+	}
+
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	return args, nil
+}
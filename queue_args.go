@@ -0,0 +1,121 @@
+package gorabbit
+
+import (
+	"fmt"
+	"time"
+)
+
+// queueModeLazy is the x-queue-mode of classic queues keeping their messages on disk.
+const queueModeLazy = "lazy"
+
+// QueueArgs is the builder of the common queue arguments, producing validated QueueConfig.Args without raw x-* keys.
+// The first invalid value is reported by Build.
+type QueueArgs struct {
+	args map[string]interface{}
+	err  error
+}
+
+// NewQueueArgs is the exported builder for QueueArgs.
+func NewQueueArgs() *QueueArgs {
+	return &QueueArgs{args: make(map[string]interface{})}
+}
+
+// fail records the first invalid value.
+func (a *QueueArgs) fail(format string, values ...interface{}) *QueueArgs {
+	if a.err == nil {
+		a.err = fmt.Errorf(format, values...)
+	}
+
+	return a
+}
+
+// SetMessageTTL will assign the time after which messages are discarded, or dead-lettered, with millisecond precision.
+func (a *QueueArgs) SetMessageTTL(ttl time.Duration) *QueueArgs {
+	if ttl < 0 {
+		return a.fail("the message TTL cannot be negative: %s", ttl)
+	}
+
+	a.args[argMessageTTL] = ttl.Milliseconds()
+
+	return a
+}
+
+// SetMaxLength will assign the maximum number of ready messages in the queue.
+func (a *QueueArgs) SetMaxLength(length int64) *QueueArgs {
+	if length < 0 {
+		return a.fail("the max length cannot be negative: %d", length)
+	}
+
+	a.args[argMaxLength] = length
+
+	return a
+}
+
+// SetMaxLengthBytes will assign the maximum total size of the ready messages' bodies in the queue.
+func (a *QueueArgs) SetMaxLengthBytes(size int64) *QueueArgs {
+	if size < 0 {
+		return a.fail("the max length bytes cannot be negative: %d", size)
+	}
+
+	a.args[argMaxLengthBytes] = size
+
+	return a
+}
+
+// SetOverflow will assign the behavior of the queue once its max length is reached.
+func (a *QueueArgs) SetOverflow(overflow OverflowBehavior) *QueueArgs {
+	switch overflow {
+	case OverflowDropHead, OverflowRejectPublish, OverflowRejectPublishDLX:
+	default:
+		return a.fail("unknown overflow behavior '%s'", overflow)
+	}
+
+	a.args[argOverflow] = overflow.String()
+
+	return a
+}
+
+// SetLazy will make a classic queue keep its messages on disk, loading them in memory only when needed.
+func (a *QueueArgs) SetLazy() *QueueArgs {
+	a.args[argQueueMode] = queueModeLazy
+
+	return a
+}
+
+// SetMaxPriority will enable the priorities on the queue, from 0 up to the given maximum, at most 255.
+// The broker recommends a maximum of 10.
+func (a *QueueArgs) SetMaxPriority(priority uint8) *QueueArgs {
+	if priority == 0 {
+		return a.fail("the max priority must be between 1 and 255")
+	}
+
+	a.args[argMaxPriority] = int64(priority)
+
+	return a
+}
+
+// SetExpires will assign the time after which the queue is deleted if unused, with millisecond precision.
+func (a *QueueArgs) SetExpires(expires time.Duration) *QueueArgs {
+	if expires < time.Millisecond {
+		return a.fail("the queue expiry must be at least 1ms: %s", expires)
+	}
+
+	a.args[argExpires] = expires.Milliseconds()
+
+	return a
+}
+
+// Build returns the queue arguments, or the error of the first invalid value.
+func (a *QueueArgs) Build() (map[string]interface{}, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+
+	args := make(map[string]interface{}, len(a.args))
+
+	for k, v := range a.args {
+		args[k] = v
+	}
+
+	return args, nil
+}
@@ -0,0 +1,58 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestQueueArgs_Build(t *testing.T) {
+	args, err := gorabbit.NewQueueArgs().
+		SetMessageTTL(time.Minute).
+		SetMaxLength(1000).
+		SetMaxLengthBytes(1 << 20).
+		SetOverflow(gorabbit.OverflowRejectPublishDLX).
+		SetLazy().
+		SetMaxPriority(10).
+		SetExpires(time.Hour).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]interface{}{
+		"x-message-ttl":      int64(60000),
+		"x-max-length":       int64(1000),
+		"x-max-length-bytes": int64(1 << 20),
+		"x-overflow":         "reject-publish-dlx",
+		"x-queue-mode":       "lazy",
+		"x-max-priority":     int64(10),
+		"x-expires":          int64(3600000),
+	}, args)
+}
+
+func TestQueueArgs_Build_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		args *gorabbit.QueueArgs
+	}{
+		{name: "negative TTL", args: gorabbit.NewQueueArgs().SetMessageTTL(-time.Second)},
+		{name: "negative max length", args: gorabbit.NewQueueArgs().SetMaxLength(-1)},
+		{name: "negative max length bytes", args: gorabbit.NewQueueArgs().SetMaxLengthBytes(-1)},
+		{name: "unknown overflow", args: gorabbit.NewQueueArgs().SetOverflow("drop-tail")},
+		{name: "zero max priority", args: gorabbit.NewQueueArgs().SetMaxPriority(0)},
+		{name: "zero expiry", args: gorabbit.NewQueueArgs().SetExpires(0)},
+		{name: "first error kept", args: gorabbit.NewQueueArgs().SetMaxLength(-1).SetMessageTTL(time.Second)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := test.args.Build()
+
+			assert.Error(t, err)
+			assert.Nil(t, args)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package gorabbit
+
+import (
+	"time"
+)
+
+// QueueMonitorConfig enables the periodical inspection of the consumed queue, to expose its depth and consumer count.
+type QueueMonitorConfig struct {
+	// Interval is the delay between two inspections. Defaults to 30 seconds.
+	Interval time.Duration
+
+	// BacklogThreshold is the number of ready messages above which the queue is reported as backlogged.
+	// The backlog is never reported if set to 0.
+	BacklogThreshold int
+
+	// OnStats is called, if set, after each inspection. It can be used to feed metrics, for autoscaling for instance.
+	OnStats func(stats QueueStats)
+}
+
+// QueueStats holds the result of a queue inspection.
+type QueueStats struct {
+	// Queue is the name of the inspected queue.
+	Queue string
+
+	// Consumer is the name of the consumer monitoring the queue.
+	Consumer string
+
+	// Messages is the number of messages ready to be delivered.
+	Messages int
+
+	// Consumers is the number of consumers subscribed to the queue.
+	Consumers int
+
+	// Backlogged is true if Messages exceeds the BacklogThreshold.
+	Backlogged bool
+
+	// CheckedAt is the time of the inspection.
+	CheckedAt time.Time
+}
+
+// monitorQueue periodically inspects the consumed queue until the consumption stops.
+func (c *amqpChannel) monitorQueue() {
+	config := c.consumer.QueueMonitor
+
+	interval := config.Interval
+	if interval <= 0 {
+		interval = defaultQueueMonitorPeriod
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	ctx := c.consumptionContext()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.inspectQueue()
+		}
+	}
+}
+
+// inspectQueue inspects the consumed queue and records its stats.
+func (c *amqpChannel) inspectQueue() {
+	if !c.ready() {
+		return
+	}
+
+	// A passive declaration only inspects the queue, which exists since it is being consumed.
+	queue, err := c.channel.Load().QueueDeclarePassive(c.consumer.Queue, false, false, false, false, nil)
+	if err != nil {
+		c.logger.Error(err, "Could not inspect queue")
+
+		return
+	}
+
+	config := c.consumer.QueueMonitor
+
+	stats := QueueStats{
+		Queue:      queue.Name,
+		Consumer:   c.consumer.Name,
+		Messages:   queue.Messages,
+		Consumers:  queue.Consumers,
+		Backlogged: config.BacklogThreshold > 0 && queue.Messages > config.BacklogThreshold,
+		CheckedAt:  time.Now(),
+	}
+
+	c.queueStatsMutex.Lock()
+	c.queueStats = &stats
+	c.queueStatsMutex.Unlock()
+
+	if stats.Backlogged {
+		c.logger.Warn("Queue is backlogged", LogField{Key: "messages", Value: stats.Messages})
+	}
+
+	if config.OnStats != nil {
+		config.OnStats(stats)
+	}
+}
+
+// lastQueueStats returns the stats of the last queue inspection, if any.
+func (c *amqpChannel) lastQueueStats() *QueueStats {
+	c.queueStatsMutex.Lock()
+	defer c.queueStatsMutex.Unlock()
+
+	if c.queueStats == nil {
+		return nil
+	}
+
+	stats := *c.queueStats
+
+	return &stats
+}
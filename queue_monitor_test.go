@@ -0,0 +1,77 @@
+package gorabbit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_QueueMonitor(t *testing.T) {
+	server := newFakeServer(t)
+	server.setDepth("events", 150)
+
+	var (
+		mutex sync.Mutex
+		stats []gorabbit.QueueStats
+	)
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{
+		QueueMonitor: &gorabbit.QueueMonitorConfig{
+			Interval:         10 * time.Millisecond,
+			BacklogThreshold: 100,
+			OnStats: func(inspected gorabbit.QueueStats) {
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				stats = append(stats, inspected)
+			},
+		},
+	})
+
+	require.Eventually(t, func() bool { return client.HealthReport().Backlogged() }, time.Second, 10*time.Millisecond)
+
+	report := client.HealthReport()
+
+	assert.True(t, report.Ready)
+	require.Len(t, report.Consumers, 1)
+	assert.Equal(t, "events", report.Consumers[0].Name)
+	assert.Equal(t, "events", report.Consumers[0].Queue)
+	assert.True(t, report.Consumers[0].Healthy)
+	assert.True(t, report.Consumers[0].Active)
+
+	queueStats := report.Consumers[0].QueueStats
+	require.NotNil(t, queueStats)
+	assert.Equal(t, "events", queueStats.Queue)
+	assert.Equal(t, 150, queueStats.Messages)
+	assert.Equal(t, 1, queueStats.Consumers)
+
+	mutex.Lock()
+	assert.NotEmpty(t, stats)
+	mutex.Unlock()
+
+	// The backlog is no longer reported once the queue was drained below the threshold.
+	server.setDepth("events", 20)
+
+	require.Eventually(t, func() bool { return !client.HealthReport().Backlogged() }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 20, client.HealthReport().Consumers[0].QueueStats.Messages)
+
+	// The passive declarations inspecting the queue are not declarations of the queue.
+	assert.Empty(t, server.declarations())
+}
+
+func TestClient_HealthReport_Unmonitored(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{})
+
+	report := client.HealthReport()
+
+	require.Len(t, report.Consumers, 1)
+	assert.Nil(t, report.Consumers[0].QueueStats)
+	assert.False(t, report.Backlogged())
+}
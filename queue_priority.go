@@ -0,0 +1,80 @@
+package gorabbit
+
+import "fmt"
+
+// maxPriority returns the maximum priority of the queue, either from its MaxPriority or its arguments, if it is a
+// priority queue.
+func (q QueueConfig) maxPriority() (uint8, bool) {
+	if q.MaxPriority > 0 {
+		return q.MaxPriority, true
+	}
+
+	converted, err := tableValue(q.Args[argMaxPriority])
+	if err != nil {
+		return 0, false
+	}
+
+	priority, ok := converted.(int64)
+	if !ok {
+		return 0, false
+	}
+
+	if priority <= 0 || priority > 255 {
+		return 0, false
+	}
+
+	return uint8(priority), true
+}
+
+// queueMaxPriority returns the maximum priority of a queue consumed by a registered consumer carrying its QueueConfig,
+// if it is a priority queue.
+func (a *amqpConnection) queueMaxPriority(queue string) (uint8, bool) {
+	for _, channel := range a.channelList() {
+		consumer := channel.consumer
+		if consumer == nil || consumer.QueueConfig == nil || consumer.Queue != queue {
+			continue
+		}
+
+		return consumer.queueConfig().maxPriority()
+	}
+
+	return 0, false
+}
+
+// queueMaxPriority returns the maximum priority of a recorded queue, if it is a priority queue.
+func (r *topologyRegistry) queueMaxPriority(queue string) (uint8, bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	declared, found := r.queues[queue]
+	if !found {
+		return 0, false
+	}
+
+	return QueueConfig{Args: declared.Arguments}.maxPriority()
+}
+
+// validatePublishingPriority verifies, for messages published directly to a queue through the default exchange, that
+// the priority does not exceed the maximum priority of the queue, when known from the registered consumers or from the
+// queues declared through the manager of the client. The broker would otherwise silently deliver the message with the
+// maximum priority.
+func (c *connectionManager) validatePublishingPriority(exchange, routingKey string, options *PublishingOptions) error {
+	if exchange != "" || options == nil || options.MessagePriority == nil || c.consumerConnection == nil {
+		return nil
+	}
+
+	maxPriority, ok := c.consumerConnection.queueMaxPriority(routingKey)
+	if !ok && c.topology != nil {
+		maxPriority, ok = c.topology.queueMaxPriority(routingKey)
+	}
+
+	if !ok {
+		return nil
+	}
+
+	if priority := options.priority(); priority > maxPriority {
+		return fmt.Errorf("%w: priority %d of a message published to the queue '%s' with a max priority of %d", ErrPriorityOutOfRange, priority, routingKey, maxPriority)
+	}
+
+	return nil
+}
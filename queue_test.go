@@ -0,0 +1,110 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestQueueConfig_Validate(t *testing.T) {
+	tests := []struct {
+		config        gorabbit.QueueConfig
+		expectedError error
+	}{
+		{
+			config:        gorabbit.QueueConfig{Name: "classic"},
+			expectedError: nil,
+		},
+		{
+			config: gorabbit.QueueConfig{
+				Name:               "quorum",
+				Durable:            true,
+				Type:               gorabbit.QueueTypeQuorum,
+				DeliveryLimit:      5,
+				DeadLetterStrategy: gorabbit.DeadLetterStrategyAtMostOnce,
+				InitialGroupSize:   3,
+			},
+			expectedError: nil,
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "unknown", Type: "unknown"},
+			expectedError: errors.New("the queue 'unknown' has an unknown type 'unknown'"),
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "classic", DeliveryLimit: 5},
+			expectedError: errors.New("the queue 'classic' uses quorum queue properties but is not a quorum queue"),
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "quorum", Type: gorabbit.QueueTypeQuorum},
+			expectedError: errors.New("the quorum queue 'quorum' must be durable"),
+		},
+		{
+			config: gorabbit.QueueConfig{
+				Name:    "quorum",
+				Durable: true,
+				Args:    map[string]interface{}{"x-queue-type": "quorum"},
+			},
+			expectedError: nil,
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "quorum", Durable: true, Exclusive: true, Type: gorabbit.QueueTypeQuorum},
+			expectedError: errors.New("the quorum queue 'quorum' cannot be exclusive"),
+		},
+		{
+			config: gorabbit.QueueConfig{
+				Name:               "quorum",
+				Durable:            true,
+				Type:               gorabbit.QueueTypeQuorum,
+				DeadLetterStrategy: gorabbit.DeadLetterStrategyAtLeastOnce,
+			},
+			expectedError: errors.New("the quorum queue 'quorum' must use the 'reject-publish' overflow with the at-least-once dead letter strategy"),
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "priority", Durable: true, MaxPriority: 10},
+			expectedError: nil,
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "stream", Durable: true, Type: gorabbit.QueueTypeStream, MaxPriority: 10},
+			expectedError: errors.New("the stream queue 'stream' cannot have a max priority"),
+		},
+		{
+			config: gorabbit.QueueConfig{
+				Name:           "bounded",
+				Durable:        true,
+				MaxLength:      1000,
+				MaxLengthBytes: 1 << 20,
+				Overflow:       gorabbit.OverflowRejectPublishDLX,
+			},
+			expectedError: nil,
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "bounded", MaxLength: -1},
+			expectedError: errors.New("the queue 'bounded' cannot have a negative max length"),
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "bounded", Overflow: "drop-tail"},
+			expectedError: errors.New("the queue 'bounded' has an unknown overflow behavior 'drop-tail'"),
+		},
+		{
+			config:        gorabbit.QueueConfig{Name: "quorum", Durable: true, Type: gorabbit.QueueTypeQuorum, Overflow: gorabbit.OverflowRejectPublishDLX},
+			expectedError: errors.New("the quorum queue 'quorum' does not support the 'reject-publish-dlx' overflow"),
+		},
+		{
+			config: gorabbit.QueueConfig{
+				Name:               "quorum",
+				Durable:            true,
+				Type:               gorabbit.QueueTypeQuorum,
+				DeadLetterStrategy: gorabbit.DeadLetterStrategyAtLeastOnce,
+				Overflow:           gorabbit.OverflowRejectPublish,
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expectedError, test.config.Validate())
+	}
+}
@@ -0,0 +1,49 @@
+package gorabbit
+
+import (
+	"context"
+	"time"
+)
+
+// rateLimiter paces the dispatch of deliveries to a maximum number per second.
+// It is not safe for concurrent use and is meant to be used by the consumption loop only.
+type rateLimiter struct {
+	// perSecond is the maximum number of dispatches per second.
+	perSecond float64
+
+	// interval is the minimum delay between two dispatches.
+	interval time.Duration
+
+	// next is the earliest time of the next dispatch.
+	next time.Time
+}
+
+// newRateLimiter instantiates a new rateLimiter allowing perSecond dispatches per second.
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{
+		perSecond: perSecond,
+		interval:  time.Duration(float64(time.Second) / perSecond),
+	}
+}
+
+// wait blocks until the next dispatch is allowed, or returns false if the context is canceled first.
+func (r *rateLimiter) wait(ctx context.Context) bool {
+	now := time.Now()
+
+	if r.next.After(now) {
+		timer := time.NewTimer(r.next.Sub(now))
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		}
+
+		now = r.next
+	}
+
+	r.next = now.Add(r.interval)
+
+	return true
+}
@@ -0,0 +1,55 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_ConsumeRateLimit(t *testing.T) {
+	server := newFakeServer(t)
+	handled := make(chan time.Time, 5)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		PrefetchCount:    10,
+		ConsumeRateLimit: 20,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func([]byte) error {
+				handled <- time.Now()
+
+				return nil
+			},
+		},
+	})
+
+	server.deliver("events", "event.created", "1", "2", "3", "4", "5")
+
+	var times []time.Time
+
+	for i := 0; i < 5; i++ {
+		select {
+		case at := <-handled:
+			times = append(times, at)
+		case <-time.After(time.Second):
+			require.FailNow(t, "delivery not handled")
+		}
+	}
+
+	// The deliveries are dispatched 50ms apart, the first one right away.
+	assert.GreaterOrEqual(t, times[4].Sub(times[0]), 190*time.Millisecond)
+}
+
+func TestMessageConsumer_Validate_ConsumeRateLimit(t *testing.T) {
+	consumer := gorabbit.MessageConsumer{
+		Queue:            "events",
+		Name:             "events",
+		ConsumeRateLimit: -1,
+		Handlers:         gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }},
+	}
+
+	assert.Error(t, consumer.Validate())
+}
@@ -0,0 +1,49 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_OnRedelivery(t *testing.T) {
+	server := newFakeServer(t)
+
+	redelivered := make(chan gorabbit.Delivery, 10)
+	flagged := make(chan bool, 10)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		OnRedelivery: func(delivery gorabbit.Delivery) { redelivered <- delivery },
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"event.created": func(ctx context.Context, _ []byte) error {
+				delivery, _ := gorabbit.DeliveryFromContext(ctx)
+
+				flagged <- delivery.Redelivered
+
+				return nil
+			},
+		},
+	})
+
+	server.deliverMessages("events",
+		fakeDelivery{RoutingKey: "event.created", Body: "first", MessageID: "1"},
+		fakeDelivery{RoutingKey: "event.created", Body: "second", MessageID: "2", Redelivered: true},
+	)
+
+	require.Eventually(t, func() bool { return len(flagged) == 2 }, time.Second, 10*time.Millisecond)
+	assert.False(t, <-flagged)
+	assert.True(t, <-flagged)
+
+	// The hook is only called for the delivery flagged as redelivered, before its processing.
+	require.Len(t, redelivered, 1)
+
+	delivery := <-redelivered
+
+	assert.Equal(t, "2", delivery.MessageID)
+	assert.True(t, delivery.Redelivered)
+}
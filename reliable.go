@@ -0,0 +1,233 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// returnTracker records the mandatory publishings returned by the broker on a channel, so that a reliable publishing
+// can tell, once confirmed, whether it was routed. The broker returns an unroutable publishing before confirming it,
+// and the returns are received before the following confirmations are dispatched, so a query made once a publishing
+// is confirmed sees its return.
+type returnTracker struct {
+	queries chan returnQuery
+	done    chan struct{}
+}
+
+// returnQuery asks a returnTracker whether the publishing with the given message ID was returned.
+type returnQuery struct {
+	messageID string
+	reply     chan bool
+}
+
+// newReturnTracker subscribes to the returns of the channel until it is closed.
+func newReturnTracker(channel *amqp.Channel) *returnTracker {
+	t := &returnTracker{
+		queries: make(chan returnQuery),
+		done:    make(chan struct{}),
+	}
+
+	// The returns are not buffered, so that each one is received before the following confirmations are dispatched.
+	go t.listen(channel.NotifyReturn(make(chan amqp.Return)))
+
+	return t
+}
+
+// listen records the returns, and answers the queries about them, until the channel is closed.
+func (t *returnTracker) listen(returns <-chan amqp.Return) {
+	defer close(t.done)
+
+	returned := make(map[string]struct{})
+
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return
+			}
+
+			returned[r.MessageId] = struct{}{}
+		case query := <-t.queries:
+			_, found := returned[query.messageID]
+			delete(returned, query.messageID)
+
+			query.reply <- found
+		}
+	}
+}
+
+// returned returns true if the publishing with the given message ID was returned. It must be called once the
+// publishing is confirmed, and only once.
+func (t *returnTracker) returned(messageID string) bool {
+	if t == nil {
+		return false
+	}
+
+	reply := make(chan bool, 1)
+
+	select {
+	case t.queries <- returnQuery{messageID: messageID, reply: reply}:
+		return <-reply
+	case <-t.done:
+		return false
+	}
+}
+
+// awaitConfirmation waits, until the context is done, for the broker to confirm a reliable publishing, then settles
+// it. If the context is done first, the publishing is settled in the background once confirmed or not. Returns true if
+// the publishing is retained, by the publishing cache or by the background settlement.
+func (c *amqpChannel) awaitConfirmation(
+	ctx context.Context,
+	msg *mqttPublishing,
+	confirmation *amqp.DeferredConfirmation,
+	returns *returnTracker,
+) (bool, error) {
+	if confirmation == nil {
+		c.observePublish(*msg, PublishOutcomeSuccess)
+
+		return false, nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	acked, err := c.faults.awaitConfirm(ctx, confirmation, time.Now())
+	if err == nil {
+		return c.settleReliably(msg, acked, returns)
+	}
+
+	go func() {
+		<-confirmation.Done()
+
+		if cached, _ := c.settleReliably(msg, confirmation.Acked(), returns); !cached {
+			releasePublishing(msg)
+		}
+	}()
+
+	return true, err
+}
+
+// settleReliably settles a reliable publishing once confirmed or not by the broker: a returned publishing fails with
+// ErrPublishingReturned, and a publishing that is not confirmed, because it was nacked or the channel was lost, is
+// cached to be replayed. Returns true if the publishing was cached.
+func (c *amqpChannel) settleReliably(msg *mqttPublishing, acked bool, returns *returnTracker) (bool, error) {
+	if returns.returned(msg.Msg.MessageId) {
+		c.observePublish(*msg, PublishOutcomeReturned)
+
+		return false, fmt.Errorf("%w: exchange '%s', routing key '%s'", ErrPublishingReturned, msg.Exchange, msg.RoutingKey)
+	}
+
+	if acked {
+		c.observePublish(*msg, PublishOutcomeSuccess)
+
+		return false, nil
+	}
+
+	err := errPublishingNotConfirmed
+	if c.publishingCache.Put(msg.HashCode(), *msg) {
+		err = fmt.Errorf("%w: %w", ErrCacheFull, err)
+	}
+
+	c.observePublish(*msg, PublishOutcomeCached)
+
+	return true, fmt.Errorf("%w: %w", ErrPublishingCached, err)
+}
+
+// replayedPublishing is a cached publishing sent again by the replay, awaiting its confirmation.
+type replayedPublishing struct {
+	key          string
+	msg          mqttPublishing
+	confirmation *amqp.DeferredConfirmation
+}
+
+// replayReliably sends the cached publishings again once the channel is opened. The replayed publishings leave the
+// cache until their confirmation is known, so that the replay of a following channel does not send them again, and
+// return to it only if not confirmed. A publishing confirmed before the channel is lost is therefore never replayed
+// twice, while the ones that are not confirmed, such as when the channel is lost in the middle of the replay, are
+// replayed with the next channel: the replay waits for the previous one to be settled, which happens once its
+// channel is lost.
+func (c *amqpChannel) replayReliably() {
+	c.replayMutex.Lock()
+	defer c.replayMutex.Unlock()
+
+	c.replays.Wait()
+
+	if c.publishingCache.Len() == 0 {
+		return
+	}
+
+	c.logger.Info("Emptying publishing cache", LogField{Key: "event", Value: "onChannelOpened"})
+
+	var replayed []replayedPublishing
+
+	channel := c.channel.Load()
+	returns := c.returns.Load()
+
+	c.publishingCache.ForEach(func(key string, msg mqttPublishing) {
+		// Once the channel is lost, the remaining publishings wait for the next one.
+		if channel.IsClosed() {
+			return
+		}
+
+		confirmation, err := channel.PublishWithDeferredConfirmWithContext(c.ctx, msg.Exchange, msg.RoutingKey, true, msg.Immediate, msg.Msg)
+		if err != nil {
+			c.observePublish(msg, PublishOutcomeFailure)
+
+			return
+		}
+
+		c.publishingCache.Delete(key)
+
+		c.auditPublished(msg, confirmation)
+
+		replayed = append(replayed, replayedPublishing{key: key, msg: msg, confirmation: confirmation})
+	})
+
+	if len(replayed) > 0 {
+		c.replays.Add(1)
+
+		go func() {
+			defer c.replays.Done()
+
+			c.settleReplayed(replayed, returns)
+		}()
+	}
+}
+
+// settleReplayed settles the replayed publishings once confirmed or not by the broker: the confirmed and the returned
+// ones are dropped, and the others are cached again to be replayed with the next channel.
+func (c *amqpChannel) settleReplayed(replayed []replayedPublishing, returns *returnTracker) {
+	kept := 0
+
+	for _, r := range replayed {
+		select {
+		case <-r.confirmation.Done():
+		case <-c.ctx.Done():
+			return
+		}
+
+		switch {
+		case returns.returned(r.msg.Msg.MessageId):
+			c.logger.Warn("Replayed publishing returned, dropping it",
+				LogField{Key: "exchange", Value: r.msg.Exchange},
+				LogField{Key: "routingKey", Value: r.msg.RoutingKey},
+			)
+
+			c.observePublish(r.msg, PublishOutcomeReturned)
+		case r.confirmation.Acked():
+			c.observePublish(r.msg, PublishOutcomeRepublished)
+		default:
+			c.publishingCache.Put(r.key, r.msg)
+
+			kept++
+		}
+	}
+
+	if kept > 0 {
+		c.logger.Warn("Replayed publishings not confirmed, caching them again", LogField{Key: "count", Value: kept})
+	}
+}
@@ -0,0 +1,306 @@
+package gorabbit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reloadableDuration is a duration that can be updated while it is read by other goroutines.
+type reloadableDuration struct {
+	value atomic.Int64
+}
+
+// newReloadableDuration instantiates a new reloadableDuration holding the given duration.
+func newReloadableDuration(d time.Duration) *reloadableDuration {
+	r := &reloadableDuration{}
+	r.set(d)
+
+	return r
+}
+
+// get returns the current duration.
+func (r *reloadableDuration) get() time.Duration {
+	return time.Duration(r.value.Load())
+}
+
+// set replaces the duration.
+func (r *reloadableDuration) set(d time.Duration) {
+	r.value.Store(int64(d))
+}
+
+// reloadableLogger is a Logger whose underlying Logger can be replaced while it is used by other goroutines.
+type reloadableLogger struct {
+	current atomic.Pointer[loggerVersion]
+}
+
+// loggerVersion is a version of the underlying Logger of a reloadableLogger.
+type loggerVersion struct {
+	logger  Logger
+	version uint64
+}
+
+// newReloadableLogger instantiates a new reloadableLogger logging through the given Logger.
+func newReloadableLogger(logger Logger) *reloadableLogger {
+	r := &reloadableLogger{}
+	r.current.Store(&loggerVersion{logger: logger})
+
+	return r
+}
+
+// set replaces the underlying Logger, along with the Loggers derived from it.
+func (r *reloadableLogger) set(logger Logger) {
+	r.current.Store(&loggerVersion{logger: logger, version: r.current.Load().version + 1})
+}
+
+func (r *reloadableLogger) Error(err error, s string, fields ...LogField) {
+	r.current.Load().logger.Error(err, s, fields...)
+}
+
+func (r *reloadableLogger) Warn(s string, fields ...LogField) {
+	r.current.Load().logger.Warn(s, fields...)
+}
+
+func (r *reloadableLogger) Info(s string, fields ...LogField) {
+	r.current.Load().logger.Info(s, fields...)
+}
+
+func (r *reloadableLogger) Debug(s string, fields ...LogField) {
+	r.current.Load().logger.Debug(s, fields...)
+}
+
+// derivedLogger is a Logger derived from the underlying Logger of a reloadableLogger, such as by inheritLogger, and
+// derived again once the underlying Logger is replaced.
+type derivedLogger struct {
+	parent  *reloadableLogger
+	derive  func(logger Logger) Logger
+	current atomic.Pointer[loggerVersion]
+}
+
+// logger returns the Logger derived from the current underlying Logger of the parent.
+func (d *derivedLogger) logger() Logger {
+	parent := d.parent.current.Load()
+
+	if current := d.current.Load(); current != nil && current.version == parent.version {
+		return current.logger
+	}
+
+	derived := &loggerVersion{logger: d.derive(parent.logger), version: parent.version}
+	d.current.Store(derived)
+
+	return derived.logger
+}
+
+func (d *derivedLogger) Error(err error, s string, fields ...LogField) {
+	d.logger().Error(err, s, fields...)
+}
+
+func (d *derivedLogger) Warn(s string, fields ...LogField) {
+	d.logger().Warn(s, fields...)
+}
+
+func (d *derivedLogger) Info(s string, fields ...LogField) {
+	d.logger().Info(s, fields...)
+}
+
+func (d *derivedLogger) Debug(s string, fields ...LogField) {
+	d.logger().Debug(s, fields...)
+}
+
+// consumerTuning holds the PrefetchCount and ConsumeRateLimit of a consumer, which can be updated while it consumes.
+type consumerTuning struct {
+	prefetchCount int
+	rateLimit     float64
+	mutex         sync.Mutex
+}
+
+// newConsumerTuning instantiates a new consumerTuning with the PrefetchCount and ConsumeRateLimit of the consumer.
+func newConsumerTuning(consumer *MessageConsumer) *consumerTuning {
+	return &consumerTuning{
+		prefetchCount: consumer.PrefetchCount,
+		rateLimit:     consumer.ConsumeRateLimit,
+	}
+}
+
+// get returns the current PrefetchCount and ConsumeRateLimit.
+func (t *consumerTuning) get() (int, float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.prefetchCount, t.rateLimit
+}
+
+// set replaces the PrefetchCount and ConsumeRateLimit, and returns true if the PrefetchCount changed.
+func (t *consumerTuning) set(prefetchCount int, rateLimit float64) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	changed := t.prefetchCount != prefetchCount
+
+	t.prefetchCount, t.rateLimit = prefetchCount, rateLimit
+
+	return changed
+}
+
+// UpdateConfig applies the options over the current ones, as NewClient does over the DefaultClientOptions. The
+// RetryDelay, the logging options, the PrefetchCount, the ConsumerDefaults and the PublishingDefaults are applied without
+// recreating the connections: the PrefetchCount and ConsumeRateLimit of the consumers that inherit them are updated
+// live. If the connection settings or KeepAlive change, the connections are dialed again and the consumers registered
+// again, the publishings cached by the previous connection being dropped. The other options only apply to new clients.
+// The options must be functional options, see WithClientConfig to reload a configuration file.
+// Returns the problems of the resulting options, in which case nothing is applied.
+func (client *mqttClient) UpdateConfig(opts ...Option) error {
+	client.reloadMutex.Lock()
+	defer client.reloadMutex.Unlock()
+
+	current := client.options.Load()
+
+	options := new(ClientOptions)
+	*options = *current
+
+	for _, opt := range opts {
+		// A *ClientOptions would replace the current settings it does not set, such as the host and the credentials.
+		if _, replaces := opt.(*ClientOptions); replaces {
+			return errUpdateWithClientOptions
+		}
+
+		if opt != nil {
+			opt.apply(options)
+		}
+	}
+
+	if err := options.Validate(); err != nil {
+		return err
+	}
+
+	client.options.Store(options)
+
+	// client is disabled, so we only keep the options.
+	if client.disabled {
+		return nil
+	}
+
+	client.logger.set(newClientLogger(options))
+
+	if options.ConnectionSettings() != current.ConnectionSettings() || options.KeepAlive != current.KeepAlive {
+		client.redial(options)
+
+		return nil
+	}
+
+	manager := client.connectionManager.Load()
+
+	manager.setRetryDelay(options.RetryDelay)
+
+	for _, consumer := range client.consumers {
+		effective := client.effectiveConsumer(consumer, options)
+
+		manager.tuneConsumer(effective.Name, effective.PrefetchCount, effective.ConsumeRateLimit)
+	}
+
+	client.logger.Info("Client configuration updated")
+
+	return nil
+}
+
+// redial replaces the connections with new ones dialed with the options, registers the consumers again on them, then
+// closes the previous connections along with the connection of the topology manager.
+func (client *mqttClient) redial(options *ClientOptions) {
+	manager := client.newConnectionManager(options)
+
+	for _, consumer := range client.consumers {
+		if err := manager.registerConsumer(client.effectiveConsumer(consumer, options)); err != nil {
+			client.logger.Error(err, "Could not register consumer again", LogField{Key: "consumer", Value: consumer.Name})
+		}
+	}
+
+	previous := client.connectionManager.Swap(manager)
+
+	if err := previous.close(); err != nil {
+		client.logger.Error(err, "Could not close the previous connections")
+	}
+
+	client.managerMutex.Lock()
+	client.managerOptions = newTopologyManagerOptions(options)
+	client.managerMutex.Unlock()
+
+	if err := client.closeTopologyManager(); err != nil {
+		client.logger.Error(err, "Could not close the topology connection")
+	}
+
+	client.logger.Info("Client configuration updated, connections dialed again")
+}
+
+// effectiveConsumer returns the consumer completed with the defaults of the options.
+func (client *mqttClient) effectiveConsumer(consumer MessageConsumer, options *ClientOptions) MessageConsumer {
+	if consumer.PrefetchCount == 0 {
+		consumer.PrefetchCount = options.PrefetchCount
+	}
+
+	options.ConsumerDefaults.apply(&consumer)
+
+	if len(options.ContextDecorators) > 0 {
+		consumer.ContextDecorators = append(append([]ContextDecorator(nil), options.ContextDecorators...),
+			consumer.ContextDecorators...)
+	}
+
+	return consumer
+}
+
+// setRetryDelay replaces the delay of the re-connection and retry mechanisms of both connections and their channels.
+func (c *connectionManager) setRetryDelay(delay time.Duration) {
+	c.consumerConnection.retryDelay.set(delay)
+	c.publisherConnection.retryDelay.set(delay)
+}
+
+// tuneConsumer updates the PrefetchCount and ConsumeRateLimit of the consumer with the given name.
+func (c *connectionManager) tuneConsumer(name string, prefetchCount int, rateLimit float64) {
+	for _, channel := range c.consumerConnection.channelList() {
+		if channel.consumer == nil || channel.consumer.Name != name {
+			continue
+		}
+
+		// The PrefetchCount of an adaptive consumer is its own.
+		if channel.prefetchObserver != nil {
+			current, _ := channel.tuning.get()
+
+			channel.tune(current, rateLimit)
+
+			continue
+		}
+
+		channel.tune(prefetchCount, rateLimit)
+	}
+}
+
+// tune updates the PrefetchCount and ConsumeRateLimit of the consumer, setting the QOS of the open channel if the
+// PrefetchCount changed. The rate limit applies from the next delivery.
+func (c *amqpChannel) tune(prefetchCount int, rateLimit float64) {
+	if !c.tuning.set(prefetchCount, rateLimit) || c.consumer.autoAck() || !c.ready() {
+		return
+	}
+
+	if err := c.channel.Load().Qos(prefetchCount, c.consumer.PrefetchSize, false); err != nil {
+		c.logger.Error(err, "Could not update QOS for consumer")
+
+		return
+	}
+
+	c.logger.Info("Consumer prefetch count updated", LogField{Key: "prefetchCount", Value: prefetchCount})
+}
+
+// rateLimiter returns the rateLimiter of the current ConsumeRateLimit, reusing the given one if the limit did not
+// change, or nil if the consumer is not rate limited.
+func (c *amqpChannel) rateLimiter(current *rateLimiter) *rateLimiter {
+	_, rateLimit := c.tuning.get()
+
+	if rateLimit <= 0 {
+		return nil
+	}
+
+	if current != nil && current.perSecond == rateLimit {
+		return current
+	}
+
+	return newRateLimiter(rateLimit)
+}
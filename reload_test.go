@@ -0,0 +1,104 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_UpdateConfig_Invalid(t *testing.T) {
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().SetPort(1).SetKeepAlive(false))
+
+	defer client.Disconnect()
+
+	err := client.UpdateConfig(
+		gorabbit.WithURI("http://localhost"),
+		gorabbit.WithKeepAlive(true),
+		gorabbit.WithRetryDelay(-time.Second),
+	)
+
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "invalid URI")
+	assert.ErrorContains(t, err, "RetryDelay")
+}
+
+func TestClient_UpdateConfig_Logger(t *testing.T) {
+	previous := new(recordingLogger)
+	logger := new(recordingLogger)
+
+	// Nothing listens on the port, so both connections keep failing every retry delay.
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(5 * time.Millisecond).
+		SetLogger(previous).
+		SetLogLevel(gorabbit.LogLevelError))
+
+	defer client.Disconnect()
+
+	require.NoError(t, client.UpdateConfig(gorabbit.WithLogger(logger)))
+
+	time.Sleep(50 * time.Millisecond)
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	// The existing connections log through the new logger, with their own fields.
+	require.NotEmpty(t, logger.messages)
+
+	assert.Contains(t, logger.fields[0], gorabbit.LogField{Key: "context", Value: "connection"})
+}
+
+func TestClient_UpdateConfig_Disabled(t *testing.T) {
+	t.Setenv("GORABBIT_DISABLED", "true")
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions())
+
+	require.NoError(t, client.UpdateConfig(gorabbit.WithPrefetchCount(50)))
+}
+
+func TestClient_UpdateConfig_ClientOptions(t *testing.T) {
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().SetHost("rabbitmq.internal").SetPort(1).SetKeepAlive(false))
+
+	defer client.Disconnect()
+
+	// A *ClientOptions would reset the host, so it is rejected.
+	err := client.UpdateConfig(gorabbit.NewClientOptions().SetPrefetchCount(50), gorabbit.WithRetryDelay(time.Second))
+	assert.Error(t, err)
+}
+
+func TestClient_UpdateConfig_ClientConfig(t *testing.T) {
+	logger := new(recordingLogger)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(5 * time.Millisecond).
+		SetLogger(logger).
+		SetLogLevel(gorabbit.LogLevelError))
+
+	defer client.Disconnect()
+
+	config, err := gorabbit.LoadClientConfig(writeConfigFile(t, `
+connection:
+  port: 2
+  retry_delay: 5ms
+`))
+	require.NoError(t, err)
+
+	require.NoError(t, client.UpdateConfig(gorabbit.WithClientConfig(config)))
+
+	logger.mutex.Lock()
+	logged := len(logger.messages)
+	logger.mutex.Unlock()
+
+	time.Sleep(50 * time.Millisecond)
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+
+	// The connections dialed again still log through the logger, which a file cannot define.
+	assert.Greater(t, len(logger.messages), logged)
+}
@@ -0,0 +1,242 @@
+package gorabbit
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ReplayReport summarizes a ReplayDeadLetters operation.
+type ReplayReport struct {
+	// Replayed is the number of messages republished and removed from the dead-letter queue.
+	Replayed int
+
+	// Skipped is the number of messages left in the dead-letter queue, because they did not match the filter or their
+	// destination is unknown or unroutable.
+	Skipped int
+}
+
+// replayResetHeaders holds the headers removed from replayed messages, so that they start over with fresh attempts.
+var replayResetHeaders = []string{
+	xDeathHeader,
+	xDeathCountHeader,
+	xRetryAttemptHeader,
+	xOriginalQueueHeader,
+	xOriginalExchangeHeader,
+	xOriginalRoutingKeyHeader,
+	xQuarantineReasonHeader,
+	"x-first-death-exchange",
+	"x-first-death-queue",
+	"x-first-death-reason",
+	"x-last-death-exchange",
+	"x-last-death-queue",
+	"x-last-death-reason",
+}
+
+func (manager *mqttManager) ReplayDeadLetters(
+	ctx context.Context,
+	from, to string,
+	filter func(delivery Delivery) bool,
+	rate int,
+) (ReplayReport, error) {
+	var report ReplayReport
+
+	// Manager is disabled, so we do nothing and return no error.
+	if manager.disabled {
+		return report, nil
+	}
+
+	// If the manager is not ready, we return its error.
+	if ready, err := manager.ready(); !ready {
+		return report, err
+	}
+
+	if rate < 0 {
+		return report, errInvalidReplayRate
+	}
+
+	// The replay uses its own channel in confirm mode, so that a message is only removed once its copy is confirmed.
+	channel, err := manager.connection.Channel()
+	if err != nil {
+		return report, err
+	}
+
+	defer channel.Close()
+
+	if err = channel.Confirm(false); err != nil {
+		return report, err
+	}
+
+	// Unroutable messages are returned by the server before being confirmed.
+	returns := channel.NotifyReturn(make(chan amqp.Return, 1))
+
+	// Only the messages present at the start are replayed, so that messages dead-lettered again are not replayed in loop.
+	queue, err := channel.QueueDeclarePassive(from, false, false, false, false, nil)
+	if err != nil {
+		return report, err
+	}
+
+	var limiter *rateLimiter
+	if rate > 0 {
+		limiter = newRateLimiter(float64(rate))
+	}
+
+	source := &MessageConsumer{Queue: from}
+
+	// Skipped messages are held unacknowledged until the end, so that they are not fetched again.
+	var lastSkipped uint64
+
+	defer func() {
+		if lastSkipped > 0 {
+			_ = channel.Nack(lastSkipped, true, true)
+		}
+	}()
+
+	for i := 0; i < queue.Messages; i++ {
+		if limiter != nil && !limiter.wait(ctx) {
+			return report, ctx.Err()
+		}
+
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+
+		delivery, ok, getErr := channel.Get(from, false)
+		if getErr != nil {
+			return report, getErr
+		}
+
+		// The queue was emptied by someone else.
+		if !ok {
+			break
+		}
+
+		exchange, routingKey := replayDestination(&delivery, to)
+
+		if (filter != nil && !filter(newDelivery(source, &delivery))) || (exchange == "" && routingKey == "") {
+			lastSkipped = delivery.DeliveryTag
+			report.Skipped++
+
+			continue
+		}
+
+		routed, publishErr := replayPublish(ctx, channel, returns, exchange, routingKey, &delivery)
+		if publishErr != nil {
+			_ = delivery.Nack(false, true)
+
+			return report, publishErr
+		}
+
+		// A message that could not be routed stays in the dead-letter queue.
+		if !routed {
+			manager.logger.Warn("Dead letter could not be routed",
+				LogField{Key: "messageID", Value: delivery.MessageId},
+				LogField{Key: "exchange", Value: exchange},
+				LogField{Key: "routingKey", Value: routingKey},
+			)
+
+			lastSkipped = delivery.DeliveryTag
+			report.Skipped++
+
+			continue
+		}
+
+		if err = delivery.Ack(false); err != nil {
+			return report, err
+		}
+
+		report.Replayed++
+
+		manager.logger.Debug("Dead letter replayed",
+			LogField{Key: "messageID", Value: delivery.MessageId},
+			LogField{Key: "exchange", Value: exchange},
+			LogField{Key: "routingKey", Value: routingKey},
+		)
+	}
+
+	return report, nil
+}
+
+// replayDestination returns the exchange and routing key a dead-lettered delivery is replayed to: the given queue if
+// set, or the original destination found in its headers.
+func replayDestination(delivery *amqp.Delivery, to string) (string, string) {
+	if to != "" {
+		return "", to
+	}
+
+	// Messages quarantined by a consumer hold their original destination.
+	if exchange, found := delivery.Headers[xOriginalExchangeHeader].(string); found {
+		return exchange, originalRoutingKey(delivery)
+	}
+
+	// Messages dead-lettered by the broker hold it in the first entry of their x-death header, the most recent one.
+	if deaths, found := delivery.Headers[xDeathHeader].([]interface{}); found && len(deaths) > 0 {
+		if death, isTable := deaths[0].(amqp.Table); isTable {
+			exchange, _ := death["exchange"].(string)
+
+			if routingKeys, hasKeys := death["routing-keys"].([]interface{}); hasKeys && len(routingKeys) > 0 {
+				routingKey, _ := routingKeys[0].(string)
+
+				return exchange, routingKey
+			}
+
+			return exchange, ""
+		}
+	}
+
+	return "", ""
+}
+
+// replayPublish republishes a dead-lettered delivery without its dead-lettering headers and waits for its confirmation.
+// It returns false if the message could not be routed.
+func replayPublish(
+	ctx context.Context,
+	channel *amqp.Channel,
+	returns <-chan amqp.Return,
+	exchange, routingKey string,
+	delivery *amqp.Delivery,
+) (bool, error) {
+	headers := amqp.Table{}
+
+	for k, v := range delivery.Headers {
+		headers[k] = v
+	}
+
+	for _, header := range replayResetHeaders {
+		delete(headers, header)
+	}
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, exchange, routingKey, true, false, amqp.Publishing{
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		Body:            delivery.Body,
+		Type:            delivery.Type,
+		Priority:        delivery.Priority,
+		DeliveryMode:    delivery.DeliveryMode,
+		MessageId:       delivery.MessageId,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		Timestamp:       delivery.Timestamp,
+		Expiration:      delivery.Expiration,
+		Headers:         headers,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if !acked {
+		return false, errReplayNotConfirmed
+	}
+
+	select {
+	case <-returns:
+		return false, nil
+	default:
+		return true, nil
+	}
+}
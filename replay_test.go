@@ -0,0 +1,95 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMQTTManager_ReplayDeadLetters(t *testing.T) {
+	server := newFakeServer(t)
+
+	server.enqueue("events.dlq",
+		fakeDelivery{RoutingKey: "events.parking-lot", Body: "quarantined", MessageID: "1", Headers: amqp.Table{
+			"x-original-exchange":    "events",
+			"x-original-routing-key": "event.created",
+			"x-quarantine-reason":    "downstream unavailable",
+			"x-tenant":               "acme",
+		}},
+		fakeDelivery{RoutingKey: "order.created", Body: "expired", MessageID: "2", Headers: amqp.Table{
+			"x-death": []interface{}{
+				amqp.Table{"exchange": "orders", "routing-keys": []interface{}{"order.created"}, "count": int64(1)},
+			},
+			"x-first-death-reason": "expired",
+		}},
+		fakeDelivery{RoutingKey: "unknown", Body: "unknown", MessageID: "3"},
+		fakeDelivery{RoutingKey: "order.created", Body: "filtered", MessageID: "4", Headers: amqp.Table{
+			"x-original-exchange": "orders",
+		}},
+	)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	report, err := manager.ReplayDeadLetters(context.Background(), "events.dlq", "", func(delivery gorabbit.Delivery) bool {
+		return delivery.MessageID != "4"
+	}, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, gorabbit.ReplayReport{Replayed: 2, Skipped: 2}, report)
+
+	// The messages are replayed to their original destination, without their dead-lettering headers.
+	publishings := server.publishings()
+	require.Len(t, publishings, 2)
+
+	assert.Equal(t, fakePublishing{Exchange: "events", RoutingKey: "event.created", Body: "quarantined", Headers: amqp.Table{
+		"x-tenant": "acme",
+	}}, publishings[0])
+	assert.Equal(t, fakePublishing{Exchange: "orders", RoutingKey: "order.created", Body: "expired"}, publishings[1])
+
+	// The replayed messages are removed from the dead-letter queue, the others are left in place.
+	assert.ElementsMatch(t, []fakeSettlement{
+		{Body: "quarantined", Acked: true},
+		{Body: "expired", Acked: true},
+		{Body: "unknown", Requeue: true},
+		{Body: "filtered", Requeue: true},
+	}, server.settled())
+}
+
+func TestMQTTManager_ReplayDeadLetters_ToQueue(t *testing.T) {
+	server := newFakeServer(t)
+
+	server.enqueue("events.dlq",
+		fakeDelivery{RoutingKey: "event.created", Body: "first"},
+		fakeDelivery{RoutingKey: "event.created", Body: "second"},
+	)
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions().SetHost("127.0.0.1").SetPort(server.port()))
+	require.NoError(t, err)
+
+	defer manager.Disconnect()
+
+	report, err := manager.ReplayDeadLetters(context.Background(), "events.dlq", "events", nil, 100)
+	require.NoError(t, err)
+
+	assert.Equal(t, gorabbit.ReplayReport{Replayed: 2}, report)
+
+	// The messages are replayed to the queue through the default exchange.
+	publishings := server.publishings()
+	require.Len(t, publishings, 2)
+
+	assert.Equal(t, "", publishings[0].Exchange)
+	assert.Equal(t, "events", publishings[0].RoutingKey)
+	assert.Equal(t, "first", publishings[0].Body)
+	assert.Equal(t, "second", publishings[1].Body)
+
+	_, err = manager.ReplayDeadLetters(context.Background(), "events.dlq", "events", nil, -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package gorabbit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RetryConfig defines an exponential backoff retry mechanism based on dead-letter and TTL queues.
+//
+// When a handler fails, the delivery is published to an automatically declared wait queue whose messages expire after
+// the computed delay and are dead-lettered back to the consumer's queue. Each distinct delay gets its own wait queue,
+// named "<queue>.retry.<delay in ms>ms".
+type RetryConfig struct {
+	// InitialDelay is the delay applied before the first retry.
+	InitialDelay time.Duration `yaml:"initial_delay"`
+
+	// Multiplier is the factor applied to the delay after each attempt. Defaults to 2 if not set.
+	Multiplier float64 `yaml:"multiplier"`
+
+	// MaxDelay caps the computed delay. Defaults to the largest message TTL accepted by RabbitMQ, about 49 days, if not
+	// set.
+	MaxDelay time.Duration `yaml:"max_delay"`
+
+	// MaxAttempts defines the maximum number of retries for a single delivery.
+	MaxAttempts uint `yaml:"max_attempts"`
+}
+
+// Delay returns the backoff delay for the given attempt, starting at 1.
+func (r RetryConfig) Delay(attempt uint) time.Duration {
+	if attempt == 0 {
+		attempt = 1
+	}
+
+	multiplier := r.Multiplier
+	if multiplier == 0 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	maxDelay := r.MaxDelay
+	if maxDelay <= 0 || maxDelay > defaultRetryMaxDelay {
+		maxDelay = defaultRetryMaxDelay
+	}
+
+	// The delay is capped before its conversion, which would overflow for the late attempts.
+	delay := float64(r.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if delay > float64(maxDelay) {
+		return maxDelay
+	}
+
+	return time.Duration(delay)
+}
+
+// Validate verifies that the RetryConfig can be used to compute delays.
+func (r RetryConfig) Validate() error {
+	if r.InitialDelay <= 0 {
+		return errors.New("the retry initial delay must be greater than 0")
+	}
+
+	if r.Multiplier != 0 && r.Multiplier < 1 {
+		return errors.New("the retry multiplier cannot be lower than 1")
+	}
+
+	if r.MaxAttempts == 0 {
+		return errors.New("the retry max attempts must be greater than 0")
+	}
+
+	return nil
+}
+
+// retryQueueName returns the name of the wait queue used for a given queue and delay.
+func retryQueueName(queue string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%dms", queue, delay.Milliseconds())
+}
+
+// retryAttempt extracts the number of retries already made from the delivery headers.
+func retryAttempt(delivery *amqp.Delivery) uint {
+	return headerCount(delivery.Headers[xRetryAttemptHeader])
+}
+
+// originalRoutingKey returns the routing key a delivery was initially published with. Retried deliveries are
+// dead-lettered back through the default exchange, so the initial routing key is kept in a header.
+func originalRoutingKey(delivery *amqp.Delivery) string {
+	if routingKey, ok := delivery.Headers[xOriginalRoutingKeyHeader].(string); ok && routingKey != "" {
+		return routingKey
+	}
+
+	return delivery.RoutingKey
+}
+
+// retryPublishing returns the copy of a delivery to publish for the given retry attempt, keeping its original exchange
+// and routing key in headers.
+func retryPublishing(delivery *amqp.Delivery, attempt uint) amqp.Publishing {
+	headers := amqp.Table{}
+
+	for k, v := range delivery.Headers {
+		headers[k] = v
+	}
+
+	headers[xRetryAttemptHeader] = int32(attempt)
+	headers[xOriginalExchangeHeader] = delivery.Exchange
+	headers[xOriginalRoutingKeyHeader] = originalRoutingKey(delivery)
+
+	return amqp.Publishing{
+		ContentType:     delivery.ContentType,
+		ContentEncoding: delivery.ContentEncoding,
+		Body:            delivery.Body,
+		Type:            delivery.Type,
+		Priority:        delivery.Priority,
+		DeliveryMode:    delivery.DeliveryMode,
+		MessageId:       delivery.MessageId,
+		CorrelationId:   delivery.CorrelationId,
+		ReplyTo:         delivery.ReplyTo,
+		Timestamp:       delivery.Timestamp,
+		Headers:         headers,
+	}
+}
+
+// declareRetryQueue declares, if not already done, the wait queue for the given delay and returns its name.
+func (c *amqpChannel) declareRetryQueue(delay time.Duration) (string, error) {
+	name := retryQueueName(c.consumer.Queue, delay)
+
+	c.declaredQueuesMutex.Lock()
+	defer c.declaredQueuesMutex.Unlock()
+
+	if c.declaredQueues[name] {
+		return name, nil
+	}
+
+	_, err := c.channel.Load().QueueDeclare(
+		name,  // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{
+			argMessageTTL:           delay.Milliseconds(),
+			argDeadLetterExchange:   "",
+			argDeadLetterRoutingKey: c.consumer.Queue,
+		},
+	)
+	if err != nil {
+		return "", err
+	}
+
+	c.declaredQueues[name] = true
+
+	return name, nil
+}
+
+// retryWithBackoff sends a failed delivery to the wait queue matching its next attempt. The original delivery is only
+// acknowledged once the copy was successfully published, otherwise it is requeued.
+func (c *amqpChannel) retryWithBackoff(delivery *amqp.Delivery, alreadyAcknowledged bool, reason error) {
+	attempt := retryAttempt(delivery) + 1
+
+	// If the max attempts are reached, we give up on the delivery.
+	if !c.consumer.Retry.ShouldRetry(attempt, reason) {
+		c.logger.Debug("Cannot retry delivery, max attempts reached", LogField{Key: "messageID", Value: delivery.MessageId})
+
+		c.deadLetter(delivery, alreadyAcknowledged, reason)
+
+		return
+	}
+
+	delay := c.consumer.Retry.Delay(attempt)
+
+	queue, err := c.declareRetryQueue(delay)
+	if err != nil {
+		c.logger.Error(err, "Could not declare retry queue", LogField{Key: "delay", Value: delay.String()})
+
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, true)
+		}
+
+		return
+	}
+
+	err = c.channel.Load().PublishWithContext(c.ctx, "", queue, false, false, retryPublishing(delivery, attempt))
+	if err != nil {
+		c.logger.Error(err, "Could not publish delivery to retry queue", LogField{Key: "queue", Value: queue})
+
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, true)
+		}
+
+		return
+	}
+
+	c.logger.Debug("Delivery sent to retry queue",
+		LogField{Key: "messageID", Value: delivery.MessageId},
+		LogField{Key: "attempt", Value: attempt},
+		LogField{Key: "delay", Value: delay.String()},
+	)
+
+	if !alreadyAcknowledged {
+		_ = delivery.Ack(false)
+	}
+}
+
+// delayedNack holds a failed delivery for the consumer's NackDelay before negative acknowledging it with requeue.
+// If the consumption is stopped in the meantime, the broker requeues the delivery by itself.
+func (c *amqpChannel) delayedNack(delivery *amqp.Delivery) {
+	c.logger.Debug("Delaying negative acknowledgement",
+		LogField{Key: "messageID", Value: delivery.MessageId},
+		LogField{Key: "delay", Value: c.consumer.NackDelay.String()},
+	)
+
+	timer := time.NewTimer(c.consumer.NackDelay)
+	defer timer.Stop()
+
+	select {
+	case <-c.consumptionContext().Done():
+		c.logger.Debug("Delayed negative acknowledgement stopped by the consumption context")
+	case <-timer.C:
+		_ = delivery.Nack(false, true)
+	}
+}
@@ -0,0 +1,192 @@
+package gorabbit
+
+import (
+	"math/rand"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RetryPolicy governs a retry mechanism: whether an attempt is made, and the delay to wait before it. The built-in
+// policies are the ConstantRetryPolicy, the exponential RetryConfig and the DecorrelatedJitterRetryPolicy.
+//
+// A RetryPolicy is shared by several goroutines, so it must be safe for concurrent use.
+type RetryPolicy interface {
+	// ShouldRetry returns true if the given attempt, starting at 1, must be made after the failure of the previous
+	// one with the given error, nil before the first attempt.
+	ShouldRetry(attempt uint, err error) bool
+
+	// NextDelay returns the delay to wait before the given attempt, starting at 1, given the delay waited before the
+	// previous one, 0 before the first attempt.
+	NextDelay(attempt uint, previous time.Duration) time.Duration
+}
+
+// BoundedRetryPolicy is optionally implemented by a RetryPolicy giving up after a known number of attempts, so that a
+// context deadline is shared among the remaining attempts rather than granted to the next one. The built-in policies
+// implement it.
+type BoundedRetryPolicy interface {
+	RetryPolicy
+
+	// AttemptLimit returns the maximum number of attempts, 0 if unlimited.
+	AttemptLimit() uint
+}
+
+// ConstantRetryPolicy is a RetryPolicy waiting the same Delay before each attempt.
+type ConstantRetryPolicy struct {
+	// Delay is the delay waited before each attempt.
+	Delay time.Duration
+
+	// MaxAttempts is the maximum number of attempts, unlimited if 0.
+	MaxAttempts uint
+}
+
+// ShouldRetry returns true until the MaxAttempts are reached.
+func (p ConstantRetryPolicy) ShouldRetry(attempt uint, _ error) bool {
+	return p.MaxAttempts == 0 || attempt <= p.MaxAttempts
+}
+
+// NextDelay returns the Delay.
+func (p ConstantRetryPolicy) NextDelay(uint, time.Duration) time.Duration {
+	return p.Delay
+}
+
+// AttemptLimit returns the MaxAttempts.
+func (p ConstantRetryPolicy) AttemptLimit() uint {
+	return p.MaxAttempts
+}
+
+// ShouldRetry returns true until the MaxAttempts are reached, unlimited if 0. It makes the RetryConfig the exponential
+// RetryPolicy.
+func (r RetryConfig) ShouldRetry(attempt uint, _ error) bool {
+	return r.MaxAttempts == 0 || attempt <= r.MaxAttempts
+}
+
+// NextDelay returns the backoff Delay of the given attempt.
+func (r RetryConfig) NextDelay(attempt uint, _ time.Duration) time.Duration {
+	return r.Delay(attempt)
+}
+
+// AttemptLimit returns the MaxAttempts.
+func (r RetryConfig) AttemptLimit() uint {
+	return r.MaxAttempts
+}
+
+// DecorrelatedJitterRetryPolicy is a RetryPolicy waiting a random delay between the BaseDelay and three times the
+// previous delay, capped by the MaxDelay. The randomness spreads the attempts of many clients recovering at once, such
+// as after a restart of the broker.
+type DecorrelatedJitterRetryPolicy struct {
+	// BaseDelay is the lowest delay, waited before the first attempt.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the delay. No cap is applied if not set.
+	MaxDelay time.Duration
+
+	// MaxAttempts is the maximum number of attempts, unlimited if 0.
+	MaxAttempts uint
+}
+
+// ShouldRetry returns true until the MaxAttempts are reached.
+func (p DecorrelatedJitterRetryPolicy) ShouldRetry(attempt uint, _ error) bool {
+	return p.MaxAttempts == 0 || attempt <= p.MaxAttempts
+}
+
+// AttemptLimit returns the MaxAttempts.
+func (p DecorrelatedJitterRetryPolicy) AttemptLimit() uint {
+	return p.MaxAttempts
+}
+
+// NextDelay returns a random delay between the BaseDelay and three times the previous delay, capped by the MaxDelay.
+func (p DecorrelatedJitterRetryPolicy) NextDelay(_ uint, previous time.Duration) time.Duration {
+	const growth = 3
+
+	delay := p.BaseDelay
+
+	if upper := previous * growth; upper > p.BaseDelay {
+		//nolint:gosec // The jitter does not need a cryptographically secure randomness.
+		delay += time.Duration(rand.Int63n(int64(upper - p.BaseDelay)))
+	}
+
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+
+	return delay
+}
+
+// reloadableRetryPolicy is the default RetryPolicy of the re-connection and retry mechanisms, waiting the RetryDelay,
+// which can be reloaded, before each attempt, without limit.
+type reloadableRetryPolicy struct {
+	delay *reloadableDuration
+}
+
+func (p reloadableRetryPolicy) ShouldRetry(uint, error) bool {
+	return true
+}
+
+func (p reloadableRetryPolicy) NextDelay(uint, time.Duration) time.Duration {
+	return p.delay.get()
+}
+
+// recoveryPolicy returns the given RetryPolicy, or the reloadableRetryPolicy of the given delay if nil.
+func recoveryPolicy(policy RetryPolicy, delay *reloadableDuration) RetryPolicy {
+	if policy == nil {
+		return reloadableRetryPolicy{delay: delay}
+	}
+
+	return policy
+}
+
+// retryWithPolicy holds a failed delivery for the delay of the RetryPolicy of the consumer, then re-publishes it to the
+// queue of the consumer. The original delivery is only acknowledged once the copy was successfully published,
+// otherwise it is requeued. If the consumption is stopped in the meantime, the broker requeues the delivery by itself.
+func (c *amqpChannel) retryWithPolicy(delivery *amqp.Delivery, alreadyAcknowledged bool, reason error) {
+	policy := c.consumer.RetryPolicy
+	attempt := retryAttempt(delivery) + 1
+
+	// If the policy gives up, so do we.
+	if !policy.ShouldRetry(attempt, reason) {
+		c.logger.Debug("Cannot retry delivery, retry policy given up", LogField{Key: "messageID", Value: delivery.MessageId})
+
+		c.deadLetter(delivery, alreadyAcknowledged, reason)
+
+		return
+	}
+
+	previous := time.Duration(headerCount(delivery.Headers[xRetryDelayHeader])) * time.Millisecond
+	delay := policy.NextDelay(attempt, previous)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-c.consumptionContext().Done():
+		c.logger.Debug("Delivery retry stopped by the consumption context")
+
+		return
+	case <-timer.C:
+	}
+
+	publishing := retryPublishing(delivery, attempt)
+	publishing.Headers[xRetryDelayHeader] = delay.Milliseconds()
+
+	err := c.channel.Load().PublishWithContext(c.ctx, "", c.consumer.Queue, false, false, publishing)
+	if err != nil {
+		c.logger.Error(err, "Could not re-publish delivery to retry it", LogField{Key: "queue", Value: c.consumer.Queue})
+
+		if !alreadyAcknowledged {
+			_ = delivery.Nack(false, true)
+		}
+
+		return
+	}
+
+	c.logger.Debug("Delivery retried",
+		LogField{Key: "messageID", Value: delivery.MessageId},
+		LogField{Key: "attempt", Value: attempt},
+		LogField{Key: "delay", Value: delay.String()},
+	)
+
+	if !alreadyAcknowledged {
+		_ = delivery.Ack(false)
+	}
+}
@@ -0,0 +1,176 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestConstantRetryPolicy(t *testing.T) {
+	policy := gorabbit.ConstantRetryPolicy{Delay: time.Second, MaxAttempts: 3}
+
+	assert.True(t, policy.ShouldRetry(3, errors.New("failure")))
+	assert.False(t, policy.ShouldRetry(4, errors.New("failure")))
+	assert.Equal(t, time.Second, policy.NextDelay(4, 5*time.Second))
+
+	unlimited := gorabbit.ConstantRetryPolicy{Delay: time.Second}
+
+	assert.True(t, unlimited.ShouldRetry(1000, nil))
+}
+
+func TestRetryConfig_RetryPolicy(t *testing.T) {
+	var policy gorabbit.RetryPolicy = gorabbit.RetryConfig{InitialDelay: time.Second, MaxAttempts: 3}
+
+	assert.True(t, policy.ShouldRetry(3, nil))
+	assert.False(t, policy.ShouldRetry(4, nil))
+	assert.Equal(t, 4*time.Second, policy.NextDelay(3, 0))
+}
+
+func TestDecorrelatedJitterRetryPolicy(t *testing.T) {
+	policy := gorabbit.DecorrelatedJitterRetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second}
+
+	assert.Equal(t, 10*time.Millisecond, policy.NextDelay(1, 0))
+
+	previous := time.Duration(0)
+
+	for attempt := uint(1); attempt <= 100; attempt++ {
+		delay := policy.NextDelay(attempt, previous)
+
+		assert.GreaterOrEqual(t, delay, policy.BaseDelay)
+		assert.LessOrEqual(t, delay, policy.MaxDelay)
+		assert.LessOrEqual(t, delay, max(3*previous, policy.BaseDelay))
+
+		previous = delay
+	}
+}
+
+func TestBoundedRetryPolicy(t *testing.T) {
+	for _, policy := range []gorabbit.RetryPolicy{
+		gorabbit.ConstantRetryPolicy{Delay: time.Second, MaxAttempts: 3},
+		gorabbit.RetryConfig{InitialDelay: time.Second, MaxAttempts: 3},
+		&gorabbit.RetryConfig{InitialDelay: time.Second, MaxAttempts: 3},
+		gorabbit.DecorrelatedJitterRetryPolicy{BaseDelay: time.Second, MaxAttempts: 3},
+	} {
+		bounded, ok := policy.(gorabbit.BoundedRetryPolicy)
+		require.True(t, ok, "%T", policy)
+
+		assert.Equal(t, uint(3), bounded.AttemptLimit(), "%T", policy)
+	}
+}
+
+type recordingRetryPolicy struct {
+	gorabbit.RetryPolicy
+
+	attempts []uint
+	mutex    sync.Mutex
+}
+
+func (p *recordingRetryPolicy) ShouldRetry(attempt uint, err error) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.attempts = append(p.attempts, attempt)
+
+	return p.RetryPolicy.ShouldRetry(attempt, err)
+}
+
+func (p *recordingRetryPolicy) calls() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return len(p.attempts)
+}
+
+func TestClient_RecoveryPolicy(t *testing.T) {
+	policy := &recordingRetryPolicy{RetryPolicy: gorabbit.ConstantRetryPolicy{Delay: 10 * time.Millisecond, MaxAttempts: 2}}
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetKeepAlive(true).
+		SetRecoveryPolicy(policy))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// Nothing listens on the port, so both connections give up after their third call to the policy.
+	assert.Eventually(t, func() bool {
+		return policy.calls() == 6
+	}, time.Second, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, 6, policy.calls())
+}
+
+func TestMessageConsumer_Validate_RetryPolicy(t *testing.T) {
+	consumer := gorabbit.MessageConsumer{
+		Queue:         "invoices_queue",
+		Name:          "invoices",
+		PrefetchCount: 10,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"invoice.created": func([]byte) error { return nil },
+		},
+		RetryPolicy: gorabbit.ConstantRetryPolicy{Delay: time.Second},
+	}
+
+	require.NoError(t, consumer.Validate())
+
+	consumer.Retry = &gorabbit.RetryConfig{InitialDelay: time.Second, MaxAttempts: 3}
+
+	err := consumer.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot define both a retry and a retry policy")
+}
+
+func TestClient_LegacyRetry_RecoveryPolicy(t *testing.T) {
+	server := newFakeServer(t)
+
+	// The failed deliveries are held for the delay of the RecoveryPolicy rather than the RetryDelay, and retried as long
+	// as both their x-death-count header and the policy allow it.
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetRetryDelay(time.Hour).
+		SetRecoveryPolicy(gorabbit.ConstantRetryPolicy{Delay: 10 * time.Millisecond, MaxAttempts: 1}))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue: "events",
+		Name:  "events",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func([]byte) error { return errors.New("downstream unavailable") },
+		},
+	}))
+	require.Eventually(t, func() bool { return server.consumed("events") }, time.Second, 10*time.Millisecond)
+
+	server.deliverMessages("events",
+		fakeDelivery{RoutingKey: "event.created", Body: "retried", Headers: amqp.Table{"x-death-count": int32(3)}},
+		fakeDelivery{RoutingKey: "event.created", Body: "spent", Headers: amqp.Table{"x-death-count": int32(0)}},
+		fakeDelivery{
+			RoutingKey: "event.created",
+			Body:       "given up",
+			Headers:    amqp.Table{"x-death-count": int32(3), "x-retry-attempt": int32(1)},
+		},
+	)
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 3 }, time.Second, 10*time.Millisecond)
+
+	// Each delivery is rejected, only the first one being published again with a decremented count.
+	assert.ElementsMatch(t, []fakeSettlement{{Body: "retried"}, {Body: "spent"}, {Body: "given up"}}, server.settled())
+
+	require.Eventually(t, func() bool { return server.receivedCount() == 1 }, time.Second, 10*time.Millisecond)
+
+	publishing := server.publishings()[0]
+
+	assert.Equal(t, "event.created", publishing.RoutingKey)
+	assert.Equal(t, "retried", publishing.Body)
+	assert.EqualValues(t, 2, publishing.Headers["x-death-count"])
+	assert.EqualValues(t, 1, publishing.Headers["x-retry-attempt"])
+}
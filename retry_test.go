@@ -0,0 +1,107 @@
+package gorabbit_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestRetryConfig_Delay(t *testing.T) {
+	config := gorabbit.RetryConfig{
+		InitialDelay: time.Second,
+		MaxDelay:     10 * time.Second,
+		MaxAttempts:  5,
+	}
+
+	tests := []struct {
+		attempt       uint
+		expectedDelay time.Duration
+	}{
+		{attempt: 0, expectedDelay: time.Second},
+		{attempt: 1, expectedDelay: time.Second},
+		{attempt: 2, expectedDelay: 2 * time.Second},
+		{attempt: 3, expectedDelay: 4 * time.Second},
+		{attempt: 4, expectedDelay: 8 * time.Second},
+		{attempt: 5, expectedDelay: 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expectedDelay, config.Delay(test.attempt))
+	}
+}
+
+func TestRetryConfig_Delay_Uncapped(t *testing.T) {
+	config := gorabbit.RetryConfig{InitialDelay: time.Second, MaxAttempts: 100}
+
+	assert.Equal(t, 512*time.Second, config.Delay(10))
+
+	// The late attempts are capped at the largest message TTL instead of overflowing.
+	assert.Equal(t, math.MaxUint32*time.Millisecond, config.Delay(100))
+	assert.Equal(t, math.MaxUint32*time.Millisecond, config.Delay(10000))
+}
+
+func TestRetryConfig_Validate(t *testing.T) {
+	tests := []struct {
+		config        gorabbit.RetryConfig
+		expectedError error
+	}{
+		{
+			config:        gorabbit.RetryConfig{InitialDelay: time.Second, Multiplier: 1.5, MaxAttempts: 3},
+			expectedError: nil,
+		},
+		{
+			config:        gorabbit.RetryConfig{MaxAttempts: 3},
+			expectedError: errors.New("the retry initial delay must be greater than 0"),
+		},
+		{
+			config:        gorabbit.RetryConfig{InitialDelay: time.Second, Multiplier: 0.5, MaxAttempts: 3},
+			expectedError: errors.New("the retry multiplier cannot be lower than 1"),
+		},
+		{
+			config:        gorabbit.RetryConfig{InitialDelay: time.Second},
+			expectedError: errors.New("the retry max attempts must be greater than 0"),
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expectedError, test.config.Validate())
+	}
+}
+
+func TestClient_NackDelay(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{
+		NackDelay: 100 * time.Millisecond,
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func([]byte) error { return errors.New("downstream unavailable") },
+		},
+	})
+
+	startedAt := time.Now()
+
+	server.deliver("events", "event.created", "failed")
+
+	// The failed delivery is held for the delay before being requeued.
+	require.Eventually(t, func() bool { return len(server.settled()) == 1 }, time.Second, 5*time.Millisecond)
+
+	assert.GreaterOrEqual(t, time.Since(startedAt), 100*time.Millisecond)
+	assert.Equal(t, []fakeSettlement{{Body: "failed", Requeue: true}}, server.settled())
+
+	// A delivery held when the consumption stops is left to the broker.
+	server.deliver("events", "event.created", "held")
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.NoError(t, client.Disconnect())
+
+	time.Sleep(150 * time.Millisecond)
+
+	assert.Len(t, server.settled(), 1)
+}
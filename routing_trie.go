@@ -0,0 +1,217 @@
+package gorabbit
+
+import (
+	"context"
+	"strings"
+)
+
+// routingTrie matches routing keys against a set of keys that can contain the '*' and '#' wildcards. The keys are
+// compiled once into a trie of their words, so that matching a routing key walks its words without allocating.
+type routingTrie[F any] struct {
+	root routingTrieNode[F]
+}
+
+// routingTrieNode is a word of the keys of a routingTrie.
+type routingTrieNode[F any] struct {
+	// words holds the nodes of the literal words following this one.
+	words map[string]*routingTrieNode[F]
+
+	// star and hash are the nodes of the '*' and '#' wildcards following this one, if any.
+	star *routingTrieNode[F]
+	hash *routingTrieNode[F]
+
+	// key and value are those registered with the key ending at this node, if terminal.
+	key      string
+	value    F
+	terminal bool
+}
+
+// newRoutingTrie compiles the keys of the given map into a routingTrie.
+func newRoutingTrie[F any](values map[string]F) *routingTrie[F] {
+	trie := &routingTrie[F]{}
+
+	for key, value := range values {
+		trie.insert(key, value)
+	}
+
+	return trie
+}
+
+// insert registers the value with the given key.
+func (t *routingTrie[F]) insert(key string, value F) {
+	node := &t.root
+
+	for _, word := range strings.Split(key, ".") {
+		switch word {
+		case "*":
+			node = wildcardNode(&node.star)
+		case "#":
+			node = wildcardNode(&node.hash)
+		default:
+			if node.words == nil {
+				node.words = make(map[string]*routingTrieNode[F])
+			}
+
+			if node.words[word] == nil {
+				node.words[word] = &routingTrieNode[F]{}
+			}
+
+			node = node.words[word]
+		}
+	}
+
+	node.key, node.value, node.terminal = key, value, true
+}
+
+// wildcardNode returns the node of a wildcard, created if needed.
+func wildcardNode[F any](node **routingTrieNode[F]) *routingTrieNode[F] {
+	if *node == nil {
+		*node = &routingTrieNode[F]{}
+	}
+
+	return *node
+}
+
+// match returns the value whose key matches the routing key, along with its key. The literal words are preferred over
+// the '*' wildcard, itself preferred over the '#' wildcard, so that the keys without wildcard are matched first.
+func (t *routingTrie[F]) match(routingKey string) (F, string, bool) {
+	if node := t.root.match(routingKey, 0); node != nil {
+		return node.value, node.key, true
+	}
+
+	var notFound F
+
+	return notFound, "", false
+}
+
+// match returns the terminal node matching the words of the routing key from the given offset, an offset past the end
+// of the routing key meaning that all its words were matched.
+func (n *routingTrieNode[F]) match(routingKey string, offset int) *routingTrieNode[F] {
+	if offset > len(routingKey) {
+		if n.terminal {
+			return n
+		}
+	} else {
+		word, next := nextWord(routingKey, offset)
+
+		if node := n.words[word]; node != nil {
+			if found := node.match(routingKey, next); found != nil {
+				return found
+			}
+		}
+
+		if n.star != nil {
+			if found := n.star.match(routingKey, next); found != nil {
+				return found
+			}
+		}
+	}
+
+	if n.hash == nil {
+		return nil
+	}
+
+	// The '#' wildcard matches zero or more words.
+	for from := offset; ; {
+		if found := n.hash.match(routingKey, from); found != nil {
+			return found
+		}
+
+		if from > len(routingKey) {
+			return nil
+		}
+
+		_, from = nextWord(routingKey, from)
+	}
+}
+
+// nextWord returns the word of the routing key starting at the given offset, and the offset of the following word.
+func nextWord(routingKey string, offset int) (string, int) {
+	end := strings.IndexByte(routingKey[offset:], '.')
+	if end < 0 {
+		return routingKey[offset:], len(routingKey) + 1
+	}
+
+	return routingKey[offset : offset+end], offset + end + 1
+}
+
+// RoutingKeyMatcher matches routing keys against a set of patterns that can contain the '*' and '#' wildcards, the
+// same way as the keys of the handlers. The patterns are compiled once, so that matching a routing key does not
+// allocate.
+type RoutingKeyMatcher struct {
+	trie *routingTrie[struct{}]
+}
+
+// NewRoutingKeyMatcher compiles the patterns into a RoutingKeyMatcher.
+func NewRoutingKeyMatcher(patterns ...string) *RoutingKeyMatcher {
+	values := make(map[string]struct{}, len(patterns))
+
+	for _, pattern := range patterns {
+		values[pattern] = struct{}{}
+	}
+
+	return &RoutingKeyMatcher{trie: newRoutingTrie(values)}
+}
+
+// Match returns the pattern matching the routing key, if any. The literal words are preferred over the '*' wildcard,
+// itself preferred over the '#' wildcard, as for the handlers.
+func (m *RoutingKeyMatcher) Match(routingKey string) (string, bool) {
+	if m == nil || m.trie == nil {
+		return "", false
+	}
+
+	_, pattern, found := m.trie.match(routingKey)
+
+	return pattern, found
+}
+
+// handlerMatcher dispatches the deliveries of a consumer to its handlers, compiled once into routingTries. The
+// ContextHandlers are looked up before the Handlers.
+type handlerMatcher struct {
+	contextHandlers *routingTrie[MQTTMessageContextHandlerFunc]
+	handlers        *routingTrie[MQTTMessageContextHandlerFunc]
+}
+
+// newHandlerMatcher compiles the handlers of the consumer.
+func newHandlerMatcher(consumer *MessageConsumer) *handlerMatcher {
+	handlers := make(map[string]MQTTMessageContextHandlerFunc, len(consumer.Handlers))
+
+	for key, fn := range consumer.Handlers {
+		if fn == nil {
+			continue
+		}
+
+		// The loop variable is shared by the iterations, so each wrapper must capture its own handler.
+		fn := fn
+
+		handlers[key] = func(_ context.Context, payload []byte) error {
+			return fn(payload)
+		}
+	}
+
+	contextHandlers := make(map[string]MQTTMessageContextHandlerFunc, len(consumer.ContextHandlers))
+
+	for key, fn := range consumer.ContextHandlers {
+		if fn != nil {
+			contextHandlers[key] = fn
+		}
+	}
+
+	return &handlerMatcher{
+		contextHandlers: newRoutingTrie(contextHandlers),
+		handlers:        newRoutingTrie(handlers),
+	}
+}
+
+// handlerFor returns the handler matching the given routing key, along with the key it is registered with.
+func (m *handlerMatcher) handlerFor(routingKey string) (MQTTMessageContextHandlerFunc, string) {
+	if fn, key, found := m.contextHandlers.match(routingKey); found {
+		return fn, key
+	}
+
+	if fn, key, found := m.handlers.match(routingKey); found {
+		return fn, key
+	}
+
+	return nil, ""
+}
@@ -0,0 +1,126 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// routingBenchKeys are the keys of the handlers of a consumer of several event families.
+var routingBenchKeys = []string{
+	"event.user.created",
+	"event.user.deleted",
+	"event.order.created",
+	"event.*.updated",
+	"event.payment.#",
+	"audit.#",
+	"#.failed",
+}
+
+func TestRoutingKeyMatcher(t *testing.T) {
+	matcher := gorabbit.NewRoutingKeyMatcher(routingBenchKeys...)
+
+	tests := map[string]string{
+		"event.user.created":             "event.user.created",
+		"event.order.updated":            "event.*.updated",
+		"event.payment.captured.partial": "event.payment.#",
+		"audit":                          "audit.#",
+		"shipment.label.failed":          "#.failed",
+	}
+
+	for routingKey, expected := range tests {
+		key, found := matcher.Match(routingKey)
+
+		assert.True(t, found, routingKey)
+		assert.Equal(t, expected, key, routingKey)
+	}
+
+	_, found := matcher.Match("shipment.label.printed")
+	assert.False(t, found)
+
+	_, found = (*gorabbit.RoutingKeyMatcher)(nil).Match("event.user.created")
+	assert.False(t, found)
+}
+
+func TestClient_Handlers_Dispatched(t *testing.T) {
+	server := newFakeServer(t)
+	handled := make(chan string, 2)
+
+	handler := func(key string) gorabbit.MQTTMessageHandlerFunc {
+		return func([]byte) error {
+			handled <- key
+
+			return nil
+		}
+	}
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()))
+
+	t.Cleanup(func() { _ = client.Disconnect() })
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue: "events",
+		Name:  "events",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": handler("event.created"),
+			"event.*":       handler("event.*"),
+		},
+	}))
+	require.Eventually(t, func() bool { return server.consumed("events") }, time.Second, 10*time.Millisecond)
+
+	// Each delivery reaches the handler registered with the key it matches.
+	server.deliver("events", "event.created", "created")
+	assert.Equal(t, "event.created", <-handled)
+
+	server.deliver("events", "event.deleted", "deleted")
+	assert.Equal(t, "event.*", <-handled)
+}
+
+// routingBenchRoutingKeys are the routing keys matched by the benchmarks, one of them matching no key.
+var routingBenchRoutingKeys = []string{
+	"event.user.created",
+	"event.order.updated",
+	"event.payment.captured.partial",
+	"shipment.label.failed",
+	"shipment.label.printed",
+}
+
+func BenchmarkRoutingTrie_Match(b *testing.B) {
+	b.Run("matcher", func(b *testing.B) {
+		matcher := gorabbit.NewRoutingKeyMatcher(routingBenchKeys...)
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			matcher.Match(routingBenchRoutingKeys[i%len(routingBenchRoutingKeys)])
+		}
+	})
+
+	b.Run("marshaller", func(b *testing.B) {
+		registry := gorabbit.NewMarshallerRegistry(nil)
+
+		for _, key := range routingBenchKeys {
+			registry.Register("events_exchange", key, gorabbit.JSONMarshaller{})
+		}
+
+		metadata := make([]*gorabbit.MessageMetadata, 0, len(routingBenchRoutingKeys))
+
+		for _, routingKey := range routingBenchRoutingKeys {
+			metadata = append(metadata, &gorabbit.MessageMetadata{Exchange: "events_exchange", RoutingKey: routingKey})
+		}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+
+		for i := 0; i < b.N; i++ {
+			registry.Lookup(metadata[i%len(metadata)])
+		}
+	})
+}
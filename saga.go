@@ -0,0 +1,354 @@
+package gorabbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SagaStatus is the state of a saga run by a SagaCoordinator.
+type SagaStatus string
+
+const (
+	// SagaRunning is the status of a saga whose steps are being run.
+	SagaRunning SagaStatus = "running"
+
+	// SagaCompleted is the status of a saga whose steps all succeeded.
+	SagaCompleted SagaStatus = "completed"
+
+	// SagaCompensating is the status of a saga whose completed steps are being compensated after a failure.
+	SagaCompensating SagaStatus = "compensating"
+
+	// SagaCompensated is the status of a saga whose completed steps were all compensated after a failure.
+	SagaCompensated SagaStatus = "compensated"
+
+	// SagaFailed is the status of a saga whose completed steps could not all be compensated after a failure.
+	SagaFailed SagaStatus = "failed"
+)
+
+// SagaStep is a step of a saga: a command published to a service, whose reply decides whether the saga goes on.
+type SagaStep struct {
+	// Name identifies the step in the transitions and the errors of the saga.
+	Name string
+
+	// Exchange is the exchange the command is published to.
+	Exchange string
+
+	// RoutingKey is the routing key the command is published with.
+	RoutingKey string
+
+	// Command returns the payload of the command, from the results of the previous steps for instance.
+	Command func(ctx context.Context) (interface{}, error)
+
+	// OnReply handles the reply to the command, see Reply. An error, such as the refusal of the command carried by the
+	// reply, fails the step.
+	OnReply func(ctx context.Context, reply Delivery) error
+
+	// Compensate undoes, if set, the step once completed, when a later step fails. It should be idempotent.
+	Compensate func(ctx context.Context) error
+
+	// Timeout bounds the wait for the reply. Defaults to the Timeout of the SagaOptions.
+	Timeout time.Duration
+
+	// Options are, if set, the options the command is published with. Its CorrelationID and ReplyTo are replaced.
+	Options *PublishingOptions
+}
+
+// SagaTransition is a change of the status of a saga, or of its current step.
+type SagaTransition struct {
+	// Saga is the identifier of the saga.
+	Saga string
+
+	// Step is the name of the step being run or compensated, empty once the saga ended.
+	Step string
+
+	// Status is the status of the saga.
+	Status SagaStatus
+
+	// Err is the error that failed the saga, if any.
+	Err error
+}
+
+// SagaOptions define the SagaCoordinator.
+type SagaOptions struct {
+	// Name names the reply queue and its consumer. It is required.
+	Name string
+
+	// ReplyQueue is the exclusive queue the replies are consumed from, declared by its consumer. Defaults to
+	// "<Name>.replies.<random suffix>", unique per instance.
+	ReplyQueue string
+
+	// Timeout bounds the wait for the reply of each step defining none. Defaults to 30 seconds.
+	Timeout time.Duration
+
+	// OnTransition is called, if set, on each transition of the sagas, to record their progress for instance.
+	OnTransition func(transition SagaTransition)
+}
+
+// SagaError is the error of a saga that failed, holding the step that failed and the errors of the compensations.
+type SagaError struct {
+	// Saga is the identifier of the saga.
+	Saga string
+
+	// Step is the name of the step that failed.
+	Step string
+
+	// Err is the error of the step.
+	Err error
+
+	// Compensation is the joined error of the compensations that failed, nil if all succeeded.
+	Compensation error
+}
+
+// Error returns the description of the failure.
+func (e *SagaError) Error() string {
+	if e.Compensation != nil {
+		return fmt.Sprintf("saga '%s' failed at step '%s': %v, compensation failed: %v", e.Saga, e.Step, e.Err, e.Compensation)
+	}
+
+	return fmt.Sprintf("saga '%s' failed at step '%s': %v", e.Saga, e.Step, e.Err)
+}
+
+// Unwrap returns the error of the step.
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+// SagaCoordinator runs sagas: sequences of commands published to services, each awaiting its correlated reply on the
+// reply queue of the coordinator. When a step fails or its reply times out, the completed steps are compensated in
+// reverse order.
+type SagaCoordinator struct {
+	publisher Publisher
+	options   SagaOptions
+
+	// mutex protects pending from concurrent access.
+	mutex sync.Mutex
+
+	// pending are the steps awaiting their reply, by correlation identifier.
+	pending map[string]*sagaPending
+}
+
+// sagaPending is a step awaiting its reply.
+type sagaPending struct {
+	// replies receives the reply of the step.
+	replies chan Delivery
+
+	// done is closed once the step stopped waiting.
+	done chan struct{}
+}
+
+// NewSagaCoordinator returns a SagaCoordinator publishing the commands with the publisher, and registering the
+// consumer of its reply queue with the registry, usually both being the client.
+// Returns the problems of the options or of the consumer, see RegisterConsumer.
+func NewSagaCoordinator(publisher Publisher, registry ConsumerRegistry, options SagaOptions) (*SagaCoordinator, error) {
+	if options.Name == "" {
+		return nil, fmt.Errorf("%w: the saga coordinator must have a name", errInvalidSubscription)
+	}
+
+	if options.ReplyQueue == "" {
+		options.ReplyQueue = fmt.Sprintf("%s.replies.%s", options.Name, uuid.NewString())
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = defaultSagaTimeout
+	}
+
+	coordinator := &SagaCoordinator{
+		publisher: publisher,
+		options:   options,
+		pending:   make(map[string]*sagaPending),
+	}
+
+	// The replies are published through the default exchange, with the reply queue as routing key.
+	err := registry.RegisterConsumer(MessageConsumer{
+		Queue:             options.ReplyQueue,
+		Name:              options.ReplyQueue,
+		ConcurrentProcess: true,
+		QueueConfig:       &QueueConfig{Exclusive: true},
+		ContextHandlers: MQTTMessageContextHandlers{
+			options.ReplyQueue: coordinator.handleReply,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return coordinator, nil
+}
+
+// ReplyQueue returns the queue the replies to the commands are expected on.
+func (c *SagaCoordinator) ReplyQueue() string {
+	return c.options.ReplyQueue
+}
+
+// Run runs the steps of the saga with the given identifier in order, each step publishing its command and awaiting
+// its reply before the next one. If a step fails, the completed ones are compensated in reverse order, even if the
+// context is done, and a *SagaError is returned. The failed step is not compensated, its service being expected to
+// leave nothing behind when it fails.
+// A reply that is not received within the Timeout of its step fails it with ErrReplyTimeout.
+func (c *SagaCoordinator) Run(ctx context.Context, id string, steps ...SagaStep) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	c.transition(SagaTransition{Saga: id, Status: SagaRunning})
+
+	for i, step := range steps {
+		c.transition(SagaTransition{Saga: id, Step: step.Name, Status: SagaRunning})
+
+		err := c.runStep(ctx, step)
+		if err == nil {
+			continue
+		}
+
+		sagaErr := &SagaError{Saga: id, Step: step.Name, Err: err}
+		sagaErr.Compensation = c.compensate(context.WithoutCancel(ctx), id, steps[:i], err)
+
+		status := SagaCompensated
+		if sagaErr.Compensation != nil {
+			status = SagaFailed
+		}
+
+		c.transition(SagaTransition{Saga: id, Status: status, Err: sagaErr})
+
+		return sagaErr
+	}
+
+	c.transition(SagaTransition{Saga: id, Status: SagaCompleted})
+
+	return nil
+}
+
+// runStep publishes the command of the step and waits for its reply, handled by the step.
+func (c *SagaCoordinator) runStep(ctx context.Context, step SagaStep) error {
+	timeout := step.Timeout
+	if timeout <= 0 {
+		timeout = c.options.Timeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var payload interface{}
+
+	if step.Command != nil {
+		var err error
+
+		if payload, err = step.Command(ctx); err != nil {
+			return err
+		}
+	}
+
+	correlationID := uuid.NewString()
+
+	// The reply can be received before the command is confirmed, so it is awaited beforehand.
+	pending := &sagaPending{replies: make(chan Delivery, 1), done: make(chan struct{})}
+
+	c.mutex.Lock()
+	c.pending[correlationID] = pending
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.pending, correlationID)
+		c.mutex.Unlock()
+
+		close(pending.done)
+	}()
+
+	options := SendOptions()
+	if step.Options != nil {
+		*options = *step.Options
+	}
+
+	options.SetCorrelationID(correlationID).SetReplyTo(c.options.ReplyQueue)
+
+	if err := c.publisher.PublishWithContext(ctx, step.Exchange, step.RoutingKey, payload, options); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: step '%s' got no reply within %s", ErrReplyTimeout, step.Name, timeout)
+		}
+
+		return ctx.Err()
+	case reply := <-pending.replies:
+		if step.OnReply == nil {
+			return nil
+		}
+
+		return step.OnReply(ctx, reply)
+	}
+}
+
+// compensate compensates the completed steps in reverse order, and returns the joined errors of the compensations.
+func (c *SagaCoordinator) compensate(ctx context.Context, id string, completed []SagaStep, cause error) error {
+	var errs []error
+
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+
+		if step.Compensate == nil {
+			continue
+		}
+
+		c.transition(SagaTransition{Saga: id, Step: step.Name, Status: SagaCompensating, Err: cause})
+
+		if err := step.Compensate(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("step '%s': %w", step.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// handleReply passes a reply to the step awaiting it. The replies are always acknowledged, the ones no step awaits
+// anymore, such as the ones received after their timeout, being dropped.
+func (c *SagaCoordinator) handleReply(ctx context.Context, _ []byte) error {
+	delivery, ok := DeliveryFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	c.mutex.Lock()
+	pending, found := c.pending[delivery.CorrelationID]
+	c.mutex.Unlock()
+
+	if !found {
+		return nil
+	}
+
+	select {
+	case pending.replies <- delivery:
+	case <-pending.done:
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
+// transition notifies the OnTransition callback, if set.
+func (c *SagaCoordinator) transition(transition SagaTransition) {
+	if c.options.OnTransition != nil {
+		c.options.OnTransition(transition)
+	}
+}
+
+// Reply publishes the payload as the reply to the delivery of a command, to its ReplyTo queue with its CorrelationID,
+// through the default exchange. It is meant for the services handling the commands of a SagaCoordinator.
+// Returns an error if the delivery expects no reply.
+func Reply(ctx context.Context, publisher Publisher, delivery Delivery, payload interface{}) error {
+	if delivery.ReplyTo == "" {
+		return errNoReplyTo
+	}
+
+	options := SendOptions().SetMode(Transient).SetCorrelationID(delivery.CorrelationID)
+
+	return publisher.PublishWithContext(ctx, "", delivery.ReplyTo, payload, options)
+}
@@ -0,0 +1,211 @@
+package gorabbit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbittest"
+)
+
+// sagaService registers a consumer of the queue replying to the commands with the given answer.
+func sagaService(t *testing.T, client *gorabbittest.MockClient, queue, answer string) {
+	t.Helper()
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue:       queue,
+		Name:        queue,
+		QueueConfig: &gorabbit.QueueConfig{},
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			queue: func(ctx context.Context, _ []byte) error {
+				delivery, _ := gorabbit.DeliveryFromContext(ctx)
+
+				return gorabbit.Reply(ctx, client, delivery, answer)
+			},
+		},
+	}))
+}
+
+// sagaStep returns a step commanding the service of the queue, failing if its answer is not "ok", and recording its
+// compensation.
+func sagaStep(queue string, compensated *[]string) gorabbit.SagaStep {
+	return gorabbit.SagaStep{
+		Name:       queue,
+		RoutingKey: queue,
+		Command: func(context.Context) (interface{}, error) {
+			return map[string]string{"order": "42"}, nil
+		},
+		OnReply: func(_ context.Context, reply gorabbit.Delivery) error {
+			var answer string
+
+			if err := json.Unmarshal(reply.Body, &answer); err != nil {
+				return err
+			}
+
+			if answer != "ok" {
+				return errors.New(answer)
+			}
+
+			return nil
+		},
+		Compensate: func(context.Context) error {
+			*compensated = append(*compensated, queue)
+
+			return nil
+		},
+	}
+}
+
+func TestSagaCoordinator_Completed(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	client := broker.Client()
+
+	sagaService(t, client, "payments", "ok")
+	sagaService(t, client, "shipments", "ok")
+
+	var transitions []gorabbit.SagaTransition
+
+	coordinator, err := gorabbit.NewSagaCoordinator(client, client, gorabbit.SagaOptions{
+		Name:         "orders",
+		OnTransition: func(transition gorabbit.SagaTransition) { transitions = append(transitions, transition) },
+	})
+	require.NoError(t, err)
+
+	var compensated []string
+
+	err = coordinator.Run(context.Background(), "order-42", sagaStep("payments", &compensated), sagaStep("shipments", &compensated))
+	require.NoError(t, err)
+
+	assert.Empty(t, compensated)
+	assert.Equal(t, []gorabbit.SagaTransition{
+		{Saga: "order-42", Status: gorabbit.SagaRunning},
+		{Saga: "order-42", Step: "payments", Status: gorabbit.SagaRunning},
+		{Saga: "order-42", Step: "shipments", Status: gorabbit.SagaRunning},
+		{Saga: "order-42", Status: gorabbit.SagaCompleted},
+	}, transitions)
+
+	// The commands carry the reply queue and the correlation identifier the replies are published with.
+	publishings := client.Publishings()
+	require.Len(t, publishings, 4)
+
+	assert.Equal(t, "payments", publishings[0].RoutingKey)
+	assert.Equal(t, coordinator.ReplyQueue(), publishings[0].Options.ReplyTo)
+	assert.NotEmpty(t, publishings[0].Options.CorrelationID)
+	assert.Equal(t, coordinator.ReplyQueue(), publishings[1].RoutingKey)
+	assert.Equal(t, publishings[0].Options.CorrelationID, publishings[1].Options.CorrelationID)
+}
+
+func TestSagaCoordinator_Compensated(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	client := broker.Client()
+
+	sagaService(t, client, "stocks", "ok")
+	sagaService(t, client, "payments", "ok")
+	sagaService(t, client, "shipments", "no carrier available")
+
+	var statuses []gorabbit.SagaStatus
+
+	coordinator, err := gorabbit.NewSagaCoordinator(client, client, gorabbit.SagaOptions{
+		Name:         "orders",
+		OnTransition: func(transition gorabbit.SagaTransition) { statuses = append(statuses, transition.Status) },
+	})
+	require.NoError(t, err)
+
+	var compensated []string
+
+	err = coordinator.Run(context.Background(), "order-42",
+		sagaStep("stocks", &compensated),
+		sagaStep("payments", &compensated),
+		sagaStep("shipments", &compensated),
+	)
+
+	var sagaErr *gorabbit.SagaError
+
+	require.ErrorAs(t, err, &sagaErr)
+	assert.Equal(t, "shipments", sagaErr.Step)
+	assert.EqualError(t, sagaErr.Err, "no carrier available")
+	assert.NoError(t, sagaErr.Compensation)
+
+	// The completed steps are compensated in reverse order.
+	assert.Equal(t, []string{"payments", "stocks"}, compensated)
+	assert.Equal(t, gorabbit.SagaCompensated, statuses[len(statuses)-1])
+}
+
+func TestSagaCoordinator_CompensationFailed(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	client := broker.Client()
+
+	sagaService(t, client, "payments", "ok")
+	sagaService(t, client, "shipments", "no carrier available")
+
+	var statuses []gorabbit.SagaStatus
+
+	coordinator, err := gorabbit.NewSagaCoordinator(client, client, gorabbit.SagaOptions{
+		Name:         "orders",
+		OnTransition: func(transition gorabbit.SagaTransition) { statuses = append(statuses, transition.Status) },
+	})
+	require.NoError(t, err)
+
+	var compensated []string
+
+	payments := sagaStep("payments", &compensated)
+	payments.Compensate = func(context.Context) error { return errors.New("refund refused") }
+
+	err = coordinator.Run(context.Background(), "order-42", payments, sagaStep("shipments", &compensated))
+
+	var sagaErr *gorabbit.SagaError
+
+	require.ErrorAs(t, err, &sagaErr)
+	assert.ErrorContains(t, sagaErr.Compensation, "refund refused")
+	assert.Equal(t, gorabbit.SagaFailed, statuses[len(statuses)-1])
+}
+
+func TestSagaCoordinator_Timeout(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	client := broker.Client()
+
+	sagaService(t, client, "payments", "ok")
+
+	coordinator, err := gorabbit.NewSagaCoordinator(client, client, gorabbit.SagaOptions{Name: "orders"})
+	require.NoError(t, err)
+
+	var compensated []string
+
+	// No service consumes the shipments.
+	shipments := sagaStep("shipments", &compensated)
+	shipments.Timeout = 20 * time.Millisecond
+
+	err = coordinator.Run(context.Background(), "order-42", sagaStep("payments", &compensated), shipments)
+
+	assert.ErrorIs(t, err, gorabbit.ErrReplyTimeout)
+	assert.Equal(t, []string{"payments"}, compensated)
+}
+
+func TestSagaCoordinator_Invalid(t *testing.T) {
+	_, err := gorabbit.NewSagaCoordinator(gorabbittest.NewMockClient(), gorabbittest.NewMockClient(), gorabbit.SagaOptions{})
+	assert.Error(t, err)
+}
+
+func TestReply(t *testing.T) {
+	client := gorabbittest.NewMockClient()
+
+	err := gorabbit.Reply(context.Background(), client, gorabbit.Delivery{CorrelationID: "42", ReplyTo: "orders.replies"}, "ok")
+	require.NoError(t, err)
+
+	publishings := client.Publishings()
+	require.Len(t, publishings, 1)
+
+	assert.Equal(t, "", publishings[0].Exchange)
+	assert.Equal(t, "orders.replies", publishings[0].RoutingKey)
+	assert.Equal(t, "42", publishings[0].Options.CorrelationID)
+
+	// A delivery without reply queue expects no reply.
+	err = gorabbit.Reply(context.Background(), client, gorabbit.Delivery{CorrelationID: "42"}, "ok")
+	assert.Error(t, err)
+}
@@ -0,0 +1,235 @@
+package gorabbit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SchedulerOptions define the Scheduler.
+type SchedulerOptions struct {
+	// Name names the coordination queue of the Scheduler, "<Name>.scheduler", and its consumer. The instances sharing
+	// the Store must share the Name. It is required.
+	Name string
+
+	// Store persists the scheduled messages. It is required.
+	Store SchedulerStore
+
+	// PollInterval is the delay between two checks of the due messages, which bounds the lateness of their publishing.
+	// Defaults to 1 second.
+	PollInterval time.Duration
+
+	// BatchSize is the maximum number of due messages read from the Store at once. Defaults to 100.
+	BatchSize int
+
+	// OnDispatch is called, if set, once a due message was published, or could not be, in which case it is published
+	// again by a later check.
+	OnDispatch func(message ScheduledMessage, err error)
+}
+
+// Scheduler publishes messages at a given time, keeping them in a SchedulerStore until then so that they survive the
+// restarts of the instances.
+//
+// The due messages are published by a single instance at a time, elected by the broker as the single active consumer
+// of the coordination queue of the Scheduler. Every PollInterval, each instance publishes a tick to the coordination
+// queue, and the active consumer publishes the due messages when receiving it. When it leaves, the broker activates
+// the next instance in line.
+//
+// A message is removed from the Store once its publishing succeeded, so it can be published twice if the instance
+// stops in-between.
+type Scheduler struct {
+	publisher Publisher
+	options   SchedulerOptions
+	queue     string
+
+	// mutex protects leader from concurrent access.
+	mutex  sync.Mutex
+	leader bool
+
+	// stop stops the ticks, done is closed once they stopped.
+	stop chan struct{}
+	done chan struct{}
+
+	stopOnce sync.Once
+}
+
+// NewScheduler returns a Scheduler publishing the messages with the publisher, and registering the consumer of its
+// coordination queue with the registry, usually both being the client. The ticks are published until Stop is called.
+// Returns the problems of the options or of the consumer, see RegisterConsumer.
+func NewScheduler(publisher Publisher, registry ConsumerRegistry, options SchedulerOptions) (*Scheduler, error) {
+	if options.Name == "" {
+		return nil, fmt.Errorf("%w: the scheduler must have a name", errInvalidSubscription)
+	}
+
+	if options.Store == nil {
+		return nil, fmt.Errorf("%w: the scheduler '%s' must have a store", errInvalidSubscription, options.Name)
+	}
+
+	if options.PollInterval <= 0 {
+		options.PollInterval = defaultSchedulerPollInterval
+	}
+
+	if options.BatchSize <= 0 {
+		options.BatchSize = defaultSchedulerBatchSize
+	}
+
+	scheduler := &Scheduler{
+		publisher: publisher,
+		options:   options,
+		queue:     options.Name + ".scheduler",
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	// The ticks are published through the default exchange, with the coordination queue as routing key. The queue
+	// holds a single tick, so that they do not pile up while the due messages are published.
+	err := registry.RegisterConsumer(MessageConsumer{
+		Queue:                scheduler.queue,
+		Name:                 scheduler.queue,
+		SingleActiveConsumer: true,
+		QueueConfig: &QueueConfig{
+			Durable:              true,
+			SingleActiveConsumer: true,
+			MaxLength:            1,
+			Overflow:             OverflowDropHead,
+		},
+		ContextHandlers: MQTTMessageContextHandlers{
+			scheduler.queue: scheduler.handleTick,
+		},
+		OnActiveStateChange: scheduler.setLeader,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	go scheduler.tick()
+
+	return scheduler, nil
+}
+
+// Queue returns the coordination queue of the Scheduler.
+func (s *Scheduler) Queue() string {
+	return s.queue
+}
+
+// Leader returns true if this instance is the one publishing the due messages.
+func (s *Scheduler) Leader() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.leader
+}
+
+// PublishAt schedules the payload to be published to the exchange with the routing key and the options at the given
+// time, or at the next check if the time is already past, and returns the ID of the scheduled message, see Cancel.
+// The payload is encoded as JSON when scheduled, and published as such.
+// Returns the error of the encoding or of the Store.
+func (s *Scheduler) PublishAt(
+	ctx context.Context,
+	at time.Time,
+	exchange string,
+	routingKey string,
+	payload interface{},
+	options *PublishingOptions,
+) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	message := ScheduledMessage{
+		ID:         uuid.NewString(),
+		PublishAt:  at,
+		Exchange:   exchange,
+		RoutingKey: routingKey,
+		Payload:    data,
+		Options:    options,
+	}
+
+	if err = s.options.Store.Save(ctx, message); err != nil {
+		return "", err
+	}
+
+	return message.ID, nil
+}
+
+// Cancel removes the scheduled message with the given ID, if it was not published yet.
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	return s.options.Store.Delete(ctx, id)
+}
+
+// Stop stops publishing the ticks. The consumer of the coordination queue stops with the client.
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	<-s.done
+}
+
+// tick publishes a tick to the coordination queue every PollInterval, until the Scheduler is stopped.
+func (s *Scheduler) tick() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+
+	options := SendOptions().SetMode(Transient)
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			// A tick that could not be published is replaced by the next one.
+			_ = s.publisher.PublishWithContext(context.Background(), "", s.queue, nil, options)
+		}
+	}
+}
+
+// handleTick publishes the due messages, removing each one from the Store once published. The tick is dropped if a
+// publishing fails, the remaining messages being published by a later tick.
+func (s *Scheduler) handleTick(ctx context.Context, _ []byte) error {
+	for {
+		due, err := s.options.Store.Due(ctx, time.Now(), s.options.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range due {
+			if err = s.dispatch(ctx, message); err != nil {
+				return err
+			}
+		}
+
+		if len(due) < s.options.BatchSize {
+			return nil
+		}
+	}
+}
+
+// dispatch publishes the scheduled message and removes it from the Store.
+func (s *Scheduler) dispatch(ctx context.Context, message ScheduledMessage) error {
+	err := s.publisher.PublishWithContext(ctx, message.Exchange, message.RoutingKey, message.Payload, message.Options)
+	if err == nil {
+		err = s.options.Store.Delete(ctx, message.ID)
+	}
+
+	if s.options.OnDispatch != nil {
+		s.options.OnDispatch(message, err)
+	}
+
+	return err
+}
+
+// setLeader records whether this instance is the active consumer of the coordination queue.
+func (s *Scheduler) setLeader(active bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.leader = active
+}
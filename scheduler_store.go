@@ -0,0 +1,193 @@
+package gorabbit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScheduledMessage is a message to be published at a given time by a Scheduler.
+type ScheduledMessage struct {
+	// ID is the unique identifier of the scheduled message.
+	ID string `json:"id"`
+
+	// PublishAt is the time the message is due to be published at.
+	PublishAt time.Time `json:"publish_at"`
+
+	// Exchange is the exchange the message is published to.
+	Exchange string `json:"exchange"`
+
+	// RoutingKey is the routing key the message is published with.
+	RoutingKey string `json:"routing_key"`
+
+	// Payload is the payload of the message, encoded as JSON when it was scheduled.
+	Payload json.RawMessage `json:"payload"`
+
+	// Options are the options the message is published with, nil if none.
+	Options *PublishingOptions `json:"options,omitempty"`
+}
+
+// SchedulerStore persists the messages scheduled by a Scheduler until they are published (database, Redis...).
+// The instances sharing a Scheduler must share its store. Implementations must be safe for concurrent use.
+type SchedulerStore interface {
+	// Save persists the scheduled message, replacing the one with the same ID if any.
+	Save(ctx context.Context, message ScheduledMessage) error
+
+	// Due returns at most limit scheduled messages due to be published at the given time, earliest first.
+	Due(ctx context.Context, until time.Time, limit int) ([]ScheduledMessage, error)
+
+	// Delete removes the scheduled message with the given ID, if any.
+	Delete(ctx context.Context, id string) error
+}
+
+// memorySchedulerStore is an in-memory SchedulerStore.
+type memorySchedulerStore struct {
+	messages map[string]ScheduledMessage
+	mutex    sync.Mutex
+}
+
+// NewMemorySchedulerStore returns an in-memory SchedulerStore, whose scheduled messages are lost with the process. It
+// suits the tests and the local development.
+func NewMemorySchedulerStore() SchedulerStore {
+	return &memorySchedulerStore{messages: make(map[string]ScheduledMessage)}
+}
+
+func (s *memorySchedulerStore) Save(_ context.Context, message ScheduledMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages[message.ID] = message
+
+	return nil
+}
+
+func (s *memorySchedulerStore) Due(_ context.Context, until time.Time, limit int) ([]ScheduledMessage, error) {
+	s.mutex.Lock()
+
+	var due []ScheduledMessage
+
+	for _, message := range s.messages {
+		if !message.PublishAt.After(until) {
+			due = append(due, message)
+		}
+	}
+
+	s.mutex.Unlock()
+
+	return earliestScheduled(due, limit), nil
+}
+
+func (s *memorySchedulerStore) Delete(_ context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.messages, id)
+
+	return nil
+}
+
+// fileSchedulerStore is a SchedulerStore writing each scheduled message as a JSON file.
+type fileSchedulerStore struct {
+	dir string
+}
+
+// NewFileSchedulerStore returns a SchedulerStore writing each scheduled message as a JSON file in the given directory,
+// created if needed. The instances of a Scheduler using it must share the directory.
+func NewFileSchedulerStore(dir string) SchedulerStore {
+	return &fileSchedulerStore{dir: dir}
+}
+
+func (s *fileSchedulerStore) Save(_ context.Context, message ScheduledMessage) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	// The message is written aside then renamed, so that a crash never leaves a partial file behind.
+	path := s.path(message.ID)
+
+	if err = os.WriteFile(path+".tmp", data, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+func (s *fileSchedulerStore) Due(_ context.Context, until time.Time, limit int) ([]ScheduledMessage, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var due []ScheduledMessage
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, readErr := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if errors.Is(readErr, os.ErrNotExist) {
+			// The message was deleted in the meantime.
+			continue
+		}
+
+		if readErr != nil {
+			return nil, readErr
+		}
+
+		var message ScheduledMessage
+
+		if err = json.Unmarshal(data, &message); err != nil {
+			return nil, err
+		}
+
+		if !message.PublishAt.After(until) {
+			due = append(due, message)
+		}
+	}
+
+	return earliestScheduled(due, limit), nil
+}
+
+func (s *fileSchedulerStore) Delete(_ context.Context, id string) error {
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path returns the path of the file of the scheduled message with the given ID.
+func (s *fileSchedulerStore) path(id string) string {
+	return filepath.Join(s.dir, filepath.Base(id)+".json")
+}
+
+// earliestScheduled sorts the scheduled messages by PublishAt, and returns the limit earliest ones, all of them if the
+// limit is not positive.
+func earliestScheduled(messages []ScheduledMessage, limit int) []ScheduledMessage {
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].PublishAt.Before(messages[j].PublishAt)
+	})
+
+	if limit > 0 && len(messages) > limit {
+		messages = messages[:limit]
+	}
+
+	return messages
+}
@@ -0,0 +1,195 @@
+package gorabbit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+	"github.com/KardinalAI/gorabbit/gorabbittest"
+)
+
+// reminderRecorder records the reminders consumed from a queue.
+type reminderRecorder struct {
+	mutex     sync.Mutex
+	reminders []string
+}
+
+func (r *reminderRecorder) handle(_ context.Context, payload []byte) error {
+	var reminder string
+
+	if err := json.Unmarshal(payload, &reminder); err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.reminders = append(r.reminders, reminder)
+
+	return nil
+}
+
+func (r *reminderRecorder) received() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]string(nil), r.reminders...)
+}
+
+func TestScheduler_PublishAt(t *testing.T) {
+	broker := gorabbittest.NewBroker()
+	client := broker.Client()
+
+	recorder := &reminderRecorder{}
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue:           "reminders",
+		Name:            "reminders",
+		QueueConfig:     &gorabbit.QueueConfig{},
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{"reminders": recorder.handle},
+	}))
+
+	store := gorabbit.NewMemorySchedulerStore()
+
+	scheduler, err := gorabbit.NewScheduler(client, client, gorabbit.SchedulerOptions{
+		Name:         "reminders",
+		Store:        store,
+		PollInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	defer scheduler.Stop()
+
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err = scheduler.PublishAt(ctx, now.Add(50*time.Millisecond), "", "reminders", "second", nil)
+	require.NoError(t, err)
+
+	_, err = scheduler.PublishAt(ctx, now.Add(-time.Minute), "", "reminders", "first", nil)
+	require.NoError(t, err)
+
+	cancelled, err := scheduler.PublishAt(ctx, now.Add(20*time.Millisecond), "", "reminders", "cancelled", nil)
+	require.NoError(t, err)
+	require.NoError(t, scheduler.Cancel(ctx, cancelled))
+
+	later, err := scheduler.PublishAt(ctx, now.Add(time.Hour), "", "reminders", "later", gorabbit.SendOptions().SetPriority(gorabbit.PriorityHigh))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return len(recorder.received()) == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"first", "second"}, recorder.received())
+
+	// Only the message scheduled later is still in the store.
+	due, err := store.Due(ctx, now.Add(2*time.Hour), 0)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, later, due[0].ID)
+	assert.Equal(t, gorabbit.PriorityHigh, *due[0].Options.MessagePriority)
+}
+
+func TestScheduler_DispatchFailed(t *testing.T) {
+	client := gorabbittest.NewMockClient()
+	store := gorabbit.NewMemorySchedulerStore()
+
+	var dispatchErrs []error
+
+	scheduler, err := gorabbit.NewScheduler(client, client, gorabbit.SchedulerOptions{
+		Name:         "reminders",
+		Store:        store,
+		PollInterval: time.Hour,
+		OnDispatch: func(_ gorabbit.ScheduledMessage, err error) {
+			dispatchErrs = append(dispatchErrs, err)
+		},
+	})
+	require.NoError(t, err)
+
+	defer scheduler.Stop()
+
+	ctx := context.Background()
+
+	_, err = scheduler.PublishAt(ctx, time.Now(), "", "reminders", "first", nil)
+	require.NoError(t, err)
+
+	// The publishing fails, so the message is kept for the next tick.
+	client.FailPublishing(errors.New("connection lost"))
+
+	assert.Error(t, client.DeliverPayload(ctx, scheduler.Queue(), scheduler.Queue(), nil))
+
+	due, err := store.Due(ctx, time.Now(), 0)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	client.FailPublishing(nil)
+
+	require.NoError(t, client.DeliverPayload(ctx, scheduler.Queue(), scheduler.Queue(), nil))
+
+	due, err = store.Due(ctx, time.Now(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	require.Len(t, dispatchErrs, 2)
+	assert.Error(t, dispatchErrs[0])
+	assert.NoError(t, dispatchErrs[1])
+
+	// The coordination queue has a single active consumer.
+	consumers := client.Consumers()
+	require.Len(t, consumers, 1)
+	assert.Equal(t, "reminders.scheduler", consumers[0].Queue)
+	assert.True(t, consumers[0].QueueConfig.SingleActiveConsumer)
+}
+
+func TestScheduler_Invalid(t *testing.T) {
+	client := gorabbittest.NewMockClient()
+
+	_, err := gorabbit.NewScheduler(client, client, gorabbit.SchedulerOptions{Store: gorabbit.NewMemorySchedulerStore()})
+	assert.Error(t, err)
+
+	_, err = gorabbit.NewScheduler(client, client, gorabbit.SchedulerOptions{Name: "reminders"})
+	assert.Error(t, err)
+}
+
+func TestFileSchedulerStore(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	now := time.Now()
+
+	store := gorabbit.NewFileSchedulerStore(dir)
+
+	require.NoError(t, store.Save(ctx, gorabbit.ScheduledMessage{ID: "b", PublishAt: now.Add(-time.Second), RoutingKey: "b"}))
+	require.NoError(t, store.Save(ctx, gorabbit.ScheduledMessage{ID: "a", PublishAt: now.Add(-time.Minute), RoutingKey: "a"}))
+	require.NoError(t, store.Save(ctx, gorabbit.ScheduledMessage{
+		ID:         "c",
+		PublishAt:  now.Add(time.Hour),
+		RoutingKey: "c",
+		Payload:    json.RawMessage(`{"user":"42"}`),
+	}))
+
+	// The messages survive the store, as they would a restart.
+	store = gorabbit.NewFileSchedulerStore(dir)
+
+	due, err := store.Due(ctx, now, 0)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, "a", due[0].ID)
+	assert.Equal(t, "b", due[1].ID)
+
+	due, err = store.Due(ctx, now.Add(2*time.Hour), 1)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "a", due[0].ID)
+
+	require.NoError(t, store.Delete(ctx, "a"))
+	require.NoError(t, store.Delete(ctx, "a"))
+
+	due, err = store.Due(ctx, now.Add(2*time.Hour), 0)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.JSONEq(t, `{"user":"42"}`, string(due[1].Payload))
+}
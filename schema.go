@@ -0,0 +1,224 @@
+package gorabbit
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Binding destination types of the SchemaDefinitions.
+const (
+	bindingDestinationQueue    = "queue"
+	bindingDestinationExchange = "exchange"
+)
+
+// topologyRegistry records the declared exchanges, queues and bindings, to export them as SchemaDefinitions.
+type topologyRegistry struct {
+	exchanges map[string]SchemaExchange
+	queues    map[string]SchemaQueue
+	bindings  map[bindingKey]SchemaBinding
+	mutex     sync.Mutex
+}
+
+// bindingKey identifies a binding of the SchemaDefinitions, regardless of its arguments.
+type bindingKey struct {
+	source          string
+	destination     string
+	destinationType string
+	routingKey      string
+}
+
+// newTopologyRegistry instantiates a new empty topologyRegistry.
+func newTopologyRegistry() *topologyRegistry {
+	return &topologyRegistry{
+		exchanges: make(map[string]SchemaExchange),
+		queues:    make(map[string]SchemaQueue),
+		bindings:  make(map[bindingKey]SchemaBinding),
+	}
+}
+
+// addExchange records a declared exchange, declared with the given arguments.
+func (r *topologyRegistry) addExchange(config ExchangeConfig, args amqp.Table) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.exchanges[config.Name] = SchemaExchange{
+		Name:       config.Name,
+		Type:       config.Type.String(),
+		Durable:    config.Persisted,
+		AutoDelete: !config.Persisted,
+		Arguments:  schemaArguments(args),
+	}
+}
+
+// addQueue records a declared queue, declared with the given arguments, without its bindings.
+func (r *topologyRegistry) addQueue(config QueueConfig, args amqp.Table) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.queues[config.Name] = SchemaQueue{
+		Name:      config.Name,
+		Durable:   config.Durable,
+		Arguments: schemaArguments(args),
+	}
+}
+
+// addBinding records a declared binding.
+func (r *topologyRegistry) addBinding(source, destination, destinationType, routingKey string, args amqp.Table) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := bindingKey{source: source, destination: destination, destinationType: destinationType, routingKey: routingKey}
+
+	r.bindings[key] = SchemaBinding{
+		Source:          source,
+		Destination:     destination,
+		DestinationType: destinationType,
+		RoutingKey:      routingKey,
+		Arguments:       schemaArguments(args),
+	}
+}
+
+// removeBinding forgets a removed binding.
+func (r *topologyRegistry) removeBinding(source, destination, destinationType, routingKey string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.bindings, bindingKey{source: source, destination: destination, destinationType: destinationType, routingKey: routingKey})
+}
+
+// removeExchange forgets a deleted exchange and its bindings.
+func (r *topologyRegistry) removeExchange(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.exchanges, name)
+
+	for key := range r.bindings {
+		if key.source == name || (key.destinationType != bindingDestinationQueue && key.destination == name) {
+			delete(r.bindings, key)
+		}
+	}
+}
+
+// removeQueue forgets a deleted queue and its bindings.
+func (r *topologyRegistry) removeQueue(name string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.queues, name)
+
+	for key := range r.bindings {
+		if key.destinationType == bindingDestinationQueue && key.destination == name {
+			delete(r.bindings, key)
+		}
+	}
+}
+
+// definitions returns the recorded topology as SchemaDefinitions of the given vhost, sorted for stable diffs.
+func (r *topologyRegistry) definitions(vhost string) SchemaDefinitions {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	definitions := SchemaDefinitions{
+		Exchanges: make([]SchemaExchange, 0, len(r.exchanges)),
+		Queues:    make([]SchemaQueue, 0, len(r.queues)),
+		Bindings:  make([]SchemaBinding, 0, len(r.bindings)),
+	}
+
+	for _, exchange := range r.exchanges {
+		exchange.Vhost = vhost
+		definitions.Exchanges = append(definitions.Exchanges, exchange)
+	}
+
+	for _, queue := range r.queues {
+		queue.Vhost = vhost
+		definitions.Queues = append(definitions.Queues, queue)
+	}
+
+	for _, binding := range r.bindings {
+		binding.Vhost = vhost
+		definitions.Bindings = append(definitions.Bindings, binding)
+	}
+
+	sort.Slice(definitions.Exchanges, func(i, j int) bool {
+		return definitions.Exchanges[i].Name < definitions.Exchanges[j].Name
+	})
+
+	sort.Slice(definitions.Queues, func(i, j int) bool {
+		return definitions.Queues[i].Name < definitions.Queues[j].Name
+	})
+
+	sort.Slice(definitions.Bindings, func(i, j int) bool {
+		a, b := definitions.Bindings[i], definitions.Bindings[j]
+
+		if a.Source != b.Source {
+			return a.Source < b.Source
+		}
+
+		if a.Destination != b.Destination {
+			return a.Destination < b.Destination
+		}
+
+		return a.RoutingKey < b.RoutingKey
+	})
+
+	return definitions
+}
+
+// export returns the recorded topology as a RabbitMQ definitions JSON document.
+func (r *topologyRegistry) export(vhost string) ([]byte, error) {
+	return json.MarshalIndent(r.definitions(vhost), "", "  ")
+}
+
+// schemaArguments returns the arguments of a definition, never nil so that they are exported as an empty object.
+func schemaArguments(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return map[string]interface{}{}
+	}
+
+	return args
+}
+
+// consumersTopology returns the topology carried by the registered consumers: their dead letter exchange, and their
+// queue and bindings if they declare them. Server-named queues are left out, as their name changes with every channel.
+func (a *amqpConnection) consumersTopology() *topologyRegistry {
+	registry := newTopologyRegistry()
+
+	for _, channel := range a.channelList() {
+		consumer := channel.consumer
+		if consumer == nil {
+			continue
+		}
+
+		// Arguments that cannot be converted fail the declaration, so their topology is never declared.
+		if consumer.DeadLetterExchange != nil {
+			if args, err := consumer.DeadLetterExchange.arguments(); err == nil {
+				registry.addExchange(*consumer.DeadLetterExchange, args)
+			}
+		}
+
+		if consumer.QueueConfig == nil || channel.serverNamedQueue {
+			continue
+		}
+
+		config := consumer.queueConfig()
+
+		args, err := config.arguments()
+		if err != nil {
+			continue
+		}
+
+		registry.addQueue(config, args)
+
+		for _, binding := range config.Bindings {
+			if bindingArgs, err := tableArguments(binding.Args); err == nil {
+				registry.addBinding(binding.Exchange, config.Name, bindingDestinationQueue, binding.RoutingKey, bindingArgs)
+			}
+		}
+	}
+
+	return registry
+}
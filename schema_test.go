@@ -0,0 +1,46 @@
+package gorabbit_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMQTTClient_ExportSchema_Disabled(t *testing.T) {
+	t.Setenv("GORABBIT_DISABLED", "true")
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions())
+
+	schema, err := client.ExportSchema()
+	require.NoError(t, err)
+
+	var definitions gorabbit.SchemaDefinitions
+
+	require.NoError(t, json.Unmarshal(schema, &definitions))
+
+	assert.Empty(t, definitions.Exchanges)
+	assert.Empty(t, definitions.Queues)
+	assert.Empty(t, definitions.Bindings)
+	assert.JSONEq(t, `{"exchanges": [], "queues": [], "bindings": []}`, string(schema))
+}
+
+func TestSchemaDefinitions_Arguments(t *testing.T) {
+	content := `{
+		"queues": [{"name": "events_queue", "vhost": "/", "durable": true, "arguments": {"x-message-ttl": 60000}}],
+		"exchanges": [{"name": "events_exchange", "vhost": "/", "type": "topic", "durable": true, "arguments": {}}],
+		"bindings": [{"source": "events_exchange", "destination": "events_queue", "destination_type": "queue", "routing_key": "event.#", "arguments": {}}]
+	}`
+
+	var definitions gorabbit.SchemaDefinitions
+
+	require.NoError(t, json.Unmarshal([]byte(content), &definitions))
+
+	require.Len(t, definitions.Queues, 1)
+	assert.Equal(t, map[string]interface{}{"x-message-ttl": float64(60000)}, definitions.Queues[0].Arguments)
+	assert.Equal(t, "topic", definitions.Exchanges[0].Type)
+	assert.Equal(t, "event.#", definitions.Bindings[0].RoutingKey)
+}
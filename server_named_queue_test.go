@@ -0,0 +1,97 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_ServerNamedQueue(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetHost("127.0.0.1").
+		SetPort(server.port()).
+		SetRetryDelay(10 * time.Millisecond))
+
+	defer func() { _ = client.Disconnect() }()
+
+	received := make(chan string, 10)
+
+	consumer := gorabbit.MessageConsumer{
+		Name: "broadcast",
+		QueueConfig: &gorabbit.QueueConfig{
+			Bindings: []gorabbit.BindingConfig{{Exchange: "events", RoutingKey: "event.#"}},
+		},
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				received <- string(payload)
+
+				return nil
+			},
+		},
+	}
+
+	require.NoError(t, client.RegisterConsumer(consumer))
+
+	// Server-named queues are exclusive to their consumer, so another instance can subscribe alongside.
+	other := consumer
+	other.Name = "broadcast-other"
+	other.QueueConfig = nil
+
+	require.NoError(t, client.RegisterConsumer(other))
+
+	require.Eventually(t, func() bool { return server.consumed("amq.gen-1") && server.consumed("amq.gen-2") }, time.Second, 10*time.Millisecond)
+
+	// The queue named by the server is bound, consumed and reported.
+	assert.Contains(t, server.declarations(), fakeDeclaration{Kind: "binding", Name: queueNamed(t, client, "broadcast"), Exchange: "events", RoutingKey: "event.#"})
+
+	server.deliver(queueNamed(t, client, "broadcast"), "event.created", "first")
+
+	assert.Equal(t, []string{"first"}, receiveAll(t, received, 1))
+
+	// A new queue is declared, bound and consumed with the new channel.
+	server.closeChannel(queueNamed(t, client, "broadcast"))
+
+	require.Eventually(t, func() bool { return server.consumed("amq.gen-3") }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "amq.gen-3", queueNamed(t, client, "broadcast"))
+	assert.Contains(t, server.declarations(), fakeDeclaration{Kind: "binding", Name: "amq.gen-3", Exchange: "events", RoutingKey: "event.#"})
+
+	server.deliver("amq.gen-3", "event.created", "second")
+
+	assert.Equal(t, []string{"second"}, receiveAll(t, received, 1))
+}
+
+// queueNamed returns the queue consumed by the consumer with the given name, as reported by the client.
+func queueNamed(t *testing.T, client gorabbit.MQTTClient, name string) string {
+	t.Helper()
+
+	for _, consumer := range client.HealthReport().Consumers {
+		if consumer.Name == name {
+			return consumer.Queue
+		}
+	}
+
+	require.FailNow(t, "consumer not found", name)
+
+	return ""
+}
+
+func TestClient_ServerNamedQueue_Invalid(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().SetHost("127.0.0.1").SetPort(server.port()))
+
+	defer client.Disconnect()
+
+	err := client.RegisterConsumer(gorabbit.MessageConsumer{
+		Name:     "broadcast",
+		Handlers: gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }},
+		Retry:    &gorabbit.RetryConfig{InitialDelay: time.Second, MaxAttempts: 3},
+	})
+	assert.Error(t, err)
+}
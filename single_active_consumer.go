@@ -0,0 +1,101 @@
+package gorabbit
+
+import "context"
+
+// setActive updates the active state of a SingleActiveConsumer, notifying the OnActiveStateChange callback on change.
+func (c *amqpChannel) setActive(active bool) {
+	if !c.consumer.SingleActiveConsumer {
+		return
+	}
+
+	c.activeMutex.Lock()
+
+	changed := c.active != active
+
+	c.active = active
+
+	c.activeMutex.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if active {
+		c.releaseLogger.Info("Consumer is now the single active consumer")
+	} else {
+		c.logger.Info("Consumer is now passive")
+	}
+
+	if c.consumer.OnActiveStateChange != nil {
+		c.consumer.OnActiveStateChange(active)
+	}
+}
+
+// isActive returns true if the consumer is receiving deliveries. A consumer that is not a SingleActiveConsumer is
+// always active while its channel is ready.
+func (c *amqpChannel) isActive() bool {
+	if !c.consumer.SingleActiveConsumer {
+		return c.ready()
+	}
+
+	c.activeMutex.Lock()
+	defer c.activeMutex.Unlock()
+
+	return c.active
+}
+
+// handOver cancels the subscription of a SingleActiveConsumer, waits for the processing of its in-flight deliveries
+// and subscribes again, last in line, so that the broker activates the next consumer of the queue. Nothing is done if
+// the consumer is not consuming.
+func (c *amqpChannel) handOver(ctx context.Context) error {
+	c.consumptionMutex.Lock()
+	consumerTag, consumptionDone := c.consumerTag, c.consumptionDone
+	c.consumptionMutex.Unlock()
+
+	if consumptionDone == nil || !c.ready() || c.isDraining() || !c.handingOver.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	defer c.handingOver.Store(false)
+
+	if err := c.channel.Load().Cancel(consumerTag, false); err != nil {
+		c.logger.Error(err, "Could not cancel consumption")
+
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-consumptionDone:
+	}
+
+	// The deliveries received before the cancellation are still processed and acknowledged on this channel.
+	processed := make(chan struct{})
+
+	go func() {
+		c.inFlight.Wait()
+		close(processed)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-processed:
+	}
+
+	c.flushAckBatch()
+
+	c.cancelConsumption()
+
+	c.setActive(false)
+
+	c.logger.Info("Consumer handed its queue over")
+
+	// The consumption starts over, as with a new channel.
+	c.renewConsumption()
+
+	go c.consume()
+
+	return nil
+}
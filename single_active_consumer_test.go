@@ -0,0 +1,76 @@
+package gorabbit_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// activeStates records the active states notified to a SingleActiveConsumer.
+type activeStates struct {
+	mutex  sync.Mutex
+	states []bool
+}
+
+func (a *activeStates) record(active bool) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.states = append(a.states, active)
+}
+
+func (a *activeStates) recorded() []bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return append([]bool(nil), a.states...)
+}
+
+func TestClient_SingleActiveConsumer(t *testing.T) {
+	server := newFakeServer(t)
+	states := &activeStates{}
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{
+		SingleActiveConsumer: true,
+		OnActiveStateChange:  states.record,
+	})
+
+	// The consumer is passive until the broker delivers to it.
+	assert.False(t, client.IsConsumerActive("events"))
+	assert.Empty(t, states.recorded())
+
+	server.deliver("events", "event.created", "first", "second")
+
+	require.Eventually(t, func() bool { return client.IsConsumerActive("events") }, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool { return len(server.settled()) == 2 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []bool{true}, states.recorded())
+
+	// Losing its channel makes the consumer passive, and it stays so once subscribed again.
+	server.closeChannel("events")
+
+	require.Eventually(t, func() bool { return len(server.consumeArguments("events")) == 2 }, time.Second, 10*time.Millisecond)
+
+	assert.False(t, client.IsConsumerActive("events"))
+	assert.Equal(t, []bool{true, false}, states.recorded())
+
+	server.deliver("events", "event.created", "third")
+
+	require.Eventually(t, func() bool { return client.IsConsumerActive("events") }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []bool{true, false, true}, states.recorded())
+}
+
+func TestClient_IsConsumerActive(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{})
+
+	// A consumer that is not a SingleActiveConsumer is active while its channel is ready.
+	assert.True(t, client.IsConsumerActive("events"))
+	assert.False(t, client.IsConsumerActive("unknown"))
+}
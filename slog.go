@@ -0,0 +1,63 @@
+package gorabbit
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// slogLogger logs through a slog.Logger, each LogField becoming an attribute.
+type slogLogger struct {
+	logger    *slog.Logger
+	logFields map[string]interface{}
+}
+
+func newSlogLogger(logger *slog.Logger) Logger {
+	return &slogLogger{
+		logger:    logger,
+		logFields: nil,
+	}
+}
+
+// attributes returns the attributes of a log: the library, the inherited fields sorted by key, then the given fields.
+func (l slogLogger) attributes(fields []LogField) []slog.Attr {
+	attributes := make([]slog.Attr, 0, 1+len(l.logFields)+len(fields))
+
+	attributes = append(attributes, slog.String("library", libraryName))
+
+	keys := make([]string, 0, len(l.logFields))
+
+	for key := range l.logFields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		attributes = append(attributes, slog.Any(key, l.logFields[key]))
+	}
+
+	for _, field := range fields {
+		attributes = append(attributes, slog.Any(field.Key, field.Value))
+	}
+
+	return attributes
+}
+
+func (l slogLogger) Error(err error, s string, fields ...LogField) {
+	attributes := append(l.attributes(fields), slog.Any("error", err))
+
+	l.logger.LogAttrs(context.Background(), slog.LevelError, s, attributes...)
+}
+
+func (l slogLogger) Warn(s string, fields ...LogField) {
+	l.logger.LogAttrs(context.Background(), slog.LevelWarn, s, l.attributes(fields)...)
+}
+
+func (l slogLogger) Info(s string, fields ...LogField) {
+	l.logger.LogAttrs(context.Background(), slog.LevelInfo, s, l.attributes(fields)...)
+}
+
+func (l slogLogger) Debug(s string, fields ...LogField) {
+	l.logger.LogAttrs(context.Background(), slog.LevelDebug, s, l.attributes(fields)...)
+}
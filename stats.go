@@ -0,0 +1,167 @@
+package gorabbit
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// statsRateWindow is the window over which the delivery rate of a consumer is computed, with one bucket per second.
+const statsRateWindow = 60
+
+// ConsumerStats holds live statistics of a registered consumer, for debugging and internal dashboards.
+type ConsumerStats struct {
+	// Name is the name of the consumer.
+	Name string `json:"name"`
+
+	// Queue is the consumed queue.
+	Queue string `json:"queue"`
+
+	// Processed is the number of deliveries passed to a handler since the client started.
+	Processed int64 `json:"processed"`
+
+	// Failed is the number of deliveries whose handler returned an error or panicked.
+	Failed int64 `json:"failed"`
+
+	// Rate is the number of deliveries processed per second over the last minute.
+	Rate float64 `json:"rate"`
+
+	// InFlight is the number of deliveries being processed.
+	InFlight int `json:"in_flight"`
+
+	// LastError is the last error returned by a handler, if any.
+	LastError string `json:"last_error,omitempty"`
+
+	// LastErrorAt is the time of the LastError, nil if there is none.
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+
+	// LastMessageAt is the time the last delivery was processed at, nil if there is none.
+	LastMessageAt *time.Time `json:"last_message_at,omitempty"`
+}
+
+// consumerStats accumulates the statistics of the deliveries processed by a consumer.
+type consumerStats struct {
+	processed     int64
+	failed        int64
+	buckets       [statsRateWindow]int64
+	bucketSeconds [statsRateWindow]int64
+	lastError     string
+	lastErrorAt   time.Time
+	lastMessageAt time.Time
+	mutex         sync.Mutex
+}
+
+// record adds a processed delivery, with the error of its handler if any.
+func (s *consumerStats) record(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	second := now.Unix()
+	bucket := second % statsRateWindow
+
+	if s.bucketSeconds[bucket] != second {
+		s.bucketSeconds[bucket] = second
+		s.buckets[bucket] = 0
+	}
+
+	s.buckets[bucket]++
+	s.processed++
+	s.lastMessageAt = now
+
+	if err != nil {
+		s.failed++
+		s.lastError = err.Error()
+		s.lastErrorAt = now
+	}
+}
+
+// fill sets the accumulated statistics into stats.
+func (s *consumerStats) fill(stats *ConsumerStats) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now().Unix()
+
+	var count int64
+
+	for i, second := range s.bucketSeconds {
+		if now-second < statsRateWindow {
+			count += s.buckets[i]
+		}
+	}
+
+	stats.Processed = s.processed
+	stats.Failed = s.failed
+	stats.Rate = float64(count) / statsRateWindow
+	stats.LastError = s.lastError
+
+	if !s.lastErrorAt.IsZero() {
+		lastErrorAt := s.lastErrorAt
+		stats.LastErrorAt = &lastErrorAt
+	}
+
+	if !s.lastMessageAt.IsZero() {
+		lastMessageAt := s.lastMessageAt
+		stats.LastMessageAt = &lastMessageAt
+	}
+}
+
+// consumerStats returns the statistics of the channel's consumer.
+func (c *amqpChannel) consumerStats() ConsumerStats {
+	stats := ConsumerStats{
+		Name:     c.consumer.Name,
+		Queue:    c.queueName(),
+		InFlight: int(c.inFlightCount.Load()),
+	}
+
+	c.stats.fill(&stats)
+
+	return stats
+}
+
+// consumersStats returns the statistics of all consumers of the connection.
+func (a *amqpConnection) consumersStats() []ConsumerStats {
+	channels := a.channelList()
+	consumers := make([]ConsumerStats, 0, len(channels))
+
+	for _, parent := range channels {
+		if parent.consumer == nil {
+			continue
+		}
+
+		for _, channel := range parent.consumerChannels() {
+			consumers = append(consumers, channel.consumerStats())
+		}
+	}
+
+	return consumers
+}
+
+// statsResponse is the JSON document served by the handler of NewStatsHandler.
+type statsResponse struct {
+	Consumers []ConsumerStats `json:"consumers"`
+}
+
+// NewStatsHandler returns an http.Handler serving the live statistics of the consumers of the client as JSON, to be
+// mounted on an internal endpoint for dashboards and debugging.
+func NewStatsHandler(client MQTTClient) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		consumers := client.ConsumerStats()
+		if consumers == nil {
+			consumers = []ConsumerStats{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		_ = json.NewEncoder(w).Encode(statsResponse{Consumers: consumers})
+	})
+}
@@ -0,0 +1,56 @@
+package gorabbit_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestNewStatsHandler(t *testing.T) {
+	// Nothing listens on the port, so the consumer is registered without receiving any delivery.
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.NoError(t, client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue: "events_queue",
+		Name:  "events_consumer",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error { return nil },
+		},
+	}))
+
+	handler := gorabbit.NewStatsHandler(client)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/stats", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var response struct {
+		Consumers []gorabbit.ConsumerStats `json:"consumers"`
+	}
+
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	require.Len(t, response.Consumers, 1)
+
+	assert.Equal(t, "events_consumer", response.Consumers[0].Name)
+	assert.Equal(t, "events_queue", response.Consumers[0].Queue)
+	assert.Zero(t, response.Consumers[0].Processed)
+	assert.Nil(t, response.Consumers[0].LastMessageAt)
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/stats", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
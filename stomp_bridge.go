@@ -0,0 +1,150 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// stompTopicPrefix is the prefix of the STOMP destinations of the topic exchange of the Web-STOMP plugin.
+const stompTopicPrefix = "/topic/"
+
+// STOMPBridgeOptions define the consumer registered by BridgeToSTOMP.
+type STOMPBridgeOptions struct {
+	// Queue is the queue consumed, declared as durable and bound to the Exchange before consuming. If empty, a
+	// server-named exclusive queue is declared instead, so that every instance of the service bridges the messages.
+	Queue string
+
+	// Exchange is the exchange the queue is bound to. It must already exist.
+	Exchange string
+
+	// RoutingKeys are the filters selecting the bridged messages, the queue being bound with each of them. They can
+	// contain the '*' and '#' wildcards, the '#' wildcard alone aside.
+	RoutingKeys []string
+
+	// Destination returns the routing key a message is bridged with, that is the STOMP destination
+	// "/topic/<routing key>" the browsers subscribe to. Defaults to the routing key of the message. A message whose
+	// destination is empty is not bridged.
+	Destination func(delivery Delivery) string
+
+	// TopicExchange is the topic exchange of the Web-STOMP plugin, amq.topic by default.
+	TopicExchange string
+
+	// Name is the name of the consumer. Defaults to the Queue, or to the Exchange followed by "_stomp_bridge".
+	Name string
+
+	// PrefetchCount is the PrefetchCount of the consumer, the one of the client if 0.
+	PrefetchCount int
+}
+
+// BridgeToSTOMP registers a consumer republishing the messages selected by the routing keys to the topic exchange of
+// the Web-STOMP plugin, so that browsers subscribed to the STOMP destination "/topic/<routing key>" receive them. The
+// messages are republished on the channel they were consumed from, with their decoded payload, content type and
+// headers, as transient messages: the consumer recovers along with the client. A message that cannot be republished
+// triggers the retry mechanism like the error of any other handler.
+//
+// Returns the problems of the options or of the resulting consumer, see RegisterConsumer.
+func BridgeToSTOMP(client ConsumerRegistry, options STOMPBridgeOptions) error {
+	if options.Exchange == "" || len(options.RoutingKeys) == 0 {
+		return fmt.Errorf("%w: the exchange and the routing keys are required", errInvalidSubscription)
+	}
+
+	return client.RegisterConsumer(options.consumer())
+}
+
+// STOMPDestination returns the STOMP destination the browsers subscribe to, through the Web-STOMP plugin, to receive
+// the messages bridged with the given routing key.
+func STOMPDestination(routingKey string) string {
+	return stompTopicPrefix + routingKey
+}
+
+// consumer returns the MessageConsumer of the bridge.
+func (o STOMPBridgeOptions) consumer() MessageConsumer {
+	name := o.Name
+
+	if name == "" {
+		name = o.Queue
+	}
+
+	if name == "" {
+		name = o.Exchange + "_stomp_bridge"
+	}
+
+	topicExchange := o.TopicExchange
+
+	if topicExchange == "" {
+		topicExchange = defaultMQTTExchange
+	}
+
+	handler := func(ctx context.Context, payload []byte) error {
+		delivery, _ := DeliveryFromContext(ctx)
+
+		routingKey := delivery.RoutingKey
+
+		if o.Destination != nil {
+			routingKey = o.Destination(delivery)
+		}
+
+		if routingKey == "" {
+			return nil
+		}
+
+		if delivery.channel == nil {
+			return fmt.Errorf("%w: the delivery cannot be bridged outside of its channel", ErrNotConnected)
+		}
+
+		delivery.Body = payload
+
+		return delivery.channel.forward(ctx, topicExchange, routingKey, delivery)
+	}
+
+	handlers := make(MQTTMessageContextHandlers, len(o.RoutingKeys))
+	bindings := make([]BindingConfig, 0, len(o.RoutingKeys))
+
+	for _, routingKey := range o.RoutingKeys {
+		handlers[routingKey] = handler
+		bindings = append(bindings, BindingConfig{Exchange: o.Exchange, RoutingKey: routingKey})
+	}
+
+	return MessageConsumer{
+		Queue:           o.Queue,
+		Name:            name,
+		PrefetchCount:   o.PrefetchCount,
+		ContextHandlers: handlers,
+		QueueConfig: &QueueConfig{
+			Durable:   o.Queue != "",
+			Exclusive: o.Queue == "",
+			Bindings:  bindings,
+		},
+	}
+}
+
+// forward republishes the payload of a delivery, as a transient message, to the given exchange and routing key.
+func (c *amqpChannel) forward(ctx context.Context, exchange, routingKey string, delivery Delivery) error {
+	headers := amqp.Table{}
+
+	for k, v := range delivery.Headers {
+		headers[k] = v
+	}
+
+	publishing := amqp.Publishing{
+		ContentType:   delivery.ContentType,
+		Body:          delivery.Body,
+		DeliveryMode:  amqp.Transient,
+		MessageId:     delivery.MessageID,
+		CorrelationId: delivery.CorrelationID,
+		Timestamp:     delivery.Timestamp,
+		Headers:       headers,
+	}
+
+	if delivery.acknowledger != nil {
+		publishing.Type = delivery.acknowledger.Type
+	}
+
+	if err := c.channel.Load().PublishWithContext(ctx, exchange, routingKey, false, false, publishing); err != nil {
+		return publishingError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestBridgeToSTOMP(t *testing.T) {
+	registry := &recordingRegistry{}
+
+	err := gorabbit.BridgeToSTOMP(registry, gorabbit.STOMPBridgeOptions{
+		Exchange:    "orders_exchange",
+		RoutingKeys: []string{"order.*.shipped", "order.*.delivered"},
+	})
+	require.NoError(t, err)
+	require.Len(t, registry.consumers, 1)
+
+	consumer := registry.consumers[0]
+
+	// Without a queue, every instance of the service bridges the messages through its own exclusive queue.
+	assert.Equal(t, "orders_exchange_stomp_bridge", consumer.Name)
+	assert.Equal(t, &gorabbit.QueueConfig{
+		Exclusive: true,
+		Bindings: []gorabbit.BindingConfig{
+			{Exchange: "orders_exchange", RoutingKey: "order.*.shipped"},
+			{Exchange: "orders_exchange", RoutingKey: "order.*.delivered"},
+		},
+	}, consumer.QueueConfig)
+
+	require.NotNil(t, consumer.ContextHandlers.FindFunc("order.eu.shipped"))
+	require.NotNil(t, consumer.ContextHandlers.FindFunc("order.eu.delivered"))
+	assert.Nil(t, consumer.ContextHandlers.FindFunc("order.eu.created"))
+
+	// A delivery can only be bridged on the channel it was consumed from.
+	handler := consumer.ContextHandlers.FindFunc("order.eu.shipped")
+	ctx := gorabbit.ContextWithDelivery(context.Background(), gorabbit.Delivery{RoutingKey: "order.eu.shipped"})
+
+	require.ErrorIs(t, handler(ctx, []byte(`{"id":"42"}`)), gorabbit.ErrNotConnected)
+}
+
+func TestBridgeToSTOMP_Destination(t *testing.T) {
+	registry := &recordingRegistry{}
+
+	err := gorabbit.BridgeToSTOMP(registry, gorabbit.STOMPBridgeOptions{
+		Queue:       "dashboard_bridge",
+		Exchange:    "orders_exchange",
+		RoutingKeys: []string{"order.#"},
+		Destination: func(delivery gorabbit.Delivery) string {
+			if delivery.Headers["internal"] == true {
+				return ""
+			}
+
+			return "dashboard." + delivery.RoutingKey
+		},
+	})
+	require.NoError(t, err)
+
+	consumer := registry.consumers[0]
+
+	assert.Equal(t, "dashboard_bridge", consumer.Name)
+	assert.True(t, consumer.QueueConfig.Durable)
+
+	// The messages without destination are acknowledged without being bridged.
+	handler := consumer.ContextHandlers.FindFunc("order.eu.shipped")
+	delivery := gorabbit.Delivery{RoutingKey: "order.eu.shipped", Headers: map[string]interface{}{"internal": true}}
+
+	require.NoError(t, handler(gorabbit.ContextWithDelivery(context.Background(), delivery), nil))
+
+	assert.Equal(t, "/topic/dashboard.order.eu.shipped", gorabbit.STOMPDestination("dashboard.order.eu.shipped"))
+}
+
+func TestBridgeToSTOMP_Errors(t *testing.T) {
+	registry := &recordingRegistry{}
+
+	require.Error(t, gorabbit.BridgeToSTOMP(registry, gorabbit.STOMPBridgeOptions{RoutingKeys: []string{"order.#"}}))
+	require.Error(t, gorabbit.BridgeToSTOMP(registry, gorabbit.STOMPBridgeOptions{Exchange: "orders_exchange"}))
+
+	// The filters are validated like the routing keys of any handler.
+	err := gorabbit.BridgeToSTOMP(registry, gorabbit.STOMPBridgeOptions{Exchange: "orders_exchange", RoutingKeys: []string{"#"}})
+	require.Error(t, err)
+
+	assert.Empty(t, registry.consumers)
+}
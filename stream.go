@@ -0,0 +1,103 @@
+package gorabbit
+
+import (
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Stream arguments.
+const (
+	argStreamOffset = "x-stream-offset"
+)
+
+// StreamOffset defines where the consumption of a stream queue starts.
+type StreamOffset struct {
+	value interface{}
+}
+
+// StreamOffsetFirst starts the consumption at the first message available in the stream.
+func StreamOffsetFirst() StreamOffset {
+	return StreamOffset{value: "first"}
+}
+
+// StreamOffsetLast starts the consumption at the last chunk of messages written to the stream.
+func StreamOffsetLast() StreamOffset {
+	return StreamOffset{value: "last"}
+}
+
+// StreamOffsetNext starts the consumption with the next message written to the stream.
+func StreamOffsetNext() StreamOffset {
+	return StreamOffset{value: "next"}
+}
+
+// StreamOffsetAt starts the consumption at the given offset.
+func StreamOffsetAt(offset int64) StreamOffset {
+	return StreamOffset{value: offset}
+}
+
+// StreamOffsetFrom starts the consumption with the messages written to the stream after the given time.
+func StreamOffsetFrom(t time.Time) StreamOffset {
+	return StreamOffset{value: t}
+}
+
+// String returns a readable representation of the offset.
+func (s StreamOffset) String() string {
+	return fmt.Sprintf("%v", s.value)
+}
+
+// validateStreamConsumer verifies that a consumer is compatible with the consumption of a stream queue.
+func validateStreamConsumer(consumer MessageConsumer) error {
+	if consumer.autoAck() {
+		return fmt.Errorf("the stream consumer '%s' cannot use auto acknowledgement", consumer.Name)
+	}
+
+	if consumer.PrefetchCount <= 0 {
+		return fmt.Errorf("the stream consumer '%s' must define a prefetch count", consumer.Name)
+	}
+
+	return nil
+}
+
+// consumeArguments returns the arguments passed to the Consume method, resuming a stream consumption right after the
+// last processed offset if any.
+func (c *amqpChannel) consumeArguments() amqp.Table {
+	if c.consumer.StreamOffset == nil {
+		return nil
+	}
+
+	offset := *c.consumer.StreamOffset
+
+	if lastOffset, ok := c.lastStreamOffset(); ok {
+		offset = StreamOffsetAt(lastOffset + 1)
+	}
+
+	c.logger.Debug("Consuming stream", LogField{Key: "offset", Value: offset.String()})
+
+	return amqp.Table{argStreamOffset: offset.value}
+}
+
+// lastStreamOffset returns the offset of the last processed stream delivery.
+func (c *amqpChannel) lastStreamOffset() (int64, bool) {
+	c.streamOffsetMutex.Lock()
+	defer c.streamOffsetMutex.Unlock()
+
+	return c.streamOffset, c.streamOffsetSet
+}
+
+// trackStreamOffset records the offset of a processed stream delivery so that the consumption can resume after it.
+func (c *amqpChannel) trackStreamOffset(delivery *amqp.Delivery) {
+	offset, ok := delivery.Headers[argStreamOffset].(int64)
+	if !ok {
+		return
+	}
+
+	c.streamOffsetMutex.Lock()
+	defer c.streamOffsetMutex.Unlock()
+
+	if !c.streamOffsetSet || offset > c.streamOffset {
+		c.streamOffset = offset
+		c.streamOffsetSet = true
+	}
+}
@@ -0,0 +1,1039 @@
+package gorabbit
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StreamMessage is a message consumed from a stream by a StreamConsumer.
+type StreamMessage struct {
+	// Stream is the stream the message was consumed from.
+	Stream string
+
+	// Offset is the offset of the message in the stream.
+	Offset int64
+
+	// Timestamp is the time the chunk of the message was written to the stream.
+	Timestamp time.Time
+
+	// ContentType is the content type of the payload, set by the Marshaller of the publisher.
+	ContentType string
+
+	// Body is the payload of the message.
+	Body []byte
+
+	marshaller Marshaller
+}
+
+// Decode unmarshals the payload of the message into v, with the Marshaller of the StreamClient.
+func (m StreamMessage) Decode(v interface{}) error {
+	metadata := &MessageMetadata{RoutingKey: m.Stream, Type: m.Stream, ContentType: m.ContentType}
+
+	return m.marshaller.Unmarshal(metadata, m.Body, v)
+}
+
+// StreamConsumer defines the consumption of a stream by a StreamClient.
+type StreamConsumer struct {
+	// Name is the reference the offsets of the consumer are stored with in the stream, after each chunk of messages.
+	// A named consumer resumes from the offset following the last one stored, its Offset being used only if none
+	// was. The offsets of an unnamed consumer are not stored.
+	Name string
+
+	// Stream is the consumed stream.
+	Stream string
+
+	// Offset defines where the consumption starts, StreamOffsetNext by default.
+	Offset StreamOffset
+
+	// Handler handles each message. The stream protocol does not acknowledge the messages: an error is logged, the
+	// consumption going on.
+	Handler func(ctx context.Context, message StreamMessage) error
+}
+
+// StreamClient speaks the native stream protocol of RabbitMQ, publishing to and consuming from its streams with their
+// offsets, which the consumption of the streams through AMQP loses along with their throughput. The publishings are
+// encoded as AMQP 1.0 messages, so that they are read by the AMQP consumers of the streams as well. No connection is
+// made until a publishing or a subscription, and a lost connection is opened again by the next publishing, or by the
+// client itself to subscribe the consumers again if KeepAlive is set.
+type StreamClient struct {
+	options    StreamOptions
+	marshaller Marshaller
+	logger     Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mutex protects conn, closed and subscriptions from concurrent access.
+	mutex         sync.Mutex
+	conn          *streamConn
+	closed        bool
+	subscriptions []*streamSubscription
+}
+
+// NewStreamClient will instantiate a new StreamClient. No connection is made until a publishing or a subscription.
+func NewStreamClient(options *StreamOptions) *StreamClient {
+	if options == nil {
+		options = DefaultStreamOptions()
+	}
+
+	opts := *options
+
+	if opts.Credit == 0 {
+		opts.Credit = defaultStreamCredit
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &StreamClient{
+		options:    opts,
+		marshaller: opts.marshaller(),
+		logger:     opts.logger(),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Publish will send the payload to the stream, waiting for the server to confirm it.
+func (c *StreamClient) Publish(stream string, payload interface{}) error {
+	return c.PublishWithContext(context.Background(), stream, payload)
+}
+
+// PublishWithContext behaves like Publish, the context bounding the connection to the server and the wait for the
+// confirmation.
+func (c *StreamClient) PublishWithContext(ctx context.Context, stream string, payload interface{}) error {
+	if stream == "" {
+		return errEmptyStreamName
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	metadata := &MessageMetadata{RoutingKey: stream, Type: stream, Headers: make(map[string]interface{})}
+
+	body, err := c.marshaller.Marshal(metadata, payload)
+	if err != nil {
+		return err
+	}
+
+	conn, err := c.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	publisherID, err := conn.publisher(ctx, stream)
+	if err != nil {
+		return err
+	}
+
+	return conn.publish(ctx, publisherID, encodeStreamMessage(metadata.ContentType, body))
+}
+
+// Subscribe will start consuming the stream with the consumer, until the client is closed.
+// Returns an error if the consumer is invalid, or if the subscription failed.
+func (c *StreamClient) Subscribe(consumer StreamConsumer) error {
+	if consumer.Stream == "" {
+		return errEmptyStreamName
+	}
+
+	if consumer.Handler == nil {
+		return errStreamConsumerHandler
+	}
+
+	conn, err := c.connection(c.ctx)
+	if err != nil {
+		return err
+	}
+
+	subscription := &streamSubscription{consumer: consumer}
+
+	if err = c.subscribe(conn, subscription); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	c.subscriptions = append(c.subscriptions, subscription)
+	c.mutex.Unlock()
+
+	return nil
+}
+
+// IsReady returns true if the client is connected to the server.
+func (c *StreamClient) IsReady() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return !c.closed && c.conn != nil && !c.conn.isClosed()
+}
+
+// IsHealthy returns true if the client is ready and all its consumers are subscribed.
+func (c *StreamClient) IsHealthy() bool {
+	report := c.HealthReport()
+
+	return report.Healthy
+}
+
+// HealthReport returns the health of the connection and of each consumer, the stream of a consumer being reported as
+// its queue.
+func (c *StreamClient) HealthReport() HealthReport {
+	c.mutex.Lock()
+	subscriptions := append([]*streamSubscription(nil), c.subscriptions...)
+	c.mutex.Unlock()
+
+	report := HealthReport{Ready: c.IsReady()}
+	report.Healthy = report.Ready
+
+	for _, subscription := range subscriptions {
+		health := subscription.health()
+
+		report.Healthy = report.Healthy && health.Healthy
+		report.Consumers = append(report.Consumers, health)
+	}
+
+	return report
+}
+
+// Disconnect closes the connection to the server, if any, ending the consumption. The StreamClient cannot publish nor
+// subscribe anymore.
+func (c *StreamClient) Disconnect() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.closed = true
+	c.cancel()
+
+	if c.conn == nil {
+		return nil
+	}
+
+	err := c.conn.disconnect()
+
+	c.conn = nil
+
+	return err
+}
+
+// connection returns the current connection, or a new one if there is none or it was lost.
+func (c *StreamClient) connection(ctx context.Context) (*streamConn, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.closed {
+		return nil, errConnectionClosed
+	}
+
+	if c.conn != nil && !c.conn.isClosed() {
+		return c.conn, nil
+	}
+
+	conn, err := dialStream(ctx, c.options, c.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	c.conn = conn
+
+	go c.watch(conn)
+
+	return conn, nil
+}
+
+// watch waits for the connection to be lost, and recovers it to subscribe the consumers again if KeepAlive is set.
+func (c *StreamClient) watch(conn *streamConn) {
+	<-conn.done
+
+	c.mutex.Lock()
+	closed, subscriptions := c.closed, append([]*streamSubscription(nil), c.subscriptions...)
+	c.mutex.Unlock()
+
+	if closed {
+		return
+	}
+
+	c.logger.Warn("Stream connection lost", LogField{Key: "error", Value: conn.closedErr()})
+
+	for _, subscription := range subscriptions {
+		subscription.setSubscribed(false, ErrNotConnected)
+	}
+
+	if !c.options.KeepAlive || len(subscriptions) == 0 {
+		return
+	}
+
+	var recovered *streamConn
+
+	// The consumers that could not be subscribed again are retried on the recovered connection until it is lost, its
+	// own watch subscribing them again then.
+	for len(subscriptions) > 0 {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(c.options.RetryDelay):
+		}
+
+		if recovered == nil {
+			conn, err := c.connection(c.ctx)
+			if err != nil {
+				c.logger.Error(err, "Could not recover the stream connection")
+
+				continue
+			}
+
+			c.logger.Info("Stream connection recovered")
+
+			recovered = conn
+		} else if recovered.isClosed() {
+			return
+		}
+
+		subscriptions = c.resubscribe(recovered, subscriptions)
+	}
+}
+
+// resubscribe subscribes the consumers again on the recovered connection, and returns the ones that failed, whose
+// error is reported by their health.
+func (c *StreamClient) resubscribe(conn *streamConn, subscriptions []*streamSubscription) []*streamSubscription {
+	var failed []*streamSubscription
+
+	for _, subscription := range subscriptions {
+		if err := c.subscribe(conn, subscription); err != nil {
+			c.logger.Error(err, "Could not subscribe the stream consumer again",
+				LogField{Key: "consumer", Value: subscription.consumer.Name},
+				LogField{Key: "stream", Value: subscription.consumer.Stream})
+
+			failed = append(failed, subscription)
+		}
+	}
+
+	return failed
+}
+
+// subscribe subscribes the consumer on the connection, from the offset following the last message it handled, the
+// last offset stored for it or its Offset, whichever is known first, and handles its messages in the background.
+func (c *StreamClient) subscribe(conn *streamConn, subscription *streamSubscription) error {
+	consumer := subscription.consumer
+
+	offsetType, offset, positioned := subscription.position()
+
+	if !positioned && consumer.Name != "" {
+		stored, err := conn.queryOffset(c.ctx, consumer.Name, consumer.Stream)
+
+		switch {
+		case err == nil:
+			offsetType, offset = streamOffsetTypeOffset, stored+1
+			subscription.resume(offset)
+		case !errors.Is(err, streamResponseError(streamCodeNoOffset)):
+			subscription.setSubscribed(false, err)
+
+			return err
+		}
+	}
+
+	if !positioned && offsetType == 0 {
+		offsetType, offset = streamOffsetSpec(consumer.Offset)
+
+		if offsetType == streamOffsetTypeOffset {
+			subscription.resume(offset)
+		}
+	}
+
+	id, chunks, err := conn.subscribe(c.ctx, consumer.Stream, offsetType, offset, c.options.Credit)
+	if err != nil {
+		subscription.setSubscribed(false, err)
+
+		return err
+	}
+
+	subscription.setSubscribed(true, nil)
+
+	go c.consume(conn, id, subscription, chunks)
+
+	return nil
+}
+
+// consume handles the chunks delivered to the subscription until the connection is lost, storing the offset of the
+// last message of each chunk and granting a credit for the next one.
+func (c *StreamClient) consume(conn *streamConn, id uint8, subscription *streamSubscription, chunks <-chan streamChunk) {
+	consumer := subscription.consumer
+
+	for chunk := range chunks {
+		last, handled := uint64(0), false
+
+		for _, record := range chunk.records {
+			if !subscription.accept(record.offset) {
+				continue
+			}
+
+			c.handle(consumer, chunk, record)
+
+			last, handled = record.offset, true
+		}
+
+		if handled && consumer.Name != "" {
+			if err := conn.storeOffset(consumer.Name, consumer.Stream, last); err != nil {
+				c.logger.Error(err, "Could not store the stream offset", LogField{Key: "consumer", Value: consumer.Name})
+			}
+		}
+
+		if err := conn.credit(id); err != nil {
+			return
+		}
+	}
+}
+
+// handle passes a record to the handler of the consumer, logging its error.
+func (c *StreamClient) handle(consumer StreamConsumer, chunk streamChunk, record streamRecord) {
+	contentType, body, err := decodeStreamMessage(record.data)
+	if err != nil {
+		c.logger.Error(err, "Could not decode the stream message",
+			LogField{Key: "stream", Value: consumer.Stream}, LogField{Key: "offset", Value: record.offset})
+
+		return
+	}
+
+	message := StreamMessage{
+		Stream:      consumer.Stream,
+		Offset:      int64(record.offset),
+		Timestamp:   time.UnixMilli(chunk.timestamp),
+		ContentType: contentType,
+		Body:        body,
+		marshaller:  c.marshaller,
+	}
+
+	if err = consumer.Handler(c.ctx, message); err != nil {
+		c.logger.Error(err, "Stream message handler failed",
+			LogField{Key: "consumer", Value: consumer.Name},
+			LogField{Key: "stream", Value: consumer.Stream},
+			LogField{Key: "offset", Value: record.offset})
+	}
+}
+
+// streamOffsetSpec returns the offset type and the offset of the subscriptions starting at the given StreamOffset.
+func streamOffsetSpec(offset StreamOffset) (uint16, uint64) {
+	switch value := offset.value.(type) {
+	case int64:
+		return streamOffsetTypeOffset, uint64(value)
+	case time.Time:
+		return streamOffsetTypeTimestamp, uint64(value.UnixMilli())
+	case string:
+		switch value {
+		case "first":
+			return streamOffsetTypeFirst, 0
+		case "last":
+			return streamOffsetTypeLast, 0
+		}
+	}
+
+	return streamOffsetTypeNext, 0
+}
+
+// streamSubscription tracks the progress of a StreamConsumer across the connections of a StreamClient.
+type streamSubscription struct {
+	consumer StreamConsumer
+
+	// mutex protects the fields below from concurrent access.
+	mutex sync.Mutex
+
+	// next is the offset of the next message to handle, known once positioned.
+	next       uint64
+	positioned bool
+	subscribed bool
+	err        error
+}
+
+// position returns the offset the subscription resumes from, if known.
+func (s *streamSubscription) position() (uint16, uint64, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.positioned {
+		return 0, 0, false
+	}
+
+	return streamOffsetTypeOffset, s.next, true
+}
+
+// resume positions the subscription at the given offset, the server delivering the whole chunk holding it.
+func (s *streamSubscription) resume(offset uint64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.next, s.positioned = offset, true
+}
+
+// accept returns true if the message at the given offset is to be handled, skipping the ones preceding the position
+// of the subscription, and moves the position past it.
+func (s *streamSubscription) accept(offset uint64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.positioned && offset < s.next {
+		return false
+	}
+
+	s.next, s.positioned = offset+1, true
+
+	return true
+}
+
+func (s *streamSubscription) setSubscribed(subscribed bool, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.subscribed, s.err = subscribed, err
+}
+
+func (s *streamSubscription) health() ConsumerHealth {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return ConsumerHealth{
+		Name:    s.consumer.Name,
+		Queue:   s.consumer.Stream,
+		Healthy: s.subscribed,
+		Active:  s.subscribed,
+		Err:     s.err,
+	}
+}
+
+// streamResponseError is the error of a response of the server whose code is not OK.
+type streamResponseError uint16
+
+func (e streamResponseError) Error() string {
+	return fmt.Sprintf("stream response code %d", uint16(e))
+}
+
+// Is matches the response codes with the errors of the client failure modes.
+func (e streamResponseError) Is(target error) bool {
+	switch uint16(e) {
+	case streamCodeStreamDoesNotExist, streamCodeStreamNotAvailable:
+		return target == ErrQueueNotFound
+	case streamCodeAuthenticationFailed, streamCodeVhostAccessFailure, streamCodeAccessRefused:
+		return target == ErrAccessRefused
+	default:
+		return false
+	}
+}
+
+// streamConn is a connection to the stream plugin, publishing and consuming until it is lost or disconnected.
+type streamConn struct {
+	conn   net.Conn
+	logger Logger
+
+	// writeMutex serializes the frames written by the operations and the heartbeats.
+	writeMutex sync.Mutex
+
+	// declareMutex serializes the declarations of the publishers.
+	declareMutex sync.Mutex
+
+	// mutex protects the fields below from concurrent access.
+	mutex         sync.Mutex
+	correlationID uint32
+	publishingID  uint64
+	responses     map[uint32]chan *frameReader
+	confirms      map[uint64]chan error
+	publishers    map[string]uint8
+	subscriptions map[uint8]chan streamChunk
+	err           error
+
+	// done is closed once the connection is lost or disconnected, err holding the reason.
+	done chan struct{}
+}
+
+// dialStream opens a connection to the stream plugin and authenticates, within the Timeout of the options and the
+// context.
+func dialStream(ctx context.Context, options StreamOptions, logger Logger) (*streamConn, error) {
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, options.Timeout)
+		defer cancel()
+	}
+
+	address := net.JoinHostPort(options.Host, strconv.Itoa(int(options.Port)))
+
+	var (
+		conn net.Conn
+		err  error
+	)
+
+	if options.UseTLS {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: options.Host, MinVersion: tls.VersionTLS12}}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		conn, err = new(net.Dialer).DialContext(ctx, "tcp", address)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	// The handshake is bounded by the context as well.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	heartbeat, err := handshakeStream(conn, reader, options)
+	if err != nil {
+		_ = conn.Close()
+
+		return nil, err
+	}
+
+	_ = conn.SetDeadline(time.Time{})
+
+	c := &streamConn{
+		conn:          conn,
+		logger:        logger,
+		responses:     make(map[uint32]chan *frameReader),
+		confirms:      make(map[uint64]chan error),
+		publishers:    make(map[string]uint8),
+		subscriptions: make(map[uint8]chan streamChunk),
+		done:          make(chan struct{}),
+	}
+
+	go c.read(reader)
+
+	if heartbeat > 0 {
+		go c.heartbeat(heartbeat)
+	}
+
+	logger.Info("Stream connection opened", LogField{Key: "address", Value: address})
+
+	return c, nil
+}
+
+// handshakeStream exchanges the peer properties, authenticates with PLAIN, tunes the heartbeat and opens the vhost.
+// Returns the negotiated heartbeat interval.
+func handshakeStream(conn net.Conn, reader *bufio.Reader, options StreamOptions) (time.Duration, error) {
+	properties := map[string]string{"product": libraryName}
+
+	credentials := append(append([]byte{0}, options.Username...), 0)
+	credentials = append(credentials, options.Password...)
+
+	steps := []struct {
+		command uint16
+		fields  *frameBuffer
+	}{
+		{streamPeerProperties, new(frameBuffer).properties([]string{"product"}, properties)},
+		{streamSaslHandshake, new(frameBuffer)},
+		{streamSaslAuthenticate, new(frameBuffer).string("PLAIN").bytes(credentials)},
+	}
+
+	correlationID := uint32(0)
+
+	for _, step := range steps {
+		correlationID++
+
+		if err := handshakeStreamRequest(conn, reader, step.command, correlationID, step.fields); err != nil {
+			return 0, err
+		}
+	}
+
+	// The server tunes the connection once authenticated, the client answering with the values it agrees to.
+	command, r, err := readStreamFrame(reader)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	if command != streamTune {
+		return 0, fmt.Errorf("%w: expected tune, got %#x", errStreamProtocol, command)
+	}
+
+	frameMax, heartbeat := r.uint32(), time.Duration(r.uint32())*time.Second
+	if heartbeat == 0 || (options.Heartbeat > 0 && options.Heartbeat < heartbeat) {
+		heartbeat = options.Heartbeat.Truncate(time.Second)
+	}
+
+	tune := new(frameBuffer).uint32(frameMax).uint32(uint32(heartbeat / time.Second))
+	if _, err = conn.Write(streamFrame(streamTune, tune)); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	open := new(frameBuffer).string(options.vhost())
+	if err = handshakeStreamRequest(conn, reader, streamOpen, correlationID+1, open); err != nil {
+		return 0, err
+	}
+
+	return heartbeat, nil
+}
+
+// handshakeStreamRequest sends a request of the handshake, and reads its response.
+func handshakeStreamRequest(conn net.Conn, reader *bufio.Reader, command uint16, correlationID uint32, fields *frameBuffer) error {
+	request := new(frameBuffer).uint32(correlationID)
+	request.Write(fields.Bytes())
+
+	if _, err := conn.Write(streamFrame(command, request)); err != nil {
+		return fmt.Errorf("%w: %w", ErrNotConnected, err)
+	}
+
+	for {
+		response, r, err := readStreamFrame(reader)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrNotConnected, err)
+		}
+
+		if response == streamHeartbeat {
+			continue
+		}
+
+		if response != command|streamResponseFlag || r.uint32() != correlationID {
+			return fmt.Errorf("%w: unexpected response %#x to %#x", errStreamProtocol, response, command)
+		}
+
+		if code := r.uint16(); code != streamCodeOK {
+			return fmt.Errorf("%w: %w", ErrNotConnected, streamResponseError(code))
+		}
+
+		return nil
+	}
+}
+
+// request sends a request and waits for its response until the context is done, returning the fields following the
+// response code.
+func (c *streamConn) request(ctx context.Context, command uint16, fields *frameBuffer) (*frameReader, error) {
+	c.mutex.Lock()
+
+	if c.err != nil {
+		c.mutex.Unlock()
+
+		return nil, c.err
+	}
+
+	c.correlationID++
+	correlationID := c.correlationID
+	response := make(chan *frameReader, 1)
+	c.responses[correlationID] = response
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.responses, correlationID)
+		c.mutex.Unlock()
+	}()
+
+	request := new(frameBuffer).uint32(correlationID)
+	request.Write(fields.Bytes())
+
+	if err := c.write(streamFrame(command, request)); err != nil {
+		return nil, err
+	}
+
+	select {
+	case r := <-response:
+		if code := r.uint16(); code != streamCodeOK {
+			return r, streamResponseError(code)
+		}
+
+		return r, nil
+	case <-c.done:
+		return nil, c.closedErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// publisher returns the identifier of the publisher of the stream, declaring it first if needed.
+func (c *streamConn) publisher(ctx context.Context, stream string) (uint8, error) {
+	c.declareMutex.Lock()
+	defer c.declareMutex.Unlock()
+
+	c.mutex.Lock()
+	id, ok := c.publishers[stream]
+	count := len(c.publishers)
+	c.mutex.Unlock()
+
+	if ok {
+		return id, nil
+	}
+
+	if count > 255 {
+		return 0, fmt.Errorf("%w: too many stream publishers", errStreamProtocol)
+	}
+
+	id = uint8(count)
+
+	// The publishers are declared without reference, the server not deduplicating their publishings.
+	fields := new(frameBuffer).uint8(id).string("").string(stream)
+	if _, err := c.request(ctx, streamDeclarePublisher, fields); err != nil {
+		return 0, fmt.Errorf("could not declare the publisher of the stream '%s': %w", stream, err)
+	}
+
+	c.mutex.Lock()
+	c.publishers[stream] = id
+	c.mutex.Unlock()
+
+	return id, nil
+}
+
+// publish sends a message with the publisher, and waits for its confirmation until the context is done.
+func (c *streamConn) publish(ctx context.Context, publisherID uint8, message []byte) error {
+	c.mutex.Lock()
+
+	if c.err != nil {
+		c.mutex.Unlock()
+
+		return c.err
+	}
+
+	c.publishingID++
+	publishingID := c.publishingID
+	confirm := make(chan error, 1)
+	c.confirms[publishingID] = confirm
+	c.mutex.Unlock()
+
+	defer func() {
+		c.mutex.Lock()
+		delete(c.confirms, publishingID)
+		c.mutex.Unlock()
+	}()
+
+	fields := new(frameBuffer).uint8(publisherID).uint32(1).uint64(publishingID).bytes(message)
+	if err := c.write(streamFrame(streamPublish, fields)); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-confirm:
+		return err
+	case <-c.done:
+		return c.closedErr()
+	case <-ctx.Done():
+		return fmt.Errorf("%w: %w", errPublishingNotConfirmed, ctx.Err())
+	}
+}
+
+// subscribe subscribes to the stream, returning the identifier of the subscription and the channel of its chunks,
+// closed once the connection is lost.
+func (c *streamConn) subscribe(ctx context.Context, stream string, offsetType uint16, offset uint64, credit uint16) (uint8, <-chan streamChunk, error) {
+	c.mutex.Lock()
+
+	if c.err != nil {
+		c.mutex.Unlock()
+
+		return 0, nil, c.err
+	}
+
+	if len(c.subscriptions) > 255 {
+		c.mutex.Unlock()
+
+		return 0, nil, fmt.Errorf("%w: too many stream subscriptions", errStreamProtocol)
+	}
+
+	id := uint8(len(c.subscriptions))
+
+	// The channel holds as many chunks as the credit, so that the deliveries never block the reads.
+	chunks := make(chan streamChunk, credit)
+	c.subscriptions[id] = chunks
+	c.mutex.Unlock()
+
+	fields := new(frameBuffer).uint8(id).string(stream).uint16(offsetType)
+	if offsetType == streamOffsetTypeOffset || offsetType == streamOffsetTypeTimestamp {
+		fields.uint64(offset)
+	}
+
+	fields.uint16(credit)
+
+	if _, err := c.request(ctx, streamSubscribe, fields); err != nil {
+		return 0, nil, fmt.Errorf("could not subscribe to the stream '%s': %w", stream, err)
+	}
+
+	return id, chunks, nil
+}
+
+// credit grants a chunk to the subscription.
+func (c *streamConn) credit(id uint8) error {
+	return c.write(streamFrame(streamCredit, new(frameBuffer).uint8(id).uint16(1)))
+}
+
+// storeOffset stores the offset of the consumer in the stream.
+func (c *streamConn) storeOffset(reference, stream string, offset uint64) error {
+	return c.write(streamFrame(streamStoreOffset, new(frameBuffer).string(reference).string(stream).uint64(offset)))
+}
+
+// queryOffset returns the offset stored for the consumer in the stream.
+func (c *streamConn) queryOffset(ctx context.Context, reference, stream string) (uint64, error) {
+	r, err := c.request(ctx, streamQueryOffset, new(frameBuffer).string(reference).string(stream))
+	if err != nil {
+		return 0, err
+	}
+
+	return r.uint64(), r.err
+}
+
+func (c *streamConn) write(frame []byte) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	if c.isClosed() {
+		return c.closedErr()
+	}
+
+	if _, err := c.conn.Write(frame); err != nil {
+		c.close(fmt.Errorf("%w: %w", ErrNotConnected, err))
+
+		return c.closedErr()
+	}
+
+	return nil
+}
+
+// read dispatches the frames of the server until the connection is lost, then closes the channels of the
+// subscriptions.
+func (c *streamConn) read(reader *bufio.Reader) {
+	defer func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		for _, chunks := range c.subscriptions {
+			close(chunks)
+		}
+	}()
+
+	for {
+		command, r, err := readStreamFrame(reader)
+		if err != nil {
+			c.close(fmt.Errorf("%w: %w", ErrNotConnected, err))
+
+			return
+		}
+
+		if err = c.dispatch(command, r); err != nil {
+			c.close(err)
+
+			return
+		}
+	}
+}
+
+// dispatch handles a frame of the server.
+func (c *streamConn) dispatch(command uint16, r *frameReader) error {
+	switch command {
+	case streamPublishConfirm, streamPublishError:
+		_ = r.uint8()
+
+		for count := r.uint32(); count > 0 && r.err == nil; count-- {
+			publishingID, err := r.uint64(), error(nil)
+			if command == streamPublishError {
+				err = streamResponseError(r.uint16())
+			}
+
+			c.mutex.Lock()
+			if confirm, ok := c.confirms[publishingID]; ok {
+				confirm <- err
+			}
+			c.mutex.Unlock()
+		}
+	case streamDeliver:
+		id := r.uint8()
+
+		chunk, err := readStreamChunk(r)
+		if err != nil {
+			return err
+		}
+
+		c.mutex.Lock()
+		chunks := c.subscriptions[id]
+		c.mutex.Unlock()
+
+		if chunks != nil {
+			chunks <- chunk
+		}
+	case streamClose:
+		correlationID, code, reason := r.uint32(), r.uint16(), r.string()
+		_ = c.write(streamFrame(streamClose|streamResponseFlag, new(frameBuffer).uint32(correlationID).uint16(streamCodeOK)))
+
+		return fmt.Errorf("%w: stream connection closed by the server with code %d: %s", ErrNotConnected, code, reason)
+	case streamHeartbeat:
+	case streamCredit | streamResponseFlag:
+		code, id := r.uint16(), r.uint8()
+		c.logger.Warn("Stream credit refused", LogField{Key: "subscription", Value: id}, LogField{Key: "code", Value: code})
+	default:
+		if command&streamResponseFlag == 0 {
+			c.logger.Debug("Ignoring stream frame", LogField{Key: "command", Value: command})
+
+			return nil
+		}
+
+		correlationID := r.uint32()
+
+		c.mutex.Lock()
+		if response, ok := c.responses[correlationID]; ok {
+			response <- r
+		}
+		c.mutex.Unlock()
+	}
+
+	return r.err
+}
+
+// heartbeat sends a heartbeat at the given interval until the connection is closed.
+func (c *streamConn) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			_ = c.write(streamFrame(streamHeartbeat, new(frameBuffer)))
+		}
+	}
+}
+
+// disconnect closes the connection, letting the server know first.
+func (c *streamConn) disconnect() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	_, err := c.request(ctx, streamClose, new(frameBuffer).uint16(streamCodeOK).string("disconnected"))
+
+	c.close(errConnectionClosed)
+
+	if errors.Is(err, ErrNotConnected) {
+		return nil
+	}
+
+	return err
+}
+
+// close closes the connection once, failing the pending operations with the given error.
+func (c *streamConn) close(err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.err != nil {
+		return
+	}
+
+	c.err = err
+	close(c.done)
+
+	_ = c.conn.Close()
+}
+
+func (c *streamConn) isClosed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *streamConn) closedErr() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.err
+}
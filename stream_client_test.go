@@ -0,0 +1,624 @@
+package gorabbit_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// fakeStreamChunk is a chunk of messages written to a stream of a fakeStreamServer.
+type fakeStreamChunk struct {
+	offset    uint64
+	timestamp time.Time
+	records   [][]byte
+}
+
+// fakeStreamSubscription is a subscription to a stream of a fakeStreamServer, delivered chunk by chunk as long as it
+// has credits.
+type fakeStreamSubscription struct {
+	conn   *fakeStreamConn
+	id     uint8
+	stream string
+	next   int
+	credit int
+}
+
+// fakeStreamConn is a connection to a fakeStreamServer.
+type fakeStreamConn struct {
+	conn       net.Conn
+	writeMutex sync.Mutex
+	publishers map[uint8]string
+}
+
+// fakeStreamServer is a minimal server of the stream protocol, writing the publishings of the declared streams in
+// chunks of a single message, storing the offsets of the consumers and delivering the chunks to the subscriptions.
+type fakeStreamServer struct {
+	listener net.Listener
+
+	mutex         sync.Mutex
+	password      string
+	streams       map[string][]fakeStreamChunk
+	offsets       map[string]uint64
+	conns         []*fakeStreamConn
+	subscriptions []*fakeStreamSubscription
+
+	// refused holds the number of next subscriptions to each stream refused as unavailable.
+	refused map[string]int
+}
+
+// newFakeStreamServer starts a fakeStreamServer with the given streams, stopped at the end of the test.
+func newFakeStreamServer(t *testing.T, streams ...string) *fakeStreamServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeStreamServer{
+		listener: listener,
+		password: "guest",
+		streams:  make(map[string][]fakeStreamChunk),
+		offsets:  make(map[string]uint64),
+		refused:  make(map[string]int),
+	}
+
+	for _, stream := range streams {
+		s.streams[stream] = nil
+	}
+
+	t.Cleanup(func() {
+		_ = listener.Close()
+		s.drop()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeStreamServer) options() *gorabbit.StreamOptions {
+	return gorabbit.NewStreamOptions().
+		SetPort(uint(s.listener.Addr().(*net.TCPAddr).Port)).
+		SetTimeout(time.Second).
+		SetRetryDelay(50 * time.Millisecond)
+}
+
+// write writes the messages to the stream in a single chunk, delivered to its subscriptions.
+func (s *fakeStreamServer) write(stream string, records ...[]byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	offset := uint64(0)
+
+	if chunks := s.streams[stream]; len(chunks) > 0 {
+		last := chunks[len(chunks)-1]
+		offset = last.offset + uint64(len(last.records))
+	}
+
+	s.streams[stream] = append(s.streams[stream], fakeStreamChunk{offset: offset, timestamp: time.Now(), records: records})
+
+	for _, subscription := range s.subscriptions {
+		s.pump(subscription)
+	}
+}
+
+func (s *fakeStreamServer) storedOffset(reference string) (uint64, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	offset, ok := s.offsets[reference]
+
+	return offset, ok
+}
+
+// refuse refuses the next n subscriptions to the stream as unavailable.
+func (s *fakeStreamServer) refuse(stream string, n int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.refused[stream] = n
+}
+
+// drop closes all the connections, as if the server was restarted.
+func (s *fakeStreamServer) drop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, conn := range s.conns {
+		_ = conn.conn.Close()
+	}
+
+	s.conns, s.subscriptions = nil, nil
+}
+
+// pump delivers the chunks of the stream to the subscription while it has credits.
+func (s *fakeStreamServer) pump(subscription *fakeStreamSubscription) {
+	chunks := s.streams[subscription.stream]
+
+	for subscription.credit > 0 && subscription.next < len(chunks) {
+		chunk := chunks[subscription.next]
+		subscription.next++
+		subscription.credit--
+
+		data := []byte{}
+		for _, record := range chunk.records {
+			data = binary.BigEndian.AppendUint32(data, uint32(len(record)))
+			data = append(data, record...)
+		}
+
+		frame := []byte{subscription.id, 0x50, 0}
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(chunk.records)))
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(chunk.records)))
+		frame = binary.BigEndian.AppendUint64(frame, uint64(chunk.timestamp.UnixMilli()))
+		frame = binary.BigEndian.AppendUint64(frame, 1)
+		frame = binary.BigEndian.AppendUint64(frame, chunk.offset)
+		frame = binary.BigEndian.AppendUint32(frame, 0)
+		frame = binary.BigEndian.AppendUint32(frame, uint32(len(data)))
+		frame = binary.BigEndian.AppendUint32(frame, 0)
+		frame = binary.BigEndian.AppendUint32(frame, 0)
+		frame = append(frame, data...)
+
+		subscription.conn.send(0x0008, frame)
+	}
+}
+
+// start returns the index of the chunk a subscription starts from.
+func (s *fakeStreamServer) start(stream string, offsetType uint16, offset uint64) int {
+	chunks := s.streams[stream]
+
+	switch offsetType {
+	case 1: // first
+		return 0
+	case 2: // last
+		return max(len(chunks)-1, 0)
+	case 4: // offset
+		for i, chunk := range chunks {
+			if offset < chunk.offset+uint64(len(chunk.records)) {
+				return i
+			}
+		}
+	case 5: // timestamp
+		for i, chunk := range chunks {
+			if chunk.timestamp.UnixMilli() >= int64(offset) {
+				return i
+			}
+		}
+	}
+
+	return len(chunks)
+}
+
+func (s *fakeStreamServer) serve(netConn net.Conn) {
+	conn := &fakeStreamConn{conn: netConn, publishers: make(map[uint8]string)}
+
+	s.mutex.Lock()
+	s.conns = append(s.conns, conn)
+	s.mutex.Unlock()
+
+	defer func() { _ = netConn.Close() }()
+
+	for {
+		var size uint32
+		if err := binary.Read(netConn, binary.BigEndian, &size); err != nil {
+			return
+		}
+
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(netConn, frame); err != nil {
+			return
+		}
+
+		r := &fakeStreamReader{data: frame[4:]}
+
+		if !s.handle(conn, binary.BigEndian.Uint16(frame), r) {
+			return
+		}
+	}
+}
+
+// handle answers a frame of a client. Returns false once the connection is closed.
+func (s *fakeStreamServer) handle(conn *fakeStreamConn, command uint16, r *fakeStreamReader) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch command {
+	case 0x0011, 0x0012: // peer properties, sasl handshake
+		conn.respond(command, r.uint32(), 1)
+	case 0x0013: // sasl authenticate
+		correlationID, _, credentials := r.uint32(), r.string(), r.bytes()
+
+		if string(credentials) != "\x00guest\x00"+s.password {
+			conn.respond(command, correlationID, 8)
+
+			return true
+		}
+
+		conn.respond(command, correlationID, 1)
+		conn.send(0x0014, binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(nil, 1048576), 60))
+	case 0x0015: // open
+		conn.respond(command, r.uint32(), 1)
+	case 0x0001: // declare publisher
+		correlationID, id, _, stream := r.uint32(), r.uint8(), r.string(), r.string()
+
+		if _, ok := s.streams[stream]; !ok {
+			conn.respond(command, correlationID, 2)
+
+			return true
+		}
+
+		conn.publishers[id] = stream
+		conn.respond(command, correlationID, 1)
+	case 0x0002: // publish
+		id := r.uint8()
+
+		for count := r.uint32(); count > 0; count-- {
+			publishingID, message := r.uint64(), r.bytes()
+
+			s.mutex.Unlock()
+			s.write(conn.publishers[id], message)
+			s.mutex.Lock()
+
+			confirm := binary.BigEndian.AppendUint32([]byte{id}, 1)
+			conn.send(0x0003, binary.BigEndian.AppendUint64(confirm, publishingID))
+		}
+	case 0x0007: // subscribe
+		correlationID, id, stream, offsetType := r.uint32(), r.uint8(), r.string(), r.uint16()
+
+		offset := uint64(0)
+		if offsetType == 4 || offsetType == 5 {
+			offset = r.uint64()
+		}
+
+		if _, ok := s.streams[stream]; !ok {
+			conn.respond(command, correlationID, 2)
+
+			return true
+		}
+
+		if s.refused[stream] > 0 {
+			s.refused[stream]--
+			conn.respond(command, correlationID, 6)
+
+			return true
+		}
+
+		subscription := &fakeStreamSubscription{
+			conn:   conn,
+			id:     id,
+			stream: stream,
+			next:   s.start(stream, offsetType, offset),
+			credit: int(r.uint16()),
+		}
+
+		s.subscriptions = append(s.subscriptions, subscription)
+		conn.respond(command, correlationID, 1)
+		s.pump(subscription)
+	case 0x0009: // credit
+		id, credit := r.uint8(), r.uint16()
+
+		for _, subscription := range s.subscriptions {
+			if subscription.conn == conn && subscription.id == id {
+				subscription.credit += int(credit)
+				s.pump(subscription)
+			}
+		}
+	case 0x000a: // store offset
+		reference, _, offset := r.string(), r.string(), r.uint64()
+		s.offsets[reference] = offset
+	case 0x000b: // query offset
+		correlationID, reference := r.uint32(), r.string()
+
+		offset, ok := s.offsets[reference]
+		if !ok {
+			conn.respond(command, correlationID, 19, make([]byte, 8)...)
+
+			return true
+		}
+
+		conn.respond(command, correlationID, 1, binary.BigEndian.AppendUint64(nil, offset)...)
+	case 0x0016: // close
+		conn.respond(command, r.uint32(), 1)
+
+		return false
+	}
+
+	return true
+}
+
+func (c *fakeStreamConn) send(command uint16, fields []byte) {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+
+	frame := binary.BigEndian.AppendUint32(nil, uint32(4+len(fields)))
+	frame = binary.BigEndian.AppendUint16(frame, command)
+	frame = binary.BigEndian.AppendUint16(frame, 1)
+
+	_, _ = c.conn.Write(append(frame, fields...))
+}
+
+func (c *fakeStreamConn) respond(command uint16, correlationID uint32, code uint16, fields ...byte) {
+	response := binary.BigEndian.AppendUint32(nil, correlationID)
+	response = binary.BigEndian.AppendUint16(response, code)
+
+	c.send(command|0x8000, append(response, fields...))
+}
+
+// fakeStreamReader decodes the fields of a frame received by a fakeStreamServer.
+type fakeStreamReader struct {
+	data []byte
+}
+
+func (r *fakeStreamReader) next(n int) []byte {
+	v := r.data[:n]
+	r.data = r.data[n:]
+
+	return v
+}
+
+func (r *fakeStreamReader) uint8() uint8 { return r.next(1)[0] }
+
+func (r *fakeStreamReader) uint16() uint16 { return binary.BigEndian.Uint16(r.next(2)) }
+
+func (r *fakeStreamReader) uint32() uint32 { return binary.BigEndian.Uint32(r.next(4)) }
+
+func (r *fakeStreamReader) uint64() uint64 { return binary.BigEndian.Uint64(r.next(8)) }
+
+func (r *fakeStreamReader) string() string { return string(r.next(int(r.uint16()))) }
+
+func (r *fakeStreamReader) bytes() []byte { return r.next(int(r.uint32())) }
+
+// amqp10Message encodes the body as the data section of an AMQP 1.0 message, like the AMQP clients of the streams.
+func amqp10Message(body string) []byte {
+	return append([]byte{0x00, 0x53, 0x75, 0xa0, byte(len(body))}, body...)
+}
+
+// streamMessages collects the messages handled by a StreamConsumer.
+type streamMessages struct {
+	mutex    sync.Mutex
+	received []gorabbit.StreamMessage
+}
+
+func (m *streamMessages) handle(_ context.Context, message gorabbit.StreamMessage) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.received = append(m.received, message)
+
+	return nil
+}
+
+func (m *streamMessages) offsets() []int64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	offsets := make([]int64, 0, len(m.received))
+	for _, message := range m.received {
+		offsets = append(offsets, message.Offset)
+	}
+
+	return offsets
+}
+
+func (m *streamMessages) bodies() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	bodies := make([]string, 0, len(m.received))
+	for _, message := range m.received {
+		bodies = append(bodies, string(message.Body))
+	}
+
+	return bodies
+}
+
+func TestStreamClient_PublishAndSubscribe(t *testing.T) {
+	server := newFakeStreamServer(t, "events")
+
+	client := gorabbit.NewStreamClient(server.options())
+	defer func() { _ = client.Disconnect() }()
+
+	require.NoError(t, client.Publish("events", map[string]string{"id": "1"}))
+	require.NoError(t, client.Publish("events", map[string]string{"id": "2"}))
+
+	var (
+		mutex    sync.Mutex
+		received []map[string]string
+	)
+
+	err := client.Subscribe(gorabbit.StreamConsumer{
+		Name:   "events_consumer",
+		Stream: "events",
+		Offset: gorabbit.StreamOffsetFirst(),
+		Handler: func(_ context.Context, message gorabbit.StreamMessage) error {
+			var payload map[string]string
+			if err := message.Decode(&payload); err != nil {
+				return err
+			}
+
+			assert.Equal(t, "application/json", message.ContentType)
+
+			mutex.Lock()
+			received = append(received, payload)
+			mutex.Unlock()
+
+			return nil
+		},
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		offset, ok := server.storedOffset("events_consumer")
+
+		return ok && offset == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []map[string]string{{"id": "1"}, {"id": "2"}}, received)
+
+	assert.True(t, client.IsHealthy())
+	assert.Equal(t, []gorabbit.ConsumerHealth{
+		{Name: "events_consumer", Queue: "events", Healthy: true, Active: true},
+	}, client.HealthReport().Consumers)
+}
+
+func TestStreamClient_ResumesFromStoredOffset(t *testing.T) {
+	server := newFakeStreamServer(t, "events")
+	server.write("events", amqp10Message("a"), amqp10Message("b"))
+
+	first := gorabbit.NewStreamClient(server.options())
+
+	messages := &streamMessages{}
+
+	consumer := gorabbit.StreamConsumer{
+		Name:    "events_consumer",
+		Stream:  "events",
+		Offset:  gorabbit.StreamOffsetFirst(),
+		Handler: messages.handle,
+	}
+
+	require.NoError(t, first.Subscribe(consumer))
+	require.Eventually(t, func() bool { return len(messages.bodies()) == 2 }, time.Second, 10*time.Millisecond)
+	require.NoError(t, first.Disconnect())
+
+	server.write("events", amqp10Message("c"))
+
+	// The stored offset takes precedence over the Offset of the consumer.
+	second := gorabbit.NewStreamClient(server.options())
+	defer func() { _ = second.Disconnect() }()
+
+	resumed := &streamMessages{}
+	consumer.Handler = resumed.handle
+
+	require.NoError(t, second.Subscribe(consumer))
+	require.Eventually(t, func() bool { return len(resumed.bodies()) == 1 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"c"}, resumed.bodies())
+	assert.Equal(t, []int64{2}, resumed.offsets())
+}
+
+func TestStreamClient_SubscribeAtOffset(t *testing.T) {
+	server := newFakeStreamServer(t, "events")
+	server.write("events", amqp10Message("a"), amqp10Message("b"), amqp10Message("c"))
+
+	client := gorabbit.NewStreamClient(server.options())
+	defer func() { _ = client.Disconnect() }()
+
+	messages := &streamMessages{}
+
+	err := client.Subscribe(gorabbit.StreamConsumer{
+		Stream:  "events",
+		Offset:  gorabbit.StreamOffsetAt(1),
+		Handler: messages.handle,
+	})
+	require.NoError(t, err)
+
+	// The whole chunk is delivered, the messages preceding the offset being skipped.
+	require.Eventually(t, func() bool { return len(messages.bodies()) == 2 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"b", "c"}, messages.bodies())
+	assert.Equal(t, []int64{1, 2}, messages.offsets())
+
+	_, stored := server.storedOffset("")
+	assert.False(t, stored)
+}
+
+func TestStreamClient_Recovery(t *testing.T) {
+	server := newFakeStreamServer(t, "events")
+
+	client := gorabbit.NewStreamClient(server.options())
+	defer func() { _ = client.Disconnect() }()
+
+	messages := &streamMessages{}
+
+	err := client.Subscribe(gorabbit.StreamConsumer{Stream: "events", Handler: messages.handle})
+	require.NoError(t, err)
+
+	server.write("events", amqp10Message("a"))
+	require.Eventually(t, func() bool { return len(messages.bodies()) == 1 }, time.Second, 10*time.Millisecond)
+
+	server.drop()
+
+	require.Eventually(t, func() bool { return !client.IsHealthy() }, time.Second, time.Millisecond)
+
+	report := client.HealthReport()
+	require.Len(t, report.Consumers, 1)
+	require.ErrorIs(t, report.Consumers[0].Err, gorabbit.ErrNotConnected)
+
+	require.Eventually(t, client.IsHealthy, time.Second, 10*time.Millisecond)
+
+	// The consumer resumes after the last message it handled.
+	server.write("events", amqp10Message("b"))
+	require.Eventually(t, func() bool { return len(messages.bodies()) == 2 }, time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, []string{"a", "b"}, messages.bodies())
+}
+
+func TestStreamClient_RecoverySubscriptionFailed(t *testing.T) {
+	server := newFakeStreamServer(t, "events")
+
+	client := gorabbit.NewStreamClient(server.options())
+	defer func() { _ = client.Disconnect() }()
+
+	messages := &streamMessages{}
+
+	err := client.Subscribe(gorabbit.StreamConsumer{Stream: "events", Handler: messages.handle})
+	require.NoError(t, err)
+
+	// The stream is unavailable for the first subscriptions following the recovery.
+	server.refuse("events", 2)
+	server.drop()
+
+	require.Eventually(t, func() bool {
+		report := client.HealthReport()
+
+		return report.Ready && len(report.Consumers) == 1 && errors.Is(report.Consumers[0].Err, gorabbit.ErrQueueNotFound)
+	}, time.Second, time.Millisecond)
+
+	// The consumer is subscribed again once the stream is available.
+	require.Eventually(t, client.IsHealthy, time.Second, 10*time.Millisecond)
+
+	server.write("events", amqp10Message("a"))
+	require.Eventually(t, func() bool { return len(messages.bodies()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestStreamClient_Errors(t *testing.T) {
+	server := newFakeStreamServer(t, "events")
+
+	client := gorabbit.NewStreamClient(server.options())
+
+	require.ErrorIs(t, client.Publish("missing", "payload"), gorabbit.ErrQueueNotFound)
+	require.ErrorIs(t, client.Subscribe(gorabbit.StreamConsumer{Stream: "missing", Handler: (&streamMessages{}).handle}),
+		gorabbit.ErrQueueNotFound)
+
+	require.Error(t, client.Subscribe(gorabbit.StreamConsumer{Handler: (&streamMessages{}).handle}))
+	require.Error(t, client.Subscribe(gorabbit.StreamConsumer{Stream: "events"}))
+
+	require.NoError(t, client.Disconnect())
+	require.ErrorIs(t, client.Publish("events", "payload"), gorabbit.ErrNotConnected)
+
+	server.password = "secret"
+
+	refused := gorabbit.NewStreamClient(server.options())
+	require.ErrorIs(t, refused.Publish("events", "payload"), gorabbit.ErrAccessRefused)
+
+	unreachable := gorabbit.NewStreamClient(gorabbit.NewStreamOptions().SetPort(1))
+	require.ErrorIs(t, unreachable.Publish("events", "payload"), gorabbit.ErrNotConnected)
+	assert.False(t, unreachable.IsReady())
+}
@@ -0,0 +1,195 @@
+package gorabbit
+
+import (
+	"time"
+)
+
+// StreamOptions holds all necessary properties to publish to and consume from the streams of RabbitMQ with a
+// StreamClient, through the stream plugin.
+type StreamOptions struct {
+	// Host is the RabbitMQ server host name.
+	Host string
+
+	// Port is the port number of the stream plugin.
+	Port uint
+
+	// Username is the RabbitMQ server allowed username.
+	Username string
+
+	// Password is the RabbitMQ server allowed password.
+	Password string
+
+	// Vhost is the vhost of the streams.
+	Vhost string
+
+	// UseTLS defines whether the connection is secured with TLS.
+	UseTLS bool
+
+	// Heartbeat is the heartbeat interval requested to the server, the lowest of both being used.
+	Heartbeat time.Duration
+
+	// Timeout bounds the connection to the server, along with the context of the operation opening it.
+	Timeout time.Duration
+
+	// KeepAlive will determine whether the consumers are subscribed again, from the offset following the last
+	// message they handled, once a lost connection is recovered.
+	KeepAlive bool
+
+	// RetryDelay is the delay between each attempt to recover a lost connection, and to subscribe again the consumers
+	// that failed to once it is recovered.
+	RetryDelay time.Duration
+
+	// Credit is the number of chunks of messages the server delivers to a consumer ahead of their handling.
+	Credit uint16
+
+	// Marshaller encodes the payloads of the publishings, JSONMarshaller if nil. Its content type is carried by the
+	// messages, so that StreamMessage.Decode picks the same one.
+	Marshaller Marshaller
+
+	// Mode will specify whether logs are enabled or not.
+	Mode string
+
+	// Logger receives the logs of the client, whatever the Mode.
+	Logger Logger
+}
+
+// DefaultStreamOptions will return a StreamOptions with default values.
+func DefaultStreamOptions() *StreamOptions {
+	return &StreamOptions{
+		Host:       defaultHost,
+		Port:       defaultStreamPort,
+		Username:   defaultUsername,
+		Password:   defaultPassword,
+		Vhost:      defaultVhost,
+		UseTLS:     defaultUseTLS,
+		Heartbeat:  defaultStreamHeartbeat,
+		Timeout:    defaultStreamTimeout,
+		KeepAlive:  defaultKeepAlive,
+		RetryDelay: defaultRetryDelay,
+		Credit:     defaultStreamCredit,
+		Mode:       defaultMode,
+	}
+}
+
+// NewStreamOptions is the exported builder for a StreamOptions and will offer setter methods for an easy construction.
+// Any non-assigned field will be set to default through DefaultStreamOptions.
+func NewStreamOptions() *StreamOptions {
+	return DefaultStreamOptions()
+}
+
+// SetHost will assign the host.
+func (s *StreamOptions) SetHost(host string) *StreamOptions {
+	s.Host = host
+
+	return s
+}
+
+// SetPort will assign the port of the stream plugin.
+func (s *StreamOptions) SetPort(port uint) *StreamOptions {
+	s.Port = port
+
+	return s
+}
+
+// SetCredentials will assign the username and password.
+func (s *StreamOptions) SetCredentials(username, password string) *StreamOptions {
+	s.Username = username
+	s.Password = password
+
+	return s
+}
+
+// SetVhost will assign the Vhost.
+func (s *StreamOptions) SetVhost(vhost string) *StreamOptions {
+	s.Vhost = vhost
+
+	return s
+}
+
+// SetUseTLS will assign the UseTLS status.
+func (s *StreamOptions) SetUseTLS(use bool) *StreamOptions {
+	s.UseTLS = use
+
+	return s
+}
+
+// SetHeartbeat will assign the heartbeat interval requested to the server.
+func (s *StreamOptions) SetHeartbeat(heartbeat time.Duration) *StreamOptions {
+	s.Heartbeat = heartbeat
+
+	return s
+}
+
+// SetTimeout will assign the timeout of the connection to the server.
+func (s *StreamOptions) SetTimeout(timeout time.Duration) *StreamOptions {
+	s.Timeout = timeout
+
+	return s
+}
+
+// SetKeepAlive will assign the KeepAlive status.
+func (s *StreamOptions) SetKeepAlive(keepAlive bool) *StreamOptions {
+	s.KeepAlive = keepAlive
+
+	return s
+}
+
+// SetRetryDelay will assign the retry delay.
+func (s *StreamOptions) SetRetryDelay(delay time.Duration) *StreamOptions {
+	s.RetryDelay = delay
+
+	return s
+}
+
+// SetCredit will assign the number of chunks delivered to a consumer ahead of their handling.
+func (s *StreamOptions) SetCredit(credit uint16) *StreamOptions {
+	s.Credit = credit
+
+	return s
+}
+
+// SetMarshaller will assign the Marshaller encoding the payloads.
+func (s *StreamOptions) SetMarshaller(marshaller Marshaller) *StreamOptions {
+	s.Marshaller = marshaller
+
+	return s
+}
+
+// SetMode will assign the mode if valid.
+func (s *StreamOptions) SetMode(mode string) *StreamOptions {
+	if isValidMode(mode) {
+		s.Mode = mode
+	}
+
+	return s
+}
+
+// SetLogger will assign the Logger receiving the logs of the client.
+func (s *StreamOptions) SetLogger(logger Logger) *StreamOptions {
+	s.Logger = logger
+
+	return s
+}
+
+// vhost returns the vhost of the streams, the default vhost being "/".
+func (s *StreamOptions) vhost() string {
+	if s.Vhost == "" {
+		return "/"
+	}
+
+	return s.Vhost
+}
+
+// marshaller returns the Marshaller, or the JSONMarshaller if not set.
+func (s *StreamOptions) marshaller() Marshaller {
+	if s.Marshaller == nil {
+		return JSONMarshaller{}
+	}
+
+	return s.Marshaller
+}
+
+// logger returns the Logger of a StreamClient, logging like the one of a client with the same mode.
+func (s *StreamOptions) logger() Logger {
+	return newClientLogger(&ClientOptions{Mode: s.Mode, Logger: s.Logger})
+}
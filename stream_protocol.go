@@ -0,0 +1,205 @@
+package gorabbit
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Commands of the stream protocol, the responses setting the high bit of the command of their request.
+const (
+	streamDeclarePublisher uint16 = 0x0001
+	streamPublish          uint16 = 0x0002
+	streamPublishConfirm   uint16 = 0x0003
+	streamPublishError     uint16 = 0x0004
+	streamSubscribe        uint16 = 0x0007
+	streamDeliver          uint16 = 0x0008
+	streamCredit           uint16 = 0x0009
+	streamStoreOffset      uint16 = 0x000a
+	streamQueryOffset      uint16 = 0x000b
+	streamPeerProperties   uint16 = 0x0011
+	streamSaslHandshake    uint16 = 0x0012
+	streamSaslAuthenticate uint16 = 0x0013
+	streamTune             uint16 = 0x0014
+	streamOpen             uint16 = 0x0015
+	streamClose            uint16 = 0x0016
+	streamHeartbeat        uint16 = 0x0017
+	streamResponseFlag     uint16 = 0x8000
+	streamProtocolVersion  uint16 = 1
+)
+
+// Response codes of the stream protocol.
+const (
+	streamCodeOK                   uint16 = 1
+	streamCodeStreamDoesNotExist   uint16 = 2
+	streamCodeStreamNotAvailable   uint16 = 6
+	streamCodeAuthenticationFailed uint16 = 8
+	streamCodeVhostAccessFailure   uint16 = 12
+	streamCodeAccessRefused        uint16 = 16
+	streamCodeNoOffset             uint16 = 19
+)
+
+// Offset types of the subscriptions of the stream protocol.
+const (
+	streamOffsetTypeFirst     uint16 = 1
+	streamOffsetTypeLast      uint16 = 2
+	streamOffsetTypeNext      uint16 = 3
+	streamOffsetTypeOffset    uint16 = 4
+	streamOffsetTypeTimestamp uint16 = 5
+)
+
+// properties encodes a map of strings, in a stable order.
+func (b *frameBuffer) properties(keys []string, values map[string]string) *frameBuffer {
+	b.uint32(uint32(len(keys)))
+
+	for _, key := range keys {
+		b.string(key).string(values[key])
+	}
+
+	return b
+}
+
+// streamFrame returns the frame of the command, prefixed with its size.
+func streamFrame(command uint16, fields *frameBuffer) []byte {
+	frame := new(frameBuffer)
+	frame.uint32(uint32(4 + fields.Len()))
+	frame.uint16(command).uint16(streamProtocolVersion)
+	frame.Write(fields.Bytes())
+
+	return frame.Bytes()
+}
+
+// readStreamFrame reads the next frame, returning its command and its fields.
+func readStreamFrame(reader io.Reader) (uint16, *frameReader, error) {
+	var size uint32
+	if err := binary.Read(reader, binary.BigEndian, &size); err != nil {
+		return 0, nil, err
+	}
+
+	if size < 4 {
+		return 0, nil, fmt.Errorf("%w: stream frame of %d bytes", errStreamProtocol, size)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return 0, nil, err
+	}
+
+	r := &frameReader{data: frame}
+	command := r.uint16()
+	_ = r.uint16()
+
+	return command, r, nil
+}
+
+// streamRecord is a record of a chunk delivered to a subscription.
+type streamRecord struct {
+	offset uint64
+	data   []byte
+}
+
+// streamChunk is a chunk of records delivered to a subscription.
+type streamChunk struct {
+	timestamp int64
+	records   []streamRecord
+}
+
+// readStreamChunk decodes the chunk of a Deliver frame. The records of the compressed sub-batches are skipped, their
+// offsets being accounted for.
+func readStreamChunk(r *frameReader) (streamChunk, error) {
+	_ = r.uint8() // magic and version
+	_ = r.uint8() // chunk type
+	entries := r.uint16()
+	_ = r.uint32() // records
+	chunk := streamChunk{timestamp: int64(r.uint64())}
+	_ = r.uint64() // epoch
+	offset := r.uint64()
+	_ = r.uint32() // checksum
+	size := r.uint32()
+	_ = r.uint32() // trailer length
+	_ = r.uint32() // reserved
+	data := &frameReader{data: r.next(int(size))}
+
+	if r.err != nil {
+		return chunk, r.err
+	}
+
+	for i := uint16(0); i < entries && data.err == nil; i++ {
+		header := data.uint32()
+
+		// A simple entry holds a single record, its size following the entry type bit.
+		if header&0x80000000 == 0 {
+			chunk.records = append(chunk.records, streamRecord{offset: offset, data: data.next(int(header))})
+			offset++
+
+			continue
+		}
+
+		// A sub-batch holds several records, compressed or not.
+		compression := (header >> 28) & 0x07
+		count := uint64(header >> 12 & 0xFFFF)
+		_ = data.uint32() // uncompressed length
+		batch := &frameReader{data: data.next(int(data.uint32()))}
+
+		if compression != 0 {
+			offset += count
+
+			continue
+		}
+
+		for j := uint64(0); j < count && batch.err == nil; j++ {
+			chunk.records = append(chunk.records, streamRecord{offset: offset, data: batch.next(int(batch.uint32()))})
+			offset++
+		}
+	}
+
+	return chunk, data.err
+}
+
+// encodeStreamMessage encodes the payload as an AMQP 1.0 message, with its content type if any, so that it is read
+// by the AMQP clients of the streams as well.
+func encodeStreamMessage(contentType string, body []byte) []byte {
+	message := new(frameBuffer)
+
+	if contentType != "" {
+		// The content type is the seventh field of the properties, the previous ones being null.
+		fields := make([]interface{}, amqp10ContentIndex+1)
+		fields[amqp10ContentIndex] = amqp10Symbol(contentType)
+
+		encodeAMQP10(message, amqp10Value{descriptor: amqp10Properties, value: fields})
+	}
+
+	encodeAMQP10(message, amqp10Value{descriptor: amqp10Data, value: body})
+
+	return message.Bytes()
+}
+
+// decodeStreamMessage decodes an AMQP 1.0 message, returning its content type, if any, and its body, read from its
+// data sections or its binary or string value.
+func decodeStreamMessage(data []byte) (string, []byte, error) {
+	r := &frameReader{data: data}
+
+	var (
+		contentType string
+		body        []byte
+	)
+
+	for len(r.data) > 0 && r.err == nil {
+		if r.uint8() != amqp10Described || r.uint8() != amqp10SmallULong {
+			return "", nil, fmt.Errorf("%w: unexpected amqp 1.0 section", errStreamProtocol)
+		}
+
+		switch r.uint8() {
+		case amqp10Properties:
+			contentType = readAMQP10ContentType(r)
+		case amqp10Data, amqp10AMQPValue:
+			if value, ok := readAMQP10Binary(r); ok {
+				body = append(body, value...)
+			}
+		default:
+			skipAMQP10Value(r)
+		}
+	}
+
+	return contentType, body, r.err
+}
@@ -0,0 +1,85 @@
+package gorabbit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_StreamConsumer(t *testing.T) {
+	server := newFakeServer(t)
+
+	var (
+		mutex   sync.Mutex
+		offsets []int64
+	)
+
+	first := gorabbit.StreamOffsetFirst()
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		PrefetchCount: 10,
+		StreamOffset:  &first,
+		ContextHandlers: gorabbit.MQTTMessageContextHandlers{
+			"event.created": func(ctx context.Context, _ []byte) error {
+				delivery, _ := gorabbit.DeliveryFromContext(ctx)
+
+				offset, ok := delivery.StreamOffset()
+				require.True(t, ok)
+
+				mutex.Lock()
+				defer mutex.Unlock()
+
+				offsets = append(offsets, offset)
+
+				return nil
+			},
+		},
+	})
+
+	server.deliverMessages("events",
+		fakeDelivery{RoutingKey: "event.created", Body: "first", Headers: amqp.Table{"x-stream-offset": int64(41)}},
+		fakeDelivery{RoutingKey: "event.created", Body: "second", Headers: amqp.Table{"x-stream-offset": int64(42)}},
+	)
+
+	require.Eventually(t, func() bool { return len(server.settled()) == 2 }, time.Second, 10*time.Millisecond)
+
+	mutex.Lock()
+	assert.Equal(t, []int64{41, 42}, offsets)
+	mutex.Unlock()
+
+	// After a channel recovery, the consumption resumes right after the last processed offset.
+	server.closeChannel("events")
+
+	require.Eventually(t, func() bool { return len(server.consumeArguments("events")) == 2 }, time.Second, 10*time.Millisecond)
+
+	arguments := server.consumeArguments("events")
+	assert.Equal(t, "first", arguments[0]["x-stream-offset"])
+	assert.Equal(t, int64(43), arguments[1]["x-stream-offset"])
+}
+
+func TestMessageConsumer_Validate_Stream(t *testing.T) {
+	next := gorabbit.StreamOffsetNext()
+
+	consumer := gorabbit.MessageConsumer{
+		Queue:        "events",
+		Name:         "events",
+		StreamOffset: &next,
+		Handlers:     gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }},
+	}
+
+	// A stream consumer must define a prefetch count.
+	assert.Error(t, consumer.Validate())
+
+	consumer.PrefetchCount = 10
+	assert.NoError(t, consumer.Validate())
+
+	consumer.AutoAck = true
+	assert.Error(t, consumer.Validate())
+}
@@ -0,0 +1,98 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// SubscriptionOptions define the consumer registered by Subscribe.
+type SubscriptionOptions struct {
+	// Queue is the queue consumed, declared as durable and bound to the Exchange before consuming. If empty, a
+	// server-named exclusive queue is declared instead, as broadcast subscribers do.
+	Queue string
+
+	// Exchange is the exchange the queue is bound to. It must already exist.
+	Exchange string
+
+	// RoutingKey is the routing key the queue is bound with, and the routing key of the handler. It can contain the
+	// '*' and '#' wildcards, the '#' wildcard alone aside.
+	RoutingKey string
+
+	// Name is the name of the consumer. Defaults to the Queue, or to the Exchange and RoutingKey.
+	Name string
+
+	// PrefetchCount is the PrefetchCount of the consumer, the one of the client if 0.
+	PrefetchCount int
+
+	// ConcurrentProcess makes the handler process the deliveries concurrently.
+	ConcurrentProcess bool
+
+	// Retry enables, if set, the retry with exponential backoff of the failed deliveries.
+	Retry *RetryConfig
+
+	// MaxDeliveryAttempts is, if positive, the number of attempts after which a failing delivery is quarantined.
+	MaxDeliveryAttempts uint
+
+	// Marshaller decodes the payloads. Defaults to the JSONMarshaller.
+	Marshaller Marshaller
+}
+
+// Subscribe registers a consumer calling the handler with the payload of each delivery decoded as a T. The queue is
+// declared and bound before consuming, and the deliveries are observed by the metrics of the client like those of any
+// other consumer. The payloads that cannot be decoded are dead-lettered without calling the handler, and the errors of
+// the handler trigger the retry mechanism unless classified, see Discard and DeadLetter.
+// The Delivery remains available through the context, see DeliveryFromContext.
+//
+// Returns the problems of the options or of the resulting consumer, see RegisterConsumer.
+func Subscribe[T any](client ConsumerRegistry, options SubscriptionOptions, handler func(ctx context.Context, message T) error) error {
+	if options.Exchange == "" || options.RoutingKey == "" {
+		return fmt.Errorf("%w: the exchange and the routing key are required", errInvalidSubscription)
+	}
+
+	if handler == nil {
+		return fmt.Errorf("%w: the handler is required", errInvalidSubscription)
+	}
+
+	return client.RegisterConsumer(options.consumer(func(ctx context.Context, payload []byte) error {
+		var message T
+
+		delivery, _ := DeliveryFromContext(ctx)
+		delivery.Body = payload
+
+		if err := delivery.Unmarshal(&message); err != nil {
+			return DeadLetter(fmt.Errorf("could not decode the payload as %s: %w", reflect.TypeOf(&message).Elem(), err))
+		}
+
+		return handler(ctx, message)
+	}))
+}
+
+// consumer returns the MessageConsumer of the subscription, calling the given handler.
+func (o SubscriptionOptions) consumer(handler MQTTMessageContextHandlerFunc) MessageConsumer {
+	name := o.Name
+
+	if name == "" {
+		name = o.Queue
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("%s_%s", o.Exchange, o.RoutingKey)
+	}
+
+	return MessageConsumer{
+		Queue:               o.Queue,
+		Name:                name,
+		PrefetchCount:       o.PrefetchCount,
+		ConcurrentProcess:   o.ConcurrentProcess,
+		Retry:               o.Retry,
+		MaxDeliveryAttempts: o.MaxDeliveryAttempts,
+		Marshaller:          o.Marshaller,
+		ContextHandlers:     MQTTMessageContextHandlers{o.RoutingKey: handler},
+		QueueConfig: &QueueConfig{
+			Durable:   o.Queue != "",
+			Exclusive: o.Queue == "",
+			Bindings:  []BindingConfig{{Exchange: o.Exchange, RoutingKey: o.RoutingKey}},
+		},
+	}
+}
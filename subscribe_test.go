@@ -0,0 +1,105 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type recordingRegistry struct {
+	consumers []gorabbit.MessageConsumer
+}
+
+func (r *recordingRegistry) RegisterConsumer(consumer gorabbit.MessageConsumer) error {
+	if err := consumer.Validate(); err != nil {
+		return err
+	}
+
+	r.consumers = append(r.consumers, consumer)
+
+	return nil
+}
+
+type invoiceCreated struct {
+	ID     string  `json:"id"`
+	Amount float64 `json:"amount"`
+}
+
+func TestSubscribe(t *testing.T) {
+	registry := &recordingRegistry{}
+
+	var received []invoiceCreated
+
+	err := gorabbit.Subscribe(registry, gorabbit.SubscriptionOptions{
+		Queue:      "invoices_queue",
+		Exchange:   "billing_exchange",
+		RoutingKey: "invoice.*.created",
+		Retry:      &gorabbit.RetryConfig{InitialDelay: time.Second, Multiplier: 2, MaxAttempts: 3},
+	}, func(_ context.Context, invoice invoiceCreated) error {
+		received = append(received, invoice)
+
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, registry.consumers, 1)
+
+	consumer := registry.consumers[0]
+
+	assert.Equal(t, "invoices_queue", consumer.Name)
+	assert.Equal(t, &gorabbit.QueueConfig{
+		Durable:  true,
+		Bindings: []gorabbit.BindingConfig{{Exchange: "billing_exchange", RoutingKey: "invoice.*.created"}},
+	}, consumer.QueueConfig)
+	assert.NotNil(t, consumer.Retry)
+
+	handler := consumer.ContextHandlers.FindFunc("invoice.eu.created")
+	require.NotNil(t, handler)
+
+	delivery := gorabbit.Delivery{RoutingKey: "invoice.eu.created"}
+	payload := []byte(`{"id":"42","amount":9.5}`)
+
+	require.NoError(t, handler(gorabbit.ContextWithDelivery(context.Background(), delivery), payload))
+	assert.Equal(t, []invoiceCreated{{ID: "42", Amount: 9.5}}, received)
+
+	err = handler(gorabbit.ContextWithDelivery(context.Background(), delivery), []byte("not json"))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "gorabbit_test.invoiceCreated")
+}
+
+func TestSubscribe_Broadcast(t *testing.T) {
+	registry := &recordingRegistry{}
+
+	err := gorabbit.Subscribe(registry, gorabbit.SubscriptionOptions{
+		Exchange:   "events_exchange",
+		RoutingKey: "event.#",
+	}, func(_ context.Context, _ map[string]interface{}) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	consumer := registry.consumers[0]
+
+	assert.Equal(t, "events_exchange_event.#", consumer.Name)
+	assert.Empty(t, consumer.Queue)
+	assert.True(t, consumer.QueueConfig.Exclusive)
+	assert.False(t, consumer.QueueConfig.Durable)
+}
+
+func TestSubscribe_Invalid(t *testing.T) {
+	registry := &recordingRegistry{}
+
+	handler := func(_ context.Context, _ invoiceCreated) error { return nil }
+
+	assert.Error(t, gorabbit.Subscribe(registry, gorabbit.SubscriptionOptions{Queue: "invoices_queue"}, handler))
+	assert.Error(t, gorabbit.Subscribe(registry, gorabbit.SubscriptionOptions{
+		Queue:      "invoices_queue",
+		Exchange:   "billing_exchange",
+		RoutingKey: "#",
+	}, handler))
+	assert.Empty(t, registry.consumers)
+}
@@ -0,0 +1,146 @@
+package gorabbit
+
+import "fmt"
+
+// QueueSubscription defines an additional queue consumed by a MessageConsumer on its own channel, with its own handlers.
+// All other consumption properties are inherited from the MessageConsumer.
+type QueueSubscription struct {
+	// Queue is the queue to consume messages from.
+	Queue string
+
+	// Handlers holds the handlers of the queue's deliveries.
+	Handlers MQTTMessageHandlers
+
+	// ContextHandlers holds the context-aware handlers of the queue's deliveries, looked up before the Handlers.
+	ContextHandlers MQTTMessageContextHandlers
+}
+
+// subscriptionConsumer returns the MessageConsumer of an additional queue, inheriting the consumer's properties.
+func (c MessageConsumer) subscriptionConsumer(subscription QueueSubscription) MessageConsumer {
+	consumer := c
+
+	consumer.Queue = subscription.Queue
+	consumer.Handlers = subscription.Handlers
+	consumer.ContextHandlers = subscription.ContextHandlers
+	consumer.Tag = fmt.Sprintf("%s_%s", c.Tag, subscription.Queue)
+
+	// The topology and the subscriptions only belong to the main queue.
+	consumer.Subscriptions = nil
+	consumer.QueueConfig = nil
+	consumer.DeadLetterExchange = nil
+
+	return consumer
+}
+
+// queues returns all named queues consumed by the consumer.
+func (c MessageConsumer) queues() []string {
+	var queues []string
+
+	// A server-named queue cannot be consumed by another consumer.
+	if c.Queue != "" {
+		queues = append(queues, c.Queue)
+	}
+
+	for _, subscription := range c.Subscriptions {
+		queues = append(queues, subscription.Queue)
+	}
+
+	return queues
+}
+
+// validateSubscriptions verifies that the additional queues of a consumer are valid and distinct.
+func validateSubscriptions(consumer MessageConsumer) error {
+	queues := map[string]bool{consumer.Queue: true}
+
+	for _, subscription := range consumer.Subscriptions {
+		if subscription.Queue == "" {
+			return fmt.Errorf("the consumer '%s' has a subscription without queue", consumer.Name)
+		}
+
+		if queues[subscription.Queue] {
+			return fmt.Errorf("the consumer '%s' subscribes to the queue '%s' twice", consumer.Name, subscription.Queue)
+		}
+
+		queues[subscription.Queue] = true
+
+		if err := subscription.Handlers.Validate(); err != nil {
+			return err
+		}
+
+		if err := subscription.ContextHandlers.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newSubscriptionChannel instantiates the amqpChannel of an additional queue. It shares the native channel of its
+// parent, which manages its lifecycle.
+func newSubscriptionChannel(parent *amqpChannel, subscription QueueSubscription, logger Logger) *amqpChannel {
+	consumer := parent.consumer.subscriptionConsumer(subscription)
+
+	channel := &amqpChannel{
+		ctx:               parent.ctx,
+		retryDelay:        parent.retryDelay,
+		recovery:          parent.recovery,
+		tuning:            parent.tuning,
+		logger:            inheritLogger(logger, consumerLogFields(&consumer)),
+		releaseLogger:     newReleaseLogger(logger, consumerLogFields(&consumer)),
+		connectionType:    connectionTypeConsumer,
+		consumptionHealth: newConsumptionHealth(),
+		declaredQueues:    make(map[string]bool),
+		consumer:          &consumer,
+		handlers:          newHandlerMatcher(&consumer),
+		metrics:           parent.metrics,
+		tracer:            parent.tracer,
+		correlation:       parent.correlation,
+		payloadLogging:    parent.payloadLogging,
+		auditor:           parent.auditor,
+		faults:            parent.faults,
+	}
+
+	channel.connection.Store(parent.connection.Load())
+
+	return channel
+}
+
+// startSubscriptions starts the consumption of the additional queues on the newly opened channel.
+func (c *amqpChannel) startSubscriptions() {
+	for _, subscription := range c.subscriptions {
+		subscription.connection.Store(c.connection.Load())
+		subscription.channel.Store(c.channel.Load())
+
+		// The subscription context does not derive from the parent consumption, so that each can be drained separately.
+		subscription.renewConsumption()
+
+		go subscription.consume()
+	}
+}
+
+// stopSubscriptions cancels the consumption of the additional queues when the channel is closed.
+func (c *amqpChannel) stopSubscriptions() {
+	for _, subscription := range c.subscriptions {
+		subscription.cancelConsumption()
+
+		subscription.setActive(false)
+	}
+}
+
+// consumerChannels returns the channel along with the channels of its additional queues.
+func (c *amqpChannel) consumerChannels() []*amqpChannel {
+	return append([]*amqpChannel{c}, c.subscriptions...)
+}
+
+// consumesAny returns true if the channel or one of its additional queues consumes any of the given queues.
+func (c *amqpChannel) consumesAny(queues []string) bool {
+	for _, channel := range c.consumerChannels() {
+		for _, queue := range queues {
+			if channel.queueName() == queue {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+// receiveAll returns the next count values received, failing the test if they are not received within a second.
+func receiveAll(t *testing.T, received <-chan string, count int) []string {
+	t.Helper()
+
+	var values []string
+
+	for i := 0; i < count; i++ {
+		select {
+		case value := <-received:
+			values = append(values, value)
+		case <-time.After(time.Second):
+			require.FailNow(t, "value not received")
+		}
+	}
+
+	return values
+}
+
+func TestClient_Subscriptions(t *testing.T) {
+	server := newFakeServer(t)
+
+	received := make(chan string, 10)
+
+	client := newConsumingClient(t, server, gorabbit.MessageConsumer{
+		Tag: "worker",
+		Handlers: gorabbit.MQTTMessageHandlers{
+			"event.created": func(payload []byte) error {
+				received <- "events: " + string(payload)
+
+				return nil
+			},
+		},
+		Subscriptions: []gorabbit.QueueSubscription{{
+			Queue: "orders",
+			Handlers: gorabbit.MQTTMessageHandlers{
+				"order.created": func(payload []byte) error {
+					received <- "orders: " + string(payload)
+
+					return nil
+				},
+			},
+		}},
+	})
+
+	// The additional queue is consumed on the channel of the main queue, with its own tag.
+	require.Eventually(t, func() bool { return server.consumed("orders") }, time.Second, 10*time.Millisecond)
+	assert.True(t, server.sameChannel("events", "orders"))
+	assert.Equal(t, []string{"worker_orders"}, server.consumerTags("orders"))
+
+	// Each queue dispatches its deliveries to its own handlers.
+	server.deliver("events", "event.created", "first")
+	server.deliver("orders", "order.created", "second")
+
+	assert.ElementsMatch(t, []string{"events: first", "orders: second"}, receiveAll(t, received, 2))
+
+	report := client.HealthReport()
+	require.Len(t, report.Consumers, 2)
+	assert.Equal(t, "orders", report.Consumers[1].Queue)
+	assert.True(t, report.Consumers[1].Healthy)
+
+	// Both queues are consumed again once the channel is recovered.
+	server.closeChannel("events")
+
+	require.Eventually(t, func() bool {
+		return len(server.consumerTags("orders")) == 2 && server.sameChannel("events", "orders")
+	}, time.Second, 10*time.Millisecond)
+
+	server.deliver("orders", "order.created", "third")
+
+	assert.Equal(t, []string{"orders: third"}, receiveAll(t, received, 1))
+}
+
+func TestClient_Subscriptions_Invalid(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().SetHost("127.0.0.1").SetPort(server.port()))
+
+	defer client.Disconnect()
+
+	handlers := gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }}
+
+	for _, subscription := range []gorabbit.QueueSubscription{
+		{Handlers: handlers},
+		{Queue: "events", Handlers: handlers},
+	} {
+		err := client.RegisterConsumer(gorabbit.MessageConsumer{
+			Queue:         "events",
+			Name:          "events",
+			Handlers:      handlers,
+			Subscriptions: []gorabbit.QueueSubscription{subscription},
+		})
+		assert.Error(t, err)
+	}
+}
@@ -0,0 +1,137 @@
+package gorabbit
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+)
+
+// Arguments of the super streams.
+const (
+	// argSuperStream flags the exchange routing to the partitions of a super stream.
+	argSuperStream = "x-super-stream"
+
+	// argStreamPartitionOrder is the binding argument holding the index of a partition.
+	argStreamPartitionOrder = "x-stream-partition-order"
+)
+
+// superStreamHashSeed is the murmur3 seed used by the stream clients to pick the partition of a routing key, so that
+// the messages published through this library reach the same partition as those published by the stream clients.
+const superStreamHashSeed = 104729
+
+// SuperStreamConfig defines a super stream: a partitioned stream made of several stream queues, named after the super
+// stream and their index, and of a direct exchange routing the messages to them by partition index.
+type SuperStreamConfig struct {
+	// Name is the name of the super stream and of its exchange. Partitions are named "<Name>-<index>".
+	Name string
+
+	// Partitions is the number of partitions.
+	Partitions int
+
+	// Args defines the arguments of each partition, such as "x-max-length-bytes" or "x-max-age".
+	Args map[string]interface{}
+}
+
+// SuperStream returns the exchange and the partitions of a super stream, to be declared with the manager's
+// SetupTopology. The partitions are bound with their index as routing key, in the layout of the streams plugin, so
+// that the super stream can be used by the stream clients as well.
+func SuperStream(config SuperStreamConfig) (ExchangeConfig, []QueueConfig, error) {
+	if config.Name == "" {
+		return ExchangeConfig{}, nil, errors.New("the super stream name cannot be empty")
+	}
+
+	if config.Partitions <= 0 {
+		return ExchangeConfig{}, nil, fmt.Errorf("the super stream '%s' must have at least one partition", config.Name)
+	}
+
+	exchange := ExchangeConfig{
+		Name:      config.Name,
+		Type:      ExchangeTypeDirect,
+		Persisted: true,
+		Args:      map[string]interface{}{argSuperStream: true},
+	}
+
+	partitions := make([]QueueConfig, 0, config.Partitions)
+
+	for i := 0; i < config.Partitions; i++ {
+		partitions = append(partitions, QueueConfig{
+			Name:    SuperStreamPartition(config.Name, i),
+			Durable: true,
+			Type:    QueueTypeStream,
+			Args:    config.Args,
+			Bindings: []BindingConfig{{
+				Exchange:   config.Name,
+				RoutingKey: strconv.Itoa(i),
+				Args:       map[string]interface{}{argStreamPartitionOrder: i},
+			}},
+		})
+	}
+
+	return exchange, partitions, nil
+}
+
+// SuperStreamPartition returns the name of the partition of a super stream with the given index, to be consumed as a
+// stream queue.
+func SuperStreamPartition(superStream string, index int) string {
+	return fmt.Sprintf("%s-%d", superStream, index)
+}
+
+// superStreamPartitionIndex returns the index of the partition of a partition key, as picked by the stream clients.
+func superStreamPartitionIndex(key string, partitions int) int {
+	return int(murmur3([]byte(key), superStreamHashSeed) % uint32(partitions))
+}
+
+// murmur3 is the 32 bits MurmurHash3 of data.
+func murmur3(data []byte, seed uint32) uint32 {
+	const (
+		c1 = 0xcc9e2d51
+		c2 = 0x1b873593
+	)
+
+	hash := seed
+	length := len(data)
+	blocks := length / 4
+
+	for i := 0; i < blocks; i++ {
+		k := uint32(data[i*4]) | uint32(data[i*4+1])<<8 | uint32(data[i*4+2])<<16 | uint32(data[i*4+3])<<24
+
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+
+		hash ^= k
+		hash = bits.RotateLeft32(hash, 13)
+		hash = hash*5 + 0xe6546b64
+	}
+
+	var k uint32
+
+	tail := data[blocks*4:]
+
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		hash ^= k
+	}
+
+	hash ^= uint32(length)
+	hash ^= hash >> 16
+	hash *= 0x85ebca6b
+	hash ^= hash >> 13
+	hash *= 0xc2b2ae35
+	hash ^= hash >> 16
+
+	return hash
+}
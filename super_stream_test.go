@@ -0,0 +1,46 @@
+package gorabbit_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestSuperStream(t *testing.T) {
+	exchange, partitions, err := gorabbit.SuperStream(gorabbit.SuperStreamConfig{
+		Name:       "orders",
+		Partitions: 3,
+		Args:       map[string]interface{}{"x-max-age": "7D"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "orders", exchange.Name)
+	assert.Equal(t, gorabbit.ExchangeTypeDirect, exchange.Type)
+	assert.Equal(t, true, exchange.Args["x-super-stream"])
+
+	require.Len(t, partitions, 3)
+
+	for i, partition := range partitions {
+		assert.Equal(t, gorabbit.SuperStreamPartition("orders", i), partition.Name)
+		assert.Equal(t, gorabbit.QueueTypeStream, partition.Type)
+		assert.NoError(t, partition.Validate())
+
+		require.Len(t, partition.Bindings, 1)
+		assert.Equal(t, "orders", partition.Bindings[0].Exchange)
+		assert.Equal(t, i, partition.Bindings[0].Args["x-stream-partition-order"])
+	}
+
+	assert.Equal(t, "orders-2", partitions[2].Name)
+	assert.Equal(t, "2", partitions[2].Bindings[0].RoutingKey)
+}
+
+func TestSuperStream_Invalid(t *testing.T) {
+	_, _, err := gorabbit.SuperStream(gorabbit.SuperStreamConfig{Partitions: 3})
+	assert.Error(t, err)
+
+	_, _, err = gorabbit.SuperStream(gorabbit.SuperStreamConfig{Name: "orders"})
+	assert.Error(t, err)
+}
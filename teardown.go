@@ -0,0 +1,141 @@
+package gorabbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Teardown deletes the exchanges and queues created by SetupTopology, typically at the end of integration tests or
+// along with ephemeral environments.
+type Teardown func(ctx context.Context) error
+
+// noTeardown is the Teardown of a topology that created nothing.
+func noTeardown(context.Context) error {
+	return nil
+}
+
+// topologyTeardown records the exchanges and queues created by SetupTopology, in their order of creation.
+type topologyTeardown struct {
+	manager   *mqttManager
+	exchanges []string
+	queues    []string
+}
+
+// createExchange declares an exchange, along with its alternate exchange, and records those that did not exist.
+func (t *topologyTeardown) createExchange(config ExchangeConfig) error {
+	var alternate []string
+
+	if config.AlternateExchange != nil && config.AlternateExchange.Declare {
+		alternate = append(alternate, config.AlternateExchange.Name)
+	}
+
+	created, err := t.missingExchanges(append(alternate, config.Name))
+	if err != nil {
+		return err
+	}
+
+	unroutable := ""
+	if config.AlternateExchange != nil {
+		unroutable = config.AlternateExchange.UnroutableQueue
+	}
+
+	createdQueues, err := t.missingQueues([]string{unroutable})
+	if err != nil {
+		return err
+	}
+
+	err = t.manager.CreateExchange(config)
+
+	// What was declared before a failure must be deleted too.
+	t.exchanges = append(t.exchanges, created...)
+	t.queues = append(t.queues, createdQueues...)
+
+	return err
+}
+
+// createQueue declares a queue, and records it if it did not exist.
+func (t *topologyTeardown) createQueue(config QueueConfig) error {
+	created, err := t.missingQueues([]string{config.Name})
+	if err != nil {
+		return err
+	}
+
+	err = t.manager.CreateQueue(config)
+
+	t.queues = append(t.queues, created...)
+
+	return err
+}
+
+// missingExchanges returns the given exchanges that do not exist.
+func (t *topologyTeardown) missingExchanges(names []string) ([]string, error) {
+	return t.missing(names, func(channel *amqp.Channel, name string) error {
+		return channel.ExchangeDeclarePassive(name, ExchangeTypeDirect.String(), false, false, false, false, nil)
+	})
+}
+
+// missingQueues returns the given queues that do not exist, ignoring empty names.
+func (t *topologyTeardown) missingQueues(names []string) ([]string, error) {
+	return t.missing(names, func(channel *amqp.Channel, name string) error {
+		_, err := channel.QueueDeclarePassive(name, false, false, false, false, nil)
+
+		return err
+	})
+}
+
+// missing returns the given exchanges or queues that the passive declaration reports as not found.
+func (t *topologyTeardown) missing(names []string, passive func(channel *amqp.Channel, name string) error) ([]string, error) {
+	var missing []string
+
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+
+		err := runOnNewChannel(t.manager.connection, func(channel *amqp.Channel) error {
+			return passive(channel, name)
+		})
+
+		switch {
+		case isErrorNotFound(err):
+			missing = append(missing, name)
+		case isErrorResourceLocked(err):
+			// An exclusive queue of another connection exists.
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
+
+// teardown deletes the recorded queues, then exchanges, in the reverse order of their creation. The bindings are
+// deleted along with them. All deletions are attempted, and their errors are joined.
+func (t *topologyTeardown) teardown(ctx context.Context) error {
+	var errs []error
+
+	for i := len(t.queues) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		if err := t.manager.DeleteQueue(t.queues[i]); err != nil {
+			errs = append(errs, fmt.Errorf("could not delete queue '%s': %w", t.queues[i], err))
+		}
+	}
+
+	for i := len(t.exchanges) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+
+		if err := t.manager.DeleteExchange(t.exchanges[i]); err != nil {
+			errs = append(errs, fmt.Errorf("could not delete exchange '%s': %w", t.exchanges[i], err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,28 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestMQTTManager_SetupTopology_Disabled(t *testing.T) {
+	t.Setenv("GORABBIT_DISABLED", "true")
+
+	manager, err := gorabbit.NewManager(gorabbit.NewManagerOptions())
+	require.NoError(t, err)
+
+	teardown, err := manager.SetupTopology(context.Background(), []gorabbit.ExchangeConfig{
+		{Name: "events_exchange", Type: gorabbit.ExchangeTypeTopic},
+	}, []gorabbit.QueueConfig{
+		{Name: "events_queue"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, teardown)
+
+	assert.NoError(t, teardown(context.Background()))
+}
@@ -0,0 +1,211 @@
+package gorabbit
+
+import (
+	"fmt"
+)
+
+// validateConsumerTopology verifies that the topology carried by a consumer is consistent with it.
+func validateConsumerTopology(consumer MessageConsumer) error {
+	if consumer.QueueConfig != nil {
+		if consumer.QueueConfig.Name != "" && consumer.QueueConfig.Name != consumer.Queue {
+			return fmt.Errorf("the consumer '%s' declares the queue '%s' but consumes '%s'", consumer.Name, consumer.QueueConfig.Name, consumer.Queue)
+		}
+
+		if err := consumer.queueConfig().Validate(); err != nil {
+			return err
+		}
+	}
+
+	if consumer.Queue == "" {
+		if consumer.StreamOffset != nil {
+			return fmt.Errorf("the consumer '%s' cannot consume a stream from a server-named queue", consumer.Name)
+		}
+
+		if consumer.Retry != nil {
+			return fmt.Errorf("the consumer '%s' cannot retry with backoff from a server-named queue", consumer.Name)
+		}
+
+		// The default quarantine queue is named after the queue, so a new one would be declared with every channel.
+		if consumer.hasQuarantine() && consumer.QuarantineQueue == "" {
+			return fmt.Errorf("the consumer '%s' must name its quarantine queue to consume a server-named queue", consumer.Name)
+		}
+	}
+
+	if consumer.DeadLetterExchange != nil && consumer.DeadLetterExchange.Name == "" {
+		return fmt.Errorf("the consumer '%s' declares a dead letter exchange without name", consumer.Name)
+	}
+
+	if consumer.DeadLetterExchange != nil && consumer.DeadLetterExchange.AlternateExchange != nil &&
+		consumer.DeadLetterExchange.AlternateExchange.Name == "" {
+		return errEmptyAlternateExchangeName
+	}
+
+	return nil
+}
+
+// hasDeadLetterExchange returns true if the queue of the consumer dead-letters to an exchange, its DeadLetterExchange or
+// the one of its QueueConfig.
+func (c MessageConsumer) hasDeadLetterExchange() bool {
+	if c.DeadLetterExchange != nil {
+		return true
+	}
+
+	if c.QueueConfig == nil {
+		return false
+	}
+
+	_, defined := c.QueueConfig.Args[argDeadLetterExchange]
+
+	return defined
+}
+
+// queueConfig returns the QueueConfig of the consumer, named after its Queue and pointing to its DeadLetterExchange.
+func (c MessageConsumer) queueConfig() QueueConfig {
+	config := *c.QueueConfig
+
+	config.Name = c.Queue
+
+	if c.DeadLetterExchange != nil {
+		if _, defined := config.Args[argDeadLetterExchange]; !defined {
+			args := make(map[string]interface{}, len(config.Args)+1)
+
+			for k, v := range config.Args {
+				args[k] = v
+			}
+
+			args[argDeadLetterExchange] = c.DeadLetterExchange.Name
+
+			config.Args = args
+		}
+	}
+
+	return config
+}
+
+// declareTopology declares the dead letter exchange, the queue and the bindings carried by the consumer, if any.
+// Declarations are idempotent, so the topology is declared again with every new channel.
+func (c *amqpChannel) declareTopology() error {
+	if dlx := c.consumer.DeadLetterExchange; dlx != nil {
+		args, err := dlx.arguments()
+		if err != nil {
+			return err
+		}
+
+		err = c.channel.Load().ExchangeDeclare(
+			dlx.Name,          // name
+			dlx.Type.String(), // type
+			dlx.Persisted,     // durable
+			!dlx.Persisted,    // auto-deleted
+			false,             // internal
+			false,             // no-wait
+			args,              // arguments
+		)
+		if err != nil {
+			return err
+		}
+
+		c.logger.Debug("Dead letter exchange declared", LogField{Key: "exchange", Value: dlx.Name})
+	}
+
+	if c.serverNamedQueue {
+		return c.declareServerNamedQueue()
+	}
+
+	if c.consumer.QueueConfig == nil {
+		return nil
+	}
+
+	config := c.consumer.queueConfig()
+
+	args, err := config.arguments()
+	if err != nil {
+		return err
+	}
+
+	_, err = c.channel.Load().QueueDeclare(
+		config.Name,      // name
+		config.Durable,   // durable
+		false,            // delete when unused
+		config.Exclusive, // exclusive
+		false,            // no-wait
+		args,             // arguments
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range config.Bindings {
+		bindingArgs, err := tableArguments(binding.Args)
+		if err != nil {
+			return err
+		}
+
+		if err = c.channel.Load().QueueBind(config.Name, binding.RoutingKey, binding.Exchange, false, bindingArgs); err != nil {
+			return err
+		}
+	}
+
+	c.logger.Debug("Queue declared", LogField{Key: "bindings", Value: len(config.Bindings)})
+
+	return nil
+}
+
+// declareServerNamedQueue declares a new server-named exclusive queue, binds it and makes it the consumer's queue.
+// The queue is auto-deleted once its consumer is canceled, so a new one is declared with every new channel.
+func (c *amqpChannel) declareServerNamedQueue() error {
+	var config QueueConfig
+
+	if c.consumer.QueueConfig != nil {
+		config = c.consumer.queueConfig()
+	}
+
+	args, err := config.arguments()
+	if err != nil {
+		return err
+	}
+
+	queue, err := c.channel.Load().QueueDeclare(
+		"",    // name
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		args,  // arguments
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, binding := range config.Bindings {
+		bindingArgs, err := tableArguments(binding.Args)
+		if err != nil {
+			return err
+		}
+
+		if err = c.channel.Load().QueueBind(queue.Name, binding.RoutingKey, binding.Exchange, false, bindingArgs); err != nil {
+			return err
+		}
+	}
+
+	c.setQueueName(queue.Name)
+
+	c.logger.Debug("Server-named queue declared", LogField{Key: "queue", Value: queue.Name}, LogField{Key: "bindings", Value: len(config.Bindings)})
+
+	return nil
+}
+
+// queueName returns the name of the queue consumed by the channel, safe to call while a server-named queue is declared.
+func (c *amqpChannel) queueName() string {
+	c.queueNameMutex.RLock()
+	defer c.queueNameMutex.RUnlock()
+
+	return c.consumer.Queue
+}
+
+// setQueueName defines the name of the queue consumed by the channel.
+func (c *amqpChannel) setQueueName(name string) {
+	c.queueNameMutex.Lock()
+	defer c.queueNameMutex.Unlock()
+
+	c.consumer.Queue = name
+}
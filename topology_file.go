@@ -0,0 +1,103 @@
+package gorabbit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// topologyFile is the content of a YAML topology file.
+type topologyFile struct {
+	Exchanges []ExchangeConfig `yaml:"exchanges"`
+	Queues    []QueueConfig    `yaml:"queues"`
+}
+
+// LoadTopology parses a YAML topology file into the exchanges and queues it declares, to be set up with the manager's
+// SetupTopology. The file follows the yaml tags of ExchangeConfig and QueueConfig:
+//
+//	exchanges:
+//	  - name: events_exchange
+//	    type: topic
+//	    persisted: true
+//	  - name: team_exchange
+//	    type: topic
+//	    persisted: true
+//	    bindings:
+//	      - exchange: events_exchange
+//	        routing_key: event.team.#
+//	queues:
+//	  - name: events_queue
+//	    durable: true
+//	    bindings:
+//	      - exchange: events_exchange
+//	        routing_key: event.#
+func LoadTopology(path string) ([]ExchangeConfig, []QueueConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return parseTopology(data)
+}
+
+// LoadTopologyTemplate parses a YAML topology file like LoadTopology, after rendering it as a text/template with the
+// given values, so that a single file serves several environments or tenants:
+//
+//	queues:
+//	  - name: "{{.ServiceName}}.events"
+//	    durable: {{ env "RABBITMQ_DURABLE" | default "true" }}
+//
+// Besides the values, the template can read environment variables with the env function, and fall back on a default
+// value for empty ones with the default function. A value missing from the map is an error, to catch typos.
+func LoadTopologyTemplate(path string, values map[string]interface{}) ([]ExchangeConfig, []QueueConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpl, err := template.New(filepath.Base(path)).
+		Option("missingkey=error").
+		Funcs(topologyTemplateFuncs).
+		Parse(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+
+	var rendered bytes.Buffer
+
+	if err = tmpl.Execute(&rendered, values); err != nil {
+		return nil, nil, err
+	}
+
+	return parseTopology(rendered.Bytes())
+}
+
+// topologyTemplateFuncs are the functions available to the topology templates.
+var topologyTemplateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+
+		return value
+	},
+}
+
+// parseTopology parses the content of a YAML topology file.
+func parseTopology(data []byte) ([]ExchangeConfig, []QueueConfig, error) {
+	var topology topologyFile
+
+	if err := yaml.Unmarshal(data, &topology); err != nil {
+		return nil, nil, err
+	}
+
+	return topology.Exchanges, topology.Queues, nil
+}
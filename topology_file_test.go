@@ -0,0 +1,112 @@
+package gorabbit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestLoadTopology(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.yaml")
+
+	content := `
+exchanges:
+  - name: events_exchange
+    type: topic
+    persisted: true
+  - name: team_exchange
+    type: headers
+    persisted: true
+    bindings:
+      - exchange: events_exchange
+        routing_key: event.team.#
+        args:
+          x-match: any
+          team: toto
+queues:
+  - name: events_queue
+    durable: true
+    type: quorum
+    delivery_limit: 5
+    args:
+      x-message-ttl: 60000
+    bindings:
+      - exchange: events_exchange
+        routing_key: event.#
+`
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	exchanges, queues, err := gorabbit.LoadTopology(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, []gorabbit.ExchangeConfig{
+		{Name: "events_exchange", Type: gorabbit.ExchangeTypeTopic, Persisted: true},
+		{
+			Name:      "team_exchange",
+			Type:      gorabbit.ExchangeTypeHeaders,
+			Persisted: true,
+			Bindings: []gorabbit.BindingConfig{
+				{
+					Exchange:   "events_exchange",
+					RoutingKey: "event.team.#",
+					Args:       map[string]interface{}{"x-match": "any", "team": "toto"},
+				},
+			},
+		},
+	}, exchanges)
+
+	require.Len(t, queues, 1)
+	assert.Equal(t, "events_queue", queues[0].Name)
+	assert.True(t, queues[0].Durable)
+	assert.Equal(t, gorabbit.QueueTypeQuorum, queues[0].Type)
+	assert.Equal(t, 5, queues[0].DeliveryLimit)
+	assert.Equal(t, map[string]interface{}{"x-message-ttl": 60000}, queues[0].Args)
+	assert.Equal(t, []gorabbit.BindingConfig{{Exchange: "events_exchange", RoutingKey: "event.#"}}, queues[0].Bindings)
+}
+
+func TestLoadTopology_MissingFile(t *testing.T) {
+	_, _, err := gorabbit.LoadTopology(filepath.Join(t.TempDir(), "missing.yaml"))
+
+	assert.Error(t, err)
+}
+
+func TestLoadTopologyTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topology.yaml")
+
+	content := `
+exchanges:
+  - name: "{{.ServiceName}}.events"
+    type: topic
+    persisted: true
+queues:
+  - name: "{{.ServiceName}}.{{ env "TOPOLOGY_TENANT" }}.events"
+    durable: {{ env "TOPOLOGY_DURABLE" | default "true" }}
+    bindings:
+      - exchange: "{{.ServiceName}}.events"
+        routing_key: event.#
+`
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	t.Setenv("TOPOLOGY_TENANT", "acme")
+
+	exchanges, queues, err := gorabbit.LoadTopologyTemplate(path, map[string]interface{}{"ServiceName": "billing"})
+	require.NoError(t, err)
+
+	require.Len(t, exchanges, 1)
+	assert.Equal(t, "billing.events", exchanges[0].Name)
+
+	require.Len(t, queues, 1)
+	assert.Equal(t, "billing.acme.events", queues[0].Name)
+	assert.True(t, queues[0].Durable)
+	assert.Equal(t, "billing.events", queues[0].Bindings[0].Exchange)
+
+	_, _, err = gorabbit.LoadTopologyTemplate(path, nil)
+	assert.Error(t, err)
+}
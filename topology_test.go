@@ -0,0 +1,69 @@
+package gorabbit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestClient_ConsumerTopology(t *testing.T) {
+	server := newFakeServer(t)
+
+	newConsumingClient(t, server, gorabbit.MessageConsumer{
+		QueueConfig: &gorabbit.QueueConfig{
+			Durable: true,
+			Bindings: []gorabbit.BindingConfig{
+				{Exchange: "events", RoutingKey: "event.created"},
+				{Exchange: "events", RoutingKey: "event.deleted"},
+			},
+		},
+		DeadLetterExchange: &gorabbit.ExchangeConfig{Name: "events.dlx", Type: gorabbit.ExchangeTypeFanout, Persisted: true},
+	})
+
+	// The dead letter exchange is declared before the queue pointing to it, then the queue is bound.
+	declarations := server.declarations()
+	require.Len(t, declarations, 4)
+
+	assert.Equal(t, fakeDeclaration{Kind: "exchange", Name: "events.dlx", RoutingKey: "fanout"}, declarations[0])
+	assert.Equal(t, "queue", declarations[1].Kind)
+	assert.Equal(t, "events", declarations[1].Name)
+	assert.Equal(t, "events.dlx", declarations[1].Arguments["x-dead-letter-exchange"])
+	assert.Equal(t, fakeDeclaration{Kind: "binding", Name: "events", Exchange: "events", RoutingKey: "event.created"}, declarations[2])
+	assert.Equal(t, fakeDeclaration{Kind: "binding", Name: "events", Exchange: "events", RoutingKey: "event.deleted"}, declarations[3])
+
+	// The topology is declared again with the new channel.
+	server.closeChannel("events")
+
+	require.Eventually(t, func() bool { return server.consumed("events") }, time.Second, 10*time.Millisecond)
+	assert.Len(t, server.declarations(), 8)
+}
+
+func TestClient_ConsumerTopology_Invalid(t *testing.T) {
+	server := newFakeServer(t)
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().SetHost("127.0.0.1").SetPort(server.port()))
+
+	defer client.Disconnect()
+
+	handlers := gorabbit.MQTTMessageHandlers{"event.created": func([]byte) error { return nil }}
+
+	err := client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue:       "events",
+		Name:        "events",
+		Handlers:    handlers,
+		QueueConfig: &gorabbit.QueueConfig{Name: "orders"},
+	})
+	assert.Error(t, err)
+
+	err = client.RegisterConsumer(gorabbit.MessageConsumer{
+		Queue:              "events",
+		Name:               "events",
+		Handlers:           handlers,
+		DeadLetterExchange: &gorabbit.ExchangeConfig{Type: gorabbit.ExchangeTypeFanout},
+	})
+	assert.Error(t, err)
+}
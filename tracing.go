@@ -0,0 +1,127 @@
+package gorabbit
+
+import (
+	"context"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the instrumentation scope of the spans.
+const tracerName = "github.com/KardinalAI/gorabbit"
+
+// defaultExchangeName is the name the broker gives to the default exchange, used to name the spans publishing to it.
+const defaultExchangeName = "amq.default"
+
+// Attributes of the consumer spans that have no semantic convention.
+const (
+	consumerAttribute = "messaging.gorabbit.consumer"
+	handlerAttribute  = "messaging.gorabbit.handler"
+)
+
+// headersCarrier carries a span context in the headers of a message.
+type headersCarrier amqp.Table
+
+// Get returns the value of a header, or an empty string if it is not a string.
+func (h headersCarrier) Get(key string) string {
+	value, _ := h[key].(string)
+
+	return value
+}
+
+// Set sets the value of a header.
+func (h headersCarrier) Set(key, value string) {
+	h[key] = value
+}
+
+// Keys returns the names of the headers.
+func (h headersCarrier) Keys() []string {
+	keys := make([]string, 0, len(h))
+
+	for key := range h {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// newTracer returns the tracer of a TracerProvider, or nil if tracing is disabled.
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		return nil
+	}
+
+	return provider.Tracer(tracerName)
+}
+
+// startPublishSpan starts the producer span of a publishing and injects its context into the publishing's headers, so
+// that consumers continue the trace. Without a tracer, the returned span does nothing.
+func (c *amqpChannel) startPublishSpan(ctx context.Context, exchange, routingKey string, publishing *amqp.Publishing) trace.Span {
+	if c.tracer == nil {
+		return trace.SpanFromContext(context.Background())
+	}
+
+	destination := exchange
+	if destination == "" {
+		destination = defaultExchangeName
+	}
+
+	ctx, span := c.tracer.Start(ctx, fmt.Sprintf("%s publish", destination),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(destination),
+			semconv.MessagingRabbitmqDestinationRoutingKey(routingKey),
+			semconv.MessagingMessageID(publishing.MessageId),
+			semconv.MessagingMessageBodySize(len(publishing.Body)),
+		),
+	)
+
+	otel.GetTextMapPropagator().Inject(ctx, headersCarrier(publishing.Headers))
+
+	return span
+}
+
+// startDeliverySpan starts the consumer span of a delivery as a child of the span context found in its headers, and
+// returns the context passed to the handler. Without a tracer, the context is returned unchanged.
+func (c *amqpChannel) startDeliverySpan(ctx context.Context, delivery *amqp.Delivery, handlerKey string) (context.Context, trace.Span) {
+	if c.tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+
+	if delivery.Headers != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, headersCarrier(delivery.Headers))
+	}
+
+	queue := c.queueName()
+
+	return c.tracer.Start(ctx, fmt.Sprintf("%s deliver", queue),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemRabbitmq,
+			semconv.MessagingOperationDeliver,
+			semconv.MessagingDestinationName(queue),
+			semconv.MessagingRabbitmqDestinationRoutingKey(delivery.RoutingKey),
+			semconv.MessagingMessageID(delivery.MessageId),
+			semconv.MessagingMessageBodySize(len(delivery.Body)),
+			attribute.String(consumerAttribute, c.consumer.Name),
+			attribute.String(handlerAttribute, handlerKey),
+		),
+	)
+}
+
+// endSpan ends a span, recording the error if any.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
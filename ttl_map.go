@@ -1,79 +1,273 @@
 package gorabbit
 
 import (
+	"container/list"
+	"context"
+	"hash/maphash"
+	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type ttlMapValue[V any] struct {
+	key       string
 	value     V
 	createdAt time.Time
+
+	// seq orders the items of all the shards by insertion.
+	seq uint64
+}
+
+// ttlMapShard holds a part of the items of a ttlMap, guarded by its own lock, in insertion order.
+type ttlMapShard[V any] struct {
+	items map[string]*list.Element
+	order *list.List
+	l     sync.Mutex
 }
 
-type ttlMap[K comparable, V any] struct {
-	m map[K]ttlMapValue[V]
-	l sync.Mutex
+// ttlMap is a cache whose items expire after a time to live. The items are spread over shards, each with its own lock,
+// so that concurrent writers rarely wait for each other. Once the map is full, its oldest item is evicted.
+type ttlMap[V any] struct {
+	shards   []*ttlMapShard[V]
+	seed     maphash.Seed
+	capacity int64
+	seq      atomic.Uint64
+
+	// size counts the items, along with the slots reserved by the items being added.
+	size atomic.Int64
+
+	// onEvict is called, if set, with the items evicted because the map is full, or expired.
+	onEvict func(value V, expired bool)
 }
 
-func newTTLMap[K comparable, V any](ln uint64, maxTTL time.Duration) *ttlMap[K, V] {
-	m := &ttlMap[K, V]{m: make(map[K]ttlMapValue[V], ln)}
+// newTTLMap instantiates a new ttlMap holding ln items at most, unlimited if 0, for maxTTL at most, forever if 0. The
+// expired items are removed until the context is done.
+func newTTLMap[V any](ctx context.Context, ln uint64, maxTTL time.Duration, onEvict func(value V, expired bool)) *ttlMap[V] {
+	shards := uint64(ttlMapShards)
+	if ln > 0 && ln < shards {
+		shards = ln
+	}
 
-	go func() {
-		const tickFraction = 3
+	m := &ttlMap[V]{
+		shards:   make([]*ttlMapShard[V], shards),
+		seed:     maphash.MakeSeed(),
+		capacity: int64(ln),
+		onEvict:  onEvict,
+	}
 
-		for now := range time.Tick(maxTTL / tickFraction) {
-			m.l.Lock()
-			for k := range m.m {
-				issueDate := m.m[k].createdAt
-				if now.Sub(issueDate) >= maxTTL {
-					delete(m.m, k)
+	for i := range m.shards {
+		m.shards[i] = &ttlMapShard[V]{items: make(map[string]*list.Element), order: list.New()}
+	}
+
+	if maxTTL > 0 {
+		go m.expire(ctx, maxTTL)
+	}
+
+	return m
+}
+
+// expire removes the expired items until the context is done.
+func (m *ttlMap[V]) expire(ctx context.Context, maxTTL time.Duration) {
+	const tickFraction = 3
+
+	ticker := time.NewTicker(maxTTL / tickFraction)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, shard := range m.shards {
+				var expired []V
+
+				shard.l.Lock()
+				for front := shard.order.Front(); front != nil; front = shard.order.Front() {
+					if now.Sub(front.Value.(*ttlMapValue[V]).createdAt) < maxTTL {
+						break
+					}
+
+					expired = append(expired, m.remove(shard, front))
 				}
+				shard.l.Unlock()
+
+				m.evicted(expired, true)
 			}
-			m.l.Unlock()
 		}
-	}()
+	}
+}
 
-	return m
+func (m *ttlMap[V]) Len() int {
+	return int(m.size.Load())
 }
 
-func (m *ttlMap[K, V]) Len() int {
-	return len(m.m)
+// Put adds the item, unless its key is already present. Returns true if an older item was evicted to make room for it.
+func (m *ttlMap[V]) Put(k string, v V) bool {
+	shard := m.shard(k)
+
+	shard.l.Lock()
+	_, found := shard.items[k]
+	shard.l.Unlock()
+
+	if found {
+		return false
+	}
+
+	// The slot of the item is reserved before its shard is locked, since making room locks the other shards.
+	evicted := m.reserve()
+
+	shard.l.Lock()
+
+	if _, found = shard.items[k]; found {
+		// The key was added in the meantime, so the slot is released.
+		m.size.Add(-1)
+	} else {
+		item := &ttlMapValue[V]{key: k, value: v, createdAt: time.Now(), seq: m.seq.Add(1)}
+
+		shard.items[k] = shard.order.PushBack(item)
+	}
+
+	shard.l.Unlock()
+
+	m.evicted(evicted, false)
+
+	return len(evicted) > 0
 }
 
-func (m *ttlMap[K, V]) Put(k K, v V) {
-	m.l.Lock()
+// reserve reserves the slot of an item, evicting the oldest items until one is free, and returns the evicted values.
+func (m *ttlMap[V]) reserve() []V {
+	var evicted []V
+
+	for {
+		size := m.size.Load()
+
+		if m.capacity == 0 || size < m.capacity {
+			if m.size.CompareAndSwap(size, size+1) {
+				return evicted
+			}
+
+			continue
+		}
 
-	defer m.l.Unlock()
+		if value, ok := m.evictOldest(); ok {
+			evicted = append(evicted, value)
 
-	if _, ok := m.m[k]; !ok {
-		m.m[k] = ttlMapValue[V]{value: v, createdAt: time.Now()}
+			continue
+		}
+
+		// The slots are reserved by items still being added, which can be evicted once added.
+		runtime.Gosched()
 	}
 }
 
-func (m *ttlMap[K, V]) Get(k K) (V, bool) {
-	m.l.Lock()
+// evictOldest removes the oldest item of the map, and returns its value, or false if the map holds no item or the
+// oldest one was removed in the meantime.
+func (m *ttlMap[V]) evictOldest() (V, bool) {
+	var (
+		oldest      *list.Element
+		oldestShard *ttlMapShard[V]
+		zero        V
+	)
+
+	for _, shard := range m.shards {
+		shard.l.Lock()
+
+		if front := shard.order.Front(); front != nil {
+			if oldest == nil || front.Value.(*ttlMapValue[V]).seq < oldest.Value.(*ttlMapValue[V]).seq {
+				oldest, oldestShard = front, shard
+			}
+		}
+
+		shard.l.Unlock()
+	}
 
-	defer m.l.Unlock()
+	if oldest == nil {
+		return zero, false
+	}
 
-	v, found := m.m[k]
+	oldestShard.l.Lock()
+	defer oldestShard.l.Unlock()
 
-	innerVal := v.value
+	if oldestShard.order.Front() != oldest {
+		return zero, false
+	}
 
-	return innerVal, found
+	return m.remove(oldestShard, oldest), true
 }
 
-func (m *ttlMap[K, V]) ForEach(process func(k K, v V)) {
-	for key, value := range m.m {
-		innerVal := value.value
+func (m *ttlMap[V]) Get(k string) (V, bool) {
+	shard := m.shard(k)
 
-		process(key, innerVal)
+	shard.l.Lock()
+
+	defer shard.l.Unlock()
+
+	element, found := shard.items[k]
+	if !found {
+		var zero V
+
+		return zero, false
 	}
+
+	return element.Value.(*ttlMapValue[V]).value, true
 }
 
-func (m *ttlMap[K, V]) Delete(k K) {
-	m.l.Lock()
+// ForEach calls process with each item, oldest first within a shard. The items are read shard by shard, so that
+// process can update the map.
+func (m *ttlMap[V]) ForEach(process func(k string, v V)) {
+	for _, shard := range m.shards {
+		shard.l.Lock()
 
-	defer m.l.Unlock()
+		items := make([]*ttlMapValue[V], 0, shard.order.Len())
 
-	delete(m.m, k)
+		for element := shard.order.Front(); element != nil; element = element.Next() {
+			items = append(items, element.Value.(*ttlMapValue[V]))
+		}
+
+		shard.l.Unlock()
+
+		for _, item := range items {
+			process(item.key, item.value)
+		}
+	}
+}
+
+func (m *ttlMap[V]) Delete(k string) {
+	shard := m.shard(k)
+
+	shard.l.Lock()
+
+	defer shard.l.Unlock()
+
+	if element, ok := shard.items[k]; ok {
+		m.remove(shard, element)
+	}
+}
+
+// shard returns the shard holding the given key.
+func (m *ttlMap[V]) shard(k string) *ttlMapShard[V] {
+	return m.shards[maphash.String(m.seed, k)%uint64(len(m.shards))]
+}
+
+// remove removes the item of the shard, which must be locked, and returns its value.
+func (m *ttlMap[V]) remove(shard *ttlMapShard[V], element *list.Element) V {
+	item := shard.order.Remove(element).(*ttlMapValue[V])
+
+	delete(shard.items, item.key)
+
+	m.size.Add(-1)
+
+	return item.value
+}
+
+// evicted passes the evicted values to onEvict, if set, once their shard is unlocked.
+func (m *ttlMap[V]) evicted(values []V, expired bool) {
+	if m.onEvict == nil {
+		return
+	}
+
+	for _, value := range values {
+		m.onEvict(value, expired)
+	}
 }
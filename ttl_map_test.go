@@ -0,0 +1,111 @@
+package gorabbit_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+type publishRecorder struct {
+	outcomes []gorabbit.PublishOutcome
+	mutex    sync.Mutex
+}
+
+func (r *publishRecorder) ObserveDelivery(gorabbit.DeliveryMetrics) {}
+
+func (r *publishRecorder) ObservePublish(metrics gorabbit.PublishMetrics) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.outcomes = append(r.outcomes, metrics.Outcome)
+}
+
+func (r *publishRecorder) count(outcome gorabbit.PublishOutcome) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	count := 0
+
+	for _, o := range r.outcomes {
+		if o == outcome {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestClient_PublishingCache_Evicted(t *testing.T) {
+	recorder := &publishRecorder{}
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetPublishingCacheSize(1).
+		SetMetrics(recorder))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// Nothing listens on the port, so the publishings are cached, the newest replacing the oldest.
+	err := client.Publish("events_exchange", "event.created", "payload")
+	require.ErrorIs(t, err, gorabbit.ErrNotConnected)
+	assert.NotErrorIs(t, err, gorabbit.ErrCacheFull)
+
+	for i := 0; i < 2; i++ {
+		err = client.Publish("events_exchange", "event.created", "payload")
+		require.ErrorIs(t, err, gorabbit.ErrCacheFull)
+		assert.ErrorIs(t, err, gorabbit.ErrNotConnected)
+	}
+
+	assert.Equal(t, 3, recorder.count(gorabbit.PublishOutcomeCached))
+	assert.Equal(t, 2, recorder.count(gorabbit.PublishOutcomeEvicted))
+}
+
+func TestClient_PublishingCache_Full(t *testing.T) {
+	recorder := &publishRecorder{}
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetPublishingCacheSize(128).
+		SetMetrics(recorder))
+
+	defer func() { _ = client.Disconnect() }()
+
+	// The cache holds exactly its size, no matter how the publishings spread over its shards.
+	for i := 0; i < 128; i++ {
+		err := client.Publish("events_exchange", "event.created", "payload-"+strconv.Itoa(i))
+		require.ErrorIs(t, err, gorabbit.ErrNotConnected)
+		require.NotErrorIs(t, err, gorabbit.ErrCacheFull, "publishing %d", i)
+	}
+
+	assert.Equal(t, 0, recorder.count(gorabbit.PublishOutcomeEvicted))
+
+	err := client.Publish("events_exchange", "event.created", "payload-128")
+	assert.ErrorIs(t, err, gorabbit.ErrCacheFull)
+	assert.Equal(t, 1, recorder.count(gorabbit.PublishOutcomeEvicted))
+}
+
+func TestClient_PublishingCache_Expired(t *testing.T) {
+	recorder := &publishRecorder{}
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions().
+		SetPort(1).
+		SetRetryDelay(time.Hour).
+		SetPublishingCacheTTL(30 * time.Millisecond).
+		SetMetrics(recorder))
+
+	defer func() { _ = client.Disconnect() }()
+
+	require.Error(t, client.Publish("events_exchange", "event.created", "payload"))
+
+	assert.Eventually(t, func() bool {
+		return recorder.count(gorabbit.PublishOutcomeExpired) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,225 @@
+package gorabbit
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// URIParameters are the query parameters of a connection URI, as defined by RabbitMQ, such as
+// amqps://host/vhost?heartbeat=30&connection_timeout=5000&cacertfile=/etc/ssl/ca.pem.
+type URIParameters struct {
+	// Heartbeat is the heartbeat interval negotiated with the server, the "heartbeat" parameter in seconds. Defaults
+	// to 10 seconds.
+	Heartbeat time.Duration `yaml:"heartbeat"`
+
+	// ConnectionTimeout is the timeout of the TCP connection and of the handshakes, the "connection_timeout" parameter
+	// in milliseconds. Defaults to 30 seconds.
+	ConnectionTimeout time.Duration `yaml:"connection_timeout"`
+
+	// ChannelMax is the maximum number of channels of the connection, the "channel_max" parameter. Defaults to the
+	// maximum of the server.
+	ChannelMax int `yaml:"channel_max"`
+
+	// CACertFile is the path to the PEM certificate of the authority verifying the server, the "cacertfile" parameter.
+	// Defaults to the system certificates.
+	CACertFile string `yaml:"cacertfile"`
+
+	// CertFile is the path to the PEM certificate of the client, the "certfile" parameter, used along with KeyFile for
+	// mutual TLS.
+	CertFile string `yaml:"certfile"`
+
+	// KeyFile is the path to the PEM private key of the client, the "keyfile" parameter.
+	KeyFile string `yaml:"keyfile"`
+
+	// ServerName is the name of the server verified by TLS, the "server_name_indication" parameter. Defaults to the
+	// host.
+	ServerName string `yaml:"server_name_indication"`
+}
+
+// ConnectionSettings are the settings of a connection to a RabbitMQ server.
+type ConnectionSettings struct {
+	// Host is the RabbitMQ server host name.
+	Host string `yaml:"host"`
+
+	// Port is the RabbitMQ server port number, omitted from the URI if 0.
+	Port uint `yaml:"port"`
+
+	// Username is the RabbitMQ server allowed username.
+	Username string `yaml:"username"`
+
+	// Password is the RabbitMQ server allowed password.
+	Password string `yaml:"password"`
+
+	// Vhost is the virtual host, the server's default one if empty.
+	Vhost string `yaml:"vhost"`
+
+	// UseTLS defines whether we use amqp or amqps protocol.
+	UseTLS bool `yaml:"use_tls"`
+
+	// URIParameters are the query parameters of the URI.
+	URIParameters `yaml:",inline"`
+}
+
+// URI returns the amqp:// or amqps:// URI of the connection, with the credentials and the vhost escaped so that they
+// can contain any character, such as '@' or '/'.
+func (s ConnectionSettings) URI() string {
+	uri := url.URL{
+		Scheme: defaultProtocol,
+		User:   url.UserPassword(s.Username, s.Password),
+		Host:   s.Host,
+		Path:   "/" + s.Vhost,
+	}
+
+	if s.UseTLS {
+		uri.Scheme = securedProtocol
+	}
+
+	if s.Port > 0 {
+		uri.Host = net.JoinHostPort(s.Host, strconv.FormatUint(uint64(s.Port), 10))
+	}
+
+	if s.Vhost != "" {
+		uri.RawPath = "/" + url.PathEscape(s.Vhost)
+	}
+
+	uri.RawQuery = s.URIParameters.query().Encode()
+
+	return uri.String()
+}
+
+// query returns the query parameters of the URI, omitting the unset ones.
+func (p URIParameters) query() url.Values {
+	query := url.Values{}
+
+	if p.Heartbeat > 0 {
+		query.Set("heartbeat", strconv.FormatInt(int64(p.Heartbeat/time.Second), 10))
+	}
+
+	if p.ConnectionTimeout > 0 {
+		query.Set("connection_timeout", strconv.FormatInt(p.ConnectionTimeout.Milliseconds(), 10))
+	}
+
+	if p.ChannelMax > 0 {
+		query.Set("channel_max", strconv.Itoa(p.ChannelMax))
+	}
+
+	for key, value := range map[string]string{
+		"cacertfile":             p.CACertFile,
+		"certfile":               p.CertFile,
+		"keyfile":                p.KeyFile,
+		"server_name_indication": p.ServerName,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	return query
+}
+
+// parseURIParameters returns the URIParameters of the query of a URI. The unknown parameters are ignored, so that the
+// URIs of other clients can be used as is.
+func parseURIParameters(query url.Values) (URIParameters, error) {
+	params := URIParameters{
+		CACertFile: query.Get("cacertfile"),
+		CertFile:   query.Get("certfile"),
+		KeyFile:    query.Get("keyfile"),
+		ServerName: query.Get("server_name_indication"),
+	}
+
+	var err error
+
+	if params.Heartbeat, err = parseDurationParameter(query, "heartbeat", time.Second); err != nil {
+		return params, err
+	}
+
+	if params.ConnectionTimeout, err = parseDurationParameter(query, "connection_timeout", time.Millisecond); err != nil {
+		return params, err
+	}
+
+	if query.Has("channel_max") {
+		value, err := strconv.ParseUint(query.Get("channel_max"), 10, 16)
+		if err != nil {
+			return params, fmt.Errorf("invalid channel_max parameter: %w", err)
+		}
+
+		params.ChannelMax = int(value)
+	}
+
+	return params, nil
+}
+
+// parseDurationParameter returns the duration of the query parameter, a number of units, or 0 if not set.
+func parseDurationParameter(query url.Values, key string, unit time.Duration) (time.Duration, error) {
+	if !query.Has(key) {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseUint(query.Get(key), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s parameter: %w", key, err)
+	}
+
+	return time.Duration(value) * unit, nil
+}
+
+// dial opens a connection to the server of the URI, honoring its query parameters.
+func dial(uri string) (*amqp.Connection, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := parseURIParameters(parsed.Query())
+	if err != nil {
+		return nil, err
+	}
+
+	config := amqp.Config{
+		Heartbeat:  defaultHeartbeat,
+		ChannelMax: params.ChannelMax,
+		Locale:     defaultLocale,
+		Dial:       amqp.DefaultDial(defaultConnectionTimeout),
+	}
+
+	if params.Heartbeat > 0 {
+		config.Heartbeat = params.Heartbeat
+	}
+
+	if params.ConnectionTimeout > 0 {
+		config.Dial = amqp.DefaultDial(params.ConnectionTimeout)
+	}
+
+	// The TLS parameters are read from the URI by amqp.DialConfig.
+	return amqp.DialConfig(uri, config)
+}
+
+// ToURI returns the amqp:// or amqps:// URI of the connection settings read from the environment variables.
+func (e RabbitMQEnvs) ToURI() string {
+	return ConnectionSettings{
+		Host:     e.Host,
+		Port:     e.Port,
+		Username: e.Username,
+		Password: e.Password,
+		Vhost:    e.Vhost,
+		UseTLS:   e.UseTLS,
+	}.URI()
+}
+
+// ConnectionSettings returns the settings of the connection of the ClientOptions.
+func (c *ClientOptions) ConnectionSettings() ConnectionSettings {
+	return ConnectionSettings{
+		Host:          c.Host,
+		Port:          c.Port,
+		Username:      c.Username,
+		Password:      c.Password,
+		Vhost:         c.Vhost,
+		UseTLS:        c.UseTLS,
+		URIParameters: c.URIParameters,
+	}
+}
@@ -8,7 +8,10 @@ import (
 
 // Error Utils.
 const (
-	codeNotFound = 404
+	codeAccessRefused      = 403
+	codeNotFound           = 404
+	codeResourceLocked     = 405
+	codePreconditionFailed = 406
 )
 
 // isErrorNotFound checks if the error returned by a connection or channel has the 404 code.
@@ -23,3 +26,42 @@ func isErrorNotFound(err error) bool {
 
 	return amqpError.Code == codeNotFound
 }
+
+// isErrorAccessRefused checks if the error returned by a connection or channel has the 403 code.
+func isErrorAccessRefused(err error) bool {
+	var amqpError *amqp.Error
+
+	errors.As(err, &amqpError)
+
+	if amqpError == nil {
+		return false
+	}
+
+	return amqpError.Code == codeAccessRefused
+}
+
+// isErrorResourceLocked checks if the error returned by a connection or channel has the 405 code.
+func isErrorResourceLocked(err error) bool {
+	var amqpError *amqp.Error
+
+	errors.As(err, &amqpError)
+
+	if amqpError == nil {
+		return false
+	}
+
+	return amqpError.Code == codeResourceLocked
+}
+
+// isErrorPreconditionFailed checks if the error returned by a connection or channel has the 406 code.
+func isErrorPreconditionFailed(err error) bool {
+	var amqpError *amqp.Error
+
+	errors.As(err, &amqpError)
+
+	if amqpError == nil {
+		return false
+	}
+
+	return amqpError.Code == codePreconditionFailed
+}
@@ -0,0 +1,226 @@
+package gorabbit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// TopologyProblem is the problem found with an exchange or queue by a topology verification.
+type TopologyProblem string
+
+const (
+	// TopologyMissing flags an exchange or queue that does not exist.
+	TopologyMissing TopologyProblem = "missing"
+
+	// TopologyMismatched flags an exchange or queue that exists with different properties or arguments.
+	TopologyMismatched TopologyProblem = "mismatched"
+)
+
+func (p TopologyProblem) String() string {
+	return string(p)
+}
+
+// TopologyIssue describes an exchange or queue that does not match the expected topology.
+type TopologyIssue struct {
+	// Kind is either "exchange" or "queue".
+	Kind string
+
+	// Name is the name of the exchange or queue.
+	Name string
+
+	// Problem is the problem found.
+	Problem TopologyProblem
+
+	// Detail is the error returned by the broker.
+	Detail string
+}
+
+// TopologyReport is the result of a topology verification.
+type TopologyReport struct {
+	// Issues holds the exchanges and queues that are missing or mismatched.
+	Issues []TopologyIssue
+}
+
+// Valid returns true if the verified topology exists as expected.
+func (r TopologyReport) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// Err returns an error describing the issues, or nil if the verified topology exists as expected.
+func (r TopologyReport) Err() error {
+	if r.Valid() {
+		return nil
+	}
+
+	errs := make([]error, 0, len(r.Issues))
+
+	for _, issue := range r.Issues {
+		errs = append(errs, fmt.Errorf("%s '%s' is %s: %s", issue.Kind, issue.Name, issue.Problem, issue.Detail))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Kinds of verified topology.
+const (
+	topologyKindExchange = "exchange"
+	topologyKindQueue    = "queue"
+)
+
+// topologyCheck is the verification of an exchange or queue.
+type topologyCheck struct {
+	kind string
+	name string
+
+	// passive verifies that the exchange or queue exists.
+	passive func(channel *amqp.Channel) error
+
+	// declare verifies, if set, that the existing exchange or queue has the expected properties, by declaring it again.
+	declare func(channel *amqp.Channel) error
+}
+
+// exchangeCheck returns the verification of an exchange.
+func exchangeCheck(config ExchangeConfig) topologyCheck {
+	return topologyCheck{
+		kind: topologyKindExchange,
+		name: config.Name,
+		passive: func(channel *amqp.Channel) error {
+			return channel.ExchangeDeclarePassive(config.Name, config.Type.String(), config.Persisted, !config.Persisted, false, false, nil)
+		},
+		declare: func(channel *amqp.Channel) error {
+			args, err := config.arguments()
+			if err != nil {
+				return err
+			}
+
+			return channel.ExchangeDeclare(config.Name, config.Type.String(), config.Persisted, !config.Persisted, false, false, args)
+		},
+	}
+}
+
+// queueCheck returns the verification of a queue, only verifying its existence if config is nil.
+func queueCheck(name string, config *QueueConfig) topologyCheck {
+	check := topologyCheck{
+		kind: topologyKindQueue,
+		name: name,
+		passive: func(channel *amqp.Channel) error {
+			_, err := channel.QueueDeclarePassive(name, false, false, false, false, nil)
+
+			return err
+		},
+	}
+
+	if config != nil {
+		check.declare = func(channel *amqp.Channel) error {
+			args, err := config.arguments()
+			if err != nil {
+				return err
+			}
+
+			_, err = channel.QueueDeclare(name, config.Durable, false, config.Exclusive, false, args)
+
+			return err
+		}
+	}
+
+	return check
+}
+
+// verifyTopology runs the given checks without creating anything. Each check uses its own channel, as the broker
+// closes the channel on the first failure. Properties are verified by declaring again the exchanges and queues that
+// exist, which the broker rejects if they differ.
+func verifyTopology(ctx context.Context, connection *amqp.Connection, checks []topologyCheck) (TopologyReport, error) {
+	var report TopologyReport
+
+	if connection == nil || connection.IsClosed() {
+		return report, errConnectionClosed
+	}
+
+	for _, check := range checks {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		err := runOnNewChannel(connection, check.passive)
+
+		switch {
+		case isErrorNotFound(err):
+			report.Issues = append(report.Issues, TopologyIssue{Kind: check.kind, Name: check.name, Problem: TopologyMissing, Detail: err.Error()})
+
+			continue
+		case isErrorResourceLocked(err):
+			// An exclusive queue of another connection exists but cannot be inspected.
+			continue
+		case err != nil:
+			return report, err
+		}
+
+		if check.declare == nil {
+			continue
+		}
+
+		err = runOnNewChannel(connection, check.declare)
+
+		switch {
+		case isErrorPreconditionFailed(err):
+			report.Issues = append(report.Issues, TopologyIssue{Kind: check.kind, Name: check.name, Problem: TopologyMismatched, Detail: err.Error()})
+		case err != nil:
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// runOnNewChannel runs an operation on a new channel, closed afterwards, so that a failure closing the channel does not
+// affect the other channels.
+func runOnNewChannel(connection *amqp.Connection, operation func(channel *amqp.Channel) error) error {
+	channel, err := connection.Channel()
+	if err != nil {
+		return err
+	}
+
+	err = operation(channel)
+
+	// The channel is already closed by the broker if the operation failed.
+	_ = channel.Close()
+
+	return err
+}
+
+// consumersTopologyChecks returns the verification of the topology used by the registered consumers: their queues,
+// with their properties if they carry a QueueConfig, and their dead letter exchange.
+func (a *amqpConnection) consumersTopologyChecks() []topologyCheck {
+	var checks []topologyCheck
+
+	for _, parent := range a.channelList() {
+		if parent.consumer == nil {
+			continue
+		}
+
+		if dlx := parent.consumer.DeadLetterExchange; dlx != nil {
+			checks = append(checks, exchangeCheck(*dlx))
+		}
+
+		for _, channel := range parent.consumerChannels() {
+			// Server-named queues are declared by the consumer itself.
+			if channel.serverNamedQueue {
+				continue
+			}
+
+			var config *QueueConfig
+
+			if channel.consumer.QueueConfig != nil {
+				queueConfig := channel.consumer.queueConfig()
+				config = &queueConfig
+			}
+
+			checks = append(checks, queueCheck(channel.queueName(), config))
+		}
+	}
+
+	return checks
+}
@@ -0,0 +1,39 @@
+package gorabbit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KardinalAI/gorabbit"
+)
+
+func TestTopologyReport_Err(t *testing.T) {
+	report := gorabbit.TopologyReport{}
+
+	assert.True(t, report.Valid())
+	assert.NoError(t, report.Err())
+
+	report.Issues = []gorabbit.TopologyIssue{
+		{Kind: "queue", Name: "events_queue", Problem: gorabbit.TopologyMissing, Detail: "NOT_FOUND"},
+		{Kind: "exchange", Name: "events_exchange", Problem: gorabbit.TopologyMismatched, Detail: "PRECONDITION_FAILED"},
+	}
+
+	assert.False(t, report.Valid())
+	require.Error(t, report.Err())
+	assert.Contains(t, report.Err().Error(), "queue 'events_queue' is missing: NOT_FOUND")
+	assert.Contains(t, report.Err().Error(), "exchange 'events_exchange' is mismatched: PRECONDITION_FAILED")
+}
+
+func TestMQTTClient_VerifyTopology_Disabled(t *testing.T) {
+	t.Setenv("GORABBIT_DISABLED", "true")
+
+	client := gorabbit.NewClient(gorabbit.NewClientOptions())
+
+	report, err := client.VerifyTopology(context.Background())
+	require.NoError(t, err)
+
+	assert.True(t, report.Valid())
+}